@@ -189,8 +189,40 @@ Use "ethereum dump 0" to dump the genesis block.`,
 			utils.TendermintTimeoutPrecommitFlag,
 			utils.TendermintTimeoutPrecommitDeltaFlag,
 			utils.TendermintTimeoutCommitFlag,
+			utils.TendermintTargetBlockIntervalFlag,
+			utils.TendermintProposerPolicyFlag,
+			utils.TendermintEpochFlag,
+			utils.TendermintProposeGossipFlag,
+			utils.TendermintPrevoteGossipFlag,
+			utils.TendermintPrecommitGossipFlag,
+			utils.TendermintGossipFanoutFlag,
+			utils.TendermintProposalAckTimeoutFlag,
+			utils.TendermintRecentMessagesCacheFlag,
+			utils.TendermintKnownMessagesCacheFlag,
+			utils.TendermintGasTargetFlag,
+			utils.TendermintProposalGasFlag,
+			utils.TendermintProposalTimeFlag,
+			utils.TendermintEvidenceMaxAgeBlocksFlag,
+			utils.TendermintEvidenceMaxAgeFlag,
+			utils.TendermintMaxTimestampDriftFlag,
+			utils.TendermintSpeculativeRoundsFlag,
 			utils.TendermintFaultyModeFlag,
 			utils.TendermintSCUseEVMCallerFlag,
+			utils.TendermintObserverFlag,
+			utils.TendermintObserversFlag,
+			utils.TendermintNoConsensusProtocolFlag,
+			utils.TendermintProtobufMessagesFlag,
+			utils.TendermintValidatorOnlyFlag,
+			utils.TendermintSentriesFlag,
+			utils.TendermintDevValidatorsFlag,
+			utils.TendermintHealthAddrFlag,
+			utils.TendermintMetricsAddrFlag,
+			utils.TendermintTracingEndpointFlag,
+			utils.TendermintEventTraceFlag,
+			utils.TendermintVoteBatchDelayFlag,
+			utils.TendermintHeartbeatIntervalFlag,
+			utils.TendermintHeartbeatExtensionFlag,
+			utils.TendermintStartPausedFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 	}
@@ -65,6 +65,7 @@ var (
 		utils.BootnodesFlag,
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
+		utils.ValidatorNodesFlag,
 		utils.DataDirFlag,
 		utils.AncientFlag,
 		utils.KeyStoreDirFlag,
@@ -126,6 +127,8 @@ var (
 		utils.MinerLegacyExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerPriorityGasFlag,
+		utils.MinerPriorityContractsFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -158,7 +161,42 @@ var (
 		utils.TendermintTimeoutPrecommitFlag,
 		utils.TendermintTimeoutPrecommitDeltaFlag,
 		utils.TendermintTimeoutCommitFlag,
+		utils.TendermintTargetBlockIntervalFlag,
+		utils.TendermintProposerPolicyFlag,
+		utils.TendermintEpochFlag,
+		utils.TendermintProposeGossipFlag,
+		utils.TendermintPrevoteGossipFlag,
+		utils.TendermintPrecommitGossipFlag,
+		utils.TendermintGossipFanoutFlag,
+		utils.TendermintProposalAckTimeoutFlag,
+		utils.TendermintRecentMessagesCacheFlag,
+		utils.TendermintKnownMessagesCacheFlag,
+		utils.TendermintGasTargetFlag,
+		utils.TendermintProposalGasFlag,
+		utils.TendermintProposalTimeFlag,
+		utils.TendermintEvidenceMaxAgeBlocksFlag,
+		utils.TendermintEvidenceMaxAgeFlag,
+		utils.TendermintMaxTimestampDriftFlag,
+		utils.TendermintSpeculativeRoundsFlag,
 		utils.TendermintSCUseEVMCallerFlag,
+		utils.TendermintObserverFlag,
+		utils.TendermintObserversFlag,
+		utils.TendermintNoConsensusProtocolFlag,
+		utils.TendermintProtobufMessagesFlag,
+		utils.TendermintValidatorOnlyFlag,
+		utils.TendermintSentriesFlag,
+		utils.TendermintDevValidatorsFlag,
+		utils.TendermintHealthAddrFlag,
+		utils.TendermintMetricsAddrFlag,
+		utils.TendermintTracingEndpointFlag,
+		utils.TendermintEventTraceFlag,
+		utils.TendermintVoteBatchDelayFlag,
+		utils.TendermintHeartbeatIntervalFlag,
+		utils.TendermintHeartbeatExtensionFlag,
+		utils.TendermintStartPausedFlag,
+		utils.TendermintValidatorKeyFlag,
+		utils.TendermintNextValidatorKeyFlag,
+		utils.TendermintValidatorPasswordFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -222,6 +260,8 @@ func init() {
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
+		// See validatorcmd.go:
+		validatorCommand,
 		// See consolecmd.go:
 		consoleCommand,
 		attachCommand,
@@ -231,6 +271,9 @@ func init() {
 		makedagCommand,
 		versionCommand,
 		licenseCommand,
+		genesisExtraCommand,
+		// See tendermintcmd.go:
+		tendermintCommand,
 		// See config.go
 		dumpConfigCommand,
 		// See retesteth.go
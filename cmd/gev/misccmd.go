@@ -17,6 +17,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"runtime"
@@ -24,7 +25,10 @@ import (
 	"strings"
 
 	"github.com/Evrynetlabs/evrynet-node/cmd/utils"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	tendermintUtils "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/evr"
 	"github.com/Evrynetlabs/evrynet-node/params"
 	"github.com/urfave/cli"
@@ -74,6 +78,27 @@ The output of this command is supposed to be machine-readable.
 		ArgsUsage: " ",
 		Category:  "MISCELLANEOUS COMMANDS",
 	}
+	genesisExtraCommand = cli.Command{
+		Action:    utils.MigrateFlags(genesisExtra),
+		Name:      "genesisextra",
+		Usage:     "Generate the genesis extra-data of tendermint validators",
+		ArgsUsage: "<address> [<address>...]",
+		Flags: []cli.Flag{
+			fixedValidatorsFlag,
+		},
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+The genesisextra command RLP encodes the addresses of the validators into the
+extra-data of a tendermint genesis block. It prints a genesis.json fragment with
+the extraData and the difficulty, and with the fixed validators of the tendermint
+config if --fixed is set, to merge into the genesis file before running init.
+`,
+	}
+
+	fixedValidatorsFlag = cli.BoolFlag{
+		Name:  "fixed",
+		Usage: "Add the validators as the fixed validators of the tendermint config",
+	}
 )
 
 // makecache generates an ethash verification cache into the provided folder.
@@ -106,6 +131,42 @@ func makedag(ctx *cli.Context) error {
 	return nil
 }
 
+// genesisExtra prints the genesis.json fragment of the validators given as arguments.
+func genesisExtra(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) == 0 {
+		utils.Fatalf(`Usage: gev genesisextra [--fixed] <address> [<address>...]`)
+	}
+	validators := make([]common.Address, 0, len(args))
+	for _, arg := range args {
+		if !common.IsHexAddress(arg) {
+			utils.Fatalf("Invalid validator address: %s", arg)
+		}
+		validators = append(validators, common.HexToAddress(arg))
+	}
+	extra, err := tendermintUtils.GenesisExtra(validators)
+	if err != nil {
+		utils.Fatalf("Failed to encode the extra-data: %v", err)
+	}
+	fragment := map[string]interface{}{
+		"difficulty": "0x1",
+		"extraData":  hexutil.Bytes(extra),
+	}
+	if ctx.Bool(fixedValidatorsFlag.Name) {
+		fragment["config"] = map[string]interface{}{
+			"tendermint": map[string]interface{}{
+				"fixedValidators": validators,
+			},
+		}
+	}
+	out, err := json.MarshalIndent(fragment, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode the genesis fragment: %v", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
 func version(ctx *cli.Context) error {
 	fmt.Println(strings.Title(clientIdentifier))
 	fmt.Println("Version:", params.VersionWithMeta)
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/cmd/utils"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/evr"
+	"github.com/Evrynetlabs/evrynet-node/evr/downloader"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/node"
+	"github.com/urfave/cli"
+)
+
+const (
+	benchTxsBatchSize  = 256  // number of transactions added to a pool at once
+	benchMaxPendingTxs = 8192 // the flood pauses while a pool has more pending transactions
+	benchPeersTimeout  = 30 * time.Second
+)
+
+var (
+	benchDurationFlag = cli.DurationFlag{
+		Name:  "duration",
+		Usage: "How long the network is measured once all the validators are connected",
+		Value: time.Minute,
+	}
+	benchAccountsFlag = cli.IntFlag{
+		Name:  "accounts",
+		Usage: "Number of funded accounts sending the synthetic transactions",
+		Value: 128,
+	}
+	benchGasLimitFlag = cli.Uint64Flag{
+		Name:  "gaslimit",
+		Usage: "Gas limit of the blocks",
+		Value: 25000000,
+	}
+)
+
+// stepHooker is implemented by the engines which notify the round/step transitions of the state machine
+type stepHooker interface {
+	AddStepHook(hook tendermintCore.StepHook) func()
+}
+
+// benchStats collects the step transitions of the validators of a benchmark network
+type benchStats struct {
+	mu        sync.Mutex
+	lastStep  map[int]time.Time                                // when each validator entered its current step
+	latencies map[tendermintCore.RoundStepType][]time.Duration // time spent in each step
+	rounds    map[uint64]int64                                 // the highest round of each height of the first validator
+}
+
+func newBenchStats() *benchStats {
+	return &benchStats{
+		lastStep:  make(map[int]time.Time),
+		latencies: make(map[tendermintCore.RoundStepType][]time.Duration),
+		rounds:    make(map[uint64]int64),
+	}
+}
+
+// hook returns the step hook of the validator at index
+func (s *benchStats) hook(index int) tendermintCore.StepHook {
+	return func(t tendermintCore.StepTransition) {
+		now := time.Now()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if last, ok := s.lastStep[index]; ok {
+			s.latencies[t.PrevStep] = append(s.latencies[t.PrevStep], now.Sub(last))
+		}
+		s.lastStep[index] = now
+		if index == 0 && t.BlockNumber != nil {
+			if number := t.BlockNumber.Uint64(); t.Round > s.rounds[number] {
+				s.rounds[number] = t.Round
+			}
+		}
+	}
+}
+
+// roundsPerHeight returns the average and the maximum number of rounds of the heights from first to last
+func (s *benchStats) roundsPerHeight(first, last uint64) (float64, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last < first {
+		return 0, 0
+	}
+	var total, max int64
+	for number := first; number <= last; number++ {
+		rounds := s.rounds[number] + 1
+		total += rounds
+		if rounds > max {
+			max = rounds
+		}
+	}
+	return float64(total) / float64(last-first+1), max
+}
+
+// percentile returns the p-th percentile of the sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(p*float64(len(sorted))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+// benchTendermint runs an in-process network of validators flooded with transactions and reports its throughput
+func benchTendermint(ctx *cli.Context) error {
+	var (
+		count    = ctx.Int(testnetValidatorsFlag.Name)
+		duration = ctx.Duration(benchDurationFlag.Name)
+		gasLimit = ctx.Uint64(benchGasLimitFlag.Name)
+	)
+	if count <= 0 {
+		utils.Fatalf("The number of validators must be positive")
+	}
+	if ctx.Uint64(testnetEpochFlag.Name) == 0 {
+		utils.Fatalf("The epoch must be positive")
+	}
+	if duration <= 0 {
+		utils.Fatalf("The duration must be positive")
+	}
+
+	keys := make([]*ecdsa.PrivateKey, count)
+	validators := make([]common.Address, count)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			utils.Fatalf("Failed to generate the validator key: %v", err)
+		}
+		keys[i], validators[i] = key, crypto.PubkeyToAddress(key.PublicKey)
+	}
+	faucets := make([]*ecdsa.PrivateKey, ctx.Int(benchAccountsFlag.Name))
+	if len(faucets) == 0 {
+		utils.Fatalf("The number of accounts must be positive")
+	}
+	genesis, err := testnetGenesis(ctx, validators)
+	if err != nil {
+		utils.Fatalf("Failed to create the genesis: %v", err)
+	}
+	genesis.GasLimit = gasLimit
+	for i := range faucets {
+		if faucets[i], err = crypto.GenerateKey(); err != nil {
+			utils.Fatalf("Failed to generate the account key: %v", err)
+		}
+		genesis.Alloc[crypto.PubkeyToAddress(faucets[i].PublicKey)] = core.GenesisAccount{
+			Balance: new(big.Int).Exp(big.NewInt(2), big.NewInt(128), nil),
+		}
+	}
+
+	datadir, err := ioutil.TempDir("", "gev-bench")
+	if err != nil {
+		utils.Fatalf("Failed to create the data directory: %v", err)
+	}
+	defer os.RemoveAll(datadir)
+
+	// start the validators and connect each of them to the previous ones
+	var (
+		stacks   = make([]*node.Node, count)
+		services = make([]*evr.Evrynet, count)
+		stats    = newBenchStats()
+	)
+	for i, key := range keys {
+		stack, service := makeBenchNode(filepath.Join(datadir, fmt.Sprintf("node%d", i+1)), key, genesis)
+		defer stack.Close()
+		for _, previous := range stacks[:i] {
+			stack.Server().AddPeer(previous.Server().Self())
+		}
+		hooker, ok := service.Engine().(stepHooker)
+		if !ok {
+			utils.Fatalf("The consensus engine does not notify its steps")
+		}
+		defer hooker.AddStepHook(stats.hook(i))()
+		stacks[i], services[i] = stack, service
+	}
+	deadline := time.Now().Add(benchPeersTimeout)
+	for _, stack := range stacks {
+		for stack.Server().PeerCount() < count-1 {
+			if time.Now().After(deadline) {
+				utils.Fatalf("The validators failed to connect to each other")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	for _, service := range services {
+		if err := service.StartMining(1); err != nil {
+			utils.Fatalf("Failed to start the validator: %v", err)
+		}
+	}
+
+	chain := services[0].BlockChain()
+	startBlock, start := chain.CurrentBlock().NumberU64(), time.Now()
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		floodTransactions(services, faucets, genesis.Config.ChainID, genesis.Config.GasPrice, quit)
+	}()
+	log.Info("Benchmarking the network", "validators", count, "duration", duration)
+	time.Sleep(duration)
+	close(quit)
+	wg.Wait()
+	endBlock, elapsed := chain.CurrentBlock().NumberU64(), time.Since(start)
+
+	var blocks, txs uint64
+	for number := startBlock + 1; number <= endBlock; number++ {
+		if block := chain.GetBlockByNumber(number); block != nil {
+			blocks++
+			txs += uint64(len(block.Transactions()))
+		}
+	}
+	avgRounds, maxRounds := stats.roundsPerHeight(startBlock+1, endBlock)
+
+	fmt.Printf("Validators:        %d\n", count)
+	fmt.Printf("Duration:          %v\n", common.PrettyDuration(elapsed))
+	fmt.Printf("Blocks:            %d (%.2f blocks/s)\n", blocks, float64(blocks)/elapsed.Seconds())
+	fmt.Printf("Transactions:      %d (%.2f tx/s)\n", txs, float64(txs)/elapsed.Seconds())
+	fmt.Printf("Rounds per height: %.2f average, %d max\n", avgRounds, maxRounds)
+	fmt.Printf("\nStep latencies:\n")
+	fmt.Printf("  %-24s %8s %12s %12s %12s %12s\n", "step", "count", "p50", "p90", "p99", "max")
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	steps := make([]tendermintCore.RoundStepType, 0, len(stats.latencies))
+	for step := range stats.latencies {
+		steps = append(steps, step)
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i] < steps[j] })
+	for _, step := range steps {
+		latencies := stats.latencies[step]
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		fmt.Printf("  %-24s %8d %12v %12v %12v %12v\n", step, len(latencies),
+			common.PrettyDuration(percentile(latencies, 0.5)), common.PrettyDuration(percentile(latencies, 0.9)),
+			common.PrettyDuration(percentile(latencies, 0.99)), common.PrettyDuration(latencies[len(latencies)-1]))
+	}
+	return nil
+}
+
+// makeBenchNode starts a validator of the benchmark network with key as its node key
+func makeBenchNode(datadir string, key *ecdsa.PrivateKey, genesis *core.Genesis) (*node.Node, *evr.Evrynet) {
+	nodeCfg := defaultNodeConfig()
+	nodeCfg.DataDir = datadir
+	nodeCfg.IPCPath = ""
+	nodeCfg.NoUSB = true
+	nodeCfg.P2P.ListenAddr = "127.0.0.1:0"
+	nodeCfg.P2P.NoDiscovery = true
+	nodeCfg.P2P.PrivateKey = key
+	stack, err := node.New(&nodeCfg)
+	if err != nil {
+		utils.Fatalf("Failed to create the node: %v", err)
+	}
+
+	cfg := evr.DefaultConfig
+	cfg.Genesis = genesis
+	cfg.NetworkId = genesis.Config.ChainID.Uint64()
+	cfg.SyncMode = downloader.FullSync
+	cfg.Miner.Etherbase = crypto.PubkeyToAddress(key.PublicKey)
+	cfg.Miner.GasFloor = genesis.GasLimit
+	cfg.Miner.GasCeil = genesis.GasLimit
+	cfg.TxPool.Journal = ""
+	cfg.TxPool.AccountSlots = benchTxsBatchSize
+	cfg.TxPool.GlobalSlots = 4 * benchMaxPendingTxs
+	utils.RegisterEvrService(stack, &cfg)
+	if err := stack.Start(); err != nil {
+		utils.Fatalf("Failed to start the node: %v", err)
+	}
+	var service *evr.Evrynet
+	if err := stack.Service(&service); err != nil {
+		utils.Fatalf("Evrynet service not running: %v", err)
+	}
+	return stack, service
+}
+
+// floodTransactions keeps the pools of the validators full of self transfers from the faucets until quit is closed.
+// The transactions of a faucet are always added to the same validator so that its nonces never leave gaps in a pool.
+func floodTransactions(services []*evr.Evrynet, faucets []*ecdsa.PrivateKey, chainID, gasPrice *big.Int, quit chan struct{}) {
+	var (
+		signer = types.NewEIP155Signer(chainID)
+		nonces = make([]uint64, len(faucets))
+	)
+	for {
+		for i, service := range services {
+			select {
+			case <-quit:
+				return
+			default:
+			}
+			if pending, _ := service.TxPool().Stats(); pending > benchMaxPendingTxs {
+				continue
+			}
+			var (
+				txs     types.Transactions
+				senders []int
+			)
+			for j := i; j < len(faucets) && len(txs) < benchTxsBatchSize; j += len(services) {
+				faucet := faucets[j]
+				tx, err := types.SignTx(types.NewTransaction(nonces[j], crypto.PubkeyToAddress(faucet.PublicKey), new(big.Int), 21000, gasPrice, nil), signer, faucet)
+				if err != nil {
+					log.Error("Failed to sign the transaction", "err", err)
+					return
+				}
+				nonces[j]++
+				txs, senders = append(txs, tx), append(senders, j)
+			}
+			for k, err := range service.TxPool().AddLocals(txs) {
+				if err != nil {
+					// resend from the rejected nonce so that the next ones are not stuck behind a gap
+					log.Warn("Failed to add the transaction", "err", err)
+					if nonce := txs[k].Nonce(); nonce < nonces[senders[k]] {
+						nonces[senders[k]] = nonce
+					}
+				}
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/cmd/utils"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/node"
+	"github.com/Evrynetlabs/evrynet-node/p2p/enode"
+	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/urfave/cli"
+)
+
+var (
+	testnetValidatorsFlag = cli.IntFlag{
+		Name:  "validators",
+		Usage: "Number of validator nodes of the local network",
+		Value: 4,
+	}
+	testnetOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Directory the genesis and the data directories of the nodes are written into",
+		Value: "testnet",
+	}
+	testnetNetworkIdFlag = cli.Uint64Flag{
+		Name:  "networkid",
+		Usage: "Chain id and network id of the local network",
+		Value: 15,
+	}
+	testnetEpochFlag = cli.Uint64Flag{
+		Name:  "epoch",
+		Usage: "Number of blocks of an epoch",
+		Value: 1024,
+	}
+	testnetPeriodFlag = cli.Uint64Flag{
+		Name:  "period",
+		Usage: "Minimum number of seconds between two blocks",
+		Value: 1,
+	}
+	testnetPortFlag = cli.IntFlag{
+		Name:  "port",
+		Usage: "Listening port of the first node, the next nodes listen on the following ports",
+		Value: 30301,
+	}
+	testnetRPCPortFlag = cli.IntFlag{
+		Name:  "rpcport",
+		Usage: "HTTP-RPC port of the first node, the next nodes listen on the following ports",
+		Value: 22001,
+	}
+
+	tendermintCommand = cli.Command{
+		Name:     "tendermint",
+		Usage:    "Tendermint network tooling",
+		Category: "MISCELLANEOUS COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:   "init-testnet",
+				Usage:  "Generate the configuration of a local multi-validator network",
+				Action: utils.MigrateFlags(initTestnet),
+				Flags: []cli.Flag{
+					testnetValidatorsFlag,
+					testnetOutputFlag,
+					testnetNetworkIdFlag,
+					testnetEpochFlag,
+					testnetPeriodFlag,
+					testnetPortFlag,
+					testnetRPCPortFlag,
+				},
+				Description: `
+    gev tendermint init-testnet --validators 4 --output testnet
+
+Generates a local tendermint network of fixed validators in one shot:
+the node key of each validator, a genesis.json with the validators in its
+extra-data and funds for each of them, and the data directory of each node
+(<output>/node1, <output>/node2, ...) initialised with the genesis and
+listing the other nodes as static nodes. It prints the command starting each node.`,
+			},
+			{
+				Name:   "init-compose",
+				Usage:  "Generate a docker-compose localnet of validators and full nodes",
+				Action: utils.MigrateFlags(initCompose),
+				Flags: []cli.Flag{
+					testnetValidatorsFlag,
+					composeFullnodesFlag,
+					composeOutputFlag,
+					composeImageFlag,
+					testnetNetworkIdFlag,
+					testnetEpochFlag,
+					testnetPeriodFlag,
+					testnetPortFlag,
+					testnetRPCPortFlag,
+				},
+				Description: `
+    gev tendermint init-compose --validators 4 --fullnodes 1 --output localnet
+
+Generates a reproducible docker-compose network: a docker-compose.yml running a
+bootnode, the validators and the full nodes on a bridge network with fixed
+addresses, the node key and the initialised data directory of each node, the
+bootnode key and a genesis.json with the validators in its extra-data. The nodes
+find each other through the bootnode and list each other as static nodes. The p2p
+and HTTP-RPC ports of the nodes are mapped to consecutive host ports.`,
+			},
+			{
+				Name:   "bench",
+				Usage:  "Measure the consensus throughput of an in-process multi-validator network",
+				Action: utils.MigrateFlags(benchTendermint),
+				Flags: []cli.Flag{
+					testnetValidatorsFlag,
+					testnetNetworkIdFlag,
+					testnetEpochFlag,
+					testnetPeriodFlag,
+					benchDurationFlag,
+					benchAccountsFlag,
+					benchGasLimitFlag,
+				},
+				Description: `
+    gev tendermint bench --validators 4 --duration 1m
+
+Starts a network of fixed validators in this process, connected over the
+loopback interface, and floods their transaction pools with self transfers from
+funded accounts. Once the duration has elapsed, it reports the blocks and the
+transactions per second, the rounds per height and the distribution of the time
+the validators spend in each step of the state machine, so that the performance
+of the consensus can be compared between versions.`,
+			},
+		},
+	}
+)
+
+// testnetNode is a validator node of a generated local network
+type testnetNode struct {
+	datadir string
+	port    int
+	rpcPort int
+	address common.Address
+	enode   *enode.Node
+}
+
+// initTestnet generates the keys, the genesis and the data directories of a local network
+func initTestnet(ctx *cli.Context) error {
+	var (
+		count  = ctx.Int(testnetValidatorsFlag.Name)
+		output = ctx.String(testnetOutputFlag.Name)
+	)
+	if count <= 0 {
+		utils.Fatalf("The number of validators must be positive")
+	}
+	if ctx.Uint64(testnetEpochFlag.Name) == 0 {
+		utils.Fatalf("The epoch must be positive")
+	}
+	if common.FileExist(output) {
+		utils.Fatalf("Output directory %s already exists", output)
+	}
+
+	nodes := make([]*testnetNode, count)
+	validators := make([]common.Address, count)
+	for i := range nodes {
+		n, err := newTestnetNode(filepath.Join(output, fmt.Sprintf("node%d", i+1)), net.ParseIP("127.0.0.1"), ctx.Int(testnetPortFlag.Name)+i)
+		if err != nil {
+			utils.Fatalf("Failed to create the node: %v", err)
+		}
+		n.rpcPort = ctx.Int(testnetRPCPortFlag.Name) + i
+		nodes[i], validators[i] = n, n.address
+	}
+
+	genesis, err := testnetGenesis(ctx, validators)
+	if err != nil {
+		utils.Fatalf("Failed to create the genesis: %v", err)
+	}
+	genesisPath := filepath.Join(output, "genesis.json")
+	if err := writeTestnetGenesis(genesis, genesisPath); err != nil {
+		utils.Fatalf("Failed to write the genesis: %v", err)
+	}
+
+	for _, n := range nodes {
+		if err := initTestnetNode(n, nodes, genesis); err != nil {
+			utils.Fatalf("Failed to initialise %s: %v", n.datadir, err)
+		}
+	}
+
+	fmt.Printf("Generated a local network of %d validators with the genesis %s\n\n", count, genesisPath)
+	for i, n := range nodes {
+		fmt.Printf("Node %d: validator %s\n", i+1, n.address.Hex())
+		fmt.Printf("  gev --datadir %s --networkid %d --port %d --nodiscover --syncmode full --mine --rpc --rpcport %d --rpcapi eth,net,web3,tendermint\n",
+			n.datadir, ctx.Uint64(testnetNetworkIdFlag.Name), n.port, n.rpcPort)
+	}
+	return nil
+}
+
+// newTestnetNode generates the node key of a node listening on ip and port and writes it into its data directory
+func newTestnetNode(datadir string, ip net.IP, port int) (*testnetNode, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	n := &testnetNode{
+		datadir: datadir,
+		port:    port,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+		enode:   enode.NewV4(&key.PublicKey, ip, port, port),
+	}
+	if err := os.MkdirAll(filepath.Join(datadir, clientIdentifier), 0700); err != nil {
+		return nil, err
+	}
+	if err := crypto.SaveECDSA(filepath.Join(datadir, clientIdentifier, "nodekey"), key); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// testnetGenesis returns the genesis of a local network of fixed validators, which are funded
+func testnetGenesis(ctx *cli.Context, validators []common.Address) (*core.Genesis, error) {
+	genesis := &core.Genesis{
+		Timestamp:  uint64(time.Now().Unix()),
+		GasLimit:   4700000,
+		Difficulty: big.NewInt(1),
+		Validators: validators,
+		Alloc:      make(core.GenesisAlloc),
+		Config: &params.ChainConfig{
+			ChainID:             new(big.Int).SetUint64(ctx.Uint64(testnetNetworkIdFlag.Name)),
+			GasPrice:            big.NewInt(params.GasPriceConfig),
+			HomesteadBlock:      big.NewInt(0),
+			EIP150Block:         big.NewInt(0),
+			EIP155Block:         big.NewInt(0),
+			EIP158Block:         big.NewInt(0),
+			ByzantiumBlock:      big.NewInt(0),
+			ConstantinopleBlock: big.NewInt(0),
+			Tendermint: &params.TendermintConfig{
+				Epoch:                 ctx.Uint64(testnetEpochFlag.Name),
+				BlockReward:           big.NewInt(5e+18),
+				FixedValidators:       validators,
+				BlockPeriod:           ctx.Uint64(testnetPeriodFlag.Name),
+				DomainSeparationBlock: big.NewInt(0),
+			},
+		},
+	}
+	for _, validator := range validators {
+		genesis.Alloc[validator] = core.GenesisAccount{
+			Balance: new(big.Int).Lsh(big.NewInt(1), 256-7), // 2^256 / 128 (allow many pre-funds without balance overflows)
+		}
+	}
+	return genesis, nil
+}
+
+// writeTestnetGenesis writes the genesis into path in JSON
+func writeTestnetGenesis(genesis *core.Genesis, path string) error {
+	genesisJSON, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, genesisJSON, 0644)
+}
+
+// initTestnetNode writes the static nodes of the node and the genesis into its databases
+func initTestnetNode(n *testnetNode, nodes []*testnetNode, genesis *core.Genesis) error {
+	cfg := defaultNodeConfig()
+	cfg.DataDir = n.datadir
+	cfg.NoUSB = true
+	stack, err := node.New(&cfg)
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+
+	var static []string
+	for _, other := range nodes {
+		if other != n {
+			static = append(static, other.enode.URLv4())
+		}
+	}
+	staticJSON, err := json.MarshalIndent(static, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(stack.ResolvePath("static-nodes.json"), staticJSON, 0644); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"chaindata", "lightchaindata"} {
+		chaindb, err := stack.OpenDatabase(name, 0, 0, "")
+		if err != nil {
+			return err
+		}
+		_, _, err = core.SetupGenesisBlock(chaindb, genesis)
+		chaindb.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
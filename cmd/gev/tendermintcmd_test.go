@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/core"
+)
+
+func TestInitTestnet(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+	output := filepath.Join(datadir, "testnet")
+
+	geth := runGeth(t, "tendermint", "init-testnet", "--validators", "3", "--output", output, "--epoch", "40")
+	geth.ExpectRegexp(`Generated a local network of 3 validators`)
+	geth.WaitExit()
+	if status := geth.ExitStatus(); status != 0 {
+		t.Fatalf("init-testnet failed with status %d", status)
+	}
+
+	genesisJSON, err := ioutil.ReadFile(filepath.Join(output, "genesis.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(genesisJSON, genesis); err != nil {
+		t.Fatal(err)
+	}
+	if validators := genesis.Config.Tendermint.FixedValidators; len(validators) != 3 {
+		t.Fatalf("validators mismatch: have %d, want 3", len(validators))
+	}
+	if epoch := genesis.Config.Tendermint.Epoch; epoch != 40 {
+		t.Fatalf("epoch mismatch: have %d, want 40", epoch)
+	}
+	for _, node := range []string{"node1", "node2", "node3"} {
+		var static []string
+		staticJSON, err := ioutil.ReadFile(filepath.Join(output, node, "geth", "static-nodes.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(staticJSON, &static); err != nil {
+			t.Fatal(err)
+		}
+		if len(static) != 2 {
+			t.Fatalf("%s: static nodes mismatch: have %d, want 2", node, len(static))
+		}
+		for _, file := range []string{"nodekey", "chaindata"} {
+			if _, err := os.Stat(filepath.Join(output, node, "geth", file)); err != nil {
+				t.Fatalf("%s: %v", node, err)
+			}
+		}
+	}
+}
+
+func TestInitCompose(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+	output := filepath.Join(datadir, "localnet")
+
+	geth := runGeth(t, "tendermint", "init-compose", "--validators", "2", "--fullnodes", "1", "--output", output)
+	geth.ExpectRegexp(`Generated a localnet of 2 validators and 1 full nodes`)
+	geth.WaitExit()
+	if status := geth.ExitStatus(); status != 0 {
+		t.Fatalf("init-compose failed with status %d", status)
+	}
+
+	compose, err := ioutil.ReadFile(filepath.Join(output, "docker-compose.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"bootnode:", "validator1:", "validator2:", "fullnode1:", `"--mine"`, "22003:8545/tcp", "ipv4_address: 172.28.0.12"} {
+		if !strings.Contains(string(compose), want) {
+			t.Errorf("docker-compose file misses %q", want)
+		}
+	}
+	if strings.Count(string(compose), `"--mine"`) != 2 {
+		t.Errorf("only the validators should mine")
+	}
+	genesisJSON, err := ioutil.ReadFile(filepath.Join(output, "genesis.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(genesisJSON, genesis); err != nil {
+		t.Fatal(err)
+	}
+	if validators := genesis.Config.Tendermint.FixedValidators; len(validators) != 2 {
+		t.Fatalf("validators mismatch: have %d, want 2", len(validators))
+	}
+	for _, file := range []string{"bootnode/bootnode.key", "fullnode1/geth/nodekey", "fullnode1/geth/chaindata", "validator1/geth/static-nodes.json"} {
+		if _, err := os.Stat(filepath.Join(output, file)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBenchStats(t *testing.T) {
+	stats := newBenchStats()
+	for _, transition := range []tendermintCore.StepTransition{
+		{BlockNumber: big.NewInt(1), Round: 0, Step: tendermintCore.RoundStepPropose, PrevStep: tendermintCore.RoundStepNewRound},
+		{BlockNumber: big.NewInt(1), Round: 0, Step: tendermintCore.RoundStepPrevote, PrevStep: tendermintCore.RoundStepPropose},
+		{BlockNumber: big.NewInt(2), Round: 2, Step: tendermintCore.RoundStepNewRound, PrevStep: tendermintCore.RoundStepPrevote},
+	} {
+		stats.hook(0)(transition)
+		stats.hook(1)(transition)
+	}
+	// the first transition of each validator has no previous step time
+	if have := len(stats.latencies[tendermintCore.RoundStepPropose]); have != 2 {
+		t.Errorf("propose latencies mismatch: have %d, want 2", have)
+	}
+	if have := len(stats.latencies[tendermintCore.RoundStepPrevote]); have != 2 {
+		t.Errorf("prevote latencies mismatch: have %d, want 2", have)
+	}
+	if have := len(stats.latencies[tendermintCore.RoundStepNewRound]); have != 0 {
+		t.Errorf("new round latencies mismatch: have %d, want 0", have)
+	}
+	if avg, max := stats.roundsPerHeight(1, 2); avg != 2 || max != 3 {
+		t.Errorf("rounds per height mismatch: have %v average %d max, want 2 average 3 max", avg, max)
+	}
+
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for p, want := range map[float64]time.Duration{0.5: 5, 0.9: 9, 0.99: 10, 0: 1} {
+		if have := percentile(sorted, p); have != want {
+			t.Errorf("percentile %v mismatch: have %v, want %v", p, have, want)
+		}
+	}
+}
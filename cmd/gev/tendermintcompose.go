@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/Evrynetlabs/evrynet-node/cmd/utils"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/p2p/enode"
+	"github.com/urfave/cli"
+)
+
+const (
+	// composeSubnet is the subnet of the docker network of the generated localnet, the nodes have fixed addresses in it
+	composeSubnet = "172.28.0.0/16"
+	// composeP2PPort and composeRPCPort are the ports the nodes listen on inside their containers
+	composeP2PPort = 30303
+	composeRPCPort = 8545
+	// composeBootnodePort is the discovery port of the bootnode, it is mapped to the same host port
+	composeBootnodePort = 30300
+)
+
+var (
+	composeFullnodesFlag = cli.IntFlag{
+		Name:  "fullnodes",
+		Usage: "Number of non-validator full nodes of the localnet",
+		Value: 1,
+	}
+	composeOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Directory the docker-compose file, the genesis and the data directories of the nodes are written into",
+		Value: "localnet",
+	}
+	composeImageFlag = cli.StringFlag{
+		Name:  "image",
+		Usage: "Docker image of the nodes, built from the Dockerfile at the root of the repository",
+		Value: "gev",
+	}
+)
+
+// composeNode is a service of the generated docker-compose file
+type composeNode struct {
+	Name      string
+	IP        string
+	Datadir   string // relative to the docker-compose file
+	Port      int    // host port mapped to the p2p port
+	RPCPort   int    // host port mapped to the HTTP-RPC port
+	Validator bool
+}
+
+// composeConfig is the data of the docker-compose template
+type composeConfig struct {
+	Image        string
+	Subnet       string
+	NetworkID    uint64
+	Bootnode     string // enode URL of the bootnode
+	BootnodeIP   string
+	BootnodePort int
+	P2PPort      int
+	RPCPort      int
+	Nodes        []*composeNode
+}
+
+var composeTemplate = template.Must(template.New("").Parse(`version: "3"
+services:
+  bootnode:
+    image: {{.Image}}
+    container_name: bootnode
+    entrypoint: ["bootnode"]
+    command: ["-nodekey", "/bootnode/bootnode.key", "-addr", ":{{.BootnodePort}}"]
+    volumes:
+      - ./bootnode:/bootnode
+    ports:
+      - {{.BootnodePort}}:{{.BootnodePort}}/udp
+    networks:
+      localnet:
+        ipv4_address: {{.BootnodeIP}}
+{{range .Nodes}}
+  {{.Name}}:
+    image: {{$.Image}}
+    container_name: {{.Name}}
+    depends_on:
+      - bootnode
+    command: ["--datadir", "/data", "--networkid", "{{$.NetworkID}}", "--syncmode", "full",
+      "--bootnodes", "{{$.Bootnode}}", "--nat", "extip:{{.IP}}", "--port", "{{$.P2PPort}}",
+      "--rpc", "--rpcaddr", "0.0.0.0", "--rpcport", "{{$.RPCPort}}", "--rpcvhosts", "*",
+      "--rpcapi", "eth,net,web3,txpool,tendermint"{{if .Validator}}, "--mine"{{end}}]
+    volumes:
+      - {{.Datadir}}:/data
+    ports:
+      - {{.Port}}:{{$.P2PPort}}/tcp
+      - {{.Port}}:{{$.P2PPort}}/udp
+      - {{.RPCPort}}:{{$.RPCPort}}/tcp
+    networks:
+      localnet:
+        ipv4_address: {{.IP}}
+{{end}}
+networks:
+  localnet:
+    driver: bridge
+    ipam:
+      config:
+        - subnet: {{.Subnet}}
+`))
+
+// initCompose generates the docker-compose file, the keys, the genesis and the data directories of a localnet
+func initCompose(ctx *cli.Context) error {
+	var (
+		validators = ctx.Int(testnetValidatorsFlag.Name)
+		fullnodes  = ctx.Int(composeFullnodesFlag.Name)
+		output     = ctx.String(composeOutputFlag.Name)
+	)
+	if validators <= 0 {
+		utils.Fatalf("The number of validators must be positive")
+	}
+	if fullnodes < 0 {
+		utils.Fatalf("The number of full nodes must not be negative")
+	}
+	if ctx.Uint64(testnetEpochFlag.Name) == 0 {
+		utils.Fatalf("The epoch must be positive")
+	}
+	if common.FileExist(output) {
+		utils.Fatalf("Output directory %s already exists", output)
+	}
+
+	// the bootnode takes the first address of the subnet, then come the validators and the full nodes
+	bootnodeKey, err := crypto.GenerateKey()
+	if err != nil {
+		utils.Fatalf("Failed to generate the bootnode key: %v", err)
+	}
+	bootnodeIP := composeIP(2)
+	if err := os.MkdirAll(filepath.Join(output, "bootnode"), 0700); err != nil {
+		utils.Fatalf("Failed to create the bootnode directory: %v", err)
+	}
+	if err := crypto.SaveECDSA(filepath.Join(output, "bootnode", "bootnode.key"), bootnodeKey); err != nil {
+		utils.Fatalf("Failed to write the bootnode key: %v", err)
+	}
+	cfg := &composeConfig{
+		Image:        ctx.String(composeImageFlag.Name),
+		Subnet:       composeSubnet,
+		NetworkID:    ctx.Uint64(testnetNetworkIdFlag.Name),
+		Bootnode:     enode.NewV4(&bootnodeKey.PublicKey, bootnodeIP, composeBootnodePort, composeBootnodePort).URLv4(),
+		BootnodeIP:   bootnodeIP.String(),
+		BootnodePort: composeBootnodePort,
+		P2PPort:      composeP2PPort,
+		RPCPort:      composeRPCPort,
+	}
+
+	var (
+		nodes     []*testnetNode
+		addresses []common.Address
+	)
+	for i := 0; i < validators+fullnodes; i++ {
+		name := fmt.Sprintf("validator%d", i+1)
+		if i >= validators {
+			name = fmt.Sprintf("fullnode%d", i-validators+1)
+		}
+		ip := composeIP(10 + i)
+		n, err := newTestnetNode(filepath.Join(output, name), ip, composeP2PPort)
+		if err != nil {
+			utils.Fatalf("Failed to create the node: %v", err)
+		}
+		nodes = append(nodes, n)
+		if i < validators {
+			addresses = append(addresses, n.address)
+		}
+		cfg.Nodes = append(cfg.Nodes, &composeNode{
+			Name:      name,
+			IP:        ip.String(),
+			Datadir:   "./" + name,
+			Port:      ctx.Int(testnetPortFlag.Name) + i,
+			RPCPort:   ctx.Int(testnetRPCPortFlag.Name) + i,
+			Validator: i < validators,
+		})
+	}
+
+	genesis, err := testnetGenesis(ctx, addresses)
+	if err != nil {
+		utils.Fatalf("Failed to create the genesis: %v", err)
+	}
+	if err := writeTestnetGenesis(genesis, filepath.Join(output, "genesis.json")); err != nil {
+		utils.Fatalf("Failed to write the genesis: %v", err)
+	}
+	for _, n := range nodes {
+		if err := initTestnetNode(n, nodes, genesis); err != nil {
+			utils.Fatalf("Failed to initialise %s: %v", n.datadir, err)
+		}
+	}
+
+	composePath := filepath.Join(output, "docker-compose.yml")
+	f, err := os.Create(composePath)
+	if err != nil {
+		utils.Fatalf("Failed to create the docker-compose file: %v", err)
+	}
+	defer f.Close()
+	if err := composeTemplate.Execute(f, cfg); err != nil {
+		utils.Fatalf("Failed to write the docker-compose file: %v", err)
+	}
+
+	fmt.Printf("Generated a localnet of %d validators and %d full nodes in %s\n\n", validators, fullnodes, output)
+	for i, n := range cfg.Nodes {
+		fmt.Printf("%-12s %s  p2p %d  rpc http://localhost:%d\n", n.Name, nodes[i].address.Hex(), n.Port, n.RPCPort)
+	}
+	fmt.Printf("\nBuild the image from the repository root with `docker build -t %s .`, then run `docker-compose up` in %s\n", cfg.Image, output)
+	return nil
+}
+
+// composeIP returns the host address of the localnet subnet
+func composeIP(host int) net.IP {
+	ip, _, _ := net.ParseCIDR(composeSubnet)
+	ip = ip.To4()
+	return net.IPv4(ip[0], ip[1], byte(host>>8), byte(host))
+}
@@ -181,6 +181,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.BootnodesFlag,
 			utils.BootnodesV4Flag,
 			utils.BootnodesV5Flag,
+			utils.ValidatorNodesFlag,
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
@@ -205,6 +206,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerfiyFlag,
+			utils.MinerPriorityGasFlag,
+			utils.MinerPriorityContractsFlag,
 		},
 	},
 	{
@@ -257,8 +260,43 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TendermintTimeoutPrecommitFlag,
 			utils.TendermintTimeoutPrecommitDeltaFlag,
 			utils.TendermintTimeoutCommitFlag,
+			utils.TendermintTargetBlockIntervalFlag,
+			utils.TendermintProposerPolicyFlag,
+			utils.TendermintEpochFlag,
+			utils.TendermintProposeGossipFlag,
+			utils.TendermintPrevoteGossipFlag,
+			utils.TendermintPrecommitGossipFlag,
+			utils.TendermintGossipFanoutFlag,
+			utils.TendermintProposalAckTimeoutFlag,
+			utils.TendermintRecentMessagesCacheFlag,
+			utils.TendermintKnownMessagesCacheFlag,
+			utils.TendermintGasTargetFlag,
+			utils.TendermintProposalGasFlag,
+			utils.TendermintProposalTimeFlag,
+			utils.TendermintEvidenceMaxAgeBlocksFlag,
+			utils.TendermintEvidenceMaxAgeFlag,
+			utils.TendermintMaxTimestampDriftFlag,
+			utils.TendermintSpeculativeRoundsFlag,
 			utils.TendermintFaultyModeFlag,
 			utils.TendermintSCUseEVMCallerFlag,
+			utils.TendermintObserverFlag,
+			utils.TendermintObserversFlag,
+			utils.TendermintNoConsensusProtocolFlag,
+			utils.TendermintProtobufMessagesFlag,
+			utils.TendermintValidatorOnlyFlag,
+			utils.TendermintSentriesFlag,
+			utils.TendermintDevValidatorsFlag,
+			utils.TendermintHealthAddrFlag,
+			utils.TendermintMetricsAddrFlag,
+			utils.TendermintTracingEndpointFlag,
+			utils.TendermintEventTraceFlag,
+			utils.TendermintVoteBatchDelayFlag,
+			utils.TendermintHeartbeatIntervalFlag,
+			utils.TendermintHeartbeatExtensionFlag,
+			utils.TendermintStartPausedFlag,
+			utils.TendermintValidatorKeyFlag,
+			utils.TendermintNextValidatorKeyFlag,
+			utils.TendermintValidatorPasswordFlag,
 		},
 	},
 	{
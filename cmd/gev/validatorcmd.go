@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/keystore"
+	"github.com/Evrynetlabs/evrynet-node/cmd/utils"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/node"
+	"github.com/pborman/uuid"
+	"github.com/urfave/cli"
+)
+
+// datadirValidatorKey is the path within the datadir of the encrypted validator key
+const datadirValidatorKey = "validatorkey"
+
+var (
+	validatorKeyFlags = []cli.Flag{
+		utils.DataDirFlag,
+		utils.TendermintValidatorKeyFlag,
+		utils.TendermintValidatorPasswordFlag,
+	}
+
+	validatorCommand = cli.Command{
+		Name:     "validator",
+		Usage:    "Manage the tendermint validator key",
+		Category: "ACCOUNT COMMANDS",
+		Description: `
+
+Manage the key signing the blocks and consensus messages of a tendermint validator,
+independently of the node key identifying the node on the p2p network.
+
+The validator key is stored encrypted in <DATADIR>/geth/validatorkey, or in the file
+set by --tendermint.validator-key. Gev decrypts it at startup, prompting for its
+password or reading it from the file set by --tendermint.validator-password.
+If there is no validator key, the node key signs the consensus messages.
+
+To rotate the validator key, schedule the rotation in the keyRotations of the
+tendermint genesis config and start gev with --tendermint.next-validator-key set
+to the new key file. The node switches to it at the rotation.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "new",
+				Usage:  "Create a new validator key",
+				Action: utils.MigrateFlags(validatorNew),
+				Flags:  append(validatorKeyFlags, utils.LightKDFFlag),
+				Description: `
+    gev validator new
+
+Generates a new validator key, stores it encrypted and prints its address.`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Import a private key as the validator key",
+				ArgsUsage: "<keyFile>",
+				Action:    utils.MigrateFlags(validatorImport),
+				Flags:     append(validatorKeyFlags, utils.LightKDFFlag),
+				Description: `
+    gev validator import <keyfile>
+
+Imports an unencrypted private key from <keyfile> and stores it encrypted as the
+validator key. The keyfile contains the private key in hexadecimal, like a node key.`,
+			},
+			{
+				Name:      "export",
+				Usage:     "Export the validator key unencrypted",
+				ArgsUsage: "<keyFile>",
+				Action:    utils.MigrateFlags(validatorExport),
+				Flags:     validatorKeyFlags,
+				Description: `
+    gev validator export <keyfile>
+
+Decrypts the validator key and writes its private key in hexadecimal into <keyfile>,
+to be imported into another node. The keyfile must not exist.`,
+			},
+			{
+				Name:   "show",
+				Usage:  "Print the address of the validator key",
+				Action: utils.MigrateFlags(validatorShow),
+				Flags:  validatorKeyFlags,
+				Description: `
+    gev validator show
+
+Prints the address of the validator key and the path of its file without decrypting it.`,
+			},
+		},
+	}
+)
+
+// validatorKeyFile returns the path of the validator key file and whether it was configured explicitly
+func validatorKeyFile(stack *node.Node, cfg *tendermint.Config) (string, bool) {
+	if cfg.ValidatorKeyFile != "" {
+		return cfg.ValidatorKeyFile, true
+	}
+	return stack.ResolvePath(datadirValidatorKey), false
+}
+
+// loadValidatorKey decrypts the validator key and the next validator key into the tendermint config.
+// It does nothing if no validator key file was configured nor created in the datadir, the node key is then used.
+func loadValidatorKey(ctx *cli.Context, stack *node.Node, cfg *tendermint.Config) {
+	if cfg.NextValidatorKeyFile != "" {
+		key := decryptValidatorKey(ctx, cfg.NextValidatorKeyFile, 1)
+		cfg.NextValidatorKey = key.PrivateKey
+		log.Info("Loaded next validator key", "address", key.Address, "file", cfg.NextValidatorKeyFile)
+	}
+	file, explicit := validatorKeyFile(stack, cfg)
+	if !explicit && (file == "" || !common.FileExist(file)) {
+		return
+	}
+	key := decryptValidatorKey(ctx, file, 0)
+	cfg.ValidatorKey = key.PrivateKey
+	log.Info("Loaded validator key", "address", key.Address, "file", file)
+}
+
+// decryptValidatorKey decrypts the key file with the password at index of the validator password list
+func decryptValidatorKey(ctx *cli.Context, file string, index int) *keystore.Key {
+	keyjson, err := ioutil.ReadFile(file)
+	if err != nil {
+		utils.Fatalf("Failed to read the validator key: %v", err)
+	}
+	password := getPassPhrase(fmt.Sprintf("Unlocking validator key %s", file), false, index, utils.MakeValidatorPasswordList(ctx))
+	key, err := keystore.DecryptKey(keyjson, password)
+	if err != nil {
+		utils.Fatalf("Failed to decrypt the validator key: %v", err)
+	}
+	return key
+}
+
+// configuredValidatorKeyFile returns the path of the validator key file of the command
+func configuredValidatorKeyFile(ctx *cli.Context) (string, *node.Node) {
+	stack, cfg := makeConfigNode(ctx)
+	file, _ := validatorKeyFile(stack, &cfg.Eth.Tendermint)
+	if file == "" {
+		utils.Fatalf("No validator key file, set --%s or --%s", utils.DataDirFlag.Name, utils.TendermintValidatorKeyFlag.Name)
+	}
+	return file, stack
+}
+
+// storeValidatorKey encrypts the key into the validator key file, which must not exist
+func storeValidatorKey(ctx *cli.Context, privateKey *ecdsa.PrivateKey) {
+	file, stack := configuredValidatorKeyFile(ctx)
+	if common.FileExist(file) {
+		utils.Fatalf("Validator key file %s already exists", file)
+	}
+	scryptN, scryptP, _, err := stack.Config().AccountConfig()
+	if err != nil {
+		utils.Fatalf("Failed to read configuration: %v", err)
+	}
+	password := getPassPhrase("Your validator key is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakeValidatorPasswordList(ctx))
+
+	key := &keystore.Key{
+		Id:         uuid.NewRandom(),
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}
+	keyjson, err := keystore.EncryptKey(key, password, scryptN, scryptP)
+	if err != nil {
+		utils.Fatalf("Failed to encrypt the validator key: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		utils.Fatalf("Failed to create the validator key directory: %v", err)
+	}
+	if err := ioutil.WriteFile(file, keyjson, 0600); err != nil {
+		utils.Fatalf("Failed to write the validator key: %v", err)
+	}
+	fmt.Printf("Validator address:  %s\n", key.Address.Hex())
+	fmt.Printf("Validator key file: %s\n", file)
+}
+
+// validatorNew generates a new validator key
+func validatorNew(ctx *cli.Context) error {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		utils.Fatalf("Failed to generate the validator key: %v", err)
+	}
+	storeValidatorKey(ctx, key)
+	return nil
+}
+
+// validatorImport stores an unencrypted private key as the validator key
+func validatorImport(ctx *cli.Context) error {
+	keyfile := ctx.Args().First()
+	if len(keyfile) == 0 {
+		utils.Fatalf("keyfile must be given as argument")
+	}
+	key, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to load the private key: %v", err)
+	}
+	storeValidatorKey(ctx, key)
+	return nil
+}
+
+// validatorExport writes the decrypted validator key into a new file
+func validatorExport(ctx *cli.Context) error {
+	keyfile := ctx.Args().First()
+	if len(keyfile) == 0 {
+		utils.Fatalf("keyfile must be given as argument")
+	}
+	if common.FileExist(keyfile) {
+		utils.Fatalf("Key file %s already exists", keyfile)
+	}
+	file, _ := configuredValidatorKeyFile(ctx)
+	key := decryptValidatorKey(ctx, file, 0)
+	if err := crypto.SaveECDSA(keyfile, key.PrivateKey); err != nil {
+		utils.Fatalf("Failed to write the private key: %v", err)
+	}
+	fmt.Printf("Exported validator %s into %s\n", key.Address.Hex(), keyfile)
+	return nil
+}
+
+// validatorShow prints the address of the validator key, which is stored unencrypted in the key file
+func validatorShow(ctx *cli.Context) error {
+	file, _ := configuredValidatorKeyFile(ctx)
+	keyjson, err := ioutil.ReadFile(file)
+	if err != nil {
+		utils.Fatalf("Failed to read the validator key: %v", err)
+	}
+	var key struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(keyjson, &key); err != nil {
+		utils.Fatalf("Failed to parse the validator key: %v", err)
+	}
+	fmt.Printf("Validator address:  %s\n", common.HexToAddress(key.Address).Hex())
+	fmt.Printf("Validator key file: %s\n", file)
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// These tests are 'smoke tests' for the validator key subcommands.
+
+func TestValidatorImportShowExport(t *testing.T) {
+	var (
+		datadir  = tmpdir(t)
+		keyfile  = filepath.Join(datadir, "key")
+		exported = filepath.Join(datadir, "exported")
+	)
+	defer os.RemoveAll(datadir)
+	if err := ioutil.WriteFile(keyfile, []byte("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	geth := runGeth(t, "validator", "import", "--datadir", datadir, "--lightkdf", keyfile)
+	geth.Expect(`
+Your validator key is locked with a password. Please give a password. Do not forget this password.
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Repeat passphrase: {{.InputLine "foobar"}}
+Validator address:  0x2c7536E3605D9C16a7a3D7b1898e529396a65c23
+`)
+	geth.ExpectRegexp(`Validator key file: .*validatorkey`)
+	geth.ExpectExit()
+
+	geth = runGeth(t, "validator", "show", "--datadir", datadir)
+	geth.ExpectRegexp(`Validator address:  0x2c7536E3605D9C16a7a3D7b1898e529396a65c23\nValidator key file: .*validatorkey`)
+	geth.ExpectExit()
+
+	// a validator key is never overwritten
+	geth = runGeth(t, "validator", "new", "--datadir", datadir, "--lightkdf")
+	geth.ExpectRegexp(`Fatal: Validator key file .* already exists`)
+	geth.ExpectExit()
+
+	geth = runGeth(t, "validator", "export", "--datadir", datadir, exported)
+	geth.Expect(`
+Unlocking validator key {{.Datadir}}/geth/validatorkey
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+`)
+	geth.ExpectRegexp(`Exported validator 0x2c7536E3605D9C16a7a3D7b1898e529396a65c23 into .*exported`)
+	geth.ExpectExit()
+
+	key, err := ioutil.ReadFile(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key) != "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318" {
+		t.Fatalf("exported key mismatch: %s", key)
+	}
+}
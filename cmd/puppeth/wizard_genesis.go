@@ -36,12 +36,11 @@ import (
 	"golang.org/x/crypto/ed25519"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	tendermintUtils "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core"
-	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/params"
-	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
 // makeGenesis creates a new genesis struct based on some user input.
@@ -124,6 +123,21 @@ func (w *wizard) makeGenesis() {
 		fmt.Println("Specify your tendermint block reward if you want an explicit one (default = 5e+18)")
 		genesis.Config.Tendermint.BlockReward = new(big.Int).Set(w.readDefaultBigInt(big.NewInt(5e+18)))
 
+		// Query the epoch length, the validators are checkpointed and the rewards paid at each epoch
+		fmt.Println()
+		fmt.Println("How many blocks should an epoch last? (default = 1024)")
+		for {
+			if epoch := w.readDefaultInt(1024); epoch > 0 {
+				genesis.Config.Tendermint.Epoch = uint64(epoch)
+				break
+			}
+			log.Error("Invalid epoch length, please retry")
+		}
+
+		fmt.Println()
+		fmt.Println("How many seconds should blocks take at least? (default = 1)")
+		genesis.Config.Tendermint.BlockPeriod = uint64(w.readDefaultInt(1))
+
 		// In the case of Tender-mint, configure the consensus parameters
 		genesis.Difficulty = big.NewInt(1)
 
@@ -141,8 +155,6 @@ func (w *wizard) makeGenesis() {
 				break
 			}
 		}
-		tendermintExtra := types.TendermintExtra{}
-
 		fmt.Println()
 		fmt.Println("Do you want to use fixed validators? (default = no)")
 		if w.readDefaultYesNo(false) {
@@ -152,20 +164,12 @@ func (w *wizard) makeGenesis() {
 			return
 		}
 
-		// RLP encode validator's address to bytes
-		valSetData, err := rlp.EncodeToBytes(validators)
-		if err != nil {
-			log.Error("rlp encode got error", "error", err)
-			return
-		}
-		tendermintExtra.ValidatorAdds = valSetData
-		extraData, err := rlp.EncodeToBytes(&tendermintExtra)
+		extraData, err := tendermintUtils.GenesisExtra(validators)
 		if err != nil {
 			log.Error("rlp encode got error", "error", err)
 			return
 		}
-		tendermintExtraVanity := bytes.Repeat([]byte{0x00}, types.TendermintExtraVanity)
-		genesis.ExtraData = append(tendermintExtraVanity, extraData...)
+		genesis.ExtraData = extraData
 	default:
 		log.Crit("Invalid consensus engine choice", "choice", choice)
 	}
@@ -200,7 +200,7 @@ func getStakingSCData(contractBackend *backends.SimulatedBackend, smlSCAddress c
 	return codeOfStakingSC, storage
 }
 
-// readStakingSCParams returns the params to deploy staking smart-contract and writes epoch to genesis config
+// readStakingSCParams returns the params to deploy staking smart-contract with the epoch of the genesis config
 func (w *wizard) readStakingSCParams(genesis *core.Genesis, validators []common.Address) []interface{} {
 	fmt.Println()
 	fmt.Println("Input params to init staking SC:")
@@ -217,8 +217,7 @@ func (w *wizard) readStakingSCParams(genesis *core.Genesis, validators []common.
 	}
 	fmt.Println("- What is the admin address of staking SC?")
 	_admin := w.readMandatoryAddress()
-	fmt.Println("- How many blocks for epoch period? (default = 1024)")
-	_epochPeriod := w.readDefaultBigInt(big.NewInt(1024))
+	_epochPeriod := new(big.Int).SetUint64(genesis.Config.Tendermint.Epoch)
 	_startBlock := big.NewInt(0)
 	fmt.Println("- What is the max size of validators? (max number of candidates to be selected as validators for producing blocks)")
 	_maxValidatorSize := w.readMandatoryBigInt()
@@ -226,7 +225,6 @@ func (w *wizard) readStakingSCParams(genesis *core.Genesis, validators []common.
 	_minValidatorStake := w.readMandatoryBigInt()
 	fmt.Println("- What is the min cap of vote? (minimum amount of EVR tokens to vote for a candidate)")
 	_minVoteCap := w.readMandatoryBigInt()
-	genesis.Config.Tendermint.Epoch = _epochPeriod.Uint64()
 	return []interface{}{validators, _candidatesOwners, _epochPeriod, _startBlock, _maxValidatorSize, _minValidatorStake, _minVoteCap, *_admin}
 }
 
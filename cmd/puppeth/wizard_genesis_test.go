@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	tendermintUtils "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// Tests that the tendermint flow of the wizard produces a genesis with the validators in the extra-data
+// and the epoch and block period in the chain config.
+func TestWizardMakeTendermintGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "puppeth-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	validators := []common.Address{
+		common.HexToAddress("0x560089aB68dc224b250f9588b3DB540D87A66b7a"),
+		common.HexToAddress("0x954e4BF2C68F13D97C45db0e02645D145dB6911f"),
+	}
+	input := strings.Join([]string{
+		"3",   // tendermint
+		"",    // round robin
+		"",    // default block reward
+		"0",   // invalid epoch, asked again
+		"100", // epoch
+		"5",   // block period
+		validators[0].Hex(),
+		validators[1].Hex(),
+		"",     // no more validators
+		"yes",  // fixed validators
+		"",     // no flood accounts
+		"",     // no pre-funded account
+		"",     // no pre-funded precompiles
+		"1234", // chain id
+		"",     // default gas price
+	}, "\n") + "\n"
+	w := &wizard{
+		network: "test",
+		conf:    config{path: filepath.Join(dir, "test")},
+		in:      bufio.NewReader(strings.NewReader(input)),
+	}
+	w.makeGenesis()
+
+	genesis := w.conf.Genesis
+	if genesis == nil {
+		t.Fatal("genesis not configured")
+	}
+	config := genesis.Config.Tendermint
+	if config.Epoch != 100 || config.BlockPeriod != 5 {
+		t.Fatalf("epoch and period mismatch: have %d and %d, want 100 and 5", config.Epoch, config.BlockPeriod)
+	}
+	if len(config.FixedValidators) != len(validators) {
+		t.Fatalf("fixed validators mismatch: have %v, want %v", config.FixedValidators, validators)
+	}
+	if genesis.Difficulty.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("difficulty mismatch: have %v, want 1", genesis.Difficulty)
+	}
+	extraValidators, err := tendermintUtils.GetValSetAddresses(&types.Header{Extra: genesis.ExtraData})
+	if err != nil {
+		t.Fatalf("failed to decode the extra-data: %v", err)
+	}
+	for i, addr := range validators {
+		if extraValidators[i] != addr {
+			t.Fatalf("validator %d mismatch: have %x, want %x", i, extraValidators[i], addr)
+		}
+	}
+}
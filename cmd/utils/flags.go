@@ -425,6 +425,15 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerPriorityGasFlag = cli.Uint64Flag{
+		Name:  "miner.prioritygas",
+		Usage: "Gas reserved in each block for the transactions to the staking and system contracts (0 = no priority lane)",
+		Value: evr.DefaultConfig.Miner.PriorityGas,
+	}
+	MinerPriorityContractsFlag = cli.StringFlag{
+		Name:  "miner.prioritycontracts",
+		Usage: "Comma separated system contracts the transactions to which are in the priority lane, besides the staking contract",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -592,6 +601,11 @@ var (
 		Usage: "Comma separated enode URLs for P2P v5 discovery bootstrap (light server, light nodes)",
 		Value: "",
 	}
+	ValidatorNodesFlag = cli.StringFlag{
+		Name:  "validatornodes",
+		Usage: "Comma separated enode URLs of fellow validators which are always kept connected",
+		Value: "",
+	}
 	NodeKeyFromKeystoreFlag = cli.BoolFlag{
 		Name:  "nodekeyfromkeystore",
 		Usage: "Enables importing the 1st account from keystore as nodekey",
@@ -705,10 +719,167 @@ var (
 		Usage: "Duration waiting to start round with new height",
 		Value: evr.DefaultConfig.Tendermint.TimeoutCommit,
 	}
+	TendermintTargetBlockIntervalFlag = cli.DurationFlag{
+		Name:  "tendermint.target-block-interval",
+		Usage: "Average block interval the commit timeout is adjusted to keep despite the round latencies (0 = fixed commit timeout)",
+		Value: evr.DefaultConfig.Tendermint.TargetBlockInterval,
+	}
+	TendermintProposerPolicyFlag = cli.Uint64Flag{
+		Name:  "tendermint.proposer-policy",
+		Usage: "Proposer selection policy if the genesis does not set one, 0: round robin, 1: sticky, 2: stake weighted round robin",
+		Value: uint64(evr.DefaultConfig.Tendermint.ProposerPolicy),
+	}
+	TendermintEpochFlag = cli.Uint64Flag{
+		Name:  "tendermint.epoch",
+		Usage: "Number of blocks of an epoch if the genesis does not set one",
+		Value: evr.DefaultConfig.Tendermint.Epoch,
+	}
+	TendermintProposeGossipFlag = cli.StringFlag{
+		Name:  "tendermint.gossip-propose",
+		Usage: "Strategy to disseminate the proposals (full, random, tree)",
+		Value: evr.DefaultConfig.Tendermint.ProposeGossip.String(),
+	}
+	TendermintPrevoteGossipFlag = cli.StringFlag{
+		Name:  "tendermint.gossip-prevote",
+		Usage: "Strategy to disseminate the prevotes (full, random, tree)",
+		Value: evr.DefaultConfig.Tendermint.PrevoteGossip.String(),
+	}
+	TendermintPrecommitGossipFlag = cli.StringFlag{
+		Name:  "tendermint.gossip-precommit",
+		Usage: "Strategy to disseminate the precommits (full, random, tree)",
+		Value: evr.DefaultConfig.Tendermint.PrecommitGossip.String(),
+	}
+	TendermintGossipFanoutFlag = cli.IntFlag{
+		Name:  "tendermint.gossip-fanout",
+		Usage: "Number of validators a message is sent to by the random and tree strategies (0 = sqrt of the validator set size)",
+		Value: evr.DefaultConfig.Tendermint.GossipFanout,
+	}
+	TendermintProposalAckTimeoutFlag = cli.DurationFlag{
+		Name:  "tendermint.proposal-ack-timeout",
+		Usage: "Duration waiting for the validators to acknowledge a proposal before resending it (0 = no resend)",
+		Value: evr.DefaultConfig.Tendermint.ProposalAckTimeout,
+	}
+	TendermintRecentMessagesCacheFlag = cli.IntFlag{
+		Name:  "tendermint.recent-messages-cache",
+		Usage: "Number of recent consensus message hashes kept to drop the duplicates (0 = default)",
+		Value: evr.DefaultConfig.Tendermint.RecentMessagesCache,
+	}
+	TendermintKnownMessagesCacheFlag = cli.IntFlag{
+		Name:  "tendermint.known-messages-cache",
+		Usage: "Number of consensus message hashes kept per peer not to send them back (0 = default)",
+		Value: evr.DefaultConfig.Tendermint.KnownMessagesCache,
+	}
+	TendermintMaxTimestampDriftFlag = cli.DurationFlag{
+		Name:  "tendermint.max-timestamp-drift",
+		Usage: "Maximum duration the timestamp of a proposal may be ahead of the local time",
+		Value: evr.DefaultConfig.Tendermint.MaxTimestampDrift,
+	}
+	TendermintSpeculativeRoundsFlag = cli.IntFlag{
+		Name:  "tendermint.speculative-rounds",
+		Usage: "Number of next rounds this validator proposes in which its block is reassembled as transactions arrive (0 = recommit interval only)",
+		Value: evr.DefaultConfig.Tendermint.SpeculativeRounds,
+	}
+	TendermintGasTargetFlag = cli.Uint64Flag{
+		Name:  "tendermint.gastarget",
+		Usage: "Gas limit the proposed blocks move toward within the per block bound (0 = miner gas floor and ceiling)",
+		Value: evr.DefaultConfig.Tendermint.GasTarget,
+	}
+	TendermintProposalGasFlag = cli.Uint64Flag{
+		Name:  "tendermint.proposal-gas",
+		Usage: "Gas the transactions of the proposed blocks may use, below their gas limit (0 = block gas limit)",
+		Value: evr.DefaultConfig.Tendermint.ProposalGas,
+	}
+	TendermintProposalTimeFlag = cli.DurationFlag{
+		Name:  "tendermint.proposal-time",
+		Usage: "Maximum duration the transactions of the proposed blocks are assembled for (0 = no limit)",
+		Value: evr.DefaultConfig.Tendermint.ProposalTime,
+	}
+	TendermintEvidenceMaxAgeBlocksFlag = cli.Uint64Flag{
+		Name:  "tendermint.evidence-max-age-blocks",
+		Usage: "Number of blocks behind the head after which an evidence of misbehavior expires, if it is also older than the max age (0 = no limit)",
+		Value: evr.DefaultConfig.Tendermint.EvidenceMaxAgeBlocks,
+	}
+	TendermintEvidenceMaxAgeFlag = cli.DurationFlag{
+		Name:  "tendermint.evidence-max-age",
+		Usage: "Duration before the head after which an evidence of misbehavior expires, if it is also older than the max age in blocks (0 = no limit)",
+		Value: evr.DefaultConfig.Tendermint.EvidenceMaxAge,
+	}
 	TendermintSCUseEVMCallerFlag = cli.BoolFlag{
 		Name:  "tendermint.use-evm-caller",
 		Usage: "The flag allowance reading data from stateDB or EVM",
 	}
+	TendermintObserverFlag = cli.BoolFlag{
+		Name:  "tendermint.observer",
+		Usage: "Run the consensus as a non-voting observer to finalize blocks as soon as 2/3 precommits are seen",
+	}
+	TendermintObserversFlag = cli.StringFlag{
+		Name:  "tendermint.observers",
+		Usage: "Comma separated node addresses of the observers which consensus messages are also sent to",
+	}
+	TendermintNoConsensusProtocolFlag = cli.BoolFlag{
+		Name:  "tendermint.no-consensus-protocol",
+		Usage: "Do not run the consensus sub protocol (non-validator nodes opt out of the consensus traffic)",
+	}
+	TendermintProtobufMessagesFlag = cli.BoolFlag{
+		Name:  "tendermint.protobuf-messages",
+		Usage: "Send the consensus messages protobuf encoded to the consensus peers which decode them",
+	}
+	TendermintValidatorOnlyFlag = cli.BoolFlag{
+		Name:  "tendermint.validator-only",
+		Usage: "Only accept validators, sentries and observers on the consensus sub protocol (private network)",
+	}
+	TendermintSentriesFlag = cli.StringFlag{
+		Name:  "tendermint.sentries",
+		Usage: "Comma separated node addresses of the sentries accepted on a validator only network",
+	}
+	TendermintDevValidatorsFlag = cli.BoolFlag{
+		Name:  "tendermint.dev-validators",
+		Usage: "Enable the admin API to add and remove validators at the next epoch (test networks only)",
+	}
+	TendermintHealthAddrFlag = cli.StringFlag{
+		Name:  "tendermint.health-addr",
+		Usage: "Listening address of the HTTP /health and /ready probes of the consensus liveness (disabled if empty)",
+	}
+	TendermintMetricsAddrFlag = cli.StringFlag{
+		Name:  "tendermint.metrics-addr",
+		Usage: "Listening address of the HTTP /metrics with the consensus gauges and /consensus validator performance per epoch (disabled if empty)",
+	}
+	TendermintTracingEndpointFlag = cli.StringFlag{
+		Name:  "tendermint.tracing-endpoint",
+		Usage: "OTLP/HTTP endpoint of the OpenTelemetry collector the spans of the consensus steps are exported to, e.g. http://localhost:4318 (disabled if empty)",
+	}
+	TendermintEventTraceFlag = cli.StringFlag{
+		Name:  "tendermint.event-trace",
+		Usage: "File the messages, timeouts and blocks handled by the consensus are appended to, for a deterministic replay of an issue (disabled if empty)",
+	}
+	TendermintVoteBatchDelayFlag = cli.DurationFlag{
+		Name:  "tendermint.vote-batch-delay",
+		Usage: "Duration the votes sent to a peer wait for other votes to be packed with them in a single signed message, the peers must run a release which understands vote batches (disabled if 0)",
+	}
+	TendermintHeartbeatIntervalFlag = cli.DurationFlag{
+		Name:  "tendermint.heartbeat-interval",
+		Usage: "Interval of the heartbeats the proposer sends to the validators while it assembles its block, the peers must run a release which understands heartbeats (disabled if 0)",
+	}
+	TendermintHeartbeatExtensionFlag = cli.DurationFlag{
+		Name:  "tendermint.heartbeat-extension",
+		Usage: "Duration the propose timeout is extended by, once per round, when the proposer sends a heartbeat (heartbeats ignored if 0)",
+	}
+	TendermintStartPausedFlag = cli.BoolFlag{
+		Name:  "tendermint.paused",
+		Usage: "Start with the consensus paused, following the chain without proposing nor voting until tendermint.resume is called",
+	}
+	TendermintValidatorKeyFlag = cli.StringFlag{
+		Name:  "tendermint.validator-key",
+		Usage: "Encrypted validator key file signing the blocks and consensus messages (default = <datadir>/geth/validatorkey, the node key if missing)",
+	}
+	TendermintNextValidatorKeyFlag = cli.StringFlag{
+		Name:  "tendermint.next-validator-key",
+		Usage: "Encrypted validator key file the validator switches to at its key rotation scheduled in the genesis",
+	}
+	TendermintValidatorPasswordFlag = cli.StringFlag{
+		Name:  "tendermint.validator-password",
+		Usage: "Password file to decrypt the validator keys non-interactively, the password of the next key on the second line",
+	}
 
 	// Metrics flags
 	MetricsEnabledFlag = cli.BoolFlag{
@@ -884,6 +1055,26 @@ func setBootstrapNodesV5(ctx *cli.Context, cfg *p2p.Config) {
 	}
 }
 
+// setValidatorNodes creates a list of validator nodes from the command line
+// flags, keeping the configured ones if none have been specified.
+func setValidatorNodes(ctx *cli.Context, cfg *p2p.Config) {
+	if !ctx.GlobalIsSet(ValidatorNodesFlag.Name) {
+		return
+	}
+	urls := strings.Split(ctx.GlobalString(ValidatorNodesFlag.Name), ",")
+	cfg.ValidatorNodes = make([]*enode.Node, 0, len(urls))
+	for _, url := range urls {
+		if url != "" {
+			node, err := enode.Parse(enode.ValidSchemes, url)
+			if err != nil {
+				log.Crit("Validator node URL invalid", "enode", url, "err", err)
+				continue
+			}
+			cfg.ValidatorNodes = append(cfg.ValidatorNodes, node)
+		}
+	}
+}
+
 // setListenAddress creates a TCP listening address string from set command
 // line flags.
 func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
@@ -1088,7 +1279,15 @@ func setEtherbase(ctx *cli.Context, ks *keystore.KeyStore, cfg *evr.Config) {
 
 // MakePasswordList reads password lines from the file specified by the global --password flag.
 func MakePasswordList(ctx *cli.Context) []string {
-	path := ctx.GlobalString(PasswordFileFlag.Name)
+	return readPasswordList(ctx.GlobalString(PasswordFileFlag.Name))
+}
+
+// MakeValidatorPasswordList reads password lines from the file specified by the --tendermint.validator-password flag.
+func MakeValidatorPasswordList(ctx *cli.Context) []string {
+	return readPasswordList(ctx.GlobalString(TendermintValidatorPasswordFlag.Name))
+}
+
+func readPasswordList(path string) []string {
 	if path == "" {
 		return nil
 	}
@@ -1110,6 +1309,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setListenAddress(ctx, cfg)
 	setBootstrapNodes(ctx, cfg)
 	setBootstrapNodesV5(ctx, cfg)
+	setValidatorNodes(ctx, cfg)
 
 	lightClient := ctx.GlobalString(SyncModeFlag.Name) == "light"
 	lightServer := ctx.GlobalInt(LightServFlag.Name) != 0
@@ -1333,6 +1533,17 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerPriorityGasFlag.Name) {
+		cfg.PriorityGas = ctx.GlobalUint64(MinerPriorityGasFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerPriorityContractsFlag.Name) {
+		for _, contract := range strings.Split(ctx.GlobalString(MinerPriorityContractsFlag.Name), ",") {
+			if !common.IsHexAddress(contract) {
+				Fatalf("Invalid priority contract: %s", contract)
+			}
+			cfg.PriorityContracts = append(cfg.PriorityContracts, common.HexToAddress(contract))
+		}
+	}
 }
 
 func setWhitelist(ctx *cli.Context, cfg *evr.Config) {
@@ -1360,11 +1571,82 @@ func setWhitelist(ctx *cli.Context, cfg *evr.Config) {
 
 // setTendermint will use params from CLI for tendermint config
 // NOTE: ProposerPolicy, Epoch are used for chain, so they not allowed to inject. They will be got from genesis
+// splitAddresses parses a comma separated list of addresses
+func splitAddresses(input string) []common.Address {
+	var addresses []common.Address
+	for _, addr := range strings.Split(input, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addresses = append(addresses, common.HexToAddress(addr))
+		}
+	}
+	return addresses
+}
+
+// parseGossipStrategy returns the gossip strategy named s, it exits on an unknown name
+func parseGossipStrategy(s string) tendermint.GossipStrategy {
+	strategy, err := tendermint.ParseGossipStrategy(s)
+	if err != nil {
+		Fatalf("Option tendermint gossip: %v", err)
+	}
+	return strategy
+}
+
 func setTendermint(ctx *cli.Context, cfg *tendermint.Config) {
 
 	if ctx.GlobalIsSet(TendermintSCUseEVMCallerFlag.Name) {
 		cfg.UseEVMCaller = true
 	}
+	if ctx.GlobalIsSet(TendermintObserverFlag.Name) {
+		cfg.Observer = true
+	}
+	if ctx.GlobalIsSet(TendermintObserversFlag.Name) {
+		cfg.Observers = splitAddresses(ctx.GlobalString(TendermintObserversFlag.Name))
+	}
+	if ctx.GlobalIsSet(TendermintNoConsensusProtocolFlag.Name) {
+		cfg.NoConsensusProtocol = true
+	}
+	if ctx.GlobalIsSet(TendermintProtobufMessagesFlag.Name) {
+		cfg.ProtobufMessages = true
+	}
+	if ctx.GlobalIsSet(TendermintValidatorOnlyFlag.Name) {
+		cfg.ValidatorOnlyNetwork = true
+	}
+	if ctx.GlobalIsSet(TendermintSentriesFlag.Name) {
+		cfg.Sentries = splitAddresses(ctx.GlobalString(TendermintSentriesFlag.Name))
+	}
+	if ctx.GlobalIsSet(TendermintDevValidatorsFlag.Name) {
+		cfg.DevValidators = true
+	}
+	if ctx.GlobalIsSet(TendermintHealthAddrFlag.Name) {
+		cfg.HealthAddr = ctx.GlobalString(TendermintHealthAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintMetricsAddrFlag.Name) {
+		cfg.MetricsAddr = ctx.GlobalString(TendermintMetricsAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintTracingEndpointFlag.Name) {
+		cfg.TracingEndpoint = ctx.GlobalString(TendermintTracingEndpointFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintEventTraceFlag.Name) {
+		cfg.EventTraceFile = ctx.GlobalString(TendermintEventTraceFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintVoteBatchDelayFlag.Name) {
+		cfg.VoteBatchDelay = ctx.GlobalDuration(TendermintVoteBatchDelayFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintHeartbeatIntervalFlag.Name) {
+		cfg.HeartbeatInterval = ctx.GlobalDuration(TendermintHeartbeatIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintHeartbeatExtensionFlag.Name) {
+		cfg.HeartbeatExtension = ctx.GlobalDuration(TendermintHeartbeatExtensionFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintStartPausedFlag.Name) {
+		cfg.StartPaused = true
+	}
+	if ctx.GlobalIsSet(TendermintValidatorKeyFlag.Name) {
+		cfg.ValidatorKeyFile = ctx.GlobalString(TendermintValidatorKeyFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintNextValidatorKeyFlag.Name) {
+		cfg.NextValidatorKeyFile = ctx.GlobalString(TendermintNextValidatorKeyFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(TendermintBlockPeriodFlag.Name) {
 		cfg.BlockPeriod = ctx.GlobalUint64(TendermintBlockPeriodFlag.Name)
@@ -1393,10 +1675,112 @@ func setTendermint(ctx *cli.Context, cfg *tendermint.Config) {
 	if ctx.GlobalIsSet(TendermintTimeoutCommitFlag.Name) {
 		cfg.TimeoutCommit = ctx.GlobalDuration(TendermintTimeoutCommitFlag.Name)
 	}
+	if ctx.GlobalIsSet(TendermintTargetBlockIntervalFlag.Name) {
+		cfg.TargetBlockInterval = ctx.GlobalDuration(TendermintTargetBlockIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintProposerPolicyFlag.Name) {
+		cfg.ProposerPolicy = tendermint.ProposerPolicy(ctx.GlobalUint64(TendermintProposerPolicyFlag.Name))
+	}
+	if ctx.GlobalIsSet(TendermintEpochFlag.Name) {
+		cfg.Epoch = ctx.GlobalUint64(TendermintEpochFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintProposeGossipFlag.Name) {
+		cfg.ProposeGossip = parseGossipStrategy(ctx.GlobalString(TendermintProposeGossipFlag.Name))
+	}
+	if ctx.GlobalIsSet(TendermintPrevoteGossipFlag.Name) {
+		cfg.PrevoteGossip = parseGossipStrategy(ctx.GlobalString(TendermintPrevoteGossipFlag.Name))
+	}
+	if ctx.GlobalIsSet(TendermintPrecommitGossipFlag.Name) {
+		cfg.PrecommitGossip = parseGossipStrategy(ctx.GlobalString(TendermintPrecommitGossipFlag.Name))
+	}
+	if ctx.GlobalIsSet(TendermintGossipFanoutFlag.Name) {
+		cfg.GossipFanout = ctx.GlobalInt(TendermintGossipFanoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintProposalAckTimeoutFlag.Name) {
+		cfg.ProposalAckTimeout = ctx.GlobalDuration(TendermintProposalAckTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintRecentMessagesCacheFlag.Name) {
+		cfg.RecentMessagesCache = ctx.GlobalInt(TendermintRecentMessagesCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintKnownMessagesCacheFlag.Name) {
+		cfg.KnownMessagesCache = ctx.GlobalInt(TendermintKnownMessagesCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintGasTargetFlag.Name) {
+		cfg.GasTarget = ctx.GlobalUint64(TendermintGasTargetFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintProposalGasFlag.Name) {
+		cfg.ProposalGas = ctx.GlobalUint64(TendermintProposalGasFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintProposalTimeFlag.Name) {
+		cfg.ProposalTime = ctx.GlobalDuration(TendermintProposalTimeFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintEvidenceMaxAgeBlocksFlag.Name) {
+		cfg.EvidenceMaxAgeBlocks = ctx.GlobalUint64(TendermintEvidenceMaxAgeBlocksFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintEvidenceMaxAgeFlag.Name) {
+		cfg.EvidenceMaxAge = ctx.GlobalDuration(TendermintEvidenceMaxAgeFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintMaxTimestampDriftFlag.Name) {
+		cfg.MaxTimestampDrift = ctx.GlobalDuration(TendermintMaxTimestampDriftFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintSpeculativeRoundsFlag.Name) {
+		cfg.SpeculativeRounds = ctx.GlobalInt(TendermintSpeculativeRoundsFlag.Name)
+	}
 
 	if ctx.IsSet(TendermintSCUseEVMCallerFlag.Name) {
 		cfg.UseEVMCaller = true
 	}
+	if ctx.IsSet(TendermintObserverFlag.Name) {
+		cfg.Observer = true
+	}
+	if ctx.IsSet(TendermintObserversFlag.Name) {
+		cfg.Observers = splitAddresses(ctx.String(TendermintObserversFlag.Name))
+	}
+	if ctx.IsSet(TendermintNoConsensusProtocolFlag.Name) {
+		cfg.NoConsensusProtocol = true
+	}
+	if ctx.IsSet(TendermintProtobufMessagesFlag.Name) {
+		cfg.ProtobufMessages = true
+	}
+	if ctx.IsSet(TendermintValidatorOnlyFlag.Name) {
+		cfg.ValidatorOnlyNetwork = true
+	}
+	if ctx.IsSet(TendermintSentriesFlag.Name) {
+		cfg.Sentries = splitAddresses(ctx.String(TendermintSentriesFlag.Name))
+	}
+	if ctx.IsSet(TendermintDevValidatorsFlag.Name) {
+		cfg.DevValidators = true
+	}
+	if ctx.IsSet(TendermintHealthAddrFlag.Name) {
+		cfg.HealthAddr = ctx.String(TendermintHealthAddrFlag.Name)
+	}
+	if ctx.IsSet(TendermintMetricsAddrFlag.Name) {
+		cfg.MetricsAddr = ctx.String(TendermintMetricsAddrFlag.Name)
+	}
+	if ctx.IsSet(TendermintTracingEndpointFlag.Name) {
+		cfg.TracingEndpoint = ctx.String(TendermintTracingEndpointFlag.Name)
+	}
+	if ctx.IsSet(TendermintEventTraceFlag.Name) {
+		cfg.EventTraceFile = ctx.String(TendermintEventTraceFlag.Name)
+	}
+	if ctx.IsSet(TendermintVoteBatchDelayFlag.Name) {
+		cfg.VoteBatchDelay = ctx.Duration(TendermintVoteBatchDelayFlag.Name)
+	}
+	if ctx.IsSet(TendermintHeartbeatIntervalFlag.Name) {
+		cfg.HeartbeatInterval = ctx.Duration(TendermintHeartbeatIntervalFlag.Name)
+	}
+	if ctx.IsSet(TendermintHeartbeatExtensionFlag.Name) {
+		cfg.HeartbeatExtension = ctx.Duration(TendermintHeartbeatExtensionFlag.Name)
+	}
+	if ctx.IsSet(TendermintStartPausedFlag.Name) {
+		cfg.StartPaused = true
+	}
+	if ctx.IsSet(TendermintValidatorKeyFlag.Name) {
+		cfg.ValidatorKeyFile = ctx.String(TendermintValidatorKeyFlag.Name)
+	}
+	if ctx.IsSet(TendermintNextValidatorKeyFlag.Name) {
+		cfg.NextValidatorKeyFile = ctx.String(TendermintNextValidatorKeyFlag.Name)
+	}
 
 	if ctx.IsSet(TendermintBlockPeriodFlag.Name) {
 		cfg.BlockPeriod = ctx.Uint64(TendermintBlockPeriodFlag.Name)
@@ -1425,6 +1809,57 @@ func setTendermint(ctx *cli.Context, cfg *tendermint.Config) {
 	if ctx.IsSet(TendermintTimeoutCommitFlag.Name) {
 		cfg.TimeoutCommit = ctx.Duration(TendermintTimeoutCommitFlag.Name)
 	}
+	if ctx.IsSet(TendermintTargetBlockIntervalFlag.Name) {
+		cfg.TargetBlockInterval = ctx.Duration(TendermintTargetBlockIntervalFlag.Name)
+	}
+	if ctx.IsSet(TendermintProposerPolicyFlag.Name) {
+		cfg.ProposerPolicy = tendermint.ProposerPolicy(ctx.Uint64(TendermintProposerPolicyFlag.Name))
+	}
+	if ctx.IsSet(TendermintEpochFlag.Name) {
+		cfg.Epoch = ctx.Uint64(TendermintEpochFlag.Name)
+	}
+	if ctx.IsSet(TendermintProposeGossipFlag.Name) {
+		cfg.ProposeGossip = parseGossipStrategy(ctx.String(TendermintProposeGossipFlag.Name))
+	}
+	if ctx.IsSet(TendermintPrevoteGossipFlag.Name) {
+		cfg.PrevoteGossip = parseGossipStrategy(ctx.String(TendermintPrevoteGossipFlag.Name))
+	}
+	if ctx.IsSet(TendermintPrecommitGossipFlag.Name) {
+		cfg.PrecommitGossip = parseGossipStrategy(ctx.String(TendermintPrecommitGossipFlag.Name))
+	}
+	if ctx.IsSet(TendermintGossipFanoutFlag.Name) {
+		cfg.GossipFanout = ctx.Int(TendermintGossipFanoutFlag.Name)
+	}
+	if ctx.IsSet(TendermintProposalAckTimeoutFlag.Name) {
+		cfg.ProposalAckTimeout = ctx.Duration(TendermintProposalAckTimeoutFlag.Name)
+	}
+	if ctx.IsSet(TendermintRecentMessagesCacheFlag.Name) {
+		cfg.RecentMessagesCache = ctx.Int(TendermintRecentMessagesCacheFlag.Name)
+	}
+	if ctx.IsSet(TendermintKnownMessagesCacheFlag.Name) {
+		cfg.KnownMessagesCache = ctx.Int(TendermintKnownMessagesCacheFlag.Name)
+	}
+	if ctx.IsSet(TendermintGasTargetFlag.Name) {
+		cfg.GasTarget = ctx.Uint64(TendermintGasTargetFlag.Name)
+	}
+	if ctx.IsSet(TendermintProposalGasFlag.Name) {
+		cfg.ProposalGas = ctx.Uint64(TendermintProposalGasFlag.Name)
+	}
+	if ctx.IsSet(TendermintProposalTimeFlag.Name) {
+		cfg.ProposalTime = ctx.Duration(TendermintProposalTimeFlag.Name)
+	}
+	if ctx.IsSet(TendermintEvidenceMaxAgeBlocksFlag.Name) {
+		cfg.EvidenceMaxAgeBlocks = ctx.Uint64(TendermintEvidenceMaxAgeBlocksFlag.Name)
+	}
+	if ctx.IsSet(TendermintEvidenceMaxAgeFlag.Name) {
+		cfg.EvidenceMaxAge = ctx.Duration(TendermintEvidenceMaxAgeFlag.Name)
+	}
+	if ctx.IsSet(TendermintMaxTimestampDriftFlag.Name) {
+		cfg.MaxTimestampDrift = ctx.Duration(TendermintMaxTimestampDriftFlag.Name)
+	}
+	if ctx.IsSet(TendermintSpeculativeRoundsFlag.Name) {
+		cfg.SpeculativeRounds = ctx.Int(TendermintSpeculativeRoundsFlag.Name)
+	}
 }
 
 // checkExclusive verifies that only a single instance of the provided flags was
@@ -1499,6 +1934,9 @@ func SetEvrConfig(ctx *cli.Context, stack *node.Node, cfg *evr.Config) {
 	setMiner(ctx, &cfg.Miner)
 	setWhitelist(ctx, cfg)
 	setTendermint(ctx, &cfg.Tendermint)
+	if err := cfg.Tendermint.Validate(); err != nil {
+		Fatalf("Invalid tendermint configuration: %v", err)
+	}
 
 	if ctx.GlobalIsSet(SyncModeFlag.Name) {
 		cfg.SyncMode = *GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
@@ -1752,11 +2190,29 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	} else if config.Tendermint != nil { // In case Clique config was not defined
 		tdmintConfig := tendermint.DefaultConfig
 		setTendermint(ctx, tdmintConfig)
-		tdmintConfig.ProposerPolicy = tendermint.ProposerPolicy(config.Tendermint.ProposerPolicy)
-		tdmintConfig.Epoch = config.Tendermint.Epoch
+		if config.Tendermint.ProposerPolicy != 0 {
+			tdmintConfig.ProposerPolicy = tendermint.ProposerPolicy(config.Tendermint.ProposerPolicy)
+		}
+		if config.Tendermint.Epoch != 0 {
+			tdmintConfig.Epoch = config.Tendermint.Epoch
+		}
 		tdmintConfig.StakingSCAddress = config.Tendermint.StakingSCAddress
 		tdmintConfig.FixedValidators = config.Tendermint.FixedValidators
+		tdmintConfig.KeyRotations = config.Tendermint.KeyRotations
+		tdmintConfig.Recoveries = config.Tendermint.Recoveries
+		tdmintConfig.RewardSchedule = config.Tendermint.RewardSchedule
 		tdmintConfig.BlockReward = config.Tendermint.BlockReward
+		tdmintConfig.ChainID = config.ChainID
+		tdmintConfig.DomainSeparationBlock = config.Tendermint.DomainSeparationBlock
+		tdmintConfig.WeightedVotingBlock = config.Tendermint.WeightedVotingBlock
+		tdmintConfig.ProtobufSigningBlock = config.Tendermint.ProtobufSigningBlock
+		tdmintConfig.StrictTimestampBlock = config.Tendermint.StrictTimestampBlock
+		tdmintConfig.GasLimitBoundBlock = config.Tendermint.GasLimitBoundBlock
+		tdmintConfig.TimeoutBackoff = tendermint.TimeoutBackoff(config.Tendermint.TimeoutBackoff)
+		tdmintConfig.TimeoutBackoffMax = time.Duration(config.Tendermint.TimeoutBackoffMax) * time.Millisecond
+		if config.Tendermint.BlockPeriod != 0 {
+			tdmintConfig.BlockPeriod = config.Tendermint.BlockPeriod
+		}
 		engine = tdmintBackend.New(tdmintConfig, stack.Config().NodeKey())
 	} else {
 		engine = ethash.NewFaker()
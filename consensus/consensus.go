@@ -19,6 +19,7 @@ package consensus
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
@@ -169,6 +170,40 @@ type Tendermint interface {
 	Address() common.Address
 }
 
+// ValidatorInfo is implemented by the engines whose blocks are proposed and committed by a set of validators
+type ValidatorInfo interface {
+	// BlockValidators returns the validators allowed to commit the block of number
+	BlockValidators(chain ChainReader, number uint64) []common.Address
+
+	// BlockSigners returns the validators whose committed seals are in the header
+	BlockSigners(header *types.Header) ([]common.Address, error)
+
+	// BlockRound returns the round in which the block was proposed.
+	// It is derived from the proposer so it is only known modulo the number of validators.
+	BlockRound(chain ChainReader, header *types.Header) (int64, error)
+}
+
+// Proposer is implemented by the engines whose blocks are proposed in turns by a known schedule
+type Proposer interface {
+	// ProposesSoon returns whether the node proposes one of the next rounds, so that its
+	// sealing work is worth reassembling as soon as new transactions arrive
+	ProposesSoon() bool
+}
+
+// ProposalGossiper is implemented by the engines which gossip the blocks they propose to their validators
+type ProposalGossiper interface {
+	// ProposedTxs returns the hashes of the transactions of the block proposed at the current height
+	// and the validators which receive it, nil if no block is proposed yet
+	ProposedTxs() (map[common.Hash]struct{}, map[common.Address]bool)
+}
+
+// ProposalBudgeter is implemented by the engines which bound the blocks they propose
+type ProposalBudgeter interface {
+	// ProposalBudget returns the gas the transactions of a proposed block may use, 0 for the block gas limit,
+	// and how long they may be assembled for, 0 for no limit
+	ProposalBudget() (gas uint64, assembly time.Duration)
+}
+
 // Handler should be implemented is the consensus needs to handle and send peer's message
 type Handler interface {
 	// HandleNewChainHead handles a new head block comes
@@ -180,3 +215,21 @@ type Handler interface {
 	// SetBroadcaster sets the broadcaster to send message to peers
 	SetBroadcaster(Broadcaster)
 }
+
+// AckHandler is a Handler which handles the acknowledgements of the consensus messages it sent
+type AckHandler interface {
+	// HandleAck handles the acknowledgement from the peer of address of the message of hash
+	HandleAck(address common.Address, hash common.Hash)
+}
+
+// CommitHandler is a Handler which serves the commits of its last blocks to the peers and receives theirs
+type CommitHandler interface {
+	// CommitAt returns the header of the block of number carrying its commit, nil if the commit is not kept
+	CommitAt(number uint64) *types.Header
+	// HandleCommit handles the commit of the block of number from the peer of address, header is nil if the peer
+	// does not keep it
+	HandleCommit(address common.Address, number uint64, header *types.Header)
+	// FetchCommit requests the commit of the block of number from the peer of address and returns the header
+	// carrying it once the commit is verified
+	FetchCommit(address common.Address, number uint64, timeout time.Duration) (*types.Header, error)
+}
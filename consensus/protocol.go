@@ -26,6 +26,12 @@ const (
 	// TendermintMsg is the new message belong to evr/64.
 	// it notify the protocol handler that this is a message for tendermint consensus purpose
 	TendermintMsg = 0x11
+	// TendermintEnvelopeMsg is a tendermint message wrapped in its versioned envelope,
+	// it is only sent to the peers whose EnvelopeVersion is positive
+	TendermintEnvelopeMsg = 0x12
+	// TendermintProtobufMsg is a tendermint message in its protobuf envelope,
+	// it is only sent to the peers which decode protobuf messages
+	TendermintProtobufMsg = 0x13
 )
 
 // Broadcaster defines the interface to enqueue blocks to fetcher and find peer
@@ -43,3 +49,42 @@ type Peer interface {
 	// Address return the address of a peer
 	Address() common.Address
 }
+
+// AckPeer defines the interface of a peer which can acknowledge the delivery of consensus messages
+type AckPeer interface {
+	Peer
+	// Acks returns true if the peer acknowledges the messages it receives
+	Acks() bool
+	// SendAck acknowledges the delivery of the message of hash to this peer
+	SendAck(hash common.Hash) error
+}
+
+// EnvelopePeer defines the interface of a peer which can receive the consensus messages in versioned envelopes
+type EnvelopePeer interface {
+	Peer
+	// EnvelopeVersion returns the latest envelope version the peer decodes, 0 if it only receives bare messages
+	EnvelopeVersion() uint64
+}
+
+// ProtobufPeer defines the interface of a peer which can receive the consensus messages protobuf encoded
+type ProtobufPeer interface {
+	Peer
+	// Protobuf returns true if the peer decodes protobuf messages
+	Protobuf() bool
+}
+
+// VoteBatchPeer defines the interface of a peer which can receive the votes packed in vote batches
+type VoteBatchPeer interface {
+	Peer
+	// VoteBatches returns true if the peer decodes vote batches
+	VoteBatches() bool
+}
+
+// CommitPeer defines the interface of a peer which serves the commits of its last blocks
+type CommitPeer interface {
+	Peer
+	// Commits returns true if the peer answers the requests of commits
+	Commits() bool
+	// RequestCommit asks the peer for the commit of the block of number, the answer is passed to the CommitHandler
+	RequestCommit(number uint64) error
+}
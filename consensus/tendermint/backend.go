@@ -43,8 +43,9 @@ type Backend interface {
 	// FindExistingPeers check validator peers exist or not by address
 	FindExistingPeers(targets ValidatorSet) map[common.Address]consensus.Peer
 
-	//Commit send the consensus block back to miner, it should also handle the logic after a block get enough vote to be the next block in chain
-	Commit(block *types.Block)
+	//Commit send the consensus block back to miner, it should also handle the logic after a block get enough vote to be the next block in chain.
+	//round is the round in which the block received +2/3 precommits.
+	Commit(block *types.Block, round int64)
 
 	//Cancel send the consensus block back to miner if it is invalid for consensus.
 	Cancel(block *types.Block)
@@ -58,3 +59,10 @@ type Backend interface {
 	// If success, the result will be send to the pending tasks of miner
 	VerifyProposalBlock(block *types.Block) error
 }
+
+// InvalidMsgHandler is a Backend which scores the peers on the consensus messages they send
+type InvalidMsgHandler interface {
+	// HandleInvalidMsg handles a message from the peer of address which core rejected after the backend passed it on:
+	// a bad signature, a message of a non-validator or outside of the view window
+	HandleInvalidMsg(address common.Address, err error)
+}
@@ -1,32 +1,504 @@
 package backend
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
 )
 
+// errNoState is returned when the chain of the API can not provide the state, e.g on a light client
+var errNoState = errors.New("the state is not available")
+
+// errNoDelegatorRewards is returned when the rewards of the voters are paid at the epoch blocks, not claimed
+var errNoDelegatorRewards = errors.New("the delegator rewards are paid at the epoch blocks")
+
 // TendermintAPI is a user facing RPC API to dump tendermint state
 type TendermintAPI struct {
 	chain consensus.ChainReader
 	be    *Backend
 }
 
+// PrivateTendermintAPI is the RPC API to manage the tendermint consensus of the node
+type PrivateTendermintAPI struct {
+	chain consensus.ChainReader
+	be    *Backend
+}
+
+// SetLogLevel sets the log level of a tendermint component (core, timeout, backend) at runtime
+func (api *PrivateTendermintAPI) SetLogLevel(component string, level string) error {
+	return tendermint.SetLogLevel(component, level)
+}
+
+// LogLevels returns the log level of every tendermint component
+func (api *PrivateTendermintAPI) LogLevels() map[string]string {
+	return tendermint.LogLevels()
+}
+
+// DumpConsensusState returns a snapshot of the consensus state of core for post-mortems
+func (api *PrivateTendermintAPI) DumpConsensusState() *tendermintCore.StateSnapshot {
+	return api.be.core.Snapshot()
+}
+
+// AddValidator adds a validator at the next epoch on a test network with the dev validators mode.
+// It returns the number of the epoch block whose extra-data includes the validator.
+func (api *PrivateTendermintAPI) AddValidator(address common.Address) (uint64, error) {
+	return api.be.changeValidator(api.chain.CurrentHeader().Number.Uint64(), address, true)
+}
+
+// RemoveValidator removes a validator at the next epoch on a test network with the dev validators mode.
+// It returns the number of the epoch block whose extra-data excludes the validator.
+func (api *PrivateTendermintAPI) RemoveValidator(address common.Address) (uint64, error) {
+	return api.be.changeValidator(api.chain.CurrentHeader().Number.Uint64(), address, false)
+}
+
+// SignRecovery returns the approval by the validator of the recovery replacing the validator set
+// from the block number by validators, to add to the recovery in the genesis of each node.
+func (api *PrivateTendermintAPI) SignRecovery(number uint64, validators []common.Address) (hexutil.Bytes, error) {
+	return api.be.signRecovery(number, validators)
+}
+
+// Pause stops the node from proposing and voting while it keeps following the chain, e.g. during a maintenance
+// or before its validator key is moved to another node. It returns false if the consensus was already paused.
+func (api *PrivateTendermintAPI) Pause() bool {
+	return api.be.core.SetPaused(true)
+}
+
+// Resume lets the paused node propose and vote again from the next step of the consensus.
+// It returns false if the consensus was not paused.
+func (api *PrivateTendermintAPI) Resume() bool {
+	return api.be.core.SetPaused(false)
+}
+
+// PeerScores returns the score of the peers on the quality of the consensus messages they send
+func (api *PrivateTendermintAPI) PeerScores() map[common.Address]PeerScoreInfo {
+	return api.be.peerScores.snapshot()
+}
+
+// StatusInfo is the consensus status of the node returned by tendermint_status
+type StatusInfo struct {
+	Running     bool           `json:"running"` // false if core is stopped, the other fields are then empty
+	Height      *big.Int       `json:"height"`
+	Round       int64          `json:"round"`
+	Step        string         `json:"step"`
+	TimeInStep  string         `json:"timeInStep"`
+	Proposer    common.Address `json:"proposer"`
+	IsValidator bool           `json:"isValidator"`
+	IsProposer  bool           `json:"isProposer"`
+	Paused      bool           `json:"paused"` // true if the node doesn't propose nor vote, see tendermintadmin_pause
+}
+
+// Status returns the height, round and step of the consensus, how long it has been in the step
+// and who is proposing: a one-call health view of the node.
+func (api *TendermintAPI) Status() *StatusInfo {
+	return statusInfo(api.be.coreStatus())
+}
+
+// coreStatus returns the status of the core, nil if it is stopped
+func (sb *Backend) coreStatus() *tendermintCore.Status {
+	sb.mutex.RLock()
+	running := sb.coreStarted
+	sb.mutex.RUnlock()
+	if !running {
+		return nil
+	}
+	return sb.core.Status()
+}
+
+func statusInfo(status *tendermintCore.Status) *StatusInfo {
+	info := &StatusInfo{Running: status != nil}
+	if status == nil {
+		return info
+	}
+	info.Height = status.BlockNumber
+	info.Round = status.Round
+	info.Step = status.Step.String()
+	info.TimeInStep = time.Since(status.StepStartTime).String()
+	info.Proposer = status.Proposer
+	info.IsValidator = status.IsValidator
+	info.IsProposer = status.IsProposer
+	info.Paused = status.Paused
+	return info
+}
+
+// GetConsensusLag compares the height and round of the node with the ones announced by the connected peers
+// in their consensus messages, it reports whether the node is behind, by how many blocks and which peers are ahead.
+func (api *TendermintAPI) GetConsensusLag() *ConsensusLag {
+	return api.be.consensusLag(api.chain.CurrentHeader())
+}
+
+// FinalizedHead is the notification of the newFinalizedHeads subscription
+type FinalizedHead struct {
+	Number      *big.Int    `json:"number"`
+	Hash        common.Hash `json:"hash"`
+	ParentHash  common.Hash `json:"parentHash"`
+	Time        uint64      `json:"timestamp"`
+	CommitRound int64       `json:"commitRound"` // -1 if the node did not commit the block itself
+	Seals       int         `json:"seals"`       // number of committed seals of the block
+}
+
+// NewFinalizedHeads sends a notification for each finalized block, i.e each block appended to the chain.
+func (api *TendermintAPI) NewFinalizedHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan tendermint.BlockFinalizedEvent)
+		sub := api.be.SubscribeBlockFinalized(events)
+
+		for {
+			select {
+			case ev := <-events:
+				head, err := api.finalizedHead(ev)
+				if err != nil {
+					log.Warn("failed to get finalized head", "number", ev.BlockNumber, "err", err)
+					continue
+				}
+				notifier.Notify(rpcSub.ID, head)
+			case <-rpcSub.Err():
+				sub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				sub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// NewExcludedCandidates sends a notification for each checkpoint whose validator selection excludes candidates
+// of the staking contract by the minimum self-bond
+func (api *TendermintAPI) NewExcludedCandidates(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan tendermint.CandidatesExcludedEvent)
+		sub := api.be.SubscribeCandidatesExcluded(events)
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				sub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				sub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+func (api *TendermintAPI) finalizedHead(ev tendermint.BlockFinalizedEvent) (*FinalizedHead, error) {
+	header := api.chain.GetHeaderByNumber(ev.BlockNumber.Uint64())
+	if header == nil {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	return &FinalizedHead{
+		Number:      header.Number,
+		Hash:        header.Hash(),
+		ParentHash:  header.ParentHash,
+		Time:        header.Time,
+		CommitRound: ev.Round,
+		Seals:       len(extra.CommittedSeal),
+	}, nil
+}
+
 // GetValidators returns the list of validators by block's number
 func (api *TendermintAPI) GetValidators(number *uint64) []common.Address {
+	blockNumber := api.chain.CurrentHeader().Number.Uint64()
+	if number != nil {
+		blockNumber = *number
+	}
+	return api.be.BlockValidators(api.chain, blockNumber)
+}
+
+// maxValidatorSetEpochs is the maximum number of epochs returned by GetValidatorSets
+const maxValidatorSetEpochs = 1024
+
+// EpochValidators is the validator set of an epoch
+type EpochValidators struct {
+	Epoch      uint64           `json:"epoch"`
+	Checkpoint uint64           `json:"checkpoint"` // the block the validator set is stored at, the blocks after it are committed by the validators
+	Validators []common.Address `json:"validators"`
+}
+
+// GetValidatorSets returns the validator sets of the epochs from the epoch from to the epoch to included, in one call
+// for the explorers to backfill the history. The epochs stop at the last checkpoint of the chain.
+func (api *TendermintAPI) GetValidatorSets(from uint64, to uint64) ([]EpochValidators, error) {
+	if to < from {
+		return nil, fmt.Errorf("invalid range of epochs, %d is before %d", to, from)
+	}
+	if to-from >= maxValidatorSetEpochs {
+		return nil, fmt.Errorf("too many epochs, the maximum is %d", maxValidatorSetEpochs)
+	}
+	var (
+		epoch = api.be.config.Epoch
+		head  = api.chain.CurrentHeader().Number.Uint64()
+		sets  = make([]EpochValidators, 0, to-from+1)
+	)
+	for e := from; e <= to && e*epoch <= head; e++ {
+		checkpoint := e * epoch
+		valSet, err := api.be.valSetInfo.GetValSet(api.chain, new(big.Int).SetUint64(checkpoint+1))
+		if err != nil {
+			return nil, err
+		}
+		validators := make([]common.Address, 0, valSet.Size())
+		for _, val := range valSet.List() {
+			validators = append(validators, val.Address())
+		}
+		sets = append(sets, EpochValidators{Epoch: e, Checkpoint: checkpoint, Validators: validators})
+	}
+	return sets, nil
+}
+
+// maxProposerSchedule is the maximum number of heights or rounds returned by GetProposerSchedule
+const maxProposerSchedule = 1024
+
+// ProposerSlot is the proposer of a round of a block
+type ProposerSlot struct {
+	Number   uint64         `json:"number"`
+	Round    int64          `json:"round"`
+	Proposer common.Address `json:"proposer"`
+}
+
+// ProposerSchedule is the result of tendermint_getProposerSchedule
+type ProposerSchedule struct {
+	Heights []ProposerSlot `json:"heights"` // the proposers of round 0 of the next blocks
+	Rounds  []ProposerSlot `json:"rounds"`  // the proposers of the rounds of the next block
+}
+
+// GetProposerSchedule returns the proposers of round 0 of the next heights blocks and of the first rounds of the next block.
+// The proposers are deterministic for the validator set and the proposer policy, the heights stop at the end
+// of the epoch whose validators are known.
+func (api *TendermintAPI) GetProposerSchedule(heights uint64, rounds uint64) (*ProposerSchedule, error) {
+	if heights > maxProposerSchedule || rounds > maxProposerSchedule {
+		return nil, fmt.Errorf("schedule too long, the maximum is %d", maxProposerSchedule)
+	}
 	var (
-		blockNumber *big.Int
+		next     = api.chain.CurrentHeader().Number.Uint64() + 1
+		schedule = &ProposerSchedule{
+			Heights: make([]ProposerSlot, 0, heights),
+			Rounds:  make([]ProposerSlot, 0, rounds),
+		}
 	)
+	for number := next; number < next+heights; number++ {
+		valSet, err := api.be.valSetInfo.GetValSet(api.chain, new(big.Int).SetUint64(number))
+		if err != nil || valSet.GetProposer() == nil {
+			break
+		}
+		schedule.Heights = append(schedule.Heights, ProposerSlot{Number: number, Proposer: valSet.GetProposer().Address()})
+	}
+	valSet, err := api.be.valSetInfo.GetValSet(api.chain, new(big.Int).SetUint64(next))
+	if err != nil {
+		return nil, err
+	}
+	if valSet.GetProposer() == nil {
+		return schedule, nil
+	}
+	first := valSet.GetProposer().Address()
+	for round := int64(0); round < int64(rounds); round++ {
+		roundValSet := valSet.Copy()
+		roundValSet.CalcProposer(first, round)
+		schedule.Rounds = append(schedule.Rounds, ProposerSlot{Number: next, Round: round, Proposer: roundValSet.GetProposer().Address()})
+	}
+	return schedule, nil
+}
+
+// GetStakingInfo returns the stake, the delegations, the commission and the pending rewards of a validator
+// at the given block, the head by default. They are read from the staking contract by executing calls in the EVM.
+func (api *TendermintAPI) GetStakingInfo(validator common.Address, number *uint64) (*StakingInfo, error) {
+	chain, ok := api.chain.(consensus.FullChainReader)
+	if !ok {
+		return nil, errNoState
+	}
+	header := api.chain.CurrentHeader()
+	if number != nil {
+		header = api.chain.GetHeaderByNumber(*number)
+	}
+	if header == nil {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	return api.be.stakingInfo(chain, validator, header)
+}
+
+// GetDelegatorRewards returns the rewards a voter can claim at the given block, the head by default,
+// and the address of the system account the claims are sent to
+func (api *TendermintAPI) GetDelegatorRewards(voter common.Address, number *uint64) (*DelegatorRewards, error) {
+	chain, ok := api.chain.(consensus.FullChainReader)
+	if !ok {
+		return nil, errNoState
+	}
+	header := api.chain.CurrentHeader()
+	if number != nil {
+		header = api.chain.GetHeaderByNumber(*number)
+	}
+	if header == nil {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	return api.be.delegatorRewardsAt(chain, voter, header)
+}
+
+// GetMissingValidators returns the validators whose precommits are absent from the committed seals of the block,
+// i.e. the committer had not received them when it committed the block
+func (api *TendermintAPI) GetMissingValidators(number *uint64) ([]common.Address, error) {
+	var header *types.Header
 	if number == nil {
-		blockNumber = api.chain.CurrentHeader().Number
+		header = api.chain.CurrentHeader()
 	} else {
-		blockNumber = new(big.Int).SetUint64(*number)
+		header = api.chain.GetHeaderByNumber(*number)
 	}
-	valSet := api.be.ValidatorsByChainReader(blockNumber, api.chain)
-	validators := make([]common.Address, 0, valSet.Size())
-	for _, validator := range valSet.List() {
-		validators = append(validators, validator.Address())
+	if header == nil {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	valSet := api.be.ValidatorsByChainReader(header.Number, api.chain)
+	return utils.GetMissingValidators(header, valSet)
+}
+
+// DuplicateProposal is the evidence of a proposer signing two blocks for a round
+type DuplicateProposal struct {
+	Proposer    common.Address `json:"proposer"`
+	Number      *big.Int       `json:"number"`
+	Round       int64          `json:"round"`
+	BlockHashes []common.Hash  `json:"blockHashes"`
+	Evidence    hexutil.Bytes  `json:"evidence"` // the evidence message, to be submitted for slashing
+}
+
+// GetDuplicateProposals returns the recent evidences of the proposers signing several blocks for a round,
+// detected by this node or relayed by its peers, the oldest first
+func (api *TendermintAPI) GetDuplicateProposals() []DuplicateProposal {
+	evidences := api.be.evidences.duplicateProposals()
+	duplicates := make([]DuplicateProposal, 0, len(evidences))
+	for _, evidence := range evidences {
+		duplicates = append(duplicates, DuplicateProposal{
+			Proposer:    evidence.Proposer,
+			Number:      evidence.BlockNumber,
+			Round:       evidence.Round,
+			BlockHashes: evidence.BlockHashes[:],
+			Evidence:    evidence.Payload,
+		})
+	}
+	return duplicates
+}
+
+// Amnesia is the evidence of a validator prevoting a block after precommitting another one at the same block number
+type Amnesia struct {
+	Validator      common.Address `json:"validator"`
+	Number         *big.Int       `json:"number"`
+	PrecommitRound int64          `json:"precommitRound"`
+	PrecommitHash  common.Hash    `json:"precommitHash"`
+	PrevoteRound   int64          `json:"prevoteRound"`
+	PrevoteHash    common.Hash    `json:"prevoteHash"`
+	Evidence       hexutil.Bytes  `json:"evidence"` // the evidence message, to be submitted for slashing
+}
+
+// GetAmnesias returns the recent evidences of the validators prevoting contrary to their lock without 2/3 prevotes
+// unlocking them, detected by this node or relayed by its peers, the oldest first. A validator is cleared by
+// the 2/3 prevotes of a round between its precommit and its prevote, see core.VerifyProofOfLock.
+func (api *TendermintAPI) GetAmnesias() []Amnesia {
+	evidences := api.be.evidences.amnesias()
+	amnesias := make([]Amnesia, 0, len(evidences))
+	for _, evidence := range evidences {
+		amnesias = append(amnesias, Amnesia{
+			Validator:      evidence.Validator,
+			Number:         evidence.BlockNumber,
+			PrecommitRound: evidence.PrecommitRound,
+			PrecommitHash:  evidence.PrecommitHash,
+			PrevoteRound:   evidence.PrevoteRound,
+			PrevoteHash:    evidence.PrevoteHash,
+			Evidence:       evidence.Payload,
+		})
+	}
+	return amnesias
+}
+
+// Lunatic is the evidence of a validator precommitting a block which fails the validity checks
+type Lunatic struct {
+	Validator common.Address `json:"validator"`
+	Number    *big.Int       `json:"number"`
+	Round     int64          `json:"round"`
+	BlockHash common.Hash    `json:"blockHash"`
+	Fault     string         `json:"fault"`    // the validity check the block fails
+	Evidence  hexutil.Bytes  `json:"evidence"` // the evidence message, to be submitted for slashing
+}
+
+// GetLunatics returns the recent evidences of the validators precommitting invalid blocks, detected by this node
+// or relayed by its peers, the oldest first.
+func (api *TendermintAPI) GetLunatics() []Lunatic {
+	evidences := api.be.evidences.lunatics()
+	lunatics := make([]Lunatic, 0, len(evidences))
+	for _, evidence := range evidences {
+		lunatics = append(lunatics, Lunatic{
+			Validator: evidence.Validator,
+			Number:    evidence.BlockNumber,
+			Round:     evidence.Round,
+			BlockHash: evidence.Header.Hash(),
+			Fault:     evidence.fault.Error(),
+			Evidence:  evidence.Payload,
+		})
+	}
+	return lunatics
+}
+
+// PendingEvidence is an evidence of misbehavior in the pool, which is not submitted for slashing yet
+type PendingEvidence struct {
+	Type      string         `json:"type"` // duplicateProposal, amnesia or lunatic
+	Validator common.Address `json:"validator"`
+	Number    *big.Int       `json:"number"`
+	AgeBlocks uint64         `json:"ageBlocks"` // the number of blocks the head is after the misbehavior
+	Age       uint64         `json:"age"`       // the seconds the head is after the misbehavior
+	Evidence  hexutil.Bytes  `json:"evidence"`  // the evidence message, to be submitted for slashing
+}
+
+// GetPendingEvidences returns the evidences of all types in the pool, the oldest first. The evidences expire
+// once older than both tendermint.evidence-max-age-blocks and tendermint.evidence-max-age, or the max ages
+// of the params contract of the genesis once it is active.
+func (api *TendermintAPI) GetPendingEvidences() []PendingEvidence {
+	evidences := api.be.evidences.list()
+	pending := make([]PendingEvidence, 0, len(evidences))
+	for _, evidence := range evidences {
+		var item PendingEvidence
+		switch evidence := evidence.(type) {
+		case *tendermintCore.DuplicateProposal:
+			item = PendingEvidence{Type: "duplicateProposal", Validator: evidence.Proposer, Number: evidence.BlockNumber, Evidence: evidence.Payload}
+		case *tendermintCore.Amnesia:
+			item = PendingEvidence{Type: "amnesia", Validator: evidence.Validator, Number: evidence.BlockNumber, Evidence: evidence.Payload}
+		case *lunatic:
+			item = PendingEvidence{Type: "lunatic", Validator: evidence.Validator, Number: evidence.BlockNumber, Evidence: evidence.Payload}
+		}
+		if blocks, age, ok := api.be.evidenceAge(item.Number.Uint64()); ok {
+			item.AgeBlocks, item.Age = blocks, uint64(age/time.Second)
+		}
+		pending = append(pending, item)
 	}
-	return validators
+	return pending
 }
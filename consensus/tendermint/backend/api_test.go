@@ -0,0 +1,118 @@
+package backend
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+func TestTendermintAPI_GetProposerSchedule(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"), nodeAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+		api            = &TendermintAPI{chain: be.chain, be: be}
+		sorted         = be.BlockValidators(be.chain, 1)
+	)
+	schedule, err := api.GetProposerSchedule(4, 4)
+	require.NoError(t, err)
+	// the proposers rotate over the sorted validators with the heights and with the rounds
+	require.Equal(t, []ProposerSlot{
+		{Number: 1, Proposer: sorted[0]},
+		{Number: 2, Proposer: sorted[1]},
+		{Number: 3, Proposer: sorted[2]},
+		{Number: 4, Proposer: sorted[0]},
+	}, schedule.Heights)
+	require.Equal(t, []ProposerSlot{
+		{Number: 1, Round: 0, Proposer: sorted[0]},
+		{Number: 1, Round: 1, Proposer: sorted[1]},
+		{Number: 1, Round: 2, Proposer: sorted[2]},
+		{Number: 1, Round: 3, Proposer: sorted[0]},
+	}, schedule.Rounds)
+
+	_, err = api.GetProposerSchedule(maxProposerSchedule+1, 0)
+	require.Error(t, err)
+}
+
+func TestTendermintAPI_GetValidatorSets(t *testing.T) {
+	_, addrs := getValidatorAccounts()
+	testFinalize(t, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(addrs[0])
+	}, stakingEpoch+1, func(chain *core.BlockChain) {
+		be := chain.Engine().(*Backend)
+		api := &TendermintAPI{chain: chain, be: be}
+
+		// the epochs after the last checkpoint are not returned
+		sets, err := api.GetValidatorSets(0, 5)
+		require.NoError(t, err)
+		require.Len(t, sets, 2)
+		for i, set := range sets {
+			require.Equal(t, uint64(i), set.Epoch)
+			require.Equal(t, uint64(i)*stakingEpoch, set.Checkpoint)
+			require.ElementsMatch(t, addrs, set.Validators)
+			require.Equal(t, be.BlockValidators(chain, set.Checkpoint+1), set.Validators)
+		}
+
+		_, err = api.GetValidatorSets(2, 1)
+		require.Error(t, err)
+		_, err = api.GetValidatorSets(0, maxValidatorSetEpochs)
+		require.Error(t, err)
+	})
+}
+
+func TestPrivateTendermintAPI_Pause(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		validators     = []common.Address{crypto.PubkeyToAddress(nodePrivateKey.PublicKey)}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+		api            = &PrivateTendermintAPI{chain: be.chain, be: be}
+	)
+	require.True(t, api.Pause())
+	require.False(t, api.Pause())
+	// a paused validator doesn't seal blocks
+	require.Equal(t, tendermintCore.ErrPaused, be.Seal(be.chain, tests_utils.MakeBlockWithoutSeal(genesisHeader), nil, nil))
+
+	require.True(t, api.Resume())
+	require.False(t, api.Resume())
+}
+
+func TestBackend_APIsPrivateNamespace(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{nodeAddr}
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, tests_utils.MakeGenesisHeader(validators), validators)
+		server         = rpc.NewServer()
+	)
+	// serve the tendermint namespace as an HTTP endpoint whitelisting it does
+	for _, api := range be.APIs(be.chain) {
+		if api.Namespace == "tendermint" {
+			require.NoError(t, server.RegisterName(api.Namespace, api.Service))
+			continue
+		}
+		require.False(t, api.Public, api.Namespace)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	private := reflect.TypeOf(&PrivateTendermintAPI{})
+	for i := 0; i < private.NumMethod(); i++ {
+		name := private.Method(i).Name
+		method := "tendermint_" + strings.ToLower(name[:1]) + name[1:]
+		err := client.Call(nil, method)
+		require.Error(t, err, method)
+		require.Contains(t, err.Error(), "does not exist", method)
+	}
+}
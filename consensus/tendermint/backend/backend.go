@@ -3,6 +3,7 @@ package backend
 import (
 	"crypto/ecdsa"
 	"math/big"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/backend/fixed_valset_info"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/backend/staking"
 	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tracing"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
@@ -43,6 +45,14 @@ var (
 //Option return an optional function for backend's initial behaviour
 type Option func(b *Backend) error
 
+// WithDB returns an option to set the database where the validator set checkpoints and the evidences are stored
+func WithDB(db evrdb.Database) Option {
+	return func(b *Backend) error {
+		b.db = db
+		return nil
+	}
+}
+
 // New creates an backend for Istanbul core engine.
 // The p2p communication, i.e, broadcaster is set separately by calling backend.SetBroadcaster
 func New(config *tendermint.Config, privateKey *ecdsa.PrivateKey, opts ...Option) consensus.Tendermint {
@@ -52,6 +62,7 @@ func New(config *tendermint.Config, privateKey *ecdsa.PrivateKey, opts ...Option
 		tendermintEventMux:   new(event.TypeMux),
 		privateKey:           privateKey,
 		address:              crypto.PubkeyToAddress(privateKey.PublicKey),
+		nextKey:              config.NextValidatorKey,
 		commitChs:            newCommitChannels(),
 		mutex:                &sync.RWMutex{},
 		storingMsgs:          queue.NewFIFO(),
@@ -59,24 +70,66 @@ func New(config *tendermint.Config, privateKey *ecdsa.PrivateKey, opts ...Option
 		broadcastCh:          make(chan broadcastTask),
 		controlChan:          make(chan struct{}),
 		computedValSetCache:  valSetCache,
+		peerScores:           newPeerScores(),
+		peerViews:            newPeerViews(),
+		msgCaches:            newMsgCaches(config.RecentMessagesCache, config.KnownMessagesCache),
+		proposalAcks:         newProposalAcks(),
+		finalized:            newFinalizedBlocks(),
+		performances:         newEpochPerformances(),
+		commits:              newServedCommits(config.ServedCommits),
 	}
 
+	for _, opt := range opts {
+		if err := opt(be); err != nil {
+			log.Error("error at initialization of backend", err)
+		}
+	}
+	be.evidences = newEvidences(be.db)
+	be.loadEvidences()
+	be.devValidators = newDevValidators(be.db)
+
 	if config.FixedValidators != nil && len(config.FixedValidators) > 0 {
-		be.valSetInfo = fixed_valset_info.NewFixedValidatorSetInfo(config.FixedValidators)
+		be.valSetInfo = fixed_valset_info.NewFixedValidatorSetInfo(config.FixedValidators, config.Epoch, config.KeyRotations)
 	} else {
-		be.valSetInfo = staking.NewStakingValidatorInfo(config.Epoch, config.ProposerPolicy)
+		stakingValidators := staking.NewStakingValidatorInfo(config.Epoch, config.ProposerPolicy, be.db)
+		stakingValidators.Weights = be.stakeWeights
+		stakingValidators.WeightedVotingBlock = config.WeightedVotingBlock
+		be.valSetInfo = stakingValidators
 		if config.StakingSCAddress == nil {
 			panic("nil staking address")
 		}
 		be.stakingContractAddr = *config.StakingSCAddress
 	}
-	be.core = tendermintCore.New(be, config)
-
-	for _, opt := range opts {
-		if err := opt(be); err != nil {
-			log.Error("error at initialization of backend", err)
+	if len(config.Recoveries) > 0 {
+		be.valSetInfo = newRecoveryValSetInfo(be.valSetInfo, config)
+	}
+	if config.VoteBatchDelay > 0 && !config.Observer {
+		be.voteBatcher = newVoteBatcher(config.VoteBatchDelay, func(blockNumber *big.Int, payloads [][]byte) ([]byte, error) {
+			return tendermintCore.EncodeVoteBatch(config, be.Address(), be.Sign, blockNumber, payloads)
+		})
+		be.voteBatcher.protobuf = config.ProtobufMessages
+	}
+	coreOpts := []tendermintCore.Option{
+		tendermintCore.WithDuplicateProposalHandler(be.handleDuplicateProposal),
+		tendermintCore.WithAmnesiaHandler(be.handleAmnesia),
+		tendermintCore.WithLunaticHandler(be.handleLunatic),
+	}
+	if config.TracingEndpoint != "" {
+		// the spans of the fleet are told apart by the validator address
+		be.tracer = tracing.NewTracer(config.TracingEndpoint,
+			tracing.String("service.name", "gev"), tracing.String("service.instance.id", be.address.Hex()))
+		coreOpts = append(coreOpts, tendermintCore.WithTracer(be.tracer))
+	}
+	if config.EventTraceFile != "" {
+		file, err := os.OpenFile(config.EventTraceFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			log.Error("Failed to open the consensus event trace", "file", config.EventTraceFile, "err", err)
+		} else {
+			be.eventTrace = file
+			coreOpts = append(coreOpts, tendermintCore.WithTraceRecorder(file))
 		}
 	}
+	be.core = tendermintCore.New(be, config, coreOpts...)
 
 	go be.dequeueMsgLoop()
 	return be
@@ -96,6 +149,8 @@ type Backend struct {
 	db                 evrdb.Database
 	broadcaster        consensus.Broadcaster
 	address            common.Address
+	nextKey            *ecdsa.PrivateKey // nextKey replaces privateKey once the validator sets list it instead, see useNextKey
+	keyMu              sync.RWMutex      // keyMu protects privateKey, address and nextKey
 
 	//once voting finish, the block will be send for commit here
 	//it is a map of blocknumber- channels with mutex
@@ -119,6 +174,31 @@ type Backend struct {
 	valSetInfo          ValidatorSetInfo
 	stakingContractAddr common.Address // stakingContractAddr stores the address of staking smart-contract
 	computedValSetCache *lru.ARCCache  // computedValSetCache stores the valset is computed from stateDB
+
+	peerScores *peerScores // peerScores scores the peers on the consensus messages they send
+	peerViews  *peerViews  // peerViews tracks the heights and rounds the peers announce in their consensus messages
+	msgCaches  *msgCaches  // msgCaches deduplicates the consensus messages received and sent
+
+	proposalAcks *proposalAcks // proposalAcks tracks the validators which did not acknowledge the proposals sent
+
+	voteBatcher *voteBatcher // voteBatcher packs the votes sent to a peer at once, nil if the batching is disabled
+
+	finalized *finalizedBlocks // finalized notifies the subscribers of the finalized blocks
+
+	commits *servedCommits // commits keeps the commits of the last blocks served to the peers and the requests of theirs
+
+	excluded event.Feed // excluded notifies the subscribers of the candidates excluded by the minimum self-bond
+
+	performances *epochPerformances // performances counts the blocks proposed and signed by the validators per epoch
+
+	evidences *evidences // evidences keeps the evidences of the validators misbehaving, detected or relayed by the peers
+
+	devValidators *devValidators // devValidators are the validators changed by the admin API on a test network
+
+	proposedTxs atomic.Value // proposedTxs is the *proposedTxs of the last proposal, see ProposedTxs
+
+	tracer     *tracing.Tracer // tracer exports the spans of core, nil if the tracing is disabled
+	eventTrace *os.File        // eventTrace records the events handled by core, nil if they are not recorded
 }
 
 // EventMux implements tendermint.Backend.EventMux
@@ -126,14 +206,23 @@ func (sb *Backend) EventMux() *event.TypeMux {
 	return sb.tendermintEventMux
 }
 
+// AddStepHook registers a hook called on every round/step transition of core and returns a function to remove it
+func (sb *Backend) AddStepHook(hook tendermintCore.StepHook) func() {
+	return sb.core.AddStepHook(hook)
+}
+
 // Sign implements tendermint.Backend.Sign
 func (sb *Backend) Sign(data []byte) ([]byte, error) {
 	hashData := crypto.Keccak256(data)
+	sb.keyMu.RLock()
+	defer sb.keyMu.RUnlock()
 	return crypto.Sign(hashData, sb.privateKey)
 }
 
 // Address implements tendermint.Backend.Address
 func (sb *Backend) Address() common.Address {
+	sb.keyMu.RLock()
+	defer sb.keyMu.RUnlock()
 	return sb.address
 }
 
@@ -146,7 +235,7 @@ func (sb *Backend) Broadcast(valSet tendermint.ValidatorSet, blockNumber *big.In
 	}
 	// send to self
 	go func() {
-		if err := sb.checkAndSendMsg(payload); err != nil {
+		if err := sb.checkAndSendMsg(payload, common.Address{}); err != nil {
 			log.Error("failed to post event to self", "error", err)
 		}
 	}()
@@ -155,6 +244,7 @@ func (sb *Backend) Broadcast(valSet tendermint.ValidatorSet, blockNumber *big.In
 
 type broadcastTask struct {
 	Payload     []byte
+	Priority    map[common.Address]bool // the targets the message is sent to directly before the others
 	MinPeers    int
 	TotalPeers  int
 	Targets     map[common.Address]bool
@@ -165,23 +255,30 @@ type broadcastTask struct {
 
 // Gossip implements tendermint.Backend.Gossip
 // It sends message to its validators only, not itself.
-// The validators must be able to connected through Peer.
+// The validators are chosen by the gossip strategy of msgType and must be able to connected through Peer.
 // It will return backend.ErrNoBroadcaster if no broadcaster is set for backend
 func (sb *Backend) Gossip(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
-	targets := make(map[common.Address]bool)
-
-	for _, val := range valSet.List() {
-		if val.Address() != sb.address {
-			targets[val.Address()] = true
-		}
+	var (
+		self     = sb.Address()
+		strategy = tendermintCore.GossipStrategy(sb.config, msgType)
+		targets  = strategy.Targets(valSet, self, self, sb.config.GossipFanout)
+		minPeers = valSet.MinPeers()
+	)
+	// the other strategies rely on every target to relay the message
+	if strategy != tendermint.FullBroadcast {
+		minPeers = len(targets)
 	}
 	if sb.broadcaster == nil {
 		return ErrNoBroadcaster
 	}
+	// our own message echoed back by the peers is a duplicate
+	hash := rLPHash(payload)
+	sb.msgCaches.markRecent(hash)
 	if len(targets) > 0 {
 		task := broadcastTask{
 			Payload:     payload,
-			MinPeers:    valSet.MinPeers(),
+			MinPeers:    minPeers,
+			Priority:    sb.priorityTargets(valSet, targets, msgType),
 			Targets:     targets,
 			TotalPeers:  len(targets),
 			BlockNumber: blockNumber,
@@ -189,10 +286,64 @@ func (sb *Backend) Gossip(valSet tendermint.ValidatorSet, blockNumber *big.Int,
 			MsgType:     msgType,
 		}
 		go sb.gossip(task)
+		if tendermintCore.IsProposal(msgType) && sb.config.ProposalAckTimeout > 0 {
+			go sb.resendUnacked(hash, payload)
+		}
 	}
+	sb.sendToObservers(payload)
 	return nil
 }
 
+// priorityTargets returns the targets a message is sent to before the others:
+// the proposers for votes, and the best scored peers for proposals.
+func (sb *Backend) priorityTargets(valSet tendermint.ValidatorSet, targets map[common.Address]bool, msgType uint64) map[common.Address]bool {
+	if tendermintCore.IsProposal(msgType) {
+		return sb.peerScores.best(targets, proposalRelays(len(targets)))
+	}
+	return proposerTargets(valSet, sb.Address(), msgType)
+}
+
+// proposerTargets returns the current and the next proposers for votes, so that they receive the quorum
+// evidence they need to move on with the least latency. It returns nil for other messages.
+func proposerTargets(valSet tendermint.ValidatorSet, self common.Address, msgType uint64) map[common.Address]bool {
+	if !tendermintCore.IsVote(msgType) || valSet == nil || valSet.GetProposer() == nil {
+		return nil
+	}
+	var (
+		targets    = make(map[common.Address]bool)
+		proposer   = valSet.GetProposer().Address()
+		nextValSet = valSet.Copy()
+	)
+	nextValSet.CalcProposer(proposer, 1)
+	for _, addr := range []common.Address{proposer, nextValSet.GetProposer().Address()} {
+		if addr != self {
+			targets[addr] = true
+		}
+	}
+	return targets
+}
+
+// sendToObservers sends the message to the connected observers, it doesn't retry as observers don't take part in the consensus
+func (sb *Backend) sendToObservers(payload []byte) {
+	if len(sb.config.Observers) == 0 {
+		return
+	}
+	self := sb.Address()
+	targets := make(map[common.Address]bool)
+	for _, addr := range sb.config.Observers {
+		if addr != self {
+			targets[addr] = true
+		}
+	}
+	for addr, p := range sb.broadcaster.FindPeers(targets) {
+		go func(p consensus.Peer, addr common.Address) {
+			if err := sendMsg(p, payload, sb.config.ProtobufMessages); err != nil {
+				log.Debug("failed to send message to observer", "error", err, "addr", addr)
+			}
+		}(p, addr)
+	}
+}
+
 func (sb *Backend) gossip(task broadcastTask) {
 	var (
 		timeSleep   = initialBroadcastSleepTime
@@ -202,7 +353,27 @@ func (sb *Backend) gossip(task broadcastTask) {
 		finalEvtSub = sb.EventMux().Subscribe(tendermint.FinalCommittedEvent{})
 		stopEvtSub  = sb.EventMux().Subscribe(tendermint.StopCoreEvent{})
 		abort       = make(chan struct{})
+		logger      = tendermint.Logger(tendermint.LogBackend).With("block", task.BlockNumber, "round", task.Round, "msg_type", task.MsgType)
 	)
+	// expect the acknowledgements of proposals before sending, as they might arrive before Send returns
+	expectAck := func(consensus.Peer, common.Address) {}
+	if tendermintCore.IsProposal(task.MsgType) {
+		hash := rLPHash(task.Payload)
+		expectAck = func(p consensus.Peer, addr common.Address) {
+			sb.expectAck(p, addr, hash)
+		}
+	}
+	// the first lookup also covers the priority targets, which are sent to before the others
+	lookup := task.Targets
+	if len(task.Priority) > 0 {
+		lookup = make(map[common.Address]bool, len(task.Targets)+len(task.Priority))
+		for addr := range task.Targets {
+			lookup[addr] = true
+		}
+		for addr := range task.Priority {
+			lookup[addr] = true
+		}
+	}
 	defer func() {
 		finalEvtSub.Unsubscribe()
 		stopEvtSub.Unsubscribe()
@@ -217,30 +388,48 @@ func (sb *Backend) gossip(task broadcastTask) {
 				}
 				finalizedBlock := finalEvt.Data.(tendermint.FinalCommittedEvent).BlockNumber
 				if finalizedBlock.Cmp(task.BlockNumber) >= 0 {
-					log.Info("cancel broadcast task because of final event", "task_block", task.BlockNumber, "finalized_block", finalizedBlock)
+					logger.Infow("cancel broadcast task because of final event", "finalized_block", finalizedBlock)
 					close(abort)
 					return
 				}
 			case _ = <-stopEvtSub.Chan():
-				log.Info("cancel broadcast task because core is stopped")
+				logger.Infow("cancel broadcast task because core is stopped")
 				close(abort)
 				return
 			}
 		}
 	}()
 	for {
-		ps := sb.broadcaster.FindPeers(task.Targets)
-		log.Info("find peers", "found_peers", len(ps),
-			"block", task.BlockNumber, "round", task.Round, "msg_type", task.MsgType)
+		ps := sb.broadcaster.FindPeers(lookup)
+		lookup = task.Targets
+		logger.Infow("find peers", "found_peers", len(ps))
+		for addr := range task.Priority {
+			p, ok := ps[addr]
+			if !ok {
+				continue
+			}
+			delete(ps, addr)
+			expectAck(p, addr)
+			if err := sb.send(p, addr, task.MsgType, task.Payload); err != nil {
+				logger.Debugw("failed to send message to priority peer", "error", err, "addr", addr)
+				continue
+			}
+			// the gossip strategy might not target this peer, then it doesn't count
+			if task.Targets[addr] {
+				delete(task.Targets, addr)
+				successSent++
+			}
+		}
+		task.Priority = nil
 		done := make(chan struct{})
 		var wg sync.WaitGroup
 		for addr, p := range ps {
 			wg.Add(1)
 			go func(p consensus.Peer, addr common.Address) {
 				defer wg.Done()
-				if err := p.Send(consensus.TendermintMsg, task.Payload); err != nil {
-					log.Error("failed to send message to peer", "error", err, "addr", addr,
-						"block", task.BlockNumber, "round", task.Round, "msg_type", task.MsgType)
+				expectAck(p, addr)
+				if err := sb.send(p, addr, task.MsgType, task.Payload); err != nil {
+					logger.Errorw("failed to send message to peer", "error", err, "addr", addr)
 					return
 				}
 				mu.Lock()
@@ -255,8 +444,7 @@ func (sb *Backend) gossip(task broadcastTask) {
 		}()
 		select {
 		case <-done:
-			log.Info("gossip to peers", "found_peers", len(ps), "min", task.MinPeers, "success", successSent,
-				"block", task.BlockNumber, "round", task.Round, "msg_type", task.MsgType)
+			logger.Infow("gossip to peers", "found_peers", len(ps), "min", task.MinPeers, "success", successSent)
 			if successSent >= task.MinPeers {
 				return
 			}
@@ -264,7 +452,7 @@ func (sb *Backend) gossip(task broadcastTask) {
 			return
 		}
 		// sleep and retries until success or core abort or new block event
-		log.Info("failed to sent to peer, sleeping", "time_sleep", timeSleep)
+		logger.Infow("failed to sent to peer, sleeping", "time_sleep", timeSleep)
 		select {
 		case <-time.After(timeSleep):
 		case _ = <-abort:
@@ -284,6 +472,8 @@ func (sb *Backend) Multicast(targets map[common.Address]bool, payload []byte) er
 	if sb.broadcaster == nil {
 		return ErrNoBroadcaster
 	}
+	// do not send the message back to the peers it was received from
+	targets = sb.msgCaches.unknownTargets(targets, rLPHash(payload))
 	if len(targets) == 0 {
 		return nil
 	}
@@ -291,6 +481,7 @@ func (sb *Backend) Multicast(targets map[common.Address]bool, payload []byte) er
 		failed   int64 = 0
 		ps             = sb.broadcaster.FindPeers(targets)
 		notFound       = len(targets) - len(ps)
+		code, _        = msgCode(payload)
 	)
 	log.Trace("multicast", "targets", len(targets), "found", len(ps))
 	var wg sync.WaitGroup
@@ -298,7 +489,7 @@ func (sb *Backend) Multicast(targets map[common.Address]bool, payload []byte) er
 		wg.Add(1)
 		go func(addr common.Address, peer consensus.Peer) {
 			defer wg.Done()
-			if err := peer.Send(consensus.TendermintMsg, payload); err != nil {
+			if err := sb.send(peer, addr, code, payload); err != nil {
 				atomic.AddInt64(&failed, 1)
 				log.Debug("failed to send when multicast", "err", err, "addr", addr)
 			}
@@ -316,7 +507,9 @@ func (sb *Backend) Validators(blockNumber *big.Int) tendermint.ValidatorSet {
 	valSet, err := sb.valSetInfo.GetValSet(sb.chain, blockNumber)
 	if err != nil {
 		log.Error("failed to get validator set", "error", err, "block", blockNumber.Int64())
+		return valSet
 	}
+	sb.useNextKey(valSet)
 	return valSet
 }
 
@@ -332,7 +525,8 @@ func (sb *Backend) FindExistingPeers(valSet tendermint.ValidatorSet) map[common.
 }
 
 //Commit implement tendermint.Backend.Commit()
-func (sb *Backend) Commit(block *types.Block) {
+func (sb *Backend) Commit(block *types.Block, round int64) {
+	sb.finalized.recordRound(block.NumberU64(), round)
 	isSent := sb.commitChs.sendBlock(block)
 	// if don't have committed channel to sent, then enqueue for downloading
 	if !isSent {
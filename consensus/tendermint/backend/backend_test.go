@@ -4,6 +4,7 @@ import (
 	"crypto/ecdsa"
 	"math/big"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -302,3 +303,66 @@ func TestBackend_Multicast(t *testing.T) {
 	broadcaster.isSendFailed = true
 	require.EqualError(t, be.Multicast(sentAddrs, []byte(expectedData)), "failed to multicast: failed to send 2 address, not found 0 address")
 }
+
+type recordingBroadcaster struct {
+	mu   sync.Mutex
+	sent []common.Address
+}
+
+func (r *recordingBroadcaster) FindPeers(targets map[common.Address]bool) map[common.Address]consensus.Peer {
+	out := make(map[common.Address]consensus.Peer)
+	for addr := range targets {
+		addr := addr
+		out[addr] = &tests_utils.MockPeer{SendFn: func(data interface{}) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.sent = append(r.sent, addr)
+			return nil
+		}}
+	}
+	return out
+}
+
+func (r *recordingBroadcaster) Enqueue(id string, block *types.Block) {
+	panic("implement me")
+}
+
+func TestBackend_GossipVoteToProposersFirst(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		genesisHeader  = tests_utils.MakeGenesisHeader([]common.Address{nodeAddr})
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, []common.Address{nodeAddr})
+		broadcaster    = &recordingBroadcaster{}
+		valSet         = validator.NewSet([]common.Address{
+			common.HexToAddress("1"),
+			common.HexToAddress("2"),
+			common.HexToAddress("3"),
+			common.HexToAddress("4"),
+			common.HexToAddress("5"),
+			nodeAddr,
+		}, tendermint.RoundRobin, 100)
+		prevote uint64 = 1
+	)
+	be.SetBroadcaster(broadcaster)
+
+	proposers := proposerTargets(valSet, nodeAddr, prevote)
+	require.Len(t, proposers, 2)
+	require.True(t, proposers[valSet.GetProposer().Address()])
+	// proposals are not sent to the proposers first
+	require.Nil(t, proposerTargets(valSet, nodeAddr, 0))
+
+	require.NoError(t, be.Gossip(valSet, big.NewInt(0), 0, prevote, []byte("vote")))
+	require.Eventually(t, func() bool {
+		broadcaster.mu.Lock()
+		defer broadcaster.mu.Unlock()
+		return len(broadcaster.sent) == 5
+	}, time.Second, 10*time.Millisecond)
+
+	// the proposers receive the vote before the others, and only once
+	broadcaster.mu.Lock()
+	defer broadcaster.mu.Unlock()
+	require.True(t, proposers[broadcaster.sent[0]])
+	require.True(t, proposers[broadcaster.sent[1]])
+	require.Len(t, broadcaster.sent, 5)
+}
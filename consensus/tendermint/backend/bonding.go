@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// bondedStakesAddress is the system account the total stakes of the candidates are recorded in at the checkpoint blocks,
+// for the validator selection of the checkpoints of the bonding period after them, see params.TendermintBonding
+var bondedStakesAddress = common.BytesToAddress(crypto.Keccak256([]byte("tendermint-bonded-stakes")))
+
+// bondedStakeSlot returns the slot of the stake of a candidate recorded at the checkpoint
+func bondedStakeSlot(candidate common.Address, checkpoint uint64) common.Hash {
+	return crypto.Keccak256Hash(candidate.Hash().Bytes(), common.BigToHash(new(big.Int).SetUint64(checkpoint)).Bytes())
+}
+
+// bondedStake returns the stake of a candidate counting toward the validator selection of the checkpoint, the lowest of
+// its current stake and the ones recorded in statedb at the checkpoints of the bonding period before
+func bondedStake(config *params.TendermintConfig, statedb *state.StateDB, checkpoint uint64, candidate common.Address, stake *big.Int) *big.Int {
+	bonded := stake
+	for i := uint64(1); i <= config.Bonding.Epochs; i++ {
+		recorded := statedb.GetState(bondedStakesAddress, bondedStakeSlot(candidate, checkpoint-i*config.Epoch)).Big()
+		if recorded.Cmp(bonded) < 0 {
+			bonded = recorded
+		}
+	}
+	return bonded
+}
+
+// recordBondedStakes records the stakes of the candidates in statedb at the checkpoint block number, and drops the ones
+// recorded at the checkpoint leaving the bonding period
+func (sb *Backend) recordBondedStakes(config *params.TendermintConfig, statedb *state.StateDB, number uint64) error {
+	if config.Bonding == nil || number < config.Bonding.Block {
+		return nil
+	}
+	candidates, stakes, err := staking.GetCandidateStakes(statedb, sb.indexConfigs(), *sb.config.StakingSCAddress)
+	if err == staking.ErrEmptyValidatorSet {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// the account holds no balance nor code, a nonce keeps it from being deleted as empty
+	if statedb.GetNonce(bondedStakesAddress) == 0 {
+		statedb.SetNonce(bondedStakesAddress, 1)
+	}
+	period := config.Bonding.Epochs * config.Epoch
+	for _, candidate := range candidates {
+		statedb.SetState(bondedStakesAddress, bondedStakeSlot(candidate, number), common.BigToHash(stakes[candidate]))
+		if number >= config.Bonding.Block+period {
+			statedb.SetState(bondedStakesAddress, bondedStakeSlot(candidate, number-period), common.Hash{})
+		}
+	}
+	return nil
+}
+
+// selectBondedValidators returns the validators of the staking contract at statedb for the checkpoint, the candidates
+// whose owner bonds less than the minimum self-bond being excluded and at most maxSize of the others being selected
+// by their bonded stakes, the maximum of the staking contract if maxSize is nil
+func (sb *Backend) selectBondedValidators(config *params.TendermintConfig, statedb *state.StateDB, checkpoint uint64,
+	maxSize *big.Int) ([]common.Address, error) {
+	var (
+		minSelfBond = config.MinSelfBondAt(checkpoint)
+		excluded    []common.Address
+	)
+	validators, err := staking.GetBondedValidators(statedb, sb.indexConfigs(), sb.stakingContractAddr, maxSize,
+		func(candidate common.Address, stake *big.Int) *big.Int {
+			if minSelfBond != nil && staking.GetCandidateSelfBond(statedb, sb.indexConfigs(), sb.stakingContractAddr, candidate).Cmp(minSelfBond) < 0 {
+				excluded = append(excluded, candidate)
+				return nil
+			}
+			if config.IsBonding(checkpoint) {
+				return bondedStake(config, statedb, checkpoint, candidate, stake)
+			}
+			return stake
+		})
+	if err != nil {
+		return nil, err
+	}
+	if len(excluded) > 0 {
+		log.Info("Excluded the candidates below the minimum self-bond", "checkpoint", checkpoint,
+			"candidates", common.PrettyAddresses(excluded))
+		sb.excluded.Send(tendermint.CandidatesExcludedEvent{Checkpoint: new(big.Int).SetUint64(checkpoint), Candidates: excluded})
+	}
+	return validators, nil
+}
+
+// SubscribeCandidatesExcluded subscribes to the candidates excluded from the validator selection of the checkpoints
+// by the minimum self-bond
+func (sb *Backend) SubscribeCandidatesExcluded(ch chan<- tendermint.CandidatesExcludedEvent) event.Subscription {
+	return sb.excluded.Subscribe(ch)
+}
@@ -0,0 +1,130 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// newStakingState returns a state with a staking contract selecting at most maxSize validators among the candidates,
+// with a stake of at least minStake
+func newStakingState(t *testing.T, stakingSC common.Address, candidates []common.Address, maxSize, minStake int64) *state.StateDB {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	statedb.SetCode(stakingSC, []byte{1})
+	setStakingSlot(statedb, stakingSC, common.BigToHash(big.NewInt(4)), big.NewInt(int64(len(candidates))))
+	for i, candidate := range candidates {
+		loc := crypto.Keccak256Hash(common.BigToHash(big.NewInt(4)).Bytes()).Big()
+		statedb.SetState(stakingSC, common.BigToHash(loc.Add(loc, big.NewInt(int64(i)))), candidate.Hash())
+	}
+	setStakingSlot(statedb, stakingSC, common.BigToHash(big.NewInt(7)), big.NewInt(maxSize))
+	setStakingSlot(statedb, stakingSC, common.BigToHash(big.NewInt(8)), big.NewInt(minStake))
+	return statedb
+}
+
+// candidateSlot returns the slot of a field of the data of the candidate in the staking contract
+func candidateSlot(candidate common.Address, field int64) common.Hash {
+	loc := crypto.Keccak256Hash(candidate.Hash().Bytes(), common.BigToHash(big.NewInt(3)).Bytes()).Big()
+	return common.BigToHash(loc.Add(loc, big.NewInt(field)))
+}
+
+func setStakingSlot(statedb *state.StateDB, stakingSC common.Address, slot common.Hash, value *big.Int) {
+	statedb.SetState(stakingSC, slot, common.BigToHash(value))
+}
+
+func TestBackend_BondedStakes(t *testing.T) {
+	var (
+		stakingSC  = common.HexToAddress("0x11")
+		candidates = []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+		cfg        = *tendermint.DefaultConfig
+		config     = &params.TendermintConfig{
+			Epoch:   10,
+			Bonding: &params.TendermintBonding{Block: 10, Epochs: 2},
+		}
+	)
+	statedb := newStakingState(t, stakingSC, candidates, 1, 10)
+	setStake := func(candidate common.Address, stake int64) {
+		setStakingSlot(statedb, stakingSC, candidateSlot(candidate, 1), big.NewInt(stake))
+	}
+	selected := func(checkpoint uint64) []common.Address {
+		validators, err := staking.GetBondedValidators(statedb, staking.DefaultConfig, stakingSC, nil,
+			func(candidate common.Address, stake *big.Int) *big.Int {
+				return bondedStake(config, statedb, checkpoint, candidate, stake)
+			})
+		require.NoError(t, err)
+		return validators
+	}
+	cfg.StakingSCAddress = &stakingSC
+	be := New(&cfg, tests_utils.MakeNodeKey()).(*Backend)
+
+	// the stakes are recorded from the block of the bonding period
+	setStake(candidates[0], 100)
+	setStake(candidates[1], 50)
+	require.NoError(t, be.recordBondedStakes(config, statedb, 0))
+	require.Equal(t, common.Hash{}, statedb.GetState(bondedStakesAddress, bondedStakeSlot(candidates[0], 0)))
+	require.NoError(t, be.recordBondedStakes(config, statedb, 10))
+
+	// the stake delegated to the second candidate only counts once it is recorded at the 2 checkpoints before
+	setStake(candidates[1], 200)
+	require.NoError(t, be.recordBondedStakes(config, statedb, 20))
+	require.Equal(t, []common.Address{candidates[0]}, selected(30))
+	require.NoError(t, be.recordBondedStakes(config, statedb, 30))
+	require.Equal(t, []common.Address{candidates[1]}, selected(40))
+	require.Equal(t, common.Hash{}, statedb.GetState(bondedStakesAddress, bondedStakeSlot(candidates[1], 10)))
+
+	// while the stake unbonded stops counting at once
+	setStake(candidates[1], 5)
+	require.Equal(t, []common.Address{candidates[0]}, selected(40))
+	require.Equal(t, int64(5), bondedStake(config, statedb, 40, candidates[1], big.NewInt(5)).Int64())
+
+	// the records keep the system account from being deleted as empty
+	require.False(t, statedb.Empty(bondedStakesAddress))
+}
+
+func TestBackend_MinSelfBond(t *testing.T) {
+	var (
+		stakingSC  = common.HexToAddress("0x11")
+		owner      = common.HexToAddress("0x3")
+		candidates = []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+		cfg        = *tendermint.DefaultConfig
+		config     = &params.TendermintConfig{
+			Epoch:       10,
+			MinSelfBond: &params.TendermintMinSelfBond{Block: 20, Stake: big.NewInt(50)},
+		}
+		statedb = newStakingState(t, stakingSC, candidates, 2, 10)
+	)
+	// both candidates have the same total stake, the owner of the first one bonds less than the minimum to it
+	for i, candidate := range candidates {
+		setStakingSlot(statedb, stakingSC, candidateSlot(candidate, 1), big.NewInt(100))
+		setStakingSlot(statedb, stakingSC, candidateSlot(candidate, 2), owner.Hash().Big())
+		voterStake := crypto.Keccak256Hash(owner.Hash().Bytes(), candidateSlot(candidate, 3).Bytes())
+		setStakingSlot(statedb, stakingSC, voterStake, big.NewInt(int64(40+20*i)))
+	}
+	cfg.StakingSCAddress = &stakingSC
+	be := New(&cfg, tests_utils.MakeNodeKey()).(*Backend)
+	be.stakingContractAddr = stakingSC
+	events := make(chan tendermint.CandidatesExcludedEvent, 1)
+	sub := be.SubscribeCandidatesExcluded(events)
+	defer sub.Unsubscribe()
+
+	// the minimum self-bond applies from its checkpoint on
+	validators, err := be.selectBondedValidators(config, statedb, 10, nil)
+	require.NoError(t, err)
+	require.Equal(t, candidates, validators)
+	require.Len(t, events, 0)
+
+	validators, err = be.selectBondedValidators(config, statedb, 20, nil)
+	require.NoError(t, err)
+	require.Equal(t, candidates[1:], validators)
+	require.Equal(t, tendermint.CandidatesExcludedEvent{Checkpoint: big.NewInt(20), Candidates: candidates[:1]}, <-events)
+}
@@ -3,12 +3,13 @@ package backend
 import (
 	"math/rand"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/log"
 )
 
-// checkAndSendMsg decided to send the message or not
-func (sb *Backend) checkAndSendMsg(payload []byte) error {
+// checkAndSendMsg decided to send the message or not, from is the peer which sent it, empty for the messages of this node
+func (sb *Backend) checkAndSendMsg(payload []byte, from common.Address) error {
 	var decidedSendMsg = true
 	if sb.config.FaultyMode == tendermint.RandomlyStopSendingMsg.Uint64() {
 		// randomly stop sending message.
@@ -25,6 +26,7 @@ func (sb *Backend) checkAndSendMsg(payload []byte) error {
 	if decidedSendMsg {
 		return sb.EventMux().Post(tendermint.MessageEvent{
 			Payload: payload,
+			From:    from,
 		})
 	}
 	return nil
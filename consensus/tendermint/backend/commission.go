@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// commissionsAddress is the system account the commissions of the validators are recorded in at the epoch blocks,
+// for the next epoch to bound their change. The record of a candidate is its commission + 1, 0 if none.
+var commissionsAddress = common.BytesToAddress(crypto.Keccak256([]byte("tendermint-commissions")))
+
+// effectiveCommission returns the commission of a candidate for the rewards of the epoch ending at block number, statedb
+// being the state the commissions of the previous epoch are recorded in. requested is the commission the owner sets in the
+// staking contract, see params.TendermintCommission.
+func effectiveCommission(config *params.TendermintConfig, statedb *state.StateDB, number uint64, candidate common.Address, requested *big.Int) uint64 {
+	commission := 100 - config.VoterPercentageAt(number)
+	bounds := config.Commission
+	if bounds == nil || number <= bounds.Block {
+		return commission
+	}
+	if requested != nil && requested.Sign() > 0 {
+		commission = 100
+		if requested.IsUint64() && requested.Uint64() < 100 {
+			commission = requested.Uint64()
+		}
+	}
+	min, max := bounds.Min, bounds.Max
+	if previous := statedb.GetState(commissionsAddress, candidate.Hash()).Big(); bounds.MaxChange > 0 && previous.Sign() > 0 {
+		previous := previous.Uint64() - 1
+		if previous > min+bounds.MaxChange {
+			min = previous - bounds.MaxChange
+		}
+		if previous+bounds.MaxChange < max {
+			max = previous + bounds.MaxChange
+		}
+	}
+	switch {
+	case commission < min:
+		return min
+	case commission > max:
+		return max
+	}
+	return commission
+}
+
+// commissions returns the commissions of the validators for the rewards of the epoch ending at block number and records them
+// in statedb for the next epoch. The commissions requested are read from the staking contract at the state of the checkpoint.
+func (sb *Backend) commissions(config *params.TendermintConfig, statedb, checkpointState *state.StateDB, number uint64,
+	validatorsData map[common.Address]staking.CandidateData) map[common.Address]uint64 {
+	commissions := make(map[common.Address]uint64, len(validatorsData))
+	for candidate := range validatorsData {
+		requested := staking.GetCandidateCommission(checkpointState, sb.indexConfigs(), *sb.config.StakingSCAddress, candidate)
+		commissions[candidate] = effectiveCommission(config, statedb, number, candidate, requested)
+	}
+	if config.Commission == nil || number <= config.Commission.Block {
+		return commissions
+	}
+	// the account holds no balance nor code, a nonce keeps it from being deleted as empty
+	if statedb.GetNonce(commissionsAddress) == 0 {
+		statedb.SetNonce(commissionsAddress, 1)
+	}
+	for candidate, commission := range commissions {
+		statedb.SetState(commissionsAddress, candidate.Hash(), common.BigToHash(new(big.Int).SetUint64(commission+1)))
+	}
+	return commissions
+}
+
+// pendingCommission returns the commission of a candidate for the rewards of the epoch of header, paid at the next epoch block
+func (sb *Backend) pendingCommission(chain consensus.FullChainReader, candidate common.Address, header *types.Header, checkpoint *types.Header) (uint64, error) {
+	statedb, err := chain.StateAt(header.Root)
+	if err != nil {
+		return 0, err
+	}
+	checkpointState, err := chain.StateAt(checkpoint.Root)
+	if err != nil {
+		return 0, err
+	}
+	requested := staking.GetCandidateCommission(checkpointState, sb.indexConfigs(), *sb.config.StakingSCAddress, candidate)
+	number := checkpoint.Number.Uint64() + sb.config.Epoch
+	return effectiveCommission(chain.Config().Tendermint, statedb, number, candidate, requested), nil
+}
+
+// indexConfigs returns the layout of the staking contract storage
+func (sb *Backend) indexConfigs() *staking.IndexConfigs {
+	if sb.config.IndexStateVariables != nil {
+		return sb.config.IndexStateVariables
+	}
+	return staking.DefaultConfig
+}
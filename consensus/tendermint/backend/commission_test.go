@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestBackend_Commissions(t *testing.T) {
+	var (
+		stakingSC = common.HexToAddress("0x11")
+		candidate = common.HexToAddress("0x1")
+		other     = common.HexToAddress("0x2")
+		cfg       = *tendermint.DefaultConfig
+		config    = &params.TendermintConfig{
+			Epoch:      10,
+			Commission: &params.TendermintCommission{Block: 10, Min: 5, Max: 60, MaxChange: 10},
+		}
+		validators = map[common.Address]staking.CandidateData{candidate: {}, other: {}}
+	)
+	newState := func() *state.StateDB {
+		statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+		require.NoError(t, err)
+		return statedb
+	}
+	// the commission is stored after the candidate data in the staking contract
+	setCommission := func(statedb *state.StateDB, commission int64) {
+		loc := crypto.Keccak256Hash(candidate.Hash().Bytes(), common.BigToHash(big.NewInt(3)).Bytes()).Big()
+		loc.Add(loc, big.NewInt(4))
+		statedb.SetState(stakingSC, common.BigToHash(loc), common.BigToHash(big.NewInt(commission)))
+	}
+	cfg.StakingSCAddress = &stakingSC
+	be := New(&cfg, tests_utils.MakeNodeKey()).(*Backend)
+	statedb, checkpointState := newState(), newState()
+	setCommission(checkpointState, 80)
+
+	// the voter percentage of the schedule applies until the block of the commissions
+	commissions := be.commissions(config, statedb, checkpointState, 10, validators)
+	require.Equal(t, map[common.Address]uint64{candidate: 50, other: 50}, commissions)
+	require.Equal(t, common.Hash{}, statedb.GetState(commissionsAddress, candidate.Hash()))
+
+	// then the commissions are bounded, a validator without commission keeps the voter percentage within the bounds
+	commissions = be.commissions(config, statedb, checkpointState, 20, validators)
+	require.Equal(t, map[common.Address]uint64{candidate: 60, other: 50}, commissions)
+
+	// and move at most MaxChange from the recorded ones
+	setCommission(checkpointState, 1)
+	commissions = be.commissions(config, statedb, checkpointState, 30, validators)
+	require.Equal(t, uint64(50), commissions[candidate])
+	commissions = be.commissions(config, statedb, checkpointState, 40, validators)
+	require.Equal(t, uint64(40), commissions[candidate])
+	require.Equal(t, uint64(50), effectiveCommission(config, statedb, 50, candidate, big.NewInt(55)))
+	require.Equal(t, uint64(30), effectiveCommission(config, statedb, 50, candidate, big.NewInt(1)))
+	require.Equal(t, uint64(50), effectiveCommission(config, statedb, 50, candidate, nil))
+
+	// the record keeps the system account from being deleted as empty
+	require.False(t, statedb.Empty(commissionsAddress))
+}
+
+func TestCalculateReward(t *testing.T) {
+	var (
+		validator = common.HexToAddress("0x1")
+		owner     = common.HexToAddress("0x2")
+		voter     = common.HexToAddress("0x3")
+		data      = map[common.Address]staking.CandidateData{validator: {
+			Owner:       owner,
+			TotalStake:  big.NewInt(100),
+			VoterStakes: map[common.Address]*big.Int{owner: big.NewInt(50), voter: big.NewInt(50)},
+		}}
+	)
+	// the owner keeps the commission and its share of the rest as a voter
+	rewards := calculateReward(data, map[common.Address]*big.Int{validator: big.NewInt(1000)}, map[common.Address]uint64{validator: 20})
+	require.Equal(t, map[common.Address]*big.Int{owner: big.NewInt(200 + 400), voter: big.NewInt(400)}, rewards)
+}
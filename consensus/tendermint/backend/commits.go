@@ -0,0 +1,220 @@
+package backend
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+var (
+	// ErrCommitsNotServed is returned when the peer does not answer the requests of commits
+	ErrCommitsNotServed = errors.New("peer does not serve the commits")
+	// ErrCommitNotKept is returned when the peer does not keep the commit of the requested block
+	ErrCommitNotKept = errors.New("peer does not keep the commit of the block")
+	// ErrCommitTimeout is returned when the peer does not answer the request of a commit in time
+	ErrCommitTimeout = errors.New("commit request timed out")
+	// ErrUnexpectedCommit is returned when the peer answers with the header of another block
+	ErrUnexpectedCommit = errors.New("commit of another block")
+)
+
+// commitKey identifies a request of the commit of the block of number to the peer of addr
+type commitKey struct {
+	addr   common.Address
+	number uint64
+}
+
+// servedCommits keeps the headers of the last blocks, whose extra data carries the committed seals, to answer the
+// requests of the peers for the commit at a height. A peer behind can then check that a block was committed, and by
+// which validators, without syncing the headers in between. The headers evicted from memory are read back from the
+// chain database as long as they are among the last heights blocks.
+type servedCommits struct {
+	mu      sync.Mutex
+	heights uint64
+	headers map[uint64]*types.Header
+	pending map[commitKey][]chan *types.Header // requests sent to the peers waiting for their answer
+}
+
+func newServedCommits(heights uint64) *servedCommits {
+	return &servedCommits{
+		heights: heights,
+		headers: make(map[uint64]*types.Header),
+		pending: make(map[commitKey][]chan *types.Header),
+	}
+}
+
+// add keeps the header of a committed block and forgets the headers no longer among the last heights blocks
+func (sc *servedCommits) add(header *types.Header) {
+	if sc.heights == 0 {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	number := header.Number.Uint64()
+	sc.headers[number] = header
+	for kept := range sc.headers {
+		if kept+sc.heights <= number {
+			delete(sc.headers, kept)
+		}
+	}
+}
+
+// get returns the header of the block of number carrying its commit, nil if the block is not among the last heights
+// blocks of chain
+func (sc *servedCommits) get(chain consensus.ChainReader, number uint64) *types.Header {
+	if sc.heights == 0 || number == 0 {
+		return nil
+	}
+	head := chain.CurrentHeader()
+	if head == nil || number > head.Number.Uint64() || number+sc.heights <= head.Number.Uint64() {
+		return nil
+	}
+	sc.mu.Lock()
+	header, ok := sc.headers[number]
+	sc.mu.Unlock()
+	if ok {
+		return header
+	}
+	if header = chain.GetHeaderByNumber(number); header == nil {
+		return nil
+	}
+	sc.add(header)
+	return header
+}
+
+// wait registers a request of the commit of the block of number to the peer of addr
+func (sc *servedCommits) wait(addr common.Address, number uint64) chan *types.Header {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	ch := make(chan *types.Header, 1)
+	key := commitKey{addr: addr, number: number}
+	sc.pending[key] = append(sc.pending[key], ch)
+	return ch
+}
+
+// forget drops the request of ch, answered or not
+func (sc *servedCommits) forget(addr common.Address, number uint64, ch chan *types.Header) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	key := commitKey{addr: addr, number: number}
+	waiting := sc.pending[key][:0]
+	for _, other := range sc.pending[key] {
+		if other != ch {
+			waiting = append(waiting, other)
+		}
+	}
+	if len(waiting) == 0 {
+		delete(sc.pending, key)
+		return
+	}
+	sc.pending[key] = waiting
+}
+
+// answer passes the answer of the peer of addr to the requests of the commit of the block of number, it returns
+// false if there was none
+func (sc *servedCommits) answer(addr common.Address, number uint64, header *types.Header) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	key := commitKey{addr: addr, number: number}
+	waiting, ok := sc.pending[key]
+	if !ok {
+		return false
+	}
+	for _, ch := range waiting {
+		ch <- header
+	}
+	delete(sc.pending, key)
+	return true
+}
+
+// recordCommit keeps the commit of the new chain head to serve it to the peers, sb.mutex must be held
+func (sb *Backend) recordCommit(blockNumber *big.Int) {
+	if sb.chain == nil {
+		return
+	}
+	if header := sb.chain.GetHeaderByNumber(blockNumber.Uint64()); header != nil {
+		sb.commits.add(header)
+	}
+}
+
+// chainReader returns the chain reader the engine was started with, nil if it is not started
+func (sb *Backend) chainReader() consensus.ChainReader {
+	sb.mutex.RLock()
+	defer sb.mutex.RUnlock()
+	return sb.chain
+}
+
+// CommitAt implements consensus.CommitHandler.CommitAt
+func (sb *Backend) CommitAt(number uint64) *types.Header {
+	chain := sb.chainReader()
+	if chain == nil {
+		return nil
+	}
+	return sb.commits.get(chain, number)
+}
+
+// HandleCommit implements consensus.CommitHandler.HandleCommit
+func (sb *Backend) HandleCommit(addr common.Address, number uint64, header *types.Header) {
+	if !sb.commits.answer(addr, number, header) {
+		log.Trace("unrequested commit", "from", addr, "number", number)
+	}
+}
+
+// FetchCommit implements consensus.CommitHandler.FetchCommit, it asks the peer of addr for the commit of the block of
+// number and returns the header carrying it once its proposal seal and committed seals are verified against the
+// validator set of the block, which the node must know.
+func (sb *Backend) FetchCommit(addr common.Address, number uint64, timeout time.Duration) (*types.Header, error) {
+	if sb.broadcaster == nil {
+		return nil, ErrNoBroadcaster
+	}
+	p, ok := sb.broadcaster.FindPeers(map[common.Address]bool{addr: true})[addr].(consensus.CommitPeer)
+	if !ok || !p.Commits() {
+		return nil, ErrCommitsNotServed
+	}
+	ch := sb.commits.wait(addr, number)
+	defer sb.commits.forget(addr, number, ch)
+	if err := p.RequestCommit(number); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case header := <-ch:
+		if header == nil {
+			return nil, ErrCommitNotKept
+		}
+		if err := sb.verifyCommit(number, header); err != nil {
+			return nil, err
+		}
+		return header, nil
+	case <-timer.C:
+		return nil, ErrCommitTimeout
+	}
+}
+
+// verifyCommit checks that header is the header of the block of number, proposed and committed by its validators
+func (sb *Backend) verifyCommit(number uint64, header *types.Header) error {
+	if header.Number == nil || header.Number.Uint64() != number {
+		return ErrUnexpectedCommit
+	}
+	chain := sb.chainReader()
+	if chain == nil {
+		return tendermint.ErrStoppedEngine
+	}
+	valSet, err := sb.valSetInfo.GetValSet(chain, header.Number)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the validator set of the commit")
+	}
+	if err := sb.verifyProposalSeal(header, valSet); err != nil {
+		return err
+	}
+	return sb.verifyCommittedSeals(header, valSet)
+}
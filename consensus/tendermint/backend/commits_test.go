@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+// commitPeer answers the requests of commits with the headers of commitFn
+type commitPeer struct {
+	tests_utils.MockPeer
+	addr     common.Address
+	be       *Backend
+	commitFn func(number uint64) *types.Header
+}
+
+func (p *commitPeer) Commits() bool {
+	return p.commitFn != nil
+}
+
+func (p *commitPeer) RequestCommit(number uint64) error {
+	commitFn := p.commitFn
+	go func() {
+		p.be.HandleCommit(p.addr, number, commitFn(number))
+	}()
+	return nil
+}
+
+type commitBroadcaster struct {
+	peer *commitPeer
+}
+
+func (b *commitBroadcaster) FindPeers(targets map[common.Address]bool) map[common.Address]consensus.Peer {
+	out := make(map[common.Address]consensus.Peer)
+	if targets[b.peer.addr] {
+		out[b.peer.addr] = b.peer
+	}
+	return out
+}
+
+func (b *commitBroadcaster) Enqueue(id string, block *types.Block) {
+	panic("implement me")
+}
+
+func TestServedCommits(t *testing.T) {
+	headers := []*types.Header{{Number: big.NewInt(0)}}
+	for number := int64(1); number <= 5; number++ {
+		headers = append(headers, &types.Header{Number: big.NewInt(number), ParentHash: headers[number-1].Hash()})
+	}
+	chain := tests_utils.NewHeadersMockChainReader(headers)
+
+	commits := newServedCommits(2)
+	for _, header := range headers[1:] {
+		commits.add(header)
+	}
+	require.Len(t, commits.headers, 2)
+	// the last 2 blocks are served, the older ones and the blocks ahead of the head are not
+	for number, served := range map[uint64]bool{0: false, 3: false, 4: true, 5: true, 6: false} {
+		require.Equal(t, served, commits.get(chain, number) != nil, "block %d", number)
+	}
+	// the headers no longer in memory are read from the chain
+	delete(commits.headers, 4)
+	require.Equal(t, headers[4], commits.get(chain, 4))
+
+	// no commit is served if the retention is disabled
+	require.Nil(t, newServedCommits(0).get(chain, 5))
+}
+
+func TestBackend_FetchCommit(t *testing.T) {
+	nodePrivateKey, err := crypto.HexToECDSA("bb047e5940b6d83354d9432db7c449ac8fca2248008aaa7271369880f9f11cc1")
+	require.NoError(t, err)
+	var (
+		nodeAddr      = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators    = []common.Address{nodeAddr}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+		be            = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+		committed     = tests_utils.MustMakeBlockWithCommittedSeal(be, genesisHeader).Header()
+		forged        = tests_utils.MustMakeBlockWithCommittedSealInvalid(be, genesisHeader).Header()
+		peerAddr      = common.HexToAddress("0x02")
+		peer          = &commitPeer{addr: peerAddr, be: be}
+	)
+	be.SetBroadcaster(&commitBroadcaster{peer: peer})
+
+	_, err = be.FetchCommit(peerAddr, 1, time.Second)
+	require.Equal(t, ErrCommitsNotServed, err)
+
+	for _, test := range []struct {
+		name   string
+		header *types.Header
+		err    error
+	}{
+		{name: "committed", header: committed},
+		{name: "not kept", err: ErrCommitNotKept},
+		{name: "another block", header: genesisHeader, err: ErrUnexpectedCommit},
+		{name: "forged seals", header: forged, err: tendermint.ErrInvalidCommittedSeals},
+	} {
+		peer.commitFn = func(number uint64) *types.Header {
+			return test.header
+		}
+		header, err := be.FetchCommit(peerAddr, 1, time.Second)
+		require.Equal(t, test.err, err, test.name)
+		if test.err == nil {
+			require.Equal(t, test.header.Hash(), header.Hash(), test.name)
+		}
+	}
+
+	// a peer which does not answer
+	peer.commitFn = func(number uint64) *types.Header {
+		time.Sleep(100 * time.Millisecond)
+		return committed
+	}
+	_, err = be.FetchCommit(peerAddr, 1, 10*time.Millisecond)
+	require.Equal(t, ErrCommitTimeout, err)
+	require.Empty(t, be.commits.pending)
+}
@@ -0,0 +1,226 @@
+package backend
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// delegatorRewardsAddress is the system account holding the rewards of the voters until they claim them, see
+// params.TendermintDelegatorRewards. A transaction sent to it claims the rewards of its sender, its value is returned.
+var delegatorRewardsAddress = common.BytesToAddress(crypto.Keccak256([]byte("tendermint-delegator-rewards")))
+
+// rewardPerStakePrecision scales the rewards per unit of stake accumulated for the voters of the candidates
+var rewardPerStakePrecision = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// DelegatorRewards are the rewards a voter can claim at a block, by sending a transaction to Address
+type DelegatorRewards struct {
+	Address   common.Address `json:"address"`
+	Claimable *hexutil.Big   `json:"claimable"`
+}
+
+// delegatorRewards reads and updates the records of the delegator rewards account:
+//   - "accumulator", candidate: the rewards per unit of stake its voters earned
+//   - "stake", candidate, voter: the stake + 1 of the voter accruing rewards since its snapshot, 0 if none
+//   - "snapshot", candidate, voter: the accumulator of the candidate when the rewards of the voter were last settled
+//   - "owed", voter: the rewards settled and not claimed yet
+//   - "voters", candidate and "candidates", voter: the voters and the candidates having a stake record, in order
+type delegatorRewards struct {
+	statedb *state.StateDB
+}
+
+// delegatorRewardsSlot returns the slot of a record of the delegator rewards account
+func delegatorRewardsSlot(record string, addrs ...common.Address) common.Hash {
+	data := [][]byte{[]byte(record)}
+	for _, addr := range addrs {
+		data = append(data, addr.Bytes())
+	}
+	return crypto.Keccak256Hash(data...)
+}
+
+func (d delegatorRewards) get(slot common.Hash) *big.Int {
+	return d.statedb.GetState(delegatorRewardsAddress, slot).Big()
+}
+
+func (d delegatorRewards) set(slot common.Hash, value *big.Int) {
+	d.statedb.SetState(delegatorRewardsAddress, slot, common.BigToHash(value))
+}
+
+// list returns the addresses of a list record
+func (d delegatorRewards) list(slot common.Hash) []common.Address {
+	length := d.get(slot).Uint64()
+	addrs := make([]common.Address, length)
+	for i := uint64(0); i < length; i++ {
+		addrs[i] = common.BytesToAddress(d.get(crypto.Keccak256Hash(slot.Bytes(), common.BigToHash(new(big.Int).SetUint64(i)).Bytes())).Bytes())
+	}
+	return addrs
+}
+
+// push appends an address to a list record
+func (d delegatorRewards) push(slot common.Hash, addr common.Address) {
+	length := d.get(slot).Uint64()
+	d.statedb.SetState(delegatorRewardsAddress, crypto.Keccak256Hash(slot.Bytes(), common.BigToHash(new(big.Int).SetUint64(length)).Bytes()), addr.Hash())
+	d.set(slot, new(big.Int).SetUint64(length+1))
+}
+
+// accrued returns the rewards the recorded stake of the voter accrued with the candidate since its snapshot
+func (d delegatorRewards) accrued(candidate, voter common.Address) *big.Int {
+	stake := d.get(delegatorRewardsSlot("stake", candidate, voter))
+	if stake.Sign() == 0 {
+		return new(big.Int)
+	}
+	stake.Sub(stake, common.Big1)
+	accrued := new(big.Int).Sub(d.get(delegatorRewardsSlot("accumulator", candidate)), d.get(delegatorRewardsSlot("snapshot", candidate, voter)))
+	accrued.Mul(accrued, stake)
+	return accrued.Div(accrued, rewardPerStakePrecision)
+}
+
+// settle moves the rewards the voter accrued with the candidate to the rewards it is owed, and records stake
+// as the one accruing from now on
+func (d delegatorRewards) settle(candidate, voter common.Address, stake *big.Int) {
+	stakeSlot := delegatorRewardsSlot("stake", candidate, voter)
+	if d.get(stakeSlot).Sign() == 0 {
+		d.push(delegatorRewardsSlot("voters", candidate), voter)
+		d.push(delegatorRewardsSlot("candidates", voter), candidate)
+	}
+	owedSlot := delegatorRewardsSlot("owed", voter)
+	d.set(owedSlot, new(big.Int).Add(d.get(owedSlot), d.accrued(candidate, voter)))
+	d.set(delegatorRewardsSlot("snapshot", candidate, voter), d.get(delegatorRewardsSlot("accumulator", candidate)))
+	d.set(stakeSlot, new(big.Int).Add(stake, common.Big1))
+}
+
+// accrue adds the reward of the voters of the candidate to its accumulator, shared by the stakes of data.
+// The voters whose stake changed since their record are settled first, so the reward accrues to their new stake.
+func (d delegatorRewards) accrue(candidate common.Address, data staking.CandidateData, reward *big.Int) {
+	voters := make([]common.Address, 0, len(data.VoterStakes))
+	for voter := range data.VoterStakes {
+		voters = append(voters, voter)
+	}
+	sort.Slice(voters, func(i, j int) bool { return bytes.Compare(voters[i].Bytes(), voters[j].Bytes()) < 0 })
+	for _, voter := range voters {
+		stake := data.VoterStakes[voter]
+		if recorded := d.get(delegatorRewardsSlot("stake", candidate, voter)); recorded.Cmp(new(big.Int).Add(stake, common.Big1)) != 0 {
+			d.settle(candidate, voter, stake)
+		}
+	}
+	// the voters which unvoted stop accruing
+	for _, voter := range d.list(delegatorRewardsSlot("voters", candidate)) {
+		if _, ok := data.VoterStakes[voter]; !ok && d.get(delegatorRewardsSlot("stake", candidate, voter)).Cmp(common.Big1) != 0 {
+			d.settle(candidate, voter, new(big.Int))
+		}
+	}
+	perStake := new(big.Int).Mul(reward, rewardPerStakePrecision)
+	perStake.Div(perStake, data.TotalStake)
+	accumulatorSlot := delegatorRewardsSlot("accumulator", candidate)
+	d.set(accumulatorSlot, perStake.Add(perStake, d.get(accumulatorSlot)))
+}
+
+// claimable returns the rewards the voter can claim, from all the candidates it voted for
+func (d delegatorRewards) claimable(voter common.Address) *big.Int {
+	claimable := d.get(delegatorRewardsSlot("owed", voter))
+	for _, candidate := range d.list(delegatorRewardsSlot("candidates", voter)) {
+		claimable.Add(claimable, d.accrued(candidate, voter))
+	}
+	return claimable
+}
+
+// claim settles the rewards of the voter and returns them, they are no longer owed
+func (d delegatorRewards) claim(voter common.Address) *big.Int {
+	for _, candidate := range d.list(delegatorRewardsSlot("candidates", voter)) {
+		stake := d.get(delegatorRewardsSlot("stake", candidate, voter))
+		d.settle(candidate, voter, stake.Sub(stake, common.Big1))
+	}
+	owedSlot := delegatorRewardsSlot("owed", voter)
+	owed := d.get(owedSlot)
+	d.set(owedSlot, new(big.Int))
+	return owed
+}
+
+// accrueDelegatorRewards keeps the rewards of the voters of the validators in the delegator rewards account and returns
+// the rewards paid at once, the commissions to the owners of the validators
+func accrueDelegatorRewards(statedb *state.StateDB, validatorsData map[common.Address]staking.CandidateData,
+	validatorsReward map[common.Address]*big.Int, commissions map[common.Address]uint64) map[common.Address]*big.Int {
+	var (
+		d          = delegatorRewards{statedb: statedb}
+		paid       = make(map[common.Address]*big.Int)
+		candidates = make([]common.Address, 0, len(validatorsData))
+	)
+	for candidate := range validatorsData {
+		candidates = append(candidates, candidate)
+	}
+	// the records of the new voters are appended in order
+	sort.Slice(candidates, func(i, j int) bool { return bytes.Compare(candidates[i].Bytes(), candidates[j].Bytes()) < 0 })
+	// the account may hold no balance, a nonce keeps it from being deleted as empty
+	if statedb.GetNonce(delegatorRewardsAddress) == 0 {
+		statedb.SetNonce(delegatorRewardsAddress, 1)
+	}
+	for _, candidate := range candidates {
+		data := validatorsData[candidate]
+		totalReward, ok := validatorsReward[candidate]
+		if !ok {
+			continue
+		}
+		commission := new(big.Int).Set(totalReward)
+		if data.TotalStake != nil && data.TotalStake.Sign() > 0 {
+			voterReward := new(big.Int).Mul(totalReward, new(big.Int).SetUint64(100-commissions[candidate]))
+			voterReward.Div(voterReward, big.NewInt(100))
+			d.accrue(candidate, data, voterReward)
+			statedb.AddBalance(delegatorRewardsAddress, voterReward)
+			commission.Sub(commission, voterReward)
+		}
+		if current, ok := paid[data.Owner]; ok {
+			commission.Add(commission, current)
+		}
+		paid[data.Owner] = commission
+	}
+	return paid
+}
+
+// claimDelegatorRewards pays the senders of the transactions of the block sent to the delegator rewards account
+// their rewards, and returns them the value of the transactions
+func claimDelegatorRewards(chain consensus.ChainReader, statedb *state.StateDB, header *types.Header, txs []*types.Transaction) {
+	if !chain.Config().Tendermint.IsDelegatorRewards(header.Number.Uint64()) {
+		return
+	}
+	var (
+		d      = delegatorRewards{statedb: statedb}
+		signer = types.MakeSigner(chain.Config(), header.Number)
+	)
+	for _, tx := range txs {
+		if tx.To() == nil || *tx.To() != delegatorRewardsAddress {
+			continue
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Warn("failed to get the sender of the delegator rewards claim", "hash", tx.Hash(), "err", err)
+			continue
+		}
+		amount := new(big.Int).Add(d.claim(sender), tx.Value())
+		statedb.SubBalance(delegatorRewardsAddress, amount)
+		statedb.AddBalance(sender, amount)
+	}
+}
+
+// delegatorRewardsAt returns the rewards the voter can claim at the state of header
+func (sb *Backend) delegatorRewardsAt(chain consensus.FullChainReader, voter common.Address, header *types.Header) (*DelegatorRewards, error) {
+	if !chain.Config().Tendermint.IsDelegatorRewards(header.Number.Uint64()) {
+		return nil, errNoDelegatorRewards
+	}
+	statedb, err := chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegatorRewards{
+		Address:   delegatorRewardsAddress,
+		Claimable: (*hexutil.Big)(delegatorRewards{statedb: statedb}.claimable(voter)),
+	}, nil
+}
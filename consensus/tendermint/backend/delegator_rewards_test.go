@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+type delegatorChain struct {
+	*tests_utils.MockChainReader
+	config *params.ChainConfig
+	state  *state.StateDB
+}
+
+func (c *delegatorChain) Config() *params.ChainConfig {
+	return c.config
+}
+
+func (c *delegatorChain) StateAt(common.Hash) (*state.StateDB, error) {
+	return c.state, nil
+}
+
+func TestBackend_DelegatorRewards(t *testing.T) {
+	var (
+		candidate = common.HexToAddress("0x1")
+		owner     = common.HexToAddress("0x2")
+		voterKey  = tests_utils.MakeNodeKey()
+		voter     = crypto.PubkeyToAddress(voterKey.PublicKey)
+		epoch     = func(stakes map[common.Address]int64) map[common.Address]staking.CandidateData {
+			data := staking.CandidateData{Owner: owner, TotalStake: new(big.Int), VoterStakes: make(map[common.Address]*big.Int)}
+			for addr, stake := range stakes {
+				data.VoterStakes[addr] = big.NewInt(stake)
+				data.TotalStake.Add(data.TotalStake, big.NewInt(stake))
+			}
+			return map[common.Address]staking.CandidateData{candidate: data}
+		}
+		rewards = func(reward int64) map[common.Address]*big.Int {
+			return map[common.Address]*big.Int{candidate: big.NewInt(reward)}
+		}
+		commissions = map[common.Address]uint64{candidate: 10}
+	)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	d := delegatorRewards{statedb: statedb}
+
+	// the owner is paid its commission, the voters' rewards accrue to their stakes
+	paid := accrueDelegatorRewards(statedb, epoch(map[common.Address]int64{owner: 100, voter: 300}), rewards(1000), commissions)
+	require.Equal(t, map[common.Address]*big.Int{owner: big.NewInt(100)}, paid)
+	require.Equal(t, int64(900), statedb.GetBalance(delegatorRewardsAddress).Int64())
+	require.Equal(t, int64(225), d.claimable(owner).Int64())
+	require.Equal(t, int64(675), d.claimable(voter).Int64())
+
+	// a changed stake accrues from the next epoch, the rewards of the previous ones are kept
+	accrueDelegatorRewards(statedb, epoch(map[common.Address]int64{owner: 100, voter: 100}), rewards(1000), commissions)
+	require.Equal(t, int64(675), d.claimable(owner).Int64())
+	require.Equal(t, int64(1125), d.claimable(voter).Int64())
+
+	// and an unvoted one stops accruing
+	accrueDelegatorRewards(statedb, epoch(map[common.Address]int64{owner: 100}), rewards(1000), commissions)
+	require.Equal(t, int64(1575), d.claimable(owner).Int64())
+	require.Equal(t, int64(1125), d.claimable(voter).Int64())
+
+	// the voter claims its rewards with a transaction to the account, the value of the transaction is returned
+	config := &params.ChainConfig{
+		ChainID:     big.NewInt(1),
+		EIP155Block: big.NewInt(0),
+		Tendermint:  &params.TendermintConfig{Epoch: 10, DelegatorRewards: &params.TendermintDelegatorRewards{Block: 10}},
+	}
+	chain := &delegatorChain{config: config, state: statedb}
+	header := &types.Header{Number: big.NewInt(35)}
+	tx, err := types.SignTx(types.NewTransaction(0, delegatorRewardsAddress, big.NewInt(5), 21000, big.NewInt(1), nil),
+		types.MakeSigner(config, header.Number), voterKey)
+	require.NoError(t, err)
+	statedb.AddBalance(delegatorRewardsAddress, big.NewInt(5))
+	claimDelegatorRewards(chain, statedb, header, []*types.Transaction{tx})
+	require.Equal(t, int64(1130), statedb.GetBalance(voter).Int64())
+	require.Equal(t, int64(1575), statedb.GetBalance(delegatorRewardsAddress).Int64())
+
+	be := New(tendermint.DefaultConfig, tests_utils.MakeNodeKey()).(*Backend)
+	info, err := be.delegatorRewardsAt(chain, voter, header)
+	require.NoError(t, err)
+	require.Equal(t, delegatorRewardsAddress, info.Address)
+	require.Equal(t, int64(0), info.Claimable.ToInt().Int64())
+	info, err = be.delegatorRewardsAt(chain, owner, header)
+	require.NoError(t, err)
+	require.Equal(t, int64(1575), info.Claimable.ToInt().Int64())
+
+	// the rewards are paid at the epoch blocks until the block of the delegator rewards
+	_, err = be.delegatorRewardsAt(chain, owner, &types.Header{Number: big.NewInt(10)})
+	require.Equal(t, errNoDelegatorRewards, err)
+}
@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	// errDevValidatorsDisabled is returned when changing the validators without the dev validators mode
+	errDevValidatorsDisabled = errors.New("dev validators mode is disabled")
+	// errFixedValidators is returned when changing the validators of a chain with fixed validators
+	errFixedValidators = errors.New("the validators are fixed by the genesis")
+)
+
+// devValidatorsKey -> rlp encoded changes of the dev validators
+var devValidatorsKey = []byte("tendermint-dev-validators")
+
+// devValidatorChange adds or removes a validator from the epoch block of number From
+type devValidatorChange struct {
+	From    uint64
+	Address common.Address
+	Add     bool
+}
+
+// devValidators keeps the validators added and removed by the admin API on a test network.
+// The changes are applied on top of the validators of the staking contract at every epoch from the next one,
+// they are stored so that a restarted node still agrees on the validators of the epoch blocks.
+type devValidators struct {
+	mu      sync.RWMutex
+	db      evrdb.Database
+	changes []devValidatorChange
+}
+
+// newDevValidators returns the dev validators stored in db, db can be nil
+func newDevValidators(db evrdb.Database) *devValidators {
+	dv := &devValidators{db: db}
+	if db == nil {
+		return dv
+	}
+	blob, err := db.Get(devValidatorsKey)
+	if err != nil {
+		return dv
+	}
+	if err := rlp.DecodeBytes(blob, &dv.changes); err != nil {
+		log.Warn("drop the invalid stored dev validators", "err", err)
+		dv.changes = nil
+		return dv
+	}
+	log.Info("Loaded the dev validators changes", "count", len(dv.changes))
+	return dv
+}
+
+// change records the validator to add or remove from the epoch block of number from
+func (dv *devValidators) change(from uint64, address common.Address, add bool) error {
+	dv.mu.Lock()
+	defer dv.mu.Unlock()
+	changes := append(dv.changes[:len(dv.changes):len(dv.changes)], devValidatorChange{From: from, Address: address, Add: add})
+	if dv.db != nil {
+		blob, err := rlp.EncodeToBytes(changes)
+		if err != nil {
+			return err
+		}
+		if err := dv.db.Put(devValidatorsKey, blob); err != nil {
+			return errors.Wrap(err, "failed to store the dev validators")
+		}
+	}
+	dv.changes = changes
+	return nil
+}
+
+// apply returns the validators of the epoch block of number with the changes applied in order
+func (dv *devValidators) apply(number uint64, validators []common.Address) []common.Address {
+	dv.mu.RLock()
+	defer dv.mu.RUnlock()
+	if len(dv.changes) == 0 {
+		return validators
+	}
+	result := make([]common.Address, 0, len(validators)+len(dv.changes))
+	result = append(result, validators...)
+	for _, c := range dv.changes {
+		if c.From > number {
+			continue
+		}
+		index := -1
+		for i, addr := range result {
+			if addr == c.Address {
+				index = i
+				break
+			}
+		}
+		switch {
+		case c.Add && index == -1:
+			result = append(result, c.Address)
+		case !c.Add && index != -1:
+			result = append(result[:index], result[index+1:]...)
+		}
+	}
+	return result
+}
+
+// changeValidator adds or removes a validator at the epoch following the head, it returns the number of the epoch block.
+// The proposals of the epoch block are checked against the changed validators, every validator of the test network
+// must make the same change.
+func (sb *Backend) changeValidator(head uint64, address common.Address, add bool) (uint64, error) {
+	if !sb.config.DevValidators {
+		return 0, errDevValidatorsDisabled
+	}
+	if len(sb.config.FixedValidators) > 0 {
+		return 0, errFixedValidators
+	}
+	var (
+		epoch = sb.config.Epoch
+		from  = (head/epoch + 1) * epoch
+	)
+	if err := sb.devValidators.change(from, address, add); err != nil {
+		return 0, err
+	}
+	return from, nil
+}
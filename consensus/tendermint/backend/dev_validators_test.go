@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestDevValidators_Apply(t *testing.T) {
+	var (
+		dv   devValidators
+		val1 = common.HexToAddress("0x01")
+		val2 = common.HexToAddress("0x02")
+		val3 = common.HexToAddress("0x03")
+	)
+	staking := []common.Address{val1, val2}
+	require.Equal(t, staking, dv.apply(10, staking))
+
+	require.NoError(t, dv.change(10, val3, true))
+	require.NoError(t, dv.change(20, val1, false))
+	require.Equal(t, staking, dv.apply(5, staking))
+	require.Equal(t, []common.Address{val1, val2, val3}, dv.apply(10, staking))
+	require.Equal(t, []common.Address{val2, val3}, dv.apply(20, staking))
+	// the staking validators are not modified
+	require.Equal(t, []common.Address{val1, val2}, staking)
+}
+
+func TestBackend_ChangeValidator(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		address        = common.HexToAddress("0x01")
+		config         = *tendermint.DefaultConfig
+	)
+	config.Epoch = 10
+	config.FixedValidators = nil
+	be := New(&config, nodePrivateKey).(*Backend)
+	_, err := be.changeValidator(3, address, true)
+	require.Equal(t, errDevValidatorsDisabled, err)
+
+	config.DevValidators = true
+	from, err := be.changeValidator(3, address, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), from)
+	from, err = be.changeValidator(10, address, false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), from)
+
+	config.FixedValidators = []common.Address{address}
+	_, err = be.changeValidator(10, address, true)
+	require.Equal(t, errFixedValidators, err)
+}
+
+func TestDevValidators_Store(t *testing.T) {
+	var (
+		db   = rawdb.NewMemoryDatabase()
+		val1 = common.HexToAddress("0x01")
+		val2 = common.HexToAddress("0x02")
+	)
+	dv := newDevValidators(db)
+	require.NoError(t, dv.change(10, val2, true))
+	require.NoError(t, dv.change(20, val1, false))
+
+	// a restarted node applies the same changes
+	restarted := newDevValidators(db)
+	staking := []common.Address{val1}
+	require.Equal(t, dv.apply(10, staking), restarted.apply(10, staking))
+	require.Equal(t, []common.Address{val2}, restarted.apply(20, staking))
+
+	// the invalid changes are dropped
+	require.NoError(t, db.Put(devValidatorsKey, []byte{0x01}))
+	require.Equal(t, staking, newDevValidators(db).apply(20, staking))
+}
+
+func TestBackend_VerifyDevValidators(t *testing.T) {
+	var (
+		nodePK, _     = crypto.HexToECDSA("bb047e5940b6d83354d9432db7c449ac8fca2248008aaa7271369880f9f11cc1")
+		validators    = []common.Address{crypto.PubkeyToAddress(nodePK.PublicKey)}
+		added         = common.HexToAddress("0x01")
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+		cfg           = *tendermint.DefaultConfig
+	)
+	genesisHeader.Time = uint64(time.Now().Unix()) - 10
+	cfg.FixedValidators = validators
+	cfg.DevValidators = true
+	cfg.Epoch = 1
+	_, engine := mustStartTestChainAndBackend(nodePK, genesisHeader, &cfg)
+	engine.computedValSetCache.Add(uint64(0), validators)
+	require.NoError(t, engine.devValidators.change(1, added, true))
+
+	verifyProposal := func(validators []common.Address) error {
+		header := tests_utils.MakeBlockWithoutSeal(genesisHeader).Header()
+		header.Time = uint64(time.Now().Unix())
+		require.NoError(t, utils.WriteValSet(header, validators))
+		tests_utils.AppendSeal(header, engine)
+		committedSeal, err := engine.Sign(utils.PrepareCommittedSeal(header.Hash()))
+		require.NoError(t, err)
+		tests_utils.AppendCommittedSeal(header, committedSeal)
+		return engine.VerifyProposalHeader(header)
+	}
+	// the validators of the epoch block include the local changes
+	require.Equal(t, tendermint.ErrMismatchValSet, verifyProposal(validators))
+	require.Equal(t, tendermint.ErrMismatchValSet, verifyProposal([]common.Address{added}))
+	require.NoError(t, verifyProposal(append(validators, added)))
+}
@@ -1,15 +1,16 @@
 package backend
 
 import (
-	"bytes"
 	"errors"
 	"math/big"
 	"reflect"
+	"runtime"
 	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
@@ -17,14 +18,11 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/core/vm"
 	"github.com/Evrynetlabs/evrynet-node/log"
-	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/params"
 	"github.com/Evrynetlabs/evrynet-node/rpc"
 )
 
 var (
-	validatorRewardPercentage int64 = 50
-	voterRewardPercentage     int64 = 50
-
 	defaultDifficulty = big.NewInt(1)
 	now               = time.Now
 )
@@ -55,18 +53,23 @@ func (sb *Backend) Seal(chain consensus.ChainReader, block *types.Block, results
 	if blockNumber == 0 {
 		return errors.New("cannot Seal block 0")
 	}
+	// a paused validator doesn't sign blocks, see PrivateTendermintAPI.Pause
+	if sb.core.Paused() {
+		return tendermintCore.ErrPaused
+	}
 	// validate address of the validator
 	valSet, err := sb.valSetInfo.GetValSet(chain, big.NewInt(int64(blockNumber)))
 	if err != nil {
 		return err
 	}
 
+	sb.useNextKey(valSet)
 	if err = sb.addProposalSeal(header); err != nil {
 		return err
 	}
 	block = block.WithSeal(header)
 	// checks the address must stored in snapshot
-	if _, v := valSet.GetByAddress(sb.address); v == nil {
+	if _, v := valSet.GetByAddress(sb.Address()); v == nil {
 		return tendermint.ErrUnauthorized
 	}
 
@@ -134,9 +137,7 @@ func (sb *Backend) tryStartCore() bool {
 	}
 	sb.coreStarted = true
 	// trigger dequeue msg loop
-	go func() {
-		sb.dequeueMsgTriggering <- struct{}{}
-	}()
+	sb.triggerDequeue()
 	return true
 }
 
@@ -209,7 +210,7 @@ func (sb *Backend) Author(header *types.Header) (common.Address, error) {
 // given engine. Verifying the seal may be done optionally here, or explicitly
 // via the VerifySeal method.
 func (sb *Backend) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
-	return sb.verifyHeader(chain, header, nil)
+	return sb.verifyHeader(chain, header, nil, nil)
 }
 
 // VerifyProposalHeader will call be.verifyHeader for checking
@@ -233,26 +234,38 @@ func (sb *Backend) VerifyProposalHeader(header *types.Header) error {
 			log.Info("No validators in the extra-data", err)
 			return err
 		}
+		// in dev validators mode, the validators include the changes made by the admin API of the node
 		if !reflect.DeepEqual(validators, valSetInHeader) {
 			return tendermint.ErrMismatchValSet
 		}
 	}
-	return sb.verifyHeader(sb.chain, header, nil)
+	// the proposer can't skew the timestamp ahead of the local time by more than the max drift,
+	// it can't skew it backward either from the strict timestamp block on since it must be after the parent's
+	if header.Time > uint64(now().Add(sb.config.MaxTimestampDrift).Unix()) {
+		return tendermint.ErrFutureTimestamp
+	}
+	return sb.verifyHeaderFields(sb.chain, header, nil, nil)
 }
 
 // verifyHeader checks whether a header conforms to the consensus rules.The
 // caller may optionally pass in a batch of parents (ascending order) to avoid
 // looking those up from the database. This is useful for concurrently verifying
-// a batch of new headers.
-func (sb *Backend) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
-	if header.Number == nil {
-		return tendermint.ErrUnknownBlock
-	}
-
+// a batch of new headers. The validator set of the header may be passed in as well,
+// it is looked up from the chain and parents if nil.
+func (sb *Backend) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header, valSet tendermint.ValidatorSet) error {
 	// Don't waste time checking blocks from the future
 	if header.Time > big.NewInt(now().Unix()).Uint64() {
 		return consensus.ErrFutureBlock
 	}
+	return sb.verifyHeaderFields(chain, header, parents, valSet)
+}
+
+// verifyHeaderFields checks the fields of a header, except its timestamp against the local time
+// which verifyHeader and VerifyProposalHeader bound differently.
+func (sb *Backend) verifyHeaderFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header, valSet tendermint.ValidatorSet) error {
+	if header.Number == nil {
+		return tendermint.ErrUnknownBlock
+	}
 
 	// Ensure that the extra data format is satisfied
 	if _, err := types.ExtractTendermintExtra(header); err != nil {
@@ -263,19 +276,23 @@ func (sb *Backend) verifyHeader(chain consensus.ChainReader, header *types.Heade
 	if header.MixDigest != types.TendermintDigest {
 		return tendermint.ErrInvalidMixDigest
 	}
+	// Ensure that the block doesn't contain any uncles, which are meaningless with instant finality
+	if header.UncleHash != types.EmptyUncleHash {
+		return tendermint.ErrInvalidUncleHash
+	}
 	// Ensure that the block's difficulty is meaningful (may not be correct at this point)
 	if header.Difficulty == nil || header.Difficulty.Cmp(defaultDifficulty) != 0 {
 		return tendermint.ErrInvalidDifficulty
 	}
 
-	return sb.verifyCascadingFields(chain, header, parents)
+	return sb.verifyCascadingFields(chain, header, parents, valSet)
 }
 
 // verifyCascadingFields verifies all the header fields that are not standalone,
 // rather depend on a batch of previous headers. The caller may optionally pass
 // in a batch of parents (ascending order) to avoid looking those up from the
 // database. This is useful for concurrently verifying a batch of new headers.
-func (sb *Backend) verifyCascadingFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+func (sb *Backend) verifyCascadingFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header, valSet tendermint.ValidatorSet) error {
 	// get block number from header of block
 	blockNumber := header.Number.Uint64()
 	if blockNumber == 0 {
@@ -291,19 +308,30 @@ func (sb *Backend) verifyCascadingFields(chain consensus.ChainReader, header *ty
 	if parent == nil || parent.Number.Uint64() != blockNumber-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
+	if sb.config.IsStrictTimestamp(header.Number) && header.Time <= parent.Time {
+		return tendermint.ErrInvalidTimestamp
+	}
 	if parent.Time+sb.config.BlockPeriod > header.Time {
 		//TODO: find out if tendermint is subject to error when Block Period is too fast
 		//	return errInvalidTimestamp
 		log.Warn("block time difference is too small", "different in ms", header.Time-sb.config.BlockPeriod)
 	}
-	// get val-sets to prepare for the verify proposal and committed seal
-	valSet, err := sb.getValSetFromChain(chain, header, parents)
-	if err != nil {
+	if err := verifyGasLimit(sb.config, header, parent); err != nil {
 		return err
 	}
+	// get val-sets to prepare for the verify proposal and committed seal
+	if valSet == nil {
+		var err error
+		if valSet, err = sb.getValSetFromChain(chain, header, parents); err != nil {
+			return err
+		}
+	}
 	if err := sb.verifyProposalSeal(header, valSet); err != nil {
 		return err
 	}
+	if err := sb.verifyParentCommit(chain, header, parent, parents); err != nil {
+		return err
+	}
 
 	return sb.verifyCommittedSeals(header, valSet)
 }
@@ -317,8 +345,8 @@ func (sb *Backend) getValSetFromChain(chain consensus.ChainReader, header *types
 		number        = header.Number.Uint64() - 1
 		currentHeader = header
 	)
-	// if type of validator set is fixed, then use valsetInfo to get it
-	if len(sb.config.FixedValidators) > 0 {
+	// if type of validator set is fixed or recovered, then use valsetInfo to get it
+	if len(sb.config.FixedValidators) > 0 || tendermint.RecoveryAt(sb.config.Recoveries, sb.config.Epoch, blockNumber) != nil {
 		return sb.valSetInfo.GetValSet(chain, big.NewInt(int64(blockNumber)))
 	}
 	// check if chain contains transition block, get it from historical data
@@ -357,20 +385,84 @@ func (sb *Backend) getValSetFromChain(chain consensus.ChainReader, header *types
 // a results channel to retrieve the async verifications (the order is that of
 // the input slice).
 func (sb *Backend) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
-	abort := make(chan struct{})
-	errorHeaders := make(chan error, len(headers))
-	go func() {
-		for i, header := range headers {
-			err := sb.verifyHeader(chain, header, headers[:i])
+	abort, results := make(chan struct{}), make(chan error, len(headers))
+	if len(headers) == 0 {
+		return abort, results
+	}
+	// Resolve the validator sets ahead, so that the workers only verify the seals
+	valSets := sb.batchValSets(chain, headers)
 
+	// Spawn as many workers as allowed threads
+	workers := runtime.GOMAXPROCS(0)
+	if len(headers) < workers {
+		workers = len(headers)
+	}
+	var (
+		inputs = make(chan int)
+		done   = make(chan int, workers)
+		errs   = make([]error, len(headers))
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for index := range inputs {
+				errs[index] = sb.verifyHeader(chain, headers[index], headers[:index], valSets[index])
+				done <- index
+			}
+		}()
+	}
+	go func() {
+		defer close(inputs)
+		var (
+			in, out = 0, 0
+			checked = make([]bool, len(headers))
+			inputs  = inputs
+		)
+		for {
 			select {
+			case inputs <- in:
+				if in++; in == len(headers) {
+					// Reached end of headers. Stop sending to workers.
+					inputs = nil
+				}
+			case index := <-done:
+				for checked[index] = true; checked[out]; out++ {
+					results <- errs[out]
+					if out == len(headers)-1 {
+						return
+					}
+				}
 			case <-abort:
 				return
-			case errorHeaders <- err:
 			}
 		}
 	}()
-	return abort, errorHeaders
+	return abort, results
+}
+
+// batchValSets returns the validator sets of a batch of headers, a set is resolved once per epoch
+// since all the headers of an epoch are committed by the validators of its checkpoint.
+// The set of a header is nil if it can't be resolved, verifyHeader then looks it up again and reports the error.
+func (sb *Backend) batchValSets(chain consensus.ChainReader, headers []*types.Header) []tendermint.ValidatorSet {
+	var (
+		valSets = make([]tendermint.ValidatorSet, len(headers))
+		epochs  = make(map[uint64]tendermint.ValidatorSet)
+	)
+	for i, header := range headers {
+		if header.Number == nil || header.Number.Sign() == 0 {
+			continue
+		}
+		checkpoint := utils.GetCheckpointNumber(sb.config.Epoch, header.Number.Uint64())
+		valSet, ok := epochs[checkpoint]
+		if !ok {
+			var err error
+			if valSet, err = sb.getValSetFromChain(chain, header, headers[:i]); err != nil {
+				continue
+			}
+			epochs[checkpoint] = valSet
+		}
+		valSets[i] = valSet
+	}
+	return valSets
 }
 
 // VerifyUncles verifies that the given block's uncles conform to the consensus
@@ -403,7 +495,12 @@ func (sb *Backend) VerifySeal(chain consensus.ChainReader, header *types.Header)
 // Prepare initializes the consensus fields of a block header according to the
 // rules of a particular engine. The changes are executed inline.
 func (sb *Backend) Prepare(chain consensus.FullChainReader, header *types.Header) error {
-	// set coinbase with the proposer's address
+	// set coinbase with the proposer's address, which is the next key once the validators are rotated to it
+	if sb.hasNextKey() {
+		if valSet, err := sb.valSetInfo.GetValSet(chain, header.Number); err == nil {
+			sb.useNextKey(valSet)
+		}
+	}
 	header.Coinbase = sb.Address()
 	// use the same difficulty and mixDigest for all blocks
 	header.MixDigest = types.TendermintDigest
@@ -419,6 +516,10 @@ func (sb *Backend) Prepare(chain consensus.FullChainReader, header *types.Header
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
+	// move the gas limit toward the target of this validator instead of the miner gas floor and ceiling
+	if sb.config.GasTarget != 0 {
+		header.GasLimit = gasLimitTowards(parent, sb.config.GasTarget)
+	}
 
 	// prepare extra data without validators
 	header.Extra = sb.prepareExtra(header)
@@ -435,10 +536,17 @@ func (sb *Backend) Prepare(chain consensus.FullChainReader, header *types.Header
 	} else {
 		header.Time = headerTime.Uint64()
 	}
+	// the timestamp must be strictly after the parent's, even without block period
+	if header.Time <= parent.Time {
+		header.Time = parent.Time + 1
+	}
 
 	if err := sb.addValSetToHeader(chain, header, parent); err != nil {
 		log.Error("failed to add val set to header", "err", err)
 	}
+	if err := sb.addParentCommitToHeader(header, parent); err != nil {
+		log.Error("failed to add parent commit to header", "err", err)
+	}
 
 	return nil
 }
@@ -454,6 +562,7 @@ func (sb *Backend) Finalize(chain consensus.FullChainReader, header *types.Heade
 		log.Error("failed to accumulateRewards", "err", err)
 		return err
 	}
+	claimDelegatorRewards(chain, state, header, txs)
 
 	// Since there is a change in stateDB, its trie must be update
 	// In case block reached EIP158 hash, the state will attempt to delete empty object as EIP158 sepcification
@@ -473,6 +582,7 @@ func (sb *Backend) FinalizeAndAssemble(chain consensus.FullChainReader, header *
 		log.Error("failed to accumulateRewards", "err", err)
 		return nil, err
 	}
+	claimDelegatorRewards(chain, state, header, txs)
 
 	// No block rewards, so the state remains as is and uncles are dropped
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
@@ -492,19 +602,28 @@ func (sb *Backend) CalcDifficulty(chain consensus.ChainReader, time uint64, pare
 	return defaultDifficulty
 }
 
-// APIs will expose some RPC API methods
+// APIs will expose some RPC API methods.
+// The methods managing the node are in their own namespace, which is only served over IPC unless it is whitelisted.
 func (sb *Backend) APIs(chain consensus.ChainReader) []rpc.API {
 	return []rpc.API{{
 		Namespace: "tendermint",
 		Version:   "1.0",
 		Service:   &TendermintAPI{chain: chain, be: sb},
 		Public:    true,
+	}, {
+		Namespace: "tendermintadmin",
+		Version:   "1.0",
+		Service:   &PrivateTendermintAPI{chain: chain, be: sb},
+		Public:    false,
 	}}
 }
 
 func (sb *Backend) Close() error {
-	log.Warn("Close: implement me")
-	//TODO: Research & Implement
+	// export the spans which are still queued
+	sb.tracer.Stop()
+	if sb.eventTrace != nil {
+		return sb.eventTrace.Close()
+	}
 	return nil
 }
 
@@ -542,9 +661,10 @@ func (sb *Backend) verifyCommittedSeals(header *types.Header, valSet tendermint.
 	}
 
 	vals := valSet.Copy()
+	signed := make(map[common.Address]bool)
 	// Check whether the committed seals are generated by parent's validators
 	validSeal := 0
-	proposalSeal := utils.PrepareCommittedSeal(header.Hash())
+	proposalSeal := utils.PrepareCommittedSealAt(sb.config, header.Number, header.Hash())
 	// 1. Get committed seals from current header
 	for _, seal := range extra.CommittedSeal {
 		// 2. Get the original address by seal and parent block hash
@@ -556,17 +676,76 @@ func (sb *Backend) verifyCommittedSeals(header *types.Header, valSet tendermint.
 		// Every validator can have only one seal. If more than one seals are signed by a
 		// validator, the validator cannot be found and errInvalidCommittedSeals is returned.
 		if vals.RemoveValidator(addr) {
-			validSeal++
+			validSeal += valSet.VotingPower(addr)
+			signed[addr] = true
 		} else {
 			return tendermint.ErrInvalidCommittedSeals
 		}
 	}
 
-	// The length of validSeal should be larger or equal than min majority (num validator - maximum faulty)
-	if validSeal < valSet.MinMajority() {
+	// The voting power of validSeal should be larger or equal than min majority (num validator - maximum faulty)
+	if validSeal < valSet.QuorumPower() {
 		return tendermint.ErrInvalidCommittedSeals
 	}
 
+	return verifyMissingValidators(extra.MissingValidators, valSet, signed)
+}
+
+// verifyParentCommit checks the parent commit of a header. It is empty unless the committers of the parent earn a share
+// of the block reward, then every seal is signed for the parent by one of the parent's validators and they are a quorum.
+// The parent's validators are looked up like the header's, from the chain and the batch of parents.
+func (sb *Backend) verifyParentCommit(chain consensus.ChainReader, header *types.Header, parent *types.Header, parents []*types.Header) error {
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return err
+	}
+	if !hasParentCommit(sb.config.RewardSchedule, header.Number.Uint64()) {
+		if len(extra.ParentCommit) > 0 {
+			return tendermint.ErrInvalidParentCommit
+		}
+		return nil
+	}
+	if len(parents) > 0 {
+		parents = parents[:len(parents)-1]
+	}
+	valSet, err := sb.getValSetFromChain(chain, parent, parents)
+	if err != nil {
+		return err
+	}
+	signers, err := sealSigners(sb.config, new(big.Int).Sub(header.Number, common.Big1), header.ParentHash, extra.ParentCommit)
+	if err != nil {
+		return err
+	}
+	var (
+		vals  = valSet.Copy()
+		power int
+	)
+	for _, addr := range signers {
+		if !vals.RemoveValidator(addr) {
+			return tendermint.ErrInvalidParentCommit
+		}
+		power += valSet.VotingPower(addr)
+	}
+	if power < valSet.QuorumPower() {
+		return tendermint.ErrInvalidParentCommit
+	}
+	return nil
+}
+
+// verifyMissingValidators checks whether the missing validators bitmap is consistent with the signers of committed seals.
+// Blocks which are committed without the bitmap are skipped.
+func verifyMissingValidators(bitmap []byte, valSet tendermint.ValidatorSet, signed map[common.Address]bool) error {
+	if len(bitmap) == 0 {
+		return nil
+	}
+	if len(bitmap) != (valSet.Size()+7)/8 {
+		return tendermint.ErrInvalidMissingValidators
+	}
+	for i, val := range valSet.List() {
+		if utils.IsValidatorMissing(bitmap, i) == signed[val.Address()] {
+			return tendermint.ErrInvalidMissingValidators
+		}
+	}
 	return nil
 }
 
@@ -608,7 +787,44 @@ func (sb *Backend) addValSetToHeader(chainReader consensus.FullChainReader, head
 	return utils.WriteValSet(header, validators)
 }
 
+// hasParentCommit returns whether the block number carries the commit of its parent: the committers of the parent earn
+// a share of the block reward in the schedule, and the parent isn't the genesis which has no committed seals
+func hasParentCommit(schedule []params.TendermintRewardStage, number uint64) bool {
+	config := params.TendermintConfig{RewardSchedule: schedule}
+	return number > 1 && config.CommitterPercentageAt(number) > 0
+}
+
+// addParentCommitToHeader copies the committed seals of the parent into the header when they earn a share of the block reward,
+// the committed seals of a block are not part of its hash so the rewards are computed from the copy every node agrees on
+func (sb *Backend) addParentCommitToHeader(header *types.Header, parent *types.Header) error {
+	if !hasParentCommit(sb.config.RewardSchedule, header.Number.Uint64()) {
+		return nil
+	}
+	extra, err := types.ExtractTendermintExtra(parent)
+	if err != nil {
+		return err
+	}
+	return utils.WriteParentCommit(header, extra.CommittedSeal)
+}
+
 func (sb *Backend) getNextValidatorSet(chainReader consensus.FullChainReader, header *types.Header) ([]common.Address, error) {
+	checkpoint := header.Number.Uint64() + 1
+	if recovery := tendermint.RecoveryAt(sb.config.Recoveries, sb.config.Epoch, checkpoint); recovery != nil {
+		// the checkpoint ending a recovery keeps its validators for the next epoch
+		return append([]common.Address{}, recovery.Validators...), nil
+	}
+	validators, err := sb.getStakingValidatorSet(chainReader, header)
+	if err != nil {
+		return nil, err
+	}
+	validators = sb.devValidators.apply(checkpoint, validators)
+	return tendermint.RotateValidators(sb.config.KeyRotations, checkpoint, validators), nil
+}
+
+// getStakingValidatorSet returns the validators of the staking contract at the state of header, selected by their
+// bonded stakes and self-bonds once the bonding period or the minimum self-bond applies to the next checkpoint,
+// up to the maximum number of validators of the params contract if it sets one
+func (sb *Backend) getStakingValidatorSet(chainReader consensus.FullChainReader, header *types.Header) ([]common.Address, error) {
 	if validators, known := sb.computedValSetCache.Get(header.Number.Uint64()); known {
 		if addresses, ok := validators.([]common.Address); ok {
 			return addresses, nil
@@ -620,8 +836,17 @@ func (sb *Backend) getNextValidatorSet(chainReader consensus.FullChainReader, he
 		return nil, err
 	}
 
-	stakingCaller := sb.getStakingCaller(chainReader, stateDB, header)
-	validators, err := stakingCaller.GetValidators(sb.stakingContractAddr)
+	var (
+		validators    []common.Address
+		config        = chainReader.Config().Tendermint
+		checkpoint    = header.Number.Uint64() + 1
+		maxValidators = maxValidatorsAt(config, stateDB, checkpoint)
+	)
+	if config.IsBonding(checkpoint) || config.MinSelfBondAt(checkpoint) != nil || maxValidators != nil {
+		validators, err = sb.selectBondedValidators(config, stateDB, checkpoint, maxValidators)
+	} else {
+		validators, err = sb.getStakingCaller(chainReader, stateDB, header).GetValidators(sb.stakingContractAddr)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -646,23 +871,9 @@ func (sb *Backend) getStakingCaller(chainReader consensus.FullChainReader, state
 }
 
 func (sb *Backend) prepareExtra(header *types.Header) []byte {
-	var (
-		tdm     *types.TendermintExtra
-		payload []byte
-		buf     bytes.Buffer
-		err     error
-	)
-
-	if len(header.Extra) < types.TendermintExtraVanity {
-		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, types.TendermintExtraVanity-len(header.Extra))...)
-	}
-	buf.Write(header.Extra[:types.TendermintExtraVanity])
-
-	tdm = &types.TendermintExtra{}
-	payload, err = rlp.EncodeToBytes(&tdm)
-
+	extra, err := (&types.TendermintExtra{}).Encode(header.Extra)
 	if err != nil {
 		log.Error("failed to encode payload to Tendermint extra", "error", err)
 	}
-	return append(buf.Bytes(), payload...)
+	return extra
 }
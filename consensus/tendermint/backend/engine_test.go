@@ -18,6 +18,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/crypto/secp256k1"
+	"github.com/Evrynetlabs/evrynet-node/params"
 )
 
 // TestSimulateSubscribeAndReceiveToSeal is a simple test to pass a block to backend.Seal()
@@ -196,6 +197,7 @@ func TestBackend_VerifyHeaders(t *testing.T) {
 		Number:     big.NewInt(0),
 		Root:       common.HexToHash("0x0"),
 		ParentHash: common.HexToHash("0x0"),
+		GasLimit:   params.GenesisGasLimit,
 	}
 	extra, _ := tests_utils.PrepareExtra(header0)
 	header0.Extra = extra
@@ -207,9 +209,11 @@ func TestBackend_VerifyHeaders(t *testing.T) {
 			Coinbase:   addr,
 			Number:     big.NewInt(number),
 			ParentHash: parent,
+			UncleHash:  types.EmptyUncleHash,
 			Root:       common.BytesToHash(big.NewInt(number).Bytes()),
-			GasLimit:   0,
+			GasLimit:   params.GenesisGasLimit,
 			GasUsed:    0,
+			Time:       uint64(number),
 			Difficulty: big.NewInt(1),
 			MixDigest:  types.TendermintDigest,
 		}
@@ -275,4 +279,18 @@ func TestBackend_VerifyHeaders(t *testing.T) {
 		re := <-results
 		require.NoError(t, re)
 	}
+
+	// a header sealed by a validator of another epoch is reported at its position in the batch
+	badHeaders := append([]*types.Header{}, verifiedHeader...)
+	badHeaders[2] = createHeader(pk3, badHeaders[2].Number.Int64(), badHeaders[2].ParentHash, nil)
+	badHeaders[3] = createHeader(pk2, badHeaders[3].Number.Int64(), badHeaders[2].Hash(), nil)
+	abort2, results2 := be.VerifyHeaders(chainReader, badHeaders[:4], nil)
+	defer close(abort2)
+	for i := 0; i < 4; i++ {
+		if i == 2 {
+			require.Equal(t, tendermint.ErrUnauthorized, <-results2)
+		} else {
+			require.NoError(t, <-results2)
+		}
+	}
 }
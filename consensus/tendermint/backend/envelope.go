@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/p2p"
+)
+
+// sendMsg sends a consensus message to a peer, in an envelope if the peer decodes them and bare otherwise.
+// The bare messages are sent without their trace id, the peers which don't decode envelopes reject it.
+// If protobuf is true, the envelope is protobuf encoded for the peers which decode it.
+// The message is not sent to a peer of a lower version than its kind, as the peer could not handle it.
+// A payload whose code can't be read is sent bare, the peer judges it.
+func sendMsg(p consensus.Peer, payload []byte, protobuf bool) error {
+	code, ok := msgCode(payload)
+	if !ok {
+		return p.Send(consensus.TendermintMsg, payload)
+	}
+	var peerVersion uint64
+	if envelopePeer, ok := p.(consensus.EnvelopePeer); ok {
+		peerVersion = envelopePeer.EnvelopeVersion()
+	}
+	if version, _ := tendermintCore.MsgVersion(code); version > peerVersion {
+		log.Trace("skip a consensus message the peer does not know", "to", p.Address(), "code", code, "version", version)
+		return nil
+	}
+	if peerVersion == 0 {
+		return p.Send(consensus.TendermintMsg, tendermintCore.StripTraceID(payload))
+	}
+	if protobufPeer, ok := p.(consensus.ProtobufPeer); ok && protobuf && protobufPeer.Protobuf() {
+		envelope, err := tendermintCore.EncodeProtobuf(code, payload)
+		if err != nil {
+			return err
+		}
+		return p.Send(consensus.TendermintProtobufMsg, envelope)
+	}
+	envelope, err := tendermintCore.EncodeEnvelope(code, payload)
+	if err != nil {
+		return err
+	}
+	return p.Send(consensus.TendermintEnvelopeMsg, envelope)
+}
+
+// handleEnvelope handles the message of an envelope, RLP or protobuf encoded, as if the peer had sent it bare.
+// The messages of the kinds this node doesn't know are dropped, they come from a peer of a later version.
+func (sb *Backend) handleEnvelope(addr common.Address, msg p2p.Msg) error {
+	data, _, err := sb.decode(msg)
+	if err != nil {
+		log.Error("failed to decode message from p2p.Msg", "err", err)
+		return err
+	}
+	decode := tendermintCore.DecodeEnvelope
+	if msg.Code == consensus.TendermintProtobufMsg {
+		decode = tendermintCore.DecodeProtobuf
+	}
+	envelope, err := decode(data)
+	if err != nil {
+		log.Debug("received an invalid consensus envelope", "from", addr, "err", err)
+		return sb.peerScores.invalid(addr)
+	}
+	if _, known := tendermintCore.MsgVersion(envelope.Code); !known {
+		log.Trace("drop a consensus message of an unknown kind", "from", addr, "code", envelope.Code, "version", envelope.Version)
+		return nil
+	}
+	if code, ok := msgCode(envelope.Payload); !ok || code != envelope.Code {
+		log.Debug("received an invalid consensus message", "from", addr)
+		return sb.peerScores.invalid(addr)
+	}
+	// the message is deduplicated with the same hash as if it was received bare
+	return sb.handlePayload(addr, envelope.Code, envelope.Payload, rLPHash(envelope.Payload))
+}
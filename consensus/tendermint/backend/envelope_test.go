@@ -0,0 +1,116 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+type envelopePeer struct {
+	tests_utils.MockPeer
+	version  uint64
+	protobuf bool
+	sent     map[uint64][]byte
+}
+
+func (p *envelopePeer) Send(msgCode uint64, data interface{}) error {
+	p.sent[msgCode] = data.([]byte)
+	return nil
+}
+
+func (p *envelopePeer) EnvelopeVersion() uint64 {
+	return p.version
+}
+
+func (p *envelopePeer) Protobuf() bool {
+	return p.protobuf
+}
+
+func TestSendMsg(t *testing.T) {
+	payload := makeConsensusPayload(1)
+
+	// the older peers receive the message bare
+	legacy := &envelopePeer{sent: make(map[uint64][]byte)}
+	require.NoError(t, sendMsg(legacy, payload, false))
+	require.Equal(t, map[uint64][]byte{consensus.TendermintMsg: payload}, legacy.sent)
+
+	// the others in an envelope
+	peer := &envelopePeer{version: tendermintCore.EnvelopeVersion, sent: make(map[uint64][]byte)}
+	require.NoError(t, sendMsg(peer, payload, false))
+	envelope, err := tendermintCore.DecodeEnvelope(peer.sent[consensus.TendermintEnvelopeMsg])
+	require.NoError(t, err)
+	require.Equal(t, tendermintCore.EnvelopeVersion, envelope.Version)
+	require.Equal(t, payload, envelope.Payload)
+
+	// protobuf encoded if the node enables it and the peer decodes it
+	peer = &envelopePeer{version: tendermintCore.EnvelopeVersion, protobuf: true, sent: make(map[uint64][]byte)}
+	require.NoError(t, sendMsg(peer, payload, false))
+	require.Contains(t, peer.sent, uint64(consensus.TendermintEnvelopeMsg))
+	require.NoError(t, sendMsg(peer, payload, true))
+	envelope, err = tendermintCore.DecodeProtobuf(peer.sent[consensus.TendermintProtobufMsg])
+	require.NoError(t, err)
+	require.Equal(t, payload, envelope.Payload)
+
+	// the trace id of a message is only sent in the envelopes
+	traced, err := rlp.EncodeToBytes(struct {
+		Code      uint64
+		Msg       []byte
+		Address   common.Address
+		Signature []byte
+		TraceID   string
+	}{Msg: []byte("1"), TraceID: "trace"})
+	require.NoError(t, err)
+	legacy = &envelopePeer{sent: make(map[uint64][]byte)}
+	require.NoError(t, sendMsg(legacy, traced, false))
+	require.Equal(t, map[uint64][]byte{consensus.TendermintMsg: payload}, legacy.sent)
+	peer = &envelopePeer{version: tendermintCore.EnvelopeVersion, sent: make(map[uint64][]byte)}
+	require.NoError(t, sendMsg(peer, traced, false))
+	envelope, err = tendermintCore.DecodeEnvelope(peer.sent[consensus.TendermintEnvelopeMsg])
+	require.NoError(t, err)
+	require.Equal(t, traced, envelope.Payload)
+}
+
+func TestBackend_HandleEnvelope(t *testing.T) {
+	var (
+		be      = New(tendermint.DefaultConfig, tests_utils.MakeNodeKey()).(*Backend)
+		addr    = common.HexToAddress("0x1")
+		payload = makeConsensusPayload(1)
+	)
+	envelope, err := tendermintCore.EncodeEnvelope(0, payload)
+	require.NoError(t, err)
+	_, err = be.HandleMsg(addr, makeMsg(consensus.TendermintEnvelopeMsg, envelope))
+	require.NoError(t, err)
+	require.Equal(t, 1, be.storingMsgs.GetLen())
+
+	// the bare message is the same one, and so is the protobuf one
+	_, err = be.HandleMsg(addr, makeMsg(consensus.TendermintMsg, payload))
+	require.NoError(t, err)
+	require.Equal(t, 1, be.storingMsgs.GetLen())
+	protobuf, err := tendermintCore.EncodeProtobuf(0, payload)
+	require.NoError(t, err)
+	_, err = be.HandleMsg(addr, makeMsg(consensus.TendermintProtobufMsg, protobuf))
+	require.NoError(t, err)
+	require.Equal(t, 1, be.storingMsgs.GetLen())
+
+	// a kind of a later version is dropped, the peer is not penalized
+	later, err := rlp.EncodeToBytes(&tendermintCore.Envelope{Version: 2, Code: 0xff, Payload: []byte{0x01}, Extra: []rlp.RawValue{{0x80}}})
+	require.NoError(t, err)
+	_, err = be.HandleMsg(addr, makeMsg(consensus.TendermintEnvelopeMsg, later))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), be.peerScores.snapshot()[addr].Invalid)
+
+	// but the code of the envelope must be the one of its message
+	envelope, err = tendermintCore.EncodeEnvelope(1, payload)
+	require.NoError(t, err)
+	_, err = be.HandleMsg(addr, makeMsg(consensus.TendermintEnvelopeMsg, envelope))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), be.peerScores.snapshot()[addr].Invalid)
+	require.Equal(t, 1, be.storingMsgs.GetLen())
+}
@@ -0,0 +1,368 @@
+package backend
+
+import (
+	"math/big"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+)
+
+const maxEvidences = 256 // number of evidences kept
+
+var (
+	duplicateProposalMeter = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/duplicateproposals", nil)
+	amnesiaMeter           = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/amnesias", nil)
+	lunaticMeter           = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/lunatics", nil)
+	expiredEvidenceMeter   = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/expiredevidences", nil)
+)
+
+// duplicateProposalKey identifies the round a proposer signed several blocks for,
+// the evidences of a round are equivalent so only the first one is kept and relayed
+type duplicateProposalKey struct {
+	proposer common.Address
+	number   uint64
+	round    int64
+}
+
+// amnesiaKey identifies the block number a validator prevoted contrary to its lock at,
+// only the first evidence of a block number is kept and relayed
+type amnesiaKey struct {
+	validator common.Address
+	number    uint64
+}
+
+// lunaticKey identifies the block number a validator precommitted an invalid block at,
+// only the first evidence of a block number is kept and relayed
+type lunaticKey struct {
+	validator common.Address
+	number    uint64
+}
+
+// lunatic is a *tendermintCore.Lunatic whose header is verified to be invalid
+type lunatic struct {
+	*tendermintCore.Lunatic
+	fault error // the validity check the header fails
+}
+
+// evidenceEntry is an evidence kept with the block number of the misbehavior, to expire it
+type evidenceEntry struct {
+	evidence interface{}
+	number   uint64
+}
+
+// evidences keeps the recent evidences of the validators misbehaving: the *tendermintCore.DuplicateProposal,
+// *tendermintCore.Amnesia and *lunatic. The staking contract has no slashing entry point yet, the evidences are kept
+// to be submitted to it until they expire, see Backend.evidenceExpiry. They are stored in the database
+// so that a restart doesn't drop them.
+type evidences struct {
+	cache *lru.Cache     // duplicateProposalKey, amnesiaKey or lunaticKey -> *evidenceEntry
+	db    evrdb.Database // db is nil if the evidences are kept in memory only
+}
+
+// newEvidences returns an evidence pool which stores to db, db can be nil
+func newEvidences(db evrdb.Database) *evidences {
+	e := &evidences{db: db}
+	e.cache, _ = lru.NewWithEvict(maxEvidences, e.evicted)
+	return e
+}
+
+// add records the evidence of key misbehaving at the block number, it returns false if an evidence of key is already known
+func (e *evidences) add(key interface{}, number uint64, evidence interface{}) bool {
+	if ok, _ := e.cache.ContainsOrAdd(key, &evidenceEntry{evidence: evidence, number: number}); ok {
+		return false
+	}
+	e.store(evidence)
+	return true
+}
+
+// list returns the evidences kept, the oldest first
+func (e *evidences) list() []interface{} {
+	var list []interface{}
+	for _, key := range e.cache.Keys() {
+		if entry, ok := e.cache.Peek(key); ok {
+			list = append(list, entry.(*evidenceEntry).evidence)
+		}
+	}
+	return list
+}
+
+// prune removes the evidences of the block numbers expired, it returns the number of evidences removed
+func (e *evidences) prune(expired func(number uint64) bool) int {
+	var pruned int
+	for _, key := range e.cache.Keys() {
+		if entry, ok := e.cache.Peek(key); ok && expired(entry.(*evidenceEntry).number) {
+			e.cache.Remove(key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// duplicateProposals returns the duplicate proposals kept, the oldest first
+func (e *evidences) duplicateProposals() []*tendermintCore.DuplicateProposal {
+	var duplicates []*tendermintCore.DuplicateProposal
+	for _, evidence := range e.list() {
+		if duplicate, ok := evidence.(*tendermintCore.DuplicateProposal); ok {
+			duplicates = append(duplicates, duplicate)
+		}
+	}
+	return duplicates
+}
+
+// amnesias returns the amnesias kept, the oldest first
+func (e *evidences) amnesias() []*tendermintCore.Amnesia {
+	var amnesias []*tendermintCore.Amnesia
+	for _, evidence := range e.list() {
+		if amnesia, ok := evidence.(*tendermintCore.Amnesia); ok {
+			amnesias = append(amnesias, amnesia)
+		}
+	}
+	return amnesias
+}
+
+// lunatics returns the lunatics kept, the oldest first
+func (e *evidences) lunatics() []*lunatic {
+	var lunatics []*lunatic
+	for _, evidence := range e.list() {
+		if lunatic, ok := evidence.(*lunatic); ok {
+			lunatics = append(lunatics, lunatic)
+		}
+	}
+	return lunatics
+}
+
+// keepEvidence records an evidence unless it is expired,
+// it returns false if the evidence is expired or already known
+func (sb *Backend) keepEvidence(evidence interface{}) bool {
+	key, number, err := evidenceKey(evidence)
+	if err != nil {
+		log.Error("drop an evidence", "err", err)
+		return false
+	}
+	if sb.evidenceExpiry()(number) {
+		expiredEvidenceMeter.Mark(1)
+		log.Debug("drop an expired evidence", "number", number)
+		return false
+	}
+	return sb.evidences.add(key, number, evidence)
+}
+
+// evidenceExpiry returns whether the evidences of a misbehavior at a block number are too old to be kept at the
+// current head: the block is more than the max age in blocks behind the head and its timestamp more than the max
+// age before the head's, see evidenceMaxAge. The age is measured on the chain so that every node agrees on it.
+func (sb *Backend) evidenceExpiry() func(number uint64) bool {
+	var head *types.Header
+	if sb.chain != nil {
+		head = sb.chain.CurrentHeader()
+	}
+	maxBlocks, maxAge := sb.evidenceMaxAge(head)
+	return func(number uint64) bool {
+		if maxBlocks == 0 && maxAge == 0 {
+			return false
+		}
+		blocks, age, ok := sb.evidenceAge(number)
+		return ok && blocks > maxBlocks && (maxAge == 0 || age > maxAge)
+	}
+}
+
+// evidenceAge returns the number of blocks and the duration the head is after the block number of a misbehavior,
+// ok is false if the block is not in the chain yet
+func (sb *Backend) evidenceAge(number uint64) (blocks uint64, age time.Duration, ok bool) {
+	if sb.chain == nil {
+		return 0, 0, false
+	}
+	head := sb.chain.CurrentHeader()
+	if head == nil || head.Number.Uint64() < number {
+		return 0, 0, false
+	}
+	header := sb.chain.GetHeaderByNumber(number)
+	if header == nil || head.Time < header.Time {
+		return 0, 0, false
+	}
+	return head.Number.Uint64() - number, time.Duration(head.Time-header.Time) * time.Second, true
+}
+
+// pruneEvidences removes the evidences expired at the new chain head
+func (sb *Backend) pruneEvidences() {
+	if pruned := sb.evidences.prune(sb.evidenceExpiry()); pruned > 0 {
+		expiredEvidenceMeter.Mark(int64(pruned))
+		log.Debug("pruned the expired evidences", "count", pruned)
+	}
+}
+
+// handleDuplicateProposal records a duplicate proposal detected by core or received from a peer,
+// and relays it to the validators of its block number the first time it is seen.
+func (sb *Backend) handleDuplicateProposal(evidence *tendermintCore.DuplicateProposal) {
+	if !sb.keepEvidence(evidence) {
+		return
+	}
+	duplicateProposalMeter.Mark(1)
+	log.Warn("A proposer signed several blocks for a round", "proposer", evidence.Proposer,
+		"number", evidence.BlockNumber, "round", evidence.Round,
+		"first", evidence.BlockHashes[0], "second", evidence.BlockHashes[1])
+	// core calls the handler in the state machine, the validator set is looked up aside
+	go sb.relayEvidence(evidence.BlockNumber, evidence.Payload)
+}
+
+// handleAmnesia records an amnesia detected by core or received from a peer,
+// and relays it to the validators of its block number the first time it is seen.
+func (sb *Backend) handleAmnesia(evidence *tendermintCore.Amnesia) {
+	if !sb.keepEvidence(evidence) {
+		return
+	}
+	amnesiaMeter.Mark(1)
+	log.Warn("A validator prevoted a block contrary to its lock", "validator", evidence.Validator,
+		"number", evidence.BlockNumber, "precommitRound", evidence.PrecommitRound, "precommitted", evidence.PrecommitHash,
+		"prevoteRound", evidence.PrevoteRound, "prevoted", evidence.PrevoteHash)
+	go sb.relayEvidence(evidence.BlockNumber, evidence.Payload)
+}
+
+// handleLunatic records a lunatic detected by core once its header is verified to be invalid,
+// core calls the handler in the state machine so the header is verified aside.
+func (sb *Backend) handleLunatic(evidence *tendermintCore.Lunatic) {
+	go func() {
+		if fault, ok := sb.lunaticFault(evidence.Header); ok && fault != nil {
+			sb.addLunatic(evidence, fault)
+		}
+	}()
+}
+
+// addLunatic records a lunatic whose header fails the validity check fault,
+// and relays it to the validators of its block number the first time it is seen.
+func (sb *Backend) addLunatic(evidence *tendermintCore.Lunatic, fault error) {
+	if !sb.keepEvidence(&lunatic{Lunatic: evidence, fault: fault}) {
+		return
+	}
+	lunaticMeter.Mark(1)
+	log.Warn("A validator precommitted an invalid block", "validator", evidence.Validator,
+		"number", evidence.BlockNumber, "round", evidence.Round, "hash", evidence.Header.Hash(), "fault", fault)
+	go sb.relayEvidence(evidence.BlockNumber, evidence.Payload)
+}
+
+// lunaticFault returns the validity check the header of a lunatic evidence fails, nil if it passes them.
+// Only the checks of the fields signed by the precommit which don't depend on the local time are run, so every
+// node gets the same result. ok is false if the header can't be checked since its parent is not known yet.
+func (sb *Backend) lunaticFault(header *types.Header) (fault error, ok bool) {
+	if sb.chain == nil || header.Number.Sign() <= 0 {
+		return nil, false
+	}
+	parent := sb.chain.GetHeaderByNumber(header.Number.Uint64() - 1)
+	if parent == nil {
+		return nil, false
+	}
+	// the blocks are final, a block not built on the parent committed can't be committed
+	if parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor, true
+	}
+	// the committed seals, the validator set and the round are not part of the block hash, they are dropped
+	// so that nobody can frame the validator by altering them
+	signed := types.TendermintFilteredHeader(header, true)
+	if signed == nil {
+		return tendermint.ErrInvalidExtraDataFormat, true
+	}
+	valSet, err := sb.valSetInfo.GetValSet(sb.chain, header.Number)
+	if err != nil {
+		return nil, false
+	}
+	if err := sb.verifyHeaderFields(sb.chain, signed, []*types.Header{parent}, valSet); err != nil && err != tendermint.ErrEmptyCommittedSeals {
+		return err, true
+	}
+	return nil, true
+}
+
+// relayEvidence sends an evidence message to the validators of its block number
+func (sb *Backend) relayEvidence(number *big.Int, payload []byte) {
+	if sb.chain == nil {
+		return
+	}
+	valSet, err := sb.valSetInfo.GetValSet(sb.chain, number)
+	if err != nil {
+		log.Debug("failed to get the validators to relay the evidence to", "number", number, "err", err)
+		return
+	}
+	targets := make(map[common.Address]bool)
+	for _, val := range valSet.List() {
+		if val.Address() != sb.Address() {
+			targets[val.Address()] = true
+		}
+	}
+	if err := sb.Multicast(targets, payload); err != nil {
+		log.Debug("failed to relay the evidence", "number", number, "err", err)
+	}
+}
+
+// handleEvidenceMsg verifies an evidence received from a peer: the misbehaving address must be
+// a validator of the block number of the evidence.
+func (sb *Backend) handleEvidenceMsg(addr common.Address, code uint64, data []byte, hash common.Hash) error {
+	_, duplicate := sb.msgCaches.markRecent(hash)
+	sb.msgCaches.markKnown(addr, hash)
+	if duplicate {
+		return nil
+	}
+	var (
+		validator common.Address
+		number    *big.Int
+		handle    func() error
+	)
+	if tendermintCore.IsDuplicateProposal(code) {
+		evidence, err := tendermintCore.DecodeDuplicateProposal(sb.config, data)
+		if err != nil {
+			log.Debug("received an invalid duplicate proposal evidence", "from", addr, "err", err)
+			return sb.peerScores.invalid(addr)
+		}
+		validator, number, handle = evidence.Proposer, evidence.BlockNumber, func() error {
+			sb.handleDuplicateProposal(evidence)
+			return nil
+		}
+	} else if tendermintCore.IsAmnesia(code) {
+		evidence, err := tendermintCore.DecodeAmnesia(sb.config, data)
+		if err != nil {
+			log.Debug("received an invalid amnesia evidence", "from", addr, "err", err)
+			return sb.peerScores.invalid(addr)
+		}
+		validator, number, handle = evidence.Validator, evidence.BlockNumber, func() error {
+			sb.handleAmnesia(evidence)
+			return nil
+		}
+	} else {
+		evidence, err := tendermintCore.DecodeLunatic(sb.config, data)
+		if err != nil {
+			log.Debug("received an invalid lunatic evidence", "from", addr, "err", err)
+			return sb.peerScores.invalid(addr)
+		}
+		validator, number, handle = evidence.Validator, evidence.BlockNumber, func() error {
+			fault, ok := sb.lunaticFault(evidence.Header)
+			if !ok {
+				return nil
+			}
+			if fault == nil {
+				log.Debug("received a lunatic evidence of a valid block", "from", addr, "validator", evidence.Validator)
+				return sb.peerScores.invalid(addr)
+			}
+			sb.addLunatic(evidence, fault)
+			return nil
+		}
+	}
+	if sb.chain == nil {
+		return nil
+	}
+	valSet, err := sb.valSetInfo.GetValSet(sb.chain, number)
+	if err != nil {
+		// the evidence of a block number we don't know the validators of yet can't be verified
+		return nil
+	}
+	if index, _ := valSet.GetByAddress(validator); index == -1 {
+		log.Debug("received an evidence of a non validator", "from", addr, "validator", validator)
+		return sb.peerScores.invalid(addr)
+	}
+	return handle()
+}
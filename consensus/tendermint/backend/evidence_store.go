@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// evidencePrefix + keccak256 of the evidence message -> rlp encoded storedEvidence
+var evidencePrefix = []byte("tendermint-evidence-")
+
+// errUnknownEvidence is returned for an evidence of a type the pool doesn't keep
+var errUnknownEvidence = errors.New("unknown evidence type")
+
+// storedEvidence is the database record of an evidence, the evidence is decoded again from its message on startup
+type storedEvidence struct {
+	Payload []byte
+	Fault   string // the validity check the block of a lunatic fails, empty for the other evidences
+}
+
+func evidenceDBKey(payload []byte) []byte {
+	return append(append([]byte{}, evidencePrefix...), crypto.Keccak256(payload)...)
+}
+
+// evidenceRecord returns the database record of an evidence kept by evidences
+func evidenceRecord(evidence interface{}) (storedEvidence, error) {
+	switch evidence := evidence.(type) {
+	case *tendermintCore.DuplicateProposal:
+		return storedEvidence{Payload: evidence.Payload}, nil
+	case *tendermintCore.Amnesia:
+		return storedEvidence{Payload: evidence.Payload}, nil
+	case *lunatic:
+		return storedEvidence{Payload: evidence.Payload, Fault: evidence.fault.Error()}, nil
+	}
+	return storedEvidence{}, errUnknownEvidence
+}
+
+// evidenceKey returns the key an evidence is kept by and the block number of the misbehavior
+func evidenceKey(evidence interface{}) (interface{}, uint64, error) {
+	switch evidence := evidence.(type) {
+	case *tendermintCore.DuplicateProposal:
+		number := evidence.BlockNumber.Uint64()
+		return duplicateProposalKey{proposer: evidence.Proposer, number: number, round: evidence.Round}, number, nil
+	case *tendermintCore.Amnesia:
+		number := evidence.BlockNumber.Uint64()
+		return amnesiaKey{validator: evidence.Validator, number: number}, number, nil
+	case *lunatic:
+		number := evidence.BlockNumber.Uint64()
+		return lunaticKey{validator: evidence.Validator, number: number}, number, nil
+	}
+	return nil, 0, errUnknownEvidence
+}
+
+// store saves an evidence added to the pool, so that a restart doesn't drop it
+func (e *evidences) store(evidence interface{}) {
+	if e.db == nil {
+		return
+	}
+	record, err := evidenceRecord(evidence)
+	if err != nil {
+		log.Error("failed to store the evidence", "err", err)
+		return
+	}
+	blob, err := rlp.EncodeToBytes(&record)
+	if err != nil {
+		log.Error("failed to encode the evidence", "err", err)
+		return
+	}
+	if err := e.db.Put(evidenceDBKey(record.Payload), blob); err != nil {
+		log.Warn("failed to store the evidence", "err", err)
+	}
+}
+
+// evicted deletes the evidences removed from the pool, pruned or evicted by newer ones, from the database
+func (e *evidences) evicted(_ interface{}, value interface{}) {
+	if e.db == nil {
+		return
+	}
+	record, err := evidenceRecord(value.(*evidenceEntry).evidence)
+	if err != nil {
+		log.Error("failed to delete the evidence", "err", err)
+		return
+	}
+	if err := e.db.Delete(evidenceDBKey(record.Payload)); err != nil {
+		log.Warn("failed to delete the evidence", "err", err)
+	}
+}
+
+// loadEvidences adds the evidences stored before a restart to the pool, they are already relayed so they
+// are not relayed again. The expired ones are pruned at the next chain head.
+func (sb *Backend) loadEvidences() {
+	if sb.db == nil {
+		return
+	}
+	// an evidence is loaded with the key it is kept by
+	type loadedEvidence struct {
+		key   interface{}
+		entry *evidenceEntry
+	}
+	var loaded []loadedEvidence
+	it := sb.db.NewIteratorWithPrefix(evidencePrefix)
+	for it.Next() {
+		evidence, err := sb.decodeStoredEvidence(it.Value())
+		if err == nil {
+			var (
+				key    interface{}
+				number uint64
+			)
+			if key, number, err = evidenceKey(evidence); err == nil {
+				loaded = append(loaded, loadedEvidence{key: key, entry: &evidenceEntry{evidence: evidence, number: number}})
+				continue
+			}
+		}
+		log.Warn("drop an invalid stored evidence", "err", err)
+		if err := sb.db.Delete(common.CopyBytes(it.Key())); err != nil {
+			log.Warn("failed to delete the evidence", "err", err)
+		}
+	}
+	it.Release()
+	// the pool keeps the newest evidences, they are added the oldest first
+	sort.SliceStable(loaded, func(i, j int) bool { return loaded[i].entry.number < loaded[j].entry.number })
+	for _, evidence := range loaded {
+		sb.evidences.cache.ContainsOrAdd(evidence.key, evidence.entry)
+	}
+	if len(loaded) > 0 {
+		log.Info("Loaded the stored evidences", "count", len(loaded))
+	}
+}
+
+// decodeStoredEvidence decodes an evidence record and verifies the signatures of its message again
+func (sb *Backend) decodeStoredEvidence(blob []byte) (interface{}, error) {
+	var record storedEvidence
+	if err := rlp.DecodeBytes(blob, &record); err != nil {
+		return nil, err
+	}
+	code, ok := msgCode(record.Payload)
+	if !ok {
+		return nil, errors.New("invalid evidence message")
+	}
+	switch {
+	case tendermintCore.IsDuplicateProposal(code):
+		return tendermintCore.DecodeDuplicateProposal(sb.config, record.Payload)
+	case tendermintCore.IsAmnesia(code):
+		return tendermintCore.DecodeAmnesia(sb.config, record.Payload)
+	case tendermintCore.IsLunatic(code):
+		evidence, err := tendermintCore.DecodeLunatic(sb.config, record.Payload)
+		if err != nil {
+			return nil, err
+		}
+		return &lunatic{Lunatic: evidence, fault: errors.New(record.Fault)}, nil
+	}
+	return nil, errors.New("unknown evidence code")
+}
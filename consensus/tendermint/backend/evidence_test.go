@@ -0,0 +1,321 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestEvidences(t *testing.T) {
+	var (
+		e        = newEvidences(nil)
+		evidence = func(proposer common.Address, number int64, round int64) *tendermintCore.DuplicateProposal {
+			return &tendermintCore.DuplicateProposal{
+				Proposer:    proposer,
+				BlockNumber: big.NewInt(number),
+				Round:       round,
+				BlockHashes: [2]common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")},
+				Payload:     []byte{byte(number), byte(round)},
+			}
+		}
+		key = func(evidence *tendermintCore.DuplicateProposal) duplicateProposalKey {
+			return duplicateProposalKey{proposer: evidence.Proposer, number: evidence.BlockNumber.Uint64(), round: evidence.Round}
+		}
+		first   = evidence(common.HexToAddress("0x1"), 10, 0)
+		second  = evidence(common.HexToAddress("0x1"), 10, 1)
+		amnesia = &tendermintCore.Amnesia{Validator: common.HexToAddress("0x1"), BlockNumber: big.NewInt(10)}
+	)
+	require.True(t, e.add(key(first), 10, first))
+	require.True(t, e.add(key(second), 10, second))
+	require.True(t, e.add(amnesiaKey{validator: amnesia.Validator, number: 10}, 10, amnesia))
+	// the evidences of a round are only kept once
+	require.False(t, e.add(key(first), 10, evidence(common.HexToAddress("0x1"), 10, 0)))
+	require.Equal(t, []*tendermintCore.DuplicateProposal{first, second}, e.duplicateProposals())
+	require.Equal(t, []*tendermintCore.Amnesia{amnesia}, e.amnesias())
+
+	for i := 0; i < maxEvidences; i++ {
+		duplicate := evidence(common.HexToAddress("0x2"), int64(i), 0)
+		e.add(key(duplicate), uint64(i), duplicate)
+	}
+	require.Len(t, e.list(), maxEvidences)
+	require.NotContains(t, e.duplicateProposals(), first)
+	require.Empty(t, e.amnesias())
+
+	require.Equal(t, 10, e.prune(func(number uint64) bool { return number < 10 }))
+	require.Len(t, e.list(), maxEvidences-10)
+}
+
+// agedChain serves the headers of a chain one block per minute
+type agedChain struct {
+	*tests_utils.MockChainReader
+	head uint64
+}
+
+func (c *agedChain) header(number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number), Time: number * 60}
+}
+
+func (c *agedChain) CurrentHeader() *types.Header {
+	return c.header(c.head)
+}
+
+func (c *agedChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number > c.head {
+		return nil
+	}
+	return c.header(number)
+}
+
+func TestBackend_EvidenceExpired(t *testing.T) {
+	var (
+		nodeKey = tests_utils.MakeNodeKey()
+		cfg     = *tendermint.DefaultConfig
+		chain   = &agedChain{head: 1000}
+	)
+	// the fixed validators are looked up without the chain to relay the evidences
+	cfg.FixedValidators = []common.Address{crypto.PubkeyToAddress(nodeKey.PublicKey)}
+	be := New(&cfg, nodeKey).(*Backend)
+	be.chain = chain
+	amnesia := func(number uint64) *tendermintCore.Amnesia {
+		return &tendermintCore.Amnesia{Validator: be.Address(), BlockNumber: new(big.Int).SetUint64(number)}
+	}
+	cfg.EvidenceMaxAgeBlocks = 100
+	cfg.EvidenceMaxAge = 2 * time.Hour
+
+	// an evidence expires once older than both limits
+	require.False(t, be.evidenceExpiry()(1000))
+	require.False(t, be.evidenceExpiry()(900))
+	require.False(t, be.evidenceExpiry()(899)) // 101 blocks but 101 minutes
+	require.True(t, be.evidenceExpiry()(800))
+	cfg.EvidenceMaxAge = 4 * time.Hour
+	require.False(t, be.evidenceExpiry()(800))
+	require.True(t, be.evidenceExpiry()(759))
+
+	// a zero limit is not checked
+	cfg.EvidenceMaxAge = 0
+	require.True(t, be.evidenceExpiry()(899))
+	cfg.EvidenceMaxAgeBlocks = 0
+	require.False(t, be.evidenceExpiry()(0))
+
+	// the expired evidences are not kept, the kept ones are pruned once expired
+	cfg.EvidenceMaxAgeBlocks = 100
+	be.handleAmnesia(amnesia(800))
+	be.handleAmnesia(amnesia(950))
+	require.Len(t, be.evidences.amnesias(), 1)
+	chain.head = 1100
+	be.pruneEvidences()
+	require.Empty(t, be.evidences.amnesias())
+}
+
+func TestTendermintAPI_GetDuplicateProposals(t *testing.T) {
+	be, _, _, err := createBlockchainAndBackendFromGenesis(FixedValidators)
+	require.NoError(t, err)
+	api := &TendermintAPI{chain: be.chain, be: be}
+	require.Empty(t, api.GetDuplicateProposals())
+
+	be.handleDuplicateProposal(&tendermintCore.DuplicateProposal{
+		Proposer:    be.Address(),
+		BlockNumber: big.NewInt(1),
+		Round:       2,
+		BlockHashes: [2]common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")},
+		Payload:     []byte{0x1},
+	})
+	duplicates := api.GetDuplicateProposals()
+	require.Len(t, duplicates, 1)
+	require.Equal(t, be.Address(), duplicates[0].Proposer)
+	require.Equal(t, int64(2), duplicates[0].Round)
+	require.Equal(t, []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")}, duplicates[0].BlockHashes)
+}
+
+func TestTendermintAPI_GetAmnesias(t *testing.T) {
+	be, _, _, err := createBlockchainAndBackendFromGenesis(FixedValidators)
+	require.NoError(t, err)
+	api := &TendermintAPI{chain: be.chain, be: be}
+	require.Empty(t, api.GetAmnesias())
+
+	evidence := &tendermintCore.Amnesia{
+		Validator:      be.Address(),
+		BlockNumber:    big.NewInt(1),
+		PrecommitRound: 0,
+		PrecommitHash:  common.HexToHash("0x1"),
+		PrevoteRound:   2,
+		PrevoteHash:    common.HexToHash("0x2"),
+		Payload:        []byte{0x1},
+	}
+	be.handleAmnesia(evidence)
+	// an amnesia of the same block number is only kept once
+	be.handleAmnesia(evidence)
+	amnesias := api.GetAmnesias()
+	require.Len(t, amnesias, 1)
+	require.Equal(t, be.Address(), amnesias[0].Validator)
+	require.Equal(t, int64(2), amnesias[0].PrevoteRound)
+	require.Equal(t, common.HexToHash("0x2"), amnesias[0].PrevoteHash)
+	require.Empty(t, api.GetDuplicateProposals())
+}
+
+func TestBackend_Lunatics(t *testing.T) {
+	var (
+		nodePK, _     = crypto.HexToECDSA("bb047e5940b6d83354d9432db7c449ac8fca2248008aaa7271369880f9f11cc1")
+		validators    = []common.Address{crypto.PubkeyToAddress(nodePK.PublicKey)}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+		cfg           = *tendermint.DefaultConfig
+	)
+	cfg.FixedValidators = validators
+	cfg.StrictTimestampBlock = big.NewInt(1)
+	_, engine := mustStartTestChainAndBackend(nodePK, genesisHeader, &cfg)
+	api := &TendermintAPI{chain: engine.chain, be: engine}
+
+	// a valid block, even with forged committed seals which are not signed by the precommits
+	header := tests_utils.MustMakeBlockWithCommittedSealInvalid(engine, genesisHeader).Header()
+	fault, ok := engine.lunaticFault(header)
+	require.True(t, ok)
+	require.NoError(t, fault)
+
+	// a block not after its parent
+	header = tests_utils.MakeBlockWithoutSeal(genesisHeader).Header()
+	header.Time = genesisHeader.Time
+	tests_utils.AppendSeal(header, engine)
+	fault, ok = engine.lunaticFault(header)
+	require.True(t, ok)
+	require.Equal(t, tendermint.ErrInvalidTimestamp, fault)
+
+	// a block of an unknown parent can't be checked
+	unknown := types.CopyHeader(header)
+	unknown.Number = big.NewInt(0)
+	_, ok = engine.lunaticFault(unknown)
+	require.False(t, ok)
+
+	engine.addLunatic(&tendermintCore.Lunatic{
+		Validator:   validators[0],
+		BlockNumber: header.Number,
+		Header:      header,
+		Payload:     []byte{0x1},
+	}, fault)
+	lunatics := api.GetLunatics()
+	require.Len(t, lunatics, 1)
+	require.Equal(t, validators[0], lunatics[0].Validator)
+	require.Equal(t, header.Hash(), lunatics[0].BlockHash)
+	require.Equal(t, tendermint.ErrInvalidTimestamp.Error(), lunatics[0].Fault)
+}
+
+// signedTestMsg returns a consensus message of code signed by key, as core encodes it
+func signedTestMsg(t *testing.T, key *ecdsa.PrivateKey, code uint64, msg []byte) []byte {
+	type testMsg struct {
+		Code      uint64
+		Msg       []byte
+		Address   common.Address
+		Signature []byte
+	}
+	m := testMsg{Code: code, Msg: msg, Address: crypto.PubkeyToAddress(key.PublicKey), Signature: []byte{}}
+	unsigned, err := rlp.EncodeToBytes(&m)
+	require.NoError(t, err)
+	m.Signature, err = crypto.Sign(crypto.Keccak256(unsigned), key)
+	require.NoError(t, err)
+	payload, err := rlp.EncodeToBytes(&m)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestEvidences_Persistence(t *testing.T) {
+	const (
+		prevoteCode   = 1
+		precommitCode = 2
+		amnesiaCode   = 8
+	)
+	var (
+		nodeKey = tests_utils.MakeNodeKey()
+		cfg     = *tendermint.DefaultConfig
+		db      = rawdb.NewMemoryDatabase()
+		vote    = func(code uint64, hash common.Hash, round int64) []byte {
+			msg, err := rlp.EncodeToBytes(&tendermintCore.Vote{BlockHash: &hash, BlockNumber: big.NewInt(1), Round: round})
+			require.NoError(t, err)
+			return signedTestMsg(t, nodeKey, code, msg)
+		}
+	)
+	cfg.FixedValidators = []common.Address{crypto.PubkeyToAddress(nodeKey.PublicKey)}
+	evidence, err := rlp.EncodeToBytes(&tendermintCore.AmnesiaEvidence{
+		Precommit: vote(precommitCode, common.HexToHash("0x1"), 0),
+		Prevote:   vote(prevoteCode, common.HexToHash("0x2"), 1),
+	})
+	require.NoError(t, err)
+	payload, err := rlp.EncodeToBytes([]interface{}{uint64(amnesiaCode), evidence, common.Address{}, []byte{}})
+	require.NoError(t, err)
+	amnesia, err := tendermintCore.DecodeAmnesia(&cfg, payload)
+	require.NoError(t, err)
+
+	be := New(&cfg, nodeKey, WithDB(db)).(*Backend)
+	be.handleAmnesia(amnesia)
+	require.Len(t, be.evidences.amnesias(), 1)
+	require.NoError(t, db.Put(append(append([]byte{}, evidencePrefix...), 0x1), []byte{0x2}))
+
+	// a restart reloads the evidence, which is already known so it is not relayed again
+	be = New(&cfg, nodeKey, WithDB(db)).(*Backend)
+	require.Equal(t, []*tendermintCore.Amnesia{amnesia}, be.evidences.amnesias())
+	require.False(t, be.keepEvidence(amnesia))
+
+	// the invalid records are dropped and the pruned evidences are deleted
+	be.evidences.prune(func(uint64) bool { return true })
+	it := db.NewIteratorWithPrefix(evidencePrefix)
+	defer it.Release()
+	require.False(t, it.Next())
+}
+
+func TestEvidences_UnknownType(t *testing.T) {
+	var (
+		nodeKey = tests_utils.MakeNodeKey()
+		cfg     = *tendermint.DefaultConfig
+		db      = rawdb.NewMemoryDatabase()
+	)
+	cfg.FixedValidators = []common.Address{crypto.PubkeyToAddress(nodeKey.PublicKey)}
+	be := New(&cfg, nodeKey, WithDB(db)).(*Backend)
+
+	// an evidence of an unknown type is not kept
+	require.False(t, be.keepEvidence("evidence"))
+	require.Empty(t, be.evidences.list())
+
+	// nor stored or deleted if it ends up in the pool
+	require.True(t, be.evidences.add("key", 1, "evidence"))
+	it := db.NewIteratorWithPrefix(evidencePrefix)
+	require.False(t, it.Next())
+	it.Release()
+	require.Equal(t, 1, be.evidences.prune(func(uint64) bool { return true }))
+}
+
+func TestTendermintAPI_GetPendingEvidences(t *testing.T) {
+	var (
+		nodeKey = tests_utils.MakeNodeKey()
+		cfg     = *tendermint.DefaultConfig
+	)
+	cfg.FixedValidators = []common.Address{crypto.PubkeyToAddress(nodeKey.PublicKey)}
+	be := New(&cfg, nodeKey).(*Backend)
+	be.chain = &agedChain{head: 1000}
+	api := &TendermintAPI{chain: be.chain, be: be}
+	require.Empty(t, api.GetPendingEvidences())
+
+	be.handleAmnesia(&tendermintCore.Amnesia{Validator: be.Address(), BlockNumber: big.NewInt(990), Payload: []byte{0x1}})
+	be.handleDuplicateProposal(&tendermintCore.DuplicateProposal{Proposer: be.Address(), BlockNumber: big.NewInt(1000), Payload: []byte{0x2}})
+	pending := api.GetPendingEvidences()
+	require.Len(t, pending, 2)
+	require.Equal(t, PendingEvidence{
+		Type:      "amnesia",
+		Validator: be.Address(),
+		Number:    big.NewInt(990),
+		AgeBlocks: 10,
+		Age:       600,
+		Evidence:  []byte{0x1},
+	}, pending[0])
+	require.Equal(t, "duplicateProposal", pending[1].Type)
+	require.Equal(t, uint64(0), pending[1].AgeBlocks)
+}
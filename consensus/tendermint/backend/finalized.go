@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"math/big"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/event"
+)
+
+const inMemoryCommitRounds = 128 // number of commit rounds kept for the blocks waiting to be finalized
+
+// finalizedBlocks sends a BlockFinalizedEvent for every block appended to the chain.
+type finalizedBlocks struct {
+	mu     sync.Mutex
+	rounds *lru.Cache // block number -> commit round of the blocks committed by this node
+	last   uint64     // number of the last finalized block sent, 0 before the first one
+	feed   event.Feed
+}
+
+func newFinalizedBlocks() *finalizedBlocks {
+	rounds, _ := lru.New(inMemoryCommitRounds)
+	return &finalizedBlocks{
+		rounds: rounds,
+	}
+}
+
+// recordRound records the round in which this node committed the block of number
+func (fb *finalizedBlocks) recordRound(number uint64, round int64) {
+	fb.rounds.Add(number, round)
+}
+
+// post sends an event for each block up to the head of number which has not been sent yet.
+// The head can skip several blocks when they are imported in a batch.
+func (fb *finalizedBlocks) post(head *big.Int) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	number := head.Uint64()
+	if number <= fb.last {
+		return
+	}
+	from := number
+	if fb.last > 0 {
+		from = fb.last + 1
+	}
+	for n := from; n <= number; n++ {
+		round := int64(-1)
+		if r, ok := fb.rounds.Get(n); ok {
+			round = r.(int64)
+			fb.rounds.Remove(n)
+		}
+		fb.feed.Send(tendermint.BlockFinalizedEvent{BlockNumber: new(big.Int).SetUint64(n), Round: round})
+	}
+	fb.last = number
+}
+
+// SubscribeBlockFinalized subscribes to the blocks finalized, in the order of their numbers
+func (sb *Backend) SubscribeBlockFinalized(ch chan<- tendermint.BlockFinalizedEvent) event.Subscription {
+	return sb.finalized.feed.Subscribe(ch)
+}
@@ -0,0 +1,32 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+)
+
+func TestFinalizedBlocks_Post(t *testing.T) {
+	var (
+		fb     = newFinalizedBlocks()
+		events = make(chan tendermint.BlockFinalizedEvent, 10)
+		sub    = fb.feed.Subscribe(events)
+	)
+	defer sub.Unsubscribe()
+
+	fb.recordRound(5, 2)
+	fb.post(big.NewInt(5))
+	require.Equal(t, tendermint.BlockFinalizedEvent{BlockNumber: big.NewInt(5), Round: 2}, <-events)
+
+	// a head already sent is ignored
+	fb.post(big.NewInt(5))
+	// the blocks skipped by the head are sent too
+	fb.recordRound(7, 0)
+	fb.post(big.NewInt(7))
+	require.Equal(t, tendermint.BlockFinalizedEvent{BlockNumber: big.NewInt(6), Round: -1}, <-events)
+	require.Equal(t, tendermint.BlockFinalizedEvent{BlockNumber: big.NewInt(7), Round: 0}, <-events)
+	require.Empty(t, events)
+}
@@ -6,20 +6,32 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/params"
 )
 
 type FixedValidatorSetInfo struct {
 	addresses []common.Address
+	epoch     uint64
+	rotations []params.TendermintKeyRotation
 }
 
-func NewFixedValidatorSetInfo(addrs []common.Address) *FixedValidatorSetInfo {
+// NewFixedValidatorSetInfo returns the fixed validators, whose keys are rotated at the checkpoint blocks of the rotations
+func NewFixedValidatorSetInfo(addrs []common.Address, epoch uint64, rotations []params.TendermintKeyRotation) *FixedValidatorSetInfo {
 	return &FixedValidatorSetInfo{
 		addresses: addrs,
+		epoch:     epoch,
+		rotations: rotations,
 	}
 }
 
 //GetValSet keep tracks of validator set in associate with blockNumber
 func (mvi *FixedValidatorSetInfo) GetValSet(chainReader consensus.ChainReader, blockNumber *big.Int) (tendermint.ValidatorSet, error) {
-	return validator.NewSet(mvi.addresses, tendermint.RoundRobin, blockNumber.Int64()), nil
+	addresses := mvi.addresses
+	if len(mvi.rotations) > 0 {
+		checkpoint := utils.GetCheckpointNumber(mvi.epoch, blockNumber.Uint64())
+		addresses = tendermint.RotateValidators(mvi.rotations, checkpoint, addresses)
+	}
+	return validator.NewSet(addresses, tendermint.RoundRobin, blockNumber.Int64()), nil
 }
@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// maxGasLimit is the highest gas limit a header may carry, gas limits are int64 in the EVM
+const maxGasLimit = uint64(0x7fffffffffffffff)
+
+// gasLimitTowards returns the gas limit of the block after parent moved toward the target of the proposer,
+// by at most the delta verifyGasLimit permits. Each proposer votes for its own target, so the gas limit
+// follows the targets of the validators weighted by how often they propose.
+func gasLimitTowards(parent *types.Header, target uint64) uint64 {
+	if target < params.MinGasLimit {
+		target = params.MinGasLimit
+	}
+	if target > maxGasLimit {
+		target = maxGasLimit
+	}
+	delta := parent.GasLimit / params.GasLimitBoundDivisor
+	if delta > 0 {
+		delta--
+	}
+	switch {
+	case target > parent.GasLimit:
+		if target-parent.GasLimit > delta {
+			return parent.GasLimit + delta
+		}
+	case target < parent.GasLimit:
+		if parent.GasLimit-target > delta {
+			return parent.GasLimit - delta
+		}
+	}
+	return target
+}
+
+// verifyGasLimit checks that the gas limit of header is within the protocol bounds and, from the gas limit
+// bound block on, at least MinGasLimit and moved from the one of parent by less than parent.GasLimit / GasLimitBoundDivisor.
+func verifyGasLimit(config *tendermint.Config, header, parent *types.Header) error {
+	if header.GasLimit > maxGasLimit {
+		return tendermint.ErrInvalidGasLimit
+	}
+	if header.GasUsed > header.GasLimit {
+		return tendermint.ErrInvalidGasUsed
+	}
+	if !config.IsGasLimitBound(header.Number) {
+		return nil
+	}
+	if header.GasLimit < params.MinGasLimit {
+		return tendermint.ErrInvalidGasLimit
+	}
+	delta := header.GasLimit - parent.GasLimit
+	if parent.GasLimit > header.GasLimit {
+		delta = parent.GasLimit - header.GasLimit
+	}
+	if delta >= parent.GasLimit/params.GasLimitBoundDivisor && delta != 0 {
+		return tendermint.ErrInvalidGasLimit
+	}
+	return nil
+}
+
+// ProposalBudget implements consensus.ProposalBudgeter.ProposalBudget, the transactions of the blocks
+// the node proposes use at most config.ProposalGas and are assembled for at most config.ProposalTime.
+func (sb *Backend) ProposalBudget() (uint64, time.Duration) {
+	return sb.config.ProposalGas, sb.config.ProposalTime
+}
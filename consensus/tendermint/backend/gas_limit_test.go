@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestGasLimitTowards(t *testing.T) {
+	parent := &types.Header{GasLimit: 8000000}
+	delta := parent.GasLimit/params.GasLimitBoundDivisor - 1
+
+	require.Equal(t, parent.GasLimit, gasLimitTowards(parent, parent.GasLimit))
+	require.Equal(t, parent.GasLimit+delta, gasLimitTowards(parent, 10000000))
+	require.Equal(t, parent.GasLimit-delta, gasLimitTowards(parent, 6000000))
+	require.Equal(t, parent.GasLimit+10, gasLimitTowards(parent, parent.GasLimit+10))
+	// the target is kept within the protocol bounds
+	parent = &types.Header{GasLimit: params.MinGasLimit + 1}
+	require.Equal(t, params.MinGasLimit, gasLimitTowards(parent, 0))
+
+	// a chain of proposers voting for their target converges to it within the permitted delta
+	config := &tendermint.Config{GasLimitBoundBlock: big.NewInt(1)}
+	parent = &types.Header{Number: big.NewInt(0), GasLimit: 8000000}
+	for i := 0; i < 1000; i++ {
+		header := &types.Header{Number: new(big.Int).Add(parent.Number, big.NewInt(1)), GasLimit: gasLimitTowards(parent, 12000000)}
+		require.NoError(t, verifyGasLimit(config, header, parent))
+		parent = header
+	}
+	require.Equal(t, uint64(12000000), parent.GasLimit)
+}
+
+func TestVerifyGasLimit(t *testing.T) {
+	var (
+		config = &tendermint.Config{GasLimitBoundBlock: big.NewInt(10)}
+		parent = &types.Header{Number: big.NewInt(9), GasLimit: 8000000}
+		bound  = parent.GasLimit / params.GasLimitBoundDivisor
+		header = func(gasLimit, gasUsed uint64) *types.Header {
+			return &types.Header{Number: big.NewInt(10), GasLimit: gasLimit, GasUsed: gasUsed}
+		}
+	)
+	require.NoError(t, verifyGasLimit(config, header(parent.GasLimit, 0), parent))
+	require.NoError(t, verifyGasLimit(config, header(parent.GasLimit+bound-1, 0), parent))
+	require.NoError(t, verifyGasLimit(config, header(parent.GasLimit-bound+1, 0), parent))
+	require.Equal(t, tendermint.ErrInvalidGasLimit, verifyGasLimit(config, header(parent.GasLimit+bound, 0), parent))
+	require.Equal(t, tendermint.ErrInvalidGasLimit, verifyGasLimit(config, header(parent.GasLimit-bound, 0), parent))
+	require.Equal(t, tendermint.ErrInvalidGasUsed, verifyGasLimit(config, header(parent.GasLimit, parent.GasLimit+1), parent))
+
+	minParent := &types.Header{Number: big.NewInt(9), GasLimit: params.MinGasLimit}
+	require.Equal(t, tendermint.ErrInvalidGasLimit, verifyGasLimit(config, header(params.MinGasLimit-1, 0), minParent))
+
+	// before the gas limit bound block, the gas limit may move freely and go below the minimum
+	prefork := &types.Header{Number: big.NewInt(9), GasLimit: parent.GasLimit + bound}
+	require.NoError(t, verifyGasLimit(config, prefork, &types.Header{Number: big.NewInt(8), GasLimit: parent.GasLimit}))
+	prefork = &types.Header{Number: big.NewInt(9), GasLimit: params.MinGasLimit - 1}
+	require.NoError(t, verifyGasLimit(config, prefork, minParent))
+	require.NoError(t, verifyGasLimit(&tendermint.Config{}, header(parent.GasLimit*2, 0), parent))
+	// the gas used is always bounded by the gas limit
+	prefork = &types.Header{Number: big.NewInt(9), GasLimit: parent.GasLimit, GasUsed: parent.GasLimit + 1}
+	require.Equal(t, tendermint.ErrInvalidGasUsed, verifyGasLimit(config, prefork, parent))
+}
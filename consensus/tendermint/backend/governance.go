@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// The storage slots of the parameters in the params contract, see params.TendermintParamsContract
+var (
+	paramsEvidenceMaxAgeBlocksSlot = common.BigToHash(big.NewInt(0))
+	paramsEvidenceMaxAgeSlot       = common.BigToHash(big.NewInt(1)) // in seconds
+	// the maximum number of validators, and the one a proposal of the validators sets from a future checkpoint
+	paramsMaxValidatorsSlot            = common.BigToHash(big.NewInt(2))
+	paramsPendingMaxValidatorsSlot     = common.BigToHash(big.NewInt(3))
+	paramsPendingMaxValidatorsFromSlot = common.BigToHash(big.NewInt(4))
+)
+
+// evidenceMaxAge returns the max age of the evidences at head, in blocks and time: the parameters of the
+// params contract once it is active, those of the node configuration otherwise
+func (sb *Backend) evidenceMaxAge(head *types.Header) (uint64, time.Duration) {
+	maxBlocks, maxAge := sb.config.EvidenceMaxAgeBlocks, sb.config.EvidenceMaxAge
+	if sb.chain == nil || head == nil {
+		return maxBlocks, maxAge
+	}
+	config := sb.chain.Config()
+	if config == nil || config.Tendermint == nil || config.Tendermint.ParamsContract == nil {
+		return maxBlocks, maxAge
+	}
+	contract := config.Tendermint.ParamsContract
+	if head.Number.Uint64() < contract.Block {
+		return maxBlocks, maxAge
+	}
+	statedb, err := sb.chain.StateAt(head.Root)
+	if err != nil {
+		log.Debug("failed to get the state of the params contract", "number", head.Number, "err", err)
+		return maxBlocks, maxAge
+	}
+	if value := statedb.GetState(contract.Address, paramsEvidenceMaxAgeBlocksSlot).Big(); value.Sign() > 0 && value.IsUint64() {
+		maxBlocks = value.Uint64()
+	}
+	if value := statedb.GetState(contract.Address, paramsEvidenceMaxAgeSlot).Big(); value.Sign() > 0 && value.IsInt64() {
+		maxAge = time.Duration(value.Int64()) * time.Second
+	}
+	return maxBlocks, maxAge
+}
+
+// maxValidatorsAt returns the maximum number of validators selected at the checkpoint set by the params contract,
+// read at statedb the validators are selected at, nil if it sets none. The number a proposal voted by the validators
+// sets is pending until the checkpoint it takes effect from, then it applies whether or not the contract already
+// made it the current one.
+func maxValidatorsAt(config *params.TendermintConfig, statedb *state.StateDB, checkpoint uint64) *big.Int {
+	contract := config.ParamsContract
+	if contract == nil || checkpoint < contract.Block {
+		return nil
+	}
+	value := statedb.GetState(contract.Address, paramsMaxValidatorsSlot).Big()
+	from := statedb.GetState(contract.Address, paramsPendingMaxValidatorsFromSlot).Big()
+	if from.Sign() > 0 && from.IsUint64() && checkpoint >= from.Uint64() {
+		value = statedb.GetState(contract.Address, paramsPendingMaxValidatorsSlot).Big()
+	}
+	if value.Sign() == 0 || !value.IsUint64() {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,115 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// governedChain is an agedChain whose genesis declares a params contract
+type governedChain struct {
+	*agedChain
+	contract *params.TendermintParamsContract
+	state    *state.StateDB
+}
+
+func (c *governedChain) Config() *params.ChainConfig {
+	return &params.ChainConfig{Tendermint: &params.TendermintConfig{ParamsContract: c.contract}}
+}
+
+func (c *governedChain) StateAt(common.Hash) (*state.StateDB, error) {
+	return c.state, nil
+}
+
+func TestBackend_EvidenceMaxAge(t *testing.T) {
+	var (
+		nodeKey = tests_utils.MakeNodeKey()
+		cfg     = *tendermint.DefaultConfig
+		address = common.HexToAddress("0x1000")
+	)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	chain := &governedChain{
+		agedChain: &agedChain{head: 1000},
+		contract:  &params.TendermintParamsContract{Block: 1001, Address: address},
+		state:     statedb,
+	}
+	cfg.FixedValidators = []common.Address{crypto.PubkeyToAddress(nodeKey.PublicKey)}
+	cfg.EvidenceMaxAgeBlocks = 100
+	cfg.EvidenceMaxAge = 2 * time.Hour
+	be := New(&cfg, nodeKey).(*Backend)
+	be.chain = chain
+	statedb.SetState(address, paramsEvidenceMaxAgeBlocksSlot, common.BigToHash(big.NewInt(10)))
+	statedb.SetState(address, paramsEvidenceMaxAgeSlot, common.BigToHash(big.NewInt(600)))
+
+	// the contract is not read before its block
+	maxBlocks, maxAge := be.evidenceMaxAge(chain.CurrentHeader())
+	require.Equal(t, uint64(100), maxBlocks)
+	require.Equal(t, 2*time.Hour, maxAge)
+	require.False(t, be.evidenceExpiry()(900))
+
+	// the parameters of the contract replace the node configuration from its block on
+	chain.head = 1001
+	maxBlocks, maxAge = be.evidenceMaxAge(chain.CurrentHeader())
+	require.Equal(t, uint64(10), maxBlocks)
+	require.Equal(t, 10*time.Minute, maxAge)
+	require.True(t, be.evidenceExpiry()(980))
+	require.False(t, be.evidenceExpiry()(991))
+
+	// a parameter left unset keeps the node configuration
+	statedb.SetState(address, paramsEvidenceMaxAgeSlot, common.Hash{})
+	maxBlocks, maxAge = be.evidenceMaxAge(chain.CurrentHeader())
+	require.Equal(t, uint64(10), maxBlocks)
+	require.Equal(t, 2*time.Hour, maxAge)
+}
+
+func TestMaxValidatorsAt(t *testing.T) {
+	var (
+		stakingSC  = common.HexToAddress("0x11")
+		address    = common.HexToAddress("0x1000")
+		candidates = []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")}
+		cfg        = *tendermint.DefaultConfig
+		config     = &params.TendermintConfig{
+			Epoch:          10,
+			ParamsContract: &params.TendermintParamsContract{Block: 20, Address: address},
+		}
+		statedb = newStakingState(t, stakingSC, candidates, 3, 10)
+	)
+	for i, candidate := range candidates {
+		setStakingSlot(statedb, stakingSC, candidateSlot(candidate, 1), big.NewInt(int64(100*(i+1))))
+	}
+	cfg.StakingSCAddress = &stakingSC
+	be := New(&cfg, tests_utils.MakeNodeKey()).(*Backend)
+	be.stakingContractAddr = stakingSC
+
+	// the contract sets no maximum before its block, nor when it leaves it unset
+	statedb.SetState(address, paramsMaxValidatorsSlot, common.BigToHash(big.NewInt(2)))
+	require.Nil(t, maxValidatorsAt(config, statedb, 10))
+	statedb.SetState(address, paramsMaxValidatorsSlot, common.Hash{})
+	require.Nil(t, maxValidatorsAt(config, statedb, 20))
+
+	// a change voted by the validators takes effect from its checkpoint
+	statedb.SetState(address, paramsMaxValidatorsSlot, common.BigToHash(big.NewInt(2)))
+	statedb.SetState(address, paramsPendingMaxValidatorsSlot, common.BigToHash(big.NewInt(1)))
+	statedb.SetState(address, paramsPendingMaxValidatorsFromSlot, common.BigToHash(big.NewInt(40)))
+	require.Equal(t, int64(2), maxValidatorsAt(config, statedb, 30).Int64())
+	require.Equal(t, int64(1), maxValidatorsAt(config, statedb, 40).Int64())
+
+	// the candidates with the most stake are selected
+	validators, err := be.selectBondedValidators(config, statedb, 30, maxValidatorsAt(config, statedb, 30))
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{candidates[2], candidates[1]}, validators)
+	validators, err = be.selectBondedValidators(config, statedb, 40, maxValidatorsAt(config, statedb, 40))
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{candidates[2]}, validators)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	queue "github.com/enriquebris/goconcurrentqueue"
 	"golang.org/x/crypto/sha3"
@@ -11,7 +12,9 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
 	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
 	"github.com/Evrynetlabs/evrynet-node/p2p"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
@@ -19,6 +22,9 @@ import (
 var (
 	// errDecodeFailed is returned when decode message fails
 	errDecodeFailed = errors.New("fail to decode istanbul message")
+
+	storedMsgsGauge        = metrics.NewRegisteredGauge("evr/consensus/tendermint/backend/storedmsgs/depth", nil)
+	droppedStoredMsgsMeter = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/storedmsgs/dropped", nil)
 )
 
 func rLPHash(v interface{}) (h common.Hash) {
@@ -36,8 +42,30 @@ func (sb *Backend) decode(msg p2p.Msg) ([]byte, common.Hash, error) {
 	return data, rLPHash(data), nil
 }
 
-func (sb *Backend) sendDataToCore(data []byte) error {
-	return sb.checkAndSendMsg(data)
+// msgCode returns the code of the consensus message of data,
+// false if data is not the RLP of a consensus message: code, msg, address, signature and an optional trace id
+func msgCode(data []byte) (uint64, bool) {
+	var msg struct {
+		Code      uint64
+		Msg       []byte
+		Address   common.Address
+		Signature []byte
+		TraceID   []string `rlp:"tail"`
+	}
+	if err := rlp.DecodeBytes(data, &msg); err != nil {
+		return 0, false
+	}
+	return msg.Code, true
+}
+
+// storedMsg is a consensus message of a peer waiting in storingMsgs to be passed to core
+type storedMsg struct {
+	payload []byte
+	from    common.Address
+}
+
+func (sb *Backend) sendDataToCore(msg storedMsg) error {
+	return sb.checkAndSendMsg(msg.payload, msg.from)
 }
 
 func (sb *Backend) replayTendermintMsg() (done bool, err error) {
@@ -61,11 +89,12 @@ func (sb *Backend) replayTendermintMsg() (done bool, err error) {
 		log.Error("failed to get data from queue", "error", err)
 		return false, err
 	}
-	if err := sb.sendDataToCore(stored.([]byte)); err != nil {
+	if err := sb.sendDataToCore(stored.(storedMsg)); err != nil {
 		log.Error("failed to Post msg to core", "error", err)
 		return false, err
 	}
 	_, _ = sb.storingMsgs.Dequeue()
+	storedMsgsGauge.Update(int64(sb.storingMsgs.GetLen()))
 	return false, nil
 }
 
@@ -92,51 +121,114 @@ func (sb *Backend) dequeueMsgLoop() {
 // HandleMsg implements consensus.Handler.HandleMsg
 // return false if the message cannot be handle by Tendermint Backend
 func (sb *Backend) HandleMsg(addr common.Address, msg p2p.Msg) (bool, error) {
+	switch msg.Code {
+	case consensus.TendermintMsg, consensus.TendermintEnvelopeMsg, consensus.TendermintProtobufMsg:
+		// the peer is disconnected once core has rejected too many of its messages
+		if err := sb.peerScores.dropped(addr); err != nil {
+			return true, err
+		}
+	}
 	switch msg.Code {
 	case consensus.TendermintMsg:
-		decodedMsg, _, err := sb.decode(msg)
+		decodedMsg, hash, err := sb.decode(msg)
 		if err != nil {
 			log.Error("failed to decode message from p2p.Msg", "err", err)
 			return true, err
 		}
+		code, ok := msgCode(decodedMsg)
+		if !ok {
+			log.Debug("received an invalid consensus message", "from", addr)
+			// the peer is disconnected once it has sent too many invalid messages
+			return true, sb.peerScores.invalid(addr)
+		}
+		return true, sb.handlePayload(addr, code, decodedMsg, hash)
+	case consensus.TendermintEnvelopeMsg, consensus.TendermintProtobufMsg:
+		return true, sb.handleEnvelope(addr, msg)
+	default:
+		return false, fmt.Errorf("unknown message code %d for Tendermint's protocol", msg.Code)
+		//TODO:Handler other cases
+		//Case 1: NewBlock when this node is the propose.
+		//More cases to be added...
+	}
+}
 
-		//Dequeue if storingMsg reached max
-		if sb.storingMsgs.GetLen() >= maxNumberMessages {
-			for n := sb.storingMsgs.GetLen() - maxNumberMessages; n > 0; n-- {
-				//Free a slot for new message
-				_, err := sb.storingMsgs.Dequeue()
-				if err != nil {
-					log.Error("failed to free a message from queue", "err", err)
-					return true, err
-				}
+// handlePayload handles a consensus message of code received from a peer by its kind
+func (sb *Backend) handlePayload(addr common.Address, code uint64, data []byte, hash common.Hash) error {
+	if tendermintCore.IsDuplicateProposal(code) || tendermintCore.IsAmnesia(code) || tendermintCore.IsLunatic(code) {
+		return sb.handleEvidenceMsg(addr, code, data, hash)
+	}
+	if tendermintCore.IsVoteBatch(code) {
+		return sb.handleVoteBatch(addr, data)
+	}
+	return sb.handleConsensusMsg(addr, code, data, hash)
+}
+
+// handleConsensusMsg queues a consensus message received from a peer for core, unless core already has it
+func (sb *Backend) handleConsensusMsg(addr common.Address, code uint64, data []byte, hash common.Hash) error {
+	// acknowledge every delivery, the sender resends the proposals which are not acknowledged
+	if tendermintCore.IsProposal(code) {
+		go sb.ackProposal(addr, hash)
+	}
+	if view, ok := tendermintCore.MsgView(data); ok {
+		sb.peerViews.observe(addr, view)
+	}
+	first, duplicate := sb.msgCaches.markRecent(hash)
+	sb.msgCaches.markKnown(addr, hash)
+	sb.peerScores.received(addr, time.Since(first), duplicate)
+	if duplicate {
+		// core already has the message, handling it again only feeds re-broadcast storms
+		return nil
+	}
+
+	//Dequeue if storingMsg reached max
+	if sb.storingMsgs.GetLen() >= maxNumberMessages {
+		for n := sb.storingMsgs.GetLen() - maxNumberMessages; n > 0; n-- {
+			//Free a slot for new message
+			_, err := sb.storingMsgs.Dequeue()
+			if err != nil {
+				log.Error("failed to free a message from queue", "err", err)
+				return err
 			}
+			droppedStoredMsgsMeter.Mark(1)
 		}
+	}
 
-		if err := sb.storingMsgs.Enqueue(decodedMsg); err != nil {
-			log.Error("failed to store message to queue", "err", err)
-			return true, err
-		}
+	if err := sb.storingMsgs.Enqueue(storedMsg{payload: data, from: addr}); err != nil {
+		log.Error("failed to store message to queue", "err", err)
+		return err
+	}
+	storedMsgsGauge.Update(int64(sb.storingMsgs.GetLen()))
 
-		//log.Debug("Received Message from peer", "address", addr.Hex(), "code", msg.Code, "hash", hash.String())
-		//TODO: mark peer's message and self known message with the hash get from message
+	//log.Debug("Received Message from peer", "address", addr.Hex(), "code", msg.Code, "hash", hash.String())
+	//TODO: mark peer's message and self known message with the hash get from message
 
-		// Trigger dequeue loop
-		go func() {
-			sb.dequeueMsgTriggering <- struct{}{}
-		}()
-		return true, nil
+	sb.triggerDequeue()
+	return nil
+}
+
+// triggerDequeue wakes the dequeue loop up. A pending signal already replays every stored message,
+// so the signal is not sent if the loop has enough of them pending rather than waiting in a goroutine per message.
+func (sb *Backend) triggerDequeue() {
+	select {
+	case sb.dequeueMsgTriggering <- struct{}{}:
 	default:
-		return false, fmt.Errorf("unknown message code %d for Tendermint's protocol", msg.Code)
-		//TODO:Handler other cases
-		//Case 1: NewBlock when this node is the propose.
-		//More cases to be added...
 	}
 }
 
+// HandleInvalidMsg implements tendermint.InvalidMsgHandler.HandleInvalidMsg
+func (sb *Backend) HandleInvalidMsg(addr common.Address, err error) {
+	log.Debug("core rejected a consensus message", "from", addr, "err", err)
+	sb.peerScores.rejected(addr)
+}
+
 // HandleNewChainHead implements consensus.Handler.HandleNewChainHead
 func (sb *Backend) HandleNewChainHead(blockNumber *big.Int) error {
+	sb.finalized.post(blockNumber)
+	go sb.pruneEvidences()
+
 	sb.mutex.RLock()
 	defer sb.mutex.RUnlock()
+	sb.recordCommit(blockNumber)
 	if !sb.coreStarted {
 		return tendermint.ErrStoppedEngine
 	}
@@ -13,6 +13,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
@@ -50,11 +51,38 @@ func TestHandleMsg(t *testing.T) {
 	}
 }
 
+func TestBackend_HandleInvalidMsg(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		validators     = []common.Address{crypto.PubkeyToAddress(nodePrivateKey.PublicKey)}
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, tests_utils.MakeGenesisHeader(validators), validators)
+		addr           = tests_utils.GetAddress()
+	)
+	// the peer is disconnected at its next message once core has rejected too many of its messages
+	for i := 0; i <= maxInvalidMsgs; i++ {
+		be.HandleInvalidMsg(addr, tendermintCore.ErrSignerMessageMissMatch)
+	}
+	handled, err := be.HandleMsg(addr, makeMsg(consensus.TendermintMsg, makeConsensusPayload(1)))
+	require.True(t, handled)
+	require.Equal(t, errTooManyInvalidMsgs, err)
+}
+
 func makeMsg(msgcode uint64, data interface{}) p2p.Msg {
 	size, r, _ := rlp.EncodeToReader(data)
 	return p2p.Msg{Code: msgcode, Size: uint32(size), Payload: r}
 }
 
+// makeConsensusPayload returns the RLP of a well formed consensus message carrying count
+func makeConsensusPayload(count int) []byte {
+	payload, _ := rlp.EncodeToBytes(struct {
+		Code      uint64
+		Msg       []byte
+		Address   common.Address
+		Signature []byte
+	}{Msg: []byte(strconv.FormatInt(int64(count), 10))})
+	return payload
+}
+
 // mockCore is similar to real core with fixed time for processing each request
 // mockCore also has 'numMsg' variable for testing
 type mockCore struct {
@@ -107,6 +135,30 @@ func (m *mockCore) SetBlockForProposal(block *types.Block) {
 	panic("implement me")
 }
 
+func (m *mockCore) Snapshot() *tendermintCore.StateSnapshot {
+	return &tendermintCore.StateSnapshot{}
+}
+
+func (m *mockCore) Status() *tendermintCore.Status {
+	return nil
+}
+
+func (m *mockCore) AddStepHook(hook tendermintCore.StepHook) func() {
+	return func() {}
+}
+
+func (m *mockCore) AddLockHook(hook tendermintCore.LockHook) func() {
+	return func() {}
+}
+
+func (m *mockCore) SetPaused(paused bool) bool {
+	return false
+}
+
+func (m *mockCore) Paused() bool {
+	return false
+}
+
 // This test case is when user start miner then stop it before core handles all msg in storingMsgs
 func TestBackend_HandleMsg(t *testing.T) {
 	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
@@ -120,14 +172,14 @@ func TestBackend_HandleMsg(t *testing.T) {
 	// send msg when core is not started
 	numMsg := 10
 	for i := 0; i < numMsg; i++ {
-		_, err := be.HandleMsg(common.Address{}, makeMsg(consensus.TendermintMsg, []byte(strconv.FormatInt(int64(count), 10))))
+		_, err := be.HandleMsg(common.Address{}, makeMsg(consensus.TendermintMsg, makeConsensusPayload(count)))
 		count += 1
 		require.NoError(t, err)
 	}
 	// start core
 	require.NoError(t, be.Start(blockchain, blockchain.CurrentBlock, nil))
 	// trigger to  dequeue and replay msg
-	_, err = be.HandleMsg(common.Address{}, makeMsg(consensus.TendermintMsg, []byte(strconv.FormatInt(int64(count), 10))))
+	_, err = be.HandleMsg(common.Address{}, makeMsg(consensus.TendermintMsg, makeConsensusPayload(count)))
 	count += 1
 	require.NoError(t, err)
 	time.Sleep(time.Millisecond)
@@ -135,7 +187,7 @@ func TestBackend_HandleMsg(t *testing.T) {
 	require.NoError(t, be.Stop())
 
 	require.NoError(t, be.Start(blockchain, blockchain.CurrentBlock, nil))
-	_, err = be.HandleMsg(common.Address{}, makeMsg(consensus.TendermintMsg, []byte(strconv.FormatInt(int64(count), 10))))
+	_, err = be.HandleMsg(common.Address{}, makeMsg(consensus.TendermintMsg, makeConsensusPayload(count)))
 	require.NoError(t, err)
 
 	time.Sleep(time.Millisecond * 16)
@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+const (
+	// defaultHealthBlocks is the number of block periods without a new head after which consensus is not advancing
+	defaultHealthBlocks = 10
+	// defaultHealthSignedBlocks is the number of recent blocks a validator must have signed one of to be ready
+	defaultHealthSignedBlocks = 10
+	// maxHealthSignedBlocks bounds the blocks read to check a validator signed recently
+	maxHealthSignedBlocks = 1024
+)
+
+// HealthStatus is the body of the health and readiness probes
+type HealthStatus struct {
+	Number         uint64         `json:"number"`
+	HeadAge        uint64         `json:"headAge"` // seconds since the timestamp of the head
+	Advancing      bool           `json:"advancing"`
+	IsValidator    bool           `json:"isValidator"`
+	SignedRecently bool           `json:"signedRecently"` // always true when the node is not a validator
+	Address        common.Address `json:"address"`
+}
+
+// healthStatus reports whether a head was produced within blocks block periods and,
+// if the node is a validator of the next block, whether it signed one of the last signedBlocks blocks.
+func (sb *Backend) healthStatus(chain consensus.ChainReader, blocks, signedBlocks uint64) *HealthStatus {
+	var (
+		head   = chain.CurrentHeader()
+		period = sb.config.BlockPeriod
+		now    = uint64(time.Now().Unix())
+		status = &HealthStatus{Number: head.Number.Uint64(), Address: sb.Address(), SignedRecently: true}
+	)
+	if period == 0 {
+		period = 1
+	}
+	if now > head.Time {
+		status.HeadAge = now - head.Time
+	}
+	status.Advancing = status.HeadAge <= blocks*period
+
+	for _, val := range sb.BlockValidators(chain, status.Number+1) {
+		if val == status.Address {
+			status.IsValidator = true
+			break
+		}
+	}
+	if !status.IsValidator {
+		return status
+	}
+	status.SignedRecently = false
+	for header := head; header != nil && header.Number.Sign() > 0 && signedBlocks > 0; signedBlocks-- {
+		signers, err := sb.BlockSigners(header)
+		if err != nil {
+			log.Debug("Failed to read the signers of the block", "number", header.Number, "err", err)
+			break
+		}
+		for _, signer := range signers {
+			if signer == status.Address {
+				status.SignedRecently = true
+				return status
+			}
+		}
+		header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return status
+}
+
+// HealthHandler returns the HTTP handler of the probes of the consensus liveness.
+// /health succeeds when consensus is advancing, for liveness checks, and /ready also requires
+// a validator to have signed recently, for load-balancer eligibility.
+// The number of block periods and of recent blocks checked can be set by the blocks and signed query parameters.
+func (sb *Backend) HealthHandler(chain consensus.ChainReader) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		status, ok := sb.serveHealth(w, r, chain)
+		if ok {
+			writeHealthStatus(w, status, status.Advancing)
+		}
+	})
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		status, ok := sb.serveHealth(w, r, chain)
+		if ok {
+			writeHealthStatus(w, status, status.Advancing && status.SignedRecently)
+		}
+	})
+	return mux
+}
+
+// serveHealth parses the query parameters of a probe and computes the health status
+func (sb *Backend) serveHealth(w http.ResponseWriter, r *http.Request, chain consensus.ChainReader) (*HealthStatus, bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	blocks, err := healthParam(r, "blocks", defaultHealthBlocks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	signedBlocks, err := healthParam(r, "signed", defaultHealthSignedBlocks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if signedBlocks > maxHealthSignedBlocks {
+		signedBlocks = maxHealthSignedBlocks
+	}
+	return sb.healthStatus(chain, blocks, signedBlocks), true
+}
+
+func healthParam(r *http.Request, name string, def uint64) (uint64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func writeHealthStatus(w http.ResponseWriter, status *HealthStatus, healthy bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Debug("Failed to write the health status", "err", err)
+	}
+}
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestBackend_HealthHandler(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{nodeAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	)
+	header := tests_utils.MakeBlockWithSeal(be, genesisHeader).Header()
+	header.Time = uint64(time.Now().Unix())
+	committedSeal, err := crypto.Sign(crypto.Keccak256(utils.PrepareCommittedSeal(header.Hash())), nodePrivateKey)
+	require.NoError(t, err)
+	tests_utils.AppendCommittedSeal(header, committedSeal)
+
+	probe := func(chain consensus.ChainReader, path string) (int, *HealthStatus) {
+		recorder := httptest.NewRecorder()
+		be.HealthHandler(chain).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code == http.StatusBadRequest {
+			return recorder.Code, nil
+		}
+		var status HealthStatus
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+		return recorder.Code, &status
+	}
+
+	// a validator which signed the head
+	chain := tests_utils.NewHeadersMockChainReader([]*types.Header{genesisHeader, header})
+	code, status := probe(chain, "/health")
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, status.Advancing)
+	require.Equal(t, uint64(1), status.Number)
+	code, status = probe(chain, "/ready")
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, status.IsValidator)
+	require.True(t, status.SignedRecently)
+
+	// the genesis is too old for consensus to be advancing and no block was signed
+	chain = tests_utils.NewHeadersMockChainReader([]*types.Header{genesisHeader})
+	code, status = probe(chain, "/health")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.False(t, status.Advancing)
+	code, status = probe(chain, "/ready")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.False(t, status.SignedRecently)
+
+	code, _ = probe(chain, "/health?blocks=abc")
+	require.Equal(t, http.StatusBadRequest, code)
+}
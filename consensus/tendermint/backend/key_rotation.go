@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// useNextKey switches to the next validator key once the validator set lists it instead of the current key,
+// which happens at the first block after the checkpoint of its key rotation.
+// It returns whether the key was switched.
+func (sb *Backend) useNextKey(valSet tendermint.ValidatorSet) bool {
+	sb.keyMu.RLock()
+	nextKey, address := sb.nextKey, sb.address
+	sb.keyMu.RUnlock()
+	if nextKey == nil || valSet == nil {
+		return false
+	}
+	next := crypto.PubkeyToAddress(nextKey.PublicKey)
+	if _, v := valSet.GetByAddress(next); v == nil {
+		return false
+	}
+	if _, v := valSet.GetByAddress(address); v != nil {
+		return false
+	}
+
+	sb.keyMu.Lock()
+	defer sb.keyMu.Unlock()
+	if sb.nextKey != nextKey {
+		return false
+	}
+	sb.privateKey, sb.address, sb.nextKey = nextKey, next, nil
+	log.Info("Switched to the next validator key", "old", address, "new", next)
+	return true
+}
+
+// hasNextKey returns whether the validator has a key to rotate to
+func (sb *Backend) hasNextKey() bool {
+	sb.keyMu.RLock()
+	defer sb.keyMu.RUnlock()
+	return sb.nextKey != nil
+}
+
+// SignWithNextKey signs the data with the next validator key, it returns nil if there is none
+func (sb *Backend) SignWithNextKey(data []byte) ([]byte, error) {
+	sb.keyMu.RLock()
+	defer sb.keyMu.RUnlock()
+	if sb.nextKey == nil {
+		return nil, nil
+	}
+	return crypto.Sign(crypto.Keccak256(data), sb.nextKey)
+}
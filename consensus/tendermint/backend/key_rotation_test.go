@@ -0,0 +1,43 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestBackend_UseNextKey(t *testing.T) {
+	var (
+		key     = tests_utils.MakeNodeKey()
+		nextKey = tests_utils.MakeNodeKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		next    = crypto.PubkeyToAddress(nextKey.PublicKey)
+		other   = common.Address{1}
+		b       = &Backend{privateKey: key, address: addr, nextKey: nextKey}
+	)
+	sig, err := b.SignWithNextKey([]byte("data"))
+	require.NoError(t, err)
+	pubKey, err := crypto.SigToPub(crypto.Keccak256([]byte("data")), sig)
+	require.NoError(t, err)
+	require.Equal(t, next, crypto.PubkeyToAddress(*pubKey))
+
+	// the key is kept while the validator set lists it
+	require.False(t, b.useNextKey(validator.NewSet([]common.Address{addr, other}, tendermint.RoundRobin, 0)))
+	require.False(t, b.useNextKey(validator.NewSet([]common.Address{addr, next}, tendermint.RoundRobin, 0)))
+	require.Equal(t, addr, b.Address())
+
+	// the next key is used once the validator set lists it instead
+	require.True(t, b.useNextKey(validator.NewSet([]common.Address{next, other}, tendermint.RoundRobin, 0)))
+	require.Equal(t, next, b.Address())
+	require.False(t, b.hasNextKey())
+	sig, err = b.SignWithNextKey([]byte("data"))
+	require.NoError(t, err)
+	require.Nil(t, sig)
+	require.False(t, b.useNextKey(validator.NewSet([]common.Address{next, other}, tendermint.RoundRobin, 0)))
+}
@@ -0,0 +1,242 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+	"github.com/Evrynetlabs/evrynet-node/metrics/prometheus"
+)
+
+const (
+	// inMemoryEpochPerformances is the number of epochs whose validator performance is kept
+	inMemoryEpochPerformances = 16
+	// consensusGaugePrefix is the prefix of the consensus gauges of the metrics server
+	consensusGaugePrefix = "evr/consensus/tendermint/"
+)
+
+// ValidatorPerformance is the participation of a validator in the blocks of an epoch
+type ValidatorPerformance struct {
+	Address  common.Address `json:"address"`
+	Proposed uint64         `json:"proposed"` // blocks proposed by the validator
+	Signed   uint64         `json:"signed"`   // blocks whose committed seals include a seal of the validator
+	Missed   uint64         `json:"missed"`   // blocks committed without a seal of the validator
+}
+
+// EpochPerformance is the participation of the validators of an epoch in its blocks
+type EpochPerformance struct {
+	Epoch      uint64                 `json:"epoch"`
+	FirstBlock uint64                 `json:"firstBlock"`
+	LastBlock  uint64                 `json:"lastBlock"` // the last block of the epoch in the chain, before FirstBlock if there is none yet
+	Complete   bool                   `json:"complete"`  // false while the epoch is in progress
+	Validators []ValidatorPerformance `json:"validators"`
+}
+
+// ConsensusSummary is the body of the /consensus endpoint of the metrics server
+type ConsensusSummary struct {
+	Status *StatusInfo       `json:"status"`
+	Health *HealthStatus     `json:"health"`
+	Epoch  *EpochPerformance `json:"epoch"`
+}
+
+// epochPerformances computes the validator performance of the epochs incrementally as the blocks are added,
+// the blocks are final so the counts of the blocks already read never change.
+type epochPerformances struct {
+	mu     sync.Mutex
+	epochs *lru.Cache // epoch number -> *EpochPerformance
+}
+
+func newEpochPerformances() *epochPerformances {
+	epochs, _ := lru.New(inMemoryEpochPerformances)
+	return &epochPerformances{epochs: epochs}
+}
+
+// epochPerformance returns the validator performance of the epoch up to the head of the chain
+func (sb *Backend) epochPerformance(chain consensus.ChainReader, epoch uint64) (*EpochPerformance, error) {
+	var (
+		size = sb.config.Epoch
+		head = chain.CurrentHeader().Number.Uint64()
+	)
+	if size == 0 {
+		return nil, fmt.Errorf("epoch length is zero")
+	}
+	if epoch > 0 && epoch*size >= head {
+		return nil, fmt.Errorf("epoch %d has not started, the head is %d", epoch, head)
+	}
+
+	sb.performances.mu.Lock()
+	defer sb.performances.mu.Unlock()
+
+	var perf *EpochPerformance
+	if cached, ok := sb.performances.epochs.Get(epoch); ok {
+		perf = cached.(*EpochPerformance)
+	} else {
+		valSet, err := sb.valSetInfo.GetValSet(chain, new(big.Int).SetUint64(epoch*size+1))
+		if err != nil {
+			return nil, err
+		}
+		perf = &EpochPerformance{Epoch: epoch, FirstBlock: epoch*size + 1, LastBlock: epoch * size}
+		for _, val := range valSet.List() {
+			perf.Validators = append(perf.Validators, ValidatorPerformance{Address: val.Address()})
+		}
+	}
+
+	last := (epoch + 1) * size
+	if last > head {
+		last = head
+	}
+	index := make(map[common.Address]int, len(perf.Validators))
+	for i, val := range perf.Validators {
+		index[val.Address] = i
+	}
+	for number := perf.LastBlock + 1; number <= last; number++ {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, fmt.Errorf("missing header %d", number)
+		}
+		proposer, err := sb.Author(header)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[proposer]; ok {
+			perf.Validators[i].Proposed++
+		}
+		signers, err := sb.BlockSigners(header)
+		if err != nil {
+			return nil, err
+		}
+		signed := make(map[common.Address]bool, len(signers))
+		for _, signer := range signers {
+			signed[signer] = true
+		}
+		for i := range perf.Validators {
+			if signed[perf.Validators[i].Address] {
+				perf.Validators[i].Signed++
+			} else {
+				perf.Validators[i].Missed++
+			}
+		}
+		perf.LastBlock = number
+	}
+	perf.Complete = perf.LastBlock == (epoch+1)*size
+	sb.performances.epochs.Add(epoch, perf)
+
+	result := *perf
+	result.Validators = append([]ValidatorPerformance(nil), perf.Validators...)
+	return &result, nil
+}
+
+// currentEpoch returns the epoch of the head of the chain, the genesis is counted in the first epoch
+func (sb *Backend) currentEpoch(chain consensus.ChainReader) uint64 {
+	head := chain.CurrentHeader().Number.Uint64()
+	if head == 0 || sb.config.Epoch == 0 {
+		return 0
+	}
+	return (head - 1) / sb.config.Epoch
+}
+
+// consensusGauges returns the gauges of the consensus state, the performance of this node in the current epoch included
+func (sb *Backend) consensusGauges(chain consensus.ChainReader) map[string]interface{} {
+	var (
+		health = sb.healthStatus(chain, defaultHealthBlocks, defaultHealthSignedBlocks)
+		lag    = sb.consensusLag(chain.CurrentHeader())
+		gauges = map[string]interface{}{
+			"head":      metrics.GaugeSnapshot(health.Number),
+			"headage":   metrics.GaugeSnapshot(health.HeadAge),
+			"advancing": boolGauge(health.Advancing),
+			"validator": boolGauge(health.IsValidator),
+			"epoch":     metrics.GaugeSnapshot(sb.currentEpoch(chain)),
+			"lag":       metrics.GaugeSnapshot(lag.Lag),
+			"peers":     metrics.GaugeSnapshot(len(lag.Peers)),
+		}
+	)
+	if status := sb.coreStatus(); status != nil {
+		gauges["height"] = metrics.GaugeSnapshot(status.BlockNumber.Int64())
+		gauges["round"] = metrics.GaugeSnapshot(status.Round)
+		gauges["step"] = metrics.GaugeSnapshot(status.Step)
+		gauges["proposer"] = boolGauge(status.IsProposer)
+	}
+	if valSet := sb.ValidatorsByChainReader(new(big.Int).SetUint64(health.Number+1), chain); valSet != nil {
+		gauges["validators"] = metrics.GaugeSnapshot(valSet.Size())
+	}
+	perf, err := sb.epochPerformance(chain, sb.currentEpoch(chain))
+	if err != nil {
+		log.Debug("Failed to compute the epoch performance", "err", err)
+		return gauges
+	}
+	for _, val := range perf.Validators {
+		if val.Address != health.Address {
+			continue
+		}
+		gauges["epoch/proposed"] = metrics.GaugeSnapshot(val.Proposed)
+		gauges["epoch/signed"] = metrics.GaugeSnapshot(val.Signed)
+		gauges["epoch/missed"] = metrics.GaugeSnapshot(val.Missed)
+		if total := val.Signed + val.Missed; total > 0 {
+			gauges["epoch/signedratio"] = metrics.GaugeFloat64Snapshot(float64(val.Signed) / float64(total))
+		}
+	}
+	return gauges
+}
+
+func boolGauge(b bool) metrics.GaugeSnapshot {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// MetricsHandler returns the HTTP handler of the metrics server for the consensus dashboards.
+// /metrics serves the registered metrics of the node, preloaded with the consensus gauges, in the Prometheus format
+// and /consensus serves a JSON summary of the consensus status and of the validator performance of an epoch,
+// the current one by default or the one of the epoch query parameter.
+func (sb *Backend) MetricsHandler(chain consensus.ChainReader) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		registry := metrics.NewRegistry()
+		metrics.DefaultRegistry.Each(func(name string, metric interface{}) {
+			registry.Register(name, metric)
+		})
+		for name, gauge := range sb.consensusGauges(chain) {
+			registry.Register(consensusGaugePrefix+name, gauge)
+		}
+		prometheus.Handler(registry).ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/consensus", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		epoch := sb.currentEpoch(chain)
+		if value := r.URL.Query().Get("epoch"); value != "" {
+			var err error
+			if epoch, err = strconv.ParseUint(value, 10, 64); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		perf, err := sb.epochPerformance(chain, epoch)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		summary := &ConsensusSummary{
+			Status: statusInfo(sb.coreStatus()),
+			Health: sb.healthStatus(chain, defaultHealthBlocks, defaultHealthSignedBlocks),
+			Epoch:  perf,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(summary); err != nil {
+			log.Debug("Failed to write the consensus summary", "err", err)
+		}
+	})
+	return mux
+}
@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestBackend_MetricsHandler(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		otherAddr      = common.HexToAddress("0x1")
+		validators     = []common.Address{nodeAddr, otherAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	)
+	header := tests_utils.MakeBlockWithSeal(be, genesisHeader).Header()
+	committedSeal, err := crypto.Sign(crypto.Keccak256(utils.PrepareCommittedSeal(header.Hash())), nodePrivateKey)
+	require.NoError(t, err)
+	tests_utils.AppendCommittedSeal(header, committedSeal)
+
+	get := func(chain consensus.ChainReader, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		be.MetricsHandler(chain).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+	performance := func(chain consensus.ChainReader, path string) *EpochPerformance {
+		recorder := get(chain, path)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var summary ConsensusSummary
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &summary))
+		require.NotNil(t, summary.Health)
+		return summary.Epoch
+	}
+
+	// no block of the first epoch yet, the validators are sorted by address
+	perf := performance(tests_utils.NewHeadersMockChainReader([]*types.Header{genesisHeader}), "/consensus")
+	require.Equal(t, uint64(0), perf.Epoch)
+	require.Equal(t, uint64(1), perf.FirstBlock)
+	require.Equal(t, uint64(0), perf.LastBlock)
+	require.False(t, perf.Complete)
+	require.Equal(t, []ValidatorPerformance{{Address: otherAddr}, {Address: nodeAddr}}, perf.Validators)
+
+	// the block proposed and signed by the node is counted once added to the chain
+	chain := tests_utils.NewHeadersMockChainReader([]*types.Header{genesisHeader, header})
+	perf = performance(chain, "/consensus?epoch=0")
+	require.Equal(t, uint64(1), perf.LastBlock)
+	require.Equal(t, []ValidatorPerformance{
+		{Address: otherAddr, Missed: 1},
+		{Address: nodeAddr, Proposed: 1, Signed: 1},
+	}, perf.Validators)
+	require.Equal(t, perf, performance(chain, "/consensus"))
+
+	require.Equal(t, http.StatusNotFound, get(chain, "/consensus?epoch=1").Code)
+	require.Equal(t, http.StatusBadRequest, get(chain, "/consensus?epoch=abc").Code)
+
+	recorder := get(chain, "/metrics")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	require.Contains(t, body, "evr_consensus_tendermint_head 1\n")
+	require.Contains(t, body, "evr_consensus_tendermint_validator 1\n")
+	require.Contains(t, body, "evr_consensus_tendermint_epoch_proposed 1\n")
+	require.Contains(t, body, "evr_consensus_tendermint_epoch_signed 1\n")
+}
@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+)
+
+const (
+	defaultRecentMessages = maxNumberMessages // default number of recent message hashes kept to drop the duplicates
+	defaultKnownMessages  = 1024              // default number of message hashes kept per peer not to send them back
+	inMemoryPeers         = 1024              // number of peers whose known messages are kept
+)
+
+var (
+	recentMsgsHitMeter   = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/recentmsgs/hit", nil)
+	recentMsgsMissMeter  = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/recentmsgs/miss", nil)
+	recentMsgsEvictMeter = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/recentmsgs/evict", nil)
+	knownMsgsHitMeter    = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/knownmsgs/hit", nil)
+	knownMsgsMissMeter   = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/knownmsgs/miss", nil)
+	knownMsgsEvictMeter  = metrics.NewRegisteredMeter("evr/consensus/tendermint/backend/knownmsgs/evict", nil)
+)
+
+// msgCaches deduplicates the consensus messages:
+// recent drops the messages already received, known avoids sending peers the messages they sent us.
+type msgCaches struct {
+	mu        sync.Mutex
+	recent    *lru.Cache // common.Hash -> time.Time the message was first received
+	known     *lru.Cache // common.Address -> *lru.Cache of the common.Hash known by the peer
+	knownSize int
+}
+
+// newMsgCaches returns the caches with the given sizes, the defaults are used for non positive sizes
+func newMsgCaches(recentSize, knownSize int) *msgCaches {
+	if recentSize <= 0 {
+		recentSize = defaultRecentMessages
+	}
+	if knownSize <= 0 {
+		knownSize = defaultKnownMessages
+	}
+	recent, _ := lru.NewWithEvict(recentSize, func(key interface{}, value interface{}) {
+		recentMsgsEvictMeter.Mark(1)
+	})
+	known, _ := lru.New(inMemoryPeers)
+	return &msgCaches{
+		recent:    recent,
+		known:     known,
+		knownSize: knownSize,
+	}
+}
+
+// markRecent records the message and returns the time it was first received,
+// with true if it was already received.
+func (mc *msgCaches) markRecent(hash common.Hash) (time.Time, bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if first, ok := mc.recent.Get(hash); ok {
+		recentMsgsHitMeter.Mark(1)
+		return first.(time.Time), true
+	}
+	recentMsgsMissMeter.Mark(1)
+	now := time.Now()
+	mc.recent.Add(hash, now)
+	return now, false
+}
+
+// markKnown records that the peer has the message
+func (mc *msgCaches) markKnown(addr common.Address, hash common.Hash) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	known, ok := mc.known.Get(addr)
+	if !ok {
+		known, _ = lru.NewWithEvict(mc.knownSize, func(key interface{}, value interface{}) {
+			knownMsgsEvictMeter.Mark(1)
+		})
+		mc.known.Add(addr, known)
+	}
+	known.(*lru.Cache).Add(hash, struct{}{})
+}
+
+// isKnown returns true if the peer is known to have the message
+func (mc *msgCaches) isKnown(addr common.Address, hash common.Hash) bool {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if known, ok := mc.known.Get(addr); ok && known.(*lru.Cache).Contains(hash) {
+		knownMsgsHitMeter.Mark(1)
+		return true
+	}
+	knownMsgsMissMeter.Mark(1)
+	return false
+}
+
+// unknownTargets returns the targets which are not known to have the message
+func (mc *msgCaches) unknownTargets(targets map[common.Address]bool, hash common.Hash) map[common.Address]bool {
+	unknown := make(map[common.Address]bool, len(targets))
+	for addr := range targets {
+		if !mc.isKnown(addr, hash) {
+			unknown[addr] = true
+		}
+	}
+	return unknown
+}
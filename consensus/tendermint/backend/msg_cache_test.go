@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+func TestMsgCaches_MarkRecent(t *testing.T) {
+	var (
+		mc    = newMsgCaches(2, 0)
+		hash1 = common.HexToHash("0x11")
+		hash2 = common.HexToHash("0x12")
+		hash3 = common.HexToHash("0x13")
+	)
+	first, duplicate := mc.markRecent(hash1)
+	require.False(t, duplicate)
+	again, duplicate := mc.markRecent(hash1)
+	require.True(t, duplicate)
+	require.Equal(t, first, again)
+
+	// hash1 is evicted by the configured size
+	mc.markRecent(hash2)
+	mc.markRecent(hash3)
+	_, duplicate = mc.markRecent(hash1)
+	require.False(t, duplicate)
+}
+
+func TestMsgCaches_Known(t *testing.T) {
+	var (
+		mc    = newMsgCaches(0, 1)
+		peer1 = common.HexToAddress("0x01")
+		peer2 = common.HexToAddress("0x02")
+		hash1 = common.HexToHash("0x11")
+		hash2 = common.HexToHash("0x12")
+	)
+	mc.markKnown(peer1, hash1)
+	require.True(t, mc.isKnown(peer1, hash1))
+	require.False(t, mc.isKnown(peer2, hash1))
+	require.Equal(t, map[common.Address]bool{peer2: true}, mc.unknownTargets(map[common.Address]bool{peer1: true, peer2: true}, hash1))
+
+	// only one message is kept per peer
+	mc.markKnown(peer1, hash2)
+	require.False(t, mc.isKnown(peer1, hash1))
+	require.True(t, mc.isKnown(peer1, hash2))
+}
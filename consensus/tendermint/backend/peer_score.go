@@ -0,0 +1,187 @@
+package backend
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+const (
+	inMemoryPeerScores = 1024 // number of peers whose score is kept
+
+	maxInvalidMsgs  = 16   // a peer which sent more invalid messages is dropped
+	invalidPenalty  = 10.0 // score lost for each invalid message
+	latencyPenalty  = 10.0 // score lost for each second a peer delivers messages after the fastest peer
+	latencySmoothen = 0.1  // weight of a new latency sample in the moving average
+)
+
+// errTooManyInvalidMsgs is returned by HandleMsg so that the peer is disconnected
+var errTooManyInvalidMsgs = errors.New("peer sent too many invalid consensus messages")
+
+// peerScore is the quality of the consensus traffic received from a peer
+type peerScore struct {
+	Useful    uint64        // messages the peer delivered first
+	Duplicate uint64        // messages the peer delivered after another peer
+	Invalid   uint64        // messages which could not be decoded
+	Latency   time.Duration // moving average of how late the peer delivers messages after the fastest peer
+}
+
+// Score returns the score of the peer, the higher the better.
+// It is the percentage of useful messages minus the penalties for the invalid messages and the latency.
+func (s *peerScore) Score() float64 {
+	ratio := 0.5
+	if total := s.Useful + s.Duplicate; total > 0 {
+		ratio = float64(s.Useful) / float64(total)
+	}
+	return ratio*100 - float64(s.Invalid)*invalidPenalty - s.Latency.Seconds()*latencyPenalty
+}
+
+// PeerScoreInfo is the score of a peer returned by the RPC API
+type PeerScoreInfo struct {
+	Useful    uint64  `json:"useful"`
+	Duplicate uint64  `json:"duplicate"`
+	Invalid   uint64  `json:"invalid"`
+	Latency   string  `json:"latency"`
+	Score     float64 `json:"score"`
+}
+
+// peerScores scores the peers on the consensus messages they send
+type peerScores struct {
+	mu       sync.Mutex
+	scores   *lru.Cache                  // common.Address -> *peerScore
+	dropping map[common.Address]struct{} // the peers to drop at their next message, see rejected
+}
+
+func newPeerScores() *peerScores {
+	scores, _ := lru.New(inMemoryPeerScores)
+	return &peerScores{
+		scores:   scores,
+		dropping: make(map[common.Address]struct{}),
+	}
+}
+
+func (ps *peerScores) get(addr common.Address) *peerScore {
+	if score, ok := ps.scores.Get(addr); ok {
+		return score.(*peerScore)
+	}
+	score := &peerScore{}
+	ps.scores.Add(addr, score)
+	return score
+}
+
+// received records a valid message from the peer, delay is how late it is after the first delivery of the message
+func (ps *peerScores) received(addr common.Address, delay time.Duration, duplicate bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	score := ps.get(addr)
+	if !duplicate {
+		score.Useful++
+		score.Latency = time.Duration(float64(score.Latency) * (1 - latencySmoothen))
+		return
+	}
+	score.Duplicate++
+	score.Latency = time.Duration(float64(score.Latency)*(1-latencySmoothen) + float64(delay)*latencySmoothen)
+}
+
+// invalid records an invalid message from the peer, it returns errTooManyInvalidMsgs if the peer should be dropped
+func (ps *peerScores) invalid(addr common.Address) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	score := ps.get(addr)
+	score.Invalid++
+	if score.Invalid > maxInvalidMsgs {
+		// forget the peer so that it starts over if it reconnects
+		ps.scores.Remove(addr)
+		return errTooManyInvalidMsgs
+	}
+	return nil
+}
+
+// rejected records an invalid message from the peer found by core once HandleMsg has returned,
+// the peer is dropped at its next message if it sent too many
+func (ps *peerScores) rejected(addr common.Address) {
+	if ps.invalid(addr) == nil {
+		return
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.dropping[addr] = struct{}{}
+}
+
+// dropped returns errTooManyInvalidMsgs once if core rejected too many messages of the peer
+func (ps *peerScores) dropped(addr common.Address) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.dropping[addr]; !ok {
+		return nil
+	}
+	delete(ps.dropping, addr)
+	return errTooManyInvalidMsgs
+}
+
+// score returns the score of the peer, a peer without messages has the score of a new peer
+func (ps *peerScores) score(addr common.Address) float64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if score, ok := ps.scores.Peek(addr); ok {
+		return score.(*peerScore).Score()
+	}
+	return (&peerScore{}).Score()
+}
+
+// snapshot returns the scores of all peers
+func (ps *peerScores) snapshot() map[common.Address]PeerScoreInfo {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	out := make(map[common.Address]PeerScoreInfo)
+	for _, key := range ps.scores.Keys() {
+		if value, ok := ps.scores.Peek(key); ok {
+			score := value.(*peerScore)
+			out[key.(common.Address)] = PeerScoreInfo{
+				Useful:    score.Useful,
+				Duplicate: score.Duplicate,
+				Invalid:   score.Invalid,
+				Latency:   score.Latency.String(),
+				Score:     score.Score(),
+			}
+		}
+	}
+	return out
+}
+
+// best returns the n targets with the highest scores
+func (ps *peerScores) best(targets map[common.Address]bool, n int) map[common.Address]bool {
+	var (
+		addrs  = make([]common.Address, 0, len(targets))
+		scores = make(map[common.Address]float64, len(targets))
+	)
+	for addr := range targets {
+		addrs = append(addrs, addr)
+		scores[addr] = ps.score(addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		if scores[addrs[i]] != scores[addrs[j]] {
+			return scores[addrs[i]] > scores[addrs[j]]
+		}
+		return addrs[i].Hex() < addrs[j].Hex()
+	})
+	if n > len(addrs) {
+		n = len(addrs)
+	}
+	out := make(map[common.Address]bool, n)
+	for _, addr := range addrs[:n] {
+		out[addr] = true
+	}
+	return out
+}
+
+// proposalRelays returns the number of best peers a proposal is sent to first
+func proposalRelays(targets int) int {
+	return int(math.Ceil(math.Sqrt(float64(targets))))
+}
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+func TestPeerScores_Received(t *testing.T) {
+	var (
+		ps   = newPeerScores()
+		fast = common.HexToAddress("0x01")
+		slow = common.HexToAddress("0x02")
+	)
+	ps.received(fast, 0, false)
+	ps.received(slow, time.Second, true)
+	ps.received(fast, 0, false)
+	ps.received(slow, time.Second, true)
+
+	scores := ps.snapshot()
+	require.Equal(t, uint64(2), scores[fast].Useful)
+	require.Equal(t, uint64(0), scores[fast].Duplicate)
+	require.Equal(t, uint64(2), scores[slow].Duplicate)
+	require.True(t, ps.score(fast) > ps.score(slow))
+
+	best := ps.best(map[common.Address]bool{fast: true, slow: true}, 1)
+	require.Equal(t, map[common.Address]bool{fast: true}, best)
+}
+
+func TestPeerScores_Invalid(t *testing.T) {
+	var (
+		ps   = newPeerScores()
+		addr = common.HexToAddress("0x01")
+	)
+	for i := 0; i < maxInvalidMsgs; i++ {
+		require.NoError(t, ps.invalid(addr))
+	}
+	require.True(t, ps.score(addr) < (&peerScore{}).Score())
+	require.Equal(t, errTooManyInvalidMsgs, ps.invalid(addr))
+	// the peer starts over once dropped
+	require.Equal(t, (&peerScore{}).Score(), ps.score(addr))
+}
+
+func TestPeerScores_Rejected(t *testing.T) {
+	var (
+		ps   = newPeerScores()
+		addr = common.HexToAddress("0x01")
+	)
+	for i := 0; i < maxInvalidMsgs; i++ {
+		ps.rejected(addr)
+	}
+	require.NoError(t, ps.dropped(addr))
+	ps.rejected(addr)
+	// the peer is dropped once, at its next message
+	require.Equal(t, errTooManyInvalidMsgs, ps.dropped(addr))
+	require.NoError(t, ps.dropped(addr))
+}
+
+func TestProposalRelays(t *testing.T) {
+	require.Equal(t, 0, proposalRelays(0))
+	require.Equal(t, 1, proposalRelays(1))
+	require.Equal(t, 2, proposalRelays(3))
+	require.Equal(t, 3, proposalRelays(9))
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+const inMemoryPeerViews = 1024 // number of peers whose consensus view is kept
+
+// peerView is the highest consensus view announced by the messages of a peer
+type peerView struct {
+	BlockNumber uint64
+	Round       int64
+	Updated     time.Time
+}
+
+// PeerViewInfo is the consensus view of a peer returned by the RPC API
+type PeerViewInfo struct {
+	Address     common.Address `json:"address"`
+	BlockNumber uint64         `json:"blockNumber"`
+	Round       int64          `json:"round"`
+	Updated     time.Time      `json:"updated"`
+}
+
+// peerViews tracks the heights and rounds the peers are at from the consensus messages they send
+type peerViews struct {
+	mu    sync.Mutex
+	views *lru.Cache // common.Address -> *peerView
+}
+
+func newPeerViews() *peerViews {
+	views, _ := lru.New(inMemoryPeerViews)
+	return &peerViews{
+		views: views,
+	}
+}
+
+// observe records the view of a message received from the peer if it is ahead of the peer's known view
+func (pv *peerViews) observe(addr common.Address, view *tendermint.View) {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
+	number := view.BlockNumber.Uint64()
+	if value, ok := pv.views.Get(addr); ok {
+		current := value.(*peerView)
+		if number < current.BlockNumber || (number == current.BlockNumber && view.Round < current.Round) {
+			return
+		}
+	}
+	pv.views.Add(addr, &peerView{BlockNumber: number, Round: view.Round, Updated: time.Now()})
+}
+
+// snapshot returns the views of the peers
+func (pv *peerViews) snapshot() map[common.Address]peerView {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
+	out := make(map[common.Address]peerView)
+	for _, key := range pv.views.Keys() {
+		if value, ok := pv.views.Peek(key); ok {
+			out[key.(common.Address)] = *value.(*peerView)
+		}
+	}
+	return out
+}
+
+// ConsensusLag compares the consensus view of the node with the views announced by the connected peers
+type ConsensusLag struct {
+	BlockNumber *big.Int       `json:"blockNumber"`
+	Round       int64          `json:"round"`
+	Behind      bool           `json:"behind"` // true if a peer is at a higher block number
+	Lag         uint64         `json:"lag"`    // number of blocks the node is behind the highest peer
+	PeersAhead  []PeerViewInfo `json:"peersAhead"`
+	Peers       []PeerViewInfo `json:"peers"`
+}
+
+// sortPeerViews sorts the views from the highest to the lowest
+func sortPeerViews(views []PeerViewInfo) {
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].BlockNumber != views[j].BlockNumber {
+			return views[i].BlockNumber > views[j].BlockNumber
+		}
+		if views[i].Round != views[j].Round {
+			return views[i].Round > views[j].Round
+		}
+		return views[i].Address.Hex() < views[j].Address.Hex()
+	})
+}
+
+// consensusLag compares the view the node is at with the views of the connected peers,
+// the node is at the block after head if it does not run consensus
+func (sb *Backend) consensusLag(head *types.Header) *ConsensusLag {
+	lag := &ConsensusLag{
+		BlockNumber: new(big.Int).Add(head.Number, common.Big1),
+		PeersAhead:  []PeerViewInfo{},
+		Peers:       []PeerViewInfo{},
+	}
+	sb.mutex.RLock()
+	running := sb.coreStarted
+	sb.mutex.RUnlock()
+	if running {
+		if status := sb.core.Status(); status != nil {
+			lag.BlockNumber, lag.Round = status.BlockNumber, status.Round
+		}
+	}
+
+	views := sb.peerViews.snapshot()
+	connected := make(map[common.Address]bool, len(views))
+	if sb.broadcaster != nil {
+		targets := make(map[common.Address]bool, len(views))
+		for addr := range views {
+			targets[addr] = true
+		}
+		for addr := range sb.broadcaster.FindPeers(targets) {
+			connected[addr] = true
+		}
+	}
+	number := lag.BlockNumber.Uint64()
+	for addr, view := range views {
+		if !connected[addr] {
+			continue
+		}
+		info := PeerViewInfo{Address: addr, BlockNumber: view.BlockNumber, Round: view.Round, Updated: view.Updated}
+		lag.Peers = append(lag.Peers, info)
+		if view.BlockNumber > number {
+			lag.PeersAhead = append(lag.PeersAhead, info)
+			if view.BlockNumber-number > lag.Lag {
+				lag.Lag = view.BlockNumber - number
+			}
+		}
+	}
+	sortPeerViews(lag.Peers)
+	sortPeerViews(lag.PeersAhead)
+	lag.Behind = lag.Lag > 0
+	return lag
+}
@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+// connectedBroadcaster finds the connected peers only
+type connectedBroadcaster struct {
+	tests_utils.MockProtocolManager
+	connected map[common.Address]bool
+}
+
+func (b *connectedBroadcaster) FindPeers(targets map[common.Address]bool) map[common.Address]consensus.Peer {
+	out := make(map[common.Address]consensus.Peer)
+	for addr := range targets {
+		if b.connected[addr] {
+			out[addr] = &tests_utils.MockPeer{}
+		}
+	}
+	return out
+}
+
+func TestPeerViews_Observe(t *testing.T) {
+	var (
+		pv   = newPeerViews()
+		addr = common.HexToAddress("0x01")
+	)
+	pv.observe(addr, &tendermint.View{BlockNumber: big.NewInt(5), Round: 1})
+	// a late message of a lower view does not move the peer back
+	pv.observe(addr, &tendermint.View{BlockNumber: big.NewInt(5), Round: 0})
+	pv.observe(addr, &tendermint.View{BlockNumber: big.NewInt(4), Round: 3})
+	view := pv.snapshot()[addr]
+	require.Equal(t, uint64(5), view.BlockNumber)
+	require.Equal(t, int64(1), view.Round)
+
+	pv.observe(addr, &tendermint.View{BlockNumber: big.NewInt(6), Round: 0})
+	view = pv.snapshot()[addr]
+	require.Equal(t, uint64(6), view.BlockNumber)
+	require.Equal(t, int64(0), view.Round)
+}
+
+func TestBackend_ConsensusLag(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{nodeAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+		ahead          = common.HexToAddress("0x01")
+		behind         = common.HexToAddress("0x02")
+		gone           = common.HexToAddress("0x03")
+	)
+	be.SetBroadcaster(&connectedBroadcaster{connected: map[common.Address]bool{ahead: true, behind: true}})
+	head := &types.Header{Number: big.NewInt(4)}
+
+	lag := be.consensusLag(head)
+	require.False(t, lag.Behind)
+	require.Empty(t, lag.Peers)
+
+	var (
+		number = lag.BlockNumber.Uint64()
+		view   = func(n uint64) *tendermint.View { return &tendermint.View{BlockNumber: new(big.Int).SetUint64(n)} }
+	)
+	be.peerViews.observe(ahead, view(number+3))
+	be.peerViews.observe(behind, view(number))
+	be.peerViews.observe(gone, view(number+10))
+
+	lag = be.consensusLag(head)
+	require.True(t, lag.Behind)
+	require.Equal(t, uint64(3), lag.Lag)
+	require.Len(t, lag.Peers, 2)
+	require.Len(t, lag.PeersAhead, 1)
+	require.Equal(t, ahead, lag.PeersAhead[0].Address)
+}
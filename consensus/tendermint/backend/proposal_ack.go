@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+const maxProposalResends = 2 // number of times a proposal is resent to the validators which did not acknowledge it
+
+// proposalAcks tracks the validators expected to acknowledge the proposals sent
+type proposalAcks struct {
+	mu      sync.Mutex
+	pending map[common.Hash]map[common.Address]bool // proposal hash -> validators which did not acknowledge it yet
+}
+
+func newProposalAcks() *proposalAcks {
+	return &proposalAcks{
+		pending: make(map[common.Hash]map[common.Address]bool),
+	}
+}
+
+// expect records that the validator of addr should acknowledge the proposal of hash
+func (pa *proposalAcks) expect(hash common.Hash, addr common.Address) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	if _, ok := pa.pending[hash]; !ok {
+		pa.pending[hash] = make(map[common.Address]bool)
+	}
+	pa.pending[hash][addr] = true
+}
+
+// ack records the acknowledgement of the proposal of hash, it returns false if it was not expected
+func (pa *proposalAcks) ack(addr common.Address, hash common.Hash) bool {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	if !pa.pending[hash][addr] {
+		return false
+	}
+	delete(pa.pending[hash], addr)
+	return true
+}
+
+// unacked returns the validators which did not acknowledge the proposal of hash yet
+func (pa *proposalAcks) unacked(hash common.Hash) map[common.Address]bool {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	unacked := make(map[common.Address]bool, len(pa.pending[hash]))
+	for addr := range pa.pending[hash] {
+		unacked[addr] = true
+	}
+	return unacked
+}
+
+// forget stops tracking the proposal of hash
+func (pa *proposalAcks) forget(hash common.Hash) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	delete(pa.pending, hash)
+}
+
+// HandleAck implements consensus.AckHandler.HandleAck
+func (sb *Backend) HandleAck(addr common.Address, hash common.Hash) {
+	if sb.proposalAcks.ack(addr, hash) {
+		log.Trace("proposal acknowledged", "from", addr, "hash", hash)
+	}
+}
+
+// expectAck records that the peer should acknowledge the proposal of hash if it can
+func (sb *Backend) expectAck(p consensus.Peer, addr common.Address, hash common.Hash) {
+	if sb.config.ProposalAckTimeout <= 0 {
+		return
+	}
+	if ackPeer, ok := p.(consensus.AckPeer); ok && ackPeer.Acks() {
+		sb.proposalAcks.expect(hash, addr)
+	}
+}
+
+// ackProposal acknowledges the proposal of hash to the peer it was received from
+func (sb *Backend) ackProposal(addr common.Address, hash common.Hash) {
+	if sb.broadcaster == nil {
+		return
+	}
+	p, ok := sb.broadcaster.FindPeers(map[common.Address]bool{addr: true})[addr].(consensus.AckPeer)
+	if !ok || !p.Acks() {
+		return
+	}
+	if err := p.SendAck(hash); err != nil {
+		log.Debug("failed to acknowledge proposal", "to", addr, "hash", hash, "err", err)
+	}
+}
+
+// resendUnacked resends the proposal to the validators which did not acknowledge it within the ack timeout,
+// so that a single lost message does not deprive the network of their prevotes.
+func (sb *Backend) resendUnacked(hash common.Hash, payload []byte) {
+	defer sb.proposalAcks.forget(hash)
+	for i := 0; i < maxProposalResends; i++ {
+		time.Sleep(sb.config.ProposalAckTimeout)
+		unacked := sb.proposalAcks.unacked(hash)
+		if len(unacked) == 0 {
+			return
+		}
+		log.Debug("resend proposal to the validators which did not acknowledge it", "hash", hash, "validators", len(unacked))
+		for addr, p := range sb.broadcaster.FindPeers(unacked) {
+			if err := sendMsg(p, payload, sb.config.ProtobufMessages); err != nil {
+				log.Debug("failed to resend proposal", "to", addr, "err", err)
+			}
+		}
+	}
+}
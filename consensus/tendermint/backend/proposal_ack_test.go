@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+// ackPeer acknowledges the messages it receives if ackFn is set
+type ackPeer struct {
+	tests_utils.MockPeer
+	ackFn func(hash common.Hash)
+}
+
+func (p *ackPeer) Acks() bool {
+	return true
+}
+
+func (p *ackPeer) SendAck(hash common.Hash) error {
+	return nil
+}
+
+// ackBroadcaster counts the messages sent to each peer, the peers in acking acknowledge them
+type ackBroadcaster struct {
+	be     *Backend
+	acking map[common.Address]bool
+	mu     sync.Mutex
+	sent   map[common.Address]int
+}
+
+func (b *ackBroadcaster) FindPeers(targets map[common.Address]bool) map[common.Address]consensus.Peer {
+	out := make(map[common.Address]consensus.Peer)
+	for addr := range targets {
+		addr := addr
+		out[addr] = &ackPeer{MockPeer: tests_utils.MockPeer{SendFn: func(data interface{}) error {
+			b.mu.Lock()
+			b.sent[addr]++
+			b.mu.Unlock()
+			if b.acking[addr] {
+				b.be.HandleAck(addr, rLPHash(data))
+			}
+			return nil
+		}}}
+	}
+	return out
+}
+
+func (b *ackBroadcaster) Enqueue(id string, block *types.Block) {
+	panic("implement me")
+}
+
+func (b *ackBroadcaster) sentTo(addr common.Address) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sent[addr]
+}
+
+func TestBackend_ResendUnackedProposal(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		genesisHeader  = tests_utils.MakeGenesisHeader([]common.Address{nodeAddr})
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, []common.Address{nodeAddr})
+		acking         = common.HexToAddress("1")
+		silent         = common.HexToAddress("2")
+		valSet         = validator.NewSet([]common.Address{acking, silent, nodeAddr}, tendermint.RoundRobin, 100)
+		broadcaster    = &ackBroadcaster{
+			be:     be,
+			acking: map[common.Address]bool{acking: true},
+			sent:   make(map[common.Address]int),
+		}
+		propose uint64 = 0
+	)
+	config := *be.config
+	config.ProposalAckTimeout = 10 * time.Millisecond
+	be.config = &config
+	be.SetBroadcaster(broadcaster)
+
+	require.NoError(t, be.Gossip(valSet, big.NewInt(0), 0, propose, []byte("proposal")))
+	// the silent validator receives the proposal again until the resends are exhausted
+	require.Eventually(t, func() bool {
+		return broadcaster.sentTo(silent) == 1+maxProposalResends
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, 1, broadcaster.sentTo(acking))
+}
+
+func TestProposalAcks(t *testing.T) {
+	var (
+		pa   = newProposalAcks()
+		hash = common.HexToHash("0x11")
+		val1 = common.HexToAddress("0x01")
+		val2 = common.HexToAddress("0x02")
+	)
+	pa.expect(hash, val1)
+	pa.expect(hash, val2)
+	require.True(t, pa.ack(val1, hash))
+	require.False(t, pa.ack(val1, hash))
+	require.Equal(t, map[common.Address]bool{val2: true}, pa.unacked(hash))
+
+	pa.forget(hash)
+	require.False(t, pa.ack(val2, hash))
+	require.Empty(t, pa.unacked(hash))
+}
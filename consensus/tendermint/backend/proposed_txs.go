@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// proposedTxs are the transactions of a proposal and the validators which receive it
+type proposedTxs struct {
+	block      common.Hash
+	txs        map[common.Hash]struct{}
+	validators map[common.Address]bool
+}
+
+// newProposedTxs indexes the transactions of the proposal block and the validators of valSet
+func newProposedTxs(block *types.Block, valSet tendermint.ValidatorSet) *proposedTxs {
+	proposed := &proposedTxs{
+		block:      block.Hash(),
+		txs:        make(map[common.Hash]struct{}, len(block.Transactions())),
+		validators: make(map[common.Address]bool, valSet.Size()),
+	}
+	for _, tx := range block.Transactions() {
+		proposed.txs[tx.Hash()] = struct{}{}
+	}
+	for _, val := range valSet.List() {
+		proposed.validators[val.Address()] = true
+	}
+	return proposed
+}
+
+// ProposedTxs implements consensus.ProposalGossiper.ProposedTxs,
+// the validators get the transactions of the proposal of the current round with its block,
+// so the transaction gossip can skip them until the height is committed.
+func (sb *Backend) ProposedTxs() (map[common.Hash]struct{}, map[common.Address]bool) {
+	status := sb.coreStatus()
+	if status == nil || status.Proposal == nil {
+		return nil, nil
+	}
+	if proposed, ok := sb.proposedTxs.Load().(*proposedTxs); ok && proposed.block == status.Proposal.Hash() {
+		return proposed.txs, proposed.validators
+	}
+	valSet, err := sb.valSetInfo.GetValSet(sb.chain, status.BlockNumber)
+	if err != nil {
+		return nil, nil
+	}
+	proposed := newProposedTxs(status.Proposal, valSet)
+	sb.proposedTxs.Store(proposed)
+	return proposed.txs, proposed.validators
+}
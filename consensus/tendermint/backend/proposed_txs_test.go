@@ -0,0 +1,34 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestBackend_ProposedTxs(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{common.HexToAddress("0x01"), nodeAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	)
+	// no block is proposed yet
+	txs, vals := be.ProposedTxs()
+	require.Nil(t, txs)
+	require.Nil(t, vals)
+
+	tx := types.NewTransaction(0, common.HexToAddress("0x02"), big.NewInt(1), 21000, big.NewInt(1), nil)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	proposed := newProposedTxs(block, be.ValidatorsByChainReader(common.Big1, be.chain))
+	require.Equal(t, block.Hash(), proposed.block)
+	require.Equal(t, map[common.Hash]struct{}{tx.Hash(): {}}, proposed.txs)
+	require.Equal(t, map[common.Address]bool{validators[0]: true, nodeAddr: true}, proposed.validators)
+}
@@ -0,0 +1,115 @@
+package backend
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// errNotRecoveryValidator is returned when approving a recovery which doesn't keep the validator
+var errNotRecoveryValidator = errors.New("the validator is not a validator of the recovery")
+
+// recoveryValSetInfo replaces the validator sets of the blocks recovered by the genesis, see params.TendermintRecovery.
+// The validator set of a recovery is only used once its approvals are verified against the set it replaces.
+type recoveryValSetInfo struct {
+	ValidatorSetInfo
+	epoch      uint64
+	chainID    *big.Int
+	policy     tendermint.ProposerPolicy
+	recoveries []params.TendermintRecovery
+
+	mu       sync.Mutex
+	verified map[uint64]error // block of the recovery -> result of the verification of its approvals
+}
+
+func newRecoveryValSetInfo(info ValidatorSetInfo, config *tendermint.Config) *recoveryValSetInfo {
+	return &recoveryValSetInfo{
+		ValidatorSetInfo: info,
+		epoch:            config.Epoch,
+		chainID:          config.ChainID,
+		policy:           config.ProposerPolicy,
+		recoveries:       config.Recoveries,
+		verified:         make(map[uint64]error),
+	}
+}
+
+// GetValSet returns the validators of the recovery of blockNumber if there is one
+func (ri *recoveryValSetInfo) GetValSet(chainReader consensus.ChainReader, blockNumber *big.Int) (tendermint.ValidatorSet, error) {
+	return ri.getValSet(chainReader, len(ri.recoveries), blockNumber)
+}
+
+// getValSet returns the validator set of blockNumber with the first count recoveries applied
+func (ri *recoveryValSetInfo) getValSet(chainReader consensus.ChainReader, count int, blockNumber *big.Int) (tendermint.ValidatorSet, error) {
+	recovery := tendermint.RecoveryAt(ri.recoveries[:count], ri.epoch, blockNumber.Uint64())
+	if recovery == nil {
+		return ri.ValidatorSetInfo.GetValSet(chainReader, blockNumber)
+	}
+	if err := ri.verify(chainReader, recovery); err != nil {
+		return validator.NewSet([]common.Address{}, ri.policy, blockNumber.Int64()), err
+	}
+	return validator.NewSet(recovery.Validators, ri.policy, blockNumber.Int64()), nil
+}
+
+// verify checks the approvals of the recovery against the validator set of its block without it
+func (ri *recoveryValSetInfo) verify(chainReader consensus.ChainReader, recovery *params.TendermintRecovery) error {
+	ri.mu.Lock()
+	err, ok := ri.verified[recovery.Block]
+	ri.mu.Unlock()
+	if ok {
+		return err
+	}
+	var index int
+	for index = range ri.recoveries {
+		if ri.recoveries[index].Block == recovery.Block {
+			break
+		}
+	}
+	valSet, err := ri.getValSet(chainReader, index, new(big.Int).SetUint64(recovery.Block))
+	if err != nil {
+		// the validator set may not be known yet, it is verified again later
+		return err
+	}
+	validators := make([]common.Address, 0, valSet.Size())
+	for _, val := range valSet.List() {
+		validators = append(validators, val.Address())
+	}
+	if err = tendermint.VerifyRecovery(ri.chainID, *recovery, validators); err != nil {
+		log.Error("Invalid validator set recovery", "block", recovery.Block, "err", err)
+	} else {
+		log.Warn("Verified the validator set recovery", "block", recovery.Block,
+			"validators", common.PrettyAddresses(recovery.Validators), "replaced", common.PrettyAddresses(validators))
+	}
+	ri.mu.Lock()
+	ri.verified[recovery.Block] = err
+	ri.mu.Unlock()
+	return err
+}
+
+// signRecovery returns the approval of the recovery of the validator set at block number by the validator
+func (sb *Backend) signRecovery(number uint64, validators []common.Address) (hexutil.Bytes, error) {
+	address := sb.Address()
+	found := false
+	for _, addr := range validators {
+		if addr == address {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errNotRecoveryValidator
+	}
+	data, err := tendermint.RecoverySigningData(sb.config.ChainID, params.TendermintRecovery{Block: number, Validators: validators})
+	if err != nil {
+		return nil, err
+	}
+	return sb.Sign(data)
+}
@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestBackend_Recovery(t *testing.T) {
+	var (
+		keys       = make([]*ecdsa.PrivateKey, 4)
+		validators = make([]common.Address, 4)
+		config     = *tendermint.DefaultConfig
+		chainID    = big.NewInt(15)
+	)
+	for i := range keys {
+		keys[i] = tests_utils.MakeNodeKey()
+		validators[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	config.Epoch = 10
+	config.ChainID = chainID
+	config.FixedValidators = validators
+	approve := func(key *ecdsa.PrivateKey, recovery params.TendermintRecovery) hexutil.Bytes {
+		data, err := tendermint.RecoverySigningData(chainID, recovery)
+		require.NoError(t, err)
+		sig, err := crypto.Sign(crypto.Keccak256(data), key)
+		require.NoError(t, err)
+		return sig
+	}
+	recovery := params.TendermintRecovery{Block: 15, Validators: validators[:2]}
+	approval, err := New(&config, keys[0]).(*Backend).signRecovery(recovery.Block, recovery.Validators)
+	require.NoError(t, err)
+	recovery.Approvals = []hexutil.Bytes{approval, approve(keys[1], recovery)}
+	_, err = New(&config, keys[2]).(*Backend).signRecovery(recovery.Block, recovery.Validators)
+	require.Equal(t, errNotRecoveryValidator, err)
+
+	config.Recoveries = []params.TendermintRecovery{recovery}
+	be := New(&config, keys[0]).(*Backend)
+	valSetSize := func(number int64) int {
+		valSet, err := be.valSetInfo.GetValSet(nil, big.NewInt(number))
+		require.NoError(t, err)
+		return valSet.Size()
+	}
+	// the recovery replaces the validator set up to the checkpoint, which lists its validators for the next epoch
+	require.Equal(t, 4, valSetSize(14))
+	require.Equal(t, 2, valSetSize(15))
+	require.Equal(t, 2, valSetSize(20))
+	next, err := be.getNextValidatorSet(nil, &types.Header{Number: big.NewInt(19)})
+	require.NoError(t, err)
+	require.Equal(t, validators[:2], next)
+
+	// the recovery must be approved by each of its validators, which were validators before it
+	invalid := recovery
+	invalid.Approvals = []hexutil.Bytes{recovery.Approvals[1], recovery.Approvals[0]}
+	config.Recoveries = []params.TendermintRecovery{invalid}
+	_, err = New(&config, keys[0]).(*Backend).valSetInfo.GetValSet(nil, big.NewInt(15))
+	require.Equal(t, tendermint.ErrInvalidRecoveryApproval, err)
+
+	outsider := tests_utils.MakeNodeKey()
+	invalid = params.TendermintRecovery{Block: 15, Validators: []common.Address{validators[0], crypto.PubkeyToAddress(outsider.PublicKey)}}
+	invalid.Approvals = []hexutil.Bytes{approve(keys[0], invalid), approve(outsider, invalid)}
+	config.Recoveries = []params.TendermintRecovery{invalid}
+	_, err = New(&config, keys[0]).(*Backend).valSetInfo.GetValSet(nil, big.NewInt(15))
+	require.Equal(t, tendermint.ErrRecoveryNotValidator, err)
+}
@@ -12,20 +12,33 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/params"
 )
 
 // AccumulateRewards credits the coinbase of the given block with the proposing
 // reward.
 func (sb *Backend) accumulateRewards(chainReader consensus.FullChainReader, state *state.StateDB, header *types.Header) error {
+	config := chainReader.Config().Tendermint
 	// If fixed validators (test) then return
-	if chainReader.Config().Tendermint.FixedValidators != nil {
-		reward := new(big.Int).Set(chainReader.Config().Tendermint.BlockReward)
+	if config.FixedValidators != nil {
+		reward, shares := blockRewards(config, header.Number.Uint64())
+		reward, committerShares := commitRewards(chainReader.Config(), header, reward)
 		state.AddBalance(header.Coinbase, reward)
+		for addr, share := range shares {
+			state.AddBalance(addr, share)
+		}
+		for addr, share := range committerShares {
+			state.AddBalance(addr, share)
+		}
+		// the fixed validators don't earn the fees, the treasury still takes its share
+		if _, treasuryFee := splitFees(config, blockFees(chainReader.Config(), header)); treasuryFee.Sign() > 0 {
+			state.AddBalance(*config.FeeTreasury, treasuryFee)
+		}
 		return nil
 	}
 	var (
 		currentBlock = header.Number.Uint64()
-		epoch        = chainReader.Config().Tendermint.Epoch
+		epoch        = config.Epoch
 		start        = time.Now()
 	)
 
@@ -37,12 +50,20 @@ func (sb *Backend) accumulateRewards(chainReader consensus.FullChainReader, stat
 		return nil
 	}
 
-	validatorsRewards := calculateTotalValidatorsRewards(chainReader, epoch, header)
+	validatorsRewards, treasuryFees := calculateTotalValidatorsRewards(chainReader, epoch, header)
 	transitionHeader := chainReader.GetHeaderByNumber(currentBlock - epoch)
 	validatorAdds, err := utils.GetValSetAddresses(transitionHeader)
 	if err != nil {
 		return err
 	}
+	if rotations := sb.config.KeyRotations; len(rotations) > 0 {
+		// the staking contract knows the rotated validators by their previous keys
+		checkpoint := transitionHeader.Number.Uint64()
+		for i, addr := range validatorAdds {
+			validatorAdds[i] = tendermint.StakingAddress(rotations, checkpoint, addr)
+		}
+		validatorsRewards = stakingRewards(rotations, checkpoint, validatorsRewards)
+	}
 	stateDB, err := chainReader.StateAt(transitionHeader.Root)
 	if err != nil {
 		return err
@@ -53,40 +74,169 @@ func (sb *Backend) accumulateRewards(chainReader consensus.FullChainReader, stat
 		return err
 	}
 
-	finalReward := calculateReward(validatorsData, validatorsRewards)
+	commissions := sb.commissions(config, state, stateDB, currentBlock, validatorsData)
+	var finalReward map[common.Address]*big.Int
+	if config.IsDelegatorRewards(currentBlock) {
+		finalReward = accrueDelegatorRewards(state, validatorsData, validatorsRewards, commissions)
+	} else {
+		finalReward = calculateReward(validatorsData, validatorsRewards, commissions)
+	}
 	for addr, value := range finalReward {
 		state.AddBalance(addr, value)
 	}
+	// the reward schedule changes at checkpoints only, so the recipients' shares are the same for each block of the epoch
+	_, shares := blockRewards(config, currentBlock)
+	for addr, share := range shares {
+		state.AddBalance(addr, new(big.Int).Mul(share, new(big.Int).SetUint64(epoch)))
+	}
+	if treasuryFees.Sign() > 0 {
+		state.AddBalance(*config.FeeTreasury, treasuryFees)
+	}
+	if err := sb.recordBondedStakes(config, state, currentBlock); err != nil {
+		return err
+	}
 	log.Debug("accumulateRewards", "number", currentBlock, "elapsed", common.PrettyDuration(time.Since(start)))
 	return nil
 }
 
 // calculateTotalValidatorsRewards gets reward from chainReader and current header (from finalize)
 // reward includes block rewards and tx fee from block number currentBlock - epoch +1
-func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, epoch uint64, header *types.Header) map[common.Address]*big.Int {
-	var currentBlock = header.Number.Uint64()
+func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, epoch uint64, header *types.Header) (map[common.Address]*big.Int, *big.Int) {
+	return sumValidatorsRewards(chainReader, header.Number.Uint64()-epoch+1, header)
+}
+
+// sumValidatorsRewards returns the block rewards and tx fees earned by the proposers of the blocks from number from to header,
+// and the shares of the block rewards earned by the committers of their parents, and the tx fees of the same blocks going
+// to the fee treasury. A committer of the checkpoint block which left the validator set at it has no stake to share its reward by,
+// its share is not paid.
+func sumValidatorsRewards(chainReader consensus.ChainReader, from uint64, header *types.Header) (map[common.Address]*big.Int, *big.Int) {
+	var (
+		currentBlock = header.Number.Uint64()
+		config       = chainReader.Config().Tendermint
+		treasuryFees = new(big.Int)
+	)
 	validatorsRewards := make(map[common.Address]*big.Int)
-	for i := currentBlock - epoch + 1; i <= currentBlock; i++ {
+	addReward := func(addr common.Address, value *big.Int) {
+		if current, ok := validatorsRewards[addr]; ok {
+			validatorsRewards[addr] = new(big.Int).Add(current, value)
+		} else {
+			validatorsRewards[addr] = value
+		}
+	}
+	for i := from; i <= currentBlock; i++ {
 		var currentHeader *types.Header
 		if i != currentBlock {
 			currentHeader = chainReader.GetHeaderByNumber(i)
 		} else {
 			currentHeader = header
 		}
-		txFee := new(big.Int).Mul(big.NewInt(int64(currentHeader.GasUsed)), chainReader.Config().GasPrice)
-		reward := new(big.Int).Add(chainReader.Config().Tendermint.BlockReward, txFee)
-		if current, ok := validatorsRewards[currentHeader.Coinbase]; ok {
-			validatorsRewards[currentHeader.Coinbase] = new(big.Int).Add(current, reward)
+		txFee, treasuryFee := splitFees(config, blockFees(chainReader.Config(), currentHeader))
+		treasuryFees.Add(treasuryFees, treasuryFee)
+		blockReward, _ := blockRewards(config, i)
+		blockReward, committerShares := commitRewards(chainReader.Config(), currentHeader, blockReward)
+		addReward(currentHeader.Coinbase, new(big.Int).Add(blockReward, txFee))
+		for addr, share := range committerShares {
+			addReward(addr, share)
+		}
+	}
+	return validatorsRewards, treasuryFees
+}
+
+// blockFees returns the transaction fees paid in the block of header
+func blockFees(config *params.ChainConfig, header *types.Header) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(header.GasUsed), config.GasPrice)
+}
+
+// splitFees returns the share of the transaction fees earned by the proposer and the share of the fee treasury,
+// the rest is burnt
+func splitFees(config *params.TendermintConfig, fees *big.Int) (*big.Int, *big.Int) {
+	if config.FeeTreasuryPercentage == 0 && config.FeeBurnPercentage == 0 {
+		return fees, new(big.Int)
+	}
+	treasuryFee := new(big.Int).Mul(fees, new(big.Int).SetUint64(config.FeeTreasuryPercentage))
+	treasuryFee.Div(treasuryFee, big.NewInt(100))
+	burntFee := new(big.Int).Mul(fees, new(big.Int).SetUint64(config.FeeBurnPercentage))
+	burntFee.Div(burntFee, big.NewInt(100))
+	proposerFee := new(big.Int).Sub(fees, treasuryFee)
+	return proposerFee.Sub(proposerFee, burntFee), treasuryFee
+}
+
+// blockRewards returns the block reward of the proposer of block number, and the shares of the recipients
+// of the reward schedule which are taken from the block reward first.
+func blockRewards(config *params.TendermintConfig, number uint64) (*big.Int, map[common.Address]*big.Int) {
+	reward := config.BlockRewardAt(number)
+	stage := config.RewardStage(number)
+	if stage == nil || len(stage.Recipients) == 0 {
+		return reward, nil
+	}
+	var (
+		proposerReward = new(big.Int).Set(reward)
+		shares         = make(map[common.Address]*big.Int, len(stage.Recipients))
+	)
+	for _, recipient := range stage.Recipients {
+		share := new(big.Int).Mul(reward, new(big.Int).SetUint64(recipient.Percentage))
+		share.Div(share, big.NewInt(100))
+		proposerReward.Sub(proposerReward, share)
+		if current, ok := shares[recipient.Address]; ok {
+			share.Add(share, current)
+		}
+		shares[recipient.Address] = share
+	}
+	return proposerReward, shares
+}
+
+// commitRewards takes the share of the committers from the proposer reward of the block of header: CommitterPercentage
+// of the block reward split equally among the signers of the parent commit of the header, see hasParentCommit.
+// The parent commit is part of the block hash so every node pays the same committers, the proposer keeps
+// the remainder of the division and the whole share if the block carries no parent commit.
+func commitRewards(chainConfig *params.ChainConfig, header *types.Header, proposerReward *big.Int) (*big.Int, map[common.Address]*big.Int) {
+	config := chainConfig.Tendermint
+	if !hasParentCommit(config.RewardSchedule, header.Number.Uint64()) {
+		return proposerReward, nil
+	}
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil || len(extra.ParentCommit) == 0 {
+		return proposerReward, nil
+	}
+	committers, err := sealSigners(utils.SealConfig(chainConfig), new(big.Int).Sub(header.Number, common.Big1), header.ParentHash, extra.ParentCommit)
+	if err != nil {
+		return proposerReward, nil
+	}
+	number := header.Number.Uint64()
+	total := new(big.Int).Mul(config.BlockRewardAt(number), new(big.Int).SetUint64(config.CommitterPercentageAt(number)))
+	total.Div(total, big.NewInt(100))
+	share := total.Div(total, big.NewInt(int64(len(committers))))
+	if share.Sign() == 0 {
+		return proposerReward, nil
+	}
+	var (
+		reward = new(big.Int).Set(proposerReward)
+		shares = make(map[common.Address]*big.Int, len(committers))
+	)
+	for _, committer := range committers {
+		shares[committer] = new(big.Int).Set(share)
+		reward.Sub(reward, share)
+	}
+	return reward, shares
+}
+
+// stakingRewards returns the rewards of the validators keyed by the addresses the staking contract knows them by
+func stakingRewards(rotations []params.TendermintKeyRotation, checkpoint uint64, validatorsRewards map[common.Address]*big.Int) map[common.Address]*big.Int {
+	result := make(map[common.Address]*big.Int, len(validatorsRewards))
+	for addr, reward := range validatorsRewards {
+		addr = tendermint.StakingAddress(rotations, checkpoint, addr)
+		if current, ok := result[addr]; ok {
+			result[addr] = new(big.Int).Add(current, reward)
 		} else {
-			validatorsRewards[currentHeader.Coinbase] = reward
+			result[addr] = reward
 		}
 	}
-	return validatorsRewards
+	return result
 }
 
-// calculateReward divides rewards into 50% to owner and 50% among voters
+// calculateReward divides rewards into the commission of each validator to its owner and the rest among its voters,
 // rewards for voters is proportional to voters'stake
-func calculateReward(validatorsData map[common.Address]staking.CandidateData, validatorsReward map[common.Address]*big.Int) map[common.Address]*big.Int {
+func calculateReward(validatorsData map[common.Address]staking.CandidateData, validatorsReward map[common.Address]*big.Int, commissions map[common.Address]uint64) map[common.Address]*big.Int {
 	finalReward := make(map[common.Address]*big.Int)
 	addReward := func(addr common.Address, value *big.Int) {
 		if current, ok := finalReward[addr]; ok {
@@ -102,7 +252,7 @@ func calculateReward(validatorsData map[common.Address]staking.CandidateData, va
 		}
 		// remainingReward to ensure the total reward for the voters and owner is equals to the wei validator earns
 		remainingReward := new(big.Int).Set(totalReward)
-		totalVoterReward := new(big.Int).Mul(totalReward, big.NewInt(voterRewardPercentage))
+		totalVoterReward := new(big.Int).Mul(totalReward, new(big.Int).SetUint64(100-commissions[addr]))
 		totalVoterReward = new(big.Int).Div(totalVoterReward, big.NewInt(100))
 		for voter, voterStake := range validatorData.VoterStakes {
 			voterReward := new(big.Int).Mul(totalVoterReward, voterStake)
@@ -110,8 +260,6 @@ func calculateReward(validatorsData map[common.Address]staking.CandidateData, va
 			addReward(voter, voterReward)
 			remainingReward.Sub(remainingReward, voterReward)
 		}
-		validatorReward := new(big.Int).Mul(totalReward, big.NewInt(validatorRewardPercentage))
-		validatorReward = new(big.Int).Div(validatorReward, big.NewInt(100))
 		addReward(validatorData.Owner, remainingReward)
 	}
 	return finalReward
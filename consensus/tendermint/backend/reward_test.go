@@ -2,6 +2,7 @@ package backend
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/params"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
@@ -163,3 +165,125 @@ func testFinalize(t *testing.T, generate func(int, *core.BlockGen), n int, asser
 		assertFn(chain)
 	}
 }
+
+// TestBackend_StakingInfo checks the stakes and the pending rewards of a validator read from the staking contract
+func TestBackend_StakingInfo(t *testing.T) {
+	_, addr := getValidatorAccounts()
+	testFinalize(t, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(addr[0])
+	}, stakingEpoch+2, func(chain *core.BlockChain) {
+		api := &TendermintAPI{chain: chain, be: chain.Engine().(*Backend)}
+
+		info, err := api.GetStakingInfo(addr[0], nil)
+		require.NoError(t, err)
+		require.Equal(t, addr[0], info.Validator)
+		require.Equal(t, 100-params.DefaultVoterPercentage, info.Commission)
+		require.True(t, info.TotalStake.ToInt().Sign() > 0)
+		totalDelegation := new(big.Int)
+		for _, stake := range info.Delegations {
+			totalDelegation.Add(totalDelegation, stake.ToInt())
+		}
+		require.Equal(t, info.TotalStake.ToInt(), totalDelegation)
+
+		totalPending := new(big.Int)
+		for _, reward := range info.PendingRewards {
+			totalPending.Add(totalPending, reward.ToInt())
+		}
+		expectedPending := new(big.Int).Mul(big.NewInt(2), chain.Config().Tendermint.BlockReward)
+		require.Equal(t, expectedPending, totalPending)
+
+		// the rewards are paid at the epoch block
+		epochBlock := uint64(stakingEpoch)
+		info, err = api.GetStakingInfo(addr[0], &epochBlock)
+		require.NoError(t, err)
+		require.Empty(t, info.PendingRewards)
+	})
+}
+
+func TestBlockRewards(t *testing.T) {
+	var (
+		treasury = common.HexToAddress("0x1")
+		fund     = common.HexToAddress("0x2")
+		config   = &params.TendermintConfig{
+			Epoch:       10,
+			BlockReward: big.NewInt(1000),
+			RewardSchedule: []params.TendermintRewardStage{{
+				Block:  10,
+				Reward: big.NewInt(999),
+				Recipients: []params.TendermintRewardRecipient{
+					{Address: treasury, Percentage: 10},
+					{Address: fund, Percentage: 5},
+					{Address: treasury, Percentage: 5},
+				},
+			}},
+		}
+	)
+	reward, shares := blockRewards(config, 10)
+	require.Equal(t, big.NewInt(1000), reward)
+	require.Empty(t, shares)
+
+	// the proposer earns the remainder of the recipients' rounded down shares
+	reward, shares = blockRewards(config, 11)
+	require.Equal(t, big.NewInt(999-99-49-49), reward)
+	require.Equal(t, map[common.Address]*big.Int{treasury: big.NewInt(99 + 49), fund: big.NewInt(49)}, shares)
+}
+
+func TestCommitRewards(t *testing.T) {
+	var (
+		parent     = common.HexToHash("0x1")
+		keys       = []*ecdsa.PrivateKey{tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey()}
+		parentSeal = func(key *ecdsa.PrivateKey) []byte {
+			seal, err := crypto.Sign(crypto.Keccak256(utils.PrepareCommittedSeal(parent)), key)
+			require.NoError(t, err)
+			return seal
+		}
+		config = &params.TendermintConfig{
+			Epoch:       10,
+			BlockReward: big.NewInt(1000),
+			RewardSchedule: []params.TendermintRewardStage{{
+				Block:               10,
+				Reward:              big.NewInt(1000),
+				CommitterPercentage: 20,
+			}},
+		}
+		makeHeader = func(number int64, seals [][]byte) *types.Header {
+			header := &types.Header{Number: big.NewInt(number), ParentHash: parent, MixDigest: types.TendermintDigest}
+			extra, err := tests_utils.PrepareExtra(header)
+			require.NoError(t, err)
+			header.Extra = extra
+			require.NoError(t, utils.WriteParentCommit(header, seals))
+			return header
+		}
+	)
+	// the committers earn no share before the stage
+	reward, shares := commitRewards(&params.ChainConfig{Tendermint: config}, makeHeader(10, nil), big.NewInt(1000))
+	require.Equal(t, big.NewInt(1000), reward)
+	require.Empty(t, shares)
+
+	// the share of the committers is split equally, the proposer earns the remainder of the division
+	seals := [][]byte{parentSeal(keys[0]), parentSeal(keys[1]), parentSeal(keys[2])}
+	reward, shares = commitRewards(&params.ChainConfig{Tendermint: config}, makeHeader(11, seals), big.NewInt(1000))
+	require.Equal(t, big.NewInt(1000-66*3), reward)
+	require.Len(t, shares, 3)
+	for _, key := range keys {
+		require.Equal(t, big.NewInt(66), shares[crypto.PubkeyToAddress(key.PublicKey)])
+	}
+
+	// the proposer keeps the share without parent commit
+	reward, shares = commitRewards(&params.ChainConfig{Tendermint: config}, makeHeader(11, nil), big.NewInt(1000))
+	require.Equal(t, big.NewInt(1000), reward)
+	require.Empty(t, shares)
+}
+
+func TestSplitFees(t *testing.T) {
+	treasury := common.HexToAddress("0x1")
+	proposerFee, treasuryFee := splitFees(&params.TendermintConfig{}, big.NewInt(999))
+	require.Equal(t, big.NewInt(999), proposerFee)
+	require.Equal(t, 0, treasuryFee.Sign())
+
+	// the proposer earns what is neither burnt nor sent to the treasury, the rounding included
+	config := &params.TendermintConfig{FeeTreasury: &treasury, FeeTreasuryPercentage: 20, FeeBurnPercentage: 30}
+	proposerFee, treasuryFee = splitFees(config, big.NewInt(999))
+	require.Equal(t, big.NewInt(999-199-299), proposerFee)
+	require.Equal(t, big.NewInt(199), treasuryFee)
+}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+)
+
+// ProposesSoon implements consensus.Proposer.ProposesSoon,
+// it returns whether the node proposes one of the next config.SpeculativeRounds rounds of the current height,
+// the current round included.
+func (sb *Backend) ProposesSoon() bool {
+	if sb.config.SpeculativeRounds <= 0 {
+		return false
+	}
+	status := sb.coreStatus()
+	if status == nil || !status.IsValidator {
+		return false
+	}
+	if status.IsProposer {
+		return true
+	}
+	valSet, err := sb.valSetInfo.GetValSet(sb.chain, status.BlockNumber)
+	if err != nil {
+		return false
+	}
+	return proposesWithin(valSet, status.Proposer, sb.Address(), sb.config.SpeculativeRounds)
+}
+
+// proposesWithin returns whether addr proposes one of the rounds rounds starting with the one proposed by proposer
+func proposesWithin(valSet tendermint.ValidatorSet, proposer, addr common.Address, rounds int) bool {
+	if proposer == addr {
+		return true
+	}
+	for round := int64(1); round < int64(rounds); round++ {
+		roundValSet := valSet.Copy()
+		roundValSet.CalcProposer(proposer, round)
+		if roundValSet.IsProposer(addr) {
+			return true
+		}
+	}
+	return false
+}
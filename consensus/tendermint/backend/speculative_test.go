@@ -0,0 +1,29 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestBackend_ProposesWithin(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"), nodeAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	)
+	valSet := be.ValidatorsByChainReader(common.Big1, be.chain)
+	index, _ := valSet.GetByAddress(nodeAddr)
+	first := valSet.GetProposer().Address()
+
+	// the node takes its turn at round index
+	require.False(t, proposesWithin(valSet, first, nodeAddr, index))
+	require.True(t, proposesWithin(valSet, first, nodeAddr, index+1))
+	require.True(t, proposesWithin(valSet, nodeAddr, nodeAddr, 1))
+}
@@ -0,0 +1,117 @@
+package staking
+
+import (
+	"encoding/binary"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+const (
+	// inMemoryCheckpoints is the number of recent checkpoints kept in memory
+	inMemoryCheckpoints = 128
+	// storedCheckpoints is the number of recent checkpoints kept in the database, older ones are pruned
+	storedCheckpoints = 1024
+)
+
+// checkpointPrefix + checkpoint number (uint64 big endian) -> rlp encoded checkpoint
+var checkpointPrefix = []byte("tendermint-valset-")
+
+// checkpoint is the validator set of an epoch boundary block of hash
+type checkpoint struct {
+	Hash       common.Hash
+	Validators []common.Address
+}
+
+// Checkpoints stores the validator set of every epoch boundary block in memory and in the database,
+// so the validator set doesn't have to be recomputed from the checkpoint header each time it is looked up.
+// Only the last storedCheckpoints checkpoints are kept in the database. A checkpoint is only read back for the block
+// it was stored for, the one of a block which was rewound and replaced is stale.
+type Checkpoints struct {
+	db    evrdb.Database // db is nil if the checkpoints are kept in memory only
+	epoch uint64
+	cache *lru.ARCCache
+
+	mu     sync.Mutex
+	latest uint64 // the highest checkpoint stored
+}
+
+// NewCheckpoints returns a Checkpoints which stores to db, db can be nil
+func NewCheckpoints(db evrdb.Database, epoch uint64) *Checkpoints {
+	cache, _ := lru.NewARC(inMemoryCheckpoints)
+	return &Checkpoints{
+		db:    db,
+		epoch: epoch,
+		cache: cache,
+	}
+}
+
+func checkpointKey(number uint64) []byte {
+	key := make([]byte, len(checkpointPrefix)+8)
+	copy(key, checkpointPrefix)
+	binary.BigEndian.PutUint64(key[len(checkpointPrefix):], number)
+	return key
+}
+
+// Get returns the validators stored at checkpoint number, false if none were stored for the block of hash
+func (c *Checkpoints) Get(number uint64, hash common.Hash) ([]common.Address, bool) {
+	if cached, ok := c.cache.Get(number); ok {
+		cp := cached.(*checkpoint)
+		return cp.Validators, cp.Hash == hash
+	}
+	if c.db == nil {
+		return nil, false
+	}
+	blob, err := c.db.Get(checkpointKey(number))
+	if err != nil || len(blob) == 0 {
+		return nil, false
+	}
+	var cp checkpoint
+	if err := rlp.DecodeBytes(blob, &cp); err != nil {
+		log.Error("failed to decode stored validator set checkpoint", "number", number, "error", err)
+		return nil, false
+	}
+	c.cache.Add(number, &cp)
+	return cp.Validators, cp.Hash == hash
+}
+
+// Store saves the validators of checkpoint number, the block of hash, and prunes the checkpoints which are too old
+func (c *Checkpoints) Store(number uint64, hash common.Hash, validators []common.Address) error {
+	cp := &checkpoint{Hash: hash, Validators: validators}
+	c.cache.Add(number, cp)
+	if c.db == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// do not store the checkpoints which would be pruned right away
+	if c.isPruned(number) {
+		return nil
+	}
+	blob, err := rlp.EncodeToBytes(cp)
+	if err != nil {
+		return err
+	}
+	if err := c.db.Put(checkpointKey(number), blob); err != nil {
+		return err
+	}
+	if number <= c.latest {
+		return nil
+	}
+	c.latest = number
+	// checkpoints are stored in order as the chain grows, so removing the one falling out of the window is enough
+	if c.epoch == 0 || number < storedCheckpoints*c.epoch {
+		return nil
+	}
+	return c.db.Delete(checkpointKey(number - storedCheckpoints*c.epoch))
+}
+
+// isPruned returns true if checkpoint number is out of the stored window, assume that c.mu is locked
+func (c *Checkpoints) isPruned(number uint64) bool {
+	return c.epoch != 0 && c.latest >= storedCheckpoints*c.epoch && number <= c.latest-storedCheckpoints*c.epoch
+}
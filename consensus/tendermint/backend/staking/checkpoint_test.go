@@ -0,0 +1,59 @@
+package staking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+)
+
+func TestCheckpoints(t *testing.T) {
+	var (
+		epoch      = uint64(10)
+		db         = rawdb.NewMemoryDatabase()
+		validators = []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+		hash       = common.HexToHash("0xa")
+		rewound    = common.HexToHash("0xb")
+	)
+	checkpoints := NewCheckpoints(db, epoch)
+	_, ok := checkpoints.Get(epoch, hash)
+	require.False(t, ok)
+
+	require.NoError(t, checkpoints.Store(epoch, hash, validators))
+	stored, ok := checkpoints.Get(epoch, hash)
+	require.True(t, ok)
+	require.Equal(t, validators, stored)
+
+	// a new instance reads the checkpoint from the database
+	stored, ok = NewCheckpoints(db, epoch).Get(epoch, hash)
+	require.True(t, ok)
+	require.Equal(t, validators, stored)
+
+	// the checkpoint of another block at the same number, once the chain is rewound, is not read back
+	_, ok = checkpoints.Get(epoch, rewound)
+	require.False(t, ok)
+	_, ok = NewCheckpoints(db, epoch).Get(epoch, rewound)
+	require.False(t, ok)
+	require.NoError(t, checkpoints.Store(epoch, rewound, validators[:1]))
+	stored, ok = NewCheckpoints(db, epoch).Get(epoch, rewound)
+	require.True(t, ok)
+	require.Equal(t, validators[:1], stored)
+	_, ok = checkpoints.Get(epoch, hash)
+	require.False(t, ok)
+
+	// storing a checkpoint which is storedCheckpoints epochs later prunes the first one
+	require.NoError(t, checkpoints.Store(epoch*(storedCheckpoints+1), hash, validators))
+	has, err := db.Has(checkpointKey(epoch))
+	require.NoError(t, err)
+	require.False(t, has)
+	_, ok = NewCheckpoints(db, epoch).Get(epoch, rewound)
+	require.False(t, ok)
+
+	// the pruned checkpoint is not stored again
+	require.NoError(t, checkpoints.Store(epoch, rewound, validators))
+	has, err = db.Has(checkpointKey(epoch))
+	require.NoError(t, err)
+	require.False(t, has)
+}
@@ -3,28 +3,78 @@ package staking
 import (
 	"math/big"
 
+	lru "github.com/hashicorp/golang-lru"
+
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
 	"github.com/Evrynetlabs/evrynet-node/log"
 )
 
+// WeightsFn returns the proposer weights of the validators of the checkpoint block, weights[i] being the one of validators[i]
+type WeightsFn func(chainReader consensus.ChainReader, checkpoint *types.Header, validators []common.Address) ([]uint64, error)
+
 // StakingValidator is implementation of ValidatorSetInfo
 type StakingValidator struct {
 	Epoch          uint64
 	ProposerPolicy tendermint.ProposerPolicy
+	Checkpoints    *Checkpoints
+	// Weights gives the proposer weights of the validators with the StakeWeighted policy,
+	// the validators have equal weights if it is nil
+	Weights WeightsFn
+	// WeightedVotingBlock is the block from which the validators also vote with their weights, nil if they never do
+	WeightedVotingBlock *big.Int
+
+	weights *lru.ARCCache // checkpoint hash -> []uint64
 }
 
-// NewStakingValidatorInfo returns new StakingValidator
-func NewStakingValidatorInfo(epoch uint64, proposerPolicy tendermint.ProposerPolicy) *StakingValidator {
+// NewStakingValidatorInfo returns new StakingValidator, the validator sets of checkpoints are stored to db.
+// db can be nil, in that case the checkpoints are only cached in memory
+func NewStakingValidatorInfo(epoch uint64, proposerPolicy tendermint.ProposerPolicy, db evrdb.Database) *StakingValidator {
+	weights, _ := lru.NewARC(inMemoryCheckpoints)
 	return &StakingValidator{
 		Epoch:          epoch,
 		ProposerPolicy: proposerPolicy,
+		Checkpoints:    NewCheckpoints(db, epoch),
+		weights:        weights,
 	}
 }
 
+// isWeightedVoting returns true if the validators of blockNumber vote with their weights
+func (v *StakingValidator) isWeightedVoting(blockNumber int64) bool {
+	return v.ProposerPolicy == tendermint.StakeWeighted && v.Weights != nil &&
+		v.WeightedVotingBlock != nil && v.WeightedVotingBlock.Cmp(big.NewInt(blockNumber)) <= 0
+}
+
+// newSet returns the validator set of blockNumber from the validators of the checkpoint. The validators propose
+// with equal weights if theirs are not available, unless they vote with them: an empty set is returned with the error.
+func (v *StakingValidator) newSet(chainReader consensus.ChainReader, checkpoint *types.Header, validatorAdds []common.Address, blockNumber int64) (tendermint.ValidatorSet, error) {
+	if v.ProposerPolicy != tendermint.StakeWeighted || v.Weights == nil {
+		return validator.NewSet(validatorAdds, v.ProposerPolicy, blockNumber), nil
+	}
+	weightedSet := validator.NewWeightedSet
+	if v.isWeightedVoting(blockNumber) {
+		weightedSet = validator.NewWeightedVotingSet
+	}
+	if weights, ok := v.weights.Get(checkpoint.Hash()); ok {
+		return weightedSet(validatorAdds, weights.([]uint64), blockNumber), nil
+	}
+	weights, err := v.Weights(chainReader, checkpoint, validatorAdds)
+	if err != nil {
+		if v.isWeightedVoting(blockNumber) {
+			return validator.NewSet([]common.Address{}, v.ProposerPolicy, blockNumber), err
+		}
+		log.Warn("failed to get the proposer weights, the validators propose with equal weights", "number", checkpoint.Number, "error", err)
+		return validator.NewSet(validatorAdds, v.ProposerPolicy, blockNumber), nil
+	}
+	v.weights.Add(checkpoint.Hash(), weights)
+	return weightedSet(validatorAdds, weights, blockNumber), nil
+}
+
 // GetValSet returns the validators available in the block if it already been created
 func (v *StakingValidator) GetValSet(chainReader consensus.ChainReader, number *big.Int) (tendermint.ValidatorSet, error) {
 	var (
@@ -33,17 +83,22 @@ func (v *StakingValidator) GetValSet(chainReader consensus.ChainReader, number *
 		checkPoint  = utils.GetCheckpointNumber(v.Epoch, number.Uint64())
 		valSet      = validator.NewSet([]common.Address{}, v.ProposerPolicy, blockNumber)
 	)
-
 	header := chainReader.GetHeaderByNumber(checkPoint)
 	if (header == nil || header.Hash() == common.Hash{}) {
 		return valSet, tendermint.ErrUnknownBlock
 	}
+	if validatorAdds, ok := v.Checkpoints.Get(checkPoint, header.Hash()); ok {
+		return v.newSet(chainReader, header, validatorAdds, blockNumber)
+	}
 
 	validatorAdds, err := utils.GetValSetAddresses(header)
 	if err != nil {
 		log.Error("can't get the validators's address from extra-data", "number", blockNumber)
 		return valSet, err
 	}
+	if err := v.Checkpoints.Store(checkPoint, header.Hash(), validatorAdds); err != nil {
+		log.Warn("failed to store validator set checkpoint", "number", checkPoint, "error", err)
+	}
 
-	return validator.NewSet(validatorAdds, v.ProposerPolicy, blockNumber), nil
+	return v.newSet(chainReader, header, validatorAdds, blockNumber)
 }
@@ -0,0 +1,155 @@
+package backend
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+)
+
+// StakingInfo is the staking state of a validator at a block
+type StakingInfo struct {
+	Validator      common.Address                  `json:"validator"`
+	Owner          common.Address                  `json:"owner"`
+	OwnerStake     *hexutil.Big                    `json:"ownerStake"` // stake bonded by the owner
+	TotalStake     *hexutil.Big                    `json:"totalStake"`
+	BondedStake    *hexutil.Big                    `json:"bondedStake"`    // stake counting toward the selection at the next epoch block
+	Commission     uint64                          `json:"commission"`     // percentage of the rewards kept by the owner before the voters share the rest
+	Delegations    map[common.Address]*hexutil.Big `json:"delegations"`    // stake of each voter, the owner included
+	PendingRewards map[common.Address]*hexutil.Big `json:"pendingRewards"` // rewards earned since the last epoch block, paid at the next one
+}
+
+// candidateData reads the data of the candidate from the staking contract by executing calls in the EVM at the state of header
+func (sb *Backend) candidateData(chain consensus.FullChainReader, candidate common.Address, header *types.Header) (staking.CandidateData, error) {
+	stateDB, err := chain.StateAt(header.Root)
+	if err != nil {
+		return staking.CandidateData{}, err
+	}
+	caller := staking.NewEVMStakingCaller(stateDB,
+		staking.NewChainContextWrapper(sb, chain.GetHeader),
+		header,
+		chain.Config(),
+		vm.Config{})
+	data, err := caller.GetValidatorsData(*sb.config.StakingSCAddress, []common.Address{candidate})
+	if err != nil {
+		return staking.CandidateData{}, err
+	}
+	return data[candidate], nil
+}
+
+// stakingInfo returns the staking state of the validator at the state of header
+func (sb *Backend) stakingInfo(chain consensus.FullChainReader, validator common.Address, header *types.Header) (*StakingInfo, error) {
+	if len(sb.config.FixedValidators) > 0 {
+		return nil, errFixedValidators
+	}
+	var (
+		number     = header.Number.Uint64()
+		checkpoint = number - number%sb.config.Epoch
+		candidate  = tendermint.StakingAddress(sb.config.KeyRotations, checkpoint, validator)
+	)
+	data, err := sb.candidateData(chain, candidate, header)
+	if err != nil {
+		return nil, err
+	}
+	transitionHeader := chain.GetHeaderByNumber(checkpoint)
+	if transitionHeader == nil {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	commission, err := sb.pendingCommission(chain, candidate, header, transitionHeader)
+	if err != nil {
+		return nil, err
+	}
+	info := &StakingInfo{
+		Validator:      validator,
+		Owner:          data.Owner,
+		OwnerStake:     (*hexutil.Big)(new(big.Int)),
+		TotalStake:     (*hexutil.Big)(new(big.Int)),
+		Commission:     commission,
+		Delegations:    make(map[common.Address]*hexutil.Big, len(data.VoterStakes)),
+		PendingRewards: make(map[common.Address]*hexutil.Big),
+	}
+	if data.TotalStake != nil {
+		info.TotalStake = (*hexutil.Big)(data.TotalStake)
+	}
+	info.BondedStake = info.TotalStake
+	if config := chain.Config().Tendermint; config.IsBonding(checkpoint + sb.config.Epoch) {
+		stateDB, err := chain.StateAt(header.Root)
+		if err != nil {
+			return nil, err
+		}
+		info.BondedStake = (*hexutil.Big)(bondedStake(config, stateDB, checkpoint+sb.config.Epoch, candidate, info.TotalStake.ToInt()))
+	}
+	for voter, stake := range data.VoterStakes {
+		info.Delegations[voter] = (*hexutil.Big)(stake)
+	}
+	if stake, ok := data.VoterStakes[data.Owner]; ok {
+		info.OwnerStake = (*hexutil.Big)(stake)
+	}
+
+	// the rewards of the current epoch are shared at the next epoch block by the stakes at the last one
+	if number == checkpoint {
+		return info, nil
+	}
+	validatorsRewards, _ := sumValidatorsRewards(chain, checkpoint+1, header)
+	rewards := stakingRewards(sb.config.KeyRotations, checkpoint, validatorsRewards)
+	if _, ok := rewards[candidate]; !ok {
+		return info, nil
+	}
+	transitionData, err := sb.candidateData(chain, candidate, transitionHeader)
+	if err != nil {
+		return nil, err
+	}
+	shares := calculateReward(map[common.Address]staking.CandidateData{candidate: transitionData},
+		map[common.Address]*big.Int{candidate: rewards[candidate]}, map[common.Address]uint64{candidate: commission})
+	for addr, reward := range shares {
+		info.PendingRewards[addr] = (*hexutil.Big)(reward)
+	}
+	return info, nil
+}
+
+// stakeWeights returns the proposer weights of the validators of the checkpoint, proportional to their total stake
+// at the state of the checkpoint block, or to their bonded stake they are selected by once the bonding period applies
+func (sb *Backend) stakeWeights(chainReader consensus.ChainReader, checkpoint *types.Header, validators []common.Address) ([]uint64, error) {
+	chain, ok := chainReader.(consensus.FullChainReader)
+	if !ok {
+		return nil, errNoState
+	}
+	var (
+		number = checkpoint.Number.Uint64()
+		config = chain.Config().Tendermint
+		state  = checkpoint
+	)
+	if config.IsBonding(number) {
+		// the stakes are bonded at the parent state the validators are selected at
+		if state = chain.GetHeader(checkpoint.ParentHash, number-1); state == nil {
+			return nil, tendermint.ErrUnknownBlock
+		}
+	}
+	stateDB, err := chain.StateAt(state.Root)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]common.Address, len(validators))
+	for i, addr := range validators {
+		// the staking contract knows the rotated validators by their previous keys
+		candidates[i] = tendermint.StakingAddress(sb.config.KeyRotations, number, addr)
+	}
+	data, err := sb.getStakingCaller(chain, stateDB, checkpoint).GetValidatorsData(*sb.config.StakingSCAddress, candidates)
+	if err != nil {
+		return nil, err
+	}
+	stakes := make([]*big.Int, len(candidates))
+	for i, candidate := range candidates {
+		stakes[i] = data[candidate].TotalStake
+		if config.IsBonding(number) && stakes[i] != nil {
+			stakes[i] = bondedStake(config, stateDB, number, candidate, stakes[i])
+		}
+	}
+	return validator.StakeWeights(stakes), nil
+}
@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// BlockValidators implements consensus.ValidatorInfo.BlockValidators
+func (sb *Backend) BlockValidators(chain consensus.ChainReader, number uint64) []common.Address {
+	valSet := sb.ValidatorsByChainReader(new(big.Int).SetUint64(number), chain)
+	if valSet == nil {
+		return nil
+	}
+	validators := make([]common.Address, 0, valSet.Size())
+	for _, val := range valSet.List() {
+		validators = append(validators, val.Address())
+	}
+	return validators
+}
+
+// BlockSigners implements consensus.ValidatorInfo.BlockSigners
+func (sb *Backend) BlockSigners(header *types.Header) ([]common.Address, error) {
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	return sealSigners(sb.config, header.Number, header.Hash(), extra.CommittedSeal)
+}
+
+// sealSigners returns the signers of the committed seals of the block number with the given hash
+func sealSigners(config *tendermint.Config, number *big.Int, hash common.Hash, seals [][]byte) ([]common.Address, error) {
+	var (
+		proposalSeal = utils.PrepareCommittedSealAt(config, number, hash)
+		signers      = make([]common.Address, 0, len(seals))
+	)
+	for _, seal := range seals {
+		addr, err := utils.GetSignatureAddress(proposalSeal, seal)
+		if err != nil {
+			return nil, tendermint.ErrInvalidSignature
+		}
+		signers = append(signers, addr)
+	}
+	return signers, nil
+}
+
+// BlockRound implements consensus.ValidatorInfo.BlockRound,
+// it returns the first round whose proposer is the proposer of the header.
+func (sb *Backend) BlockRound(chain consensus.ChainReader, header *types.Header) (int64, error) {
+	proposer, err := blockProposer(header)
+	if err != nil {
+		return 0, err
+	}
+	valSet := sb.ValidatorsByChainReader(header.Number, chain)
+	if valSet == nil || valSet.GetProposer() == nil {
+		return 0, tendermint.ErrUnauthorized
+	}
+	first := valSet.GetProposer().Address()
+	for round := int64(0); round < int64(valSet.Size()); round++ {
+		roundValSet := valSet.Copy()
+		roundValSet.CalcProposer(first, round)
+		if roundValSet.GetProposer().Address() == proposer {
+			return round, nil
+		}
+	}
+	return 0, tendermint.ErrUnauthorized
+}
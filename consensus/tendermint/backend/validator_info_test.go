@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestBackend_ValidatorInfo(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{nodeAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	)
+	header := tests_utils.MakeBlockWithSeal(be, genesisHeader).Header()
+	committedSeal, err := crypto.Sign(crypto.Keccak256(utils.PrepareCommittedSeal(header.Hash())), nodePrivateKey)
+	require.NoError(t, err)
+	tests_utils.AppendCommittedSeal(header, committedSeal)
+
+	require.Equal(t, validators, be.BlockValidators(be.chain, header.Number.Uint64()))
+
+	signers, err := be.BlockSigners(header)
+	require.NoError(t, err)
+	require.Equal(t, validators, signers)
+
+	round, err := be.BlockRound(be.chain, header)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), round)
+}
+
+func TestBackend_BlockRound(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"), nodeAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	)
+	header := tests_utils.MakeBlockWithSeal(be, genesisHeader).Header()
+	valSet := be.ValidatorsByChainReader(header.Number, be.chain)
+	// the first validator proposes at round 0 of block 1, the node takes its turn at its index
+	index, _ := valSet.GetByAddress(nodeAddr)
+
+	round, err := be.BlockRound(be.chain, header)
+	require.NoError(t, err)
+	require.Equal(t, int64(index), round)
+}
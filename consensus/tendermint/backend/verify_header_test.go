@@ -3,16 +3,21 @@ package backend
 import (
 	"crypto/ecdsa"
 	"log"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/crypto/secp256k1"
+	"github.com/Evrynetlabs/evrynet-node/params"
 )
 
 func TestBackend_VerifyHeader(t *testing.T) {
@@ -53,6 +58,12 @@ func TestBackend_VerifyHeader(t *testing.T) {
 	block = tests_utils.MustMakeBlockWithCommittedSealInvalid(engine, genesisHeader)
 	assert.Equal(t, tendermint.ErrInvalidCommittedSeals, engine.VerifyHeader(engine.chain, block.Header(), false))
 
+	// with uncles
+	block = tests_utils.MustMakeBlockWithCommittedSeal(engine, genesisHeader)
+	header = block.Header()
+	header.UncleHash = types.CalcUncleHash([]*types.Header{genesisHeader})
+	assert.Equal(t, tendermint.ErrInvalidUncleHash, engine.VerifyHeader(engine.chain, header, false))
+
 	// with committed seal
 	block = tests_utils.MustMakeBlockWithCommittedSeal(engine, genesisHeader)
 	assert.NotNil(t, engine.chain)
@@ -60,6 +71,92 @@ func TestBackend_VerifyHeader(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBackend_VerifyTimestamp(t *testing.T) {
+	var (
+		nodePK, _     = crypto.HexToECDSA("bb047e5940b6d83354d9432db7c449ac8fca2248008aaa7271369880f9f11cc1")
+		validators    = []common.Address{crypto.PubkeyToAddress(nodePK.PublicKey)}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+		cfg           = *tendermint.DefaultConfig
+	)
+	genesisHeader.Time = uint64(time.Now().Unix()) - 10
+	cfg.FixedValidators = validators
+	cfg.MaxTimestampDrift = 5 * time.Second
+	cfg.StrictTimestampBlock = big.NewInt(2)
+	_, engine := mustStartTestChainAndBackend(nodePK, genesisHeader, &cfg)
+
+	makeHeader := func(timestamp uint64) *types.Header {
+		header := tests_utils.MakeBlockWithoutSeal(genesisHeader).Header()
+		header.Time = timestamp
+		tests_utils.AppendSeal(header, engine)
+		committedSeal, err := engine.Sign(utils.PrepareCommittedSeal(header.Hash()))
+		assert.NoError(t, err)
+		tests_utils.AppendCommittedSeal(header, committedSeal)
+		return header
+	}
+
+	// the timestamp of a block before the strict timestamp block may equal the parent's
+	header := makeHeader(genesisHeader.Time)
+	assert.NoError(t, engine.VerifyHeader(engine.chain, header, false))
+
+	// from the strict timestamp block on, it must be after the parent's
+	engine.config.StrictTimestampBlock = big.NewInt(1)
+	assert.Equal(t, tendermint.ErrInvalidTimestamp, engine.VerifyHeader(engine.chain, header, false))
+	assert.Equal(t, tendermint.ErrInvalidTimestamp, engine.VerifyProposalHeader(header))
+
+	// ahead of the local time within the max drift, only accepted as a proposal
+	header = makeHeader(uint64(time.Now().Unix()) + 2)
+	assert.Equal(t, consensus.ErrFutureBlock, engine.VerifyHeader(engine.chain, header, false))
+	assert.NoError(t, engine.VerifyProposalHeader(header))
+
+	// ahead of the local time beyond the max drift
+	header = makeHeader(uint64(time.Now().Unix()) + 10)
+	assert.Equal(t, tendermint.ErrFutureTimestamp, engine.VerifyProposalHeader(header))
+}
+
+func TestBackend_VerifyParentCommit(t *testing.T) {
+	var (
+		nodePK, _     = crypto.HexToECDSA("bb047e5940b6d83354d9432db7c449ac8fca2248008aaa7271369880f9f11cc1")
+		otherPK       = tests_utils.MakeNodeKey()
+		validators    = []common.Address{crypto.PubkeyToAddress(nodePK.PublicKey)}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+		cfg           = *tendermint.DefaultConfig
+	)
+	cfg.FixedValidators = validators
+	chain, engine := mustStartTestChainAndBackend(nodePK, genesisHeader, &cfg)
+	parent := tests_utils.MakeBlockWithoutSeal(genesisHeader).Header()
+	makeHeader := func(keys ...*ecdsa.PrivateKey) *types.Header {
+		header := tests_utils.MakeBlockWithoutSeal(parent).Header()
+		var seals [][]byte
+		for _, key := range keys {
+			seal, err := crypto.Sign(crypto.Keccak256(utils.PrepareCommittedSeal(parent.Hash())), key)
+			assert.NoError(t, err)
+			seals = append(seals, seal)
+		}
+		assert.NoError(t, utils.WriteParentCommit(header, seals))
+		return header
+	}
+
+	// the parent commit must be a quorum of the parent's validators once the committers are rewarded
+	engine.config.RewardSchedule = []params.TendermintRewardStage{{Reward: big.NewInt(1000), CommitterPercentage: 20}}
+	assert.NoError(t, engine.verifyParentCommit(chain, makeHeader(nodePK), parent, nil))
+	assert.Equal(t, tendermint.ErrInvalidParentCommit, engine.verifyParentCommit(chain, makeHeader(), parent, nil))
+	assert.Equal(t, tendermint.ErrInvalidParentCommit, engine.verifyParentCommit(chain, makeHeader(otherPK), parent, nil))
+	assert.Equal(t, tendermint.ErrInvalidParentCommit, engine.verifyParentCommit(chain, makeHeader(nodePK, nodePK), parent, nil))
+
+	// the proposer copies the committed seals of its parent
+	header := makeHeader()
+	committedSeal, err := engine.Sign(utils.PrepareCommittedSeal(parent.Hash()))
+	assert.NoError(t, err)
+	tests_utils.AppendCommittedSeal(parent, committedSeal)
+	assert.NoError(t, engine.addParentCommitToHeader(header, parent))
+	assert.NoError(t, engine.verifyParentCommit(chain, header, parent, nil))
+
+	// and empty before
+	engine.config.RewardSchedule = nil
+	assert.NoError(t, engine.verifyParentCommit(chain, makeHeader(), parent, nil))
+	assert.Equal(t, tendermint.ErrInvalidParentCommit, engine.verifyParentCommit(chain, makeHeader(nodePK), parent, nil))
+}
+
 func mustStartTestChainAndBackend(nodePK *ecdsa.PrivateKey, genesisHeader *types.Header, cfg *tendermint.Config) (*tests_utils.MockChainReader, *Backend) {
 	var (
 		config = tendermint.DefaultConfig
@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+)
+
+var voteBatchSizeHistogram = metrics.NewRegisteredHistogram("evr/consensus/tendermint/backend/votebatch/size", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+// voteBatcher packs the votes sent to a peer within a short delay into a single message signed by the node,
+// so that our vote and the relayed votes the peer is missing cost one message instead of one per vote.
+type voteBatcher struct {
+	delay    time.Duration
+	pack     func(blockNumber *big.Int, payloads [][]byte) ([]byte, error)
+	protobuf bool // the batches are sent protobuf encoded to the peers which decode them
+
+	mu      sync.Mutex
+	pending map[common.Address]*voteBatch
+}
+
+// voteBatch is the votes of a block number waiting to be sent to a peer
+type voteBatch struct {
+	peer        consensus.Peer
+	blockNumber *big.Int
+	payloads    [][]byte
+
+	done chan struct{} // closed once the batch is sent
+	err  error         // the error of the sending of the batch, set once done is closed
+}
+
+func newVoteBatcher(delay time.Duration, pack func(*big.Int, [][]byte) ([]byte, error)) *voteBatcher {
+	return &voteBatcher{
+		delay:   delay,
+		pack:    pack,
+		pending: make(map[common.Address]*voteBatch),
+	}
+}
+
+// send adds the vote payload of blockNumber to the batch of the peer and returns the batch without waiting for it
+// to be sent, so that the votes sent to several peers are not delayed one after another.
+// A batch is sent once the delay has elapsed since its first vote or once it is full.
+func (b *voteBatcher) send(p consensus.Peer, addr common.Address, blockNumber *big.Int, payload []byte) *voteBatch {
+	b.mu.Lock()
+	batch, ok := b.pending[addr]
+	if ok && batch.blockNumber.Cmp(blockNumber) != 0 {
+		// a batch only holds the votes of a block number
+		delete(b.pending, addr)
+		go b.flush(batch)
+		ok = false
+	}
+	if !ok {
+		batch = &voteBatch{
+			peer:        p,
+			blockNumber: blockNumber,
+			done:        make(chan struct{}),
+		}
+		b.pending[addr] = batch
+		time.AfterFunc(b.delay, func() { b.flushPending(addr, batch) })
+	}
+	if !batch.contains(payload) {
+		batch.payloads = append(batch.payloads, payload)
+	}
+	if len(batch.payloads) >= tendermintCore.MaxVoteBatchSize {
+		delete(b.pending, addr)
+		go b.flush(batch)
+	}
+	b.mu.Unlock()
+	return batch
+}
+
+// flushPending sends the batch if it is still the pending one of the peer
+func (b *voteBatcher) flushPending(addr common.Address, batch *voteBatch) {
+	b.mu.Lock()
+	if b.pending[addr] != batch {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.pending, addr)
+	b.mu.Unlock()
+	b.flush(batch)
+}
+
+// flush sends a single vote as is, and several votes packed in a vote batch
+func (b *voteBatcher) flush(batch *voteBatch) {
+	defer close(batch.done)
+	voteBatchSizeHistogram.Update(int64(len(batch.payloads)))
+	payload := batch.payloads[0]
+	if len(batch.payloads) > 1 {
+		packed, err := b.pack(batch.blockNumber, batch.payloads)
+		if err != nil {
+			log.Error("failed to pack the vote batch", "err", err, "votes", len(batch.payloads))
+			batch.err = err
+			return
+		}
+		payload = packed
+	}
+	if batch.err = sendMsg(batch.peer, payload, b.protobuf); batch.err != nil {
+		log.Debug("failed to send the vote batch", "err", batch.err, "votes", len(batch.payloads))
+	}
+}
+
+func (batch *voteBatch) contains(payload []byte) bool {
+	for _, p := range batch.payloads {
+		if bytes.Equal(p, payload) {
+			return true
+		}
+	}
+	return false
+}
+
+// send sends a consensus message to a peer. The votes go through the vote batcher if it is enabled and the peer
+// decodes vote batches, their sending errors are then reported by the batch.
+func (sb *Backend) send(p consensus.Peer, addr common.Address, msgType uint64, payload []byte) error {
+	if sb.voteBatcher != nil && tendermintCore.IsVote(msgType) {
+		if batchPeer, ok := p.(consensus.VoteBatchPeer); ok && batchPeer.VoteBatches() {
+			if view, ok := tendermintCore.MsgView(payload); ok {
+				sb.voteBatcher.send(p, addr, view.BlockNumber, payload)
+				return nil
+			}
+		}
+	}
+	return sendMsg(p, payload, sb.config.ProtobufMessages)
+}
+
+// handleVoteBatch handles the votes of a batch as if the peer had sent them one by one,
+// the peer must have signed the batch itself.
+func (sb *Backend) handleVoteBatch(addr common.Address, data []byte) error {
+	sender, payloads, err := tendermintCore.DecodeVoteBatch(sb.config, data)
+	if err != nil || sender != addr {
+		log.Debug("received an invalid vote batch", "from", addr, "sender", sender, "err", err)
+		return sb.peerScores.invalid(addr)
+	}
+	for _, payload := range payloads {
+		code, ok := msgCode(payload)
+		if !ok {
+			return sb.peerScores.invalid(addr)
+		}
+		if err := sb.handleConsensusMsg(addr, code, payload, rLPHash(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// makeVotePayload returns the RLP of a prevote of blockNumber, its signature is not valid
+func makeVotePayload(t *testing.T, blockNumber int64, round int64) []byte {
+	hash := common.HexToHash("0x01")
+	vote, err := rlp.EncodeToBytes(&tendermintCore.Vote{
+		BlockHash:   &hash,
+		BlockNumber: big.NewInt(blockNumber),
+		Round:       round,
+	})
+	require.NoError(t, err)
+	payload, err := rlp.EncodeToBytes(struct {
+		Code      uint64
+		Msg       []byte
+		Address   common.Address
+		Signature []byte
+	}{Code: 1, Msg: vote, Address: tests_utils.GetAddress()})
+	require.NoError(t, err)
+	return payload
+}
+
+func TestVoteBatcher(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent [][]byte
+		peer = &tests_utils.MockPeer{SendFn: func(data interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, data.([]byte))
+			return nil
+		}}
+		addr    = tests_utils.GetAddress()
+		batcher = newVoteBatcher(20*time.Millisecond, func(blockNumber *big.Int, payloads [][]byte) ([]byte, error) {
+			return rlp.EncodeToBytes(&tendermintCore.VoteBatchMsg{BlockNumber: blockNumber, Payloads: payloads})
+		})
+		send = func(blockNumber int64, payloads ...[]byte) {
+			var batches []*voteBatch
+			for _, payload := range payloads {
+				batches = append(batches, batcher.send(peer, addr, big.NewInt(blockNumber), payload))
+			}
+			for _, batch := range batches {
+				<-batch.done
+				require.NoError(t, batch.err)
+			}
+		}
+		votes = [][]byte{makeVotePayload(t, 1, 0), makeVotePayload(t, 1, 1), makeVotePayload(t, 1, 2)}
+	)
+
+	// the votes sent within the delay are packed, once each
+	send(1, votes[0], votes[1], votes[2], votes[0])
+	require.Len(t, sent, 1)
+	var batch tendermintCore.VoteBatchMsg
+	require.NoError(t, rlp.DecodeBytes(sent[0], &batch))
+	require.Equal(t, int64(1), batch.BlockNumber.Int64())
+	require.ElementsMatch(t, votes, batch.Payloads)
+
+	// a single vote is sent as is
+	send(1, votes[0])
+	require.Len(t, sent, 2)
+	require.Equal(t, votes[0], sent[1])
+
+	// a vote of another block number is not packed with the pending ones
+	send(1, votes[1])
+	send(2, makeVotePayload(t, 2, 0))
+	require.Len(t, sent, 4)
+}
+
+// voteBatchPeer decodes the vote batches
+type voteBatchPeer struct {
+	tests_utils.MockPeer
+}
+
+func (p *voteBatchPeer) VoteBatches() bool {
+	return true
+}
+
+func TestBackend_SendVote(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		be             = mustCreateAndStartNewBackend(t, nodePrivateKey, tests_utils.MakeGenesisHeader([]common.Address{nodeAddr}), []common.Address{nodeAddr})
+		vote           = makeVotePayload(t, 1, 0)
+		sent           = make(chan []byte, 2)
+		sendFn         = func(data interface{}) error {
+			sent <- data.([]byte)
+			return nil
+		}
+	)
+	be.voteBatcher = newVoteBatcher(time.Hour, func(blockNumber *big.Int, payloads [][]byte) ([]byte, error) {
+		return rlp.EncodeToBytes(&tendermintCore.VoteBatchMsg{BlockNumber: blockNumber, Payloads: payloads})
+	})
+
+	// the vote is batched for a peer decoding the batches, without waiting for the batch to be sent
+	require.NoError(t, be.send(&voteBatchPeer{MockPeer: tests_utils.MockPeer{SendFn: sendFn}}, common.HexToAddress("0x01"), 1, vote))
+	require.Empty(t, sent)
+
+	// and sent as is to the others
+	require.NoError(t, be.send(&tests_utils.MockPeer{SendFn: sendFn}, common.HexToAddress("0x02"), 1, vote))
+	require.Equal(t, vote, <-sent)
+}
+
+func TestBackend_HandleVoteBatch(t *testing.T) {
+	be, _, _, err := createBlockchainAndBackendFromGenesis(FixedValidators)
+	require.NoError(t, err)
+	var (
+		key    = tests_utils.MakeNodeKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		sign   = func(data []byte) ([]byte, error) {
+			return crypto.Sign(crypto.Keccak256(data), key)
+		}
+		votes = [][]byte{makeVotePayload(t, 1, 0), makeVotePayload(t, 1, 1)}
+	)
+	batch, err := tendermintCore.EncodeVoteBatch(be.config, sender, sign, big.NewInt(1), votes)
+	require.NoError(t, err)
+
+	// the batch must be signed by the peer which sent it
+	handled, err := be.HandleMsg(tests_utils.GetAddress(), makeMsg(consensus.TendermintMsg, batch))
+	require.True(t, handled)
+	require.NoError(t, err)
+	require.Equal(t, 0, be.storingMsgs.GetLen())
+
+	// the votes are queued for core one by one
+	handled, err = be.HandleMsg(sender, makeMsg(consensus.TendermintMsg, batch))
+	require.True(t, handled)
+	require.NoError(t, err)
+	require.Equal(t, len(votes), be.storingMsgs.GetLen())
+	for i, vote := range votes {
+		stored, err := be.storingMsgs.Get(i)
+		require.NoError(t, err)
+		require.Equal(t, storedMsg{payload: vote, from: sender}, stored)
+	}
+
+	// the votes the peer sent in the batch are not sent back to it
+	require.Empty(t, be.msgCaches.unknownTargets(map[common.Address]bool{sender: true}, rLPHash(votes[0])))
+}
@@ -0,0 +1,57 @@
+package tendermint
+
+import (
+	"math"
+	"time"
+)
+
+// TimeoutBackoff is the strategy to grow the propose, prevote and precommit timeouts with the round,
+// so that the validators eventually reach a round long enough to agree.
+type TimeoutBackoff uint64
+
+const (
+	// LinearBackoff grows a timeout by its delta each round: timeout + round*delta
+	LinearBackoff TimeoutBackoff = iota
+	// ExponentialBackoff doubles the growth each round: timeout + (2^round-1)*delta
+	ExponentialBackoff
+	// ConstantBackoff keeps the timeout the same for every round
+	ConstantBackoff
+)
+
+// String implements fmt.Stringer
+func (b TimeoutBackoff) String() string {
+	switch b {
+	case LinearBackoff:
+		return "linear"
+	case ExponentialBackoff:
+		return "exponential"
+	case ConstantBackoff:
+		return "constant"
+	default:
+		return "unknown"
+	}
+}
+
+// Timeout returns the timeout at round for a base timeout and its delta.
+// If maxTimeout is positive, the result never exceeds it.
+func (b TimeoutBackoff) Timeout(timeout, delta time.Duration, round int64, maxTimeout time.Duration) time.Duration {
+	var growth time.Duration
+	switch b {
+	case ExponentialBackoff:
+		// saturate instead of overflowing for the very high rounds
+		if round >= 62 || (round > 0 && int64(delta) > math.MaxInt64>>uint(round)) {
+			growth = math.MaxInt64 - timeout
+		} else {
+			growth = delta * time.Duration((int64(1)<<uint(round))-1)
+		}
+	case ConstantBackoff:
+		growth = 0
+	default:
+		growth = delta * time.Duration(round)
+	}
+	result := timeout + growth
+	if maxTimeout > 0 && result > maxTimeout {
+		return maxTimeout
+	}
+	return result
+}
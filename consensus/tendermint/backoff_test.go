@@ -0,0 +1,48 @@
+package tendermint
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutBackoff(t *testing.T) {
+	var (
+		timeout = time.Second
+		delta   = 500 * time.Millisecond
+	)
+	tests := []struct {
+		backoff    TimeoutBackoff
+		round      int64
+		maxTimeout time.Duration
+		expected   time.Duration
+	}{
+		{LinearBackoff, 0, 0, time.Second},
+		{LinearBackoff, 3, 0, 2500 * time.Millisecond},
+		{LinearBackoff, 3, 2 * time.Second, 2 * time.Second},
+		{ExponentialBackoff, 0, 0, time.Second},
+		{ExponentialBackoff, 1, 0, 1500 * time.Millisecond},
+		{ExponentialBackoff, 3, 0, 4500 * time.Millisecond},
+		{ExponentialBackoff, 10, time.Minute, time.Minute},
+		{ExponentialBackoff, 100, 0, math.MaxInt64},
+		{ConstantBackoff, 0, 0, time.Second},
+		{ConstantBackoff, 10, 0, time.Second},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, test.backoff.Timeout(timeout, delta, test.round, test.maxTimeout),
+			"%s backoff at round %d", test.backoff, test.round)
+	}
+}
+
+func TestConfigTimeoutBackoff(t *testing.T) {
+	cfg := *DefaultConfig
+	require.Equal(t, 4500*time.Millisecond, cfg.ProposeTimeout(3))
+
+	cfg.TimeoutBackoff = ExponentialBackoff
+	cfg.TimeoutBackoffMax = 5 * time.Second
+	require.Equal(t, 2500*time.Millisecond, cfg.PrevoteTimeout(2))
+	require.Equal(t, 5*time.Second, cfg.PrecommitTimeout(4))
+	require.Equal(t, 10*time.Second, cfg.PrevoteCatchupTimeout(4))
+}
@@ -1,11 +1,15 @@
 package tendermint
 
 import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/params"
 )
 
 type ProposerPolicy uint64
@@ -13,6 +17,8 @@ type ProposerPolicy uint64
 const (
 	RoundRobin ProposerPolicy = iota
 	Sticky
+	// StakeWeighted gives each validator a number of propose slots proportional to its stake at the epoch's checkpoint
+	StakeWeighted
 )
 
 //FaultyMode is the config mode to enable fauty node
@@ -37,6 +43,7 @@ type Config struct {
 	Epoch                 uint64           `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
 	StakingSCAddress      *common.Address  `toml:",omitempty"` // The staking SC address for validating when deploy SC
 	BlockPeriod           uint64           `toml:",omitempty"` // Default minimum difference between two consecutive block's timestamps in second
+	MaxTimestampDrift     time.Duration    `toml:",omitempty"` // The maximum a proposal's timestamp may be ahead of the local time
 	TimeoutPropose        time.Duration    //Duration waiting a propose
 	TimeoutProposeDelta   time.Duration    //Increment if timeout happens at propose step to reach eventually synchronous
 	TimeoutPrevote        time.Duration    //Duration waiting for more prevote after 2/3 received
@@ -44,11 +51,87 @@ type Config struct {
 	TimeoutPrecommit      time.Duration    //Duration waiting for more precommit after 2/3 received
 	TimeoutPrecommitDelta time.Duration    //Duration waiting to increase if precommit wait expired to reach eventually synchronous
 	TimeoutCommit         time.Duration    //Duration waiting to start round with new height
+	TargetBlockInterval   time.Duration    `toml:",omitempty"` // The average block interval the commit timeout is adjusted to keep, 0 always waits TimeoutCommit
+	TimeoutBackoff        TimeoutBackoff   `toml:"-"`          // The strategy to grow the timeouts with the round
+	TimeoutBackoffMax     time.Duration    `toml:"-"`          // The maximum of a timeout growing with the round, 0 means no limit
 	FixedValidators       []common.Address // The fixed validators
-	BlockReward           *big.Int         //BlockReward for accumulating reward
+	BlockReward           *big.Int         `toml:",omitempty"` // BlockReward for accumulating reward
+	ChainID               *big.Int         `toml:"-"`          // The chain id, signed in consensus messages from DomainSeparationBlock
+	DomainSeparationBlock *big.Int         `toml:"-"`          // The block from which consensus messages sign chain id, block number, round and type
+	WeightedVotingBlock   *big.Int         `toml:"-"`          // The block from which the validators vote with their stake weights
+	ProtobufSigningBlock  *big.Int         `toml:"-"`          // The block from which consensus messages are signed protobuf encoded
+	StrictTimestampBlock  *big.Int         `toml:"-"`          // The block from which a block's timestamp must be after its parent's
+	GasLimitBoundBlock    *big.Int         `toml:"-"`          // The block from which a block's gas limit is bounded by its parent's
 
 	FaultyMode uint64 `toml:",omitempty"` // The faulty node indicates the faulty node's behavior
 
+	Observer  bool             `toml:",omitempty"` // Observer follows the rounds and finalizes blocks without signing any message
+	Observers []common.Address `toml:",omitempty"` // The node addresses of observers which consensus messages are also sent to
+	// StartPaused starts core paused, following the rounds without proposing nor voting until it is resumed by the admin API
+	StartPaused bool `toml:",omitempty"`
+
+	NoConsensusProtocol  bool             `toml:",omitempty"` // Do not run the consensus sub protocol, consensus messages are then only sent over evr/64
+	ProtobufMessages     bool             `toml:",omitempty"` // Send the consensus messages protobuf encoded to the peers of the consensus sub protocol which decode them
+	ValidatorOnlyNetwork bool             `toml:",omitempty"` // Only validators, sentries and observers may connect on the consensus sub protocol
+	Sentries             []common.Address `toml:",omitempty"` // The node addresses of the sentries allowed on a validator only network
+	DevValidators        bool             `toml:",omitempty"` // Enable the admin API to add and remove validators at the next epoch, for test networks only
+
+	ProposeGossip   GossipStrategy `toml:",omitempty"` // The strategy to disseminate proposals
+	PrevoteGossip   GossipStrategy `toml:",omitempty"` // The strategy to disseminate prevotes
+	PrecommitGossip GossipStrategy `toml:",omitempty"` // The strategy to disseminate precommits
+	GossipFanout    int            `toml:",omitempty"` // The number of validators a message is sent to by the random and tree strategies, 0 means sqrt of the validator set size
+
+	RecentMessagesCache int `toml:",omitempty"` // The number of recent message hashes kept to drop the duplicates, 0 means the default
+	KnownMessagesCache  int `toml:",omitempty"` // The number of message hashes kept per peer not to send them back, 0 means the default
+
+	// The proposals and votes more than HeightWindow blocks behind or ahead of the current block, and the ones of the current
+	// block more than RoundWindow rounds ahead of the current round or behind the latest round of their signer, are dropped
+	// before their signature is verified. A zero window is not checked.
+	HeightWindow uint64 `toml:",omitempty"`
+	RoundWindow  uint64 `toml:",omitempty"`
+
+	VoteRetentionHeights uint64 `toml:",omitempty"` // The number of past blocks all the votes of which are kept to serve the peers still at them, 0 keeps only the commit of the last block
+	MaxVoteRounds        uint64 `toml:",omitempty"` // The number of rounds the vote sets of which keep the messages, the older ones only keep their votes and tallies, 0 for no limit
+	ServedCommits        uint64 `toml:",omitempty"` // The number of last blocks whose commits are served to the peers requesting them, 0 serves none
+
+	MessageQueueSize int `toml:",omitempty"` // The number of received messages waiting for their signature to be verified, the others are dropped, 0 means the default
+	VerifyWorkers    int `toml:",omitempty"` // The number of goroutines verifying the signatures of the received messages, 0 means the number of CPUs
+
+	ProposalAckTimeout time.Duration `toml:",omitempty"` // Duration waiting for the validators to acknowledge a proposal before resending it, 0 disables the resends
+
+	HeartbeatInterval  time.Duration `toml:",omitempty"` // The interval of the heartbeats the proposer sends while it has no block to propose yet, 0 disables them
+	HeartbeatExtension time.Duration `toml:",omitempty"` // Duration the propose timeout is extended by, once per round, on a heartbeat of the proposer, 0 ignores the heartbeats
+
+	VoteBatchDelay time.Duration `toml:",omitempty"` // Duration the votes sent to a peer wait for other votes to be packed with them in a single message, 0 disables the batching
+
+	// An evidence of misbehavior expires once its block is both EvidenceMaxAgeBlocks behind the head and EvidenceMaxAge
+	// older than it, it is then discarded and no longer accepted. A zero limit is not checked, both zero keep the evidences.
+	EvidenceMaxAgeBlocks uint64        `toml:",omitempty"`
+	EvidenceMaxAge       time.Duration `toml:",omitempty"`
+
+	GasTarget uint64 `toml:",omitempty"` // The gas limit the proposed blocks move toward, 0 follows the miner gas floor and ceiling
+
+	ProposalGas  uint64        `toml:",omitempty"` // The gas the transactions of a proposed block may use below its gas limit, 0 for the gas limit
+	ProposalTime time.Duration `toml:",omitempty"` // The maximum duration the transactions of a proposed block are assembled for, 0 for no limit
+
+	SpeculativeRounds int `toml:",omitempty"` // The number of next rounds the proposals of which are reassembled as transactions arrive, 0 disables it
+
+	HealthAddr  string `toml:",omitempty"` // The listening address of the HTTP health and readiness probes, empty disables them
+	MetricsAddr string `toml:",omitempty"` // The listening address of the HTTP metrics server for the consensus dashboards, empty disables it
+
+	TracingEndpoint string `toml:",omitempty"` // The OTLP/HTTP endpoint of the collector the consensus spans are exported to, empty disables the tracing
+	EventTraceFile  string `toml:",omitempty"` // The file the events handled by the consensus core are appended to, to replay them with core.Replay, empty disables it
+
+	ValidatorKeyFile     string            `toml:",omitempty"` // The encrypted validator key file, <datadir>/geth/validatorkey if empty
+	ValidatorKey         *ecdsa.PrivateKey `toml:"-"`          // The key signing the blocks and consensus messages, the node key is used if nil
+	NextValidatorKeyFile string            `toml:",omitempty"` // The encrypted key file of the key the validator is rotated to
+	NextValidatorKey     *ecdsa.PrivateKey `toml:"-"`          // The key the validator signs with once the validator sets list it, see KeyRotations
+
+	KeyRotations []params.TendermintKeyRotation `toml:"-"` // The key rotations of the validators scheduled by the genesis
+	Recoveries   []params.TendermintRecovery    `toml:"-"` // The validator sets replaced by the genesis after a loss of quorum
+	// The reward schedule of the genesis, the blocks carry the commit of their parent while its committers are rewarded
+	RewardSchedule []params.TendermintRewardStage `toml:"-"`
+
 	UseEVMCaller        bool
 	IndexStateVariables *staking.IndexConfigs //The index of state variables has stored in stateDB
 }
@@ -65,24 +148,29 @@ var DefaultConfig = &Config{
 	TimeoutPrecommit:      1000 * time.Millisecond,
 	TimeoutPrecommitDelta: 500 * time.Millisecond,
 	TimeoutCommit:         1000 * time.Millisecond,
+	ProposalAckTimeout:    500 * time.Millisecond,
+	MaxTimestampDrift:     5 * time.Second,
+	SpeculativeRounds:     2,
+	HeightWindow:          100,
+	RoundWindow:           100,
+	MaxVoteRounds:         16,
+	ServedCommits:         128,
+	EvidenceMaxAgeBlocks:  100000,
+	EvidenceMaxAge:        48 * time.Hour,
 	FaultyMode:            Disabled.Uint64(),
 	UseEVMCaller:          false,
 	IndexStateVariables:   staking.DefaultConfig,
 }
 
 //ProposeTimeout return the timeout for a specific round
-//The formula is timeout= TimeoutPropose + round*TimeoutProposeDelta
+//The formula depends on TimeoutBackoff, e.g with LinearBackoff timeout= TimeoutPropose + round*TimeoutProposeDelta
 func (cfg Config) ProposeTimeout(round int64) time.Duration {
-	return time.Duration(
-		cfg.TimeoutPropose.Nanoseconds()+cfg.TimeoutProposeDelta.Nanoseconds()*(round),
-	) * time.Nanosecond
+	return cfg.TimeoutBackoff.Timeout(cfg.TimeoutPropose, cfg.TimeoutProposeDelta, round, cfg.TimeoutBackoffMax)
 }
 
 // PrevoteTimeout returns the amount of time to wait for straggler votes after receiving any +2/3 prevotes
 func (cfg *Config) PrevoteTimeout(round int64) time.Duration {
-	return time.Duration(
-		cfg.TimeoutPrevote.Nanoseconds()+cfg.TimeoutPrevoteDelta.Nanoseconds()*int64(round),
-	) * time.Nanosecond
+	return cfg.TimeoutBackoff.Timeout(cfg.TimeoutPrevote, cfg.TimeoutPrevoteDelta, round, cfg.TimeoutBackoffMax)
 }
 
 //PrevoteCatchupTimeout returns the amount of time to wait for prevote msgs before sending catchup msg
@@ -95,9 +183,7 @@ func (cfg *Config) PrevoteCatchupTimeout(round int64) time.Duration {
 
 // PrecommitTimeout returns the amount of time to wait for straggler votes after receiving any +2/3 precommits
 func (cfg *Config) PrecommitTimeout(round int64) time.Duration {
-	return time.Duration(
-		cfg.TimeoutPrecommit.Nanoseconds()+cfg.TimeoutPrecommitDelta.Nanoseconds()*int64(round),
-	) * time.Nanosecond
+	return cfg.TimeoutBackoff.Timeout(cfg.TimeoutPrecommit, cfg.TimeoutPrecommitDelta, round, cfg.TimeoutBackoffMax)
 }
 
 //PrecommitCatchupTimeout returns the amount of time to wait for precommit msgs before sending catchup msg
@@ -112,3 +198,68 @@ func (cfg *Config) PrecommitCatchupTimeout(round int64) time.Duration {
 func (cfg *Config) Commit(t time.Time) time.Time {
 	return t.Add(cfg.TimeoutCommit)
 }
+
+// IsStrictTimestamp returns whether the block number must have a timestamp after its parent's
+func (cfg *Config) IsStrictTimestamp(number *big.Int) bool {
+	return cfg.StrictTimestampBlock != nil && number != nil && cfg.StrictTimestampBlock.Cmp(number) <= 0
+}
+
+// IsGasLimitBound returns whether the gas limit of the block number is bounded by its parent's, see GasLimitBoundBlock
+func (cfg *Config) IsGasLimitBound(number *big.Int) bool {
+	return cfg.GasLimitBoundBlock != nil && number != nil && cfg.GasLimitBoundBlock.Cmp(number) <= 0
+}
+
+// Validate checks the configuration values a node can not run consensus with
+func (cfg *Config) Validate() error {
+	if cfg.ProposerPolicy != RoundRobin && cfg.ProposerPolicy != Sticky && cfg.ProposerPolicy != StakeWeighted {
+		return fmt.Errorf("unknown proposer policy %d", cfg.ProposerPolicy)
+	}
+	if cfg.Epoch == 0 {
+		return errors.New("epoch must be positive")
+	}
+	for name, timeout := range map[string]time.Duration{
+		"propose":   cfg.TimeoutPropose,
+		"prevote":   cfg.TimeoutPrevote,
+		"precommit": cfg.TimeoutPrecommit,
+	} {
+		if timeout <= 0 {
+			return fmt.Errorf("%s timeout must be positive, got %v", name, timeout)
+		}
+	}
+	for name, duration := range map[string]time.Duration{
+		"propose timeout delta":   cfg.TimeoutProposeDelta,
+		"prevote timeout delta":   cfg.TimeoutPrevoteDelta,
+		"precommit timeout delta": cfg.TimeoutPrecommitDelta,
+		"commit timeout":          cfg.TimeoutCommit,
+		"target block interval":   cfg.TargetBlockInterval,
+		"proposal ack timeout":    cfg.ProposalAckTimeout,
+		"max timestamp drift":     cfg.MaxTimestampDrift,
+		"evidence max age":        cfg.EvidenceMaxAge,
+		"timeout backoff max":     cfg.TimeoutBackoffMax,
+	} {
+		if duration < 0 {
+			return fmt.Errorf("%s must not be negative, got %v", name, duration)
+		}
+	}
+	if cfg.TimeoutBackoff > ConstantBackoff {
+		return fmt.Errorf("unknown timeout backoff %d", cfg.TimeoutBackoff)
+	}
+	for _, strategy := range []GossipStrategy{cfg.ProposeGossip, cfg.PrevoteGossip, cfg.PrecommitGossip} {
+		if strategy > TreeGossip {
+			return fmt.Errorf("unknown gossip strategy %d", strategy)
+		}
+	}
+	if cfg.GossipFanout < 0 || cfg.RecentMessagesCache < 0 || cfg.KnownMessagesCache < 0 {
+		return errors.New("gossip fanout and message cache sizes must not be negative")
+	}
+	if cfg.MessageQueueSize < 0 || cfg.VerifyWorkers < 0 {
+		return errors.New("message queue size and verify workers must not be negative")
+	}
+	if cfg.WeightedVotingBlock != nil && cfg.ProposerPolicy != StakeWeighted {
+		return errors.New("weighted voting requires the stake weighted proposer policy")
+	}
+	if cfg.SpeculativeRounds < 0 {
+		return fmt.Errorf("speculative rounds must not be negative, got %d", cfg.SpeculativeRounds)
+	}
+	return nil
+}
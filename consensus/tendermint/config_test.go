@@ -0,0 +1,34 @@
+package tendermint_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, tendermint.DefaultConfig.Validate())
+
+	for name, modify := range map[string]func(cfg *tendermint.Config){
+		"unknown policy":   func(cfg *tendermint.Config) { cfg.ProposerPolicy = tendermint.StakeWeighted + 1 },
+		"zero epoch":       func(cfg *tendermint.Config) { cfg.Epoch = 0 },
+		"zero propose":     func(cfg *tendermint.Config) { cfg.TimeoutPropose = 0 },
+		"negative delta":   func(cfg *tendermint.Config) { cfg.TimeoutPrevoteDelta = -1 },
+		"negative commit":  func(cfg *tendermint.Config) { cfg.TimeoutCommit = -1 },
+		"unknown backoff":  func(cfg *tendermint.Config) { cfg.TimeoutBackoff = tendermint.ConstantBackoff + 1 },
+		"negative backoff": func(cfg *tendermint.Config) { cfg.TimeoutBackoffMax = -1 },
+		"unknown strategy": func(cfg *tendermint.Config) { cfg.PrecommitGossip = tendermint.TreeGossip + 1 },
+		"negative fanout":  func(cfg *tendermint.Config) { cfg.GossipFanout = -1 },
+		"negative workers": func(cfg *tendermint.Config) { cfg.VerifyWorkers = -1 },
+		"weighted voting": func(cfg *tendermint.Config) {
+			cfg.ProposerPolicy, cfg.WeightedVotingBlock = tendermint.RoundRobin, big.NewInt(10)
+		},
+	} {
+		cfg := *tendermint.DefaultConfig
+		modify(&cfg)
+		require.Error(t, cfg.Validate(), name)
+	}
+}
@@ -0,0 +1,256 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	// ErrInvalidAmnesia is returned by DecodeAmnesia when the evidence doesn't hold a precommit of a block and
+	// a later prevote of another block signed by the same validator at the same block number
+	ErrInvalidAmnesia = errors.New("evidence doesn't hold a precommit and a later prevote of another block by the same validator")
+	// ErrInvalidProofOfLock is returned by VerifyProofOfLock when the prevotes don't unlock the validator
+	ErrInvalidProofOfLock = errors.New("proof of lock doesn't hold 2/3 prevotes unlocking the validator")
+)
+
+// AmnesiaEvidence proves that a validator precommitted a block, which locks it on the block, and prevoted another
+// block in a later round of the same block number. It holds the signed precommit and prevote messages.
+// The prevote doesn't violate the lock if 2/3 of the validators prevoted another block or nil in a round after
+// the precommit, see ProofOfLock.
+type AmnesiaEvidence struct {
+	Precommit []byte
+	Prevote   []byte
+}
+
+// ProofOfLock is the 2/3 prevotes of a round for the same block, or for nil, which unlock a validator
+// from the block it precommitted in an earlier round. It clears a validator accused by an AmnesiaEvidence.
+type ProofOfLock struct {
+	Round    int64
+	Prevotes [][]byte
+}
+
+// Amnesia is a verified AmnesiaEvidence
+type Amnesia struct {
+	Validator      common.Address
+	BlockNumber    *big.Int
+	PrecommitRound int64
+	PrecommitHash  common.Hash
+	PrevoteRound   int64
+	PrevoteHash    common.Hash
+	Payload        []byte // the evidence message, as it is gossiped
+}
+
+// AmnesiaHandler is called when core receives a prevote contrary to the lock of its validator without 2/3 prevotes
+// unlocking it. It is called synchronously by the state machine so it must return quickly and must not call core.
+type AmnesiaHandler func(*Amnesia)
+
+// WithAmnesiaHandler returns an option to set the handler of the amnesia evidences core detects
+func WithAmnesiaHandler(handler AmnesiaHandler) Option {
+	return func(c *core) error {
+		c.onAmnesia = handler
+		return nil
+	}
+}
+
+// IsAmnesia returns true if msgType is the code of amnesia evidences
+func IsAmnesia(msgType uint64) bool {
+	return msgType == msgAmnesia
+}
+
+// encodeAmnesia returns the evidence message of a precommit and a later prevote
+func encodeAmnesia(precommit, prevote *message) ([]byte, error) {
+	var evidence AmnesiaEvidence
+	var err error
+	if evidence.Precommit, err = rlp.EncodeToBytes(precommit); err != nil {
+		return nil, err
+	}
+	if evidence.Prevote, err = rlp.EncodeToBytes(prevote); err != nil {
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes(&evidence)
+	if err != nil {
+		return nil, err
+	}
+	// the evidence is signed by the validator in its votes, the message itself is not signed
+	return rlp.EncodeToBytes(&message{
+		Code: msgAmnesia,
+		Msg:  data,
+	})
+}
+
+// decodeSignedVote decodes a vote message of code and verifies it is signed by its address
+func decodeSignedVote(config *tendermint.Config, data []byte, code uint64) (*message, *Vote, error) {
+	var msg message
+	if err := rlp.DecodeBytes(data, &msg); err != nil {
+		return nil, nil, err
+	}
+	if msg.Code != code {
+		return nil, nil, errors.Errorf("unexpected msg code %d", msg.Code)
+	}
+	signer, err := msg.GetAddressFromSignature(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	if signer != msg.Address {
+		return nil, nil, ErrSignerMessageMissMatch
+	}
+	var vote Vote
+	if err := rlp.DecodeBytes(msg.Msg, &vote); err != nil {
+		return nil, nil, err
+	}
+	if vote.BlockHash == nil || vote.BlockNumber == nil {
+		return nil, nil, ErrEmptyVote
+	}
+	return &msg, &vote, nil
+}
+
+// DecodeAmnesia verifies an evidence message encoded by core: the precommit of a block and the prevote of another
+// block in a later round are signed by the same address for the same block number. Whether the address is a
+// validator of the block number, and whether a ProofOfLock clears it, is left to the caller.
+func DecodeAmnesia(config *tendermint.Config, payload []byte) (*Amnesia, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return nil, err
+	}
+	if msg.Code != msgAmnesia {
+		return nil, errors.Errorf("unexpected msg code %d", msg.Code)
+	}
+	var evidence AmnesiaEvidence
+	if err := rlp.DecodeBytes(msg.Msg, &evidence); err != nil {
+		return nil, err
+	}
+	precommitMsg, precommit, err := decodeSignedVote(config, evidence.Precommit, msgPrecommit)
+	if err != nil {
+		return nil, err
+	}
+	prevoteMsg, prevote, err := decodeSignedVote(config, evidence.Prevote, msgPrevote)
+	if err != nil {
+		return nil, err
+	}
+	if precommitMsg.Address != prevoteMsg.Address || precommit.BlockNumber.Cmp(prevote.BlockNumber) != 0 ||
+		precommit.Round >= prevote.Round || *precommit.BlockHash == emptyBlockHash || *prevote.BlockHash == emptyBlockHash ||
+		*precommit.BlockHash == *prevote.BlockHash {
+		return nil, ErrInvalidAmnesia
+	}
+	return &Amnesia{
+		Validator:      precommitMsg.Address,
+		BlockNumber:    precommit.BlockNumber,
+		PrecommitRound: precommit.Round,
+		PrecommitHash:  *precommit.BlockHash,
+		PrevoteRound:   prevote.Round,
+		PrevoteHash:    *prevote.BlockHash,
+		Payload:        payload,
+	}, nil
+}
+
+// VerifyProofOfLock returns nil if pol clears the validator of amnesia: it holds the prevotes of a quorum of valSet,
+// the validators of the block number, for the same block or nil other than the precommitted one, in a round after
+// the precommit and up to the prevote.
+func VerifyProofOfLock(config *tendermint.Config, valSet tendermint.ValidatorSet, amnesia *Amnesia, pol *ProofOfLock) error {
+	if pol.Round <= amnesia.PrecommitRound || pol.Round > amnesia.PrevoteRound {
+		return ErrInvalidProofOfLock
+	}
+	var (
+		hash   *common.Hash
+		voters = make(map[common.Address]bool)
+	)
+	for _, data := range pol.Prevotes {
+		msg, vote, err := decodeSignedVote(config, data, msgPrevote)
+		if err != nil {
+			return err
+		}
+		if vote.BlockNumber.Cmp(amnesia.BlockNumber) != 0 || vote.Round != pol.Round {
+			return ErrInvalidProofOfLock
+		}
+		if hash == nil {
+			hash = vote.BlockHash
+		}
+		if *vote.BlockHash != *hash || *hash == amnesia.PrecommitHash {
+			return ErrInvalidProofOfLock
+		}
+		if index, _ := valSet.GetByAddress(msg.Address); index == -1 {
+			return ErrInvalidProofOfLock
+		}
+		voters[msg.Address] = true
+	}
+	var power int
+	for voter := range voters {
+		power += valSet.VotingPower(voter)
+	}
+	if power < valSet.QuorumPower() {
+		return ErrInvalidProofOfLock
+	}
+	return nil
+}
+
+// checkAmnesia reports the validator of the vote just added if it prevoted a block after precommitting another one
+// at the current block number, while no 2/3 prevotes core received unlock it. A validator is reported once per
+// block number.
+func (c *core) checkAmnesia(validator common.Address) {
+	if c.reportedAmnesias[validator] {
+		return
+	}
+	state := c.CurrentState()
+	for precommitRound, precommits := range state.PrecommitsReceived {
+		precommitMsg, precommit := precommits.voteOf(validator)
+		// the evidence needs both messages, those of the evicted rounds are gone
+		if precommitMsg == nil || *precommit.BlockHash == emptyBlockHash {
+			continue
+		}
+		for prevoteRound, prevotes := range state.PrevotesReceived {
+			if prevoteRound <= precommitRound {
+				continue
+			}
+			prevoteMsg, prevote := prevotes.voteOf(validator)
+			if prevoteMsg == nil || *prevote.BlockHash == emptyBlockHash || *prevote.BlockHash == *precommit.BlockHash {
+				continue
+			}
+			if c.unlocked(*precommit.BlockHash, precommitRound, prevoteRound) {
+				continue
+			}
+			c.reportAmnesia(precommitMsg, prevoteMsg)
+			return
+		}
+	}
+}
+
+// unlocked returns true if core received 2/3 prevotes for a block or nil other than locked in a round
+// after lockedRound and up to round
+func (c *core) unlocked(locked common.Hash, lockedRound int64, round int64) bool {
+	for polRound := lockedRound + 1; polRound <= round; polRound++ {
+		prevotes, ok := c.CurrentState().GetPrevotesByRound(polRound)
+		if !ok {
+			continue
+		}
+		if hash, ok := prevotes.TwoThirdMajority(); ok && hash != locked {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *core) reportAmnesia(precommitMsg, prevoteMsg *message) {
+	c.reportedAmnesias[prevoteMsg.Address] = true
+	logger := c.getLogger().With("validator", prevoteMsg.Address)
+	payload, err := encodeAmnesia(precommitMsg, prevoteMsg)
+	if err != nil {
+		logger.Errorw("failed to encode the amnesia evidence", "err", err)
+		return
+	}
+	amnesia, err := DecodeAmnesia(c.config, payload)
+	if err != nil {
+		logger.Errorw("failed to verify the amnesia evidence", "err", err)
+		return
+	}
+	logger.Warnw("the validator prevoted a block contrary to its lock", "precommit_round", amnesia.PrecommitRound,
+		"precommit_block_hash", amnesia.PrecommitHash.Hex(), "prevote_round", amnesia.PrevoteRound,
+		"prevote_block_hash", amnesia.PrevoteHash.Hex())
+	if c.onAmnesia != nil {
+		c.onAmnesia(amnesia)
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestCore_Amnesia(t *testing.T) {
+	var (
+		privateKeys []*ecdsa.PrivateKey
+		validators  []common.Address
+		reported    []*Amnesia
+		lockedHash  = common.HexToHash("0x1")
+		otherHash   = common.HexToHash("0x2")
+		voteMsg     = func(code uint64, key *ecdsa.PrivateKey, hash common.Hash, round int64) message {
+			msgData, err := rlp.EncodeToBytes(&Vote{
+				BlockHash:   &hash,
+				BlockNumber: big.NewInt(1),
+				Round:       round,
+			})
+			require.NoError(t, err)
+			msg := message{
+				Code:    code,
+				Msg:     msgData,
+				Address: crypto.PubkeyToAddress(key.PublicKey),
+			}
+			sign(t, &msg, key)
+			return msg
+		}
+	)
+	for i := 0; i < 4; i++ {
+		key := tests_utils.MakeNodeKey()
+		privateKeys = append(privateKeys, key)
+		validators = append(validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	genesisHeader := tests_utils.MakeGenesisHeader(validators)
+	be, _ := tests_utils.MustCreateAndStartNewBackend(t, privateKeys[0], genesisHeader, validators)
+	core := newTestCore(be, tendermint.DefaultConfig)
+	core.onAmnesia = func(evidence *Amnesia) {
+		reported = append(reported, evidence)
+	}
+	core.currentState = core.getInitializedState()
+	core.valSet = be.Validators(core.CurrentState().BlockNumber())
+	// the votes are not of future rounds, a validator only holds one of these
+	core.currentState.SetView(&tendermint.View{BlockNumber: core.CurrentState().BlockNumber(), Round: 4})
+
+	// prevoting the locked block again, or nil, is not an amnesia
+	precommit := voteMsg(msgPrecommit, privateKeys[1], lockedHash, 0)
+	require.NoError(t, core.handleMsg(precommit))
+	require.NoError(t, core.handleMsg(voteMsg(msgPrevote, privateKeys[1], lockedHash, 1)))
+	require.NoError(t, core.handleMsg(voteMsg(msgPrevote, privateKeys[1], emptyBlockHash, 2)))
+	require.Empty(t, reported)
+
+	// prevoting another block in a later round is reported once
+	prevote := voteMsg(msgPrevote, privateKeys[1], otherHash, 3)
+	require.NoError(t, core.handleMsg(prevote))
+	require.NoError(t, core.handleMsg(voteMsg(msgPrevote, privateKeys[1], otherHash, 4)))
+	require.Len(t, reported, 1)
+	evidence, err := DecodeAmnesia(tendermint.DefaultConfig, reported[0].Payload)
+	require.NoError(t, err)
+	require.Equal(t, reported[0], evidence)
+	require.Equal(t, validators[1], evidence.Validator)
+	require.Equal(t, int64(0), evidence.PrecommitRound)
+	require.Equal(t, lockedHash, evidence.PrecommitHash)
+	require.Equal(t, int64(3), evidence.PrevoteRound)
+	require.Equal(t, otherHash, evidence.PrevoteHash)
+	require.True(t, IsAmnesia(msgCode(t, evidence.Payload)))
+
+	// 2/3 prevotes for another block after the precommit unlock the validator
+	require.NoError(t, core.handleMsg(voteMsg(msgPrecommit, privateKeys[2], lockedHash, 0)))
+	require.NoError(t, core.handleMsg(voteMsg(msgPrevote, privateKeys[0], otherHash, 3)))
+	require.NoError(t, core.handleMsg(voteMsg(msgPrevote, privateKeys[2], otherHash, 3)))
+	require.Len(t, reported, 1)
+
+	// the prevotes of 2/3 of the validators clear the validator
+	valSet := be.Validators(big.NewInt(1))
+	pol := &ProofOfLock{Round: 3}
+	for _, key := range privateKeys[:3] {
+		msg := voteMsg(msgPrevote, key, otherHash, 3)
+		data, err := rlp.EncodeToBytes(&msg)
+		require.NoError(t, err)
+		pol.Prevotes = append(pol.Prevotes, data)
+	}
+	require.NoError(t, VerifyProofOfLock(tendermint.DefaultConfig, valSet, evidence, pol))
+	require.Equal(t, ErrInvalidProofOfLock, VerifyProofOfLock(tendermint.DefaultConfig, valSet, evidence,
+		&ProofOfLock{Round: 3, Prevotes: pol.Prevotes[:2]}))
+	require.Equal(t, ErrInvalidProofOfLock, VerifyProofOfLock(tendermint.DefaultConfig, valSet, evidence,
+		&ProofOfLock{Round: 0, Prevotes: pol.Prevotes}))
+
+	// the evidence needs a precommit, and a prevote of a later round
+	payload, err := encodeAmnesia(&precommit, &precommit)
+	require.NoError(t, err)
+	_, err = DecodeAmnesia(tendermint.DefaultConfig, payload)
+	require.Error(t, err)
+	sameRound := voteMsg(msgPrevote, privateKeys[1], otherHash, 0)
+	payload, err = encodeAmnesia(&precommit, &sameRound)
+	require.NoError(t, err)
+	_, err = DecodeAmnesia(tendermint.DefaultConfig, payload)
+	require.Equal(t, ErrInvalidAmnesia, err)
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+)
+
+// blockPacerWindow is the number of recent blocks the average latency of the rounds is taken over
+const blockPacerWindow = 20
+
+var commitTimeoutGauge = metrics.NewRegisteredGauge("evr/consensus/tendermint/committimeout", nil)
+
+// blockPacer adjusts the commit timeout so that the average interval of the blocks stays close to
+// config.TargetBlockInterval. The interval between two blocks is the commit timeout waited after the first
+// plus the latency of the rounds of the second, so the commit timeout is the target minus the average latency
+// of the recent blocks. As the difficulty retargeting, a block slower than the target makes the next ones faster.
+type blockPacer struct {
+	latencies []time.Duration // the latencies of the rounds of the recent blocks, oldest first
+
+	lastHeight  *big.Int      // the block committed last, nil before the first one
+	lastCommit  time.Time     // when the block committed last was
+	lastTimeout time.Duration // the commit timeout waited after the block committed last
+}
+
+// commitTimeout returns the commit timeout to wait after the block of height committed at commitTime,
+// base is the one used while no latency is known. A gap in the heights, e.g. after a sync, restarts the average.
+func (p *blockPacer) commitTimeout(target time.Duration, height *big.Int, commitTime time.Time, base time.Duration) time.Duration {
+	if p.lastHeight != nil && new(big.Int).Sub(height, p.lastHeight).Cmp(big.NewInt(1)) == 0 {
+		latency := commitTime.Sub(p.lastCommit) - p.lastTimeout
+		if latency < 0 {
+			latency = 0
+		}
+		p.latencies = append(p.latencies, latency)
+		if len(p.latencies) > blockPacerWindow {
+			p.latencies = p.latencies[1:]
+		}
+	} else {
+		p.latencies = nil
+	}
+	timeout := base
+	if len(p.latencies) > 0 {
+		var total time.Duration
+		for _, latency := range p.latencies {
+			total += latency
+		}
+		timeout = target - total/time.Duration(len(p.latencies))
+		if timeout < 0 {
+			timeout = 0
+		}
+	}
+	p.lastHeight = new(big.Int).Set(height)
+	p.lastCommit = commitTime
+	p.lastTimeout = timeout
+	commitTimeoutGauge.Update(int64(timeout))
+	return timeout
+}
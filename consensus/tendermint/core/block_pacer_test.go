@@ -0,0 +1,46 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockPacer(t *testing.T) {
+	var (
+		pacer  blockPacer
+		target = 2 * time.Second
+		base   = time.Second
+		now    = time.Unix(1000, 0)
+		commit = func(height int64, latency time.Duration) time.Duration {
+			now = now.Add(pacer.lastTimeout + latency)
+			return pacer.commitTimeout(target, big.NewInt(height), now, base)
+		}
+	)
+	// the first block has no latency known
+	require.Equal(t, base, commit(1, 0))
+
+	// the commit timeout fills the target interval after the latency of the rounds
+	require.Equal(t, 1500*time.Millisecond, commit(2, 500*time.Millisecond))
+	require.Equal(t, 1250*time.Millisecond, commit(3, time.Second))
+
+	// a slow block makes the next ones faster, down to no commit timeout
+	require.Equal(t, time.Duration(0), commit(4, 10*time.Second))
+	for height := int64(5); height < 5+blockPacerWindow; height++ {
+		commit(height, 500*time.Millisecond)
+	}
+	require.Equal(t, 1500*time.Millisecond, pacer.lastTimeout)
+
+	// the average interval of the blocks is the target
+	start := now
+	for height := int64(5 + blockPacerWindow); height < 5+2*blockPacerWindow; height++ {
+		commit(height, time.Duration(height%3)*300*time.Millisecond)
+	}
+	interval := now.Sub(start) / blockPacerWindow
+	require.InDelta(t, float64(target), float64(interval), float64(100*time.Millisecond))
+
+	// a gap in the heights restarts the average
+	require.Equal(t, base, commit(100, 0))
+}
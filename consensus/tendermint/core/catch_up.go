@@ -47,6 +47,9 @@ func (c *core) enterCatchup(tiBlock *big.Int, tiRound int64, tiStep RoundStepTyp
 }
 
 func (c *core) sendCatchUpRequest(logger *zap.SugaredLogger, tiBlock *big.Int, tiRound int64, tiStep RoundStepType) {
+	if !c.signs() {
+		return
+	}
 	var (
 		state = c.currentState
 		addr  = c.backend.Address()
@@ -115,3 +118,17 @@ func (c *core) sendCatchUpRequest(logger *zap.SugaredLogger, tiBlock *big.Int, t
 		logger.Debugw("Failed to multicast msg", "err", err.Error())
 	}
 }
+
+// replyPastVotes replies to a catch up request of a peer still at a committed block with the votes kept of the block
+func (c *core) replyPastVotes(logger *zap.SugaredLogger, target common.Address, catchUpMsg *CatchUpRequestMsg) {
+	payloads := c.pastVotes.get(catchUpMsg.BlockNumber, catchUpMsg.Round, catchUpMsg.Step)
+	if len(payloads) == 0 {
+		logger.Debugw("no past votes for catchup request available")
+		return
+	}
+	for len(payloads) > catchUpReplyBatchSize {
+		c.SendCatchupReply(target, catchUpMsg.BlockNumber, payloads[:catchUpReplyBatchSize])
+		payloads = payloads[catchUpReplyBatchSize:]
+	}
+	c.SendCatchupReply(target, catchUpMsg.BlockNumber, payloads)
+}
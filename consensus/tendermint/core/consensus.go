@@ -11,7 +11,6 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
-	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/metrics"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
@@ -66,7 +65,7 @@ func (c *core) enterNewRound(blockNumber *big.Int, round int64) {
 
 	// handle future proposal if not nil
 	if _, ok := c.futureProposals[round]; ok {
-		if err := c.handleMsgLocked(c.futureProposals[round]); err != nil {
+		if err := c.handleVerifiedMsgLocked(c.futureProposals[round]); err != nil {
 			c.getLogger().Errorw("failed to handle msg from next round", "err", err)
 		}
 	}
@@ -76,7 +75,7 @@ func (c *core) getDefaultProposal(logger *zap.SugaredLogger, round int64) *Propo
 	proposal := c.defaultDecideProposal(logger, round)
 
 	if err := c.checkAndFakeProposal(proposal); err != nil {
-		log.Error("fail to fake proposal block", "err", err)
+		logger.Errorw("fail to fake proposal block", "err", err)
 	}
 	return proposal
 }
@@ -100,6 +99,10 @@ func (c *core) defaultDecideProposal(logger *zap.SugaredLogger, round int64) *Pr
 	if (state.Block() == nil) || (state.Block() != nil && state.Block().Hash().Hex() == emptyBlockHash.Hex()) {
 		return nil
 	}
+	// the initial state holds an empty block until the miner sends one for the current block number
+	if state.Block().Number().Cmp(state.BlockNumber()) != 0 {
+		return nil
+	}
 	//TODO: remove this
 	//get the block node currently received from miner
 
@@ -173,6 +176,8 @@ func (c *core) enterPropose(blockNumber *big.Int, round int64) {
 		proposal := c.getDefaultProposal(logger, round)
 		if proposal != nil {
 			c.SendPropose(proposal)
+		} else {
+			c.startHeartbeats(timeOutBlock, round)
 		}
 	}
 }
@@ -525,7 +530,7 @@ func (c *core) finalizeCommit(blockNumber *big.Int) {
 		logger.Panicw("block committing failed", "error", err)
 	}
 
-	c.backend.Commit(block)
+	c.backend.Commit(block, state.commitRound)
 }
 
 //FinalizeBlock will fill extradata with signature and return the ready to store block
@@ -533,10 +538,11 @@ func (c *core) FinalizeBlock(proposal *Proposal) (*types.Block, error) {
 	var (
 		state           = c.currentState
 		round           = state.commitRound
-		totalPrecommits = 0
+		totalPrecommits = 0 // the voting power of the seals
 		commitSeals     = [][]byte{}
+		committed       = make(map[int]bool)
 		header          = proposal.Block.Header()
-		minMajority     = c.valSet.MinMajority()
+		minMajority     = c.valSet.QuorumPower()
 	)
 	precommits, ok := state.GetPrecommitsByRound(round)
 	if !ok {
@@ -551,16 +557,15 @@ func (c *core) FinalizeBlock(proposal *Proposal) (*types.Block, error) {
 		return nil, fmt.Errorf("not enough precommits received expect at least %d received %d", minMajority, totalPrecommits)
 	}
 
-	for _, vote := range votes.votes {
+	// every precommit received for the block is sealed, not only a quorum of them, so that the missing validators
+	// are the ones whose precommit had not been received when the block was committed
+	for i, vote := range votes.votes {
 		if vote == nil {
 			continue
 		}
 		commitSeals = append(commitSeals, vote.Seal)
-		totalPrecommits++
-		//TODO: is it fair to always take the first 2F+1 seals?
-		if totalPrecommits >= minMajority {
-			break
-		}
+		committed[i] = true
+		totalPrecommits += c.valSet.VotingPower(c.valSet.GetByIndex(int64(i)).Address())
 	}
 
 	if totalPrecommits < minMajority {
@@ -570,6 +575,14 @@ func (c *core) FinalizeBlock(proposal *Proposal) (*types.Block, error) {
 	if err := utils.WriteCommittedSeals(header, commitSeals); err != nil {
 		return nil, err
 	}
+	//record validators whose precommits are not in the committed seals
+	if err := utils.WriteMissingValidators(header, utils.NewMissingValidators(len(votes.votes), committed)); err != nil {
+		return nil, err
+	}
+	//record the round the block is committed in
+	if err := utils.WriteCommitRound(header, round); err != nil {
+		return nil, err
+	}
 	return proposal.Block.WithSeal(header), nil
 }
 
@@ -690,7 +703,8 @@ func (c *core) processFutureMessages(logger *zap.SugaredLogger) (done bool, err
 		if _, err := c.futureMessages.Get(1); err != nil {
 			logger.Warn("failed to remove from future msgs", "err", err)
 		}
-		if err := c.handleMsgLocked(msg); err != nil {
+		// messages are queued after their signer is verified
+		if err := c.handleVerifiedMsgLocked(msg); err != nil {
 			logger.Warn("failed to handle msg", "err", err)
 		}
 	}
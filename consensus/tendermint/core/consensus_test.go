@@ -126,6 +126,7 @@ func TestFinalizeBlock(t *testing.T) {
 			require.NotEqual(t, bl1.Hash().Hex(), bl2.Hash().Hex(), "Block hash of 2 blocks must be different")
 
 			var block2ExpectCommittedSeals [][]byte //It stores what commit seals were appended to block 2
+			block2ExpectCommitted := make(map[int]bool)
 			//Add vote from node 1,2,3,4
 			for i := 0; i < len(validators); i++ {
 				msg := message{
@@ -156,10 +157,9 @@ func TestFinalizeBlock(t *testing.T) {
 					require.NoError(t, err)
 					assert.True(t, ok)
 
-					//Add committed seals will be added to block 2 to compare after finalizing
-					if len(block2ExpectCommittedSeals) < core.valSet.MinMajority() {
-						block2ExpectCommittedSeals = append(block2ExpectCommittedSeals, vote.Seal)
-					}
+					//Add committed seals will be added to block 2 to compare after finalizing, all the precommits are sealed
+					block2ExpectCommittedSeals = append(block2ExpectCommittedSeals, vote.Seal)
+					block2ExpectCommitted[i] = true
 				default:
 					fmt.Println("Not support this case")
 				}
@@ -181,7 +181,8 @@ func TestFinalizeBlock(t *testing.T) {
 			if err == nil {
 				//Check committed seals in header extra block after finalizing
 				expectExtra, err := rlp.EncodeToBytes(&types.TendermintExtra{
-					CommittedSeal: block2ExpectCommittedSeals,
+					CommittedSeal:     block2ExpectCommittedSeals,
+					MissingValidators: utils.NewMissingValidators(len(validators), block2ExpectCommitted),
 				})
 				require.Nil(t, err)
 				expectCommittedSeals := append(bytes.Repeat([]byte{0x00}, types.TendermintExtraVanity), expectExtra...)
@@ -10,6 +10,7 @@ import (
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tracing"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/event"
@@ -29,17 +30,27 @@ func WithoutRebroadcast() Option {
 // New creates an Tendermint consensus core
 func New(backend tendermint.Backend, config *tendermint.Config, opts ...Option) Engine {
 	c := &core{
-		handlerWg:       new(sync.WaitGroup),
-		backend:         backend,
-		timeout:         NewTimeoutTicker(),
-		config:          config,
-		mu:              &sync.RWMutex{},
-		blockFinalize:   new(event.TypeMux),
-		futureMessages:  queue.NewPriorityQueue(0, true),
-		futureProposals: make(map[int64]message),
-		sentMsgStorage:  NewMsgStorage(),
-		rebroadcast:     true,
+		handlerWg:        new(sync.WaitGroup),
+		backend:          backend,
+		timeout:          NewTimeoutTicker(),
+		config:           config,
+		mu:               &sync.RWMutex{},
+		blockFinalize:    new(event.TypeMux),
+		futureMessages:   queue.NewPriorityQueue(0, true),
+		futureProposals:  make(map[int64]message),
+		proposalMsgs:     make(map[int64]message),
+		reportedAmnesias: make(map[common.Address]bool),
+		rejectedHeaders:  make(map[common.Hash]*types.Header),
+		pastVotes:        newPastVotes(config.VoteRetentionHeights),
+		sentMsgStorage:   NewMsgStorage(),
+		rebroadcast:      true,
+		hooks:            newHooks(),
 	}
+	if config.StartPaused {
+		c.paused = 1
+	}
+	c.window = newMsgWindow(config)
+	c.hooks.addStepHook(c.window.onStep)
 	for _, opt := range opts {
 		if err := opt(c); err != nil {
 			panic(err)
@@ -58,6 +69,12 @@ type core struct {
 	//- NewBlockEvent: when there is a new composed block from Tx_pool
 	//- MessageEvent: when there is a new message from other validators/ peers
 	events *event.TypeMuxSubscription
+	//messages is the channel to receive MessageEvent, the messages are decoded and verified by verifyMessages
+	//then passed to handleEvents through verifiedMsgs
+	messages     *event.TypeMuxSubscription
+	verifiedMsgs chan message
+	//quit stops verifyMessages and the proposer heartbeats when core stops
+	quit chan struct{}
 
 	// finalCommitted events is the chanel to raise when committed and run to new round
 	finalCommitted *event.TypeMuxSubscription
@@ -95,7 +112,41 @@ type core struct {
 	// In case: the current node is still at precommit but another node jumps to next round and sends the proposal
 	futureProposals map[int64]message
 
+	// proposalMsgs stores the first proposal of each round of the current block number signed by its proposer,
+	// to detect the proposers signing several blocks for a round, see checkDuplicateProposal
+	proposalMsgs map[int64]message
+	// onDuplicateProposal is called with the evidence of a proposer signing several blocks for a round, it may be nil
+	onDuplicateProposal DuplicateProposalHandler
+	// reportedAmnesias are the validators reported for amnesia at the current block number, see checkAmnesia
+	reportedAmnesias map[common.Address]bool
+	// onAmnesia is called with the evidence of a validator prevoting contrary to its lock, it may be nil
+	onAmnesia AmnesiaHandler
+	// rejectedHeaders are the headers of the proposals of the current block number which failed the header
+	// verification, by block hash, see checkLunatic
+	rejectedHeaders map[common.Hash]*types.Header
+	// onLunatic is called with the evidence of a validator precommitting a block whose header was rejected, it may be nil
+	onLunatic LunaticHandler
+	// pastVotes are the votes of the committed blocks served to the peers still at these blocks
+	pastVotes *pastVotes
+
 	rebroadcast bool
+
+	// hooks are called on every round/step transition and lock/unlock, see AddStepHook and AddLockHook
+	hooks *hooks
+	// window drops the messages too far behind or ahead of the current view before verifyMessages recovers their signer
+	window *msgWindow
+
+	// spans records the spans of the consensus if a tracer is set, see WithTracer
+	spans *consensusSpans
+
+	// recorder writes the events handled by core for Replay, nil if they are not recorded
+	recorder *traceRecorder
+
+	// paused is 1 while core doesn't sign, see SetPaused
+	paused int32
+
+	// pacer adjusts the commit timeout to the config.TargetBlockInterval
+	pacer blockPacer
 }
 
 // Start implements core.Engine.Start
@@ -115,7 +166,12 @@ func (c *core) Start() error {
 	if err := c.timeout.Start(); err != nil {
 		return err
 	}
+	c.record(TraceEvent{Start: c.CurrentState().BlockNumber()})
+	c.verifiedMsgs = make(chan message, verifiedMsgsBuffer)
+	c.quit = make(chan struct{})
 	c.startNewRound()
+	c.handlerWg.Add(1)
+	go c.verifyMessages(c.messages, c.quit)
 	go c.handleEvents()
 
 	return nil
@@ -124,23 +180,35 @@ func (c *core) Start() error {
 // Stop implements core.Engine.Stop
 // Note: this function is not thread-safe
 func (c *core) Stop() error {
-	c.getLogger().Infow("stopping Tendermint's timeout core...")
+	// the round state is owned by the handler goroutine until it stops, so the state isn't logged here
+	logger := tendermint.Logger(tendermint.LogCore)
+	logger.Infow("stopping Tendermint's timeout core...")
 	err := c.timeout.Stop()
+	close(c.quit)
 	c.unsubscribeEvents()
 	c.handlerWg.Wait()
-	c.getLogger().Infow("Tendermint's timeout core stopped")
+	logger.Infow("Tendermint's timeout core stopped")
 	return err
 }
 
 //FinalizeMsg set address, signature and encode msg to bytes
 func (c *core) FinalizeMsg(msg *message) ([]byte, error) {
+	if c.config.Observer {
+		return nil, ErrObserverMode
+	}
+	if c.Paused() {
+		return nil, ErrPaused
+	}
+	span := c.startSpan("sign", tracing.String("msg", msgName(msg.Code)))
+	defer span.End()
 	msg.Address = c.backend.Address()
-	msgPayLoadWithoutSignature, err := msg.PayLoadWithoutSignature()
+	msgPayLoadWithoutSignature, err := msg.SigningPayload(c.config)
 	if err != nil {
 		return nil, err
 	}
 	signature, err := c.backend.Sign(msgPayLoadWithoutSignature)
 	if err != nil {
+		span.SetError(err)
 		return nil, err
 	}
 	msg.Signature = signature
@@ -150,8 +218,15 @@ func (c *core) FinalizeMsg(msg *message) ([]byte, error) {
 //SendPropose will Finalize the Proposal in term of signature and
 //Gossip it to other nodes
 func (c *core) SendPropose(propose *Proposal) {
+	// an observer or a paused core follows the consensus without proposing nor voting
+	if !c.signs() {
+		return
+	}
+	if propose.TraceID == "" {
+		propose.TraceID = newTraceID()
+	}
 	logger := c.getLogger().With("propose_round", propose.Round,
-		"propose_block_number", propose.Block.Number(), "propose_block_hash", propose.Block.Hash())
+		"propose_block_number", propose.Block.Number(), "propose_block_hash", propose.Block.Hash(), "trace_id", propose.TraceID)
 
 	msgData, err := rlp.EncodeToBytes(propose)
 	if err != nil {
@@ -159,8 +234,9 @@ func (c *core) SendPropose(propose *Proposal) {
 		return
 	}
 	payload, err := c.FinalizeMsg(&message{
-		Code: msgPropose,
-		Msg:  msgData,
+		Code:    msgPropose,
+		Msg:     msgData,
+		TraceID: propose.TraceID,
 	})
 	if err != nil {
 		logger.Errorw("Failed to Finalize Proposal", "error", err)
@@ -170,7 +246,11 @@ func (c *core) SendPropose(propose *Proposal) {
 	// store before send propose msg
 	c.sentMsgStorage.storeSentMsg(c.getLogger(), RoundStepPropose, propose.Round, payload)
 
-	if err := c.backend.Broadcast(c.valSet, c.currentState.CopyBlockNumber(), propose.Round, msgPropose, payload); err != nil {
+	span := c.startSpan("broadcast", tracing.String("msg", msgName(msgPropose)), tracing.Int64("round", propose.Round))
+	err = c.backend.Broadcast(c.valSet, c.currentState.CopyBlockNumber(), propose.Round, msgPropose, payload)
+	span.SetError(err)
+	span.End()
+	if err != nil {
 		c.getLogger().Errorw("Failed to Broadcast proposal", "error", err)
 		return
 	}
@@ -186,7 +266,11 @@ func (c *core) SetBlockForProposal(b *types.Block) {
 //SendVote send broadcast its vote to the network
 //it only accept 2 voteType: msgPrevote and msgcommit
 func (c *core) SendVote(voteType uint64, block *types.Block, round int64) {
-	logger := c.getLogger().With("send_vote_type", voteType, "send_vote_round", round)
+	if !c.signs() {
+		return
+	}
+	traceID := c.currentTraceID(round)
+	logger := c.getLogger().With("send_vote_type", voteType, "send_vote_round", round, "trace_id", traceID)
 	if voteType != msgPrevote && voteType != msgPrecommit {
 		logger.Errorw("vote type is invalid")
 		return
@@ -197,8 +281,11 @@ func (c *core) SendVote(voteType uint64, block *types.Block, round int64) {
 	)
 	if block != nil {
 		var err error
-		commitHash := utils.PrepareCommittedSeal(block.Header().Hash())
+		commitHash := utils.PrepareCommittedSealAt(c.config, block.Number(), block.Header().Hash())
+		span := c.startSpan("sign", tracing.String("msg", "seal"))
 		seal, err = c.backend.Sign(commitHash)
+		span.SetError(err)
+		span.End()
 		if err != nil {
 			logger.Errorw("failed to sign seal", err, "err")
 			return
@@ -210,6 +297,7 @@ func (c *core) SendVote(voteType uint64, block *types.Block, round int64) {
 		Round:       round,
 		BlockNumber: c.CurrentState().BlockNumber(),
 		Seal:        seal,
+		TraceID:     traceID,
 	}
 	msgData, err := rlp.EncodeToBytes(vote)
 	if err != nil {
@@ -217,8 +305,9 @@ func (c *core) SendVote(voteType uint64, block *types.Block, round int64) {
 		return
 	}
 	payload, err := c.FinalizeMsg(&message{
-		Code: voteType,
-		Msg:  msgData,
+		Code:    voteType,
+		Msg:     msgData,
+		TraceID: traceID,
 	})
 	if err != nil {
 		logger.Errorw("Failed to Finalize Vote", "error", err)
@@ -234,7 +323,11 @@ func (c *core) SendVote(voteType uint64, block *types.Block, round int64) {
 	default:
 	}
 
-	if err := c.backend.Broadcast(c.valSet, c.currentState.CopyBlockNumber(), round, voteType, payload); err != nil {
+	span := c.startSpan("broadcast", tracing.String("msg", msgName(voteType)), tracing.Int64("round", round))
+	err = c.backend.Broadcast(c.valSet, c.currentState.CopyBlockNumber(), round, voteType, payload)
+	span.SetError(err)
+	span.End()
+	if err != nil {
 		logger.Errorw("Failed to Broadcast vote", "error", err)
 		return
 	}
@@ -242,10 +335,13 @@ func (c *core) SendVote(voteType uint64, block *types.Block, round int64) {
 }
 
 // SendCatchupReply sends catchup reply to target node
-func (c *core) SendCatchupReply(target common.Address, payloads [][]byte) {
+func (c *core) SendCatchupReply(target common.Address, blockNumber *big.Int, payloads [][]byte) {
+	if !c.signs() {
+		return
+	}
 	logger := c.getLogger().With("num_msg", len(payloads), "target", target.Hex())
 	catchUpReplyMsg := &CatchUpReplyMsg{
-		BlockNumber: new(big.Int).Set(c.CurrentState().BlockNumber()),
+		BlockNumber: new(big.Int).Set(blockNumber),
 		Payloads:    payloads,
 	}
 	msgData, err := rlp.EncodeToBytes(catchUpReplyMsg)
@@ -275,12 +371,9 @@ func (c *core) CurrentState() *roundState {
 // getLogger returns a zap logger with state info
 func (c *core) getLogger() *zap.SugaredLogger {
 	if c.currentState == nil {
-		return zap.S()
+		return tendermint.Logger(tendermint.LogCore)
 	}
-	return zap.L().With(
-		zap.Stringer("block", c.currentState.BlockNumber()),
-		zap.Int64("round", c.currentState.Round()),
-		zap.Stringer("step", c.currentState.Step())).Sugar()
+	return c.currentState.Logger()
 }
 
 // address returns address of current nodes
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// EnvelopeVersion is the version of the envelopes this node wraps its messages in.
+// Version 1 is the first one, the older nodes only receive bare messages.
+const EnvelopeVersion uint64 = 1
+
+// msgType is a kind of consensus message of the registry
+type msgType struct {
+	name string // the name of the messages in the spans
+	// version is the envelope version which added the kind, the peers of a lower version don't receive it.
+	// The kinds of version 0 are also sent bare to the peers which don't decode envelopes.
+	version uint64
+}
+
+// msgTypes is the registry of the kinds of consensus messages this node knows by their code.
+// A new kind takes the next code with the envelope version it is added in, so that the older peers are not
+// sent messages they can't handle and the peers which don't know it drop it without penalizing the sender.
+var msgTypes = map[uint64]msgType{
+	msgPropose:           {name: "propose"},
+	msgPrevote:           {name: "prevote"},
+	msgPrecommit:         {name: "precommit"},
+	msgCatchUpRequest:    {name: "catch_up_request"},
+	msgCatchUpReply:      {name: "catch_up_reply"},
+	msgVoteBatch:         {name: "vote_batch"},
+	msgHeartbeat:         {name: "heartbeat"},
+	msgDuplicateProposal: {name: "duplicate_proposal"},
+	msgAmnesia:           {name: "amnesia"},
+	msgLunatic:           {name: "lunatic"},
+}
+
+// MsgVersion returns the envelope version which added the kind of code, false if this node doesn't know it
+func MsgVersion(code uint64) (uint64, bool) {
+	t, ok := msgTypes[code]
+	return t.version, ok
+}
+
+// Envelope wraps a consensus message with the version of the sender and the code of the message,
+// so that the receiver can tell the kinds it doesn't know from invalid messages before decoding them
+type Envelope struct {
+	Version uint64
+	Code    uint64
+	Payload []byte // the signed message, as it is sent bare
+	// Extra are the fields the later versions append, they are ignored
+	Extra []rlp.RawValue `rlp:"tail"`
+}
+
+// EncodeEnvelope wraps the payload of a message of code in an envelope of EnvelopeVersion
+func EncodeEnvelope(code uint64, payload []byte) ([]byte, error) {
+	return rlp.EncodeToBytes(&Envelope{
+		Version: EnvelopeVersion,
+		Code:    code,
+		Payload: payload,
+	})
+}
+
+// DecodeEnvelope decodes an envelope of any version, the caller checks that it knows its code with MsgVersion
+// before handling its payload
+func DecodeEnvelope(data []byte) (*Envelope, error) {
+	var envelope Envelope
+	if err := rlp.DecodeBytes(data, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// ErrInvalidDuplicateProposal is returned by DecodeDuplicateProposal when the evidence doesn't hold two different
+// proposals of the same block number and round signed by the same proposer
+var ErrInvalidDuplicateProposal = errors.New("evidence doesn't hold two proposals of the same round by the same proposer")
+
+// DuplicateProposalEvidence proves that a proposer signed two different blocks for the same block number and round,
+// it holds the two signed proposal messages. It carries the signatures of the proposer, so any node can relay it.
+type DuplicateProposalEvidence struct {
+	First  []byte
+	Second []byte
+}
+
+// DuplicateProposal is a verified DuplicateProposalEvidence
+type DuplicateProposal struct {
+	Proposer    common.Address
+	BlockNumber *big.Int
+	Round       int64
+	BlockHashes [2]common.Hash
+	Payload     []byte // the evidence message, as it is gossiped
+}
+
+// DuplicateProposalHandler is called when core receives two different proposals of a round from its proposer.
+// It is called synchronously by the state machine so it must return quickly and must not call core.
+type DuplicateProposalHandler func(*DuplicateProposal)
+
+// WithDuplicateProposalHandler returns an option to set the handler of the duplicate proposals core detects
+func WithDuplicateProposalHandler(handler DuplicateProposalHandler) Option {
+	return func(c *core) error {
+		c.onDuplicateProposal = handler
+		return nil
+	}
+}
+
+// IsDuplicateProposal returns true if msgType is the code of duplicate proposal evidences
+func IsDuplicateProposal(msgType uint64) bool {
+	return msgType == msgDuplicateProposal
+}
+
+// encodeDuplicateProposal returns the evidence message of two proposals of the same round
+func encodeDuplicateProposal(first, second message) ([]byte, error) {
+	var evidence DuplicateProposalEvidence
+	var err error
+	if evidence.First, err = rlp.EncodeToBytes(&first); err != nil {
+		return nil, err
+	}
+	if evidence.Second, err = rlp.EncodeToBytes(&second); err != nil {
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes(&evidence)
+	if err != nil {
+		return nil, err
+	}
+	// the evidence is signed by the proposer in its proposals, the message itself is not signed
+	return rlp.EncodeToBytes(&message{
+		Code: msgDuplicateProposal,
+		Msg:  data,
+	})
+}
+
+// DecodeDuplicateProposal verifies an evidence message encoded by core: both proposals are signed by the same
+// address for the same block number and round, with different blocks. Whether the signer is the proposer of
+// the round is left to the caller, who knows the validator set of the block number.
+func DecodeDuplicateProposal(config *tendermint.Config, payload []byte) (*DuplicateProposal, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return nil, err
+	}
+	if msg.Code != msgDuplicateProposal {
+		return nil, errors.Errorf("unexpected msg code %d", msg.Code)
+	}
+	var evidence DuplicateProposalEvidence
+	if err := rlp.DecodeBytes(msg.Msg, &evidence); err != nil {
+		return nil, err
+	}
+	var proposals [2]*Proposal
+	var signers [2]common.Address
+	for i, data := range [][]byte{evidence.First, evidence.Second} {
+		var proposalMsg message
+		if err := rlp.DecodeBytes(data, &proposalMsg); err != nil {
+			return nil, err
+		}
+		if proposalMsg.Code != msgPropose {
+			return nil, ErrInvalidDuplicateProposal
+		}
+		signer, err := proposalMsg.GetAddressFromSignature(config)
+		if err != nil {
+			return nil, err
+		}
+		if signer != proposalMsg.Address {
+			return nil, ErrSignerMessageMissMatch
+		}
+		proposal, err := decodeProposal(proposalMsg)
+		if err != nil {
+			return nil, err
+		}
+		proposals[i], signers[i] = proposal, signer
+	}
+	if signers[0] != signers[1] || proposals[0].Round != proposals[1].Round ||
+		proposals[0].Block.Number().Cmp(proposals[1].Block.Number()) != 0 ||
+		proposals[0].Block.Hash() == proposals[1].Block.Hash() {
+		return nil, ErrInvalidDuplicateProposal
+	}
+	return &DuplicateProposal{
+		Proposer:    signers[0],
+		BlockNumber: proposals[0].Block.Number(),
+		Round:       proposals[0].Round,
+		BlockHashes: [2]common.Hash{proposals[0].Block.Hash(), proposals[1].Block.Hash()},
+		Payload:     payload,
+	}, nil
+}
+
+// checkDuplicateProposal keeps the first proposal of each round of the current block number signed by the
+// proposer of the round, and reports the proposals of another block for the same round to onDuplicateProposal.
+// The rounds before the current one are not checked, their proposals are ignored by handlePropose.
+func (c *core) checkDuplicateProposal(msg message, proposal *Proposal) {
+	state := c.CurrentState()
+	if proposal.Block.Number().Cmp(state.BlockNumber()) != 0 || proposal.Round < state.Round() {
+		return
+	}
+	first, ok := c.proposalMsgs[proposal.Round]
+	if !ok || first.proposal.Block.Number().Cmp(proposal.Block.Number()) != 0 {
+		valSet := c.valSet
+		if proposal.Round > state.Round() {
+			valSet = c.valSet.Copy()
+			valSet.CalcProposer(c.valSet.GetProposer().Address(), proposal.Round-state.Round())
+		}
+		if valSet.GetProposer().Address() == msg.Address {
+			c.proposalMsgs[proposal.Round] = msg
+		}
+		return
+	}
+	if first.Address != msg.Address || first.proposal.Block.Hash() == proposal.Block.Hash() {
+		return
+	}
+	logger := c.getLogger().With("proposer", msg.Address, "proposal_round", proposal.Round)
+	payload, err := encodeDuplicateProposal(first, msg)
+	if err != nil {
+		logger.Errorw("failed to encode the duplicate proposal evidence", "err", err)
+		return
+	}
+	logger.Warnw("the proposer signed two different blocks for the round",
+		"first_block_hash", first.proposal.Block.Hash().Hex(), "second_block_hash", proposal.Block.Hash().Hex())
+	if c.onDuplicateProposal != nil {
+		c.onDuplicateProposal(&DuplicateProposal{
+			Proposer:    msg.Address,
+			BlockNumber: proposal.Block.Number(),
+			Round:       proposal.Round,
+			BlockHashes: [2]common.Hash{first.proposal.Block.Hash(), proposal.Block.Hash()},
+			Payload:     payload,
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestCore_DuplicateProposal(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{nodeAddr}
+		genesisHeader  = tests_utils.MakeGenesisHeader(validators)
+		reported       []*DuplicateProposal
+		proposalMsg    = func(extra byte, round int64) message {
+			header := types.CopyHeader(genesisHeader)
+			header.Number = big.NewInt(1)
+			header.Extra = append(common.CopyBytes(header.Extra), extra)
+			msgData, err := rlp.EncodeToBytes(&Proposal{
+				Block:    types.NewBlock(header, nil, nil, nil),
+				Round:    round,
+				POLRound: -1,
+			})
+			require.NoError(t, err)
+			msg := message{
+				Code:    msgPropose,
+				Msg:     msgData,
+				Address: nodeAddr,
+			}
+			sign(t, &msg, nodePrivateKey)
+			return msg
+		}
+	)
+	be, _ := tests_utils.MustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	core := newTestCore(be, tendermint.DefaultConfig)
+	core.onDuplicateProposal = func(evidence *DuplicateProposal) {
+		reported = append(reported, evidence)
+	}
+	core.currentState = core.getInitializedState()
+	core.valSet = be.Validators(core.CurrentState().BlockNumber())
+
+	// the same proposal received twice, or proposals of different rounds, are not duplicates
+	first, second := proposalMsg(1, 0), proposalMsg(2, 0)
+	_ = core.handleMsg(first)
+	_ = core.handleMsg(first)
+	_ = core.handleMsg(proposalMsg(3, 1))
+	require.Empty(t, reported)
+
+	// another block for the same round is reported with the evidence of both
+	_ = core.handleMsg(second)
+	require.Len(t, reported, 1)
+	evidence, err := DecodeDuplicateProposal(tendermint.DefaultConfig, reported[0].Payload)
+	require.NoError(t, err)
+	require.Equal(t, reported[0], evidence)
+	require.Equal(t, nodeAddr, evidence.Proposer)
+	require.Equal(t, int64(1), evidence.BlockNumber.Int64())
+	require.Equal(t, int64(0), evidence.Round)
+	require.NotEqual(t, evidence.BlockHashes[0], evidence.BlockHashes[1])
+	require.True(t, IsDuplicateProposal(msgCode(t, evidence.Payload)))
+
+	// two copies of the same proposal are not an evidence
+	payload, err := encodeDuplicateProposal(first, first)
+	require.NoError(t, err)
+	_, err = DecodeDuplicateProposal(tendermint.DefaultConfig, payload)
+	require.Equal(t, ErrInvalidDuplicateProposal, err)
+
+	// both proposals must be signed by their proposer
+	forged := second
+	forged.Address = common.HexToAddress("0x1")
+	payload, err = encodeDuplicateProposal(first, forged)
+	require.NoError(t, err)
+	_, err = DecodeDuplicateProposal(tendermint.DefaultConfig, payload)
+	require.Equal(t, ErrSignerMessageMissMatch, err)
+}
+
+func msgCode(t *testing.T, payload []byte) uint64 {
+	var msg message
+	require.NoError(t, rlp.DecodeBytes(payload, &msg))
+	return msg.Code
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
-	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/params"
 )
 
@@ -26,10 +25,10 @@ func (c *core) checkAndFakeProposal(proposal *Proposal) error {
 		fakeHeader := *proposal.Block.Header()
 		switch rand.Intn(2) {
 		case 0:
-			log.Warn("send fake proposal with fake parent hash", "number", proposal.Block.Number())
+			c.getLogger().Warnw("send fake proposal with fake parent hash", "number", proposal.Block.Number())
 			fakeHeader.ParentHash = common.HexToHash(random.Hex(32))
 		case 1:
-			log.Warn("send fake proposal with fake transaction", "number", proposal.Block.Number())
+			c.getLogger().Warnw("send fake proposal with fake transaction", "number", proposal.Block.Number())
 			if err := fakeTxsForProposalBlock(&fakeHeader, proposal); err != nil {
 				return errors.Errorf("fail to fake transactions. Error: %s", err)
 			}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// fuzzRoundTrip checks that a value decoded from arbitrary bytes is re-encoded to a stable form
+func fuzzRoundTrip(t *testing.T, data []byte, newValue func() interface{}) {
+	value := newValue()
+	if err := rlp.DecodeBytes(data, value); err != nil {
+		return
+	}
+	enc, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		t.Fatalf("failed to re-encode decoded value: %v", err)
+	}
+	decoded := newValue()
+	if err := rlp.DecodeBytes(enc, decoded); err != nil {
+		t.Fatalf("failed to decode re-encoded value: %v", err)
+	}
+	reEnc, err := rlp.EncodeToBytes(decoded)
+	if err != nil {
+		t.Fatalf("failed to re-encode value: %v", err)
+	}
+	if !bytes.Equal(enc, reEnc) {
+		t.Fatalf("encoding is not stable: %x != %x", enc, reEnc)
+	}
+}
+
+func fuzzSeedProposal(f *testing.F) []byte {
+	genesis := tests_utils.MakeGenesisHeader([]common.Address{tests_utils.GetAddress()})
+	data, err := rlp.EncodeToBytes(&Proposal{
+		Block:    tests_utils.MakeBlockWithoutSeal(genesis),
+		Round:    1,
+		POLRound: -1,
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+	return data
+}
+
+func fuzzSeedVote(f *testing.F) []byte {
+	hash := common.HexToHash("0x01")
+	data, err := rlp.EncodeToBytes(&Vote{
+		BlockHash:   &hash,
+		BlockNumber: big.NewInt(1),
+		Round:       2,
+		Seal:        []byte{0x01, 0x02},
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+	return data
+}
+
+func FuzzMessageDecode(f *testing.F) {
+	data, err := rlp.EncodeToBytes(&message{
+		Code:      msgPrevote,
+		Msg:       fuzzSeedVote(f),
+		Address:   tests_utils.GetAddress(),
+		Signature: []byte{0x01},
+		TraceID:   newTraceID(),
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add([]byte{0xc0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRoundTrip(t, data, func() interface{} { return new(message) })
+		var msg message
+		if err := rlp.DecodeBytes(data, &msg); err == nil {
+			// recovering the signer of a malformed message must fail gracefully
+			_, _ = msg.GetAddressFromSignature(tests_utils.DefaultTestConfig)
+		}
+	})
+}
+
+func FuzzProposalDecode(f *testing.F) {
+	f.Add(fuzzSeedProposal(f))
+	f.Add([]byte{0xc0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRoundTrip(t, data, func() interface{} { return new(Proposal) })
+	})
+}
+
+func FuzzVoteDecode(f *testing.F) {
+	f.Add(fuzzSeedVote(f))
+	f.Add([]byte{0xc0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRoundTrip(t, data, func() interface{} { return new(Vote) })
+	})
+}
+
+// FuzzHandleMsg feeds arbitrary payloads, correctly signed by another validator, to the handler entry point
+// of a core which is at the first height.
+func FuzzHandleMsg(f *testing.F) {
+	f.Add(uint64(msgPropose), fuzzSeedProposal(f))
+	f.Add(uint64(msgPrevote), fuzzSeedVote(f))
+	f.Add(uint64(msgPrecommit), fuzzSeedVote(f))
+	f.Add(uint64(msgCatchUpRequest), []byte{0xc3, 0x01, 0x00, 0x03})
+	f.Add(uint64(msgCatchUpReply), []byte{0xc0})
+
+	net := newSimNetwork(&testing.T{}, 4, tests_utils.DefaultTestConfig, false)
+	var (
+		node   = net.node(0)
+		sender = net.node(1)
+	)
+	f.Fuzz(func(t *testing.T, code uint64, payload []byte) {
+		c := New(node, tests_utils.DefaultTestConfig, WithoutRebroadcast()).(*core)
+		c.currentState = c.getInitializedState()
+		c.valSet = node.Validators(c.CurrentState().BlockNumber())
+		// the timeout ticker is not running, make sure scheduling never blocks
+		c.timeout = &fuzzTimeoutTicker{}
+
+		msg := message{
+			Code:    code,
+			Msg:     payload,
+			Address: sender.address,
+		}
+		raw, err := msg.PayLoadWithoutSignature()
+		if err != nil {
+			return
+		}
+		if msg.Signature, err = sender.Sign(raw); err != nil {
+			t.Fatal(err)
+		}
+		_ = c.handleMsg(msg)
+
+		net.mu.Lock()
+		net.sent, net.pending = nil, nil
+		net.mu.Unlock()
+	})
+}
+
+// fuzzTimeoutTicker is a TimeoutTicker which drops every scheduled timeout
+type fuzzTimeoutTicker struct{}
+
+func (tt *fuzzTimeoutTicker) Start() error                  { return nil }
+func (tt *fuzzTimeoutTicker) Stop() error                   { return nil }
+func (tt *fuzzTimeoutTicker) Chan() <-chan timeoutInfo      { return nil }
+func (tt *fuzzTimeoutTicker) ScheduleTimeout(_ timeoutInfo) {}
+func (tt *fuzzTimeoutTicker) Pending() *timeoutInfo         { return nil }
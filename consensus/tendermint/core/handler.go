@@ -8,9 +8,9 @@ import (
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tracing"
 	evrynetCore "github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
-	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
@@ -20,6 +20,9 @@ var (
 	ErrVoteHeightMismatch           = errors.New("vote height mismatch")
 	ErrVoteInvalidValidatorAddress  = errors.New("invalid validator address")
 	ErrEmptyBlockProposal           = errors.New("empty block proposal")
+	ErrInvalidRound                 = errors.New("invalid round")
+	ErrEmptyVote                    = errors.New("vote with nil block hash or block number")
+	ErrObserverMode                 = errors.New("an observer does not sign messages")
 	ErrSignerMessageMissMatch       = errors.New("deprived signer and address field of msg are miss-match")
 	ErrCatchUpReplyAddressMissMatch = errors.New("address of catch up reply msg and its child are miss match")
 	emptyBlockHash                  = common.Hash{}
@@ -33,6 +36,9 @@ func (c *core) subscribeEvents() {
 	c.events = c.backend.EventMux().Subscribe(
 		// external events
 		tendermint.NewBlockEvent{},
+	)
+	// messages are verified by verifyMessages before reaching handleEvents
+	c.messages = c.backend.EventMux().Subscribe(
 		tendermint.MessageEvent{},
 	)
 
@@ -44,6 +50,7 @@ func (c *core) subscribeEvents() {
 // Unsubscribe all events
 func (c *core) unsubscribeEvents() {
 	c.events.Unsubscribe()
+	c.messages.Unsubscribe()
 	c.finalCommitted.Unsubscribe()
 }
 
@@ -66,25 +73,25 @@ func (c *core) handleEvents() {
 			// A real event arrived, process interesting content
 			switch ev := event.Data.(type) {
 			case tendermint.NewBlockEvent:
+				c.recordNewBlock(ev.Block)
 				c.handleNewBlock(ev.Block)
-			case tendermint.MessageEvent:
-				//TODO: Handle ev.Payload, if got error then call c.backend.Gossip()
-				var msg message
-				if err := rlp.DecodeBytes(ev.Payload, &msg); err != nil {
-					logger.Errorw("failed to decode msg", "error", err)
-				} else {
-					//log.Info("received message event", "from", msg.Address, "msg_Code", msg.Code)
-					if err := c.handleMsg(msg); err != nil {
-						logger.Errorw("failed to handle msg", "error", err)
-					}
-				}
 			default:
 				c.getLogger().Infow("Unknown event ", "event", ev)
 			}
+		case msg := <-c.verifiedMsgs: //a message from other validators/ peers, its signature is already verified
+			c.recordMsg(msg)
+			if err := c.handleVerifiedMsg(msg); err != nil {
+				logger.Errorw("failed to handle msg", "error", err)
+				if errors.Is(err, ErrVoteInvalidValidatorAddress) || errors.Is(err, ErrInvalidProposalSignature) {
+					// the message is not from a validator of its round
+					c.reportInvalidMsg(msg.from, err)
+				}
+			}
 		case ti, ok := <-c.timeout.Chan(): //something from timeout...
 			if !ok {
 				return
 			}
+			c.record(TraceEvent{Timeout: &ti})
 			c.handleTimeout(ti)
 		case event, ok := <-c.finalCommitted.Chan():
 			if !ok {
@@ -92,6 +99,7 @@ func (c *core) handleEvents() {
 			}
 			switch ev := event.Data.(type) {
 			case tendermint.FinalCommittedEvent:
+				c.record(TraceEvent{FinalCommitted: ev.BlockNumber})
 				_ = c.handleFinalCommitted(ev.BlockNumber)
 			}
 		}
@@ -166,7 +174,7 @@ func (c *core) VerifyProposal(proposal Proposal, msg message) error {
 	}
 
 	// Verify signature
-	signer, err := msg.GetAddressFromSignature()
+	signer, err := msg.GetAddressFromSignature(c.config)
 	if err != nil {
 		return err
 	}
@@ -183,6 +191,7 @@ func (c *core) VerifyProposal(proposal Proposal, msg message) error {
 	// verify the header of proposed block
 	// ignore ErrEmptyCommittedSeals error because we don't have the committed seals yet
 	if err := c.backend.VerifyProposalHeader(proposal.Block.Header()); err != nil && err != tendermint.ErrEmptyCommittedSeals {
+		c.rejectHeader(proposal.Block.Header())
 		return err
 	}
 
@@ -194,20 +203,23 @@ func (c *core) VerifyProposal(proposal Proposal, msg message) error {
 }
 
 func (c *core) handlePropose(msg message) error {
-	var (
-		state    = c.CurrentState()
-		proposal Proposal
-	)
-
-	if err := rlp.DecodeBytes(msg.Msg, &proposal); err != nil {
-		return err
+	state := c.CurrentState()
+	// the verifier decodes and checks the proposals ahead of the state machine, the others are decoded here
+	if msg.proposal == nil {
+		proposal, err := decodeProposal(msg)
+		if err != nil {
+			return err
+		}
+		msg.proposal = proposal
 	}
+	proposal := *msg.proposal
 	logger := c.getLogger().With("proposal_round", proposal.Round, "proposal_block_hash", proposal.Block.Hash().Hex(),
-		"proposal_block_number", proposal.Block.Number().String())
+		"proposal_block_number", proposal.Block.Number().String(), "trace_id", proposal.TraceID)
 	logger.Infow("received a proposal", "from", msg.Address)
 
+	c.checkDuplicateProposal(msg, msg.proposal)
+
 	// Already have one
-	// TODO: possibly catch double proposals
 	if state.ProposalReceived() != nil {
 		return nil
 	}
@@ -254,11 +266,11 @@ func (c *core) handlePropose(msg message) error {
 	state.SetProposalReceived(&proposal)
 	//TODO: Simulate and test the case where core receives proposal at these steps: prevote/ precommit
 	if state.Step() <= RoundStepPropose && state.IsProposalComplete() {
-		log.Info("handle proposal: received proposal, proposal completed. before enterPrevote Jump to enterPrevote")
+		logger.Infow("handle proposal: received proposal, proposal completed. before enterPrevote Jump to enterPrevote")
 		// Move onto the next step
 		c.enterPrevote(state.BlockNumber(), state.Round())
 	} else if state.Step() == RoundStepCommit {
-		log.Info("handle proposal: received proposal, proposal completed. at commit waiting for proposal block. Jump to finalizeCommit")
+		logger.Infow("handle proposal: received proposal, proposal completed. at commit waiting for proposal block. Jump to finalizeCommit")
 
 		// If we're waiting on the proposal block...
 		c.finalizeCommit(proposal.Block.Number())
@@ -274,11 +286,16 @@ func (c *core) handlePrevote(msg message) error {
 	if err := rlp.DecodeBytes(msg.Msg, &vote); err != nil {
 		return err
 	}
+	vote.TraceID = msg.TraceID
 
 	if vote.BlockHash == nil || vote.BlockNumber == nil {
-		c.getLogger().Panic("nil block hash is not allowed. Please make sure that prevote nil send an emptyBlockHash")
+		return ErrEmptyVote
+	}
+	if vote.Round < 0 {
+		return ErrInvalidRound
 	}
-	logger := c.getLogger().With("vote_block", vote.BlockNumber, "from", msg.Address, "vote_round", vote.Round, "block_hash", vote.BlockHash.Hex())
+	logger := c.getLogger().With("vote_block", vote.BlockNumber, "from", msg.Address, "vote_round", vote.Round, "block_hash", vote.BlockHash.Hex(),
+		"trace_id", vote.TraceID)
 
 	if vote.BlockNumber.Cmp(state.BlockNumber()) != 0 {
 		logger.Warnw("vote's block is different with current block")
@@ -301,6 +318,7 @@ func (c *core) handlePrevote(msg message) error {
 	}
 
 	logger.Infow("added prevote vote into roundState")
+	c.checkAmnesia(msg.Address)
 	prevotes, ok := state.GetPrevotesByRound(vote.Round)
 	if !ok {
 		logger.Panic("expect prevotes to exist now")
@@ -361,12 +379,16 @@ func (c *core) handlePrecommit(msg message) error {
 	if err := rlp.DecodeBytes(msg.Msg, &vote); err != nil {
 		return err
 	}
+	vote.TraceID = msg.TraceID
 	if vote.BlockHash == nil || vote.BlockNumber == nil {
-		c.getLogger().Panic("nil block hash is not allowed. Please make sure that prevote nil send an emptyBlockHash")
+		return ErrEmptyVote
+	}
+	if vote.Round < 0 {
+		return ErrInvalidRound
 	}
 
 	logger := c.getLogger().With("vote_block", vote.BlockNumber, "vote_round", vote.Round,
-		"from", msg.Address.Hex(), "block_hash", vote.BlockHash.Hex())
+		"from", msg.Address.Hex(), "block_hash", vote.BlockHash.Hex(), "trace_id", vote.TraceID)
 	if vote.BlockNumber.Cmp(state.BlockNumber()) != 0 {
 		logger.Warnw("vote's block is different with current block")
 		if vote.BlockNumber.Cmp(state.BlockNumber()) > 0 {
@@ -388,6 +410,8 @@ func (c *core) handlePrecommit(msg message) error {
 		return nil
 	}
 	logger.Infow("added precommit vote into roundState")
+	c.checkAmnesia(msg.Address)
+	c.checkLunatic(msg, &vote)
 
 	go c.reBroadcastMsg(msg, logger)
 
@@ -399,7 +423,7 @@ func (c *core) handlePrecommit(msg message) error {
 
 	blockHash, ok := precommits.TwoThirdMajority()
 	if ok {
-		log.Info(" got 2/3 precommits  majority on a block", "block", blockHash)
+		logger.Infow("got 2/3 precommits majority on a block", "block", blockHash)
 		//this will go through the roundstep again to update core's roundState accordingly in case the vote Round is higher than core's Round
 		c.enterNewRound(state.BlockNumber(), vote.Round)
 		c.enterPrecommit(state.BlockNumber(), vote.Round)
@@ -438,6 +462,10 @@ func (c *core) handleCatchupRequest(msg message) error {
 
 	logger := c.getLogger().With("catchup_block", catchUpMsg.BlockNumber, "catchup_round", catchUpMsg.Round,
 		"catchup_step", catchUpMsg.Step, "from", msg.Address.Hex())
+	if catchUpMsg.BlockNumber.Cmp(blockNumber) < 0 {
+		c.replyPastVotes(logger, msg.Address, &catchUpMsg)
+		return nil
+	}
 	if catchUpMsg.BlockNumber.Cmp(blockNumber) != 0 || catchUpMsg.Round > round || (catchUpMsg.Round == round && catchUpMsg.Step > step) {
 		logger.Debugw(" Ignoring timeout because we're behind or different with block")
 		return nil
@@ -459,12 +487,12 @@ func (c *core) handleCatchupRequest(msg message) error {
 		}
 		payloads = append(payloads, data)
 		if len(payloads) >= catchUpReplyBatchSize {
-			c.SendCatchupReply(msg.Address, payloads)
+			c.SendCatchupReply(msg.Address, blockNumber, payloads)
 			payloads = make([][]byte, 0)
 		}
 	}
 	if len(payloads) != 0 {
-		c.SendCatchupReply(msg.Address, payloads)
+		c.SendCatchupReply(msg.Address, blockNumber, payloads)
 	}
 	return nil
 }
@@ -503,18 +531,15 @@ func (c *core) handleCatchUpReply(msg message) error {
 
 // handleMsgLocked assume that c.mu is locked
 func (c *core) handleMsgLocked(msg message) error {
-	logger := c.getLogger()
-	signer, err := msg.GetAddressFromSignature()
-	if err != nil {
-		logger.Debugw("Failed to get signer from msg", "err", err)
+	if err := c.verifyMsg(msg); err != nil {
+		c.getLogger().Debugw("Failed to verify msg", "from", msg.Address, "err", err)
 		return err
 	}
-	if signer != msg.Address {
-		logger.Debugw("Deprived signer and address field of msg are miss-match",
-			"signer", signer, "from", msg.Address)
-		return ErrSignerMessageMissMatch
-	}
+	return c.handleVerifiedMsgLocked(msg)
+}
 
+// handleVerifiedMsgLocked handles a msg which signer is already verified, it assume that c.mu is locked
+func (c *core) handleVerifiedMsgLocked(msg message) error {
 	switch msg.Code {
 	case msgPropose:
 		return c.handlePropose(msg)
@@ -526,15 +551,33 @@ func (c *core) handleMsgLocked(msg message) error {
 		return c.handleCatchupRequest(msg)
 	case msgCatchUpReply:
 		return c.handleCatchUpReply(msg)
+	case msgHeartbeat:
+		return c.handleHeartbeat(msg)
 	default:
 		return fmt.Errorf("unknown msg code %d", msg.Code)
 	}
 }
 
 func (c *core) handleMsg(msg message) error {
+	if err := c.verifyMsg(msg); err != nil {
+		return err
+	}
+	return c.handleVerifiedMsg(msg)
+}
+
+func (c *core) handleVerifiedMsg(msg message) error {
+	span := c.startSpan("handle", tracing.String("msg", msgName(msg.Code)), tracing.String("from", msg.Address.Hex()))
+	defer span.End()
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.handleMsgLocked(msg)
+	err := c.handleVerifiedMsgLocked(msg)
+	span.SetError(err)
+	if view, ok := msgBlockView(msg); ok && err == nil && c.valSet != nil {
+		if i, _ := c.valSet.GetByAddress(msg.Address); i != -1 {
+			c.window.observe(msg.Address, view)
+		}
+	}
+	return err
 }
 
 func (c *core) handleTimeout(ti timeoutInfo) {
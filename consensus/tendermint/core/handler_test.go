@@ -22,15 +22,19 @@ import (
 
 func newTestCore(backend tendermint.Backend, config *tendermint.Config) *core {
 	return &core{
-		handlerWg:      new(sync.WaitGroup),
-		backend:        backend,
-		timeout:        NewTimeoutTicker(),
-		config:         config,
-		mu:             &sync.RWMutex{},
-		blockFinalize:  new(event.TypeMux),
-		futureMessages: queue.NewPriorityQueue(0, true),
-		sentMsgStorage: NewMsgStorage(),
-		rebroadcast:    false,
+		handlerWg:        new(sync.WaitGroup),
+		backend:          backend,
+		timeout:          NewTimeoutTicker(),
+		config:           config,
+		mu:               &sync.RWMutex{},
+		blockFinalize:    new(event.TypeMux),
+		futureMessages:   queue.NewPriorityQueue(0, true),
+		sentMsgStorage:   NewMsgStorage(),
+		proposalMsgs:     make(map[int64]message),
+		reportedAmnesias: make(map[common.Address]bool),
+		rejectedHeaders:  make(map[common.Hash]*types.Header),
+		pastVotes:        newPastVotes(config.VoteRetentionHeights),
+		rebroadcast:      false,
 	}
 }
 
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// ErrHeartbeatNotFromProposer is returned when a heartbeat of the current round is not signed by its proposer
+var ErrHeartbeatNotFromProposer = errors.New("heartbeat is not from the proposer of the round")
+
+// startHeartbeats sends a heartbeat to the validators every HeartbeatInterval while core is the proposer in the
+// propose step of blockNumber and round without a block to propose yet. The propose timeout of the proposer is
+// extended as the validators' are. It does nothing if the heartbeats are disabled, core doesn't sign or is not started,
+// e.g. in a replay.
+func (c *core) startHeartbeats(blockNumber *big.Int, round int64) {
+	if c.config.HeartbeatInterval <= 0 || !c.signs() || c.quit == nil {
+		return
+	}
+	if c.config.HeartbeatExtension > 0 {
+		c.extendProposeTimeout(blockNumber, round)
+	}
+	go c.sendHeartbeats(new(big.Int).Set(blockNumber), round, c.quit)
+}
+
+func (c *core) sendHeartbeats(blockNumber *big.Int, round int64, quit <-chan struct{}) {
+	ticker := time.NewTicker(c.config.HeartbeatInterval)
+	defer ticker.Stop()
+	for sequence := uint64(0); ; sequence++ {
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+		targets, waiting := c.heartbeatTargets(blockNumber, round)
+		if !waiting {
+			return
+		}
+		if err := c.sendHeartbeat(blockNumber, round, sequence, targets); err != nil {
+			// the round state is owned by handleEvents, so the state isn't logged here
+			tendermint.Logger(tendermint.LogCore).Debugw("failed to send heartbeat", "block_number", blockNumber, "round", round, "err", err)
+		}
+	}
+}
+
+// heartbeatTargets returns the validators to send a heartbeat to, and false if core is no longer
+// waiting for a block to propose at blockNumber and round
+func (c *core) heartbeatTargets(blockNumber *big.Int, round int64) (map[common.Address]bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	state := c.CurrentState()
+	if state.BlockNumber().Cmp(blockNumber) != 0 || state.Round() != round || state.Step() != RoundStepPropose {
+		return nil, false
+	}
+	if c.defaultDecideProposal(tendermint.Logger(tendermint.LogCore), round) != nil {
+		// the proposal is sent
+		return nil, false
+	}
+	self := c.backend.Address()
+	targets := make(map[common.Address]bool)
+	for _, val := range c.valSet.List() {
+		if val.Address() != self {
+			targets[val.Address()] = true
+		}
+	}
+	return targets, true
+}
+
+func (c *core) sendHeartbeat(blockNumber *big.Int, round int64, sequence uint64, targets map[common.Address]bool) error {
+	msgData, err := rlp.EncodeToBytes(&Heartbeat{
+		BlockNumber: blockNumber,
+		Round:       round,
+		Sequence:    sequence,
+	})
+	if err != nil {
+		return err
+	}
+	payload, err := c.FinalizeMsg(&message{
+		Code: msgHeartbeat,
+		Msg:  msgData,
+	})
+	if err != nil {
+		return err
+	}
+	return c.backend.Multicast(targets, payload)
+}
+
+// handleHeartbeat extends the propose timeout of the current round by HeartbeatExtension when its proposer
+// is still assembling the block. The timeout is extended once per round, so a proposer can't stall the round.
+func (c *core) handleHeartbeat(msg message) error {
+	var heartbeat Heartbeat
+	if err := rlp.DecodeBytes(msg.Msg, &heartbeat); err != nil {
+		return err
+	}
+	state := c.CurrentState()
+	if heartbeat.BlockNumber == nil || heartbeat.BlockNumber.Cmp(state.BlockNumber()) != 0 || heartbeat.Round != state.Round() {
+		// the heartbeats of other rounds have no effect
+		return nil
+	}
+	if c.valSet.GetProposer().Address() != msg.Address {
+		return ErrHeartbeatNotFromProposer
+	}
+	if c.config.HeartbeatExtension <= 0 || state.Step() != RoundStepPropose || state.ProposalReceived() != nil {
+		return nil
+	}
+	pending := c.timeout.Pending()
+	if pending == nil || pending.BlockNumber.Cmp(heartbeat.BlockNumber) != 0 || pending.Round != heartbeat.Round ||
+		pending.Step != RoundStepPropose || pending.Retry != 0 {
+		return nil
+	}
+	c.extendProposeTimeout(heartbeat.BlockNumber, heartbeat.Round)
+	return nil
+}
+
+// extendProposeTimeout replaces the propose timeout of round by one HeartbeatExtension later
+func (c *core) extendProposeTimeout(blockNumber *big.Int, round int64) {
+	deadline := c.proposeStart.Add(c.config.ProposeTimeout(round))
+	// a retry of the propose timeout replaces the pending one, see timeoutInfo.earlierOrEqual
+	c.timeout.ScheduleTimeout(timeoutInfo{
+		Duration:    time.Until(deadline) + c.config.HeartbeatExtension,
+		BlockNumber: new(big.Int).Set(blockNumber),
+		Round:       round,
+		Step:        RoundStepPropose,
+		Retry:       1,
+	})
+	c.getLogger().Infow("extended the propose timeout while the proposer assembles its block", "round", round, "extension", c.config.HeartbeatExtension)
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// StepTransition is passed to the step hooks when the state machine enters a new round or step
+type StepTransition struct {
+	BlockNumber *big.Int
+	Round       int64
+	Step        RoundStepType
+	PrevStep    RoundStepType // the step before the transition
+}
+
+// LockTransition is passed to the lock hooks when the node locks on a block or unlocks
+type LockTransition struct {
+	BlockNumber *big.Int
+	Round       int64       // the round of the state machine when the lock changed
+	Locked      bool        // false if the node unlocked
+	LockedRound int64       // -1 if the node unlocked
+	LockedBlock common.Hash // empty if the node unlocked
+}
+
+// StepHook is called on every round/step transition
+type StepHook func(StepTransition)
+
+// LockHook is called on every lock/unlock of a block
+type LockHook func(LockTransition)
+
+// WithStepHook returns an option to register a step hook before core starts
+func WithStepHook(hook StepHook) Option {
+	return func(c *core) error {
+		c.hooks.addStepHook(hook)
+		return nil
+	}
+}
+
+// WithLockHook returns an option to register a lock hook before core starts
+func WithLockHook(hook LockHook) Option {
+	return func(c *core) error {
+		c.hooks.addLockHook(hook)
+		return nil
+	}
+}
+
+// AddStepHook registers a hook called on every round/step transition and returns a function to remove it.
+// Hooks are called synchronously by the state machine so they must return quickly and must not call core.
+func (c *core) AddStepHook(hook StepHook) func() {
+	return c.hooks.addStepHook(hook)
+}
+
+// AddLockHook registers a hook called on every lock/unlock and returns a function to remove it.
+// Hooks are called synchronously by the state machine so they must return quickly and must not call core.
+func (c *core) AddLockHook(hook LockHook) func() {
+	return c.hooks.addLockHook(hook)
+}
+
+// hooks stores the registered hooks, it is shared by core and its roundState
+type hooks struct {
+	mu        sync.RWMutex
+	nextID    uint64
+	stepHooks map[uint64]StepHook
+	lockHooks map[uint64]LockHook
+}
+
+func newHooks() *hooks {
+	return &hooks{
+		stepHooks: make(map[uint64]StepHook),
+		lockHooks: make(map[uint64]LockHook),
+	}
+}
+
+func (h *hooks) addStepHook(hook StepHook) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := h.nextID
+	h.nextID++
+	h.stepHooks[id] = hook
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.stepHooks, id)
+	}
+}
+
+func (h *hooks) addLockHook(hook LockHook) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := h.nextID
+	h.nextID++
+	h.lockHooks[id] = hook
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.lockHooks, id)
+	}
+}
+
+func (h *hooks) onStep(blockNumber *big.Int, round int64, step, prevStep RoundStepType) {
+	if h == nil {
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, hook := range h.stepHooks {
+		hook(StepTransition{
+			BlockNumber: new(big.Int).Set(blockNumber),
+			Round:       round,
+			Step:        step,
+			PrevStep:    prevStep,
+		})
+	}
+}
+
+func (h *hooks) onLock(blockNumber *big.Int, round int64, lockedRound int64, lockedBlock *types.Block) {
+	if h == nil {
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, hook := range h.lockHooks {
+		transition := LockTransition{
+			BlockNumber: new(big.Int).Set(blockNumber),
+			Round:       round,
+			LockedRound: lockedRound,
+		}
+		if lockedBlock != nil {
+			transition.Locked = true
+			transition.LockedBlock = lockedBlock.Hash()
+		}
+		hook(transition)
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+)
+
+func TestHooks_StepAndLockTransitions(t *testing.T) {
+	net := newSimNetwork(t, 4, tests_utils.DefaultTestConfig, true)
+	var (
+		mu    sync.Mutex
+		steps []StepTransition
+		locks []LockTransition
+	)
+	node := net.node(0)
+	node.core.AddStepHook(func(transition StepTransition) {
+		mu.Lock()
+		defer mu.Unlock()
+		steps = append(steps, transition)
+	})
+	removeLockHook := node.core.AddLockHook(func(transition LockTransition) {
+		mu.Lock()
+		defer mu.Unlock()
+		locks = append(locks, transition)
+	})
+	net.start()
+	defer net.stop()
+	net.waitForHeight(1, 10*time.Second, node)
+
+	mu.Lock()
+	var committed bool
+	for _, transition := range steps {
+		if transition.BlockNumber.Uint64() == 1 && transition.Step == RoundStepCommit {
+			committed = true
+		}
+		require.NotEqual(t, transition.PrevStep, transition.Step)
+	}
+	require.True(t, committed, "expect a transition to commit at height 1")
+
+	// the node locks on the block it precommits at height 1
+	require.NotEmpty(t, locks)
+	require.True(t, locks[0].Locked)
+	require.Equal(t, uint64(1), locks[0].BlockNumber.Uint64())
+	require.Equal(t, node.blockAt(1).Hash(), locks[0].LockedBlock)
+	mu.Unlock()
+
+	removeLockHook()
+	require.Empty(t, node.core.hooks.lockHooks)
+}
@@ -7,4 +7,16 @@ package core
 type Engine interface {
 	Start() error
 	Stop() error
+	// Snapshot returns a copy of the current consensus state for debugging
+	Snapshot() *StateSnapshot
+	// Status returns a summary of the current consensus state, cheap enough for health checks
+	Status() *Status
+	// AddStepHook registers a hook called on every round/step transition and returns a function to remove it
+	AddStepHook(hook StepHook) func()
+	// AddLockHook registers a hook called on every lock/unlock and returns a function to remove it
+	AddLockHook(hook LockHook) func()
+	// SetPaused pauses or resumes the proposing and voting of core, it returns false if the state is unchanged
+	SetPaused(paused bool) bool
+	// Paused returns whether core is paused
+	Paused() bool
 }
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// ErrInvalidLunatic is returned by DecodeLunatic when the header of the evidence is not the block precommitted
+var ErrInvalidLunatic = errors.New("evidence header is not the block precommitted")
+
+// LunaticEvidence proves that a validator precommitted an invalid block, it holds the signed precommit and the
+// header of the block. Whether the header fails the validity checks is verified against the parent block,
+// so any node having the chain up to the parent can verify it.
+type LunaticEvidence struct {
+	Precommit []byte
+	Header    []byte
+}
+
+// Lunatic is a decoded LunaticEvidence, whose precommit is verified to be for its header
+type Lunatic struct {
+	Validator   common.Address
+	BlockNumber *big.Int
+	Round       int64
+	Header      *types.Header
+	Payload     []byte // the evidence message, as it is gossiped
+}
+
+// LunaticHandler is called when core receives a precommit for a block whose header it rejected.
+// It is called synchronously by the state machine so it must return quickly and must not call core.
+type LunaticHandler func(*Lunatic)
+
+// WithLunaticHandler returns an option to set the handler of the lunatic evidences core detects
+func WithLunaticHandler(handler LunaticHandler) Option {
+	return func(c *core) error {
+		c.onLunatic = handler
+		return nil
+	}
+}
+
+// IsLunatic returns true if msgType is the code of lunatic evidences
+func IsLunatic(msgType uint64) bool {
+	return msgType == msgLunatic
+}
+
+// encodeLunatic returns the evidence message of a precommit and the header of its block
+func encodeLunatic(precommit *message, header *types.Header) ([]byte, error) {
+	var evidence LunaticEvidence
+	var err error
+	if evidence.Precommit, err = rlp.EncodeToBytes(precommit); err != nil {
+		return nil, err
+	}
+	if evidence.Header, err = rlp.EncodeToBytes(header); err != nil {
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes(&evidence)
+	if err != nil {
+		return nil, err
+	}
+	// the evidence is signed by the validator in its precommit, the message itself is not signed
+	return rlp.EncodeToBytes(&message{
+		Code: msgLunatic,
+		Msg:  data,
+	})
+}
+
+// DecodeLunatic verifies an evidence message encoded by core: the precommit is signed by its address and is for
+// the block of the header. Whether the address is a validator of the block number, and whether the header fails
+// the validity checks, is left to the caller who knows the chain.
+func DecodeLunatic(config *tendermint.Config, payload []byte) (*Lunatic, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return nil, err
+	}
+	if msg.Code != msgLunatic {
+		return nil, errors.Errorf("unexpected msg code %d", msg.Code)
+	}
+	var evidence LunaticEvidence
+	if err := rlp.DecodeBytes(msg.Msg, &evidence); err != nil {
+		return nil, err
+	}
+	precommitMsg, precommit, err := decodeSignedVote(config, evidence.Precommit, msgPrecommit)
+	if err != nil {
+		return nil, err
+	}
+	var header types.Header
+	if err := rlp.DecodeBytes(evidence.Header, &header); err != nil {
+		return nil, err
+	}
+	if header.Number == nil || header.Number.Cmp(precommit.BlockNumber) != 0 || header.Hash() != *precommit.BlockHash {
+		return nil, ErrInvalidLunatic
+	}
+	return &Lunatic{
+		Validator:   precommitMsg.Address,
+		BlockNumber: precommit.BlockNumber,
+		Round:       precommit.Round,
+		Header:      &header,
+		Payload:     payload,
+	}, nil
+}
+
+// rejectHeader keeps the header of a proposal of the current block number which failed the header verification,
+// to report the validators precommitting it, see checkLunatic
+func (c *core) rejectHeader(header *types.Header) {
+	if header.Number.Cmp(c.CurrentState().BlockNumber()) == 0 {
+		c.rejectedHeaders[header.Hash()] = header
+	}
+}
+
+// checkLunatic reports the validator of a precommit for a block whose header core rejected. The header may have
+// been rejected for a reason which is not deterministic, e.g. a timestamp ahead of the local time, the handler
+// is expected to check the header again before using the evidence.
+func (c *core) checkLunatic(msg message, vote *Vote) {
+	header, ok := c.rejectedHeaders[*vote.BlockHash]
+	if !ok {
+		return
+	}
+	logger := c.getLogger().With("validator", msg.Address, "vote_round", vote.Round, "block_hash", vote.BlockHash.Hex())
+	payload, err := encodeLunatic(&msg, header)
+	if err != nil {
+		logger.Errorw("failed to encode the lunatic evidence", "err", err)
+		return
+	}
+	logger.Warnw("the validator precommitted a block whose header was rejected")
+	if c.onLunatic != nil {
+		c.onLunatic(&Lunatic{
+			Validator:   msg.Address,
+			BlockNumber: vote.BlockNumber,
+			Round:       vote.Round,
+			Header:      header,
+			Payload:     payload,
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestCore_Lunatic(t *testing.T) {
+	var (
+		privateKeys []*ecdsa.PrivateKey
+		validators  []common.Address
+		reported    []*Lunatic
+		precommit   = func(key *ecdsa.PrivateKey, hash common.Hash, round int64) message {
+			msgData, err := rlp.EncodeToBytes(&Vote{
+				BlockHash:   &hash,
+				BlockNumber: big.NewInt(1),
+				Round:       round,
+			})
+			require.NoError(t, err)
+			msg := message{
+				Code:    msgPrecommit,
+				Msg:     msgData,
+				Address: crypto.PubkeyToAddress(key.PublicKey),
+			}
+			sign(t, &msg, key)
+			return msg
+		}
+	)
+	for i := 0; i < 4; i++ {
+		key := tests_utils.MakeNodeKey()
+		privateKeys = append(privateKeys, key)
+		validators = append(validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	genesisHeader := tests_utils.MakeGenesisHeader(validators)
+	be, _ := tests_utils.MustCreateAndStartNewBackend(t, privateKeys[0], genesisHeader, validators)
+	core := newTestCore(be, tendermint.DefaultConfig)
+	core.onLunatic = func(evidence *Lunatic) {
+		reported = append(reported, evidence)
+	}
+	core.currentState = core.getInitializedState()
+	core.valSet = be.Validators(core.CurrentState().BlockNumber())
+
+	rejected := tests_utils.MakeBlockWithoutSeal(genesisHeader).Header()
+	core.rejectHeader(rejected)
+	// a header of another block number is not kept
+	other := types.CopyHeader(rejected)
+	other.Number = big.NewInt(2)
+	core.rejectHeader(other)
+	require.Len(t, core.rejectedHeaders, 1)
+
+	// the precommits of other blocks are not reported
+	require.NoError(t, core.handleMsg(precommit(privateKeys[1], common.HexToHash("0x1"), 0)))
+	require.NoError(t, core.handleMsg(precommit(privateKeys[1], emptyBlockHash, 1)))
+	require.Empty(t, reported)
+
+	// the precommit of the rejected block is reported with its header
+	require.NoError(t, core.handleMsg(precommit(privateKeys[2], rejected.Hash(), 0)))
+	require.Len(t, reported, 1)
+	evidence, err := DecodeLunatic(tendermint.DefaultConfig, reported[0].Payload)
+	require.NoError(t, err)
+	require.Equal(t, validators[2], evidence.Validator)
+	require.Equal(t, int64(1), evidence.BlockNumber.Int64())
+	require.Equal(t, rejected.Hash(), evidence.Header.Hash())
+	require.True(t, IsLunatic(msgCode(t, evidence.Payload)))
+
+	// the header must be the block precommitted
+	msg := precommit(privateKeys[2], common.HexToHash("0x1"), 0)
+	payload, err := encodeLunatic(&msg, rejected)
+	require.NoError(t, err)
+	_, err = DecodeLunatic(tendermint.DefaultConfig, payload)
+	require.Equal(t, ErrInvalidLunatic, err)
+}
@@ -9,8 +9,6 @@ import (
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
-	"github.com/Evrynetlabs/evrynet-node/crypto"
-	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
@@ -26,6 +24,11 @@ const (
 	msgPrecommit
 	msgCatchUpRequest
 	msgCatchUpReply
+	msgVoteBatch
+	msgHeartbeat
+	msgDuplicateProposal
+	msgAmnesia
+	msgLunatic
 )
 
 //message is used to store consensus information between steps
@@ -34,21 +37,40 @@ type message struct {
 	Msg       []byte
 	Address   common.Address
 	Signature []byte
+	// TraceID is the trace id of a proposal or a vote, see Proposal.TraceID. It is not signed and is only
+	// appended to the messages sent to the peers decoding envelopes, the older nodes reject the fields they don't know.
+	TraceID string
+
+	proposal *Proposal     // the decoded Msg of a proposal which passed the stateless checks, it is not encoded
+	from     common.Address // the peer which sent the message, empty for the messages of this node, it is not encoded
 }
 
 // EncodeRLP serializes m into the Evrynet RLP format.
 func (m *message) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{m.Code, m.Msg, m.Address, m.Signature})
+	fields := []interface{}{m.Code, m.Msg, m.Address, m.Signature}
+	if m.TraceID != "" {
+		fields = append(fields, m.TraceID)
+	}
+	return rlp.Encode(w, fields)
 }
 
 // DecodeRLP implements rlp.Decoder, and load the consensus fields from a RLP stream.
+// A trace id longer than the ones this node generates is dropped, it is not signed.
 func (m *message) DecodeRLP(s *rlp.Stream) error {
-	type msg message
-	var decodedMsg msg
+	var decodedMsg struct {
+		Code      uint64
+		Msg       []byte
+		Address   common.Address
+		Signature []byte
+		TraceID   []string `rlp:"tail"` // optional, see message.TraceID
+	}
 	if err := s.Decode(&decodedMsg); err != nil {
 		return err
 	}
 	m.Code, m.Msg, m.Address, m.Signature = decodedMsg.Code, decodedMsg.Msg, decodedMsg.Address, decodedMsg.Signature
+	if len(decodedMsg.TraceID) > 0 && len(decodedMsg.TraceID[0]) <= maxTraceIDLength {
+		m.TraceID = decodedMsg.TraceID[0]
+	}
 	return nil
 }
 
@@ -61,22 +83,6 @@ func (m *message) PayLoadWithoutSignature() ([]byte, error) {
 	})
 }
 
-// GetAddressFromSignature gets the signer address from the signature
-func (m *message) GetAddressFromSignature() (common.Address, error) {
-	payLoad, err := m.PayLoadWithoutSignature()
-	if err != nil {
-		return common.Address{}, err
-	}
-	hashData := crypto.Keccak256(payLoad)
-
-	// 2. Recover public key
-	pubkey, err := crypto.SigToPub(hashData, m.Signature)
-	if err != nil {
-		return common.Address{}, err
-	}
-	return crypto.PubkeyToAddress(*pubkey), nil
-}
-
 type msgItem struct {
 	message interface{}
 	height  uint64
@@ -101,7 +107,7 @@ func (item *msgItem) Compare(other queue.Item) int {
 //blockVotes store the voting received for a particular block
 type blockVotes struct {
 	votes         []*Vote // validatorIndex -> *Vote
-	totalReceived int
+	totalReceived int     // the voting power of the votes, see tendermint.ValidatorSet.VotingPower
 }
 
 type messageSet struct {
@@ -113,7 +119,9 @@ type messageSet struct {
 	voteByAddress map[common.Address]*Vote
 	voteByBlock   map[common.Hash]*blockVotes
 	maj23         *common.Hash
-	totalReceived int
+	totalReceived int // the voting power of the votes
+	payloadSize   int  // the size of the messages kept, see evictMessages
+	evicted       bool // the messages were dropped, only the votes and their tallies are kept
 	//TODO: Do we have to keep track of which peer has 2/3Majority?
 }
 
@@ -157,18 +165,13 @@ func (ms *messageSet) AddVote(msg message, vote *Vote) (bool, error) {
 		return false, ErrVoteHeightMismatch
 	}
 	if ms.view.Round != vote.Round {
-		log.Error("message set round is not the same as vote round", "msg_set_round", ms.view.Round, "vote_round", vote.Round)
+		tendermint.Logger(tendermint.LogCore).Errorw("message set round is not the same as vote round", "msg_set_round", ms.view.Round, "vote_round", vote.Round)
 		return false, errors.New("invalid vote for the message set")
 	}
 	//Signer is supposed to be checked at previous steps so it doesn't need to be check again.
 
 	// if this message set already got this msg, check if the vote is duplicate or double voting
-	current, existed := ms.messages[msg.Address]
-	if existed {
-		var currentVote Vote
-		if err := rlp.DecodeBytes(current.Msg, &currentVote); err != nil {
-			return false, err
-		}
+	if currentVote, existed := ms.voteByAddress[msg.Address]; existed {
 		if currentVote.BlockHash.Hex() != vote.BlockHash.Hex() {
 			return false, ErrConflictingVotes
 		}
@@ -176,14 +179,18 @@ func (ms *messageSet) AddVote(msg message, vote *Vote) (bool, error) {
 		return false, nil
 	}
 
-	ms.messages[msg.Address] = &msg
+	if !ms.evicted {
+		ms.messages[msg.Address] = &msg
+		ms.payloadSize += len(msg.Msg) + len(msg.Signature)
+	}
 	ms.voteByAddress[msg.Address] = vote
-	ms.totalReceived++
-	if err := ms.addVoteToBlockVote(vote, index); err != nil {
+	power := ms.valSet.VotingPower(msg.Address)
+	ms.totalReceived += power
+	if err := ms.addVoteToBlockVote(vote, index, power); err != nil {
 		return false, err
 	}
 
-	if ms.voteByBlock[copyHash].totalReceived >= ms.valSet.MinMajority() {
+	if ms.voteByBlock[copyHash].totalReceived >= ms.valSet.QuorumPower() {
 		if ms.maj23 == nil {
 			ms.maj23 = &copyHash
 		}
@@ -192,7 +199,7 @@ func (ms *messageSet) AddVote(msg message, vote *Vote) (bool, error) {
 	return true, nil
 }
 
-func (ms *messageSet) addVoteToBlockVote(vote *Vote, index int) error {
+func (ms *messageSet) addVoteToBlockVote(vote *Vote, index int, power int) error {
 	bvotes, exist := ms.voteByBlock[*(vote.BlockHash)]
 	if !exist {
 		bvotes = &blockVotes{
@@ -205,7 +212,7 @@ func (ms *messageSet) addVoteToBlockVote(vote *Vote, index int) error {
 		return ErrConflictingVotes
 	}
 	bvotes.votes[index] = vote
-	bvotes.totalReceived++
+	bvotes.totalReceived += power
 	ms.voteByBlock[*(vote.BlockHash)] = bvotes
 	return nil
 }
@@ -225,7 +232,7 @@ func (ms *messageSet) HasTwoThirdAny() bool {
 	}
 	ms.messagesMu.Lock()
 	defer ms.messagesMu.Unlock()
-	return ms.totalReceived >= ms.valSet.MinMajority()
+	return ms.totalReceived >= ms.valSet.QuorumPower()
 }
 
 //TwoThirdMajority return a blockHash and a bool inidicate if this messageSet hash got a
@@ -242,6 +249,32 @@ func (ms *messageSet) TwoThirdMajority() (common.Hash, bool) {
 	return common.Hash{}, false
 }
 
+// evictMessages drops the messages of the set, its votes and their tallies are kept.
+// It returns the size of the messages dropped.
+func (ms *messageSet) evictMessages() int {
+	ms.messagesMu.Lock()
+	defer ms.messagesMu.Unlock()
+	size := ms.payloadSize
+	ms.messages = make(map[common.Address]*message)
+	ms.payloadSize = 0
+	ms.evicted = true
+	return size
+}
+
+// size returns the size of the messages kept by the set
+func (ms *messageSet) size() int {
+	ms.messagesMu.Lock()
+	defer ms.messagesMu.Unlock()
+	return ms.payloadSize
+}
+
+// voteOf returns the vote message of addr and its decoded vote, nil if addr has not voted or its message was evicted
+func (ms *messageSet) voteOf(addr common.Address) (*message, *Vote) {
+	ms.messagesMu.Lock()
+	defer ms.messagesMu.Unlock()
+	return ms.messages[addr], ms.voteByAddress[addr]
+}
+
 //MissingVotes returns a set of address not sending vote
 func (ms *messageSet) MissingVotes() map[common.Address]bool {
 	missing := make(map[common.Address]bool)
@@ -252,3 +285,28 @@ func (ms *messageSet) MissingVotes() map[common.Address]bool {
 	}
 	return missing
 }
+
+// StripTraceID returns the consensus message of data without its trace id, to be sent to the peers which decode
+// the messages bare, see message.TraceID. data is returned as is if it carries none or is not a consensus message.
+func StripTraceID(data []byte) []byte {
+	var msg message
+	if err := rlp.DecodeBytes(data, &msg); err != nil || msg.TraceID == "" {
+		return data
+	}
+	msg.TraceID = ""
+	stripped, err := rlp.EncodeToBytes(&msg)
+	if err != nil {
+		return data
+	}
+	return stripped
+}
+
+// MsgView returns the block number and round the consensus message of data was sent at,
+// false if data is not a proposal or a vote
+func MsgView(data []byte) (*tendermint.View, bool) {
+	var msg message
+	if err := rlp.DecodeBytes(data, &msg); err != nil {
+		return nil, false
+	}
+	return msgBlockView(msg)
+}
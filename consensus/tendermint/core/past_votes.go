@@ -0,0 +1,105 @@
+package core
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// pastVotes keeps the votes of the committed blocks once the round state moved on to the next block, to serve the
+// catch up requests of the peers still at these blocks. The votes are kept encoded so that the message sets of
+// the rounds are freed: the commit of the last block, which is enough for a peer to commit it, and all the votes of
+// the last config.VoteRetentionHeights blocks. It is owned by the state machine.
+type pastVotes struct {
+	heights uint64
+	commit  *pastHeight   // the precommits for the committed block of the last block, nil before a commit
+	full    []*pastHeight // all the votes of the last heights blocks, the oldest first
+}
+
+// pastHeight is the votes kept for a block number, in the order of their round and step
+type pastHeight struct {
+	blockNumber *big.Int
+	votes       []*pastVote
+}
+
+type pastVote struct {
+	round   int64
+	step    RoundStepType
+	payload []byte
+}
+
+func newPastVotes(heights uint64) *pastVotes {
+	return &pastVotes{heights: heights}
+}
+
+// retain keeps the votes of state, whose block was committed at its commit round, before the round state is cleared
+func (p *pastVotes) retain(state *roundState) {
+	blockNumber := new(big.Int).Set(state.BlockNumber())
+	p.commit = nil
+	if precommits, ok := state.GetPrecommitsByRound(state.commitRound); ok {
+		if hash, ok := precommits.TwoThirdMajority(); ok {
+			p.commit = &pastHeight{blockNumber: blockNumber}
+			for addr := range precommits.VotesByAddress() {
+				if msg, vote := precommits.voteOf(addr); msg != nil && vote.BlockHash != nil && *vote.BlockHash == hash {
+					p.commit.add(state.commitRound, RoundStepPrecommit, msg)
+				}
+			}
+		}
+	}
+	if p.heights == 0 {
+		return
+	}
+	full := &pastHeight{blockNumber: blockNumber}
+	for step, sets := range map[RoundStepType]map[int64]*messageSet{
+		RoundStepPrevote:   state.PrevotesReceived,
+		RoundStepPrecommit: state.PrecommitsReceived,
+	} {
+		for round, set := range sets {
+			for addr := range set.VotesByAddress() {
+				if msg, _ := set.voteOf(addr); msg != nil {
+					full.add(round, step, msg)
+				}
+			}
+		}
+	}
+	sort.SliceStable(full.votes, func(i, j int) bool {
+		a, b := full.votes[i], full.votes[j]
+		return a.round < b.round || (a.round == b.round && a.step < b.step)
+	})
+	p.full = append(p.full, full)
+	if uint64(len(p.full)) > p.heights {
+		p.full = append([]*pastHeight{}, p.full[uint64(len(p.full))-p.heights:]...)
+	}
+}
+
+// get returns the payloads of the votes of blockNumber a peer stuck at round and step is missing: all the votes
+// kept from its round and step on, or the commit if only the commit of the block is kept
+func (p *pastVotes) get(blockNumber *big.Int, round int64, step RoundStepType) [][]byte {
+	var payloads [][]byte
+	for _, height := range p.full {
+		if height.blockNumber.Cmp(blockNumber) != 0 {
+			continue
+		}
+		for _, vote := range height.votes {
+			if vote.round > round || (vote.round == round && vote.step >= step) {
+				payloads = append(payloads, vote.payload)
+			}
+		}
+		return payloads
+	}
+	if p.commit != nil && p.commit.blockNumber.Cmp(blockNumber) == 0 {
+		for _, vote := range p.commit.votes {
+			payloads = append(payloads, vote.payload)
+		}
+	}
+	return payloads
+}
+
+func (h *pastHeight) add(round int64, step RoundStepType, msg *message) {
+	payload, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return
+	}
+	h.votes = append(h.votes, &pastVote{round: round, step: step, payload: payload})
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestPastVotes(t *testing.T) {
+	var (
+		validators = []common.Address{
+			common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03"), common.HexToAddress("0x04"),
+		}
+		valSet    = validator.NewSet(validators, tendermint.RoundRobin, 5)
+		committed = common.HexToHash("0xaa")
+		other     = common.HexToHash("0xbb")
+		newState  = func(blockNumber int64) *roundState {
+			view := &tendermint.View{BlockNumber: big.NewInt(blockNumber), Round: 1}
+			state := newRoundState(view, make(map[int64]*messageSet), make(map[int64]*messageSet), nil,
+				-1, nil, -1, nil, nil, RoundStepCommit, 1)
+			for round := int64(0); round < 2; round++ {
+				prevotes := newMessageSet(valSet, msgPrevote, &tendermint.View{BlockNumber: view.BlockNumber, Round: round})
+				precommits := newMessageSet(valSet, msgPrecommit, &tendermint.View{BlockNumber: view.BlockNumber, Round: round})
+				for i, addr := range validators {
+					hash := committed
+					if round == 0 || i == 3 {
+						hash = other
+					}
+					vote := &Vote{BlockHash: &hash, BlockNumber: view.BlockNumber, Round: round}
+					_, err := prevotes.AddVote(message{Code: msgPrevote, Address: addr}, vote)
+					require.NoError(t, err)
+					_, err = precommits.AddVote(message{Code: msgPrecommit, Address: addr}, vote)
+					require.NoError(t, err)
+				}
+				state.PrevotesReceived[round], state.PrecommitsReceived[round] = prevotes, precommits
+			}
+			return state
+		}
+		codes = func(payloads [][]byte) map[uint64]int {
+			counts := make(map[uint64]int)
+			for _, payload := range payloads {
+				var msg message
+				require.NoError(t, rlp.DecodeBytes(payload, &msg))
+				counts[msg.Code]++
+			}
+			return counts
+		}
+	)
+
+	// only the precommits for the committed block of the last block are kept by default
+	past := newPastVotes(0)
+	past.retain(newState(5))
+	require.Equal(t, map[uint64]int{msgPrecommit: 3}, codes(past.get(big.NewInt(5), 0, RoundStepPrevote)))
+	past.retain(newState(6))
+	require.Empty(t, past.get(big.NewInt(5), 0, RoundStepPrevote))
+	require.Len(t, past.get(big.NewInt(6), 0, RoundStepPrevote), 3)
+
+	// all the votes of the retained blocks from the round and step of the request on
+	past = newPastVotes(2)
+	for blockNumber := int64(5); blockNumber <= 7; blockNumber++ {
+		past.retain(newState(blockNumber))
+	}
+	require.Empty(t, past.get(big.NewInt(5), 0, RoundStepPrevote))
+	require.Equal(t, map[uint64]int{msgPrevote: 8, msgPrecommit: 8}, codes(past.get(big.NewInt(6), 0, RoundStepPrevote)))
+	require.Equal(t, map[uint64]int{msgPrevote: 4, msgPrecommit: 4}, codes(past.get(big.NewInt(7), 1, RoundStepPrevote)))
+	require.Equal(t, map[uint64]int{msgPrecommit: 4}, codes(past.get(big.NewInt(7), 1, RoundStepPrecommit)))
+}
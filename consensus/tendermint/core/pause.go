@@ -0,0 +1,39 @@
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+)
+
+// ErrPaused is returned when core is asked to sign a message while it is paused
+var ErrPaused = errors.New("the consensus is paused")
+
+// SetPaused pauses or resumes the signing of core. A paused core keeps following the rounds and committing the blocks
+// the other validators decide, without proposing nor voting, e.g. while its key is migrated to another node.
+// It returns false if core was already in the requested state.
+func (c *core) SetPaused(paused bool) bool {
+	var changed bool
+	if paused {
+		changed = atomic.CompareAndSwapInt32(&c.paused, 0, 1)
+	} else {
+		changed = atomic.CompareAndSwapInt32(&c.paused, 1, 0)
+	}
+	if changed {
+		// the round state is owned by handleEvents, so it isn't logged here
+		tendermint.Logger(tendermint.LogCore).Infow("changed the consensus pause", "paused", paused)
+	}
+	return changed
+}
+
+// Paused returns whether core is paused
+func (c *core) Paused() bool {
+	return atomic.LoadInt32(&c.paused) == 1
+}
+
+// signs returns whether core signs its consensus messages, which an observer or a paused core doesn't
+func (c *core) signs() bool {
+	return !c.config.Observer && !c.Paused()
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/pb"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// errNoProtobufMessage is returned when a protobuf envelope has no message
+var errNoProtobufMessage = errors.New("protobuf envelope without message")
+
+// rlpProposal is the RLP of a proposal with its block left encoded, see Proposal.EncodeRLP
+type rlpProposal struct {
+	Block   rlp.RawValue
+	RStr    string
+	POLRStr string
+}
+
+// EncodeProtobuf wraps the RLP payload of a message of code in a protobuf envelope of EnvelopeVersion, see pb.Envelope.
+// The votes and the proposals are converted field by field, the bodies of the other kinds are kept RLP encoded.
+// The trace id of the message is carried by its vote or proposal, it is dropped with the bodies kept encoded.
+func EncodeProtobuf(code uint64, payload []byte) ([]byte, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return nil, err
+	}
+	pbMsg := &pb.Message{
+		Code:      msg.Code,
+		Address:   msg.Address.Bytes(),
+		Signature: msg.Signature,
+	}
+	switch msg.Code {
+	case msgPrevote, msgPrecommit:
+		if pbMsg.Vote = protobufVote(msg.Msg); pbMsg.Vote != nil {
+			pbMsg.Vote.TraceId = msg.TraceID
+		}
+	case msgPropose:
+		if pbMsg.Proposal = protobufProposal(msg.Msg); pbMsg.Proposal != nil {
+			pbMsg.Proposal.TraceId = msg.TraceID
+		}
+	}
+	// a body which doesn't convert back to the same bytes, not encoded by this node's encoding, is kept as is
+	// so that its signature still verifies
+	if body, err := rlpBody(pbMsg); err != nil || !bytes.Equal(body, msg.Msg) {
+		pbMsg.Vote, pbMsg.Proposal, pbMsg.Msg = nil, nil, msg.Msg
+	}
+	return proto.Marshal(&pb.Envelope{
+		Version: EnvelopeVersion,
+		Code:    code,
+		Message: pbMsg,
+	})
+}
+
+// DecodeProtobuf converts a protobuf envelope into the envelope of the RLP payload of its message,
+// the caller checks that it knows its code with MsgVersion before handling its payload
+func DecodeProtobuf(data []byte) (*Envelope, error) {
+	var envelope pb.Envelope
+	if err := proto.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Message == nil {
+		return nil, errNoProtobufMessage
+	}
+	body, err := rlpBody(envelope.Message)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := rlp.EncodeToBytes(&message{
+		Code:      envelope.Message.Code,
+		Msg:       body,
+		Address:   common.BytesToAddress(envelope.Message.Address),
+		Signature: envelope.Message.Signature,
+		TraceID:   protobufTraceID(envelope.Message),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Version: envelope.Version,
+		Code:    envelope.Code,
+		Payload: payload,
+	}, nil
+}
+
+// protobufVote returns the protobuf vote of the RLP body of a vote, nil if it is not a vote
+func protobufVote(body []byte) *pb.Vote {
+	var vote Vote
+	if err := rlp.DecodeBytes(body, &vote); err != nil {
+		return nil
+	}
+	pbVote := &pb.Vote{
+		Round: vote.Round,
+		Seal:  vote.Seal,
+	}
+	if vote.BlockHash != nil {
+		pbVote.BlockHash = vote.BlockHash.Bytes()
+	}
+	if vote.BlockNumber != nil {
+		pbVote.BlockNumber = vote.BlockNumber.Bytes()
+	}
+	return pbVote
+}
+
+// protobufProposal returns the protobuf proposal of the RLP body of a proposal, nil if it is not a proposal
+func protobufProposal(body []byte) *pb.Proposal {
+	var proposal rlpProposal
+	if err := rlp.DecodeBytes(body, &proposal); err != nil {
+		return nil
+	}
+	round, err := strconv.ParseInt(proposal.RStr, 10, 64)
+	if err != nil {
+		return nil
+	}
+	polRound, err := strconv.ParseInt(proposal.POLRStr, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &pb.Proposal{
+		Block:    proposal.Block,
+		Round:    round,
+		PolRound: polRound,
+	}
+}
+
+// protobufTraceID returns the trace id carried by the vote or the proposal of a protobuf message, see message.TraceID
+func protobufTraceID(msg *pb.Message) string {
+	var traceID string
+	switch {
+	case msg.Vote != nil:
+		traceID = msg.Vote.TraceId
+	case msg.Proposal != nil:
+		traceID = msg.Proposal.TraceId
+	}
+	if len(traceID) > maxTraceIDLength {
+		return ""
+	}
+	return traceID
+}
+
+// rlpBody returns the RLP body of a protobuf message, as Vote and Proposal encode it
+func rlpBody(msg *pb.Message) ([]byte, error) {
+	switch {
+	case msg.Vote != nil:
+		vote := &Vote{
+			BlockNumber: new(big.Int).SetBytes(msg.Vote.BlockNumber),
+			Round:       msg.Vote.Round,
+			Seal:        msg.Vote.Seal,
+		}
+		if len(msg.Vote.BlockHash) > 0 {
+			hash := common.BytesToHash(msg.Vote.BlockHash)
+			vote.BlockHash = &hash
+		}
+		return rlp.EncodeToBytes(vote)
+	case msg.Proposal != nil:
+		if len(msg.Proposal.Block) == 0 {
+			return nil, ErrEmptyBlockProposal
+		}
+		return rlp.EncodeToBytes([]interface{}{
+			rlp.RawValue(msg.Proposal.Block),
+			strconv.FormatInt(msg.Proposal.Round, 10),
+			strconv.FormatInt(msg.Proposal.PolRound, 10),
+		})
+	}
+	return msg.Msg, nil
+}
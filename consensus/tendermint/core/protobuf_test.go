@@ -0,0 +1,61 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestProtobuf_RoundTrip(t *testing.T) {
+	var (
+		config = &tendermint.Config{ChainID: big.NewInt(1), DomainSeparationBlock: big.NewInt(0)}
+		key    = tests_utils.MakeNodeKey()
+		block  = tests_utils.MakeBlockWithoutSeal(tests_utils.MakeGenesisHeader([]common.Address{crypto.PubkeyToAddress(key.PublicKey)}))
+		signed = func(code uint64, body interface{}, traceID string) []byte {
+			data, err := rlp.EncodeToBytes(body)
+			require.NoError(t, err)
+			msg := message{Code: code, Msg: data, Address: crypto.PubkeyToAddress(key.PublicKey), TraceID: traceID}
+			signingPayload, err := msg.SigningPayload(config)
+			require.NoError(t, err)
+			msg.Signature, err = crypto.Sign(crypto.Keccak256(signingPayload), key)
+			require.NoError(t, err)
+			payload, err := rlp.EncodeToBytes(&msg)
+			require.NoError(t, err)
+			return payload
+		}
+		hash = common.HexToHash("0x01")
+	)
+	for name, payload := range map[string][]byte{
+		"prevote":   signed(msgPrevote, &Vote{BlockHash: &hash, BlockNumber: big.NewInt(1), Round: 2}, "trace"),
+		"precommit": signed(msgPrecommit, &Vote{BlockHash: &emptyBlockHash, BlockNumber: big.NewInt(1), Seal: []byte{0x01}}, ""),
+		"proposal":  signed(msgPropose, &Proposal{Block: block, Round: 1, POLRound: -1}, "trace"),
+		"heartbeat": signed(msgHeartbeat, &Heartbeat{BlockNumber: big.NewInt(1), Round: 3, Sequence: 2}, ""),
+		// a vote the node would not encode, its round being padded, is kept RLP encoded
+		"padded": signed(msgPrevote, []interface{}{&hash, big.NewInt(1), "01", []byte{}}, ""),
+	} {
+		var msg message
+		require.NoError(t, rlp.DecodeBytes(payload, &msg), name)
+		data, err := EncodeProtobuf(msg.Code, payload)
+		require.NoError(t, err, name)
+		envelope, err := DecodeProtobuf(data)
+		require.NoError(t, err, name)
+		require.Equal(t, EnvelopeVersion, envelope.Version, name)
+		require.Equal(t, msg.Code, envelope.Code, name)
+		// the message is the same, and so is its signature
+		require.Equal(t, payload, envelope.Payload, name)
+		require.NoError(t, rlp.DecodeBytes(envelope.Payload, &msg), name)
+		addr, err := msg.GetAddressFromSignature(config)
+		require.NoError(t, err, name)
+		require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), addr, name)
+	}
+
+	_, err := DecodeProtobuf([]byte{0x08, 0x01})
+	require.Equal(t, errNoProtobufMessage, err)
+}
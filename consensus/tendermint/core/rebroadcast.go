@@ -3,9 +3,34 @@ package core
 import (
 	"go.uber.org/zap"
 
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
+// IsProposal returns true if msgType is the code of proposals
+func IsProposal(msgType uint64) bool {
+	return msgType == msgPropose
+}
+
+// IsVote returns true if msgType is the code of prevotes or precommits
+func IsVote(msgType uint64) bool {
+	return msgType == msgPrevote || msgType == msgPrecommit
+}
+
+// GossipStrategy returns the configured strategy to disseminate the messages of msgType
+func GossipStrategy(config *tendermint.Config, msgType uint64) tendermint.GossipStrategy {
+	switch msgType {
+	case msgPropose:
+		return config.ProposeGossip
+	case msgPrevote:
+		return config.PrevoteGossip
+	case msgPrecommit:
+		return config.PrecommitGossip
+	default:
+		return tendermint.FullBroadcast
+	}
+}
+
 func (c *core) reBroadcastMsg(msg message, logger *zap.SugaredLogger) {
 	if !c.rebroadcast {
 		return
@@ -19,7 +44,8 @@ func (c *core) reBroadcastMsg(msg message, logger *zap.SugaredLogger) {
 		logger.Error("failed to encode msg", "error", err)
 		return
 	}
-	if err := c.backend.Multicast(c.valSet.GetNeighbors(c.getAddress()), payload); err != nil {
+	targets := GossipStrategy(c.config, msg.Code).Targets(c.valSet, c.getAddress(), msg.Address, c.config.GossipFanout)
+	if err := c.backend.Multicast(targets, payload); err != nil {
 		logger.Error("failed to re-gossip the vote received", "error", err)
 	}
 }
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	// ErrInvalidTraceEvent is returned by Replay when an event of the trace has none or several inputs set
+	ErrInvalidTraceEvent = errors.New("trace event must have exactly one input")
+	// ErrTraceStartMismatch is returned by Replay when the head of the backend is not the one core started on
+	ErrTraceStartMismatch = errors.New("trace starts at a different block number than the backend's head")
+)
+
+// TraceEvent is an input of the state machine of core. A trace is the sequence of the events in the order
+// core handled them, as JSON lines, so that replaying it reproduces the same state transitions.
+type TraceEvent struct {
+	Time time.Time `json:"time"` // when core handled the event, informational only
+
+	Start          *big.Int      `json:"start,omitempty"`           // core started at this block number
+	NewBlock       hexutil.Bytes `json:"new_block,omitempty"`       // the RLP of the block posted by the miner
+	Message        hexutil.Bytes `json:"message,omitempty"`         // the payload of a consensus message, its signature is verified
+	Timeout        *timeoutInfo  `json:"timeout,omitempty"`         // a timeout fired
+	FinalCommitted *big.Int      `json:"final_committed,omitempty"` // the block number which was committed to the chain
+}
+
+// traceRecorder appends the events handled by core to a writer
+type traceRecorder struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	failed bool // the first failure is logged, the following events are dropped
+}
+
+// WithTraceRecorder returns an option to write the events handled by core to w, see Replay
+func WithTraceRecorder(w io.Writer) Option {
+	return func(c *core) error {
+		c.recorder = &traceRecorder{enc: json.NewEncoder(w)}
+		return nil
+	}
+}
+
+// record writes ev if core has a recorder
+func (c *core) record(ev TraceEvent) {
+	r := c.recorder
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failed {
+		return
+	}
+	ev.Time = time.Now()
+	if err := r.enc.Encode(&ev); err != nil {
+		r.failed = true
+		tendermint.Logger(tendermint.LogCore).Errorw("failed to record trace event, recording stopped", "err", err)
+	}
+}
+
+func (c *core) recordMsg(msg message) {
+	if c.recorder == nil {
+		return
+	}
+	payload, err := rlp.EncodeToBytes(&msg)
+	if err != nil {
+		return
+	}
+	c.record(TraceEvent{Message: payload})
+}
+
+func (c *core) recordNewBlock(block *types.Block) {
+	if c.recorder == nil {
+		return
+	}
+	payload, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return
+	}
+	c.record(TraceEvent{NewBlock: payload})
+}
+
+// Replay runs the events of a trace recorded by WithTraceRecorder through a new core, one after the other
+// without any timer, and returns the final state of core. The backend must have the address of the node which
+// recorded the trace and the head block it had when core started.
+func Replay(backend tendermint.Backend, config *tendermint.Config, r io.Reader) (*StateSnapshot, error) {
+	c := New(backend, config, WithoutRebroadcast()).(*core)
+	c.timeout = &replayTimeoutTicker{}
+
+	dec := json.NewDecoder(r)
+	for i := 0; ; i++ {
+		var ev TraceEvent
+		if err := dec.Decode(&ev); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("event %d: %v", i, err)
+		}
+		if err := c.replayEvent(ev); err != nil {
+			return nil, fmt.Errorf("event %d: %v", i, err)
+		}
+	}
+	if c.currentState == nil {
+		return nil, errors.New("trace has no start event")
+	}
+	return c.Snapshot(), nil
+}
+
+// replayEvent runs ev through the handler handleEvents would call
+func (c *core) replayEvent(ev TraceEvent) error {
+	inputs := 0
+	for _, set := range []bool{ev.Start != nil, ev.NewBlock != nil, ev.Message != nil, ev.Timeout != nil, ev.FinalCommitted != nil} {
+		if set {
+			inputs++
+		}
+	}
+	if inputs != 1 {
+		return ErrInvalidTraceEvent
+	}
+	if ev.Start == nil && c.currentState == nil {
+		return errors.New("event before the start event")
+	}
+	switch {
+	case ev.Start != nil:
+		// a restarted core keeps its state, as Start does
+		if c.currentState == nil {
+			c.currentState = c.getInitializedState()
+			c.valSet = c.backend.Validators(c.CurrentState().BlockNumber())
+		}
+		if c.currentState.BlockNumber().Cmp(ev.Start) != 0 {
+			return ErrTraceStartMismatch
+		}
+		c.startNewRound()
+	case ev.NewBlock != nil:
+		var block types.Block
+		if err := rlp.DecodeBytes(ev.NewBlock, &block); err != nil {
+			return err
+		}
+		c.handleNewBlock(&block)
+	case ev.Message != nil:
+		msg, err := c.decodeAndVerifyMsg(ev.Message, nil)
+		if err != nil {
+			return err
+		}
+		if err := c.handleVerifiedMsg(msg); err != nil {
+			c.getLogger().Debugw("failed to handle replayed msg", "error", err)
+		}
+	case ev.Timeout != nil:
+		c.handleTimeout(*ev.Timeout)
+	case ev.FinalCommitted != nil:
+		_ = c.handleFinalCommitted(ev.FinalCommitted)
+	}
+	return nil
+}
+
+// replayTimeoutTicker keeps the last scheduled timeout without firing it, the timeouts of a replay come from the trace
+type replayTimeoutTicker struct {
+	mu      sync.Mutex
+	pending *timeoutInfo
+}
+
+func (tt *replayTimeoutTicker) Start() error             { return nil }
+func (tt *replayTimeoutTicker) Stop() error              { return nil }
+func (tt *replayTimeoutTicker) Chan() <-chan timeoutInfo { return nil }
+
+func (tt *replayTimeoutTicker) ScheduleTimeout(ti timeoutInfo) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.pending = &ti
+}
+
+func (tt *replayTimeoutTicker) Pending() *timeoutInfo {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if tt.pending == nil {
+		return nil
+	}
+	pending := *tt.pending
+	return &pending
+}
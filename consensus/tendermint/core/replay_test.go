@@ -0,0 +1,63 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/event"
+)
+
+// TestReplay records the events handled by a validator while the network commits a few heights,
+// and checks that replaying them reaches the same state and commits the same blocks.
+func TestReplay(t *testing.T) {
+	var (
+		trace  bytes.Buffer
+		net    = newSimNetwork(t, 4, tests_utils.DefaultTestConfig, true)
+		node   = net.node(0)
+		config = tests_utils.DefaultTestConfig
+	)
+	node.core = New(node, config, WithoutRebroadcast(), WithTraceRecorder(&trace)).(*core)
+	// the proposer of the first height is offline so that the trace also holds timeouts and a round change
+	net.isolate(net.node(1).address)
+	net.start()
+	net.waitForHeight(3, 10*time.Second, node)
+	net.stop()
+	recorded := node.core.Snapshot()
+	require.Contains(t, trace.String(), `"timeout"`)
+
+	replayed := &simNode{
+		net:        &simNetwork{t: t, nodes: make(map[common.Address]*simNode), commits: make(map[uint64]common.Hash)},
+		privateKey: node.privateKey,
+		address:    node.address,
+		validators: node.validators,
+		config:     config,
+		eventMux:   new(event.TypeMux),
+		chain:      []*types.Block{node.blockAt(0)},
+		mu:         &sync.RWMutex{},
+	}
+	snapshot, err := Replay(replayed, config, bytes.NewReader(trace.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, recorded.BlockNumber, snapshot.BlockNumber)
+	require.Equal(t, recorded.Round, snapshot.Round)
+	require.Equal(t, recorded.Step, snapshot.Step)
+	require.Equal(t, recorded.LockedRound, snapshot.LockedRound)
+	require.Equal(t, recorded.ValidRound, snapshot.ValidRound)
+	require.Equal(t, node.head().NumberU64(), replayed.head().NumberU64())
+	for number := uint64(1); number <= node.head().NumberU64(); number++ {
+		require.Equal(t, node.blockAt(number).Hash(), replayed.blockAt(number).Hash())
+	}
+
+	// the backend must be at the head core started on
+	_, err = Replay(replayed, config, bytes.NewReader(trace.Bytes()))
+	require.Error(t, err)
+	_, err = Replay(replayed, config, strings.NewReader(`{"time":"2020-01-01T00:00:00Z"}`))
+	require.Error(t, err)
+}
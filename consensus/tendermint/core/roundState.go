@@ -21,6 +21,8 @@ import (
 	"math/big"
 	"time"
 
+	"go.uber.org/zap"
+
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
@@ -43,6 +45,7 @@ func newRoundState(view *tendermint.View, prevotesReceived, precommitsReceived m
 		PrevotesReceived:   prevotesReceived,
 		PrecommitsReceived: precommitsReceived,
 		step:               step,
+		stepTime:           time.Now(),
 		commitRound:        commitRound,
 	}
 }
@@ -69,7 +72,20 @@ type roundState struct {
 
 	//step is the enumerate Step that currently the core is at.
 	//to jump to the next step, UpdateRoundStep is called.
-	step RoundStepType
+	step     RoundStepType
+	stepTime time.Time // stepTime is when the current step was entered
+
+	//logger is bound with the current block number, round and step.
+	//it is refreshed whenever the view or the step changes.
+	logger *zap.SugaredLogger
+
+	//hooks are called on every round/step transition and lock/unlock, it can be nil
+	hooks *hooks
+
+	//maxVoteRounds is the number of rounds the vote sets of which keep their messages, 0 for no limit, see evictVoteRounds
+	maxVoteRounds uint64
+	//futureRounds is the highest round above the current one each validator voted in, see acceptRound
+	futureRounds map[common.Address]int64
 }
 
 func (s *roundState) Step() RoundStepType {
@@ -89,8 +105,33 @@ func (s *roundState) Round() int64 {
 }
 
 func (s *roundState) UpdateRoundStep(round int64, step RoundStepType) {
+	prevRound, prevStep := s.view.Round, s.step
 	s.view.Round = round
 	s.step = step
+	s.updateLogger()
+	if prevRound != round || prevStep != step {
+		s.stepTime = time.Now()
+		s.hooks.onStep(s.view.BlockNumber, round, step, prevStep)
+	}
+}
+
+// Logger returns a logger which includes the current block number, round and step
+func (s *roundState) Logger() *zap.SugaredLogger {
+	if s.logger == nil {
+		s.updateLogger()
+	}
+	return s.logger
+}
+
+func (s *roundState) updateLogger() {
+	if s.view == nil || s.view.BlockNumber == nil {
+		s.logger = tendermint.Logger(tendermint.LogCore)
+		return
+	}
+	s.logger = tendermint.Logger(tendermint.LogCore).With(
+		zap.String("block", s.view.BlockNumber.String()),
+		zap.Int64("round", s.view.Round),
+		zap.Stringer("step", s.step))
 }
 
 func (s *roundState) ProposalReceived() *Proposal {
@@ -104,6 +145,7 @@ func (s *roundState) SetProposalReceived(proposalReceived *Proposal) {
 
 func (s *roundState) SetView(v *tendermint.View) {
 	s.view = v
+	s.updateLogger()
 }
 
 // IsProposalComplete Returns true if the proposal block is complete &&
@@ -136,13 +178,16 @@ func (s *roundState) Block() *types.Block {
 }
 
 func (s *roundState) SetLockedRoundAndBlock(lockedR int64, lockedBl *types.Block) {
+	wasLocked := s.lockedBlock != nil
 	s.lockedRound = lockedR
 	s.lockedBlock = lockedBl
+	if lockedBl != nil || wasLocked {
+		s.hooks.onLock(s.view.BlockNumber, s.view.Round, lockedR, lockedBl)
+	}
 }
 
 func (s *roundState) Unlock() {
-	s.lockedRound = -1
-	s.lockedBlock = nil
+	s.SetLockedRoundAndBlock(-1, nil)
 }
 
 func (s *roundState) LockedRound() int64 {
@@ -207,6 +252,7 @@ func (s *roundState) DecodeRLP(stream *rlp.Stream) error {
 	s.proposalReceived = ss.proposalReceived
 	s.PrevotesReceived = ss.PrevotesReceived
 	s.PrecommitsReceived = ss.PrecommitsReceived
+	s.logger = nil
 
 	return nil
 }
@@ -239,12 +285,23 @@ func (s *roundState) addPrevote(msg message, vote *Vote, valset tendermint.Valid
 		BlockNumber: big.NewInt(0).Set(vote.BlockNumber),
 		Round:       vote.Round,
 	}
+	if !s.acceptRound(msg.Address, vote.Round) {
+		return false, nil
+	}
 	msgSet, ok := s.PrevotesReceived[vote.Round]
 	if !ok {
 		msgSet = newMessageSet(valset, msgPrevote, &view)
 		s.PrevotesReceived[vote.Round] = msgSet
 	}
-	return msgSet.AddVote(msg, vote)
+	added, err := msgSet.AddVote(msg, vote)
+	if added {
+		if !ok {
+			// the rounds are evicted once the first vote of the new round is counted among its signers
+			s.evictVoteRounds()
+		}
+		s.updateVoteSetGauges()
+	}
+	return added, err
 }
 
 //GetPrevotesByRound return prevote messageSet for that round, if there is no prevotes message on the said round, return nil and false
@@ -258,12 +315,23 @@ func (s *roundState) addPrecommit(msg message, vote *Vote, valset tendermint.Val
 		BlockNumber: big.NewInt(0).Set(vote.BlockNumber),
 		Round:       vote.Round,
 	}
+	if !s.acceptRound(msg.Address, vote.Round) {
+		return false, nil
+	}
 	msgSet, ok := s.PrecommitsReceived[vote.Round]
 	if !ok {
 		msgSet = newMessageSet(valset, msgPrecommit, &view)
 		s.PrecommitsReceived[vote.Round] = msgSet
 	}
-	return msgSet.AddVote(msg, vote)
+	added, err := msgSet.AddVote(msg, vote)
+	if added {
+		if !ok {
+			// the rounds are evicted once the first vote of the new round is counted among its signers
+			s.evictVoteRounds()
+		}
+		s.updateVoteSetGauges()
+	}
+	return added, err
 }
 
 //GetPrecommitsByRound return precommit messageSet for that round, if there is no precommit message on the said round, return nil and false
@@ -293,5 +361,6 @@ func (s *roundState) clearPreviousRoundData() {
 	s.commitRound = -1
 	s.PrevotesReceived = make(map[int64]*messageSet)
 	s.PrecommitsReceived = make(map[int64]*messageSet)
+	s.updateVoteSetGauges()
 	s.PrecommitWaited = false
 }
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/pb"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// signingDomain is the first field of every domain separated signing payload,
+// it stops consensus signatures from being valid for any other kind of signed data.
+const signingDomain = "evrynet-tendermint-msg-v1"
+
+// protobufSigningDomain is the domain of the protobuf sign bytes, see pb.SignBytes
+const protobufSigningDomain = "evrynet-tendermint-msg-v2"
+
+// signingPayload is what validators sign for a consensus message once the domain separation is active
+// at the message's block number:
+//
+//	keccak256(rlp([domain, chain_id, block_number, round, code, msg, address]))
+//
+// chain_id stops a message from being replayed on another Evrynet network, block_number and round stop it
+// from being replayed at another height or round, and code (propose, prevote, precommit, catch up request,
+// catch up reply, vote batch or heartbeat) stops a vote from being replayed as a vote of another type.
+// The round of a catch up reply or a vote batch is always 0.
+//
+// From the protobuf signing block on, the same fields are signed protobuf encoded instead, see pb.SignBytes,
+// so that signers built against the protobuf schema can check what they sign.
+//
+// Before the domain separation block, or if no chain id is configured, messages are signed with the legacy
+// payload rlp([code, msg, address, ""]) so that existing networks keep working until they fork.
+type signingPayload struct {
+	Domain      string
+	ChainID     *big.Int
+	BlockNumber *big.Int
+	Round       uint64
+	Code        uint64
+	Msg         []byte
+	Address     common.Address
+}
+
+// isDomainSeparated returns true if the messages of blockNumber are signed with the domain separated payload
+func isDomainSeparated(config *tendermint.Config, blockNumber *big.Int) bool {
+	if config == nil || config.ChainID == nil || config.DomainSeparationBlock == nil {
+		return false
+	}
+	return blockNumber.Cmp(config.DomainSeparationBlock) >= 0
+}
+
+// isProtobufSigning returns true if the messages of blockNumber are signed with the protobuf sign bytes
+func isProtobufSigning(config *tendermint.Config, blockNumber *big.Int) bool {
+	if config == nil || config.ChainID == nil || config.ProtobufSigningBlock == nil {
+		return false
+	}
+	return blockNumber.Cmp(config.ProtobufSigningBlock) >= 0
+}
+
+// signingView returns the block number and the round of the consensus message
+func (m *message) signingView() (*big.Int, int64, error) {
+	var (
+		blockNumber *big.Int
+		round       int64
+	)
+	switch m.Code {
+	case msgPropose:
+		var proposal Proposal
+		if err := rlp.DecodeBytes(m.Msg, &proposal); err != nil {
+			return nil, 0, err
+		}
+		if proposal.Block == nil {
+			return nil, 0, ErrEmptyBlockProposal
+		}
+		blockNumber, round = proposal.Block.Number(), proposal.Round
+	case msgPrevote, msgPrecommit:
+		var vote Vote
+		if err := rlp.DecodeBytes(m.Msg, &vote); err != nil {
+			return nil, 0, err
+		}
+		blockNumber, round = vote.BlockNumber, vote.Round
+	case msgCatchUpRequest:
+		var request CatchUpRequestMsg
+		if err := rlp.DecodeBytes(m.Msg, &request); err != nil {
+			return nil, 0, err
+		}
+		blockNumber, round = request.BlockNumber, request.Round
+	case msgCatchUpReply:
+		var reply CatchUpReplyMsg
+		if err := rlp.DecodeBytes(m.Msg, &reply); err != nil {
+			return nil, 0, err
+		}
+		blockNumber = reply.BlockNumber
+	case msgVoteBatch:
+		var batch VoteBatchMsg
+		if err := rlp.DecodeBytes(m.Msg, &batch); err != nil {
+			return nil, 0, err
+		}
+		blockNumber = batch.BlockNumber
+	case msgHeartbeat:
+		var heartbeat Heartbeat
+		if err := rlp.DecodeBytes(m.Msg, &heartbeat); err != nil {
+			return nil, 0, err
+		}
+		blockNumber, round = heartbeat.BlockNumber, heartbeat.Round
+	default:
+		return nil, 0, errors.Errorf("unknown msg code %d", m.Code)
+	}
+	if blockNumber == nil {
+		return nil, 0, ErrEmptyVote
+	}
+	if round < 0 {
+		return nil, 0, ErrInvalidRound
+	}
+	return blockNumber, round, nil
+}
+
+// SigningPayload returns the data a validator signs for the message, see signingPayload.
+func (m *message) SigningPayload(config *tendermint.Config) ([]byte, error) {
+	if config == nil || config.ChainID == nil || (config.DomainSeparationBlock == nil && config.ProtobufSigningBlock == nil) {
+		return m.PayLoadWithoutSignature()
+	}
+	blockNumber, round, err := m.signingView()
+	if err != nil {
+		return nil, err
+	}
+	if isProtobufSigning(config, blockNumber) {
+		return proto.Marshal(&pb.SignBytes{
+			Domain:      protobufSigningDomain,
+			ChainId:     config.ChainID.Bytes(),
+			BlockNumber: blockNumber.Bytes(),
+			Round:       round,
+			Code:        m.Code,
+			Msg:         m.Msg,
+			Address:     m.Address.Bytes(),
+		})
+	}
+	if !isDomainSeparated(config, blockNumber) {
+		return m.PayLoadWithoutSignature()
+	}
+	return rlp.EncodeToBytes(&signingPayload{
+		Domain:      signingDomain,
+		ChainID:     config.ChainID,
+		BlockNumber: blockNumber,
+		Round:       uint64(round),
+		Code:        m.Code,
+		Msg:         m.Msg,
+		Address:     m.Address,
+	})
+}
+
+// GetAddressFromSignature gets the signer address from the signature
+func (m *message) GetAddressFromSignature(config *tendermint.Config) (common.Address, error) {
+	payLoad, err := m.SigningPayload(config)
+	if err != nil {
+		return common.Address{}, err
+	}
+	hashData := crypto.Keccak256(payLoad)
+
+	// recover public key
+	pubkey, err := crypto.SigToPub(hashData, m.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
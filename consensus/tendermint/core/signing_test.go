@@ -0,0 +1,110 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/pb"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func makeSignedVoteMsg(t *testing.T, config *tendermint.Config, code uint64, blockNumber int64, round int64) (message, common.Address) {
+	var (
+		key  = tests_utils.MakeNodeKey()
+		hash = common.HexToHash("0x01")
+	)
+	data, err := rlp.EncodeToBytes(&Vote{
+		BlockHash:   &hash,
+		BlockNumber: big.NewInt(blockNumber),
+		Round:       round,
+	})
+	require.NoError(t, err)
+	msg := message{
+		Code:    code,
+		Msg:     data,
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+	payload, err := msg.SigningPayload(config)
+	require.NoError(t, err)
+	msg.Signature, err = crypto.Sign(crypto.Keccak256(payload), key)
+	require.NoError(t, err)
+	return msg, msg.Address
+}
+
+func TestSigningPayload_Legacy(t *testing.T) {
+	config := &tendermint.Config{ChainID: big.NewInt(1)}
+	msg, _ := makeSignedVoteMsg(t, config, msgPrevote, 10, 0)
+
+	payload, err := msg.SigningPayload(config)
+	require.NoError(t, err)
+	legacy, err := msg.PayLoadWithoutSignature()
+	require.NoError(t, err)
+	require.Equal(t, legacy, payload)
+
+	// before the domain separation block, the legacy payload is used
+	config.DomainSeparationBlock = big.NewInt(11)
+	payload, err = msg.SigningPayload(config)
+	require.NoError(t, err)
+	require.Equal(t, legacy, payload)
+}
+
+func TestSigningPayload_DomainSeparated(t *testing.T) {
+	var (
+		config = &tendermint.Config{ChainID: big.NewInt(1), DomainSeparationBlock: big.NewInt(10)}
+		other  = &tendermint.Config{ChainID: big.NewInt(2), DomainSeparationBlock: big.NewInt(10)}
+	)
+	msg, signer := makeSignedVoteMsg(t, config, msgPrevote, 10, 1)
+
+	payload, err := msg.SigningPayload(config)
+	require.NoError(t, err)
+	legacy, err := msg.PayLoadWithoutSignature()
+	require.NoError(t, err)
+	require.NotEqual(t, legacy, payload)
+
+	addr, err := msg.GetAddressFromSignature(config)
+	require.NoError(t, err)
+	require.Equal(t, signer, addr)
+
+	// the message can not be replayed on another network
+	addr, err = msg.GetAddressFromSignature(other)
+	require.NoError(t, err)
+	require.NotEqual(t, signer, addr)
+
+	// nor as a vote of another type
+	msg.Code = msgPrecommit
+	addr, err = msg.GetAddressFromSignature(config)
+	require.NoError(t, err)
+	require.NotEqual(t, signer, addr)
+}
+
+func TestSigningPayload_Protobuf(t *testing.T) {
+	var (
+		config = &tendermint.Config{ChainID: big.NewInt(1), DomainSeparationBlock: big.NewInt(5), ProtobufSigningBlock: big.NewInt(10)}
+		before = &tendermint.Config{ChainID: big.NewInt(1), DomainSeparationBlock: big.NewInt(5)}
+	)
+	msg, signer := makeSignedVoteMsg(t, config, msgPrevote, 10, 1)
+
+	// the protobuf sign bytes replace the domain separated ones from the protobuf signing block on
+	payload, err := msg.SigningPayload(config)
+	require.NoError(t, err)
+	var signBytes pb.SignBytes
+	require.NoError(t, proto.Unmarshal(payload, &signBytes))
+	require.Equal(t, protobufSigningDomain, signBytes.Domain)
+	require.Equal(t, int64(10), new(big.Int).SetBytes(signBytes.BlockNumber).Int64())
+	require.Equal(t, int64(1), signBytes.Round)
+	require.Equal(t, msg.Msg, signBytes.Msg)
+
+	addr, err := msg.GetAddressFromSignature(config)
+	require.NoError(t, err)
+	require.Equal(t, signer, addr)
+	addr, err = msg.GetAddressFromSignature(before)
+	require.NoError(t, err)
+	require.NotEqual(t, signer, addr)
+}
@@ -0,0 +1,501 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// simMsg is a consensus message travelling through the simulated network
+type simMsg struct {
+	from    common.Address
+	to      common.Address
+	payload []byte
+}
+
+// decode returns the decoded message, the block number and the round of a simMsg.
+// blockNumber is nil if the message is neither a proposal nor a vote.
+func (m *simMsg) decode() (message, *big.Int, int64) {
+	var msg message
+	if err := rlp.DecodeBytes(m.payload, &msg); err != nil {
+		return msg, nil, 0
+	}
+	switch msg.Code {
+	case msgPropose:
+		var proposal Proposal
+		if err := rlp.DecodeBytes(msg.Msg, &proposal); err == nil {
+			return msg, proposal.Block.Number(), proposal.Round
+		}
+	case msgPrevote, msgPrecommit:
+		var vote Vote
+		if err := rlp.DecodeBytes(msg.Msg, &vote); err == nil {
+			return msg, vote.BlockNumber, vote.Round
+		}
+	}
+	return msg, nil, 0
+}
+
+// simNetwork connects the simulated nodes in memory.
+// In auto mode, messages are delivered as soon as they are sent, otherwise they stay in pending
+// until the test delivers them in any order it wants.
+// Messages matching a drop rule are never delivered.
+type simNetwork struct {
+	t         *testing.T
+	mu        sync.Mutex
+	nodes     map[common.Address]*simNode
+	order     []common.Address // sorted address of nodes, same as the validator set's order
+	observers []common.Address // observers receive the messages gossiped by validators
+	auto      bool
+	drops     []func(*simMsg) bool
+	groups    map[common.Address]int // the partition of each node, the messages between partitions are dropped
+	sent      []*simMsg              // every message sent through the network, for assertions
+	pending   []*simMsg
+
+	maxDrift time.Duration          // the max drift of the proposals' timestamps ahead of a node's clock, 0 disables the check
+	commits  map[uint64]common.Hash // the first block committed at each height, to detect conflicting commits
+}
+
+// newSimNetwork creates n nodes sharing the same genesis and validator set. The nodes are not started.
+func newSimNetwork(t *testing.T, n int, config *tendermint.Config, auto bool) *simNetwork {
+	var (
+		keys       = make([]*ecdsa.PrivateKey, n)
+		validators = make([]common.Address, n)
+	)
+	for i := 0; i < n; i++ {
+		keys[i] = tests_utils.MakeNodeKey()
+		validators[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	genesis := types.NewBlockWithHeader(tests_utils.MakeGenesisHeader(validators))
+	net := &simNetwork{
+		t:       t,
+		nodes:   make(map[common.Address]*simNode),
+		auto:    auto,
+		commits: make(map[uint64]common.Hash),
+	}
+	for i := 0; i < n; i++ {
+		node := &simNode{
+			net:        net,
+			privateKey: keys[i],
+			address:    validators[i],
+			validators: validators,
+			config:     config,
+			eventMux:   new(event.TypeMux),
+			chain:      []*types.Block{genesis},
+			mu:         &sync.RWMutex{},
+		}
+		node.core = New(node, config, WithoutRebroadcast()).(*core)
+		net.nodes[node.address] = node
+		net.order = append(net.order, node.address)
+	}
+	sort.Slice(net.order, func(i, j int) bool {
+		return net.order[i].String() < net.order[j].String()
+	})
+	return net
+}
+
+// addObserver adds a node which is not a validator and runs core in observer mode. The node is not started.
+func (net *simNetwork) addObserver(config *tendermint.Config) *simNode {
+	observerConfig := *config
+	observerConfig.Observer = true
+	var (
+		key     = tests_utils.MakeNodeKey()
+		genesis = net.node(0).blockAt(0)
+	)
+	node := &simNode{
+		net:        net,
+		privateKey: key,
+		address:    crypto.PubkeyToAddress(key.PublicKey),
+		validators: net.node(0).validators,
+		config:     &observerConfig,
+		eventMux:   new(event.TypeMux),
+		chain:      []*types.Block{genesis},
+		mu:         &sync.RWMutex{},
+	}
+	node.core = New(node, &observerConfig, WithoutRebroadcast()).(*core)
+	net.mu.Lock()
+	net.nodes[node.address] = node
+	net.observers = append(net.observers, node.address)
+	net.mu.Unlock()
+	return node
+}
+
+// node returns the i-th node in validator set's order
+func (net *simNetwork) node(i int) *simNode {
+	return net.nodes[net.order[i]]
+}
+
+func (net *simNetwork) start() {
+	for _, addr := range net.order {
+		require.NoError(net.t, net.nodes[addr].start())
+	}
+}
+
+func (net *simNetwork) stop() {
+	for _, addr := range append(net.order, net.observers...) {
+		_ = net.nodes[addr].core.Stop()
+	}
+}
+
+// drop adds a rule to drop every message matching it
+func (net *simNetwork) drop(rule func(*simMsg) bool) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.drops = append(net.drops, rule)
+}
+
+// isolate drops every message from and to addr
+func (net *simNetwork) isolate(addr common.Address) {
+	net.drop(func(m *simMsg) bool {
+		return m.from == addr || m.to == addr
+	})
+}
+
+// partition splits the network, the nodes of each group only receive the messages of their group
+// and the nodes out of any group receive no message at all
+func (net *simNetwork) partition(groups ...[]common.Address) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.groups = make(map[common.Address]int)
+	for i, group := range groups {
+		for _, addr := range group {
+			net.groups[addr] = i
+		}
+	}
+}
+
+// heal removes the partition of the network
+func (net *simNetwork) heal() {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.groups = nil
+}
+
+func (net *simNetwork) send(from, to common.Address, payload []byte) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	msg := &simMsg{from: from, to: to, payload: payload}
+	net.sent = append(net.sent, msg)
+	for _, rule := range net.drops {
+		if rule(msg) {
+			return
+		}
+	}
+	if net.groups != nil {
+		fromGroup, fromOk := net.groups[from]
+		toGroup, toOk := net.groups[to]
+		if !fromOk || !toOk || fromGroup != toGroup {
+			return
+		}
+	}
+	if net.auto {
+		go net.nodes[to].receive(msg.payload)
+		return
+	}
+	net.pending = append(net.pending, msg)
+}
+
+// deliver sends the pending messages matching filter in their current order, the others stay pending
+func (net *simNetwork) deliver(filter func(*simMsg) bool) int {
+	net.mu.Lock()
+	var (
+		remain  []*simMsg
+		matched []*simMsg
+	)
+	for _, msg := range net.pending {
+		if filter == nil || filter(msg) {
+			matched = append(matched, msg)
+		} else {
+			remain = append(remain, msg)
+		}
+	}
+	net.pending = remain
+	net.mu.Unlock()
+	for _, msg := range matched {
+		net.nodes[msg.to].receive(msg.payload)
+	}
+	return len(matched)
+}
+
+// shuffle randomizes the order of pending messages
+func (net *simNetwork) shuffle(r *rand.Rand) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	r.Shuffle(len(net.pending), func(i, j int) {
+		net.pending[i], net.pending[j] = net.pending[j], net.pending[i]
+	})
+}
+
+// sentMessages returns all messages which have been sent and matched filter
+func (net *simNetwork) sentMessages(filter func(*simMsg) bool) []*simMsg {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	var msgs []*simMsg
+	for _, msg := range net.sent {
+		if filter(msg) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// waitForHeight waits until all the given nodes have committed block number height
+func (net *simNetwork) waitForHeight(height uint64, timeout time.Duration, nodes ...*simNode) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		done := true
+		for _, node := range nodes {
+			if node.head().NumberU64() < height {
+				done = false
+				break
+			}
+		}
+		if done {
+			return
+		}
+		if !net.auto {
+			net.deliver(nil)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, node := range nodes {
+		net.t.Logf("node %s is at height %d", node.address.Hex(), node.head().NumberU64())
+	}
+	net.t.Fatalf("timeout waiting for height %d", height)
+}
+
+// restart stops the core of node, copies the blocks it misses from the longest chain of the network
+// as the downloader would, and starts a new core which has lost the state of the previous one
+func (net *simNetwork) restart(node *simNode) {
+	_ = node.core.Stop()
+	var longest *simNode
+	for _, addr := range net.order {
+		if other := net.nodes[addr]; longest == nil || other.head().NumberU64() > longest.head().NumberU64() {
+			longest = other
+		}
+	}
+	head := node.head()
+	if ancestor := longest.blockAt(head.NumberU64()); ancestor.Hash() != head.Hash() {
+		net.t.Errorf("node %s has block %x at %d, the longest chain has %x", node.address.Hex(), head.Hash(), head.NumberU64(), ancestor.Hash())
+	}
+	for number := head.NumberU64() + 1; number <= longest.head().NumberU64(); number++ {
+		node.mu.Lock()
+		node.chain = append(node.chain, longest.blockAt(number))
+		node.mu.Unlock()
+	}
+	node.core = New(node, node.config, WithoutRebroadcast()).(*core)
+	require.NoError(net.t, node.start())
+}
+
+// simNode is a simulated validator. It implements tendermint.Backend for its core,
+// committing blocks to an in-memory chain and producing a new block for every height as the miner does.
+type simNode struct {
+	net        *simNetwork
+	core       *core
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+	validators []common.Address
+	config     *tendermint.Config
+	eventMux   *event.TypeMux
+
+	mu    *sync.RWMutex
+	chain []*types.Block
+	skew  time.Duration // the offset of the node's clock
+
+	blockDelay time.Duration // the time the miner takes to assemble a block
+}
+
+func (n *simNode) start() error {
+	if err := n.core.Start(); err != nil {
+		return err
+	}
+	n.postNewBlock()
+	return nil
+}
+
+func (n *simNode) receive(payload []byte) {
+	if err := n.eventMux.Post(tendermint.MessageEvent{Payload: payload}); err != nil {
+		n.net.t.Logf("failed to post message to node %s: %v", n.address.Hex(), err)
+	}
+}
+
+// head returns the last committed block of the node
+func (n *simNode) head() *types.Block {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.chain[len(n.chain)-1]
+}
+
+// blockAt returns the committed block at number, or nil
+func (n *simNode) blockAt(number uint64) *types.Block {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if number >= uint64(len(n.chain)) {
+		return nil
+	}
+	return n.chain[number]
+}
+
+// setSkew sets the offset of the node's clock
+func (n *simNode) setSkew(skew time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.skew = skew
+}
+
+// now returns the time of the node's clock
+func (n *simNode) now() time.Time {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return time.Now().Add(n.skew)
+}
+
+// postNewBlock posts a block on top of the current head to core, as the miner does after each commit
+func (n *simNode) postNewBlock() {
+	parent := n.head()
+	header := &types.Header{
+		Coinbase:   n.address,
+		ParentHash: parent.Hash(),
+		TxHash:     types.EmptyRootHash,
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Difficulty: big.NewInt(1),
+		MixDigest:  types.TendermintDigest,
+		Time:       uint64(n.now().UnixNano()),
+	}
+	extra, err := tests_utils.PrepareExtra(header)
+	require.NoError(n.net.t, err)
+	header.Extra = extra
+	go func() {
+		time.Sleep(n.blockDelay)
+		_ = n.eventMux.Post(tendermint.NewBlockEvent{Block: types.NewBlockWithHeader(header)})
+	}()
+}
+
+// Address implements tendermint.Backend.Address
+func (n *simNode) Address() common.Address {
+	return n.address
+}
+
+// EventMux implements tendermint.Backend.EventMux
+func (n *simNode) EventMux() *event.TypeMux {
+	return n.eventMux
+}
+
+// Sign implements tendermint.Backend.Sign
+func (n *simNode) Sign(data []byte) ([]byte, error) {
+	return crypto.Sign(crypto.Keccak256(data), n.privateKey)
+}
+
+// Gossip implements tendermint.Backend.Gossip
+func (n *simNode) Gossip(valSet tendermint.ValidatorSet, _ *big.Int, _ int64, _ uint64, payload []byte) error {
+	for _, val := range valSet.List() {
+		if val.Address() != n.address {
+			n.net.send(n.address, val.Address(), payload)
+		}
+	}
+	for _, addr := range n.net.observers {
+		if addr != n.address {
+			n.net.send(n.address, addr, payload)
+		}
+	}
+	return nil
+}
+
+// Broadcast implements tendermint.Backend.Broadcast
+func (n *simNode) Broadcast(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
+	if err := n.Gossip(valSet, blockNumber, round, msgType, payload); err != nil {
+		return err
+	}
+	go n.receive(payload)
+	return nil
+}
+
+// Multicast implements tendermint.Backend.Multicast
+func (n *simNode) Multicast(targets map[common.Address]bool, payload []byte) error {
+	for addr := range targets {
+		if addr != n.address {
+			n.net.send(n.address, addr, payload)
+		}
+	}
+	return nil
+}
+
+// Validators implements tendermint.Backend.Validators
+func (n *simNode) Validators(blockNumber *big.Int) tendermint.ValidatorSet {
+	return validator.NewSet(n.validators, tendermint.RoundRobin, blockNumber.Int64())
+}
+
+// CurrentHeadBlock implements tendermint.Backend.CurrentHeadBlock
+func (n *simNode) CurrentHeadBlock() *types.Block {
+	return n.head()
+}
+
+// FindExistingPeers implements tendermint.Backend.FindExistingPeers
+func (n *simNode) FindExistingPeers(valSet tendermint.ValidatorSet) map[common.Address]consensus.Peer {
+	peers := make(map[common.Address]consensus.Peer)
+	for _, val := range valSet.List() {
+		if val.Address() != n.address {
+			peers[val.Address()] = &tests_utils.MockPeer{}
+		}
+	}
+	return peers
+}
+
+// Commit implements tendermint.Backend.Commit, it inserts the block into the node's chain and moves core to the next height
+func (n *simNode) Commit(block *types.Block, round int64) {
+	n.mu.Lock()
+	head := n.chain[len(n.chain)-1]
+	if block.NumberU64() != head.NumberU64()+1 || block.ParentHash() != head.Hash() {
+		n.mu.Unlock()
+		n.net.t.Errorf("node %s committed block %d which is not on top of head %d", n.address.Hex(), block.NumberU64(), head.NumberU64())
+		return
+	}
+	n.chain = append(n.chain, block)
+	n.mu.Unlock()
+
+	n.net.mu.Lock()
+	if committed, ok := n.net.commits[block.NumberU64()]; ok && committed != block.Hash() {
+		n.net.t.Errorf("node %s committed block %x at %d, another node committed %x", n.address.Hex(), block.Hash(), block.NumberU64(), committed)
+	} else {
+		n.net.commits[block.NumberU64()] = block.Hash()
+	}
+	n.net.mu.Unlock()
+
+	go func() {
+		_ = n.eventMux.Post(tendermint.FinalCommittedEvent{BlockNumber: block.Number()})
+	}()
+	n.postNewBlock()
+}
+
+// Cancel implements tendermint.Backend.Cancel
+func (n *simNode) Cancel(block *types.Block) {}
+
+// VerifyProposalHeader implements tendermint.Backend.VerifyProposalHeader, it rejects the proposals
+// whose timestamp is ahead of the node's clock by more than the max drift of the network
+func (n *simNode) VerifyProposalHeader(header *types.Header) error {
+	if n.net.maxDrift > 0 && header.Time > uint64(n.now().Add(n.net.maxDrift).UnixNano()) {
+		return tendermint.ErrFutureTimestamp
+	}
+	return nil
+}
+
+// VerifyProposalBlock implements tendermint.Backend.VerifyProposalBlock
+func (n *simNode) VerifyProposalBlock(block *types.Block) error {
+	return nil
+}
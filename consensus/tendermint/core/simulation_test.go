@@ -0,0 +1,268 @@
+package core
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// requireSameChain asserts that all nodes have committed the same blocks up to height
+func requireSameChain(t *testing.T, net *simNetwork, height uint64) {
+	first := net.node(0)
+	for number := uint64(1); number <= height; number++ {
+		expected := first.blockAt(number)
+		require.NotNil(t, expected)
+		for i := 1; i < len(net.order); i++ {
+			block := net.node(i).blockAt(number)
+			require.NotNil(t, block)
+			require.Equal(t, expected.Hash(), block.Hash(), "node %d has a different block at %d", i, number)
+		}
+	}
+}
+
+func TestSimulation_CommitHeights(t *testing.T) {
+	net := newSimNetwork(t, 4, tests_utils.DefaultTestConfig, true)
+	net.start()
+	defer net.stop()
+
+	var nodes []*simNode
+	for i := range net.order {
+		nodes = append(nodes, net.node(i))
+	}
+	net.waitForHeight(3, 10*time.Second, nodes...)
+	requireSameChain(t, net, 3)
+}
+
+func TestSimulation_RoundSkipWhenProposerIsOffline(t *testing.T) {
+	net := newSimNetwork(t, 4, tests_utils.DefaultTestConfig, true)
+	// the proposer of height 1, round 0 is the first validator
+	offline := net.node(0)
+	net.isolate(offline.address)
+	net.start()
+	defer net.stop()
+
+	online := []*simNode{net.node(1), net.node(2), net.node(3)}
+	net.waitForHeight(1, 10*time.Second, online...)
+
+	block := online[0].blockAt(1)
+	require.NotEqual(t, offline.address, block.Coinbase())
+	for _, node := range online {
+		require.Equal(t, block.Hash(), node.blockAt(1).Hash())
+	}
+	require.Equal(t, uint64(0), offline.head().NumberU64())
+}
+
+func TestSimulation_ShuffledDelivery(t *testing.T) {
+	net := newSimNetwork(t, 4, tests_utils.DefaultTestConfig, false)
+	net.start()
+	defer net.stop()
+
+	var (
+		r        = rand.New(rand.NewSource(1))
+		nodes    []*simNode
+		deadline = time.Now().Add(10 * time.Second)
+	)
+	for i := range net.order {
+		nodes = append(nodes, net.node(i))
+	}
+	for time.Now().Before(deadline) {
+		done := true
+		for _, node := range nodes {
+			if node.head().NumberU64() < 2 {
+				done = false
+			}
+		}
+		if done {
+			break
+		}
+		net.shuffle(r)
+		net.deliver(nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+	requireSameChain(t, net, 2)
+}
+
+func TestSimulation_Snapshot(t *testing.T) {
+	net := newSimNetwork(t, 4, tests_utils.DefaultTestConfig, true)
+	net.start()
+	defer net.stop()
+
+	node := net.node(0)
+	net.waitForHeight(1, 10*time.Second, node)
+
+	snapshot := node.core.Snapshot()
+	require.Equal(t, len(net.order), len(snapshot.Validators))
+	require.NotNil(t, snapshot.Proposer)
+	require.True(t, snapshot.BlockNumber.Cmp(common.Big1) >= 0)
+	require.NotEmpty(t, snapshot.Step)
+	_, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+}
+
+func TestSimulation_Observer(t *testing.T) {
+	net := newSimNetwork(t, 4, tests_utils.DefaultTestConfig, true)
+	observer := net.addObserver(tests_utils.DefaultTestConfig)
+	net.start()
+	require.NoError(t, observer.start())
+	defer net.stop()
+
+	net.waitForHeight(2, 10*time.Second, observer)
+	net.waitForHeight(2, 10*time.Second, net.node(0))
+	for number := uint64(1); number <= 2; number++ {
+		require.Equal(t, net.node(0).blockAt(number).Hash(), observer.blockAt(number).Hash())
+	}
+	// the observer never sends any message
+	require.Empty(t, net.sentMessages(func(m *simMsg) bool {
+		return m.from == observer.address
+	}))
+}
+
+func TestSimulation_Pause(t *testing.T) {
+	net := newSimNetwork(t, 4, tests_utils.DefaultTestConfig, true)
+	paused := net.node(3)
+	require.True(t, paused.core.SetPaused(true))
+	require.False(t, paused.core.SetPaused(true))
+	net.start()
+	defer net.stop()
+
+	// the paused validator follows the chain the others commit without sending any message
+	net.waitForHeight(2, 10*time.Second, paused, net.node(0))
+	for number := uint64(1); number <= 2; number++ {
+		require.Equal(t, net.node(0).blockAt(number).Hash(), paused.blockAt(number).Hash())
+	}
+	sentByPaused := func(m *simMsg) bool {
+		return m.from == paused.address
+	}
+	require.Empty(t, net.sentMessages(sentByPaused))
+	require.True(t, paused.core.Status().Paused)
+
+	// once resumed, it votes again
+	require.True(t, paused.core.SetPaused(false))
+	height := paused.head().NumberU64()
+	net.waitForHeight(height+2, 10*time.Second, paused)
+	require.NotEmpty(t, net.sentMessages(sentByPaused))
+}
+
+func TestSimulation_ProposerHeartbeat(t *testing.T) {
+	// run returns the proposer of height 1, round 0 and the coinbase of the block committed at height 1
+	run := func(heartbeatInterval time.Duration) (common.Address, common.Address) {
+		config := *tests_utils.DefaultTestConfig
+		config.TimeoutPropose = 300 * time.Millisecond
+		config.HeartbeatInterval = heartbeatInterval
+		config.HeartbeatExtension = time.Second
+		net := newSimNetwork(t, 4, &config, true)
+		// the proposer of height 1, round 0 is the first validator, its miner is slower than the propose timeout
+		proposer := net.node(0)
+		proposer.blockDelay = 600 * time.Millisecond
+		net.start()
+		defer net.stop()
+
+		net.waitForHeight(1, 10*time.Second, net.node(1), net.node(2), net.node(3))
+		return proposer.address, net.node(1).blockAt(1).Coinbase()
+	}
+
+	// without heartbeats, the validators prevote nil and the block of the next round's proposer is committed
+	proposer, coinbase := run(0)
+	require.NotEqual(t, proposer, coinbase)
+
+	// the heartbeats extend the propose timeout until the block of the slow proposer arrives
+	proposer, coinbase = run(50 * time.Millisecond)
+	require.Equal(t, proposer, coinbase)
+}
+
+// runLockSimulation locks a validator on the block proposed at round 0 of height 1, then lets the block proposed at
+// round 1 gather +2/3 prevotes without being committed. The locked validator receives all of these prevotes only if
+// seesPolka. It returns the blocks proposed at rounds 0 and 1, and the votes of the locked validator: its precommit at
+// round 0 and its prevotes at rounds 1 and 2, the round it proposes at.
+func runLockSimulation(t *testing.T, seesPolka bool) (proposed [2]common.Hash, votes [3]common.Hash) {
+	config := *tests_utils.DefaultTestConfig
+	// the validators which receive the proposal must prevote it, the one which doesn't waits for the timeout
+	config.TimeoutPropose = 500 * time.Millisecond
+	net := newSimNetwork(t, 4, &config, true)
+	// the proposers of rounds 0, 1 and 2 are the first three validators
+	var (
+		p0, p1 = net.node(0).address, net.node(1).address
+		locked = net.node(2)
+		other  = net.node(3).address
+	)
+	net.drop(func(m *simMsg) bool {
+		msg, blockNumber, round := m.decode()
+		if msg.Code == msgCatchUpRequest || msg.Code == msgCatchUpReply {
+			return true
+		}
+		if blockNumber == nil {
+			return false
+		}
+		switch {
+		case round == 0 && msg.Code == msgPropose:
+			// the other validator prevotes nil, so that only the locked validator sees +2/3 prevotes for the proposal
+			return m.to == other
+		case round == 0 && msg.Code == msgPrevote:
+			return m.from == locked.address && (m.to == p0 || m.to == p1)
+		case round == 1 && msg.Code == msgPrevote:
+			// only p0, and the locked validator if it sees the polka, receive the +2/3 prevotes,
+			// the others precommit nil and the block is not committed
+			return m.from == p0 && (m.to == p1 || m.to == other || (m.to == locked.address && !seesPolka))
+		}
+		return false
+	})
+	net.start()
+	defer net.stop()
+
+	var (
+		sent = func(from common.Address, code uint64, round int64) (message, bool) {
+			for _, m := range net.sentMessages(func(m *simMsg) bool { return m.from == from }) {
+				if msg, blockNumber, msgRound := m.decode(); msg.Code == code && blockNumber.Uint64() == 1 && msgRound == round {
+					return msg, true
+				}
+			}
+			return message{}, false
+		}
+		vote = func(code uint64, round int64) common.Hash {
+			msg, ok := sent(locked.address, code, round)
+			require.True(t, ok, "no vote %d at round %d", code, round)
+			var v Vote
+			require.NoError(t, rlp.DecodeBytes(msg.Msg, &v))
+			return *v.BlockHash
+		}
+		deadline = time.Now().Add(10 * time.Second)
+	)
+	for _, ok := sent(locked.address, msgPrevote, 2); !ok; _, ok = sent(locked.address, msgPrevote, 2) {
+		require.True(t, time.Now().Before(deadline), "the locked validator did not prevote at round 2")
+		time.Sleep(10 * time.Millisecond)
+	}
+	for round, proposer := range []common.Address{p0, p1} {
+		msg, ok := sent(proposer, msgPropose, int64(round))
+		require.True(t, ok, "no proposal at round %d", round)
+		var proposal Proposal
+		require.NoError(t, rlp.DecodeBytes(msg.Msg, &proposal))
+		proposed[round] = proposal.Block.Hash()
+	}
+	return proposed, [3]common.Hash{vote(msgPrecommit, 0), vote(msgPrevote, 1), vote(msgPrevote, 2)}
+}
+
+func TestSimulation_UnlockOnLaterPolka(t *testing.T) {
+	proposed, votes := runLockSimulation(t, true)
+	require.NotEqual(t, proposed[0], proposed[1])
+	// locked at round 0, the validator prevotes the locked block at round 1
+	require.Equal(t, proposed[0], votes[0])
+	require.Equal(t, proposed[0], votes[1])
+	// the +2/3 prevotes of round 1 for another block unlock it, it prevotes that block at round 2
+	require.Equal(t, proposed[1], votes[2])
+}
+
+func TestSimulation_StayLockedWithoutPolka(t *testing.T) {
+	proposed, votes := runLockSimulation(t, false)
+	require.NotEqual(t, proposed[0], proposed[1])
+	require.Equal(t, proposed[0], votes[0])
+	require.Equal(t, proposed[0], votes[1])
+	// without +2/3 prevotes for the other block, the validator stays locked
+	require.Equal(t, proposed[0], votes[2])
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// StateSnapshot is a copy of the full consensus state of core at one point in time.
+// It is meant to be attached to bug reports when a validator is stuck.
+type StateSnapshot struct {
+	BlockNumber     *big.Int  `json:"block_number"`
+	Round           int64     `json:"round"`
+	Step            string    `json:"step"`
+	StartTime       time.Time `json:"start_time"`
+	CommitTime      time.Time `json:"commit_time"`
+	CommitRound     int64     `json:"commit_round"`
+	PrecommitWaited bool      `json:"precommit_waited"`
+
+	BlockHash       *common.Hash `json:"block_hash"`
+	LockedRound     int64        `json:"locked_round"`
+	LockedBlockHash *common.Hash `json:"locked_block_hash"`
+	ValidRound      int64        `json:"valid_round"`
+	ValidBlockHash  *common.Hash `json:"valid_block_hash"`
+
+	Proposal   *ProposalSnapshot          `json:"proposal"`
+	Prevotes   map[int64]*VoteSetSnapshot `json:"prevotes"`
+	Precommits map[int64]*VoteSetSnapshot `json:"precommits"`
+
+	Validators []common.Address `json:"validators"`
+	Proposer   *common.Address  `json:"proposer"`
+
+	PendingTimeout       *timeoutInfo `json:"pending_timeout"`
+	FutureMessages       int          `json:"future_messages"`
+	FutureProposalRounds []int64      `json:"future_proposal_rounds"`
+}
+
+// ProposalSnapshot describes the proposal received at the current round
+type ProposalSnapshot struct {
+	BlockHash   common.Hash `json:"block_hash"`
+	BlockNumber *big.Int    `json:"block_number"`
+	Round       int64       `json:"round"`
+	POLRound    int64       `json:"pol_round"`
+	TraceID     string      `json:"trace_id"`
+}
+
+// VoteSetSnapshot describes the votes received for a round
+type VoteSetSnapshot struct {
+	Votes         map[common.Address]common.Hash `json:"votes"`
+	Maj23         *common.Hash                   `json:"maj23"`
+	TotalReceived int                            `json:"total_received"`
+}
+
+// Snapshot returns a copy of the consensus state. It holds core's lock so that no message or timeout
+// is handled while the state is copied.
+func (c *core) Snapshot() *StateSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := &StateSnapshot{
+		Prevotes:       make(map[int64]*VoteSetSnapshot),
+		Precommits:     make(map[int64]*VoteSetSnapshot),
+		PendingTimeout: c.timeout.Pending(),
+		FutureMessages: int(c.futureMessages.Len()),
+	}
+	for round := range c.futureProposals {
+		snapshot.FutureProposalRounds = append(snapshot.FutureProposalRounds, round)
+	}
+	sort.Slice(snapshot.FutureProposalRounds, func(i, j int) bool {
+		return snapshot.FutureProposalRounds[i] < snapshot.FutureProposalRounds[j]
+	})
+	if c.valSet != nil {
+		for _, val := range c.valSet.List() {
+			snapshot.Validators = append(snapshot.Validators, val.Address())
+		}
+		if proposer := c.valSet.GetProposer(); proposer != nil {
+			addr := proposer.Address()
+			snapshot.Proposer = &addr
+		}
+	}
+
+	state := c.currentState
+	if state == nil {
+		return snapshot
+	}
+	snapshot.BlockNumber = state.CopyBlockNumber()
+	snapshot.Round = state.Round()
+	snapshot.Step = state.Step().String()
+	snapshot.StartTime = state.startTime
+	snapshot.CommitTime = state.commitTime
+	snapshot.CommitRound = state.commitRound
+	snapshot.PrecommitWaited = state.PrecommitWaited
+	snapshot.BlockHash = blockHashOrNil(state.Block())
+	snapshot.LockedRound = state.LockedRound()
+	snapshot.LockedBlockHash = blockHashOrNil(state.LockedBlock())
+	snapshot.ValidRound = state.ValidRound()
+	snapshot.ValidBlockHash = blockHashOrNil(state.ValidBlock())
+	if proposal := state.ProposalReceived(); proposal != nil {
+		snapshot.Proposal = &ProposalSnapshot{
+			BlockHash:   proposal.Block.Hash(),
+			BlockNumber: proposal.Block.Number(),
+			Round:       proposal.Round,
+			POLRound:    proposal.POLRound,
+			TraceID:     proposal.TraceID,
+		}
+	}
+	for round, ms := range state.PrevotesReceived {
+		snapshot.Prevotes[round] = ms.snapshot()
+	}
+	for round, ms := range state.PrecommitsReceived {
+		snapshot.Precommits[round] = ms.snapshot()
+	}
+	return snapshot
+}
+
+func (ms *messageSet) snapshot() *VoteSetSnapshot {
+	ms.messagesMu.Lock()
+	defer ms.messagesMu.Unlock()
+	snapshot := &VoteSetSnapshot{
+		Votes:         make(map[common.Address]common.Hash),
+		TotalReceived: ms.totalReceived,
+	}
+	for addr, vote := range ms.voteByAddress {
+		snapshot.Votes[addr] = *vote.BlockHash
+	}
+	if ms.maj23 != nil {
+		maj23 := *ms.maj23
+		snapshot.Maj23 = &maj23
+	}
+	return snapshot
+}
+
+func blockHashOrNil(block *types.Block) *common.Hash {
+	if block == nil {
+		return nil
+	}
+	hash := block.Hash()
+	return &hash
+}
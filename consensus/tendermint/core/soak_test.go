@@ -0,0 +1,130 @@
+package core
+
+import (
+	"flag"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+)
+
+var (
+	soakDuration = flag.Duration("soak", 0, "duration of the soak simulation with partitions, restarts and clock skew, a short run if 0")
+	soakSeed     = flag.Int64("soak.seed", 0, "seed of the faults injected by the soak simulation, the current time if 0")
+)
+
+const (
+	shortSoakDuration = 5 * time.Second
+	soakMaxDrift      = 200 * time.Millisecond
+	soakHealTimeout   = 30 * time.Second // the time the network has to commit again once the faults are removed
+)
+
+// TestSimulation_Soak runs a network of validators while injecting partitions, validator restarts and clock skew,
+// each fault is removed after a while and the network must then keep committing the same blocks on every node.
+// Run it for hours with: go test -run TestSimulation_Soak -soak 4h ./consensus/tendermint/core
+func TestSimulation_Soak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak simulation skipped in short mode")
+	}
+	duration := *soakDuration
+	if duration == 0 {
+		duration = shortSoakDuration
+	}
+	seed := *soakSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.Logf("soak simulation for %v with seed %d", duration, seed)
+
+	net := newSimNetwork(t, 7, tests_utils.DefaultTestConfig, true)
+	net.maxDrift = soakMaxDrift
+	net.start()
+	defer net.stop()
+
+	var (
+		r        = rand.New(rand.NewSource(seed))
+		nodes    = make([]*simNode, len(net.order))
+		deadline = time.Now().Add(duration)
+		faults   int
+	)
+	for i := range net.order {
+		nodes[i] = net.node(i)
+	}
+	for time.Now().Before(deadline) && !t.Failed() {
+		faulty := nodes[r.Intn(len(nodes))]
+		switch r.Intn(3) {
+		case 0:
+			// split the validators in two random groups, at most one of them has a quorum
+			addrs := append([]common.Address{}, net.order...)
+			r.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+			split := 1 + r.Intn(len(addrs)-1)
+			t.Logf("fault %d: partition %d/%d", faults, split, len(addrs)-split)
+			net.partition(addrs[:split], addrs[split:])
+		case 1:
+			t.Logf("fault %d: restart %s", faults, faulty.address.Hex())
+			net.restart(faulty)
+		case 2:
+			// the clock is skewed beyond the max drift, either the others or the faulty node reject the proposals
+			skew := 2 * soakMaxDrift
+			if r.Intn(2) == 0 {
+				skew = -skew
+			}
+			t.Logf("fault %d: skew %s by %v", faults, faulty.address.Hex(), skew)
+			faulty.setSkew(skew)
+		}
+		time.Sleep(time.Duration(200+r.Intn(800)) * time.Millisecond)
+
+		net.heal()
+		faulty.setSkew(0)
+		waitForSyncedHeight(net, nodes, highestHead(nodes)+2, soakHealTimeout)
+		faults++
+	}
+	syncLagging(net, nodes, highestHead(nodes))
+	requireSameChain(t, net, lowestHead(nodes))
+	t.Logf("soak simulation committed %d blocks with %d faults", highestHead(nodes), faults)
+}
+
+// waitForSyncedHeight waits until all the nodes have committed block number height, the nodes which fell behind
+// the others and cannot catch up on the consensus messages sync the missing blocks meanwhile
+func waitForSyncedHeight(net *simNetwork, nodes []*simNode, height uint64, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if lowestHead(nodes) >= height {
+			return
+		}
+		syncLagging(net, nodes, highestHead(nodes)-1)
+		time.Sleep(500 * time.Millisecond)
+	}
+	net.waitForHeight(height, 0, nodes...)
+}
+
+// syncLagging restarts the nodes behind height with the blocks they miss
+func syncLagging(net *simNetwork, nodes []*simNode, height uint64) {
+	for _, node := range nodes {
+		if node.head().NumberU64() < height {
+			net.restart(node)
+		}
+	}
+}
+
+func highestHead(nodes []*simNode) uint64 {
+	var height uint64
+	for _, node := range nodes {
+		if number := node.head().NumberU64(); number > height {
+			height = number
+		}
+	}
+	return height
+}
+
+func lowestHead(nodes []*simNode) uint64 {
+	height := nodes[0].head().NumberU64()
+	for _, node := range nodes[1:] {
+		if number := node.head().NumberU64(); number < height {
+			height = number
+		}
+	}
+	return height
+}
@@ -4,6 +4,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 )
@@ -43,6 +44,8 @@ func (c *core) getInitializedState() *roundState {
 		proposalReceived,
 		step, commitRound,
 	)
+	rs.hooks = c.hooks
+	rs.maxVoteRounds = c.config.MaxVoteRounds
 
 	//TODO: timeout setup
 	return rs
@@ -68,26 +71,39 @@ func (c *core) updateStateForNewblock() {
 		}
 	}
 
+	// keep the votes of the committed block to serve the peers still at it, the message sets are then dropped
+	if state.commitRound > -1 {
+		c.pastVotes.retain(state)
+	}
+
 	// Update all roundState's fields
 	height := state.BlockNumber()
+	committed := new(big.Int).Set(height)
 	state.SetView(&tendermint.View{
 		Round:       0,
 		BlockNumber: height.Add(height, big.NewInt(1)),
 	})
 
-	if state.commitTime.IsZero() {
+	commitTime := state.commitTime
+	if commitTime.IsZero() {
 		// "Now" makes it easier to sync up dev nodes.
 		// We add timeoutCommit to allow transactions
 		// to be gathered for the first block.
 		// And alternative solution that relies on clocks:
-		state.startTime = c.config.Commit(time.Now())
+		commitTime = time.Now()
+	}
+	if c.config.TargetBlockInterval > 0 {
+		state.startTime = commitTime.Add(c.pacer.commitTimeout(c.config.TargetBlockInterval, committed, commitTime, c.config.TimeoutCommit))
 	} else {
-		state.startTime = c.config.Commit(state.commitTime)
+		state.startTime = c.config.Commit(commitTime)
 	}
 
 	state.clearPreviousRoundData()
 	c.currentState = state
 	c.valSet = c.backend.Validators(c.CurrentState().BlockNumber())
 	c.futureProposals = make(map[int64]message)
+	c.proposalMsgs = make(map[int64]message)
+	c.reportedAmnesias = make(map[common.Address]bool)
+	c.rejectedHeaders = make(map[common.Hash]*types.Header)
 	logger.Infow("updated to new block", "new_block_number", state.BlockNumber())
 }
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// Status is a summary of the consensus state of core, meant for operators and load balancers.
+// Unlike StateSnapshot it does not copy the votes.
+type Status struct {
+	BlockNumber   *big.Int
+	Round         int64
+	Step          RoundStepType
+	StepStartTime time.Time // when the current step was entered
+	Proposer      common.Address
+	IsValidator   bool         // true if this node is a validator of BlockNumber
+	IsProposer    bool         // true if this node is the proposer of the current round
+	Proposal      *types.Block // the block proposed in the current round, nil until it is received
+	Paused        bool         // true if this node doesn't propose nor vote, see Engine.SetPaused
+}
+
+// Status returns the summary of the consensus state, nil if core has no state yet
+func (c *core) Status() *Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.currentState
+	if state == nil {
+		return nil
+	}
+	status := &Status{
+		BlockNumber:   state.CopyBlockNumber(),
+		Round:         state.Round(),
+		Step:          state.Step(),
+		StepStartTime: state.stepTime,
+		Paused:        c.Paused(),
+	}
+	if proposal := state.ProposalReceived(); proposal != nil {
+		status.Proposal = proposal.Block
+	}
+	if c.valSet != nil {
+		if proposer := c.valSet.GetProposer(); proposer != nil {
+			status.Proposer = proposer.Address()
+		}
+		index, _ := c.valSet.GetByAddress(c.getAddress())
+		status.IsValidator = index != -1
+		status.IsProposer = c.valSet.IsProposer(c.getAddress())
+	}
+	return status
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+)
+
+func TestCore_Status(t *testing.T) {
+	net := newSimNetwork(t, 4, tests_utils.DefaultTestConfig, true)
+	observer := net.addObserver(tests_utils.DefaultTestConfig)
+	node := net.node(0)
+	require.Nil(t, node.core.Status())
+
+	net.start()
+	require.NoError(t, observer.start())
+	defer net.stop()
+	net.waitForHeight(1, 10*time.Second, node, observer)
+
+	status := node.core.Status()
+	require.NotNil(t, status)
+	require.True(t, status.BlockNumber.Uint64() >= 2)
+	require.True(t, status.IsValidator)
+	require.Contains(t, node.validators, status.Proposer)
+	require.Equal(t, status.Proposer == node.address, status.IsProposer)
+	require.False(t, status.StepStartTime.IsZero())
+	require.False(t, status.StepStartTime.After(time.Now()))
+
+	observerStatus := observer.core.Status()
+	require.NotNil(t, observerStatus)
+	require.False(t, observerStatus.IsValidator)
+	require.False(t, observerStatus.IsProposer)
+}
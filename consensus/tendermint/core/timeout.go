@@ -7,7 +7,7 @@ import (
 
 	"github.com/pkg/errors"
 
-	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 )
 
 const (
@@ -23,6 +23,7 @@ type TimeoutTicker interface {
 	Stop() error
 	Chan() <-chan timeoutInfo       // on which to receive a timeout
 	ScheduleTimeout(ti timeoutInfo) // reset the timer
+	Pending() *timeoutInfo          // the scheduled timeout which has not fired yet, or nil
 }
 
 // timeoutInfo keep track about a timeout job
@@ -75,6 +76,9 @@ type timeoutTicker struct {
 
 	running bool
 	lock    sync.Mutex
+
+	pendingMu sync.Mutex
+	pending   *timeoutInfo // the timeout currently scheduled on timer
 }
 
 // NewTimeoutTicker returns a new TimeoutTicker that's ready to use
@@ -128,6 +132,23 @@ func (tt *timeoutTicker) Chan() <-chan timeoutInfo {
 	return tt.tockChan
 }
 
+// Pending returns a copy of the scheduled timeout which has not fired yet, or nil if there is none.
+func (tt *timeoutTicker) Pending() *timeoutInfo {
+	tt.pendingMu.Lock()
+	defer tt.pendingMu.Unlock()
+	if tt.pending == nil {
+		return nil
+	}
+	pending := *tt.pending
+	return &pending
+}
+
+func (tt *timeoutTicker) setPending(ti *timeoutInfo) {
+	tt.pendingMu.Lock()
+	defer tt.pendingMu.Unlock()
+	tt.pending = ti
+}
+
 // stop the timer and drain if necessary
 func (tt *timeoutTicker) stopTimer() {
 	// Stop() returns false if it was already fired or was stopped
@@ -135,7 +156,7 @@ func (tt *timeoutTicker) stopTimer() {
 		select {
 		case <-tt.timer.C:
 		default:
-			log.Debug("Timer already stopped")
+			tendermint.Logger(tendermint.LogTimeout).Debugw("Timer already stopped")
 		}
 	}
 }
@@ -152,13 +173,14 @@ func (tt *timeoutTicker) timeoutRoutine() {
 		abort = make(chan struct{})
 	)
 	tt.wg = new(sync.WaitGroup)
+	logger := tendermint.Logger(tendermint.LogTimeout)
 	//TODO: DO we need mutex for this?
 	for {
 		select {
 		case newti := <-tt.tickChan:
 			// ignore tickers for old height/round/step
 			if newti.earlierOrEqual(ti) {
-				log.Info("timeout ignore: New ticker is earlier or equal to current ticker",
+				logger.Infow("timeout ignore: New ticker is earlier or equal to current ticker",
 					"new_ticker_block_number", newti.BlockNumber, "current_ticker_block_number", ti.BlockNumber,
 					"new_ticker_round", newti.Round, "current_ticker_round", ti.Round,
 					"new_ticker_step", newti.Step.String(), "current_ticker_step", ti.Step)
@@ -170,10 +192,12 @@ func (tt *timeoutTicker) timeoutRoutine() {
 			// update timeoutInfo and reset timer
 			// NOTE time.Timer allows duration to be non-positive
 			ti = newti
+			tt.setPending(&newti)
 			tt.timer.Reset(ti.Duration)
-			log.Info("Scheduled timeout", "dur", ti.Duration, "block_number", ti.BlockNumber, "round", ti.Round, "step", ti.Step)
+			logger.Infow("Scheduled timeout", "dur", ti.Duration, "block_number", ti.BlockNumber, "round", ti.Round, "step", ti.Step)
 		case <-tt.timer.C:
-			log.Info("Timed out", "dur", ti.Duration, "block_number", ti.BlockNumber, "round", ti.Round, "step", ti.Step)
+			logger.Infow("Timed out", "dur", ti.Duration, "block_number", ti.BlockNumber, "round", ti.Round, "step", ti.Step)
+			tt.setPending(nil)
 			// go routine here guarantees timeoutRoutine doesn't block.
 			// Determinism comes from playback in the handleEvents.
 			// We can eliminate it by merging the timeoutRoutine into receiveRoutine
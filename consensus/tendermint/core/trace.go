@@ -0,0 +1,96 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tracing"
+)
+
+const (
+	traceIDLength = 8
+	// maxTraceIDLength is the length of the hex trace ids, the longer ones received are dropped
+	maxTraceIDLength = 2 * traceIDLength
+)
+
+// newTraceID returns a random hex id to be attached to a new proposal
+func newTraceID() string {
+	b := make([]byte, traceIDLength)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// currentTraceID returns the trace id of the proposal received at the given round,
+// or empty if core has not received a proposal for that round.
+func (c *core) currentTraceID(round int64) string {
+	proposal := c.CurrentState().ProposalReceived()
+	if proposal == nil || proposal.Round != round {
+		return ""
+	}
+	return proposal.TraceID
+}
+
+// WithTracer returns an option to record the spans of the heights, steps, message handling, signing and broadcasts
+func WithTracer(tracer *tracing.Tracer) Option {
+	return func(c *core) error {
+		c.spans = &consensusSpans{tracer: tracer}
+		c.hooks.addStepHook(c.spans.onStep)
+		return nil
+	}
+}
+
+// consensusSpans keeps the spans of the current height and step, the other spans are their children
+type consensusSpans struct {
+	tracer *tracing.Tracer
+
+	mu     sync.Mutex
+	number *big.Int
+	height *tracing.Span
+	step   *tracing.Span
+}
+
+// onStep ends the span of the previous step and starts the one of the new step,
+// the span of the height is restarted when the block number changes
+func (s *consensusSpans) onStep(transition StepTransition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.step.End()
+	if s.number == nil || s.number.Cmp(transition.BlockNumber) != 0 {
+		s.height.End()
+		s.number = transition.BlockNumber
+		s.height = s.tracer.StartTrace(tracing.HeightTraceID(s.number.Uint64()), "height",
+			tracing.Int64("height", s.number.Int64()))
+	}
+	s.step = s.tracer.Start(s.height, transition.Step.String(), tracing.Int64("round", transition.Round))
+}
+
+// start starts a span child of the current step
+func (s *consensusSpans) start(name string, attrs ...tracing.Attribute) *tracing.Span {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parent := s.step
+	if parent == nil {
+		parent = s.height
+	}
+	return s.tracer.Start(parent, name, attrs...)
+}
+
+// startSpan starts a span child of the current step, it returns nil if core has no tracer
+func (c *core) startSpan(name string, attrs ...tracing.Attribute) *tracing.Span {
+	if c.spans == nil {
+		return nil
+	}
+	return c.spans.start(name, attrs...)
+}
+
+// msgName returns the name of a message code in the spans
+func msgName(code uint64) string {
+	if t, ok := msgTypes[code]; ok {
+		return t.name
+	}
+	return "unknown"
+}
@@ -16,6 +16,10 @@ type Proposal struct {
 	Block    *types.Block
 	Round    int64
 	POLRound int64
+	// TraceID is generated by the proposer and carried by the votes on this proposal
+	// so that the messages of a round can be correlated across validators' logs.
+	// It is not signed nor encoded with the proposal, the message carries it, see message.TraceID.
+	TraceID string
 }
 
 func (p *Proposal) EncodeRLP(w io.Writer) error {
@@ -55,6 +59,9 @@ type Vote struct {
 	BlockNumber *big.Int
 	Round       int64
 	Seal        []byte
+	// TraceID is the trace id of the proposal the voter received at this round, empty if there was none.
+	// Like the trace id of a proposal, the message carries it.
+	TraceID string
 }
 
 func (v *Vote) EncodeRLP(w io.Writer) error {
@@ -122,3 +129,39 @@ type CatchUpReplyMsg struct {
 	BlockNumber *big.Int
 	Payloads    [][]byte
 }
+
+// Heartbeat tells the validators that the proposer of a round is alive and still assembling its block
+type Heartbeat struct {
+	BlockNumber *big.Int
+	Round       int64
+	Sequence    uint64 // the number of heartbeats sent before in the round, so that peers don't drop them as duplicates
+}
+
+func (msg *Heartbeat) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, []interface{}{
+		msg.BlockNumber,
+		uint64(msg.Round),
+		msg.Sequence,
+	})
+}
+
+func (msg *Heartbeat) DecodeRLP(s *rlp.Stream) error {
+	var vs struct {
+		BlockNumber *big.Int
+		Round       uint64
+		Sequence    uint64
+	}
+	if err := s.Decode(&vs); err != nil {
+		return err
+	}
+	msg.BlockNumber = vs.BlockNumber
+	msg.Round = int64(vs.Round)
+	msg.Sequence = vs.Sequence
+	return nil
+}
+
+// VoteBatchMsg packs the signed votes of a block number sent to a peer at once, see EncodeVoteBatch
+type VoteBatchMsg struct {
+	BlockNumber *big.Int
+	Payloads    [][]byte
+}
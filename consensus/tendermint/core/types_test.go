@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
@@ -40,3 +41,45 @@ func TestCatchUpReplyMsg_DecodeRLP(t *testing.T) {
 	require.Equal(t, payload1, newMsg.Payloads[0])
 	require.Equal(t, payload2, newMsg.Payloads[1])
 }
+
+func TestMessage_TraceID(t *testing.T) {
+	blockHash := common.HexToHash("0x1")
+	data, err := rlp.EncodeToBytes(&Vote{BlockHash: &blockHash, BlockNumber: big.NewInt(5), Round: 2, Seal: []byte{}})
+	require.NoError(t, err)
+	msg := message{Code: msgPrevote, Msg: data, Address: common.HexToAddress("0x2"), Signature: []byte{0x01}}
+	bare, err := rlp.EncodeToBytes(&msg)
+	require.NoError(t, err)
+	signingPayload, err := msg.PayLoadWithoutSignature()
+	require.NoError(t, err)
+
+	msg.TraceID = newTraceID()
+	payload, err := rlp.EncodeToBytes(&msg)
+	require.NoError(t, err)
+	var decodedMsg message
+	require.NoError(t, rlp.DecodeBytes(payload, &decodedMsg))
+	require.Equal(t, msg.TraceID, decodedMsg.TraceID)
+	// the trace id is not signed
+	decodedPayload, err := decodedMsg.PayLoadWithoutSignature()
+	require.NoError(t, err)
+	require.Equal(t, signingPayload, decodedPayload)
+
+	// the message sent bare is the one the older nodes decode
+	require.Equal(t, bare, StripTraceID(payload))
+	require.Equal(t, bare, StripTraceID(bare))
+	var oldMsg struct {
+		Code      uint64
+		Msg       []byte
+		Address   common.Address
+		Signature []byte
+	}
+	require.Error(t, rlp.DecodeBytes(payload, &oldMsg))
+	require.NoError(t, rlp.DecodeBytes(StripTraceID(payload), &oldMsg))
+
+	// a trace id longer than the generated ones is dropped
+	msg.TraceID = msg.TraceID + msg.TraceID
+	payload, err = rlp.EncodeToBytes(&msg)
+	require.NoError(t, err)
+	decodedMsg = message{}
+	require.NoError(t, rlp.DecodeBytes(payload, &decodedMsg))
+	require.Equal(t, "", decodedMsg.TraceID)
+}
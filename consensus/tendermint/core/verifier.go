@@ -0,0 +1,259 @@
+package core
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+const (
+	// verifiedMsgsBuffer is the number of verified messages which can wait for the state machine
+	verifiedMsgsBuffer = 256
+	// defaultMessageQueueSize is the default number of messages waiting to be verified
+	defaultMessageQueueSize = 4096
+	// proposalQueueSize is the number of verified proposals waiting for their block to be decoded
+	proposalQueueSize = 16
+	// proposalDecoders is the number of goroutines decoding the blocks of the proposals
+	proposalDecoders = 2
+)
+
+var (
+	msgQueueGauge     = metrics.NewRegisteredGauge("evr/consensus/tendermint/msgqueue/depth", nil)
+	droppedMsgsMeter  = metrics.NewRegisteredMeter("evr/consensus/tendermint/msgqueue/dropped", nil)
+	backpressureMeter = metrics.NewRegisteredMeter("evr/consensus/tendermint/msgqueue/backpressure", nil)
+
+	proposalDecodeTimer = metrics.NewRegisteredTimer("evr/consensus/tendermint/proposaldecode", nil)
+)
+
+// verifyMsg checks that msg is signed by its Address field.
+// It only reads the static config so it is safe to call without holding c.mu.
+func (c *core) verifyMsg(msg message) error {
+	signer, err := msg.GetAddressFromSignature(c.config)
+	if err != nil {
+		return err
+	}
+	if signer != msg.Address {
+		return ErrSignerMessageMissMatch
+	}
+	return nil
+}
+
+// decodeProposal decodes the proposal of msg and runs the checks which don't depend on the round state:
+// the rounds are not negative, the block is not empty and matches its transactions root.
+// It also caches the hash of the block and sets the trace id of msg on the proposal.
+func decodeProposal(msg message) (*Proposal, error) {
+	var proposal Proposal
+	if err := rlp.DecodeBytes(msg.Msg, &proposal); err != nil {
+		return nil, err
+	}
+	if proposal.Round < 0 || proposal.POLRound < -1 {
+		return nil, ErrInvalidRound
+	}
+	if proposal.Block == nil || proposal.Block.Hash() == emptyBlockHash {
+		return nil, ErrEmptyBlockProposal
+	}
+	if types.DeriveSha(proposal.Block.Transactions()) != proposal.Block.TxHash() {
+		return nil, tendermint.ErrMismatchTxhashes
+	}
+	proposal.TraceID = msg.TraceID
+	return &proposal, nil
+}
+
+// decodeAndVerifyMsg decodes a message payload, checks its signer and decodes its proposal if any.
+// The proposals and votes outside of window are dropped before their signer is recovered, window may be nil.
+func (c *core) decodeAndVerifyMsg(payload []byte, window *msgWindow) (message, error) {
+	msg, err := c.verifyPayload(payload, window)
+	if err != nil {
+		return msg, err
+	}
+	if msg.Code == msgPropose {
+		proposal, err := decodeProposal(msg)
+		if err != nil {
+			return msg, err
+		}
+		msg.proposal = proposal
+	}
+	return msg, nil
+}
+
+// verifyPayload decodes a message payload and checks its signer, the block of a proposal is left encoded
+func (c *core) verifyPayload(payload []byte, window *msgWindow) (message, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return msg, err
+	}
+	if view, ok := msgBlockView(msg); ok {
+		if err := window.check(msg.Address, view); err != nil {
+			return msg, err
+		}
+	}
+	return msg, c.verifyMsg(msg)
+}
+
+// verifyMessages decodes the messages from peers and recovers their signers as they arrive,
+// ahead of the state machine. Only verified messages are passed to handleEvents, so the signature
+// recovery runs in parallel with the step transitions instead of inside them. The proposals are also decoded
+// and checked by decodeProposal, so handlePropose gets the block of a large proposal ready to be verified.
+// The votes of rounds we haven't entered yet are then indexed in the round state by handlePrevote/handlePrecommit,
+// and the messages of future blocks are queued already verified, so entering a round or a step only looks up
+// the vote counts of its messageSet.
+//
+// The messages wait in a queue of config.MessageQueueSize for a pool of config.VerifyWorkers goroutines.
+// Once the queue is full the messages of the peers are dropped, and counted, rather than piling up in memory,
+// the messages of this node wait for a slot. The blocks of the verified proposals are decoded by a pool of their own,
+// so that decoding a large block delays neither the votes queued behind it nor the state machine.
+func (c *core) verifyMessages(messages *event.TypeMuxSubscription, quit <-chan struct{}) {
+	defer c.handlerWg.Done()
+	// the round state is owned by handleEvents, so the verifier does not log with it
+	logger := tendermint.Logger(tendermint.LogCore)
+	var (
+		queue     = make(chan tendermint.MessageEvent, c.messageQueueSize())
+		proposals = make(chan message, proposalQueueSize)
+		workers   sync.WaitGroup
+		decoders  sync.WaitGroup
+	)
+	for i := 0; i < proposalDecoders; i++ {
+		decoders.Add(1)
+		go func() {
+			defer decoders.Done()
+			c.decodeProposals(proposals, quit)
+		}()
+	}
+	for i := 0; i < c.verifyWorkers(); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			c.verifyQueuedMessages(queue, proposals, quit)
+		}()
+	}
+	defer func() {
+		close(queue)
+		workers.Wait()
+		close(proposals)
+		decoders.Wait()
+		msgQueueGauge.Update(0)
+	}()
+	for ev := range messages.Chan() {
+		msgEvent, ok := ev.Data.(tendermint.MessageEvent)
+		if !ok {
+			continue
+		}
+		select {
+		case queue <- msgEvent:
+		default:
+			if !isOwnMsg(msgEvent) {
+				droppedMsgsMeter.Mark(1)
+				logger.Debugw("message queue is full, dropping msg", "size", cap(queue))
+				continue
+			}
+			select {
+			case queue <- msgEvent:
+			case <-quit:
+				return
+			}
+		}
+		msgQueueGauge.Update(int64(len(queue)))
+	}
+}
+
+// verifyQueuedMessages verifies the messages of queue until it is closed and passes them to handleEvents,
+// the proposals through the proposal decoders.
+func (c *core) verifyQueuedMessages(queue <-chan tendermint.MessageEvent, proposals chan<- message, quit <-chan struct{}) {
+	logger := tendermint.Logger(tendermint.LogCore)
+	for ev := range queue {
+		msg, err := c.verifyPayload(ev.Payload, c.window)
+		if err != nil {
+			logger.Debugw("failed to verify msg", "from", msg.Address, "err", err)
+			c.reportInvalidMsg(ev.From, err)
+			continue
+		}
+		msg.from = ev.From
+		if msg.Code == msgPropose {
+			select {
+			case proposals <- msg:
+			case <-quit:
+				return
+			}
+			continue
+		}
+		if !c.deliverVerifiedMsg(msg, quit) {
+			return
+		}
+	}
+}
+
+// decodeProposals decodes the blocks of the verified proposals until proposals is closed and passes them to handleEvents
+func (c *core) decodeProposals(proposals <-chan message, quit <-chan struct{}) {
+	logger := tendermint.Logger(tendermint.LogCore)
+	for msg := range proposals {
+		start := time.Now()
+		proposal, err := decodeProposal(msg)
+		proposalDecodeTimer.UpdateSince(start)
+		if err != nil {
+			logger.Debugw("failed to decode proposal", "from", msg.Address, "err", err)
+			c.reportInvalidMsg(msg.from, err)
+			continue
+		}
+		msg.proposal = proposal
+		if !c.deliverVerifiedMsg(msg, quit) {
+			return
+		}
+	}
+}
+
+// deliverVerifiedMsg passes msg to handleEvents, false if core stopped first.
+// Waiting for handleEvents to take the message is counted as backpressure.
+func (c *core) deliverVerifiedMsg(msg message, quit <-chan struct{}) bool {
+	select {
+	case c.verifiedMsgs <- msg:
+		return true
+	default:
+		backpressureMeter.Mark(1)
+	}
+	select {
+	case c.verifiedMsgs <- msg:
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+// reportInvalidMsg passes the error of a message the peer from sent to the backend, which scores the peers.
+// The messages ahead of the view window are not reported, they are expected from every peer while this node lags behind.
+func (c *core) reportInvalidMsg(from common.Address, err error) {
+	if from == (common.Address{}) || err == errMsgAheadOfWindow {
+		return
+	}
+	if handler, ok := c.backend.(tendermint.InvalidMsgHandler); ok {
+		handler.HandleInvalidMsg(from, err)
+	}
+}
+
+// isOwnMsg returns whether the message was posted by this node rather than received from a peer.
+// The Address of the message is not verified yet, a peer could claim to be this node to skip the queue limit.
+func isOwnMsg(ev tendermint.MessageEvent) bool {
+	return ev.From == (common.Address{})
+}
+
+// messageQueueSize returns the number of messages waiting to be verified, see tendermint.Config.MessageQueueSize
+func (c *core) messageQueueSize() int {
+	if c.config.MessageQueueSize > 0 {
+		return c.config.MessageQueueSize
+	}
+	return defaultMessageQueueSize
+}
+
+// verifyWorkers returns the number of goroutines verifying the messages, see tendermint.Config.VerifyWorkers
+func (c *core) verifyWorkers() int {
+	if c.config.VerifyWorkers > 0 {
+		return c.config.VerifyWorkers
+	}
+	return runtime.NumCPU()
+}
@@ -0,0 +1,254 @@
+package core
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestVerifyMessages(t *testing.T) {
+	var (
+		config = tendermint.DefaultConfig
+		mux    = new(event.TypeMux)
+		quit   = make(chan struct{})
+		c      = &core{
+			config:       config,
+			handlerWg:    new(sync.WaitGroup),
+			verifiedMsgs: make(chan message, verifiedMsgsBuffer),
+		}
+	)
+	sub := mux.Subscribe(tendermint.MessageEvent{})
+	c.handlerWg.Add(1)
+	go c.verifyMessages(sub, quit)
+
+	valid, _ := makeSignedVoteMsg(t, config, msgPrevote, 1, 0)
+	// the signature does not match the address anymore
+	forged, _ := makeSignedVoteMsg(t, config, msgPrevote, 1, 0)
+	forged.Address = valid.Address
+	forged.Msg = append([]byte{}, valid.Msg...)
+	forged.Msg[len(forged.Msg)-1]++
+
+	for _, msg := range []message{forged, valid} {
+		payload, err := rlp.EncodeToBytes(&msg)
+		require.NoError(t, err)
+		require.NoError(t, mux.Post(tendermint.MessageEvent{Payload: payload}))
+	}
+	require.NoError(t, mux.Post(tendermint.MessageEvent{Payload: []byte("not a message")}))
+
+	select {
+	case msg := <-c.verifiedMsgs:
+		require.Equal(t, valid, msg)
+	case <-time.After(time.Second):
+		t.Fatal("valid message was not verified")
+	}
+	select {
+	case msg := <-c.verifiedMsgs:
+		t.Fatalf("unexpected verified message %v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(quit)
+	sub.Unsubscribe()
+	c.handlerWg.Wait()
+}
+
+func TestVerifyMessages_QueueFull(t *testing.T) {
+	var (
+		config = &tendermint.Config{MessageQueueSize: 1, VerifyWorkers: 1}
+		mux    = new(event.TypeMux)
+		quit   = make(chan struct{})
+		c      = &core{
+			config:       config,
+			handlerWg:    new(sync.WaitGroup),
+			verifiedMsgs: make(chan message),
+		}
+	)
+	sub := mux.Subscribe(tendermint.MessageEvent{})
+	c.handlerWg.Add(1)
+	go c.verifyMessages(sub, quit)
+
+	var (
+		peer = common.HexToAddress("0x01")
+		sent []message
+	)
+	post := func(round int64, from common.Address) message {
+		msg, _ := makeSignedVoteMsg(t, config, msgPrevote, 1, round)
+		payload, err := rlp.EncodeToBytes(&msg)
+		require.NoError(t, err)
+		require.NoError(t, mux.Post(tendermint.MessageEvent{Payload: payload, From: from}))
+		// the worker takes the first message and waits for the state machine, the second one fills the queue
+		time.Sleep(50 * time.Millisecond)
+		msg.from = from
+		return msg
+	}
+	for i := int64(0); i < 3; i++ {
+		sent = append(sent, post(i, peer))
+	}
+	// a message of this node waits for the queue instead of being dropped
+	own := post(3, common.Address{})
+
+	// the third message was dropped
+	for _, msg := range []message{sent[0], sent[1], own} {
+		select {
+		case verified := <-c.verifiedMsgs:
+			require.Equal(t, msg, verified)
+		case <-time.After(time.Second):
+			t.Fatal("queued message was not verified")
+		}
+	}
+	select {
+	case msg := <-c.verifiedMsgs:
+		t.Fatalf("unexpected verified message %v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(quit)
+	sub.Unsubscribe()
+	c.handlerWg.Wait()
+}
+
+// invalidMsgBackend records the peers whose messages core rejects
+type invalidMsgBackend struct {
+	tendermint.Backend
+	rejected chan common.Address
+}
+
+func (b *invalidMsgBackend) HandleInvalidMsg(address common.Address, err error) {
+	b.rejected <- address
+}
+
+func TestVerifyMessages_ReportInvalid(t *testing.T) {
+	var (
+		config  = *tendermint.DefaultConfig
+		mux     = new(event.TypeMux)
+		quit    = make(chan struct{})
+		backend = &invalidMsgBackend{rejected: make(chan common.Address, 8)}
+		peer    = common.HexToAddress("0x01")
+	)
+	config.HeightWindow = 2
+	c := &core{
+		config:       &config,
+		backend:      backend,
+		handlerWg:    new(sync.WaitGroup),
+		verifiedMsgs: make(chan message, verifiedMsgsBuffer),
+		window:       newMsgWindow(&config),
+	}
+	c.window.onStep(StepTransition{BlockNumber: big.NewInt(10), Step: RoundStepPropose})
+	sub := mux.Subscribe(tendermint.MessageEvent{})
+	c.handlerWg.Add(1)
+	go c.verifyMessages(sub, quit)
+
+	post := func(msg message, from common.Address) {
+		payload, err := rlp.EncodeToBytes(&msg)
+		require.NoError(t, err)
+		require.NoError(t, mux.Post(tendermint.MessageEvent{Payload: payload, From: from}))
+	}
+	forged, _ := makeSignedVoteMsg(t, &config, msgPrevote, 10, 0)
+	forged.Msg[len(forged.Msg)-1]++
+	behind, _ := makeSignedVoteMsg(t, &config, msgPrevote, 7, 0)
+	ahead, _ := makeSignedVoteMsg(t, &config, msgPrevote, 13, 0)
+
+	// the messages of this node and the ones ahead of the window, which this node may lag behind, are not reported
+	post(forged, common.Address{})
+	post(ahead, peer)
+	// the bad signatures and the messages behind the window are
+	post(forged, peer)
+	post(behind, peer)
+	require.NoError(t, mux.Post(tendermint.MessageEvent{Payload: []byte("not a message"), From: peer}))
+	for i := 0; i < 3; i++ {
+		select {
+		case addr := <-backend.rejected:
+			require.Equal(t, peer, addr)
+		case <-time.After(time.Second):
+			t.Fatal("invalid message was not reported")
+		}
+	}
+	select {
+	case addr := <-backend.rejected:
+		t.Fatalf("unexpected report of %v", addr)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(quit)
+	sub.Unsubscribe()
+	c.handlerWg.Wait()
+}
+
+func TestVerifyMessages_Proposal(t *testing.T) {
+	var (
+		config = tendermint.DefaultConfig
+		mux    = new(event.TypeMux)
+		quit   = make(chan struct{})
+		c      = &core{
+			config:       config,
+			handlerWg:    new(sync.WaitGroup),
+			verifiedMsgs: make(chan message, verifiedMsgsBuffer),
+		}
+		key   = tests_utils.MakeNodeKey()
+		block = types.NewBlock(&types.Header{Number: big.NewInt(1)}, nil, nil, nil)
+	)
+	sub := mux.Subscribe(tendermint.MessageEvent{})
+	c.handlerWg.Add(1)
+	go c.verifyMessages(sub, quit)
+
+	data, err := rlp.EncodeToBytes(&Proposal{Block: block, Round: 0, POLRound: -1})
+	require.NoError(t, err)
+	proposal := message{Code: msgPropose, Msg: data, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	signingPayload, err := proposal.SigningPayload(config)
+	require.NoError(t, err)
+	proposal.Signature, err = crypto.Sign(crypto.Keccak256(signingPayload), key)
+	require.NoError(t, err)
+	payload, err := rlp.EncodeToBytes(&proposal)
+	require.NoError(t, err)
+	require.NoError(t, mux.Post(tendermint.MessageEvent{Payload: payload}))
+
+	// the proposal reaches the state machine with its block decoded
+	select {
+	case msg := <-c.verifiedMsgs:
+		require.Equal(t, msgPropose, msg.Code)
+		require.NotNil(t, msg.proposal)
+		require.Equal(t, block.Hash(), msg.proposal.Block.Hash())
+	case <-time.After(time.Second):
+		t.Fatal("proposal was not verified")
+	}
+
+	close(quit)
+	sub.Unsubscribe()
+	c.handlerWg.Wait()
+}
+
+func TestDecodeProposal(t *testing.T) {
+	tx := types.NewTransaction(0, common.HexToAddress("0x01"), big.NewInt(10), 21000, big.NewInt(1), nil)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	// the header claims no transaction
+	mismatched := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), TxHash: types.EmptyRootHash}).
+		WithBody([]*types.Transaction{tx}, nil)
+
+	for _, test := range []struct {
+		proposal Proposal
+		err      error
+	}{
+		{Proposal{Block: block, Round: 1, POLRound: -1}, nil},
+		{Proposal{Block: block, Round: -1, POLRound: -1}, ErrInvalidRound},
+		{Proposal{Block: mismatched, Round: 0, POLRound: -1}, tendermint.ErrMismatchTxhashes},
+	} {
+		data, err := rlp.EncodeToBytes(&test.proposal)
+		require.NoError(t, err)
+		proposal, err := decodeProposal(message{Code: msgPropose, Msg: data})
+		require.Equal(t, test.err, err)
+		if err == nil {
+			require.Equal(t, block.Hash(), proposal.Block.Hash())
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// MaxVoteBatchSize is the max number of votes in a vote batch
+const MaxVoteBatchSize = 128
+
+var (
+	// ErrInvalidVoteBatch is returned by DecodeVoteBatch when a packed payload is not a vote of the batch's block number
+	ErrInvalidVoteBatch = errors.New("vote batch holds a message which is not a vote of its block number")
+	// ErrVoteBatchTooLarge is returned by DecodeVoteBatch when a batch holds more than MaxVoteBatchSize votes
+	ErrVoteBatchTooLarge = errors.New("vote batch holds too many votes")
+)
+
+// IsVoteBatch returns true if msgType is the code of vote batches
+func IsVoteBatch(msgType uint64) bool {
+	return msgType == msgVoteBatch
+}
+
+// EncodeVoteBatch packs the payloads of the votes of blockNumber into a single message signed by address,
+// the votes keep the signatures of their validators.
+func EncodeVoteBatch(config *tendermint.Config, address common.Address, sign func([]byte) ([]byte, error),
+	blockNumber *big.Int, payloads [][]byte) ([]byte, error) {
+	if len(payloads) > MaxVoteBatchSize {
+		return nil, ErrVoteBatchTooLarge
+	}
+	data, err := rlp.EncodeToBytes(&VoteBatchMsg{
+		BlockNumber: blockNumber,
+		Payloads:    payloads,
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg := &message{
+		Code:    msgVoteBatch,
+		Msg:     data,
+		Address: address,
+	}
+	signingPayload, err := msg.SigningPayload(config)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Signature, err = sign(signingPayload); err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(msg)
+}
+
+// DecodeVoteBatch returns the sender and the vote payloads of a batch encoded by EncodeVoteBatch,
+// it verifies the signature of the sender but not the ones of the votes, which are verified by core.
+func DecodeVoteBatch(config *tendermint.Config, payload []byte) (common.Address, [][]byte, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return common.Address{}, nil, err
+	}
+	if msg.Code != msgVoteBatch {
+		return common.Address{}, nil, errors.Errorf("unexpected msg code %d", msg.Code)
+	}
+	signer, err := msg.GetAddressFromSignature(config)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	if signer != msg.Address {
+		return common.Address{}, nil, ErrSignerMessageMissMatch
+	}
+	var batch VoteBatchMsg
+	if err := rlp.DecodeBytes(msg.Msg, &batch); err != nil {
+		return common.Address{}, nil, err
+	}
+	if len(batch.Payloads) > MaxVoteBatchSize {
+		return common.Address{}, nil, ErrVoteBatchTooLarge
+	}
+	for _, votePayload := range batch.Payloads {
+		var vote message
+		if err := rlp.DecodeBytes(votePayload, &vote); err != nil {
+			return common.Address{}, nil, err
+		}
+		if !IsVote(vote.Code) {
+			return common.Address{}, nil, ErrInvalidVoteBatch
+		}
+		blockNumber, _, err := vote.signingView()
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		if blockNumber.Cmp(batch.BlockNumber) != 0 {
+			return common.Address{}, nil, ErrInvalidVoteBatch
+		}
+	}
+	return msg.Address, batch.Payloads, nil
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestVoteBatch(t *testing.T) {
+	var (
+		config = &tendermint.Config{ChainID: big.NewInt(1), DomainSeparationBlock: big.NewInt(1)}
+		key    = tests_utils.MakeNodeKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		sign   = func(data []byte) ([]byte, error) {
+			return crypto.Sign(crypto.Keccak256(data), key)
+		}
+		encodeVotes = func(votes ...message) [][]byte {
+			var payloads [][]byte
+			for _, vote := range votes {
+				payload, err := rlp.EncodeToBytes(&vote)
+				require.NoError(t, err)
+				payloads = append(payloads, payload)
+			}
+			return payloads
+		}
+	)
+	prevote, _ := makeSignedVoteMsg(t, config, msgPrevote, 10, 0)
+	precommit, _ := makeSignedVoteMsg(t, config, msgPrecommit, 10, 1)
+	payloads := encodeVotes(prevote, precommit)
+
+	batch, err := EncodeVoteBatch(config, sender, sign, big.NewInt(10), payloads)
+	require.NoError(t, err)
+	addr, decoded, err := DecodeVoteBatch(config, batch)
+	require.NoError(t, err)
+	require.Equal(t, sender, addr)
+	require.Equal(t, payloads, decoded)
+
+	// the sender must have signed the batch
+	var msg message
+	require.NoError(t, rlp.DecodeBytes(batch, &msg))
+	msg.Address = prevote.Address
+	forged, err := rlp.EncodeToBytes(&msg)
+	require.NoError(t, err)
+	_, _, err = DecodeVoteBatch(config, forged)
+	require.Equal(t, ErrSignerMessageMissMatch, err)
+
+	// the votes must be of the batch's block number
+	other, _ := makeSignedVoteMsg(t, config, msgPrevote, 11, 0)
+	batch, err = EncodeVoteBatch(config, sender, sign, big.NewInt(10), encodeVotes(prevote, other))
+	require.NoError(t, err)
+	_, _, err = DecodeVoteBatch(config, batch)
+	require.Equal(t, ErrInvalidVoteBatch, err)
+
+	// a batch only holds votes
+	catchUp, err := rlp.EncodeToBytes(&message{Code: msgCatchUpRequest, Address: sender})
+	require.NoError(t, err)
+	batch, err = EncodeVoteBatch(config, sender, sign, big.NewInt(10), append(payloads, catchUp))
+	require.NoError(t, err)
+	_, _, err = DecodeVoteBatch(config, batch)
+	require.Equal(t, ErrInvalidVoteBatch, err)
+
+	_, err = EncodeVoteBatch(config, sender, sign, big.NewInt(10), make([][]byte, MaxVoteBatchSize+1))
+	require.Equal(t, ErrVoteBatchTooLarge, err)
+}
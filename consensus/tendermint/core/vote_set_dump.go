@@ -0,0 +1,147 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// voteSetDump is the JSON encoding of a messageSet: its validators with their voting power, the votes and the tallies
+// as they were. A vote set of a round gone wrong can be dumped, attached to a bug report and loaded in a unit test
+// to reproduce the quorum calculation.
+type voteSetDump struct {
+	BlockNumber   *big.Int         `json:"block_number"`
+	Round         int64            `json:"round"`
+	Code          uint64           `json:"code"`
+	Validators    []validatorDump  `json:"validators"`
+	QuorumPower   int              `json:"quorum_power"` // informational, the quorum is computed by the validator set
+	Votes         []voteDump       `json:"votes"`
+	Blocks        []blockVotesDump `json:"blocks"`
+	Maj23         *common.Hash     `json:"maj23"`
+	TotalReceived int              `json:"total_received"`
+	Evicted       bool             `json:"evicted,omitempty"`
+}
+
+type validatorDump struct {
+	Address     common.Address `json:"address"`
+	VotingPower int            `json:"voting_power"`
+}
+
+type voteDump struct {
+	Address   common.Address `json:"address"`
+	BlockHash common.Hash    `json:"block_hash"`
+	Seal      hexutil.Bytes  `json:"seal,omitempty"`
+	TraceID   string         `json:"trace_id,omitempty"`
+	Message   hexutil.Bytes  `json:"message,omitempty"` // the RLP of the signed message, empty if it was evicted
+}
+
+type blockVotesDump struct {
+	BlockHash     common.Hash `json:"block_hash"`
+	TotalReceived int         `json:"total_received"`
+}
+
+// MarshalJSON implements json.Marshaler, see voteSetDump
+func (ms *messageSet) MarshalJSON() ([]byte, error) {
+	ms.messagesMu.Lock()
+	defer ms.messagesMu.Unlock()
+	dump := &voteSetDump{
+		BlockNumber:   ms.view.BlockNumber,
+		Round:         ms.view.Round,
+		Code:          ms.msgCode,
+		QuorumPower:   ms.valSet.QuorumPower(),
+		Maj23:         ms.maj23,
+		TotalReceived: ms.totalReceived,
+		Evicted:       ms.evicted,
+	}
+	for _, val := range ms.valSet.List() {
+		dump.Validators = append(dump.Validators, validatorDump{Address: val.Address(), VotingPower: ms.valSet.VotingPower(val.Address())})
+	}
+	for addr, vote := range ms.voteByAddress {
+		voteDump := voteDump{Address: addr, BlockHash: *vote.BlockHash, Seal: vote.Seal, TraceID: vote.TraceID}
+		if msg, ok := ms.messages[addr]; ok {
+			payload, err := rlp.EncodeToBytes(msg)
+			if err != nil {
+				return nil, err
+			}
+			voteDump.Message = payload
+		}
+		dump.Votes = append(dump.Votes, voteDump)
+	}
+	sort.Slice(dump.Votes, func(i, j int) bool { return dump.Votes[i].Address.Hex() < dump.Votes[j].Address.Hex() })
+	for hash, votes := range ms.voteByBlock {
+		dump.Blocks = append(dump.Blocks, blockVotesDump{BlockHash: hash, TotalReceived: votes.totalReceived})
+	}
+	sort.Slice(dump.Blocks, func(i, j int) bool { return dump.Blocks[i].BlockHash.Hex() < dump.Blocks[j].BlockHash.Hex() })
+	return json.Marshal(dump)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see voteSetDump. The validator set is rebuilt from the validators,
+// with weighted voting if one of them votes with a power other than 1. The tallies are restored as they were
+// dumped rather than recomputed, so that a wrong tally is reproduced, the votes added after it are counted as usual.
+func (ms *messageSet) UnmarshalJSON(data []byte) error {
+	var dump voteSetDump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return err
+	}
+	if dump.BlockNumber == nil {
+		return fmt.Errorf("vote set without block number")
+	}
+	var (
+		addrs    = make([]common.Address, len(dump.Validators))
+		weights  = make([]uint64, len(dump.Validators))
+		weighted = false
+	)
+	for i, val := range dump.Validators {
+		addrs[i], weights[i] = val.Address, uint64(val.VotingPower)
+		weighted = weighted || val.VotingPower != 1
+	}
+	valSet := validator.NewSet(addrs, tendermint.RoundRobin, dump.BlockNumber.Int64())
+	if weighted {
+		valSet = validator.NewWeightedVotingSet(addrs, weights, dump.BlockNumber.Int64())
+	}
+
+	restored := newMessageSet(valSet, dump.Code, &tendermint.View{BlockNumber: dump.BlockNumber, Round: dump.Round})
+	for _, v := range dump.Votes {
+		index, _ := valSet.GetByAddress(v.Address)
+		if index == -1 {
+			return fmt.Errorf("vote of %s which is not a validator of the set", v.Address.Hex())
+		}
+		hash := v.BlockHash
+		vote := &Vote{BlockHash: &hash, BlockNumber: dump.BlockNumber, Round: dump.Round, Seal: v.Seal, TraceID: v.TraceID}
+		restored.voteByAddress[v.Address] = vote
+		votes, ok := restored.voteByBlock[hash]
+		if !ok {
+			votes = &blockVotes{votes: make([]*Vote, valSet.Size())}
+			restored.voteByBlock[hash] = votes
+		}
+		votes.votes[index] = vote
+		if len(v.Message) > 0 {
+			var msg message
+			if err := rlp.DecodeBytes(v.Message, &msg); err != nil {
+				return err
+			}
+			restored.messages[v.Address] = &msg
+			restored.payloadSize += len(msg.Msg) + len(msg.Signature)
+		}
+	}
+	for _, block := range dump.Blocks {
+		votes, ok := restored.voteByBlock[block.BlockHash]
+		if !ok {
+			votes = &blockVotes{votes: make([]*Vote, valSet.Size())}
+			restored.voteByBlock[block.BlockHash] = votes
+		}
+		votes.totalReceived = block.TotalReceived
+	}
+	restored.maj23 = dump.Maj23
+	restored.totalReceived = dump.TotalReceived
+	restored.evicted = dump.Evicted
+	*ms = *restored
+	return nil
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+)
+
+func TestMessageSet_JSON(t *testing.T) {
+	var (
+		validators = []common.Address{
+			common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03"), common.HexToAddress("0x04"),
+		}
+		hash  = common.HexToHash("0xaa")
+		other = common.HexToHash("0xbb")
+		view  = &tendermint.View{BlockNumber: big.NewInt(5), Round: 2}
+	)
+	for name, valSet := range map[string]tendermint.ValidatorSet{
+		"round robin":     validator.NewSet(validators, tendermint.RoundRobin, 5),
+		"weighted voting": validator.NewWeightedVotingSet(validators, []uint64{4, 4, 8, 16}, 5),
+	} {
+		set := newMessageSet(valSet, msgPrecommit, view)
+		for i, addr := range validators[:3] {
+			voted := hash
+			if i == 0 {
+				voted = other
+			}
+			_, err := set.AddVote(message{Code: msgPrecommit, Address: addr, Msg: []byte{byte(i)}, Signature: []byte{0x01}},
+				&Vote{BlockHash: &voted, BlockNumber: view.BlockNumber, Round: view.Round, Seal: []byte{byte(i)}})
+			require.NoError(t, err, name)
+		}
+		data, err := json.Marshal(set)
+		require.NoError(t, err, name)
+
+		var restored messageSet
+		require.NoError(t, json.Unmarshal(data, &restored), name)
+		require.Equal(t, set.snapshot(), restored.snapshot(), name)
+		require.Equal(t, valSet.QuorumPower(), restored.valSet.QuorumPower(), name)
+		for _, addr := range validators {
+			msg, vote := set.voteOf(addr)
+			restoredMsg, restoredVote := restored.voteOf(addr)
+			require.Equal(t, msg, restoredMsg, name)
+			require.Equal(t, vote, restoredVote, name)
+		}
+		again, err := json.Marshal(&restored)
+		require.NoError(t, err, name)
+		require.JSONEq(t, string(data), string(again), name)
+
+		// the votes are counted on top of the restored tallies
+		_, err = restored.AddVote(message{Code: msgPrecommit, Address: validators[3]},
+			&Vote{BlockHash: &hash, BlockNumber: view.BlockNumber, Round: view.Round})
+		require.NoError(t, err, name)
+		maj23, ok := restored.TwoThirdMajority()
+		require.True(t, ok, name)
+		require.Equal(t, hash, maj23, name)
+	}
+}
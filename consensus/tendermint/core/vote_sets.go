@@ -0,0 +1,153 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+)
+
+var (
+	voteSetRoundsGauge = metrics.NewRegisteredGauge("evr/consensus/tendermint/votesets/rounds", nil)
+	voteSetBytesGauge  = metrics.NewRegisteredGauge("evr/consensus/tendermint/votesets/bytes", nil)
+	evictedRoundsMeter = metrics.NewRegisteredMeter("evr/consensus/tendermint/votesets/evicted", nil)
+)
+
+// evictVoteRounds drops the messages of the vote sets of the rounds beyond s.maxVoteRounds, their votes and tallies
+// are kept so the steps are decided the same. The rounds furthest from the current round are evicted first, the past
+// ones before the future ones, then the ones with the fewest distinct signers, so that a validator spamming round
+// numbers evicts its own rounds rather than the rounds the other validators vote in. The current round and the locked
+// and valid rounds keep their messages.
+func (s *roundState) evictVoteRounds() {
+	if s.maxVoteRounds == 0 {
+		return
+	}
+	var rounds []int64
+	for _, sets := range []map[int64]*messageSet{s.PrevotesReceived, s.PrecommitsReceived} {
+		for round, set := range sets {
+			if !set.evicted && !containsRound(rounds, round) {
+				rounds = append(rounds, round)
+			}
+		}
+	}
+	if uint64(len(rounds)) <= s.maxVoteRounds {
+		return
+	}
+	signers := make(map[int64]map[common.Address]bool)
+	for _, sets := range []map[int64]*messageSet{s.PrevotesReceived, s.PrecommitsReceived} {
+		for round, set := range sets {
+			if set.evicted {
+				continue
+			}
+			if _, ok := signers[round]; !ok {
+				signers[round] = make(map[common.Address]bool)
+			}
+			for addr := range set.VotesByAddress() {
+				signers[round][addr] = true
+			}
+		}
+	}
+	distance := func(round int64) int64 {
+		if round > s.Round() {
+			return round - s.Round()
+		}
+		return s.Round() - round
+	}
+	sort.Slice(rounds, func(i, j int) bool {
+		a, b := rounds[i], rounds[j]
+		if distance(a) != distance(b) {
+			return distance(a) > distance(b)
+		}
+		if (a < s.Round()) != (b < s.Round()) {
+			return a < s.Round()
+		}
+		if len(signers[a]) != len(signers[b]) {
+			return len(signers[a]) < len(signers[b])
+		}
+		return a < b
+	})
+	excess := uint64(len(rounds)) - s.maxVoteRounds
+	for _, round := range rounds {
+		if excess == 0 {
+			break
+		}
+		if round == s.Round() || round == s.lockedRound || round == s.validRound {
+			continue
+		}
+		excess--
+		for _, sets := range []map[int64]*messageSet{s.PrevotesReceived, s.PrecommitsReceived} {
+			if set, ok := sets[round]; ok {
+				set.evictMessages()
+			}
+		}
+		evictedRoundsMeter.Mark(1)
+	}
+}
+
+// acceptRound returns false for a vote of addr for a round above the current one if addr already voted for a higher
+// round: as in the round skipping of Tendermint, each validator holds at most one future round, its highest, so that
+// a validator voting for ever higher rounds holds a single vote set. The vote sets of the future rounds no validator
+// holds anymore are dropped, the votes of the validators in them only mattered to skip to these rounds.
+func (s *roundState) acceptRound(addr common.Address, round int64) bool {
+	if round <= s.Round() {
+		return true
+	}
+	if s.futureRounds == nil {
+		s.futureRounds = make(map[common.Address]int64)
+	}
+	held, ok := s.futureRounds[addr]
+	ok = ok && held > s.Round()
+	switch {
+	case ok && round < held:
+		return false
+	case ok && round == held:
+		return true
+	}
+	s.futureRounds[addr] = round
+	if ok {
+		s.dropFutureRound(held)
+	}
+	return true
+}
+
+// dropFutureRound drops the vote sets of the future round if no validator holds it anymore
+func (s *roundState) dropFutureRound(round int64) {
+	for _, held := range s.futureRounds {
+		if held == round {
+			return
+		}
+	}
+	delete(s.PrevotesReceived, round)
+	delete(s.PrecommitsReceived, round)
+	s.updateVoteSetGauges()
+}
+
+// updateVoteSetGauges exports the number of rounds with votes and the size of the messages their vote sets keep
+func (s *roundState) updateVoteSetGauges() {
+	if !metrics.Enabled {
+		return
+	}
+	var (
+		rounds []int64
+		size   int
+	)
+	for _, sets := range []map[int64]*messageSet{s.PrevotesReceived, s.PrecommitsReceived} {
+		for round, set := range sets {
+			if !containsRound(rounds, round) {
+				rounds = append(rounds, round)
+			}
+			size += set.size()
+		}
+	}
+	voteSetRoundsGauge.Update(int64(len(rounds)))
+	voteSetBytesGauge.Update(int64(size))
+}
+
+func containsRound(rounds []int64, round int64) bool {
+	for _, r := range rounds {
+		if r == round {
+			return true
+		}
+	}
+	return false
+}
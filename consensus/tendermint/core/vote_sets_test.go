@@ -0,0 +1,105 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+)
+
+func TestRoundState_EvictVoteRounds(t *testing.T) {
+	var (
+		validators = []common.Address{
+			common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03"), common.HexToAddress("0x04"),
+		}
+		valSet = validator.NewSet(validators, tendermint.RoundRobin, 5)
+		state  = newRoundState(&tendermint.View{BlockNumber: big.NewInt(5), Round: 3}, make(map[int64]*messageSet),
+			make(map[int64]*messageSet), nil, -1, nil, -1, nil, nil, RoundStepPrevote, -1)
+		hash  = common.HexToHash("0xaa")
+		other = common.HexToHash("0xbb")
+	)
+	state.maxVoteRounds = 2
+	for _, round := range []int64{0, 1, 2, 3} {
+		for _, addr := range validators {
+			vote := &Vote{BlockHash: &hash, BlockNumber: big.NewInt(5), Round: round}
+			added, err := state.addPrevote(message{Code: msgPrevote, Address: addr, Msg: []byte{0x01}}, vote, valSet)
+			require.NoError(t, err)
+			require.True(t, added)
+		}
+	}
+
+	// the oldest rounds dropped their messages but the current one keeps them
+	for round, evicted := range map[int64]bool{0: true, 1: true, 2: false, 3: false} {
+		prevotes, ok := state.GetPrevotesByRound(round)
+		require.True(t, ok)
+		msg, vote := prevotes.voteOf(validators[0])
+		require.NotNil(t, vote, "round %d", round)
+		require.Equal(t, evicted, msg == nil, "round %d", round)
+		// the tallies are kept
+		maj23, ok := prevotes.TwoThirdMajority()
+		require.True(t, ok)
+		require.Equal(t, hash, maj23)
+	}
+
+	// a conflicting vote of an evicted round is still detected
+	prevotes, _ := state.GetPrevotesByRound(0)
+	_, err := prevotes.AddVote(message{Code: msgPrevote, Address: validators[0]}, &Vote{BlockHash: &other, BlockNumber: big.NewInt(5), Round: 0})
+	require.Equal(t, ErrConflictingVotes, err)
+	require.Zero(t, prevotes.size())
+}
+
+func TestRoundState_EvictVoteRoundsSpam(t *testing.T) {
+	var (
+		validators = []common.Address{
+			common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03"), common.HexToAddress("0x04"),
+		}
+		spammer = validators[3]
+		valSet  = validator.NewSet(validators, tendermint.RoundRobin, 5)
+		state   = newRoundState(&tendermint.View{BlockNumber: big.NewInt(5), Round: 1}, make(map[int64]*messageSet),
+			make(map[int64]*messageSet), nil, -1, nil, -1, nil, nil, RoundStepPrevote, -1)
+		hash    = common.HexToHash("0xaa")
+		prevote = func(addr common.Address, round int64) bool {
+			vote := &Vote{BlockHash: &hash, BlockNumber: big.NewInt(5), Round: round}
+			added, err := state.addPrevote(message{Code: msgPrevote, Address: addr, Msg: []byte{0x01}}, vote, valSet)
+			require.NoError(t, err)
+			return added
+		}
+	)
+	state.maxVoteRounds = 2
+	for _, round := range []int64{0, 1} {
+		for _, addr := range validators[:3] {
+			require.True(t, prevote(addr, round))
+		}
+	}
+	// a validator votes for ever higher rounds
+	for round := int64(1000000); round < 1000010; round++ {
+		require.True(t, prevote(spammer, round))
+	}
+
+	// it only holds its highest round, whose messages are evicted rather than the ones of the honest rounds
+	require.Len(t, state.PrevotesReceived, 3)
+	for round, evicted := range map[int64]bool{0: false, 1: false, 1000009: true} {
+		prevotes, ok := state.GetPrevotesByRound(round)
+		require.True(t, ok, "round %d", round)
+		require.Equal(t, evicted, prevotes.size() == 0, "round %d", round)
+	}
+	// nor does it go back to a lower future round
+	require.False(t, prevote(spammer, 1000000))
+	_, ok := state.GetPrevotesByRound(1000000)
+	require.False(t, ok)
+
+	// the future rounds of the honest validators are kept as long as one of them holds it
+	require.True(t, prevote(validators[0], 2))
+	require.True(t, prevote(validators[1], 2))
+	require.True(t, prevote(validators[0], 3))
+	prevotes, ok := state.GetPrevotesByRound(2)
+	require.True(t, ok)
+	require.Len(t, prevotes.VotesByAddress(), 2)
+	require.True(t, prevote(validators[1], 3))
+	_, ok = state.GetPrevotesByRound(2)
+	require.False(t, ok)
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	// errMsgOutsideWindow is returned for the messages too far behind the view of the state machine
+	errMsgOutsideWindow = errors.New("message outside of the view window")
+	// errMsgAheadOfWindow is returned for the messages too far ahead of the view of the state machine
+	errMsgAheadOfWindow = errors.New("message ahead of the view window")
+)
+
+// msgWindow drops the proposals and votes too far behind or ahead of the view of the state machine before their
+// signature is recovered, see tendermint.Config.HeightWindow and RoundWindow, so that replayed or time-shifted
+// messages neither cost a signature recovery nor pile up in the future messages and the vote sets.
+// verifyMessages checks the messages while the state machine updates the view with a step hook,
+// and records the latest view of each validator once their message is handled.
+type msgWindow struct {
+	heights uint64
+	rounds  uint64
+
+	mu          sync.RWMutex
+	blockNumber *big.Int                            // the block number of the state machine, nil until it starts
+	round       int64                               // the round of the state machine
+	lastSeen    map[common.Address]*tendermint.View // the latest view of the handled messages of each validator
+}
+
+func newMsgWindow(config *tendermint.Config) *msgWindow {
+	return &msgWindow{
+		heights:  config.HeightWindow,
+		rounds:   config.RoundWindow,
+		lastSeen: make(map[common.Address]*tendermint.View),
+	}
+}
+
+// onStep is the step hook updating the view, the latest views of the validators are cleared on a new block
+func (w *msgWindow) onStep(transition StepTransition) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.blockNumber == nil || w.blockNumber.Cmp(transition.BlockNumber) != 0 {
+		w.blockNumber = new(big.Int).Set(transition.BlockNumber)
+		w.lastSeen = make(map[common.Address]*tendermint.View)
+	}
+	w.round = transition.Round
+}
+
+// check returns errMsgOutsideWindow if the message of view from signer is behind the window and errMsgAheadOfWindow
+// if it is ahead of it, a nil window accepts all
+func (w *msgWindow) check(signer common.Address, view *tendermint.View) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.blockNumber == nil {
+		return nil
+	}
+	if w.heights > 0 {
+		distance := new(big.Int).Sub(view.BlockNumber, w.blockNumber)
+		if distance.CmpAbs(new(big.Int).SetUint64(w.heights)) > 0 {
+			if distance.Sign() > 0 {
+				return errMsgAheadOfWindow
+			}
+			return errMsgOutsideWindow
+		}
+	}
+	if w.rounds == 0 || view.BlockNumber.Cmp(w.blockNumber) != 0 {
+		return nil
+	}
+	if view.Round > w.round && uint64(view.Round-w.round) > w.rounds {
+		return errMsgAheadOfWindow
+	}
+	// an honest validator only moves on to later rounds, so its messages far behind its latest one are stale
+	if last, ok := w.lastSeen[signer]; ok && last.Round > view.Round && uint64(last.Round-view.Round) > w.rounds {
+		return errMsgOutsideWindow
+	}
+	return nil
+}
+
+// observe records the view of a handled message of a validator of the current block
+func (w *msgWindow) observe(signer common.Address, view *tendermint.View) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.blockNumber == nil || view.BlockNumber.Cmp(w.blockNumber) != 0 {
+		return
+	}
+	if last, ok := w.lastSeen[signer]; !ok || last.Round < view.Round {
+		w.lastSeen[signer] = view
+	}
+}
+
+// msgBlockView returns the block number and round of a proposal or a vote, false for the other kinds.
+// The block of a proposal is not decoded, only its header, unless the verifier decoded it already.
+func msgBlockView(msg message) (*tendermint.View, bool) {
+	switch msg.Code {
+	case msgPropose:
+		if msg.proposal != nil {
+			return &tendermint.View{BlockNumber: msg.proposal.Block.Number(), Round: msg.proposal.Round}, true
+		}
+		var proposal rlpProposal
+		if err := rlp.DecodeBytes(msg.Msg, &proposal); err != nil {
+			return nil, false
+		}
+		var block struct {
+			Header *types.Header
+			Rest   []rlp.RawValue `rlp:"tail"`
+		}
+		if err := rlp.DecodeBytes(proposal.Block, &block); err != nil || block.Header == nil || block.Header.Number == nil {
+			return nil, false
+		}
+		round, err := strconv.ParseInt(proposal.RStr, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		return &tendermint.View{BlockNumber: block.Header.Number, Round: round}, true
+	case msgPrevote, msgPrecommit:
+		var vote Vote
+		if err := rlp.DecodeBytes(msg.Msg, &vote); err != nil || vote.BlockNumber == nil {
+			return nil, false
+		}
+		return &tendermint.View{BlockNumber: vote.BlockNumber, Round: vote.Round}, true
+	}
+	return nil, false
+}
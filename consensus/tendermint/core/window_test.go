@@ -0,0 +1,75 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestMsgWindow(t *testing.T) {
+	var (
+		window    = newMsgWindow(&tendermint.Config{HeightWindow: 2, RoundWindow: 3})
+		validator = common.HexToAddress("0x01")
+		other     = common.HexToAddress("0x02")
+		view      = func(blockNumber, round int64) *tendermint.View {
+			return &tendermint.View{BlockNumber: big.NewInt(blockNumber), Round: round}
+		}
+	)
+	// every message is accepted until the state machine starts
+	require.NoError(t, window.check(validator, view(100, 100)))
+
+	window.onStep(StepTransition{BlockNumber: big.NewInt(10), Round: 1, Step: RoundStepPropose})
+	for _, test := range []struct {
+		view *tendermint.View
+		err  error
+	}{
+		{view(8, 50), nil},
+		{view(7, 0), errMsgOutsideWindow},
+		{view(12, 50), nil},
+		{view(13, 0), errMsgAheadOfWindow},
+		{view(10, 4), nil},
+		{view(10, 5), errMsgAheadOfWindow},
+	} {
+		require.Equal(t, test.err, window.check(validator, test.view), "view %v", test.view)
+	}
+
+	// the messages far behind the latest round of their signer are stale
+	window.onStep(StepTransition{BlockNumber: big.NewInt(10), Round: 4, Step: RoundStepPropose})
+	window.observe(validator, view(10, 6))
+	window.observe(validator, view(10, 2))
+	require.Equal(t, errMsgOutsideWindow, window.check(validator, view(10, 2)))
+	require.NoError(t, window.check(validator, view(10, 3)))
+	require.NoError(t, window.check(other, view(10, 2)))
+
+	// until the next block
+	window.onStep(StepTransition{BlockNumber: big.NewInt(11), Round: 0, Step: RoundStepPropose})
+	require.NoError(t, window.check(validator, view(10, 0)))
+
+	// a zero window is not checked
+	window = newMsgWindow(&tendermint.Config{})
+	window.onStep(StepTransition{BlockNumber: big.NewInt(10), Round: 0, Step: RoundStepPropose})
+	require.NoError(t, window.check(validator, view(1000, 1000)))
+}
+
+func TestMsgBlockView(t *testing.T) {
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(7)})
+	data, err := rlp.EncodeToBytes(&Proposal{Block: block, Round: 3, POLRound: -1})
+	require.NoError(t, err)
+	view, ok := msgBlockView(message{Code: msgPropose, Msg: data})
+	require.True(t, ok)
+	require.Equal(t, &tendermint.View{BlockNumber: big.NewInt(7), Round: 3}, view)
+
+	vote, _ := makeSignedVoteMsg(t, tendermint.DefaultConfig, msgPrecommit, 5, 2)
+	view, ok = msgBlockView(vote)
+	require.True(t, ok)
+	require.Equal(t, &tendermint.View{BlockNumber: big.NewInt(5), Round: 2}, view)
+
+	_, ok = msgBlockView(message{Code: msgHeartbeat, Msg: data})
+	require.False(t, ok)
+}
@@ -27,10 +27,23 @@ var (
 	ErrInvalidDifficulty = errors.New("invalid difficulty")
 	// errInvalidExtraDataFormat is returned when the extra data format is incorrect
 	ErrInvalidExtraDataFormat = errors.New("invalid extra data format")
+	// ErrInvalidGasLimit is returned if the gas limit of a block is out of bounds or moved too far from its parent's.
+	ErrInvalidGasLimit = errors.New("invalid gas limit")
+	// ErrInvalidGasUsed is returned if the gas used by a block is above its gas limit.
+	ErrInvalidGasUsed = errors.New("invalid gas used")
+	// ErrInvalidTimestamp is returned if the timestamp of a block is not after its parent's.
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	// ErrFutureTimestamp is returned if the timestamp of a proposal is ahead of the local time by more than the max drift.
+	ErrFutureTimestamp = errors.New("proposal timestamp too far in the future")
 	// errInvalidMixDigest is returned if a block's mix digest is not Tendermint digest.
 	ErrInvalidMixDigest = errors.New("invalid Tendermint mix digest")
 	// errInvalidCommittedSeals is returned if the committed seal is not signed by any of parent validators.
 	ErrInvalidCommittedSeals = errors.New("invalid committed seals")
+	// ErrInvalidMissingValidators is returned if the missing validators bitmap does not match the committed seals.
+	ErrInvalidMissingValidators = errors.New("invalid missing validators")
+	// ErrInvalidParentCommit is returned if the parent commit of a block is not a quorum of the parent's validators
+	// while the committers earn a share of the block reward, or is not empty while they don't.
+	ErrInvalidParentCommit = errors.New("invalid parent commit")
 	// errInvalidVotingChain is returned if an authorization list is attempted to
 	// be modified via out-of-range or non-contiguous headers.
 	ErrInvalidVotingChain = errors.New("invalid voting chain")
@@ -47,4 +60,8 @@ var (
 	ErrUnknownParent = errors.New("unknown parent")
 	// ErrFinalizeZeroBlock is returned if node finalize with block number = 0
 	ErrFinalizeZeroBlock = errors.New("finalize zero block")
+	// ErrRecoveryNotValidator is returned if a recovery keeps a validator which isn't in the validator set it replaces
+	ErrRecoveryNotValidator = errors.New("recovery validator is not a validator of the recovered block")
+	// ErrInvalidRecoveryApproval is returned if a validator of a recovery didn't approve it
+	ErrInvalidRecoveryApproval = errors.New("invalid recovery approval")
 )
@@ -3,6 +3,7 @@ package tendermint
 import (
 	"math/big"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 )
 
@@ -15,6 +16,7 @@ type NewBlockEvent struct {
 // MessageEvent is posted for Tendermint engine communication
 type MessageEvent struct {
 	Payload []byte
+	From    common.Address // the peer which sent the message, empty for the messages of this node
 }
 
 // FinalCommittedEvent is posted when a proposal is committed
@@ -22,5 +24,19 @@ type FinalCommittedEvent struct {
 	BlockNumber *big.Int
 }
 
+// BlockFinalizedEvent is sent to the finalized block subscribers when a block becomes the head of the chain,
+// which makes it final with tendermint
+type BlockFinalizedEvent struct {
+	BlockNumber *big.Int
+	Round       int64 // the commit round of the block, -1 if this node did not commit it
+}
+
+// CandidatesExcludedEvent is sent to the subscribers when candidates of the staking contract are excluded from
+// the validator selection of a checkpoint, their owners bonding less than the minimum self-bond to them
+type CandidatesExcludedEvent struct {
+	Checkpoint *big.Int         `json:"checkpoint"`
+	Candidates []common.Address `json:"candidates"`
+}
+
 // StopCoreEvent is posted when core is stopped
 type StopCoreEvent struct{}
@@ -0,0 +1,101 @@
+package tendermint
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// GossipStrategy is the way a consensus message is disseminated to the validators
+type GossipStrategy uint64
+
+const (
+	// FullBroadcast sends the message to every validator, which relay it to their neighbors
+	FullBroadcast GossipStrategy = iota
+	// RandomGossip sends the message to fanout random validators, which relay it the same way when they first receive it
+	RandomGossip
+	// TreeGossip disseminates the message along a tree rooted at its sender, each validator sends it to its fanout children
+	TreeGossip
+)
+
+// String implements fmt.Stringer
+func (s GossipStrategy) String() string {
+	switch s {
+	case FullBroadcast:
+		return "full"
+	case RandomGossip:
+		return "random"
+	case TreeGossip:
+		return "tree"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseGossipStrategy returns the strategy named s: full, random or tree
+func ParseGossipStrategy(s string) (GossipStrategy, error) {
+	for _, strategy := range []GossipStrategy{FullBroadcast, RandomGossip, TreeGossip} {
+		if strategy.String() == s {
+			return strategy, nil
+		}
+	}
+	return FullBroadcast, fmt.Errorf("unknown gossip strategy %q, want full, random or tree", s)
+}
+
+// gossipFanout returns fanout, or sqrt of the validator set size if fanout is not positive, at most size-1
+func gossipFanout(size, fanout int) int {
+	if fanout <= 0 {
+		fanout = int(math.Ceil(math.Sqrt(float64(size))))
+	}
+	if fanout > size-1 {
+		fanout = size - 1
+	}
+	return fanout
+}
+
+// Targets returns the validators self sends a message signed by origin to.
+// If self is origin, the message is sent for the first time, otherwise self relays it.
+func (s GossipStrategy) Targets(valSet ValidatorSet, self, origin common.Address, fanout int) map[common.Address]bool {
+	targets := make(map[common.Address]bool)
+	if valSet == nil || valSet.Size() <= 1 {
+		return targets
+	}
+	size := valSet.Size()
+	fanout = gossipFanout(size, fanout)
+
+	switch s {
+	case RandomGossip:
+		for _, i := range rand.Perm(size) {
+			if len(targets) >= fanout {
+				break
+			}
+			addr := valSet.GetByIndex(int64(i)).Address()
+			if addr != self && addr != origin {
+				targets[addr] = true
+			}
+		}
+	case TreeGossip:
+		selfIndex, _ := valSet.GetByAddress(self)
+		originIndex, _ := valSet.GetByAddress(origin)
+		if selfIndex == -1 || originIndex == -1 {
+			return targets
+		}
+		// the position in the tree is the distance from the origin in the validator list
+		position := (selfIndex - originIndex + size) % size
+		for child := position*fanout + 1; child <= position*fanout+fanout && child < size; child++ {
+			targets[valSet.GetByIndex(int64((originIndex+child)%size)).Address()] = true
+		}
+	default:
+		if self != origin {
+			return valSet.GetNeighbors(self)
+		}
+		for _, val := range valSet.List() {
+			if val.Address() != self {
+				targets[val.Address()] = true
+			}
+		}
+	}
+	return targets
+}
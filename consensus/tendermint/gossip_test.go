@@ -0,0 +1,89 @@
+package tendermint_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+)
+
+func makeGossipValSet(n int) tendermint.ValidatorSet {
+	addrs := make([]common.Address, n)
+	for i := range addrs {
+		addrs[i] = common.BytesToAddress([]byte{byte(i + 1)})
+	}
+	return validator.NewSet(addrs, tendermint.RoundRobin, 0)
+}
+
+// disseminate simulates the relays of a message from origin and returns the validators reached and the number of messages sent
+func disseminate(strategy tendermint.GossipStrategy, valSet tendermint.ValidatorSet, origin common.Address, fanout int) (map[common.Address]bool, int) {
+	var (
+		reached = map[common.Address]bool{origin: true}
+		queue   = []common.Address{origin}
+		sent    int
+	)
+	for len(queue) > 0 {
+		self := queue[0]
+		queue = queue[1:]
+		for target := range strategy.Targets(valSet, self, origin, fanout) {
+			sent++
+			if !reached[target] {
+				reached[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+	return reached, sent
+}
+
+func TestGossipStrategy_Targets(t *testing.T) {
+	var (
+		valSet = makeGossipValSet(10)
+		origin = valSet.GetByIndex(3).Address()
+	)
+	full := tendermint.FullBroadcast.Targets(valSet, origin, origin, 0)
+	require.Len(t, full, 9)
+	require.False(t, full[origin])
+
+	random := tendermint.RandomGossip.Targets(valSet, origin, origin, 3)
+	require.Len(t, random, 3)
+	require.False(t, random[origin])
+
+	// the origin is the root of the tree, its children are the next validators in the list
+	tree := tendermint.TreeGossip.Targets(valSet, origin, origin, 3)
+	require.Equal(t, map[common.Address]bool{
+		valSet.GetByIndex(4).Address(): true,
+		valSet.GetByIndex(5).Address(): true,
+		valSet.GetByIndex(6).Address(): true,
+	}, tree)
+	// the last validators of the tree are leaves
+	require.Empty(t, tendermint.TreeGossip.Targets(valSet, valSet.GetByIndex(2).Address(), origin, 3))
+
+	require.Empty(t, tendermint.TreeGossip.Targets(makeGossipValSet(1), origin, origin, 3))
+}
+
+func TestGossipStrategy_ReachesAllValidators(t *testing.T) {
+	valSet := makeGossipValSet(50)
+	for _, origin := range []common.Address{valSet.GetByIndex(0).Address(), valSet.GetByIndex(27).Address()} {
+		reached, sent := disseminate(tendermint.TreeGossip, valSet, origin, 0)
+		require.Len(t, reached, 50)
+		// every validator receives the message exactly once
+		require.Equal(t, 49, sent)
+
+		reached, _ = disseminate(tendermint.FullBroadcast, valSet, origin, 0)
+		require.Len(t, reached, 50)
+	}
+}
+
+func TestParseGossipStrategy(t *testing.T) {
+	for _, strategy := range []tendermint.GossipStrategy{tendermint.FullBroadcast, tendermint.RandomGossip, tendermint.TreeGossip} {
+		parsed, err := tendermint.ParseGossipStrategy(strategy.String())
+		require.NoError(t, err)
+		require.Equal(t, strategy, parsed)
+	}
+	_, err := tendermint.ParseGossipStrategy("flood")
+	require.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package tendermint
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// RotateValidators returns the validators of the checkpoint block with the keys rotated at or before it
+func RotateValidators(rotations []params.TendermintKeyRotation, checkpoint uint64, validators []common.Address) []common.Address {
+	if len(rotations) == 0 {
+		return validators
+	}
+	result := make([]common.Address, len(validators))
+	copy(result, validators)
+	for _, r := range rotations {
+		if r.Block > checkpoint {
+			break
+		}
+		for i, addr := range result {
+			if addr == r.Old {
+				result[i] = r.New
+			}
+		}
+	}
+	return result
+}
+
+// StakingAddress returns the address the staking contract knows the validator by
+// at the checkpoint block, which is its key before the rotations at or before the checkpoint
+func StakingAddress(rotations []params.TendermintKeyRotation, checkpoint uint64, validator common.Address) common.Address {
+	for i := len(rotations) - 1; i >= 0; i-- {
+		if r := rotations[i]; r.Block <= checkpoint && r.New == validator {
+			validator = r.Old
+		}
+	}
+	return validator
+}
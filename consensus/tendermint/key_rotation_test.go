@@ -0,0 +1,35 @@
+package tendermint_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestKeyRotations(t *testing.T) {
+	var (
+		a, b, c, d = common.Address{1}, common.Address{2}, common.Address{3}, common.Address{4}
+		validators = []common.Address{a, b}
+		rotations  = []params.TendermintKeyRotation{
+			{Block: 10, Old: a, New: c},
+			{Block: 20, Old: c, New: d},
+		}
+	)
+	// the validators are rotated from the checkpoint of the rotation on
+	require.Equal(t, []common.Address{a, b}, tendermint.RotateValidators(rotations, 0, validators))
+	require.Equal(t, []common.Address{c, b}, tendermint.RotateValidators(rotations, 10, validators))
+	require.Equal(t, []common.Address{d, b}, tendermint.RotateValidators(rotations, 20, validators))
+	// the validators passed are left unchanged
+	require.Equal(t, []common.Address{a, b}, validators)
+
+	// the staking contract keeps knowing the validator by its first key
+	require.Equal(t, c, tendermint.StakingAddress(rotations, 0, c))
+	require.Equal(t, a, tendermint.StakingAddress(rotations, 10, c))
+	require.Equal(t, a, tendermint.StakingAddress(rotations, 20, d))
+	require.Equal(t, d, tendermint.StakingAddress(rotations, 10, d))
+	require.Equal(t, b, tendermint.StakingAddress(rotations, 20, b))
+}
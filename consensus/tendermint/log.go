@@ -0,0 +1,89 @@
+package tendermint
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Components of Tendermint consensus which have their own log level
+const (
+	LogCore    = "core"
+	LogTimeout = "timeout"
+	LogBackend = "backend"
+)
+
+var (
+	componentLevelsMu sync.RWMutex
+	// componentLevels keeps the log level of each component. A component level only filters out
+	// the messages more verbose than it, the level of the global logger is still applied.
+	componentLevels = map[string]zap.AtomicLevel{
+		LogCore:    zap.NewAtomicLevelAt(zap.DebugLevel),
+		LogTimeout: zap.NewAtomicLevelAt(zap.DebugLevel),
+		LogBackend: zap.NewAtomicLevelAt(zap.DebugLevel),
+	}
+)
+
+// componentCore wraps a zapcore.Core with the level of a component
+type componentCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c *componentCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *componentCore) With(fields []zapcore.Field) zapcore.Core {
+	return &componentCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *componentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// Logger returns the global zap logger filtered by the level of the given component.
+// Changing the component level by SetLogLevel also applies to the loggers returned before.
+func Logger(component string) *zap.SugaredLogger {
+	componentLevelsMu.RLock()
+	level, ok := componentLevels[component]
+	componentLevelsMu.RUnlock()
+	if !ok {
+		return zap.S().Named(component)
+	}
+	return zap.L().WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &componentCore{Core: core, level: level}
+	})).Named(component).Sugar()
+}
+
+// SetLogLevel updates the log level of a component at runtime, the level is one of debug, info, warn, error.
+func SetLogLevel(component string, level string) error {
+	componentLevelsMu.RLock()
+	defer componentLevelsMu.RUnlock()
+	atomicLevel, ok := componentLevels[component]
+	if !ok {
+		return fmt.Errorf("unknown log component %s", component)
+	}
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return err
+	}
+	atomicLevel.SetLevel(lvl)
+	return nil
+}
+
+// LogLevels returns the current log level of every component
+func LogLevels() map[string]string {
+	componentLevelsMu.RLock()
+	defer componentLevelsMu.RUnlock()
+	levels := make(map[string]string, len(componentLevels))
+	for component, level := range componentLevels {
+		levels[component] = level.String()
+	}
+	return levels
+}
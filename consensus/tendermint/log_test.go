@@ -0,0 +1,36 @@
+package tendermint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSetLogLevel(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	undo := zap.ReplaceGlobals(zap.New(core))
+	defer undo()
+	defer func() {
+		require.NoError(t, SetLogLevel(LogCore, "debug"))
+	}()
+
+	logger := Logger(LogCore)
+	logger.Debugw("debug message")
+	require.Equal(t, 1, logs.Len())
+
+	require.NoError(t, SetLogLevel(LogCore, "warn"))
+	require.Equal(t, "warn", LogLevels()[LogCore])
+	logger.Infow("info message")
+	require.Equal(t, 1, logs.Len())
+	logger.Warnw("warn message")
+	require.Equal(t, 2, logs.Len())
+
+	// other components are not affected
+	Logger(LogTimeout).Debugw("timeout message")
+	require.Equal(t, 3, logs.Len())
+
+	require.Error(t, SetLogLevel("unknown", "info"))
+	require.Error(t, SetLogLevel(LogCore, "verbose"))
+}
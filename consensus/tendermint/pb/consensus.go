@@ -0,0 +1,69 @@
+// Package pb contains the protobuf encoding of the tendermint consensus messages.
+//
+// The types follow consensus.proto, they are kept in sync by hand: the schema only has scalar, bytes and
+// message fields, which the protobuf library encodes from the struct tags without generated descriptors.
+package pb
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+type Envelope struct {
+	Version uint64   `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Code    uint64   `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message *Message `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (m *Envelope) Reset()         { *m = Envelope{} }
+func (m *Envelope) String() string { return proto.CompactTextString(m) }
+func (*Envelope) ProtoMessage()    {}
+
+type Message struct {
+	Code      uint64    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Address   []byte    `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Signature []byte    `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	Vote      *Vote     `protobuf:"bytes,4,opt,name=vote,proto3" json:"vote,omitempty"`
+	Proposal  *Proposal `protobuf:"bytes,5,opt,name=proposal,proto3" json:"proposal,omitempty"`
+	Msg       []byte    `protobuf:"bytes,6,opt,name=msg,proto3" json:"msg,omitempty"`
+}
+
+func (m *Message) Reset()         { *m = Message{} }
+func (m *Message) String() string { return proto.CompactTextString(m) }
+func (*Message) ProtoMessage()    {}
+
+type Vote struct {
+	BlockHash   []byte `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockNumber []byte `protobuf:"bytes,2,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	Round       int64  `protobuf:"varint,3,opt,name=round,proto3" json:"round,omitempty"`
+	Seal        []byte `protobuf:"bytes,4,opt,name=seal,proto3" json:"seal,omitempty"`
+	TraceId     string `protobuf:"bytes,5,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+}
+
+func (m *Vote) Reset()         { *m = Vote{} }
+func (m *Vote) String() string { return proto.CompactTextString(m) }
+func (*Vote) ProtoMessage()    {}
+
+type Proposal struct {
+	Block    []byte `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Round    int64  `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
+	PolRound int64  `protobuf:"varint,3,opt,name=pol_round,json=polRound,proto3" json:"pol_round,omitempty"`
+	TraceId  string `protobuf:"bytes,4,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+}
+
+func (m *Proposal) Reset()         { *m = Proposal{} }
+func (m *Proposal) String() string { return proto.CompactTextString(m) }
+func (*Proposal) ProtoMessage()    {}
+
+type SignBytes struct {
+	Domain      string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	ChainId     []byte `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	BlockNumber []byte `protobuf:"bytes,3,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	Round       int64  `protobuf:"varint,4,opt,name=round,proto3" json:"round,omitempty"`
+	Code        uint64 `protobuf:"varint,5,opt,name=code,proto3" json:"code,omitempty"`
+	Msg         []byte `protobuf:"bytes,6,opt,name=msg,proto3" json:"msg,omitempty"`
+	Address     []byte `protobuf:"bytes,7,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (m *SignBytes) Reset()         { *m = SignBytes{} }
+func (m *SignBytes) String() string { return proto.CompactTextString(m) }
+func (*SignBytes) ProtoMessage()    {}
@@ -0,0 +1,68 @@
+package tendermint
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// recoveryDomain separates the approvals of a recovery from the other data signed by the validators
+const recoveryDomain = "tendermint-recovery"
+
+// RecoveryAt returns the recovery replacing the validator set of block number, nil if there is none.
+// A recovery replaces it from its block to the first checkpoint at or after it.
+func RecoveryAt(recoveries []params.TendermintRecovery, epoch uint64, number uint64) *params.TendermintRecovery {
+	for i := len(recoveries) - 1; i >= 0; i-- {
+		r := &recoveries[i]
+		if r.Block > number {
+			continue
+		}
+		end := r.Block
+		if epoch != 0 && end%epoch != 0 {
+			end += epoch - end%epoch
+		}
+		if number <= end {
+			return r
+		}
+		return nil
+	}
+	return nil
+}
+
+// RecoverySigningData returns the data a validator signs to approve the recovery on the chain of chainID
+func RecoverySigningData(chainID *big.Int, recovery params.TendermintRecovery) ([]byte, error) {
+	if chainID == nil {
+		chainID = new(big.Int)
+	}
+	return rlp.EncodeToBytes([]interface{}{recoveryDomain, chainID, recovery.Block, recovery.Validators})
+}
+
+// VerifyRecovery checks that the validators of the recovery are validators of its block before the recovery,
+// and that each of them signed its approval
+func VerifyRecovery(chainID *big.Int, recovery params.TendermintRecovery, validators []common.Address) error {
+	if len(recovery.Validators) == 0 || len(recovery.Approvals) != len(recovery.Validators) {
+		return ErrInvalidRecoveryApproval
+	}
+	previous := make(map[common.Address]bool, len(validators))
+	for _, addr := range validators {
+		previous[addr] = true
+	}
+	data, err := RecoverySigningData(chainID, recovery)
+	if err != nil {
+		return err
+	}
+	hash := crypto.Keccak256(data)
+	for i, addr := range recovery.Validators {
+		if !previous[addr] {
+			return ErrRecoveryNotValidator
+		}
+		pubKey, err := crypto.SigToPub(hash, recovery.Approvals[i])
+		if err != nil || crypto.PubkeyToAddress(*pubKey) != addr {
+			return ErrInvalidRecoveryApproval
+		}
+	}
+	return nil
+}
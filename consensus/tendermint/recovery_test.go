@@ -0,0 +1,44 @@
+package tendermint_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestRecoveryAt(t *testing.T) {
+	recoveries := []params.TendermintRecovery{{Block: 15}, {Block: 30}}
+	// a recovery lasts until the first checkpoint at or after its block
+	require.Nil(t, tendermint.RecoveryAt(recoveries, 10, 14))
+	require.Equal(t, uint64(15), tendermint.RecoveryAt(recoveries, 10, 15).Block)
+	require.Equal(t, uint64(15), tendermint.RecoveryAt(recoveries, 10, 20).Block)
+	require.Nil(t, tendermint.RecoveryAt(recoveries, 10, 21))
+	require.Equal(t, uint64(30), tendermint.RecoveryAt(recoveries, 10, 30).Block)
+	require.Nil(t, tendermint.RecoveryAt(recoveries, 10, 31))
+}
+
+func TestVerifyRecovery(t *testing.T) {
+	var (
+		chainID  = big.NewInt(15)
+		key, _   = crypto.GenerateKey()
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		recovery = params.TendermintRecovery{Block: 15, Validators: []common.Address{addr}}
+	)
+	data, err := tendermint.RecoverySigningData(chainID, recovery)
+	require.NoError(t, err)
+	sig, err := crypto.Sign(crypto.Keccak256(data), key)
+	require.NoError(t, err)
+	recovery.Approvals = []hexutil.Bytes{sig}
+
+	require.NoError(t, tendermint.VerifyRecovery(chainID, recovery, []common.Address{{1}, addr}))
+	require.Equal(t, tendermint.ErrRecoveryNotValidator, tendermint.VerifyRecovery(chainID, recovery, []common.Address{{1}}))
+	// the approval is bound to the chain
+	require.Equal(t, tendermint.ErrInvalidRecoveryApproval, tendermint.VerifyRecovery(big.NewInt(16), recovery, []common.Address{addr}))
+}
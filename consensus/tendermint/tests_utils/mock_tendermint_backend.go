@@ -151,7 +151,7 @@ func (mb *MockBackend) FindExistingPeers(valSet tendermint.ValidatorSet) map[com
 }
 
 //Commit implement tendermint.Backend.Commit()
-func (mb *MockBackend) Commit(block *types.Block) {
+func (mb *MockBackend) Commit(block *types.Block, round int64) {
 	log.Error("not implemented")
 }
 
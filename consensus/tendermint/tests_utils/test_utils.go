@@ -1,7 +1,6 @@
 package tests_utils
 
 import (
-	"bytes"
 	"crypto/ecdsa"
 	"math/big"
 	"testing"
@@ -15,7 +14,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
-	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/params"
 )
 
 // DefaultTestConfig with time is smaller than DefaultConfig for speed-up
@@ -65,22 +64,11 @@ func MakeGenesisHeader(validators []common.Address) *types.Header {
 		ParentHash: common.HexToHash("0x01"),
 		UncleHash:  types.CalcUncleHash(nil),
 		Root:       common.HexToHash("0x0"),
+		GasLimit:   params.GenesisGasLimit,
 		Difficulty: big.NewInt(1),
 		MixDigest:  types.TendermintDigest,
 	}
-	extra, _ := PrepareExtra(header)
-
-	var buf bytes.Buffer
-	buf.Write(extra[:types.TendermintExtraVanity])
-	valSetData, _ := rlp.EncodeToBytes(validators)
-	tdm := &types.TendermintExtra{
-		Seal:          []byte{},
-		CommittedSeal: [][]byte{},
-		ValidatorAdds: valSetData,
-	}
-	payload, _ := rlp.EncodeToBytes(&tdm)
-
-	header.Extra = append(buf.Bytes(), payload...)
+	header.Extra, _ = utils.GenesisExtra(validators)
 	return header
 }
 
@@ -141,8 +129,10 @@ func makeHeaderFromParent(parent *types.Block) *types.Header {
 	header := &types.Header{
 		Coinbase:   GetAddress(),
 		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
 		Number:     parent.Number().Add(parent.Number(), common.Big1),
 		GasLimit:   core.CalcGasLimit(parent, parent.GasLimit(), parent.GasLimit()),
+		Time:       parent.Time() + 1,
 		GasUsed:    0,
 		Difficulty: big.NewInt(1),
 		MixDigest:  types.TendermintDigest,
@@ -162,19 +152,5 @@ func GeneratePrivateKey() (*ecdsa.PrivateKey, error) {
 
 // PrepareExtra returns a extra-data of the given header and validators
 func PrepareExtra(header *types.Header) ([]byte, error) {
-	var buf bytes.Buffer
-
-	// compensate the lack bytes if header.Extra is not enough TendermintExtraVanity bytes.
-	if len(header.Extra) < types.TendermintExtraVanity {
-		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, types.TendermintExtraVanity-len(header.Extra))...)
-	}
-	buf.Write(header.Extra[:types.TendermintExtraVanity])
-
-	tdm := &types.TendermintExtra{}
-	payload, err := rlp.EncodeToBytes(&tdm)
-	if err != nil {
-		return nil, err
-	}
-
-	return append(buf.Bytes(), payload...), nil
+	return (&types.TendermintExtra{}).Encode(header.Extra)
 }
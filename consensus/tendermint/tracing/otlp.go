@@ -0,0 +1,173 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+const (
+	maxQueuedSpans = 2048            // the number of ended spans waiting for the export, the next ones are dropped
+	maxBatchSize   = 256             // the number of spans exported at once
+	exportInterval = 2 * time.Second // the interval the queued spans are exported at if the batch is not full
+	exportTimeout  = 10 * time.Second
+
+	tracesPath      = "/v1/traces"
+	instrumentation = "github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+
+	spanKindInternal = 1 // SPAN_KIND_INTERNAL
+	statusCodeError  = 2 // STATUS_CODE_ERROR
+)
+
+// exporter posts the spans to an OTLP/HTTP collector with the JSON encoding
+type exporter struct {
+	url      string
+	resource []otlpKeyValue
+	client   *http.Client
+}
+
+// newExporter returns an exporter to endpoint, the traces path is appended if endpoint has no path
+func newExporter(endpoint string, resource []Attribute) *exporter {
+	if u, err := url.Parse(endpoint); err == nil && (u.Path == "" || u.Path == "/") {
+		u.Path = tracesPath
+		endpoint = u.String()
+	}
+	return &exporter{
+		url:      endpoint,
+		resource: encodeAttributes(resource),
+		client:   &http.Client{Timeout: exportTimeout},
+	}
+}
+
+func (e *exporter) export(spans []*Span) error {
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Debug("Failed to export the consensus spans", "url", e.url, "spans", len(spans), "err", err)
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		log.Debug("Consensus spans rejected by the collector", "url", e.url, "spans", len(spans), "status", resp.Status)
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below are the subset of the OTLP/JSON trace request the tracer fills,
+// see https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"` // 64 bits integers are strings in the JSON encoding
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func (e *exporter) encode(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.attributes),
+		}
+		if s.parentID != (SpanID{}) {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != nil {
+			span.Status = &otlpStatus{Code: statusCodeError, Message: s.err.Error()}
+		}
+		s.mu.Unlock()
+		encoded = append(encoded, span)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: e.resource},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: instrumentation},
+			Spans: encoded,
+		}},
+	}}}
+}
+
+func encodeAttributes(attrs []Attribute) []otlpKeyValue {
+	encoded := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var value otlpAnyValue
+		switch v := attr.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		case bool:
+			value.BoolValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		encoded = append(encoded, otlpKeyValue{Key: attr.Key, Value: value})
+	}
+	return encoded
+}
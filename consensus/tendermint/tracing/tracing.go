@@ -0,0 +1,227 @@
+// Package tracing records the spans of the consensus steps and exports them to an OpenTelemetry collector
+// with the OTLP/HTTP JSON encoding.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+)
+
+var (
+	exportedSpansMeter = metrics.NewRegisteredMeter("evr/consensus/tendermint/tracing/exported", nil)
+	droppedSpansMeter  = metrics.NewRegisteredMeter("evr/consensus/tendermint/tracing/dropped", nil)
+)
+
+// TraceID identifies the spans of a trace
+type TraceID [16]byte
+
+// SpanID identifies a span in its trace
+type SpanID [8]byte
+
+// HeightTraceID returns the trace id of the consensus of block number, it is the same on every node
+// so the spans of the nodes of a fleet for a height are in a single trace.
+func HeightTraceID(number uint64) TraceID {
+	var (
+		id  TraceID
+		buf [8]byte
+	)
+	binary.BigEndian.PutUint64(buf[:], number)
+	copy(id[:], crypto.Keccak256([]byte("tendermint/height"), buf[:]))
+	return id
+}
+
+// Attribute is a key value pair describing a span
+type Attribute struct {
+	Key   string
+	Value interface{} // string, int64 or bool
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int64 returns an integer attribute
+func Int64(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is a timed operation of a trace. All the methods of a nil span do nothing.
+type Span struct {
+	tracer *Tracer
+
+	mu         sync.Mutex
+	traceID    TraceID
+	spanID     SpanID
+	parentID   SpanID // zero for the root span of a trace
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes []Attribute
+	err        error
+}
+
+// SetAttributes adds attrs to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes = append(s.attributes, attrs...)
+}
+
+// SetError marks the span as failed with err, a nil err is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End ends the span and queues it for the export, the calls after the first one are ignored
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.enqueue(s)
+}
+
+// Tracer creates the spans and exports the ended ones in batches. All the methods of a nil tracer
+// do nothing and return nil spans, so a disabled tracing costs a nil check.
+type Tracer struct {
+	exporter *exporter
+
+	spans    chan *Span
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewTracer returns a tracer exporting the spans to the OTLP/HTTP endpoint, the resource attributes
+// describe the node the spans come from.
+func NewTracer(endpoint string, resource ...Attribute) *Tracer {
+	t := &Tracer{
+		exporter: newExporter(endpoint, resource),
+		spans:    make(chan *Span, maxQueuedSpans),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+// StartTrace starts the root span of the trace id
+func (t *Tracer) StartTrace(id TraceID, name string, attrs ...Attribute) *Span {
+	if t == nil {
+		return nil
+	}
+	return t.newSpan(id, SpanID{}, name, attrs)
+}
+
+// Start starts a span, child of parent if any, or the root of a new trace otherwise
+func (t *Tracer) Start(parent *Span, name string, attrs ...Attribute) *Span {
+	if t == nil {
+		return nil
+	}
+	if parent == nil {
+		var id TraceID
+		rand.Read(id[:])
+		return t.newSpan(id, SpanID{}, name, attrs)
+	}
+	return t.newSpan(parent.traceID, parent.spanID, name, attrs)
+}
+
+// Stop exports the queued spans and stops the tracer, the spans ended afterward are dropped
+func (t *Tracer) Stop() {
+	if t == nil {
+		return
+	}
+	t.stopOnce.Do(func() {
+		close(t.quit)
+		<-t.done
+	})
+}
+
+func (t *Tracer) newSpan(traceID TraceID, parentID SpanID, name string, attrs []Attribute) *Span {
+	s := &Span{
+		tracer:     t,
+		traceID:    traceID,
+		parentID:   parentID,
+		name:       name,
+		start:      time.Now(),
+		attributes: attrs,
+	}
+	rand.Read(s.spanID[:])
+	return s
+}
+
+// enqueue queues an ended span for the export, it never blocks the consensus and drops the span if the queue is full
+func (t *Tracer) enqueue(s *Span) {
+	select {
+	case <-t.quit:
+		droppedSpansMeter.Mark(1)
+	case t.spans <- s:
+	default:
+		droppedSpansMeter.Mark(1)
+	}
+}
+
+func (t *Tracer) loop() {
+	defer close(t.done)
+	var (
+		ticker = time.NewTicker(exportInterval)
+		batch  = make([]*Span, 0, maxBatchSize)
+	)
+	defer ticker.Stop()
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.exporter.export(batch); err != nil {
+			droppedSpansMeter.Mark(int64(len(batch)))
+		} else {
+			exportedSpansMeter.Mark(int64(len(batch)))
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case s := <-t.spans:
+			if batch = append(batch, s); len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.quit:
+			for {
+				select {
+				case s := <-t.spans:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
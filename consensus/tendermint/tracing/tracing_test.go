@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracer_Export(t *testing.T) {
+	requests := make(chan otlpRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, tracesPath, r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var req otlpRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests <- req
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(server.URL, String("service.name", "gev"))
+	height := tracer.StartTrace(HeightTraceID(10), "height", Int64("height", 10))
+	step := tracer.Start(height, "RoundStepPropose", Int64("round", 0))
+	step.SetError(errors.New("timeout"))
+	step.End()
+	step.End()
+	height.End()
+	tracer.Stop()
+
+	req := <-requests
+	require.Len(t, req.ResourceSpans, 1)
+	require.Equal(t, "service.name", req.ResourceSpans[0].Resource.Attributes[0].Key)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	traceID := HeightTraceID(10)
+	require.Equal(t, hex.EncodeToString(traceID[:]), spans[0].TraceID)
+	require.Equal(t, spans[0].TraceID, spans[1].TraceID)
+	require.Equal(t, "RoundStepPropose", spans[0].Name)
+	require.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	require.Equal(t, "0", *spans[0].Attributes[0].Value.IntValue)
+	require.Equal(t, statusCodeError, spans[0].Status.Code)
+	require.Empty(t, spans[1].ParentSpanID)
+	require.Nil(t, spans[1].Status)
+}
+
+func TestTracer_Nil(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start(nil, "sign")
+	require.Nil(t, span)
+	span.SetAttributes(Bool("ok", true))
+	span.SetError(errors.New("failed"))
+	span.End()
+	tracer.Stop()
+}
+
+func TestHeightTraceID(t *testing.T) {
+	require.Equal(t, HeightTraceID(1), HeightTraceID(1))
+	require.NotEqual(t, HeightTraceID(1), HeightTraceID(2))
+}
@@ -3,24 +3,33 @@ package utils
 import (
 	"bytes"
 	"errors"
+	"math/big"
 
+	"github.com/golang/protobuf/proto"
 	"golang.org/x/crypto/sha3"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/pb"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
 var (
-	ErrInvalidSealLength = errors.New("seal is expected to be multiplication of 65")
+	ErrInvalidSealLength  = errors.New("seal is expected to be multiplication of 65")
+	ErrInvalidCommitRound = errors.New("commit round is negative")
 )
 
 const (
 	msgCommit uint64 = iota
 )
 
+// commitSigningDomain is the domain of the committed seal sign bytes, it differs from the domain of the consensus
+// messages so that a committed seal can never be replayed as a message signature or the other way round.
+const commitSigningDomain = "evrynet-tendermint-commit-v2"
+
 // sigHash returns the hash
 // signing. It is the hash of the entire header apart from the 65 byte signature
 // contained at the end of the extra data.
@@ -47,13 +56,7 @@ func WriteSeal(h *types.Header, seal []byte) error {
 	}
 
 	tendermintExtra.Seal = seal
-	payload, err := rlp.EncodeToBytes(&tendermintExtra)
-	if err != nil {
-		return err
-	}
-
-	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
-	return nil
+	return types.WriteTendermintExtra(h, tendermintExtra)
 }
 
 // WriteValSet writes the extra-data field of the given header with the given val-sets address.
@@ -63,20 +66,23 @@ func WriteValSet(h *types.Header, validators []common.Address) error {
 		return err
 	}
 
-	// RLP encode validator's address to bytes
-	valSetData, err := rlp.EncodeToBytes(validators)
-	if err != nil {
+	if err := tendermintExtra.SetValidators(validators); err != nil {
 		return err
 	}
-	tendermintExtra.ValidatorAdds = valSetData
+	return types.WriteTendermintExtra(h, tendermintExtra)
+}
 
-	payload, err := rlp.EncodeToBytes(&tendermintExtra)
-	if err != nil {
-		return err
+// GenesisExtra returns the extra-data of a genesis block whose validator set is validators:
+// the vanity followed by the tendermint extra with the RLP encoded validators' addresses.
+func GenesisExtra(validators []common.Address) ([]byte, error) {
+	tendermintExtra := &types.TendermintExtra{
+		Seal:          []byte{},
+		CommittedSeal: [][]byte{},
 	}
-
-	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
-	return nil
+	if err := tendermintExtra.SetValidators(validators); err != nil {
+		return nil, err
+	}
+	return tendermintExtra.Encode(nil)
 }
 
 // WriteCommittedSeals writes the extra-data field of a block header with given committed seals.
@@ -98,14 +104,38 @@ func WriteCommittedSeals(h *types.Header, committedSeals [][]byte) error {
 
 	tendermintExtra.CommittedSeal = make([][]byte, len(committedSeals))
 	copy(tendermintExtra.CommittedSeal, committedSeals)
+	return types.WriteTendermintExtra(h, tendermintExtra)
+}
 
-	payload, err := rlp.EncodeToBytes(&tendermintExtra)
+// WriteCommitRound writes the extra-data field of a block header with the round it is committed in.
+func WriteCommitRound(h *types.Header, round int64) error {
+	if round < 0 {
+		return ErrInvalidCommitRound
+	}
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
 	if err != nil {
 		return err
 	}
 
-	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
-	return nil
+	tendermintExtra.Round = uint64(round)
+	return types.WriteTendermintExtra(h, tendermintExtra)
+}
+
+// WriteParentCommit writes the extra-data field of a block header with the committed seals of its parent.
+func WriteParentCommit(h *types.Header, committedSeals [][]byte) error {
+	for _, seal := range committedSeals {
+		if len(seal) != types.TendermintExtraSeal {
+			return ErrInvalidSealLength
+		}
+	}
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return err
+	}
+
+	tendermintExtra.ParentCommit = make([][]byte, len(committedSeals))
+	copy(tendermintExtra.ParentCommit, committedSeals)
+	return types.WriteTendermintExtra(h, tendermintExtra)
 }
 
 // GetSignatureAddress gets the signer address from the signature
@@ -128,6 +158,39 @@ func PrepareCommittedSeal(hash common.Hash) []byte {
 	return buf.Bytes()
 }
 
+// PrepareCommittedSealAt returns the committed seal data for the hash of the block blockNumber.
+// From the protobuf signing block on, the seal signs the same pb.SignBytes as the consensus messages with the
+// commit domain, the chain id and the block number, so that it can't be replayed on another network.
+// Before that block, or if no chain id is configured, it is the legacy seal of PrepareCommittedSeal.
+func PrepareCommittedSealAt(config *tendermint.Config, blockNumber *big.Int, hash common.Hash) []byte {
+	if config == nil || config.ChainID == nil || config.ProtobufSigningBlock == nil || blockNumber == nil ||
+		blockNumber.Cmp(config.ProtobufSigningBlock) < 0 {
+		return PrepareCommittedSeal(hash)
+	}
+	data, err := proto.Marshal(&pb.SignBytes{
+		Domain:      commitSigningDomain,
+		ChainId:     config.ChainID.Bytes(),
+		BlockNumber: blockNumber.Bytes(),
+		Code:        msgCommit,
+		Msg:         hash.Bytes(),
+	})
+	if err != nil {
+		// SignBytes only has scalar and bytes fields, marshalling it can't fail
+		panic(err)
+	}
+	return data
+}
+
+// SealConfig returns the part of the tendermint config the committed seals of the chain depend on,
+// for the code which only has the chain config at hand
+func SealConfig(config *params.ChainConfig) *tendermint.Config {
+	sealConfig := &tendermint.Config{ChainID: config.ChainID}
+	if config.Tendermint != nil {
+		sealConfig.ProtobufSigningBlock = config.Tendermint.ProtobufSigningBlock
+	}
+	return sealConfig
+}
+
 // GetCheckpointNumber returns check-point block where header contains valset of current epoch
 func GetCheckpointNumber(epochDuration uint64, blockNumber uint64) uint64 {
 	if blockNumber == 0 || blockNumber < epochDuration {
@@ -142,16 +205,59 @@ func GetValSetAddresses(h *types.Header) ([]common.Address, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(tdmExtra.ValidatorAdds) == 0 {
+	validators, err := tdmExtra.Validators()
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
 		return nil, tendermint.ErrEmptyValSet
 	}
+	return validators, nil
+}
+
+// NewMissingValidators returns a bitmap of size validators where the bit of every index not in committed is set.
+func NewMissingValidators(size int, committed map[int]bool) []byte {
+	bitmap := make([]byte, (size+7)/8)
+	for i := 0; i < size; i++ {
+		if !committed[i] {
+			bitmap[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return bitmap
+}
 
-	// RLP decode validator's address from bytes
-	var validators []common.Address
-	err = rlp.DecodeBytes(tdmExtra.ValidatorAdds, &validators)
+// IsValidatorMissing returns true if the bit of the validator's index is set in the bitmap.
+func IsValidatorMissing(bitmap []byte, index int) bool {
+	if index < 0 || index/8 >= len(bitmap) {
+		return false
+	}
+	return bitmap[index/8]&(1<<uint(index%8)) != 0
+}
+
+// WriteMissingValidators writes the extra-data field of the given header with the given missing validators bitmap.
+func WriteMissingValidators(h *types.Header, bitmap []byte) error {
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return err
+	}
+
+	tendermintExtra.MissingValidators = bitmap
+	return types.WriteTendermintExtra(h, tendermintExtra)
+}
+
+// GetMissingValidators returns the addresses of validators whose precommits are absent from the committed seals of the header.
+// valSet must be the validator set which committed the header.
+func GetMissingValidators(h *types.Header, valSet tendermint.ValidatorSet) ([]common.Address, error) {
+	tdmExtra, err := types.ExtractTendermintExtra(h)
 	if err != nil {
 		return nil, err
 	}
 
-	return validators, nil
+	var missing []common.Address
+	for i, val := range valSet.List() {
+		if IsValidatorMissing(tdmExtra.MissingValidators, i) {
+			missing = append(missing, val.Address())
+		}
+	}
+	return missing, nil
 }
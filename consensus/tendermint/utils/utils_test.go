@@ -1,6 +1,17 @@
 package utils
 
-import "testing"
+import (
+	"math/big"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/pb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
 
 func TestGetCheckpointNumber(t *testing.T) {
 	type args struct {
@@ -45,3 +56,59 @@ func TestGetCheckpointNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestMissingValidators(t *testing.T) {
+	bitmap := NewMissingValidators(10, map[int]bool{0: true, 2: true, 9: true})
+	if len(bitmap) != 2 {
+		t.Fatalf("bitmap length = %d, want 2", len(bitmap))
+	}
+	for i := 0; i < 10; i++ {
+		want := i != 0 && i != 2 && i != 9
+		if got := IsValidatorMissing(bitmap, i); got != want {
+			t.Errorf("IsValidatorMissing(%d) = %v, want %v", i, got, want)
+		}
+	}
+	if IsValidatorMissing(bitmap, 16) {
+		t.Errorf("index out of bitmap must not be missing")
+	}
+}
+
+func TestGenesisExtra(t *testing.T) {
+	validators := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	extra, err := GenesisExtra(validators)
+	require.NoError(t, err)
+
+	header := &types.Header{Extra: extra}
+	decoded, err := GetValSetAddresses(header)
+	require.NoError(t, err)
+	require.Equal(t, validators, decoded)
+	tendermintExtra, err := types.ExtractTendermintExtra(header)
+	require.NoError(t, err)
+	require.Empty(t, tendermintExtra.Seal)
+	require.Empty(t, tendermintExtra.CommittedSeal)
+}
+
+func TestPrepareCommittedSealAt(t *testing.T) {
+	var (
+		hash   = common.HexToHash("0x1234")
+		config = &tendermint.Config{ChainID: big.NewInt(1), ProtobufSigningBlock: big.NewInt(10)}
+		other  = &tendermint.Config{ChainID: big.NewInt(2), ProtobufSigningBlock: big.NewInt(10)}
+	)
+	// the legacy seal is kept before the fork and without chain id
+	require.Equal(t, PrepareCommittedSeal(hash), PrepareCommittedSealAt(config, big.NewInt(9), hash))
+	require.Equal(t, PrepareCommittedSeal(hash), PrepareCommittedSealAt(&tendermint.Config{ProtobufSigningBlock: big.NewInt(10)}, big.NewInt(10), hash))
+	require.Equal(t, PrepareCommittedSeal(hash), PrepareCommittedSealAt(nil, big.NewInt(10), hash))
+
+	// from the fork on, the seal signs the commit domain, the chain id and the block number
+	seal := PrepareCommittedSealAt(config, big.NewInt(10), hash)
+	var signBytes pb.SignBytes
+	require.NoError(t, proto.Unmarshal(seal, &signBytes))
+	require.Equal(t, commitSigningDomain, signBytes.Domain)
+	require.Equal(t, int64(1), new(big.Int).SetBytes(signBytes.ChainId).Int64())
+	require.Equal(t, int64(10), new(big.Int).SetBytes(signBytes.BlockNumber).Int64())
+	require.Equal(t, hash.Bytes(), signBytes.Msg)
+
+	// so that it is not valid on another network nor at another height
+	require.NotEqual(t, seal, PrepareCommittedSealAt(other, big.NewInt(10), hash))
+	require.NotEqual(t, seal, PrepareCommittedSealAt(config, big.NewInt(11), hash))
+}
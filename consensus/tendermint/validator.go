@@ -57,6 +57,10 @@ type ValidatorSet interface {
 	Copy() ValidatorSet
 	// Get the minimum number of votes for a polka
 	MinMajority() int
+	// VotingPower returns the voting power of a validator: 1 unless the set votes by weight, 0 if it is not in the set
+	VotingPower(address common.Address) int
+	// QuorumPower returns the minimum voting power of a polka: MinMajority unless the set votes by weight
+	QuorumPower() int
 	// Get the minimum number of peers to archive consensus
 	MinPeers() int
 	// Get the maximum number of faulty nodes
@@ -37,6 +37,13 @@ type defaultSet struct {
 	selector    tendermint.ProposalSelector
 
 	height int64 // current height when backend init validator set
+
+	// the schedule of the StakeWeighted policy, see buildSchedule
+	weights  []uint64 // the proposer weight of each validator
+	schedule []int    // the indexes of the validators in the order they propose
+	slot     int64    // the position of the proposer in schedule
+
+	weightedVoting bool // the validators vote with their weights, see NewWeightedVotingSet
 }
 
 func newDefaultSet(addrs []common.Address, policy tendermint.ProposerPolicy, height int64) *defaultSet {
@@ -69,6 +76,9 @@ func newDefaultSet(addrs []common.Address, policy tendermint.ProposerPolicy, hei
 	}
 
 	valSet.height = height
+	if policy == tendermint.StakeWeighted {
+		valSet.setWeights(addrs, nil)
+	}
 
 	return valSet
 }
@@ -159,6 +169,10 @@ func (valSet *defaultSet) AddValidator(address common.Address) bool {
 		}
 	}
 	valSet.validators = append(valSet.validators, New(address))
+	if valSet.policy == tendermint.StakeWeighted {
+		valSet.weights = append(valSet.weights, valSet.minWeight())
+		valSet.buildSchedule()
+	}
 	return true
 }
 
@@ -170,6 +184,10 @@ func (valSet *defaultSet) RemoveValidator(address common.Address) bool {
 	for i, v := range valSet.validators {
 		if v.Address() == address {
 			valSet.validators = append(valSet.validators[:i], valSet.validators[i+1:]...)
+			if valSet.policy == tendermint.StakeWeighted {
+				valSet.weights = append(valSet.weights[:i], valSet.weights[i+1:]...)
+				valSet.buildSchedule()
+			}
 			return true
 		}
 	}
@@ -185,6 +203,15 @@ func (valSet *defaultSet) Copy() tendermint.ValidatorSet {
 	for _, v := range valSet.validators {
 		addresses = append(addresses, v.Address())
 	}
+	if valSet.policy == tendermint.StakeWeighted {
+		// the copy keeps the proposer's slot, as a validator has several slots in the schedule
+		cpy := newDefaultSet(addresses, valSet.policy, valSet.height)
+		cpy.setWeights(addresses, valSet.weights)
+		cpy.slot = valSet.slot
+		cpy.proposer = cpy.proposerAt(cpy.slot)
+		cpy.weightedVoting = valSet.weightedVoting
+		return cpy
+	}
 	return NewSet(addresses, valSet.policy, valSet.height)
 }
 
@@ -234,6 +261,10 @@ func (valSet *defaultSet) GetNeighbors(addr common.Address) map[common.Address]b
 func (valSet *defaultSet) CalcProposer(lastProposer common.Address, roundDiff int64) {
 	valSet.validatorMu.RLock()
 	defer valSet.validatorMu.RUnlock()
+	if valSet.policy == tendermint.StakeWeighted {
+		valSet.calcWeightedProposer(lastProposer, roundDiff)
+		return
+	}
 	valSet.proposer = valSet.selector(valSet, lastProposer, roundDiff)
 }
 
@@ -0,0 +1,216 @@
+package validator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+)
+
+const (
+	// stakeWeightPrecision is the weight of the smallest stake, so that the stakes are weighted by quarters of it
+	stakeWeightPrecision = 4
+	// maxStakeWeight caps the weight of a stake, so that the schedule stays short whatever the stakes are
+	maxStakeWeight = 64 * stakeWeightPrecision
+)
+
+// NewWeightedSet creates a validator set with the StakeWeighted policy, weights[i] being the proposer weight
+// of addrs[i]. A validator proposes weights[i] times per cycle of the schedule, a missing or zero weight counts as 1.
+func NewWeightedSet(addrs []common.Address, weights []uint64, height int64) tendermint.ValidatorSet {
+	valSet := newDefaultSet(addrs, tendermint.StakeWeighted, height)
+	valSet.setWeights(addrs, weights)
+	return valSet
+}
+
+// NewWeightedVotingSet creates a validator set with the StakeWeighted policy whose validators also vote with their weights:
+// a polka needs more than 2/3 of the total weight instead of more than 2/3 of the validators
+func NewWeightedVotingSet(addrs []common.Address, weights []uint64, height int64) tendermint.ValidatorSet {
+	valSet := newDefaultSet(addrs, tendermint.StakeWeighted, height)
+	valSet.setWeights(addrs, weights)
+	valSet.weightedVoting = true
+	return valSet
+}
+
+// StakeWeights returns the proposer weights of stakes: proportional to the stakes relative to the smallest one,
+// rounded down to a quarter of it and capped at 64 times it. A nil or zero stake weighs as the smallest one.
+// It only depends on the stakes, so that every node derives the same schedule.
+func StakeWeights(stakes []*big.Int) []uint64 {
+	var min *big.Int
+	for _, stake := range stakes {
+		if stake != nil && stake.Sign() > 0 && (min == nil || stake.Cmp(min) < 0) {
+			min = stake
+		}
+	}
+	weights := make([]uint64, len(stakes))
+	for i, stake := range stakes {
+		if min == nil || stake == nil || stake.Sign() <= 0 {
+			weights[i] = stakeWeightPrecision
+			continue
+		}
+		weight := new(big.Int).Mul(stake, big.NewInt(stakeWeightPrecision))
+		weight.Div(weight, min)
+		if weight.Cmp(big.NewInt(maxStakeWeight)) > 0 {
+			weights[i] = maxStakeWeight
+		} else {
+			weights[i] = weight.Uint64()
+		}
+	}
+	return weights
+}
+
+// setWeights sets the weights of the validators, weights[i] being the one of addrs[i],
+// and moves the proposer to the first slot of the height in the new schedule
+func (valSet *defaultSet) setWeights(addrs []common.Address, weights []uint64) {
+	valSet.validatorMu.Lock()
+	defer valSet.validatorMu.Unlock()
+	byAddress := make(map[common.Address]uint64, len(addrs))
+	for i, addr := range addrs {
+		if i < len(weights) {
+			byAddress[addr] = weights[i]
+		}
+	}
+	valSet.weights = make([]uint64, len(valSet.validators))
+	for i, val := range valSet.validators {
+		valSet.weights[i] = byAddress[val.Address()]
+	}
+	valSet.buildSchedule()
+	valSet.slot = 0
+	if len(valSet.schedule) > 0 {
+		shiftHeight := valSet.height
+		if shiftHeight > 0 {
+			shiftHeight = shiftHeight - 1
+		}
+		valSet.slot = shiftHeight % int64(len(valSet.schedule))
+	}
+	valSet.proposer = valSet.proposerAt(valSet.slot)
+}
+
+// buildSchedule computes the order the validators propose in over a cycle with the smooth weighted round robin:
+// at each slot, every validator gains its weight, the one with the most proposes and loses the total weight.
+// The validators with the larger weights propose more often, interleaved with the others.
+func (valSet *defaultSet) buildSchedule() {
+	var gcd uint64
+	for i, weight := range valSet.weights {
+		if weight == 0 {
+			valSet.weights[i] = 1
+		}
+		gcd = greatestCommonDivisor(gcd, valSet.weights[i])
+	}
+	var total int64
+	for i := range valSet.weights {
+		valSet.weights[i] /= gcd
+		total += int64(valSet.weights[i])
+	}
+	var (
+		current  = make([]int64, len(valSet.weights))
+		schedule = make([]int, 0, total)
+	)
+	for slot := int64(0); slot < total; slot++ {
+		best := -1
+		for i, weight := range valSet.weights {
+			current[i] += int64(weight)
+			if best == -1 || current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		schedule = append(schedule, best)
+	}
+	valSet.schedule = schedule
+}
+
+func greatestCommonDivisor(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// proposerAt returns the proposer of slot in the schedule, nil if the set is empty
+func (valSet *defaultSet) proposerAt(slot int64) tendermint.Validator {
+	if len(valSet.schedule) == 0 {
+		return nil
+	}
+	return valSet.validators[valSet.schedule[slot%int64(len(valSet.schedule))]]
+}
+
+// calcWeightedProposer moves the proposer roundDiff slots after the one of lastProposer. If lastProposer is not
+// the current proposer, its first slot from the one of the height is used.
+func (valSet *defaultSet) calcWeightedProposer(lastProposer common.Address, roundDiff int64) {
+	size := int64(len(valSet.schedule))
+	if size == 0 {
+		valSet.proposer = nil
+		return
+	}
+	slot := valSet.slot
+	if valSet.proposer == nil || valSet.proposer.Address() != lastProposer {
+		slot = valSet.slotOf(lastProposer)
+	}
+	valSet.slot = (slot + roundDiff) % size
+	valSet.proposer = valSet.proposerAt(valSet.slot)
+}
+
+// slotOf returns the first slot of addr from the one of the height, 0 if addr has no slot
+func (valSet *defaultSet) slotOf(addr common.Address) int64 {
+	var (
+		size  = int64(len(valSet.schedule))
+		start = int64(0)
+	)
+	if valSet.height > 0 {
+		start = (valSet.height - 1) % size
+	}
+	for i := int64(0); i < size; i++ {
+		slot := (start + i) % size
+		if valSet.validators[valSet.schedule[slot]].Address() == addr {
+			return slot
+		}
+	}
+	return 0
+}
+
+// minWeight returns the smallest weight of the validators, the weight of the validators added to the set
+func (valSet *defaultSet) minWeight() uint64 {
+	var min uint64
+	for _, weight := range valSet.weights {
+		if min == 0 || weight < min {
+			min = weight
+		}
+	}
+	if min == 0 {
+		return 1
+	}
+	return min
+}
+
+// VotingPower returns the voting power of a validator: its weight if the set votes by weight, 1 otherwise,
+// 0 if it is not in the set
+func (valSet *defaultSet) VotingPower(address common.Address) int {
+	valSet.validatorMu.RLock()
+	defer valSet.validatorMu.RUnlock()
+	for i, val := range valSet.validators {
+		if val.Address() != address {
+			continue
+		}
+		if valSet.weightedVoting {
+			return int(valSet.weights[i])
+		}
+		return 1
+	}
+	return 0
+}
+
+// QuorumPower returns the minimum voting power of a polka: the total weight minus the maximum faulty weight if the set
+// votes by weight, MinMajority otherwise
+func (valSet *defaultSet) QuorumPower() int {
+	if !valSet.weightedVoting {
+		return valSet.MinMajority()
+	}
+	valSet.validatorMu.RLock()
+	defer valSet.validatorMu.RUnlock()
+	var total int
+	for _, weight := range valSet.weights {
+		total += int(weight)
+	}
+	return total - (int(math.Ceil(float64(total)/3)) - 1)
+}
@@ -0,0 +1,99 @@
+package validator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+)
+
+func TestStakeWeights(t *testing.T) {
+	require.Equal(t, []uint64{4, 8, 6, 4, 256},
+		StakeWeights([]*big.Int{big.NewInt(100), big.NewInt(200), big.NewInt(150), nil, big.NewInt(1000000)}))
+	require.Equal(t, []uint64{4, 4}, StakeWeights([]*big.Int{nil, big.NewInt(0)}))
+}
+
+func TestWeightedSet_Schedule(t *testing.T) {
+	addrs := []common.Address{
+		common.HexToAddress("0x1"),
+		common.HexToAddress("0x2"),
+		common.HexToAddress("0x3"),
+	}
+	// 0x1 has twice the stake of the others
+	valSet := NewWeightedSet(addrs, StakeWeights([]*big.Int{big.NewInt(200), big.NewInt(100), big.NewInt(100)}), 1)
+
+	// the proposers of the heights over a cycle, height h proposing in the slot h-1
+	counts := make(map[common.Address]int)
+	var proposers []common.Address
+	for height := int64(1); height <= 8; height++ {
+		proposer := NewWeightedSet(addrs, StakeWeights([]*big.Int{big.NewInt(200), big.NewInt(100), big.NewInt(100)}), height).GetProposer().Address()
+		counts[proposer]++
+		proposers = append(proposers, proposer)
+	}
+	require.Equal(t, map[common.Address]int{addrs[0]: 4, addrs[1]: 2, addrs[2]: 2}, counts)
+	// the cycle of the weights 2, 1 and 1
+	require.Equal(t, []common.Address{addrs[0], addrs[1], addrs[2], addrs[0], addrs[0], addrs[1], addrs[2], addrs[0]}, proposers)
+	require.Equal(t, addrs[0], valSet.GetProposer().Address())
+
+	// the rounds move the proposer along the schedule, a copy keeps its slot
+	valSet.CalcProposer(addrs[0], 2)
+	require.Equal(t, addrs[2], valSet.GetProposer().Address())
+	next := valSet.Copy()
+	next.CalcProposer(valSet.GetProposer().Address(), 1)
+	require.Equal(t, addrs[0], next.GetProposer().Address())
+	next.CalcProposer(addrs[0], 1)
+	require.Equal(t, addrs[0], next.GetProposer().Address())
+}
+
+func TestWeightedSet_EqualWeights(t *testing.T) {
+	addrs := []common.Address{
+		common.HexToAddress("0x1"),
+		common.HexToAddress("0x2"),
+		common.HexToAddress("0x3"),
+	}
+	// without stakes, the schedule is the round robin one
+	for height := int64(0); height < 6; height++ {
+		weighted := NewSet(addrs, tendermint.StakeWeighted, height)
+		roundRobin := NewSet(addrs, tendermint.RoundRobin, height)
+		require.Equal(t, roundRobin.GetProposer().Address(), weighted.GetProposer().Address())
+		for round := int64(1); round < 4; round++ {
+			weighted.CalcProposer(weighted.GetProposer().Address(), 1)
+			roundRobin.CalcProposer(roundRobin.GetProposer().Address(), 1)
+			require.Equal(t, roundRobin.GetProposer().Address(), weighted.GetProposer().Address())
+		}
+	}
+
+	valSet := NewSet(addrs, tendermint.StakeWeighted, 1)
+	require.True(t, valSet.AddValidator(common.HexToAddress("0x4")))
+	require.True(t, valSet.RemoveValidator(addrs[0]))
+	for round := int64(0); round < 3; round++ {
+		valSet.CalcProposer(valSet.GetProposer().Address(), 1)
+		require.NotEqual(t, addrs[0], valSet.GetProposer().Address())
+	}
+}
+
+func TestWeightedVotingSet_Quorum(t *testing.T) {
+	addrs := []common.Address{
+		common.HexToAddress("0x1"),
+		common.HexToAddress("0x2"),
+		common.HexToAddress("0x3"),
+		common.HexToAddress("0x4"),
+	}
+	weights := StakeWeights([]*big.Int{big.NewInt(500), big.NewInt(100), big.NewInt(100), big.NewInt(100)})
+
+	// before weighted voting, every validator has a vote
+	valSet := NewWeightedSet(addrs, weights, 1)
+	require.Equal(t, 1, valSet.VotingPower(addrs[0]))
+	require.Equal(t, 3, valSet.QuorumPower())
+
+	// after, 0x1 holds 5 of the 8 votes, a quorum needs 6 of them
+	valSet = NewWeightedVotingSet(addrs, weights, 1)
+	require.Equal(t, 5, valSet.VotingPower(addrs[0]))
+	require.Equal(t, 1, valSet.VotingPower(addrs[1]))
+	require.Equal(t, 0, valSet.VotingPower(common.HexToAddress("0x5")))
+	require.Equal(t, 6, valSet.QuorumPower())
+	require.Equal(t, 6, valSet.Copy().QuorumPower())
+}
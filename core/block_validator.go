@@ -55,6 +55,10 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	}
 	// Header validity is known at this point, check the uncles and transactions
 	header := block.Header()
+	// Tendermint blocks are final, there are no side blocks to include as uncles
+	if v.config.Tendermint != nil && (header.UncleHash != types.EmptyUncleHash || len(block.Uncles()) > 0) {
+		return ErrUnclesNotAllowed
+	}
 	if err := v.engine.VerifyUncles(v.bc, block); err != nil {
 		return err
 	}
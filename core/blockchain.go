@@ -68,6 +68,8 @@ var (
 	blockPrefetchExecuteTimer   = metrics.NewRegisteredTimer("chain/prefetch/executes", nil)
 	blockPrefetchInterruptMeter = metrics.NewRegisteredMeter("chain/prefetch/interrupts", nil)
 
+	conflictingBlockMeter = metrics.NewRegisteredMeter("chain/conflicts", nil)
+
 	errInsertionInterrupted = errors.New("insertion is interrupted")
 )
 
@@ -149,6 +151,7 @@ type BlockChain struct {
 
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
+	currentFinalized atomic.Value // Latest finalized block of the chain, nil if no block is finalized
 
 	stateCache    state.Database // State database to reuse between imports (contains state cache)
 	bodyCache     *lru.Cache     // Cache for the most recent block bodies
@@ -275,7 +278,7 @@ func NewBlockChain(db evrdb.Database, cacheConfig *CacheConfig, chainConfig *par
 			// make sure the headerByNumber (if present) is in our current canonical chain
 			if headerByNumber != nil && headerByNumber.Hash() == header.Hash() {
 				log.Error("Found bad hash, rewinding chain", "number", header.Number, "hash", header.ParentHash)
-				bc.SetHead(header.Number.Uint64() - 1)
+				bc.ForceSetHead(header.Number.Uint64() - 1)
 				log.Error("Chain rewind was successful, resuming normal operation")
 			}
 		}
@@ -357,6 +360,8 @@ func (bc *BlockChain) loadLastState() error {
 			headFastBlockGauge.Update(int64(block.NumberU64()))
 		}
 	}
+	bc.loadLastFinalized(currentBlock)
+
 	// Issue a status log for the user
 	currentFastBlock := bc.CurrentFastBlock()
 
@@ -375,7 +380,19 @@ func (bc *BlockChain) loadLastState() error {
 // above the new head will be deleted and the new one set. In the case of blocks
 // though, the head may be further rewound if block bodies are missing (non-archive
 // nodes after a fast sync).
+// Tendermint blocks are final, the chain is not rewound below its latest finalized
+// block unless it is forced with ForceSetHead.
 func (bc *BlockChain) SetHead(head uint64) error {
+	if finalized := bc.LatestFinalized(); bc.chainConfig.Tendermint != nil && finalized != nil && head < finalized.NumberU64() {
+		log.Warn("Refused to rewind below the latest finalized block", "target", head, "finalized", finalized.NumberU64())
+		return ErrRewindFinalized
+	}
+	return bc.ForceSetHead(head)
+}
+
+// ForceSetHead rewinds the local chain to a new head as SetHead does, even below its
+// latest finalized block, e.g. to drop a bad block or upgrade the chain configuration.
+func (bc *BlockChain) ForceSetHead(head uint64) error {
 	log.Warn("Rewinding blockchain", "target", head)
 
 	bc.chainmu.Lock()
@@ -431,6 +448,7 @@ func (bc *BlockChain) SetHead(head uint64) error {
 			rawdb.DeleteBody(db, hash, num)
 			rawdb.DeleteReceipts(db, hash, num)
 		}
+		rawdb.DeleteFinality(db, hash, num)
 		// Todo(rjl493456442) txlookup, bloombits, etc
 	}
 	bc.hc.SetHead(head, updateFn, delFn)
@@ -483,6 +501,79 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return bc.currentFastBlock.Load().(*types.Block)
 }
 
+// LatestFinalized retrieves the latest finalized block of the chain, nil if no block
+// is finalized. It is behind the current block while the node fast syncs.
+func (bc *BlockChain) LatestFinalized() *types.Block {
+	block, _ := bc.currentFinalized.Load().(*types.Block)
+	return block
+}
+
+// IsFinalized returns whether the block of hash is finalized: imported with its commit verified
+// or committed by the node, as opposed to a block downloaded without checking its commit.
+func (bc *BlockChain) IsFinalized(hash common.Hash) bool {
+	return bc.GetFinality(hash) != nil
+}
+
+// GetFinality retrieves the finality marker of the block of hash, nil if it is not finalized.
+func (bc *BlockChain) GetFinality(hash common.Hash) *rawdb.FinalityMarker {
+	number := bc.hc.GetBlockNumber(hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadFinality(bc.db, hash, *number)
+}
+
+// MarkFinalized marks the canonical tendermint block of hash finalized once its commit is verified, e.g. a fast
+// synced block whose commit was fetched from a peer, and makes it the latest finalized block if it is above it.
+func (bc *BlockChain) MarkFinalized(hash common.Hash) error {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	block := bc.GetBlockByHash(hash)
+	if block == nil || block.MixDigest() != types.TendermintDigest || rawdb.ReadCanonicalHash(bc.db, block.NumberU64()) != hash {
+		return ErrNotFinalizable
+	}
+	if bc.IsFinalized(hash) {
+		return nil
+	}
+	writeFinality(bc.db, block)
+	if finalized := bc.LatestFinalized(); finalized != nil && finalized.NumberU64() > block.NumberU64() {
+		rawdb.WriteHeadFinalizedBlockHash(bc.db, finalized.Hash())
+		return nil
+	}
+	bc.currentFinalized.Store(block)
+	return nil
+}
+
+// writeFinality marks a tendermint block finalized in the round of its extra-data and makes it the latest finalized block.
+func writeFinality(db evrdb.KeyValueWriter, block *types.Block) {
+	marker := &rawdb.FinalityMarker{Time: uint64(time.Now().Unix())}
+	if extra, err := types.ExtractTendermintExtra(block.Header()); err == nil {
+		marker.Round = extra.Round
+	}
+	rawdb.WriteFinality(db, block.Hash(), block.NumberU64(), marker)
+	rawdb.WriteHeadFinalizedBlockHash(db, block.Hash())
+}
+
+// loadLastFinalized restores the latest finalized block, which can not be above the head block.
+// After a rewind, the head block becomes the latest finalized block if it is finalized.
+func (bc *BlockChain) loadLastFinalized(currentBlock *types.Block) {
+	var finalized *types.Block
+	if hash := rawdb.ReadHeadFinalizedBlockHash(bc.db); hash != (common.Hash{}) {
+		finalized = bc.GetBlockByHash(hash)
+	}
+	if finalized == nil || finalized.NumberU64() > currentBlock.NumberU64() {
+		finalized = nil
+		if bc.IsFinalized(currentBlock.Hash()) {
+			finalized = currentBlock
+			rawdb.WriteHeadFinalizedBlockHash(bc.db, currentBlock.Hash())
+		} else {
+			rawdb.DeleteHeadFinalizedBlockHash(bc.db)
+		}
+	}
+	bc.currentFinalized.Store(finalized)
+}
+
 // Validator returns the current validator.
 func (bc *BlockChain) Validator() Validator {
 	return bc.validator
@@ -517,7 +608,7 @@ func (bc *BlockChain) Reset() error {
 // specified genesis state.
 func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 	// Dump the entire block chain and purge the caches
-	if err := bc.SetHead(0); err != nil {
+	if err := bc.ForceSetHead(0); err != nil {
 		return err
 	}
 	bc.chainmu.Lock()
@@ -1213,6 +1304,9 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	bc.wg.Add(1)
 	defer bc.wg.Done()
 
+	if err := bc.checkHeadExtension(block); err != nil {
+		return NonStatTy, err
+	}
 	// Calculate the total difficulty of the block
 	ptd := bc.GetTd(block.ParentHash(), block.NumberU64()-1)
 	if ptd == nil {
@@ -1325,6 +1419,11 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	} else {
 		status = SideStatTy
 	}
+	// The commit of a tendermint block was verified or made by the node, mark it finalized
+	finalized := status == CanonStatTy && block.MixDigest() == types.TendermintDigest
+	if finalized {
+		writeFinality(batch, block)
+	}
 	if err := batch.Write(); err != nil {
 		return NonStatTy, err
 	}
@@ -1333,6 +1432,9 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if status == CanonStatTy {
 		bc.insert(block)
 	}
+	if finalized {
+		bc.currentFinalized.Store(block)
+	}
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
 }
@@ -1716,6 +1818,9 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 				return it.index, nil, nil, errors.New("sidechain ghost-state attack")
 			}
 		}
+		if err := bc.checkHeadExtension(block); err != nil {
+			return it.index, nil, nil, err
+		}
 		if externTd == nil {
 			externTd = bc.GetTd(block.ParentHash(), block.NumberU64()-1)
 		}
@@ -1798,6 +1903,10 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 // blocks and inserts them to be part of the new canonical chain and accumulates
 // potential missing transactions and post an event about them.
 func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
+	// Tendermint blocks are final, the canonical chain is never rewound
+	if bc.chainConfig.Tendermint != nil {
+		return ErrNotHeadExtension
+	}
 	var (
 		newChain    types.Blocks
 		oldChain    types.Blocks
@@ -1991,6 +2100,32 @@ func (bc *BlockChain) addBadBlock(block *types.Block) {
 	bc.badBlocks.Add(block.Hash(), block)
 }
 
+// checkHeadExtension refuses the tendermint blocks which do not extend the head: finality
+// forbids side chains and reorgs. A block conflicting with the canonical block of the same
+// number is reported as a critical alert, since it can only be imported once its commit is
+// verified, the validators committed two blocks at that number.
+func (bc *BlockChain) checkHeadExtension(block *types.Block) error {
+	if bc.chainConfig.Tendermint == nil || block.ParentHash() == bc.CurrentBlock().Hash() {
+		return nil
+	}
+	canonical := rawdb.ReadCanonicalHash(bc.db, block.NumberU64())
+	if canonical == (common.Hash{}) || canonical == block.Hash() {
+		return ErrNotHeadExtension
+	}
+	conflictingBlockMeter.Mark(1)
+	log.Error(fmt.Sprintf(`
+########## CONFLICTING FINALIZED BLOCK #########
+Number: %v
+Hash: 0x%x
+Canonical hash: 0x%x
+Canonical finalized: %v
+
+Validators committed two blocks at the same number.
+################################################
+`, block.Number(), block.Hash(), canonical, bc.IsFinalized(canonical)))
+	return ErrConflictingFinalizedBlock
+}
+
 // reportBlock logs a bad block error.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
 	bc.addBadBlock(block)
@@ -2255,3 +2255,164 @@ func BenchmarkBlockChain_1x1000Executions(b *testing.B) {
 	}
 	benchmarkLargeNumberOfValueToNonexisting(b, numTxs, numBlocks, recipientFn, dataFn)
 }
+
+// Tests that the blocks imported with their commit verified are marked finalized,
+// unlike the fast synced ones, and that the latest finalized block follows rewinds.
+func TestBlockFinality(t *testing.T) {
+	var (
+		gendb   = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(gendb)
+		archive = &CacheConfig{TrieCleanLimit: 256, TrieDirtyLimit: 256, TrieTimeLimit: 5 * time.Minute, TrieDirtyDisabled: true}
+	)
+	blocks, receipts := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 10, nil)
+
+	fullDb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(fullDb)
+	full, _ := NewBlockChain(fullDb, archive, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer full.Stop()
+
+	if full.LatestFinalized() != nil {
+		t.Fatalf("latest finalized block before import: have %v, want nil", full.LatestFinalized().Number())
+	}
+	if n, err := full.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	for _, block := range blocks {
+		if !full.IsFinalized(block.Hash()) {
+			t.Errorf("block #%d not finalized", block.NumberU64())
+		}
+	}
+	if have, want := full.LatestFinalized().Hash(), blocks[9].Hash(); have != want {
+		t.Errorf("latest finalized block mismatch: have %x, want %x", have, want)
+	}
+
+	// the latest finalized block is restored with the chain and rewound with it, the archive keeps the states to rewind to
+	full.Stop()
+	full, _ = NewBlockChain(fullDb, archive, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if have, want := full.LatestFinalized().Hash(), blocks[9].Hash(); have != want {
+		t.Errorf("restored latest finalized block mismatch: have %x, want %x", have, want)
+	}
+	if err := full.SetHead(5); err != nil {
+		t.Fatalf("failed to rewind the chain: %v", err)
+	}
+	if have, want := full.LatestFinalized().Hash(), blocks[4].Hash(); have != want {
+		t.Errorf("rewound latest finalized block mismatch: have %x, want %x", have, want)
+	}
+	if full.IsFinalized(blocks[5].Hash()) {
+		t.Errorf("rewound block #%d still finalized", blocks[5].NumberU64())
+	}
+
+	// the fast synced blocks are not finalized
+	fastDb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(fastDb)
+	fast, _ := NewBlockChain(fastDb, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer fast.Stop()
+
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := fast.InsertHeaderChain(headers, 1); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := fast.InsertReceiptChain(blocks, receipts, 0); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	for _, block := range blocks {
+		if fast.IsFinalized(block.Hash()) {
+			t.Errorf("fast synced block #%d finalized", block.NumberU64())
+		}
+	}
+	if fast.LatestFinalized() != nil {
+		t.Errorf("latest finalized block after fast sync: have %v, want nil", fast.LatestFinalized().Number())
+	}
+}
+
+// Tests that a tendermint chain refuses the blocks which do not extend its head, and
+// reports the ones conflicting with a canonical block instead of reorganising to them.
+func TestTendermintRejectsSideChain(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TendermintTestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 5, nil)
+	forks, _ := GenerateChain(gspec.Config, blocks[1], ethash.NewFaker(), db, 5, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{1})
+	})
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	if _, err := chain.InsertChain(forks); err != ErrConflictingFinalizedBlock {
+		t.Errorf("conflicting block import error mismatch: have %v, want %v", err, ErrConflictingFinalizedBlock)
+	}
+	if _, err := chain.InsertChain(forks[3:]); err == nil {
+		t.Errorf("side chain import succeeded")
+	}
+	if have, want := chain.CurrentBlock().Hash(), blocks[4].Hash(); have != want {
+		t.Errorf("head mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that a tendermint chain refuses the blocks including uncles.
+func TestTendermintRejectsUncles(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TendermintTestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	forks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 1, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{1})
+	})
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, func(i int, b *BlockGen) {
+		if i == 1 {
+			b.AddUncle(forks[0].Header())
+		}
+	})
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); n != 1 || err != ErrUnclesNotAllowed {
+		t.Fatalf("uncle block import mismatch: have %d, %v, want %d, %v", n, err, 1, ErrUnclesNotAllowed)
+	}
+}
+
+// Tests that a tendermint chain is not rewound below its latest finalized block
+// unless the rewind is forced.
+func TestTendermintRefusesFinalizedRewind(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TendermintTestChainConfig}
+		genesis = gspec.MustCommit(db)
+		archive = &CacheConfig{TrieCleanLimit: 256, TrieDirtyLimit: 256, TrieTimeLimit: 5 * time.Minute, TrieDirtyDisabled: true}
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 5, nil)
+	chain, _ := NewBlockChain(db, archive, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	if err := chain.SetHead(2); err != ErrRewindFinalized {
+		t.Errorf("finalized rewind error mismatch: have %v, want %v", err, ErrRewindFinalized)
+	}
+	if have, want := chain.CurrentBlock().Hash(), blocks[4].Hash(); have != want {
+		t.Errorf("head mismatch after refused rewind: have %x, want %x", have, want)
+	}
+	if err := chain.SetHead(5); err != nil {
+		t.Errorf("failed to rewind to the finalized block: %v", err)
+	}
+	if err := chain.ForceSetHead(2); err != nil {
+		t.Fatalf("failed to force the rewind: %v", err)
+	}
+	if have, want := chain.CurrentBlock().Hash(), blocks[1].Hash(); have != want {
+		t.Errorf("head mismatch after forced rewind: have %x, want %x", have, want)
+	}
+	if have, want := chain.LatestFinalized().Hash(), blocks[1].Hash(); have != want {
+		t.Errorf("latest finalized block mismatch after forced rewind: have %x, want %x", have, want)
+	}
+}
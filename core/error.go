@@ -35,4 +35,22 @@ var (
 
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
+
+	// ErrNotHeadExtension is returned if a tendermint block to import does not extend
+	// the head, tendermint blocks are final so there are no side chains nor reorgs.
+	ErrNotHeadExtension = errors.New("block does not extend the head of a final chain")
+
+	// ErrConflictingFinalizedBlock is returned if a tendermint block to import is valid but
+	// conflicts with the canonical block of the same number, i.e. finality was violated.
+	ErrConflictingFinalizedBlock = errors.New("block conflicts with a finalized block")
+
+	// ErrNotFinalizable is returned if a block marked finalized is not a canonical tendermint block.
+	ErrNotFinalizable = errors.New("block is not a canonical tendermint block")
+
+	// ErrRewindFinalized is returned if a tendermint chain is rewound below its latest
+	// finalized block without forcing it.
+	ErrRewindFinalized = errors.New("rewind below the latest finalized block")
+
+	// ErrUnclesNotAllowed is returned if a tendermint block includes uncles or a non empty uncle hash.
+	ErrUnclesNotAllowed = errors.New("uncles not allowed with tendermint")
 )
@@ -40,7 +40,11 @@ import (
 //go:generate gencodec -type Genesis -field-override genesisSpecMarshaling -out gen_genesis.go
 //go:generate gencodec -type GenesisAccount -field-override genesisAccountMarshaling -out gen_genesis_account.go
 
-var errGenesisNoConfig = errors.New("genesis has no chain configuration")
+var (
+	errGenesisNoConfig               = errors.New("genesis has no chain configuration")
+	errGenesisValidatorsNoTendermint = errors.New("genesis validators require the tendermint engine")
+	errGenesisValidatorsMismatch     = errors.New("genesis validators don't match the validators of the extra-data")
+)
 
 // Genesis specifies the header fields, state of a genesis block. It also defines hard
 // fork switch-over blocks through the chain configuration.
@@ -55,6 +59,10 @@ type Genesis struct {
 	Coinbase   common.Address      `json:"coinbase"`
 	Alloc      GenesisAlloc        `json:"alloc"      gencodec:"required"`
 
+	// Validators is the initial validator set of a tendermint chain. The extra-data is derived from it if empty,
+	// otherwise its validators must be the same ones in the same order.
+	Validators []common.Address `json:"validators,omitempty"`
+
 	// These fields are used for consensus tests. Please don't use them
 	// in actual genesis blocks.
 	Number     uint64      `json:"number"`
@@ -157,6 +165,11 @@ func SetupGenesisBlockWithOverride(db evrdb.Database, genesis *Genesis, constant
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil {
+		if _, err := genesis.extraData(); err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+	}
 	// Just commit the new block if there is no stored genesis block.
 	stored := rawdb.ReadCanonicalHash(db, 0)
 	if (stored == common.Hash{}) {
@@ -240,9 +253,63 @@ func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 	}
 }
 
+// extraData returns the extra-data of the genesis block. With Validators, it is derived from them if ExtraData
+// is empty, otherwise the validator set of ExtraData must be Validators.
+func (g *Genesis) extraData() ([]byte, error) {
+	if len(g.Validators) == 0 {
+		return g.ExtraData, nil
+	}
+	if g.Config == nil || g.Config.Tendermint == nil {
+		return nil, errGenesisValidatorsNoTendermint
+	}
+	seen := make(map[common.Address]bool, len(g.Validators))
+	for _, validator := range g.Validators {
+		if (validator == common.Address{}) {
+			return nil, fmt.Errorf("genesis validators contain the zero address")
+		}
+		if seen[validator] {
+			return nil, fmt.Errorf("genesis validator %s is listed twice", validator.Hex())
+		}
+		seen[validator] = true
+	}
+	if len(g.ExtraData) == 0 {
+		extra := &types.TendermintExtra{
+			Seal:          []byte{},
+			CommittedSeal: [][]byte{},
+		}
+		if err := extra.SetValidators(g.Validators); err != nil {
+			return nil, err
+		}
+		return extra.Encode(nil)
+	}
+	extra, err := types.DecodeTendermintExtra(g.ExtraData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis extra-data: %v", err)
+	}
+	validators, err := extra.Validators()
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis extra-data validators: %v", err)
+	}
+	if len(validators) != len(g.Validators) {
+		return nil, errGenesisValidatorsMismatch
+	}
+	for i := range validators {
+		if validators[i] != g.Validators[i] {
+			return nil, errGenesisValidatorsMismatch
+		}
+	}
+	return g.ExtraData, nil
+}
+
 // ToBlock creates the genesis block and writes state of a genesis specification
 // to the given database (or discards it if nil).
 func (g *Genesis) ToBlock(db evrdb.Database) *types.Block {
+	extra, err := g.extraData()
+	if err != nil {
+		// Commit and SetupGenesisBlock report the invalid validators
+		log.Error("invalid genesis validators", "err", err)
+		extra = g.ExtraData
+	}
 	if db == nil {
 		db = rawdb.NewMemoryDatabase()
 	}
@@ -261,7 +328,7 @@ func (g *Genesis) ToBlock(db evrdb.Database) *types.Block {
 		Nonce:      types.EncodeNonce(g.Nonce),
 		Time:       g.Timestamp,
 		ParentHash: g.ParentHash,
-		Extra:      g.ExtraData,
+		Extra:      extra,
 		GasLimit:   g.GasLimit,
 		GasUsed:    g.GasUsed,
 		Difficulty: g.Difficulty,
@@ -284,6 +351,9 @@ func (g *Genesis) ToBlock(db evrdb.Database) *types.Block {
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Commit(db evrdb.Database) (*types.Block, error) {
+	if _, err := g.extraData(); err != nil {
+		return nil, err
+	}
 	block := g.ToBlock(db)
 	if block.Number().Sign() != 0 {
 		return nil, fmt.Errorf("can't commit genesis block with number > 0")
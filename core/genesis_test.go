@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"math/big"
 	"reflect"
 	"testing"
@@ -26,6 +27,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/core/vm"
 	"github.com/Evrynetlabs/evrynet-node/evrdb"
 	"github.com/Evrynetlabs/evrynet-node/params"
@@ -163,3 +165,64 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+func TestGenesisValidators(t *testing.T) {
+	var (
+		validators = []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+		config     = &params.ChainConfig{Tendermint: &params.TendermintConfig{Epoch: 10}}
+		extra      = &types.TendermintExtra{Seal: []byte{}, CommittedSeal: [][]byte{}}
+	)
+	if err := extra.SetValidators(validators); err != nil {
+		t.Fatal(err)
+	}
+	extraData, err := extra.Encode(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the extra-data is derived from the validators
+	genesis := &Genesis{Config: config, Validators: validators}
+	block, err := genesis.Commit(rawdb.NewMemoryDatabase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(block.Extra(), extraData) {
+		t.Errorf("wrong extra-data, got %x, want %x", block.Extra(), extraData)
+	}
+	// the same extra-data is accepted, and gives the same genesis block
+	genesis = &Genesis{Config: config, Validators: validators, ExtraData: extraData}
+	if hash := genesis.ToBlock(nil).Hash(); hash != block.Hash() {
+		t.Errorf("wrong genesis hash, got %v, want %v", hash.Hex(), block.Hash().Hex())
+	}
+
+	tests := []struct {
+		name    string
+		genesis *Genesis
+		wantErr error
+	}{
+		{
+			name:    "other order",
+			genesis: &Genesis{Config: config, Validators: []common.Address{validators[1], validators[0]}, ExtraData: extraData},
+			wantErr: errGenesisValidatorsMismatch,
+		},
+		{
+			name:    "missing validator",
+			genesis: &Genesis{Config: config, Validators: validators[:1], ExtraData: extraData},
+			wantErr: errGenesisValidatorsMismatch,
+		},
+		{
+			name:    "without tendermint",
+			genesis: &Genesis{Config: &params.ChainConfig{}, Validators: validators},
+			wantErr: errGenesisValidatorsNoTendermint,
+		},
+	}
+	for _, test := range tests {
+		if _, _, err := SetupGenesisBlock(rawdb.NewMemoryDatabase(), test.genesis); err != test.wantErr {
+			t.Errorf("%s: returned error %v, want %v", test.name, err, test.wantErr)
+		}
+	}
+	duplicated := &Genesis{Config: config, Validators: []common.Address{validators[0], validators[0]}}
+	if _, err := duplicated.Commit(rawdb.NewMemoryDatabase()); err == nil {
+		t.Error("duplicated validators are accepted")
+	}
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/metrics"
+)
+
+var (
+	parallelProcessMeter  = metrics.NewRegisteredMeter("chain/parallel/processed", nil)
+	parallelFallbackMeter = metrics.NewRegisteredMeter("chain/parallel/fallback", nil)
+)
+
+// txGroup is a set of transactions of a block which share a sender, a recipient or a gas payer,
+// they are executed in order on a copy of the state.
+type txGroup struct {
+	txs     []int // indexes of the transactions in the block
+	state   *state.StateDB
+	results []*types.Receipt
+	err     error
+}
+
+// ProcessParallel processes the state changes like Process, but the transactions which share no sender,
+// recipient or gas payer are executed concurrently on copies of statedb. If the groups of transactions
+// turn out to access the same accounts, or any transaction fails, the block is processed sequentially again.
+func (p *StateProcessor) ProcessParallel(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	// the receipts of the blocks before byzantium hold the intermediate roots which require the sequential execution
+	if !p.config.IsByzantium(block.Number()) || (p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0) {
+		return p.Process(block, statedb, cfg)
+	}
+	groups := p.groupTransactions(block)
+	if len(groups) < 2 {
+		return p.Process(block, statedb, cfg)
+	}
+	p.executeGroups(block, statedb, groups, cfg)
+
+	receipts, usedGas, ok := mergeGroups(block, statedb, groups)
+	if !ok {
+		parallelFallbackMeter.Mark(1)
+		return p.Process(block, statedb, cfg)
+	}
+	parallelProcessMeter.Mark(1)
+
+	var allLogs []*types.Log
+	for _, receipt := range receipts {
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards),
+	// tendermint blocks have no uncles to reward
+	uncles := block.Uncles()
+	if p.config.Tendermint != nil {
+		uncles = nil
+	}
+	err := p.engine.Finalize(p.bc, block.Header(), statedb, block.Transactions(), uncles)
+	return receipts, allLogs, usedGas, err
+}
+
+// groupTransactions splits the transactions of block into the groups of transactions connected by their senders,
+// recipients and gas payers, the groups are in the order of their first transaction.
+// It returns no group if a sender cannot be recovered.
+func (p *StateProcessor) groupTransactions(block *types.Block) []*txGroup {
+	var (
+		signer  = types.MakeSigner(p.config, block.Number())
+		parents = make(map[common.Address]common.Address)
+	)
+	find := func(addr common.Address) common.Address {
+		for {
+			parent, ok := parents[addr]
+			if !ok || parent == addr {
+				parents[addr] = addr
+				return addr
+			}
+			parents[addr] = parents[parent]
+			addr = parent
+		}
+	}
+	txAddrs := make([][]common.Address, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			return nil
+		}
+		addrs := []common.Address{msg.From(), msg.GasPayer()}
+		if msg.To() != nil {
+			addrs = append(addrs, *msg.To())
+		}
+		root := find(addrs[0])
+		for _, addr := range addrs[1:] {
+			if other := find(addr); other != root {
+				parents[other] = root
+			}
+		}
+		txAddrs[i] = addrs
+	}
+	var (
+		groups  []*txGroup
+		byRoots = make(map[common.Address]*txGroup)
+	)
+	for i, addrs := range txAddrs {
+		root := find(addrs[0])
+		group, ok := byRoots[root]
+		if !ok {
+			group = new(txGroup)
+			byRoots[root] = group
+			groups = append(groups, group)
+		}
+		group.txs = append(group.txs, i)
+	}
+	return groups
+}
+
+// executeGroups executes the transactions of each group in order on its own copy of statedb,
+// the groups are executed concurrently.
+func (p *StateProcessor) executeGroups(block *types.Block, statedb *state.StateDB, groups []*txGroup, cfg vm.Config) {
+	var (
+		header = block.Header()
+		txs    = block.Transactions()
+		work   = make(chan *txGroup)
+		wg     sync.WaitGroup
+	)
+	for _, group := range groups {
+		group.state = statedb.Copy()
+		group.state.TrackAccesses()
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if len(groups) < workers {
+		workers = len(groups)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range work {
+				// every group may spend the whole block gas, the sum is checked when the groups are merged
+				var (
+					gp      = new(GasPool).AddGas(block.GasLimit())
+					usedGas = new(uint64)
+				)
+				for _, index := range group.txs {
+					group.state.Prepare(txs[index].Hash(), block.Hash(), index)
+					receipt, _, err := ApplyTransaction(p.config, p.bc, nil, gp, group.state, header, txs[index], usedGas, cfg)
+					if err != nil {
+						group.err = err
+						break
+					}
+					group.results = append(group.results, receipt)
+				}
+			}
+		}()
+	}
+	for _, group := range groups {
+		work <- group
+	}
+	close(work)
+	wg.Wait()
+}
+
+// mergeGroups moves the changes of the executed groups into statedb and returns the receipts in the order of the block.
+// It returns false and leaves statedb untouched if a group failed, if two groups accessed the same account,
+// or if a transaction would have exceeded the gas left in the block when executed in order.
+func mergeGroups(block *types.Block, statedb *state.StateDB, groups []*txGroup) (types.Receipts, uint64, bool) {
+	var (
+		txs      = block.Transactions()
+		receipts = make(types.Receipts, len(txs))
+		accessed = make(map[common.Address]struct{})
+	)
+	for _, group := range groups {
+		if group.err != nil {
+			return nil, 0, false
+		}
+		for addr := range group.state.AccessedAccounts() {
+			if _, conflict := accessed[addr]; conflict {
+				return nil, 0, false
+			}
+			accessed[addr] = struct{}{}
+		}
+		for i, index := range group.txs {
+			receipts[index] = group.results[i]
+		}
+	}
+	var usedGas uint64
+	for i, receipt := range receipts {
+		if block.GasLimit()-usedGas < txs[i].Gas() {
+			return nil, 0, false
+		}
+		usedGas += receipt.GasUsed
+		receipt.CumulativeGasUsed = usedGas
+	}
+	// number the logs in the order of the block, after the ones statedb holds already
+	logIndex := uint(len(statedb.Logs()))
+	for _, group := range groups {
+		statedb.Merge(group.state, group.state.AccessedAccounts())
+	}
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			l.Index = logIndex
+			logIndex++
+		}
+	}
+	return receipts, usedGas, true
+}
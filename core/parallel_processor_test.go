@@ -0,0 +1,103 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// TestProcessParallel checks that the parallel processing of a block results in the same state and receipts
+// as the sequential one, and that it falls back to it when the transactions access the same accounts.
+func TestProcessParallel(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		key3, _ = crypto.HexToECDSA("49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee")
+		funds   = new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(10))
+		shared  = common.HexToAddress("0xaaaa")
+		// forward sends the value of the call to the shared account
+		forward = append(append([]byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x34, 0x73}, shared.Bytes()...), 0x5a, 0xf1, 0x00)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				crypto.PubkeyToAddress(key1.PublicKey): {Balance: funds},
+				crypto.PubkeyToAddress(key2.PublicKey): {Balance: funds},
+				crypto.PubkeyToAddress(key3.PublicKey): {Balance: funds},
+				common.HexToAddress("0xc1"):            {Balance: new(big.Int), Code: forward},
+				common.HexToAddress("0xc2"):            {Balance: new(big.Int), Code: forward},
+			},
+		}
+		db      = rawdb.NewMemoryDatabase()
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	send := func(b *BlockGen, key *ecdsa.PrivateKey, to common.Address, gas uint64) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(crypto.PubkeyToAddress(key.PublicKey)), to, big.NewInt(1000), gas, big.NewInt(params.GasPriceConfig), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.AddTx(tx)
+	}
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, func(i int, b *BlockGen) {
+		switch i {
+		case 0:
+			// independent transfers, two of them from the same sender
+			send(b, key1, common.HexToAddress("0x01"), params.TxGas)
+			send(b, key2, common.HexToAddress("0x02"), params.TxGas)
+			send(b, key1, common.HexToAddress("0x03"), params.TxGas)
+			send(b, key3, common.HexToAddress("0x04"), params.TxGas)
+		case 1:
+			// distinct contracts forwarding the value to the same account
+			send(b, key2, common.HexToAddress("0xc1"), 100000)
+			send(b, key3, common.HexToAddress("0xc2"), 100000)
+		}
+	})
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	defer chain.Stop()
+	processor := chain.Processor().(*StateProcessor)
+
+	parent := genesis
+	for i, block := range blocks {
+		groups := processor.groupTransactions(block)
+		if len(groups) != 3-i {
+			t.Fatalf("block %d: groups mismatch: have %d, want %d", i, len(groups), 3-i)
+		}
+		state, _ := chain.StateAt(parent.Root())
+		processor.executeGroups(block, state, groups, vm.Config{})
+		if _, _, ok := mergeGroups(block, state, groups); ok != (i == 0) {
+			t.Fatalf("block %d: merge mismatch: have %v, want %v", i, ok, i == 0)
+		}
+
+		sequential, _ := chain.StateAt(parent.Root())
+		wantReceipts, _, wantGas, err := processor.Process(block, sequential, vm.Config{})
+		if err != nil {
+			t.Fatalf("block %d: failed to process: %v", i, err)
+		}
+		parallel, _ := chain.StateAt(parent.Root())
+		receipts, _, gas, err := processor.ProcessParallel(block, parallel, vm.Config{})
+		if err != nil {
+			t.Fatalf("block %d: failed to process in parallel: %v", i, err)
+		}
+		if gas != wantGas {
+			t.Errorf("block %d: gas mismatch: have %d, want %d", i, gas, wantGas)
+		}
+		if have, want := types.DeriveSha(receipts), types.DeriveSha(wantReceipts); have != want {
+			t.Errorf("block %d: receipts mismatch: have %x, want %x", i, have, want)
+		}
+		if have, want := parallel.IntermediateRoot(true), block.Root(); have != want {
+			t.Errorf("block %d: root mismatch: have %x, want %x", i, have, want)
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("block %d: failed to insert: %v", i, err)
+		}
+		parent = block
+	}
+}
@@ -153,6 +153,29 @@ func WriteHeadFastBlockHash(db evrdb.KeyValueWriter, hash common.Hash) {
 	}
 }
 
+// ReadHeadFinalizedBlockHash retrieves the hash of the latest finalized block.
+func ReadHeadFinalizedBlockHash(db evrdb.KeyValueReader) common.Hash {
+	data, _ := db.Get(headFinalizedBlockKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteHeadFinalizedBlockHash stores the hash of the latest finalized block.
+func WriteHeadFinalizedBlockHash(db evrdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Put(headFinalizedBlockKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store last finalized block's hash", "err", err)
+	}
+}
+
+// DeleteHeadFinalizedBlockHash removes the hash of the latest finalized block.
+func DeleteHeadFinalizedBlockHash(db evrdb.KeyValueWriter) {
+	if err := db.Delete(headFinalizedBlockKey); err != nil {
+		log.Crit("Failed to delete last finalized block's hash", "err", err)
+	}
+}
+
 // ReadFastTrieProgress retrieves the number of tries nodes fast synced to allow
 // reporting correct numbers across restarts.
 func ReadFastTrieProgress(db evrdb.KeyValueReader) uint64 {
@@ -515,12 +538,52 @@ func WriteAncientBlock(db evrdb.AncientWriter, block *types.Block, receipts type
 	return len(headerBlob) + len(bodyBlob) + len(receiptBlob) + len(tdBlob) + common.HashLength
 }
 
+// FinalityMarker records that the commit of a block was verified, or made, by the node,
+// as opposed to a block whose commit was not checked, e.g. downloaded by fast sync.
+type FinalityMarker struct {
+	Round uint64 // Round the block was committed in
+	Time  uint64 // Unix time the node finalized the block
+}
+
+// ReadFinality retrieves the finality marker of a block, nil if the block is not finalized.
+func ReadFinality(db evrdb.KeyValueReader, hash common.Hash, number uint64) *FinalityMarker {
+	data, _ := db.Get(finalityKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	marker := new(FinalityMarker)
+	if err := rlp.DecodeBytes(data, marker); err != nil {
+		log.Error("Invalid block finality marker RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return marker
+}
+
+// WriteFinality stores the finality marker of a block.
+func WriteFinality(db evrdb.KeyValueWriter, hash common.Hash, number uint64, marker *FinalityMarker) {
+	data, err := rlp.EncodeToBytes(marker)
+	if err != nil {
+		log.Crit("Failed to RLP encode block finality marker", "err", err)
+	}
+	if err := db.Put(finalityKey(number, hash), data); err != nil {
+		log.Crit("Failed to store block finality marker", "err", err)
+	}
+}
+
+// DeleteFinality removes the finality marker of a block.
+func DeleteFinality(db evrdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(finalityKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block finality marker", "err", err)
+	}
+}
+
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db evrdb.KeyValueWriter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
+	DeleteFinality(db, hash, number)
 }
 
 // DeleteBlockWithoutNumber removes all block data associated with a hash, except
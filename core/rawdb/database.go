@@ -244,6 +244,7 @@ func InspectDatabase(db evrdb.Database) error {
 		bloomBitsSize       common.StorageSize
 		cliqueSnapsSize     common.StorageSize
 		tendermintSnapsSize common.StorageSize
+		finalitySize        common.StorageSize
 
 		// Ancient store statistics
 		ancientHeaders  common.StorageSize
@@ -280,6 +281,8 @@ func InspectDatabase(db evrdb.Database) error {
 			bodySize += size
 		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
 			receiptSize += size
+		case bytes.HasPrefix(key, finalityPrefix) && len(key) == (len(finalityPrefix)+8+common.HashLength):
+			finalitySize += size
 		case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
 			txlookupSize += size
 		case bytes.HasPrefix(key, preimagePrefix) && len(key) == (len(preimagePrefix)+common.HashLength):
@@ -298,7 +301,7 @@ func InspectDatabase(db evrdb.Database) error {
 			trieSize += size
 		default:
 			var accounted bool
-			for _, meta := range [][]byte{databaseVerisionKey, headHeaderKey, headBlockKey, headFastBlockKey, fastTrieProgressKey} {
+			for _, meta := range [][]byte{databaseVerisionKey, headHeaderKey, headBlockKey, headFastBlockKey, headFinalizedBlockKey, fastTrieProgressKey} {
 				if bytes.Equal(key, meta) {
 					metadata += size
 					accounted = true
@@ -328,6 +331,7 @@ func InspectDatabase(db evrdb.Database) error {
 		{"Key-Value store", "Headers", headerSize.String()},
 		{"Key-Value store", "Bodies", bodySize.String()},
 		{"Key-Value store", "Receipts", receiptSize.String()},
+		{"Key-Value store", "Finality markers", finalitySize.String()},
 		{"Key-Value store", "Difficulties", tdSize.String()},
 		{"Key-Value store", "Block number->hash", numHashPairing.String()},
 		{"Key-Value store", "Block hash->number", hashNumPairing.String()},
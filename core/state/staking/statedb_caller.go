@@ -133,6 +133,85 @@ func (c *stateDBStakingCaller) GetVoters(stakingAddr common.Address, candidate c
 	return voters
 }
 
+// GetCandidateCommission returns the commission the owner of a candidate sets in the staking contract, the percentage
+// of the rewards it keeps before its voters share the rest, 0 if it sets none
+func (c *stateDBStakingCaller) GetCandidateCommission(scAddress common.Address, candidate common.Address) *big.Int {
+	loc := getMappingElementLoc(c.config.CandidateDataLayout.slotHash(), candidate.Hash())
+	loc = addOffsetToLoc(loc, new(big.Int).SetUint64(c.config.CandidateDataStruct.Commission.Slot))
+	return c.getBigInt(scAddress, loc)
+}
+
+// GetCandidateCommission returns the commission of a candidate read from the storage of the staking contract,
+// whichever caller reads its other data, see IndexConfigs.CandidateDataStruct
+func GetCandidateCommission(state *state.StateDB, cfg *IndexConfigs, scAddress common.Address, candidate common.Address) *big.Int {
+	return (&stateDBStakingCaller{stateDB: state, config: cfg}).GetCandidateCommission(scAddress, candidate)
+}
+
+// GetCandidateStakes returns the candidates of the staking contract and their total stakes read from its storage
+func GetCandidateStakes(state *state.StateDB, cfg *IndexConfigs, scAddress common.Address) ([]common.Address, map[common.Address]*big.Int, error) {
+	c := &stateDBStakingCaller{stateDB: state, config: cfg}
+	candidates, err := c.GetCandidates(scAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	stakes := make(map[common.Address]*big.Int, len(candidates))
+	for _, candidate := range candidates {
+		stakes[candidate] = c.GetCandidateStake(scAddress, candidate)
+	}
+	return candidates, stakes, nil
+}
+
+// GetCandidateSelfBond returns the stake the owner of a candidate votes for it with, read from the storage of the staking contract
+func GetCandidateSelfBond(state *state.StateDB, cfg *IndexConfigs, scAddress common.Address, candidate common.Address) *big.Int {
+	c := &stateDBStakingCaller{stateDB: state, config: cfg}
+	owner := c.GetCandidateOwner(scAddress, candidate)
+	loc := getMappingElementLoc(cfg.CandidateDataLayout.slotHash(), candidate.Hash())
+	voterStakesSlot := addOffsetToLoc(loc, new(big.Int).SetUint64(cfg.CandidateDataStruct.VotersStakes.Slot))
+	return c.getBigInt(scAddress, getMappingElementLoc(voterStakesSlot, owner.Hash()))
+}
+
+// GetBondedValidators returns the validators of the staking contract selected as GetValidators does, by the stakes
+// bonded returns from the total stakes of the candidates. The candidates bonded returns nil for are not selected.
+// maxSize replaces the maximum number of validators of the contract, unless it is nil.
+func GetBondedValidators(state *state.StateDB, cfg *IndexConfigs, scAddress common.Address, maxSize *big.Int,
+	bonded func(candidate common.Address, stake *big.Int) *big.Int) ([]common.Address, error) {
+	if codes := state.GetCode(scAddress); len(codes) == 0 {
+		return nil, bind.ErrNoCode
+	}
+	c := &stateDBStakingCaller{stateDB: state, config: cfg}
+	candidates, totalStakes, err := GetCandidateStakes(state, cfg, scAddress)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		validators  []common.Address
+		stakes      = make(map[common.Address]*big.Int)
+		minValStake = c.GetMinValidatorStake(scAddress)
+	)
+	for _, candidate := range candidates {
+		stake := bonded(candidate, totalStakes[candidate])
+		if stake == nil || stake.Cmp(minValStake) < 0 {
+			continue
+		}
+		validators = append(validators, candidate)
+		stakes[candidate] = stake
+	}
+
+	if maxSize == nil {
+		maxSize = c.GetMaxValidatorSize(scAddress)
+	}
+	if !maxSize.IsUint64() || maxSize.Uint64() >= uint64(len(validators)) {
+		return validators, nil
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		if stakes[validators[i]].Cmp(stakes[validators[j]]) == 0 {
+			return strings.Compare(validators[i].String(), validators[j].String()) > 0
+		}
+		return stakes[validators[i]].Cmp(stakes[validators[j]]) > 0
+	})
+	return validators[:maxSize.Uint64()], nil
+}
+
 // GetCandidateStake returns current stake of a candidate
 func (c *stateDBStakingCaller) GetCandidateStake(scAddress common.Address, candidate common.Address) *big.Int {
 	loc := getMappingElementLoc(c.config.CandidateDataLayout.slotHash(), candidate.Hash())
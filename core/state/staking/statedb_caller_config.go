@@ -37,6 +37,7 @@ type CandidateDataStructIndex struct {
 	Owner        LayOut
 	TotalStake   LayOut
 	VotersStakes LayOut
+	Commission   LayOut // the slot after the candidate data of contracts which don't store a commission, it reads 0
 }
 
 // DefaultConfig represents he default configuration.
@@ -55,6 +56,7 @@ var DefaultConfig = &IndexConfigs{
 		TotalStake:   NewLayOut(1, 0),
 		Owner:        NewLayOut(2, 0),
 		VotersStakes: NewLayOut(3, 0),
+		Commission:   NewLayOut(4, 0),
 	},
 }
 
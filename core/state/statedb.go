@@ -87,6 +87,9 @@ type StateDB struct {
 
 	preimages map[common.Hash][]byte
 
+	// The accounts read or written since TrackAccesses, nil if they are not tracked.
+	accessed map[common.Address]struct{}
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -459,6 +462,9 @@ func (s *StateDB) deleteStateObject(stateObject *stateObject) {
 
 // Retrieve a state object given by the address. Returns nil if not found.
 func (s *StateDB) getStateObject(addr common.Address) (stateObject *stateObject) {
+	if s.accessed != nil {
+		s.accessed[addr] = struct{}{}
+	}
 	// Prefer live objects
 	if obj := s.stateObjects[addr]; obj != nil {
 		if obj.deleted {
@@ -760,3 +766,44 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 	})
 	return root, err
 }
+
+// TrackAccesses starts recording the accounts read or written, the ones recorded so far are forgotten.
+func (self *StateDB) TrackAccesses() {
+	self.accessed = make(map[common.Address]struct{})
+}
+
+// AccessedAccounts returns the accounts read or written since TrackAccesses.
+func (self *StateDB) AccessedAccounts() map[common.Address]struct{} {
+	return self.accessed
+}
+
+// Merge moves the finalised changes of the accounts addrs, the logs and the preimages of other into the state.
+// other must be a copy of the state which has been modified independently, the state must not
+// have touched addrs since, and the logs of other must belong to transactions the state has no logs of.
+func (self *StateDB) Merge(other *StateDB, addrs map[common.Address]struct{}) {
+	for addr := range addrs {
+		if _, dirty := other.stateObjectsDirty[addr]; !dirty {
+			continue
+		}
+		object := other.stateObjects[addr].deepCopy(self)
+		self.stateObjects[addr] = object
+		self.stateObjectsDirty[addr] = struct{}{}
+		if object.deleted {
+			self.deleteStateObject(object)
+		} else {
+			self.updateStateObject(object)
+		}
+	}
+	for hash, logs := range other.logs {
+		if _, exist := self.logs[hash]; !exist {
+			self.logs[hash] = logs
+			self.logSize += uint(len(logs))
+		}
+	}
+	for hash, preimage := range other.preimages {
+		self.preimages[hash] = preimage
+	}
+	if other.dbErr != nil {
+		self.setError(other.dbErr)
+	}
+}
@@ -75,8 +75,13 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, receipt.Logs...)
 	}
-	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
-	err := p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles())
+	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards),
+	// tendermint blocks have no uncles to reward
+	uncles := block.Uncles()
+	if p.config.Tendermint != nil {
+		uncles = nil
+	}
+	err := p.engine.Finalize(p.bc, header, statedb, block.Transactions(), uncles)
 	return receipts, allLogs, *usedGas, err
 }
 
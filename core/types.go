@@ -49,3 +49,12 @@ type Processor interface {
 	// the processor (coinbase) and any included uncles.
 	Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error)
 }
+
+// ParallelProcessor is a Processor which can execute the independent transactions of a block concurrently.
+type ParallelProcessor interface {
+	Processor
+
+	// ProcessParallel processes the block like Process, executing the transactions which access distinct
+	// accounts concurrently, and falls back to Process if they don't.
+	ProcessParallel(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error)
+}
@@ -38,6 +38,10 @@ var (
 
 	// ErrInvalidTendermintHeaderExtra is returned if the length of extra-data is less than 32 bytes
 	ErrInvalidTendermintHeaderExtra = errors.New("invalid tendermint header extra-data")
+	// ErrInvalidTendermintSeal is returned if the proposer seal is neither empty nor TendermintExtraSeal bytes
+	ErrInvalidTendermintSeal = errors.New("invalid tendermint proposer seal length")
+	// ErrInvalidTendermintCommittedSeal is returned if a committed seal is not TendermintExtraSeal bytes
+	ErrInvalidTendermintCommittedSeal = errors.New("invalid tendermint committed seal length")
 )
 
 // TendermintExtra extra data for Tendermint consensus
@@ -47,45 +51,167 @@ type TendermintExtra struct {
 	CommittedSeal [][]byte
 	// Set of authorized validators at this moment
 	ValidatorAdds []byte
+	// MissingValidators is a bitmap over the sorted validator set of the block, bit i is set if
+	// the precommit of validator i is absent from CommittedSeal, which holds every precommit for the block the
+	// committer had received when it committed it. A precommit received later is still counted as missing.
+	// It is empty for blocks committed before it was introduced.
+	MissingValidators []byte
+	// Round is the round the block was committed in. Like CommittedSeal it is written at commit
+	// and is not part of the block hash, so it is not signed by the validators.
+	Round uint64
+	// ParentCommit is the committed seals of the parent block, as known by the proposer. Unlike CommittedSeal
+	// it is written when the block is prepared and is part of the block hash, so every node agrees on it.
+	ParentCommit [][]byte
 }
 
 // EncodeRLP serializes ist into the Evrynet RLP format.
+// MissingValidators, Round and ParentCommit are only appended when they are not empty so the encoding of older extra-data stays the same.
 func (te *TendermintExtra) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{
+	fields := []interface{}{
 		te.Seal,
 		te.CommittedSeal,
 		te.ValidatorAdds,
-	})
+	}
+	if len(te.MissingValidators) > 0 || te.Round > 0 || len(te.ParentCommit) > 0 {
+		fields = append(fields, te.MissingValidators)
+	}
+	if te.Round > 0 || len(te.ParentCommit) > 0 {
+		fields = append(fields, te.Round)
+	}
+	if len(te.ParentCommit) > 0 {
+		fields = append(fields, te.ParentCommit)
+	}
+	return rlp.Encode(w, fields)
 }
 
 // DecodeRLP implements rlp.Decoder, and load the tendermint fields from a RLP stream.
 func (te *TendermintExtra) DecodeRLP(s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
 	var tendermintExtra struct {
-		Seal          []byte
-		CommittedSeal [][]byte
-		ValidatorAdds []byte
+		Seal              []byte
+		CommittedSeal     [][]byte
+		ValidatorAdds     []byte
+		MissingValidators []byte
+		Round             uint64
+		ParentCommit      [][]byte
+	}
+	if err := s.Decode(&tendermintExtra.Seal); err != nil {
+		return err
+	}
+	if err := s.Decode(&tendermintExtra.CommittedSeal); err != nil {
+		return err
+	}
+	if err := s.Decode(&tendermintExtra.ValidatorAdds); err != nil {
+		return err
+	}
+	// MissingValidators, Round and ParentCommit are optional, older extra-data only has 3, 4 or 5 fields
+	err := s.Decode(&tendermintExtra.MissingValidators)
+	if err == nil {
+		err = s.Decode(&tendermintExtra.Round)
+	}
+	if err == nil {
+		err = s.Decode(&tendermintExtra.ParentCommit)
 	}
-	if err := s.Decode(&tendermintExtra); err != nil {
+	if err != nil && err != rlp.EOL {
+		return err
+	}
+	if err := s.ListEnd(); err != nil {
 		return err
 	}
 	te.Seal, te.CommittedSeal, te.ValidatorAdds = tendermintExtra.Seal, tendermintExtra.CommittedSeal, tendermintExtra.ValidatorAdds
+	te.MissingValidators, te.Round = tendermintExtra.MissingValidators, tendermintExtra.Round
+	te.ParentCommit = tendermintExtra.ParentCommit
 	return nil
 }
 
-// ExtractTendermintExtra extracts all values of the TendermintExtra from the header. It returns an
-// error if the length of the given extra-data is less than 32 bytes or the extra-data can not
-// be decoded.
-func ExtractTendermintExtra(h *Header) (*TendermintExtra, error) {
-	if len(h.Extra) < TendermintExtraVanity {
-		return nil, ErrInvalidTendermintHeaderExtra
+// Validate checks the sizes of the seals: the proposer seal is empty before the block is sealed
+// and every seal is a TendermintExtraSeal bytes signature.
+func (te *TendermintExtra) Validate() error {
+	if len(te.Seal) != 0 && len(te.Seal) != TendermintExtraSeal {
+		return ErrInvalidTendermintSeal
+	}
+	for _, seal := range te.CommittedSeal {
+		if len(seal) != TendermintExtraSeal {
+			return ErrInvalidTendermintCommittedSeal
+		}
+	}
+	for _, seal := range te.ParentCommit {
+		if len(seal) != TendermintExtraSeal {
+			return ErrInvalidTendermintCommittedSeal
+		}
+	}
+	return nil
+}
+
+// Validators decodes the addresses of the validator set, empty if the block is not a checkpoint.
+func (te *TendermintExtra) Validators() ([]common.Address, error) {
+	if len(te.ValidatorAdds) == 0 {
+		return nil, nil
 	}
+	var validators []common.Address
+	if err := rlp.DecodeBytes(te.ValidatorAdds, &validators); err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
 
-	var tendermintExtra *TendermintExtra
-	err := rlp.DecodeBytes(h.Extra[TendermintExtraVanity:], &tendermintExtra)
+// SetValidators encodes the addresses of the validator set into ValidatorAdds.
+func (te *TendermintExtra) SetValidators(validators []common.Address) error {
+	data, err := rlp.EncodeToBytes(validators)
+	if err != nil {
+		return err
+	}
+	te.ValidatorAdds = data
+	return nil
+}
+
+// Encode returns the extra-data of the given vanity followed by the RLP encoded tendermint extra.
+// The vanity is padded with zeros or truncated to TendermintExtraVanity bytes.
+func (te *TendermintExtra) Encode(vanity []byte) ([]byte, error) {
+	if err := te.Validate(); err != nil {
+		return nil, err
+	}
+	payload, err := rlp.EncodeToBytes(te)
 	if err != nil {
 		return nil, err
 	}
-	return tendermintExtra, nil
+	extra := make([]byte, TendermintExtraVanity, TendermintExtraVanity+len(payload))
+	copy(extra, vanity)
+	return append(extra, payload...), nil
+}
+
+// DecodeTendermintExtra decodes and validates the tendermint extra of an extra-data. It returns an
+// error if the length of the extra-data is less than 32 bytes, the extra-data can not be decoded
+// or the seals have invalid sizes.
+func DecodeTendermintExtra(extra []byte) (*TendermintExtra, error) {
+	if len(extra) < TendermintExtraVanity {
+		return nil, ErrInvalidTendermintHeaderExtra
+	}
+	var tendermintExtra TendermintExtra
+	if err := rlp.DecodeBytes(extra[TendermintExtraVanity:], &tendermintExtra); err != nil {
+		return nil, err
+	}
+	if err := tendermintExtra.Validate(); err != nil {
+		return nil, err
+	}
+	return &tendermintExtra, nil
+}
+
+// ExtractTendermintExtra extracts all values of the TendermintExtra from the header, see DecodeTendermintExtra.
+func ExtractTendermintExtra(h *Header) (*TendermintExtra, error) {
+	return DecodeTendermintExtra(h.Extra)
+}
+
+// WriteTendermintExtra replaces the tendermint extra of the header, keeping its vanity.
+func WriteTendermintExtra(h *Header, te *TendermintExtra) error {
+	extra, err := te.Encode(h.Extra)
+	if err != nil {
+		return err
+	}
+	h.Extra = extra
+	return nil
 }
 
 // TendermintFilteredHeader returns a filtered header which some information (like seal, committed seals)
@@ -103,13 +229,11 @@ func TendermintFilteredHeader(h *Header, keepSeal bool) *Header {
 	}
 	tendermintExtra.CommittedSeal = [][]byte{}
 	tendermintExtra.ValidatorAdds = []byte{}
+	tendermintExtra.MissingValidators = []byte{}
+	tendermintExtra.Round = 0
 
-	payload, err := rlp.EncodeToBytes(&tendermintExtra)
-	if err != nil {
+	if err := WriteTendermintExtra(newHeader, tendermintExtra); err != nil {
 		return nil
 	}
-
-	newHeader.Extra = append(newHeader.Extra[:TendermintExtraVanity], payload...)
-
 	return newHeader
 }
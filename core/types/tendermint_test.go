@@ -0,0 +1,157 @@
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func TestTendermintExtraRLP(t *testing.T) {
+	extra := &TendermintExtra{
+		Seal:              bytes.Repeat([]byte{0x01}, TendermintExtraSeal),
+		CommittedSeal:     [][]byte{bytes.Repeat([]byte{0x02}, TendermintExtraSeal)},
+		ValidatorAdds:     []byte{0x03},
+		MissingValidators: []byte{0x04},
+	}
+	enc, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		t.Fatalf("failed to encode extra: %v", err)
+	}
+	var decoded TendermintExtra
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatalf("failed to decode extra: %v", err)
+	}
+	if !bytes.Equal(decoded.Seal, extra.Seal) || len(decoded.CommittedSeal) != 1 ||
+		!bytes.Equal(decoded.ValidatorAdds, extra.ValidatorAdds) || !bytes.Equal(decoded.MissingValidators, extra.MissingValidators) {
+		t.Errorf("decoded extra mismatch: have %+v, want %+v", decoded, extra)
+	}
+}
+
+func TestTendermintExtraRLPWithoutMissingValidators(t *testing.T) {
+	// extra-data encoded before MissingValidators was introduced
+	legacy, err := rlp.EncodeToBytes([]interface{}{[]byte{0x01}, [][]byte{}, []byte{0x03}})
+	if err != nil {
+		t.Fatalf("failed to encode legacy extra: %v", err)
+	}
+	var decoded TendermintExtra
+	if err := rlp.DecodeBytes(legacy, &decoded); err != nil {
+		t.Fatalf("failed to decode legacy extra: %v", err)
+	}
+	if len(decoded.MissingValidators) != 0 {
+		t.Errorf("expected empty missing validators, have %x", decoded.MissingValidators)
+	}
+	enc, err := rlp.EncodeToBytes(&decoded)
+	if err != nil {
+		t.Fatalf("failed to re-encode extra: %v", err)
+	}
+	if !bytes.Equal(enc, legacy) {
+		t.Errorf("legacy encoding changed: have %x, want %x", enc, legacy)
+	}
+}
+
+func TestTendermintExtraRound(t *testing.T) {
+	// the round follows an empty missing validators bitmap
+	extra := &TendermintExtra{Seal: []byte{}, CommittedSeal: [][]byte{}, Round: 3}
+	enc, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		t.Fatalf("failed to encode extra: %v", err)
+	}
+	var decoded TendermintExtra
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatalf("failed to decode extra: %v", err)
+	}
+	if decoded.Round != 3 || len(decoded.MissingValidators) != 0 {
+		t.Errorf("decoded extra mismatch: have %+v, want %+v", decoded, extra)
+	}
+
+	// the round is written at commit, it does not change the block hash
+	header := &Header{Number: big.NewInt(1), MixDigest: TendermintDigest}
+	if err := WriteTendermintExtra(header, &TendermintExtra{}); err != nil {
+		t.Fatalf("failed to write extra: %v", err)
+	}
+	hash := header.Hash()
+	if err := WriteTendermintExtra(header, extra); err != nil {
+		t.Fatalf("failed to write extra: %v", err)
+	}
+	if header.Hash() != hash {
+		t.Errorf("hash changed with the round: have %x, want %x", header.Hash(), hash)
+	}
+}
+
+func TestTendermintExtraParentCommit(t *testing.T) {
+	extra := &TendermintExtra{
+		Seal:          []byte{},
+		CommittedSeal: [][]byte{},
+		ParentCommit:  [][]byte{bytes.Repeat([]byte{0x02}, TendermintExtraSeal)},
+	}
+	enc, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		t.Fatalf("failed to encode extra: %v", err)
+	}
+	var decoded TendermintExtra
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatalf("failed to decode extra: %v", err)
+	}
+	if len(decoded.ParentCommit) != 1 || !bytes.Equal(decoded.ParentCommit[0], extra.ParentCommit[0]) {
+		t.Errorf("decoded extra mismatch: have %+v, want %+v", decoded, extra)
+	}
+
+	// the parent commit is written when the block is prepared, it is part of the block hash
+	header := &Header{Number: big.NewInt(1), MixDigest: TendermintDigest}
+	if err := WriteTendermintExtra(header, &TendermintExtra{}); err != nil {
+		t.Fatalf("failed to write extra: %v", err)
+	}
+	hash := header.Hash()
+	if err := WriteTendermintExtra(header, extra); err != nil {
+		t.Fatalf("failed to write extra: %v", err)
+	}
+	if header.Hash() == hash {
+		t.Errorf("hash did not change with the parent commit")
+	}
+}
+
+func TestDecodeTendermintExtra(t *testing.T) {
+	validators := []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+	extra := &TendermintExtra{
+		Seal:          bytes.Repeat([]byte{0x01}, TendermintExtraSeal),
+		CommittedSeal: [][]byte{bytes.Repeat([]byte{0x02}, TendermintExtraSeal)},
+	}
+	if err := extra.SetValidators(validators); err != nil {
+		t.Fatalf("failed to set validators: %v", err)
+	}
+	vanity := []byte("vanity")
+	enc, err := extra.Encode(vanity)
+	if err != nil {
+		t.Fatalf("failed to encode extra: %v", err)
+	}
+	if !bytes.Equal(enc[:len(vanity)], vanity) || !bytes.Equal(enc[len(vanity):TendermintExtraVanity], make([]byte, TendermintExtraVanity-len(vanity))) {
+		t.Errorf("vanity not padded: have %x", enc[:TendermintExtraVanity])
+	}
+	decoded, err := DecodeTendermintExtra(enc)
+	if err != nil {
+		t.Fatalf("failed to decode extra: %v", err)
+	}
+	have, err := decoded.Validators()
+	if err != nil {
+		t.Fatalf("failed to decode validators: %v", err)
+	}
+	if !reflect.DeepEqual(have, validators) {
+		t.Errorf("validators mismatch: have %v, want %v", have, validators)
+	}
+
+	if _, err := DecodeTendermintExtra(vanity); err != ErrInvalidTendermintHeaderExtra {
+		t.Errorf("short extra-data: have %v, want %v", err, ErrInvalidTendermintHeaderExtra)
+	}
+	extra.Seal = extra.Seal[1:]
+	if _, err := extra.Encode(vanity); err != ErrInvalidTendermintSeal {
+		t.Errorf("invalid seal: have %v, want %v", err, ErrInvalidTendermintSeal)
+	}
+	payload, _ := rlp.EncodeToBytes(&TendermintExtra{CommittedSeal: [][]byte{{0x01}}})
+	if _, err := DecodeTendermintExtra(append(make([]byte, TendermintExtraVanity), payload...)); err != ErrInvalidTendermintCommittedSeal {
+		t.Errorf("invalid committed seal: have %v, want %v", err, ErrInvalidTendermintCommittedSeal)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/accounts"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/math"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/bloombits"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
@@ -54,9 +55,12 @@ func (b *EvrAPIBackend) CurrentBlock() *types.Block {
 	return b.evr.blockchain.CurrentBlock()
 }
 
-func (b *EvrAPIBackend) SetHead(number uint64) {
+func (b *EvrAPIBackend) SetHead(number uint64, force bool) error {
 	b.evr.protocolManager.downloader.Cancel()
-	b.evr.blockchain.SetHead(number)
+	if force {
+		return b.evr.blockchain.ForceSetHead(number)
+	}
+	return b.evr.blockchain.SetHead(number)
 }
 
 func (b *EvrAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
@@ -69,6 +73,15 @@ func (b *EvrAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber {
 		return b.evr.blockchain.CurrentBlock().Header(), nil
 	}
+	// A committed tendermint block is final, the finalized and safe blocks are the head once its commit is verified.
+	// They lag behind it while the node syncs the blocks without their commits, and are nil under the other engines.
+	if blockNr == rpc.FinalizedBlockNumber || blockNr == rpc.SafeBlockNumber {
+		block := b.evr.blockchain.LatestFinalized()
+		if block == nil {
+			return nil, nil
+		}
+		return block.Header(), nil
+	}
 	return b.evr.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
@@ -86,6 +99,9 @@ func (b *EvrAPIBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	if blockNr == rpc.LatestBlockNumber {
 		return b.evr.blockchain.CurrentBlock(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber || blockNr == rpc.SafeBlockNumber {
+		return b.evr.blockchain.LatestFinalized(), nil
+	}
 	return b.evr.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
 
@@ -212,6 +228,16 @@ func (b *EvrAPIBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+// Engine returns the consensus engine of the chain
+func (b *EvrAPIBackend) Engine() consensus.Engine {
+	return b.evr.engine
+}
+
+// ChainReader returns the chain to read the headers from, for the consensus engine
+func (b *EvrAPIBackend) ChainReader() consensus.ChainReader {
+	return b.evr.blockchain
+}
+
 func (b *EvrAPIBackend) ChainDb() evrdb.Database {
 	return b.evr.ChainDb()
 }
@@ -0,0 +1,46 @@
+package evr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+func TestEvrAPIBackend_FinalizedBlock(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 5, nil)
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	require.NoError(t, err)
+	defer chain.Stop()
+	backend := &EvrAPIBackend{evr: &Evrynet{blockchain: chain}}
+
+	// no block is finalized yet
+	for _, number := range []rpc.BlockNumber{rpc.FinalizedBlockNumber, rpc.SafeBlockNumber} {
+		header, err := backend.HeaderByNumber(context.Background(), number)
+		require.NoError(t, err)
+		require.Nil(t, header)
+	}
+
+	_, err = chain.InsertChain(blocks[:3])
+	require.NoError(t, err)
+	for _, number := range []rpc.BlockNumber{rpc.FinalizedBlockNumber, rpc.SafeBlockNumber} {
+		header, err := backend.HeaderByNumber(context.Background(), number)
+		require.NoError(t, err)
+		require.Equal(t, blocks[2].Hash(), header.Hash())
+		block, err := backend.BlockByNumber(context.Background(), number)
+		require.NoError(t, err)
+		require.Equal(t, blocks[2].Hash(), block.Hash())
+	}
+}
@@ -21,9 +21,12 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
+	"net/http"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/accounts"
 	"github.com/Evrynetlabs/evrynet-node/common"
@@ -38,6 +41,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/evr/downloader"
 	"github.com/Evrynetlabs/evrynet-node/evr/filters"
@@ -93,6 +97,9 @@ type Evrynet struct {
 	networkID     uint64
 	netRPCService *evrapi.PublicNetAPI
 
+	healthListener  net.Listener // healthListener serves the health and readiness probes of the consensus
+	metricsListener net.Listener // metricsListener serves the metrics server for the consensus dashboards
+
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and etherbase)
 }
 
@@ -127,6 +134,41 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 		return nil, genesisErr
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
+	if err := chainConfig.CheckEngine(); err != nil {
+		return nil, err
+	}
+	if chainConfig.Tendermint != nil {
+		if err := chainConfig.Tendermint.CheckKeyRotations(); err != nil {
+			return nil, err
+		}
+		if err := chainConfig.Tendermint.CheckRewardSchedule(); err != nil {
+			return nil, err
+		}
+		if err := chainConfig.Tendermint.CheckFeeRouting(); err != nil {
+			return nil, err
+		}
+		if err := chainConfig.Tendermint.CheckTimeoutBackoff(); err != nil {
+			return nil, err
+		}
+		if err := chainConfig.Tendermint.CheckRecoveries(); err != nil {
+			return nil, err
+		}
+		if err := chainConfig.Tendermint.CheckCommission(); err != nil {
+			return nil, err
+		}
+		if err := chainConfig.Tendermint.CheckBonding(); err != nil {
+			return nil, err
+		}
+		if err := chainConfig.Tendermint.CheckDelegatorRewards(); err != nil {
+			return nil, err
+		}
+		if err := chainConfig.Tendermint.CheckMinSelfBond(); err != nil {
+			return nil, err
+		}
+		for _, r := range chainConfig.Tendermint.Recoveries {
+			log.Warn("Tendermint validator set recovery", "block", r.Block, "validators", common.PrettyAddresses(r.Validators))
+		}
+	}
 
 	evr := &Evrynet{
 		config:         config,
@@ -178,7 +220,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
-		evr.blockchain.SetHead(compat.RewindTo)
+		evr.blockchain.ForceSetHead(compat.RewindTo)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	evr.bloomIndexer.Start(evr.blockchain)
@@ -193,6 +235,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 	if evr.protocolManager, err = NewProtocolManager(chainConfig, config.SyncMode, config.NetworkId, evr.eventMux, evr.txPool, evr.engine, evr.blockchain, chainDb, cacheLimit, config.Whitelist); err != nil {
 		return nil, err
 	}
+	if !config.Tendermint.NoConsensusProtocol {
+		evr.protocolManager.EnableConsensusProtocol()
+		if config.Tendermint.ValidatorOnlyNetwork {
+			// observers are whitelisted since the validators send them the consensus messages
+			whitelist := append(append([]common.Address{}, config.Tendermint.Sentries...), config.Tendermint.Observers...)
+			evr.protocolManager.RestrictConsensusProtocol(whitelist)
+		}
+	}
 	evr.miner = miner.New(evr, &config.Miner, chainConfig, evr.EventMux(), evr.engine, evr.isLocalBlock)
 	evr.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
 
@@ -228,13 +278,37 @@ func CreateConsensusEngine(ctx *node.ServiceContext, chainConfig *params.ChainCo
 	}
 	// If Tendermint is requested, set it up
 	if chainConfig.Tendermint != nil {
-		config.Tendermint.ProposerPolicy = tendermint.ProposerPolicy(chainConfig.Tendermint.ProposerPolicy)
-		config.Tendermint.Epoch = chainConfig.Tendermint.Epoch
+		// the genesis sets the consensus parameters all validators must agree on, the node configuration only fills the ones it leaves out
+		if chainConfig.Tendermint.ProposerPolicy != 0 {
+			config.Tendermint.ProposerPolicy = tendermint.ProposerPolicy(chainConfig.Tendermint.ProposerPolicy)
+		}
+		if chainConfig.Tendermint.Epoch != 0 {
+			config.Tendermint.Epoch = chainConfig.Tendermint.Epoch
+		}
 		config.Tendermint.StakingSCAddress = chainConfig.Tendermint.StakingSCAddress
 		config.Tendermint.FixedValidators = chainConfig.Tendermint.FixedValidators
+		config.Tendermint.KeyRotations = chainConfig.Tendermint.KeyRotations
+		config.Tendermint.Recoveries = chainConfig.Tendermint.Recoveries
+		config.Tendermint.RewardSchedule = chainConfig.Tendermint.RewardSchedule
 		config.Tendermint.BlockReward = chainConfig.Tendermint.BlockReward
-		log.Info("Create Tendermint consensus engine")
-		return tendermintBackend.New(&config.Tendermint, ctx.NodeKey())
+		config.Tendermint.ChainID = chainConfig.ChainID
+		config.Tendermint.DomainSeparationBlock = chainConfig.Tendermint.DomainSeparationBlock
+		config.Tendermint.WeightedVotingBlock = chainConfig.Tendermint.WeightedVotingBlock
+		config.Tendermint.ProtobufSigningBlock = chainConfig.Tendermint.ProtobufSigningBlock
+		config.Tendermint.StrictTimestampBlock = chainConfig.Tendermint.StrictTimestampBlock
+		config.Tendermint.GasLimitBoundBlock = chainConfig.Tendermint.GasLimitBoundBlock
+		config.Tendermint.TimeoutBackoff = tendermint.TimeoutBackoff(chainConfig.Tendermint.TimeoutBackoff)
+		config.Tendermint.TimeoutBackoffMax = time.Duration(chainConfig.Tendermint.TimeoutBackoffMax) * time.Millisecond
+		if chainConfig.Tendermint.BlockPeriod != 0 {
+			config.Tendermint.BlockPeriod = chainConfig.Tendermint.BlockPeriod
+		}
+		// The validator signs with its own key if one was loaded, the node key otherwise
+		key := config.Tendermint.ValidatorKey
+		if key == nil {
+			key = ctx.NodeKey()
+		}
+		log.Info("Create Tendermint consensus engine", "validator", crypto.PubkeyToAddress(key.PublicKey))
+		return tendermintBackend.New(&config.Tendermint, key, tendermintBackend.WithDB(db))
 	}
 
 	// Otherwise assume proof-of-work
@@ -528,11 +602,64 @@ func (s *Evrynet) Start(srvr *p2p.Server) error {
 		}
 		maxPeers -= s.config.LightPeers
 	}
+	// The consensus peers identify the validator by its validator key, which may differ from its node key
+	if err := s.protocolManager.SignConsensusIdentity(srvr.Self().ID()); err != nil {
+		return err
+	}
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	// An observer runs the tendermint core without mining to finalize blocks as soon as they are committed
+	if tendermint, ok := s.engine.(consensus.Tendermint); ok && s.config.Tendermint.Observer {
+		go func() {
+			if err := tendermint.Start(s.blockchain, s.blockchain.CurrentBlock, s.blockchain.Validator().ValidateBody); err != nil {
+				log.Error("Failed to start Tendermint observer", "err", err)
+			}
+		}()
+	}
+	if err := s.startHealth(); err != nil {
+		return err
+	}
+	return s.startMetrics()
+}
+
+// startHealth serves the health and readiness probes of the consensus engine if an address is configured
+func (s *Evrynet) startHealth() error {
+	type healthChecker interface {
+		HealthHandler(chain consensus.ChainReader) http.Handler
+	}
+	checker, ok := s.engine.(healthChecker)
+	if !ok || s.config.Tendermint.HealthAddr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", s.config.Tendermint.HealthAddr)
+	if err != nil {
+		return err
+	}
+	s.healthListener = listener
+	log.Info("Health endpoint opened", "url", fmt.Sprintf("http://%s/health", listener.Addr()))
+	go http.Serve(listener, checker.HealthHandler(s.blockchain))
+	return nil
+}
+
+// startMetrics serves the metrics and the validator performance of the consensus engine if an address is configured
+func (s *Evrynet) startMetrics() error {
+	type metricsServer interface {
+		MetricsHandler(chain consensus.ChainReader) http.Handler
+	}
+	server, ok := s.engine.(metricsServer)
+	if !ok || s.config.Tendermint.MetricsAddr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", s.config.Tendermint.MetricsAddr)
+	if err != nil {
+		return err
+	}
+	s.metricsListener = listener
+	log.Info("Consensus metrics server opened", "url", fmt.Sprintf("http://%s/metrics", listener.Addr()))
+	go http.Serve(listener, server.MetricsHandler(s.blockchain))
 	return nil
 }
 
@@ -543,6 +670,12 @@ func (s *Evrynet) GetPm() *ProtocolManager {
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Evrynet protocol.
 func (s *Evrynet) Stop() error {
+	if s.healthListener != nil {
+		s.healthListener.Close()
+	}
+	if s.metricsListener != nil {
+		s.metricsListener.Close()
+	}
 	s.bloomIndexer.Close()
 	s.blockchain.Stop()
 	s.engine.Close()
@@ -53,6 +53,8 @@ var DefaultConfig = Config{
 		GasFloor: 8000000,
 		GasCeil:  8000000,
 		Recommit: 3 * time.Second,
+
+		PriorityGas: 1000000,
 	},
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -0,0 +1,625 @@
+package evr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/p2p"
+	"github.com/Evrynetlabs/evrynet-node/p2p/enode"
+)
+
+// Constants to match up consensus protocol versions and messages
+const (
+	consensus1 = 1
+	consensus2 = 2 // adds the acknowledgements of proposals
+	consensus3 = 3 // adds the validator identity to the status
+	consensus4 = 4 // wraps the consensus messages in versioned envelopes
+	consensus5 = 5 // adds the protobuf encoding of the consensus messages
+	consensus6 = 6 // adds the requests of the commits of the last blocks
+)
+
+// ConsensusProtocolName is the short name of the consensus sub protocol used during capability negotiation.
+// Consensus messages are sent over it instead of evr/64, so that they can evolve independently of evr
+// and non-validator nodes can opt out of the consensus traffic entirely by not running it.
+var ConsensusProtocolName = "evrc"
+
+// ConsensusProtocolVersions are the supported versions of the consensus protocol (first is primary).
+var ConsensusProtocolVersions = []uint{consensus6, consensus5, consensus4, consensus3, consensus2, consensus1}
+
+// ConsensusProtocolLengths are the number of implemented message corresponding to different protocol versions.
+var ConsensusProtocolLengths = []uint64{7, 5, 4, 3, 3, 2}
+
+// consensus protocol message codes
+const (
+	// Protocol messages belonging to consensus/1
+	ConsensusStatusMsg = 0x00
+	ConsensusMsg       = 0x01
+
+	// Protocol messages belonging to consensus/2
+	ConsensusAckMsg = 0x02
+
+	// Protocol messages belonging to consensus/4
+	ConsensusEnvelopeMsg = 0x03
+
+	// Protocol messages belonging to consensus/5
+	ConsensusProtobufMsg = 0x04
+
+	// Protocol messages belonging to consensus/6
+	ConsensusGetCommitMsg = 0x05
+	ConsensusCommitMsg    = 0x06
+)
+
+// consensusEnvelopeVersion is the envelope version of the consensus messages sent from consensus/4 on
+const consensusEnvelopeVersion = 1
+
+var (
+	errNoConsensusHandler        = errors.New("consensus engine does not handle peer messages")
+	errUnauthorizedConsensusPeer = errors.New("consensus peer is neither a validator nor a whitelisted node")
+	errInvalidConsensusIdentity  = errors.New("invalid consensus identity")
+)
+
+// consensusCommitData is the network packet answering a ConsensusGetCommitMsg: the header of the block of Number,
+// whose extra data carries its committed seals, Headers is empty if the peer does not keep the commit of the block.
+type consensusCommitData struct {
+	Number  uint64
+	Headers []*types.Header
+}
+
+// consensusIdentityPrefix is prepended to the node id signed by the validator key of a node
+var consensusIdentityPrefix = []byte("evrc validator identity")
+
+// consensusValidators is implemented by the engines which know the validator set of a block
+type consensusValidators interface {
+	ValidatorsByChainReader(blockNumber *big.Int, chain consensus.ChainReader) tendermint.ValidatorSet
+}
+
+// consensusStatusData is the network packet for the consensus status message.
+// Both sides must run the same consensus protocol version on the same network.
+type consensusStatusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	GenesisBlock    common.Hash
+}
+
+// consensusStatusData3 is the consensus status from consensus/3 on.
+// Identity is the signature of the node id of the sender by its validator key, so that the peers identify it
+// by its validator address when it differs from the address of its node key. It is empty if the node does not sign.
+// NextIdentities are the signatures by the keys the validator is rotated to, so that the peers also reach it
+// by its next address, they are left out if the validator has no next key.
+type consensusStatusData3 struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	GenesisBlock    common.Hash
+	Identity        []byte
+	NextIdentities  [][]byte `rlp:"tail"`
+}
+
+// consensusSigner is implemented by the engines which sign with the key of the validator
+type consensusSigner interface {
+	Sign(data []byte) ([]byte, error)
+}
+
+// consensusNextSigner is implemented by the engines which sign with the next key of the validator once it is rotated
+type consensusNextSigner interface {
+	SignWithNextKey(data []byte) ([]byte, error)
+}
+
+// consensusIdentityData returns the data signed by a node to prove its validator identity
+func consensusIdentityData(id enode.ID) []byte {
+	return append(append([]byte{}, consensusIdentityPrefix...), id.Bytes()...)
+}
+
+// recoverConsensusIdentity returns the validator address which signed the id of the node
+func recoverConsensusIdentity(id enode.ID, identity []byte) (common.Address, error) {
+	pubKey, err := crypto.SigToPub(crypto.Keccak256(consensusIdentityData(id)), identity)
+	if err != nil {
+		return common.Address{}, errInvalidConsensusIdentity
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// consensusPeer is a peer connected over the consensus protocol, it implements consensus.Peer
+type consensusPeer struct {
+	*p2p.Peer
+	rw      p2p.MsgReadWriter
+	version int
+	address common.Address
+	// addresses are the address of the peer followed by the next validator addresses it proved
+	addresses []common.Address
+
+	id       string   // the id of the evr peer of the same node
+	evrPeers *peerSet // the evr peers, whose broadcast queue the consensus messages share
+}
+
+func newConsensusPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *consensusPeer {
+	peer := &consensusPeer{
+		Peer:    p,
+		rw:      rw,
+		version: version,
+		id:      fmt.Sprintf("%x", p.ID().Bytes()[:8]),
+	}
+	if pubKey := p.Node().Pubkey(); pubKey != nil {
+		peer.address = crypto.PubkeyToAddress(*pubKey)
+	}
+	peer.addresses = []common.Address{peer.address}
+	return peer
+}
+
+// Send implements consensus.Peer.Send.
+// The engine sends its messages as consensus.TendermintMsg whatever the protocol, which is ConsensusMsg here,
+// its envelopes as consensus.TendermintEnvelopeMsg, which is ConsensusEnvelopeMsg, and its protobuf envelopes
+// as consensus.TendermintProtobufMsg, which is ConsensusProtobufMsg.
+func (p *consensusPeer) Send(msgcode uint64, data interface{}) error {
+	switch msgcode {
+	case consensus.TendermintMsg:
+		msgcode = ConsensusMsg
+	case consensus.TendermintEnvelopeMsg:
+		if p.EnvelopeVersion() == 0 {
+			return errResp(ErrInvalidMsgCode, "%v", ConsensusEnvelopeMsg)
+		}
+		msgcode = ConsensusEnvelopeMsg
+	case consensus.TendermintProtobufMsg:
+		if !p.Protobuf() {
+			return errResp(ErrInvalidMsgCode, "%v", ConsensusProtobufMsg)
+		}
+		msgcode = ConsensusProtobufMsg
+	}
+	return p.write(msgcode, data)
+}
+
+// Protobuf implements consensus.ProtobufPeer.Protobuf
+func (p *consensusPeer) Protobuf() bool {
+	return p.version >= consensus5
+}
+
+// EnvelopeVersion implements consensus.EnvelopePeer.EnvelopeVersion
+func (p *consensusPeer) EnvelopeVersion() uint64 {
+	if p.version >= consensus4 {
+		return consensusEnvelopeVersion
+	}
+	return 0
+}
+
+// VoteBatches implements consensus.VoteBatchPeer.VoteBatches.
+// The vote batches predate consensus/4, every peer speaking it decodes them.
+func (p *consensusPeer) VoteBatches() bool {
+	return p.version >= consensus4
+}
+
+// Acks implements consensus.AckPeer.Acks
+func (p *consensusPeer) Acks() bool {
+	return p.version >= consensus2
+}
+
+// SendAck implements consensus.AckPeer.SendAck
+func (p *consensusPeer) SendAck(hash common.Hash) error {
+	if !p.Acks() {
+		return errResp(ErrInvalidMsgCode, "%v", ConsensusAckMsg)
+	}
+	return p.write(ConsensusAckMsg, hash)
+}
+
+// Commits implements consensus.CommitPeer.Commits
+func (p *consensusPeer) Commits() bool {
+	return p.version >= consensus6
+}
+
+// RequestCommit implements consensus.CommitPeer.RequestCommit
+func (p *consensusPeer) RequestCommit(number uint64) error {
+	if !p.Commits() {
+		return errResp(ErrInvalidMsgCode, "%v", ConsensusGetCommitMsg)
+	}
+	return p.write(ConsensusGetCommitMsg, number)
+}
+
+// write sends the message, ahead of the queued blocks and transactions if the node is also connected over evr
+func (p *consensusPeer) write(msgcode uint64, data interface{}) error {
+	send := func() error {
+		return p2p.Send(p.rw, msgcode, data)
+	}
+	if p.evrPeers != nil {
+		if evrPeer := p.evrPeers.Peer(p.id); evrPeer != nil {
+			return evrPeer.sendPrioritized(send)
+		}
+	}
+	return send()
+}
+
+// Address implements consensus.Peer.Address
+func (p *consensusPeer) Address() common.Address {
+	return p.address
+}
+
+// Handshake exchanges the consensus status with the peer and checks that both sides are compatible.
+// From consensus/3 on, identities prove the validator address of the node followed by its next ones,
+// and the address of the peer becomes the validator address proven by its status if any.
+func (p *consensusPeer) Handshake(network uint64, genesis common.Hash, identities [][]byte) error {
+	errc := make(chan error, 2)
+	go func() {
+		if p.version >= consensus3 {
+			status := &consensusStatusData3{
+				ProtocolVersion: uint32(p.version),
+				NetworkId:       network,
+				GenesisBlock:    genesis,
+			}
+			if len(identities) > 0 {
+				status.Identity, status.NextIdentities = identities[0], identities[1:]
+			}
+			errc <- p2p.Send(p.rw, ConsensusStatusMsg, status)
+			return
+		}
+		errc <- p2p.Send(p.rw, ConsensusStatusMsg, &consensusStatusData{
+			ProtocolVersion: uint32(p.version),
+			NetworkId:       network,
+			GenesisBlock:    genesis,
+		})
+	}()
+	go func() {
+		errc <- p.readStatus(network, genesis)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return p2p.DiscReadTimeout
+		}
+	}
+	return nil
+}
+
+func (p *consensusPeer) readStatus(network uint64, genesis common.Hash) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+	if msg.Code != ConsensusStatusMsg {
+		return errResp(ErrNoStatusMsg, "first msg has code %x (!= %x)", msg.Code, ConsensusStatusMsg)
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	var status consensusStatusData3
+	if p.version >= consensus3 {
+		if err := msg.Decode(&status); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+	} else {
+		var legacy consensusStatusData
+		if err := msg.Decode(&legacy); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		status.ProtocolVersion, status.NetworkId, status.GenesisBlock = legacy.ProtocolVersion, legacy.NetworkId, legacy.GenesisBlock
+	}
+	if status.GenesisBlock != genesis {
+		return errResp(ErrGenesisBlockMismatch, "%x (!= %x)", status.GenesisBlock[:8], genesis[:8])
+	}
+	if status.NetworkId != network {
+		return errResp(ErrNetworkIdMismatch, "%d (!= %d)", status.NetworkId, network)
+	}
+	if int(status.ProtocolVersion) != p.version {
+		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
+	}
+	if len(status.Identity) > 0 {
+		addr, err := recoverConsensusIdentity(p.ID(), status.Identity)
+		if err != nil {
+			return errResp(ErrDecode, "%v", err)
+		}
+		p.address = addr
+		p.addresses = []common.Address{addr}
+	}
+	for _, identity := range status.NextIdentities {
+		addr, err := recoverConsensusIdentity(p.ID(), identity)
+		if err != nil {
+			return errResp(ErrDecode, "%v", err)
+		}
+		p.addresses = append(p.addresses, addr)
+	}
+	return nil
+}
+
+// String implements fmt.Stringer.
+func (p *consensusPeer) String() string {
+	return fmt.Sprintf("Peer %x [%s/%d]", p.ID().Bytes()[:8], ConsensusProtocolName, p.version)
+}
+
+// consensusPeerSet stores the peers connected over the consensus protocol by their address
+type consensusPeerSet struct {
+	lock   sync.RWMutex
+	peers  map[common.Address]*consensusPeer
+	closed bool
+}
+
+func newConsensusPeerSet() *consensusPeerSet {
+	return &consensusPeerSet{
+		peers: make(map[common.Address]*consensusPeer),
+	}
+}
+
+// Register adds the peer into the set under each of its addresses,
+// a newer connection replaces the older one of the same address
+func (ps *consensusPeerSet) Register(p *consensusPeer) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if ps.closed {
+		return errClosed
+	}
+	for _, addr := range p.addresses {
+		ps.peers[addr] = p
+	}
+	return nil
+}
+
+// Unregister removes the peer from the addresses it is still the registered connection of
+func (ps *consensusPeerSet) Unregister(p *consensusPeer) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	for _, addr := range p.addresses {
+		if current, ok := ps.peers[addr]; ok && current == p {
+			delete(ps.peers, addr)
+		}
+	}
+}
+
+// Peer returns the peer of the address, nil if it is not connected over the consensus protocol
+func (ps *consensusPeerSet) Peer(addr common.Address) *consensusPeer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+	return ps.peers[addr]
+}
+
+// Len returns the number of peers in the set
+func (ps *consensusPeerSet) Len() int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+	peers := make(map[*consensusPeer]struct{}, len(ps.peers))
+	for _, p := range ps.peers {
+		peers[p] = struct{}{}
+	}
+	return len(peers)
+}
+
+// Close disconnects all peers.
+// No new peers can be registered after Close has returned.
+func (ps *consensusPeerSet) Close() {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	for _, p := range ps.peers {
+		p.Disconnect(p2p.DiscQuitting)
+	}
+	ps.closed = true
+}
+
+// EnableConsensusProtocol adds the consensus protocol to the sub protocols of the manager.
+// It does nothing if the consensus engine does not handle peer messages.
+func (pm *ProtocolManager) EnableConsensusProtocol() {
+	if _, ok := pm.engine.(consensus.Handler); !ok {
+		return
+	}
+	pm.consensusEnabled = true
+	for i, version := range ConsensusProtocolVersions {
+		version := version // Closure for the run
+		pm.SubProtocols = append(pm.SubProtocols, p2p.Protocol{
+			Name:    ConsensusProtocolName,
+			Version: version,
+			Length:  ConsensusProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				select {
+				case <-pm.quitSync:
+					return p2p.DiscQuitting
+				default:
+				}
+				pm.wg.Add(1)
+				defer pm.wg.Done()
+				peer := newConsensusPeer(int(version), p, rw)
+				peer.evrPeers = pm.peers
+				return pm.handleConsensus(peer)
+			},
+		})
+	}
+}
+
+// SignConsensusIdentity signs the id of the local node with the key of the consensus engine, and with its next key if any,
+// for the consensus peers to identify the node by its validator addresses rather than by its node key.
+// It does nothing if the engine does not sign.
+func (pm *ProtocolManager) SignConsensusIdentity(self enode.ID) error {
+	signer, ok := pm.engine.(consensusSigner)
+	if !ok {
+		return nil
+	}
+	identity, err := signer.Sign(consensusIdentityData(self))
+	if err != nil {
+		return err
+	}
+	identities := [][]byte{identity}
+	if nextSigner, ok := pm.engine.(consensusNextSigner); ok {
+		next, err := nextSigner.SignWithNextKey(consensusIdentityData(self))
+		if err != nil {
+			return err
+		}
+		if next != nil {
+			identities = append(identities, next)
+		}
+	}
+	pm.consensusIdentity.Store(identities)
+	return nil
+}
+
+// RestrictConsensusProtocol makes the consensus protocol validator only: a peer may only connect
+// if its validator identity, or its node key, is the one of a validator of the current or the next block, or of a whitelisted node.
+func (pm *ProtocolManager) RestrictConsensusProtocol(whitelist []common.Address) {
+	pm.consensusWhitelist = make(map[common.Address]bool, len(whitelist))
+	for _, addr := range whitelist {
+		pm.consensusWhitelist[addr] = true
+	}
+}
+
+// authorizeConsensusPeer returns errUnauthorizedConsensusPeer if the consensus protocol is validator only
+// and none of the addresses of the peer is a validator nor whitelisted
+func (pm *ProtocolManager) authorizeConsensusPeer(addrs []common.Address) error {
+	if pm.consensusWhitelist == nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if pm.consensusWhitelist[addr] {
+			return nil
+		}
+	}
+	engine, ok := pm.engine.(consensusValidators)
+	if !ok {
+		return errUnauthorizedConsensusPeer
+	}
+	head := pm.blockchain.CurrentHeader().Number
+	for _, number := range []*big.Int{head, new(big.Int).Add(head, common.Big1)} {
+		valSet := engine.ValidatorsByChainReader(number, pm.blockchain)
+		if valSet == nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if index, _ := valSet.GetByAddress(addr); index != -1 {
+				return nil
+			}
+		}
+	}
+	return errUnauthorizedConsensusPeer
+}
+
+// runsConsensusProtocol returns true if the peer advertises the consensus protocol
+func runsConsensusProtocol(p *p2p.Peer) bool {
+	for _, c := range p.Caps() {
+		if c.Name == ConsensusProtocolName {
+			return true
+		}
+	}
+	return false
+}
+
+// isConsensusMsg returns true if code is the code of a consensus message sent over evr/64
+func isConsensusMsg(code uint64) bool {
+	switch code {
+	case consensus.TendermintMsg, consensus.TendermintEnvelopeMsg, consensus.TendermintProtobufMsg:
+		return true
+	}
+	return false
+}
+
+// handleConsensus is the callback invoked to manage the life cycle of a consensus peer.
+// When this function terminates, the peer is disconnected.
+func (pm *ProtocolManager) handleConsensus(p *consensusPeer) error {
+	handler, ok := pm.engine.(consensus.Handler)
+	if !ok {
+		return errNoConsensusHandler
+	}
+	identities, _ := pm.consensusIdentity.Load().([][]byte)
+	if err := p.Handshake(pm.networkID, pm.blockchain.Genesis().Hash(), identities); err != nil {
+		p.Log().Debug("Consensus handshake failed", "err", err)
+		return err
+	}
+	// the peer is authorized once its validator identity is known
+	if err := pm.authorizeConsensusPeer(p.addresses); err != nil {
+		p.Log().Debug("Consensus peer rejected", "addresses", p.addresses, "err", err)
+		return err
+	}
+	if err := pm.consensusPeers.Register(p); err != nil {
+		return err
+	}
+	defer pm.consensusPeers.Unregister(p)
+	p.Log().Debug("Consensus peer connected", "address", p.address, "version", p.version)
+
+	for {
+		if err := pm.handleConsensusMsg(handler, p); err != nil {
+			p.Log().Debug("Consensus message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleConsensusMsg reads the next message from the consensus peer and passes it to the engine
+func (pm *ProtocolManager) handleConsensusMsg(handler consensus.Handler, p *consensusPeer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch {
+	case msg.Code == ConsensusStatusMsg:
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+	case msg.Code == ConsensusMsg || (p.version >= consensus4 && msg.Code == ConsensusEnvelopeMsg) ||
+		(p.version >= consensus5 && msg.Code == ConsensusProtobufMsg):
+		// the engine handles its messages as consensus.TendermintMsg whatever the protocol, its envelopes
+		// as consensus.TendermintEnvelopeMsg and its protobuf envelopes as consensus.TendermintProtobufMsg
+		switch msg.Code {
+		case ConsensusMsg:
+			msg.Code = consensus.TendermintMsg
+		case ConsensusEnvelopeMsg:
+			msg.Code = consensus.TendermintEnvelopeMsg
+		default:
+			msg.Code = consensus.TendermintProtobufMsg
+		}
+		handled, err := handler.HandleMsg(p.address, msg)
+		if !handled {
+			return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+		}
+		if err == tendermint.ErrStoppedEngine {
+			return nil
+		}
+		return err
+	case p.version >= consensus2 && msg.Code == ConsensusAckMsg:
+		var hash common.Hash
+		if err := msg.Decode(&hash); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if ackHandler, ok := handler.(consensus.AckHandler); ok {
+			ackHandler.HandleAck(p.address, hash)
+		}
+		return nil
+	case p.version >= consensus6 && msg.Code == ConsensusGetCommitMsg:
+		var number uint64
+		if err := msg.Decode(&number); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// the request is always answered, with no header if the engine does not keep the commit
+		reply := &consensusCommitData{Number: number}
+		if commitHandler, ok := handler.(consensus.CommitHandler); ok {
+			if header := commitHandler.CommitAt(number); header != nil {
+				reply.Headers = []*types.Header{header}
+			}
+		}
+		return p.write(ConsensusCommitMsg, reply)
+	case p.version >= consensus6 && msg.Code == ConsensusCommitMsg:
+		var reply consensusCommitData
+		if err := msg.Decode(&reply); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(reply.Headers) > 1 {
+			return errResp(ErrDecode, "msg %v: %d headers", msg, len(reply.Headers))
+		}
+		if commitHandler, ok := handler.(consensus.CommitHandler); ok {
+			var header *types.Header
+			if len(reply.Headers) == 1 {
+				header = reply.Headers[0]
+			}
+			commitHandler.HandleCommit(p.address, reply.Number, header)
+		}
+		return nil
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+}
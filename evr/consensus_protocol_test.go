@@ -0,0 +1,323 @@
+package evr
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/p2p"
+	"github.com/Evrynetlabs/evrynet-node/p2p/enode"
+)
+
+func newTestConsensusPeers(t *testing.T, version1, version2 int) (*consensusPeer, *consensusPeer) {
+	var (
+		pk1      = mustGeneratePrivateKey(t)
+		pk2      = mustGeneratePrivateKey(t)
+		n1       = enode.MustParseV4("enode://" + hex.EncodeToString(crypto.FromECDSAPub(&pk1.PublicKey)[1:]) + "@0.0.0.0:30303")
+		n2       = enode.MustParseV4("enode://" + hex.EncodeToString(crypto.FromECDSAPub(&pk2.PublicKey)[1:]) + "@0.0.0.0:30304")
+		io1, io2 = p2p.MsgPipe()
+	)
+	// p1 is the view of node 1 from node 2 and vice versa
+	p1 := newConsensusPeer(version1, p2p.NewPeerFromNode(n1, "peer 1", nil), io1)
+	p2 := newConsensusPeer(version2, p2p.NewPeerFromNode(n2, "peer 2", nil), io2)
+	return p1, p2
+}
+
+func TestConsensusPeer_Handshake(t *testing.T) {
+	genesis := common.HexToHash("0x01")
+
+	p1, p2 := newTestConsensusPeers(t, consensus1, consensus1)
+	errc := make(chan error, 1)
+	go func() { errc <- p2.Handshake(1, genesis, nil) }()
+	require.NoError(t, p1.Handshake(1, genesis, nil))
+	require.NoError(t, <-errc)
+
+	// the versions must match
+	p1, p2 = newTestConsensusPeers(t, consensus1, consensus1+1)
+	go func() { errc <- p2.Handshake(1, genesis, nil) }()
+	require.Error(t, p1.Handshake(1, genesis, nil))
+	require.Error(t, <-errc)
+
+	// the networks must match
+	p1, p2 = newTestConsensusPeers(t, consensus1, consensus1)
+	go func() { errc <- p2.Handshake(2, genesis, nil) }()
+	require.Error(t, p1.Handshake(1, genesis, nil))
+	require.Error(t, <-errc)
+}
+
+func TestConsensusPeer_HandshakeIdentity(t *testing.T) {
+	var (
+		genesis      = common.HexToHash("0x01")
+		validatorKey = mustGeneratePrivateKey(t)
+		validator    = crypto.PubkeyToAddress(validatorKey.PublicKey)
+		errc         = make(chan error, 1)
+	)
+	// node 2 proves the validator identity of its id
+	p1, p2 := newTestConsensusPeers(t, consensus3, consensus3)
+	node1 := p1.Address()
+	identity, err := crypto.Sign(crypto.Keccak256(consensusIdentityData(p2.ID())), validatorKey)
+	require.NoError(t, err)
+	go func() { errc <- p2.Handshake(1, genesis, nil) }()
+	require.NoError(t, p1.Handshake(1, genesis, [][]byte{identity}))
+	require.NoError(t, <-errc)
+	require.Equal(t, validator, p2.Address())
+	require.Equal(t, node1, p1.Address())
+
+	// the identity of another node does not prove the validator address
+	p1, p2 = newTestConsensusPeers(t, consensus3, consensus3)
+	identity, err = crypto.Sign(crypto.Keccak256(consensusIdentityData(p1.ID())), validatorKey)
+	require.NoError(t, err)
+	go func() { errc <- p2.Handshake(1, genesis, nil) }()
+	require.NoError(t, p1.Handshake(1, genesis, [][]byte{identity}))
+	require.NoError(t, <-errc)
+	require.NotEqual(t, validator, p2.Address())
+
+	// an invalid signature fails the handshake
+	p1, p2 = newTestConsensusPeers(t, consensus3, consensus3)
+	go func() { errc <- p2.Handshake(1, genesis, nil) }()
+	require.NoError(t, p1.Handshake(1, genesis, [][]byte{{0x01}}))
+	require.Error(t, <-errc)
+	// node 2 also proves its next validator identity, it is registered under both addresses
+	var (
+		nextKey = mustGeneratePrivateKey(t)
+		next    = crypto.PubkeyToAddress(nextKey.PublicKey)
+		ps      = newConsensusPeerSet()
+	)
+	p1, p2 = newTestConsensusPeers(t, consensus3, consensus3)
+	identity, err = crypto.Sign(crypto.Keccak256(consensusIdentityData(p2.ID())), validatorKey)
+	require.NoError(t, err)
+	nextIdentity, err := crypto.Sign(crypto.Keccak256(consensusIdentityData(p2.ID())), nextKey)
+	require.NoError(t, err)
+	go func() { errc <- p2.Handshake(1, genesis, nil) }()
+	require.NoError(t, p1.Handshake(1, genesis, [][]byte{identity, nextIdentity}))
+	require.NoError(t, <-errc)
+	require.Equal(t, validator, p2.Address())
+	require.Equal(t, []common.Address{validator, next}, p2.addresses)
+	require.NoError(t, ps.Register(p2))
+	require.Equal(t, 1, ps.Len())
+	require.Equal(t, p2, ps.Peer(next))
+	ps.Unregister(p2)
+	require.Nil(t, ps.Peer(next))
+}
+
+func TestConsensusPeer_Send(t *testing.T) {
+	p1, p2 := newTestConsensusPeers(t, consensus1, consensus1)
+	payload := []byte("vote message")
+	go func() {
+		require.NoError(t, p1.Send(consensus.TendermintMsg, payload))
+	}()
+	// consensus messages are sent with the consensus protocol code
+	require.NoError(t, p2p.ExpectMsg(p2.rw, ConsensusMsg, payload))
+}
+
+func TestConsensusPeer_SendEnvelope(t *testing.T) {
+	envelope := []byte("enveloped vote message")
+
+	// envelopes are sent from consensus/4 on
+	p1, _ := newTestConsensusPeers(t, consensus3, consensus3)
+	require.Equal(t, uint64(0), p1.EnvelopeVersion())
+	require.Error(t, p1.Send(consensus.TendermintEnvelopeMsg, envelope))
+
+	p1, p2 := newTestConsensusPeers(t, consensus4, consensus4)
+	require.Equal(t, uint64(consensusEnvelopeVersion), p1.EnvelopeVersion())
+	go func() {
+		require.NoError(t, p1.Send(consensus.TendermintEnvelopeMsg, envelope))
+	}()
+	require.NoError(t, p2p.ExpectMsg(p2.rw, ConsensusEnvelopeMsg, envelope))
+
+	// and vote batches from consensus/4 on
+	require.True(t, p1.VoteBatches())
+	p3, _ := newTestConsensusPeers(t, consensus3, consensus3)
+	require.False(t, p3.VoteBatches())
+
+	// and protobuf envelopes from consensus/5 on
+	require.False(t, p1.Protobuf())
+	require.Error(t, p1.Send(consensus.TendermintProtobufMsg, envelope))
+	p1, p2 = newTestConsensusPeers(t, consensus5, consensus5)
+	require.True(t, p1.Protobuf())
+	go func() {
+		require.NoError(t, p1.Send(consensus.TendermintProtobufMsg, envelope))
+	}()
+	require.NoError(t, p2p.ExpectMsg(p2.rw, ConsensusProtobufMsg, envelope))
+}
+
+func TestConsensusPeer_SendAck(t *testing.T) {
+	hash := common.HexToHash("0x01")
+
+	// consensus/1 peers do not acknowledge the messages
+	p1, _ := newTestConsensusPeers(t, consensus1, consensus1)
+	require.False(t, p1.Acks())
+	require.Error(t, p1.SendAck(hash))
+
+	p1, p2 := newTestConsensusPeers(t, consensus2, consensus2)
+	require.True(t, p1.Acks())
+	go func() {
+		require.NoError(t, p1.SendAck(hash))
+	}()
+	require.NoError(t, p2p.ExpectMsg(p2.rw, ConsensusAckMsg, hash))
+}
+
+func TestConsensusPeer_RequestCommit(t *testing.T) {
+	// the commits are requested from consensus/6 on
+	p1, _ := newTestConsensusPeers(t, consensus5, consensus5)
+	require.False(t, p1.Commits())
+	require.Error(t, p1.RequestCommit(1))
+
+	p1, p2 := newTestConsensusPeers(t, consensus6, consensus6)
+	require.True(t, p1.Commits())
+	go func() {
+		require.NoError(t, p1.RequestCommit(1))
+	}()
+	require.NoError(t, p2p.ExpectMsg(p2.rw, ConsensusGetCommitMsg, uint64(1)))
+}
+
+func TestConsensusPeerSet(t *testing.T) {
+	var (
+		ps     = newConsensusPeerSet()
+		p1, p2 = newTestConsensusPeers(t, consensus1, consensus1)
+		// a newer connection of node 1
+		p1Again = newConsensusPeer(consensus1, p1.Peer, p1.rw)
+	)
+	require.NoError(t, ps.Register(p1))
+	require.NoError(t, ps.Register(p2))
+	require.NoError(t, ps.Register(p1Again))
+	require.Equal(t, 2, ps.Len())
+	require.Equal(t, p1Again, ps.Peer(p1.Address()))
+
+	// the older connection does not unregister the newer one
+	ps.Unregister(p1)
+	require.Equal(t, p1Again, ps.Peer(p1.Address()))
+	ps.Unregister(p1Again)
+	require.Nil(t, ps.Peer(p1.Address()))
+
+	ps.Close()
+	require.Equal(t, errClosed, ps.Register(p1))
+}
+
+func TestProtocolManager_AuthorizeConsensusPeer(t *testing.T) {
+	var (
+		pm       = &ProtocolManager{}
+		sentry   = common.HexToAddress("0x01")
+		stranger = common.HexToAddress("0x02")
+	)
+	// the consensus protocol is open to all peers by default
+	require.NoError(t, pm.authorizeConsensusPeer([]common.Address{stranger}))
+
+	// the engine doesn't know the validators, only the whitelisted nodes are accepted
+	pm.RestrictConsensusProtocol([]common.Address{sentry})
+	require.NoError(t, pm.authorizeConsensusPeer([]common.Address{sentry}))
+	require.Equal(t, errUnauthorizedConsensusPeer, pm.authorizeConsensusPeer([]common.Address{stranger}))
+	// a peer is authorized if any of its addresses is
+	require.NoError(t, pm.authorizeConsensusPeer([]common.Address{stranger, sentry}))
+}
+
+// consensusHandlerEngine counts the consensus messages passed to the engine
+type consensusHandlerEngine struct {
+	consensus.Engine
+	handled int
+}
+
+func (e *consensusHandlerEngine) HandleNewChainHead(blockNumber *big.Int) error {
+	return nil
+}
+
+func (e *consensusHandlerEngine) HandleMsg(address common.Address, data p2p.Msg) (bool, error) {
+	e.handled++
+	return true, nil
+}
+
+func (e *consensusHandlerEngine) SetBroadcaster(consensus.Broadcaster) {}
+
+func TestProtocolManager_HandleConsensusMsgValidatorOnly(t *testing.T) {
+	var (
+		engine   = &consensusHandlerEngine{}
+		pm       = &ProtocolManager{engine: engine, consensusPeers: newConsensusPeerSet()}
+		pk       = mustGeneratePrivateKey(t)
+		n        = enode.MustParseV4("enode://" + hex.EncodeToString(crypto.FromECDSAPub(&pk.PublicKey)[1:]) + "@0.0.0.0:30303")
+		io1, io2 = p2p.MsgPipe()
+		p        = newPeer(eth64, p2p.NewPeerFromNode(n, "peer", nil), io1)
+	)
+	defer p.close()
+	send := func() error {
+		go func() {
+			_ = p2p.Send(io2, consensus.TendermintMsg, []byte("vote message"))
+		}()
+		return pm.HandleMsg(p)
+	}
+
+	// the consensus messages over evr/64 are passed to the engine by default
+	require.NoError(t, send())
+	require.Equal(t, 1, engine.handled)
+
+	// but dropped from the peers which are not authorized over evrc on a validator only network
+	pm.RestrictConsensusProtocol(nil)
+	require.NoError(t, send())
+	require.Equal(t, 1, engine.handled)
+	require.Empty(t, pm.FindPeers(map[common.Address]bool{crypto.PubkeyToAddress(pk.PublicKey): true}))
+}
+
+func TestProtocolManager_FindPeersNoConsensus(t *testing.T) {
+	var (
+		pm        = &ProtocolManager{peers: newPeerSet(), consensusPeers: newConsensusPeerSet()}
+		evrcKey   = mustGeneratePrivateKey(t)
+		optOutKey = mustGeneratePrivateKey(t)
+		evrc      = enode.MustParseV4("enode://" + hex.EncodeToString(crypto.FromECDSAPub(&evrcKey.PublicKey)[1:]) + "@0.0.0.0:30303")
+		optOut    = enode.MustParseV4("enode://" + hex.EncodeToString(crypto.FromECDSAPub(&optOutKey.PublicKey)[1:]) + "@0.0.0.0:30304")
+		evrCaps   = []p2p.Cap{{Name: ProtocolName, Version: eth64}}
+		bothCaps  = append(evrCaps, p2p.Cap{Name: ConsensusProtocolName, Version: consensus6})
+		targets   = map[common.Address]bool{
+			crypto.PubkeyToAddress(evrcKey.PublicKey):   true,
+			crypto.PubkeyToAddress(optOutKey.PublicKey): true,
+		}
+	)
+	newPeer := func(node *enode.Node, caps []p2p.Cap) *Peer {
+		io, _ := p2p.MsgPipe()
+		p := pm.NewPeer(eth64, p2p.NewPeerFromNode(node, "peer", caps), io)
+		require.NoError(t, pm.peers.Register(p))
+		return p
+	}
+
+	// a node not running the consensus protocol sends the consensus messages over evr/64 to every peer
+	p1 := newPeer(evrc, bothCaps)
+	p2 := newPeer(optOut, evrCaps)
+	require.Len(t, pm.FindPeers(targets), 2)
+	require.NoError(t, pm.peers.Unregister(p1.id))
+	require.NoError(t, pm.peers.Unregister(p2.id))
+
+	// a node running it skips the peers which do not run it
+	pm.consensusEnabled = true
+	newPeer(evrc, bothCaps)
+	newPeer(optOut, evrCaps)
+	peers := pm.FindPeers(targets)
+	require.Len(t, peers, 1)
+	require.Contains(t, peers, crypto.PubkeyToAddress(evrcKey.PublicKey))
+}
+
+func TestPeer_SendConsensusFirst(t *testing.T) {
+	var (
+		io1, io2 = p2p.MsgPipe()
+		p        = newPeer(eth64, p2p.NewPeer(enode.ID{1}, "peer", nil), io1)
+		txs      = []*types.Transaction{types.NewTransaction(0, common.Address{}, common.Big0, 0, common.Big0, nil)}
+		payload  = []byte("vote message")
+		errc     = make(chan error, 1)
+	)
+	defer p.close()
+	// queue transactions then a consensus message before the broadcast loop runs
+	p.AsyncSendTransactions(txs)
+	go func() { errc <- p.Send(consensus.TendermintMsg, payload) }()
+	for len(p.queuedConsensus) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go p.broadcast()
+
+	require.NoError(t, p2p.ExpectMsg(io2, consensus.TendermintMsg, payload))
+	require.NoError(t, <-errc)
+	require.NoError(t, p2p.ExpectMsg(io2, TxMsg, txs))
+}
@@ -114,6 +114,25 @@ func newFilter(backend Backend, addresses []common.Address, topics [][]common.Ha
 	}
 }
 
+// errNoFinalizedBlock is returned for a filter range bounded by the finalized or safe block before any block is final
+var errNoFinalizedBlock = errors.New("no finalized block")
+
+// resolveFinalized returns the number of the latest finalized block if number is the finalized or the safe block
+// tag, number otherwise. The other tags are left to the filters, which resolve them against the head.
+func resolveFinalized(ctx context.Context, backend Backend, number int64) (int64, error) {
+	if number != rpc.FinalizedBlockNumber.Int64() && number != rpc.SafeBlockNumber.Int64() {
+		return number, nil
+	}
+	header, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, errNoFinalizedBlock
+	}
+	return header.Number.Int64(), nil
+}
+
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
@@ -135,6 +154,13 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 	head := header.Number.Uint64()
 
+	var err error
+	if f.begin, err = resolveFinalized(ctx, f.backend, f.begin); err != nil {
+		return nil, err
+	}
+	if f.end, err = resolveFinalized(ctx, f.backend, f.end); err != nil {
+		return nil, err
+	}
 	if f.begin == -1 {
 		f.begin = int64(head)
 	}
@@ -143,10 +169,7 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		end = head
 	}
 	// Gather all indexed logs, and finish with non indexed ones
-	var (
-		logs []*types.Log
-		err  error
-	)
+	var logs []*types.Log
 	size, sections := f.backend.BloomStatus()
 	if indexed := sections * size; indexed > uint64(f.begin) {
 		if indexed > end {
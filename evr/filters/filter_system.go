@@ -22,6 +22,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -194,8 +195,19 @@ func (es *EventSystem) subscribe(sub *subscription) *Subscription {
 
 // SubscribeLogs creates a subscription that will write all logs matching the
 // given criteria to the given logs channel. Default value for the from and to
-// block is "latest". If the fromBlock > toBlock an error is returned.
+// block is "latest". The "finalized" and "safe" blocks are resolved to the latest finalized block
+// when the subscription is created. If the fromBlock > toBlock an error is returned.
 func (es *EventSystem) SubscribeLogs(crit ethereum.FilterQuery, logs chan []*types.Log) (*Subscription, error) {
+	for _, number := range []**big.Int{&crit.FromBlock, &crit.ToBlock} {
+		if *number == nil {
+			continue
+		}
+		resolved, err := resolveFinalized(context.Background(), es.backend, (*number).Int64())
+		if err != nil {
+			return nil, err
+		}
+		*number = big.NewInt(resolved)
+	}
 	var from, to rpc.BlockNumber
 	if crit.FromBlock == nil {
 		from = rpc.LatestBlockNumber
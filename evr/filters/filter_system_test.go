@@ -61,8 +61,12 @@ func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumbe
 		hash common.Hash
 		num  uint64
 	)
-	if blockNr == rpc.LatestBlockNumber {
-		hash = rawdb.ReadHeadBlockHash(b.db)
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.FinalizedBlockNumber || blockNr == rpc.SafeBlockNumber {
+		if blockNr == rpc.LatestBlockNumber {
+			hash = rawdb.ReadHeadBlockHash(b.db)
+		} else {
+			hash = rawdb.ReadHeadFinalizedBlockHash(b.db)
+		}
 		number := rawdb.ReadHeaderNumber(b.db, hash)
 		if number == nil {
 			return nil, nil
@@ -31,6 +31,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
 )
 
 func makeReceipt(addr common.Address) *types.Receipt {
@@ -234,3 +235,72 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+func TestFinalizedLogFilter(t *testing.T) {
+	var (
+		db         = rawdb.NewMemoryDatabase()
+		mux        = new(event.TypeMux)
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api        = NewPublicFilterAPI(backend, false)
+		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr       = crypto.PubkeyToAddress(key1.PublicKey)
+		finalized  = big.NewInt(rpc.FinalizedBlockNumber.Int64())
+		safe       = big.NewInt(rpc.SafeBlockNumber.Int64())
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		// blocks 2 and 8 have a log
+		if i == 1 || i == 7 {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr}}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+
+	// no block is finalized yet, the tags can't be resolved
+	for _, crit := range []FilterCriteria{{FromBlock: finalized}, {FromBlock: big.NewInt(0), ToBlock: safe}} {
+		if _, err := api.GetLogs(context.Background(), crit); err != errNoFinalizedBlock {
+			t.Errorf("GetLogs %v: have error %v, want %v", crit, err, errNoFinalizedBlock)
+		}
+		if _, err := api.NewFilter(crit); err != errNoFinalizedBlock {
+			t.Errorf("NewFilter %v: have error %v, want %v", crit, err, errNoFinalizedBlock)
+		}
+	}
+
+	// both tags resolve to the finalized block 5
+	rawdb.WriteHeadFinalizedBlockHash(db, chain[4].Hash())
+	for _, test := range []struct {
+		crit   FilterCriteria
+		number uint64
+	}{
+		{crit: FilterCriteria{FromBlock: finalized}, number: 8},
+		{crit: FilterCriteria{FromBlock: safe}, number: 8},
+		{crit: FilterCriteria{FromBlock: big.NewInt(0), ToBlock: finalized}, number: 2},
+		{crit: FilterCriteria{FromBlock: big.NewInt(0), ToBlock: safe}, number: 2},
+	} {
+		logs, err := api.GetLogs(context.Background(), test.crit)
+		if err != nil {
+			t.Fatalf("GetLogs %v: %v", test.crit, err)
+		}
+		if len(logs) != 1 || logs[0].BlockNumber != test.number {
+			t.Errorf("GetLogs %v: have %v, want the log of block %d", test.crit, logs, test.number)
+		}
+	}
+	// a subscription from the finalized block streams the new logs
+	id, err := api.NewFilter(FilterCriteria{FromBlock: finalized})
+	if err != nil {
+		t.Fatalf("NewFilter: %v", err)
+	}
+	api.UninstallFilter(id)
+}
@@ -86,6 +86,15 @@ type ProtocolManager struct {
 	fetcher    *fetcher.Fetcher
 	peers      *peerSet
 
+	// consensusPeers are the peers connected over the consensus protocol, see EnableConsensusProtocol
+	consensusPeers   *consensusPeerSet
+	consensusEnabled bool // whether the node runs the consensus protocol
+	// consensusWhitelist are the non-validator nodes allowed on a validator only consensus protocol,
+	// nil if the protocol is open to all peers, see RestrictConsensusProtocol
+	consensusWhitelist map[common.Address]bool
+	// consensusIdentity are the signatures proving the validator addresses of the node, see SignConsensusIdentity
+	consensusIdentity atomic.Value // [][]byte
+
 	SubProtocols []p2p.Protocol
 
 	eventMux      *event.TypeMux
@@ -112,18 +121,19 @@ type ProtocolManager struct {
 func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, networkID uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb evrdb.Database, cacheLimit int, whitelist map[uint64]common.Hash) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
-		networkID:   networkID,
-		eventMux:    mux,
-		txpool:      txpool,
-		blockchain:  blockchain,
-		chainconfig: config,
-		peers:       newPeerSet(),
-		whitelist:   whitelist,
-		newPeerCh:   make(chan *Peer),
-		noMorePeers: make(chan struct{}),
-		txsyncCh:    make(chan *txsync),
-		quitSync:    make(chan struct{}),
-		engine:      engine,
+		networkID:      networkID,
+		eventMux:       mux,
+		txpool:         txpool,
+		blockchain:     blockchain,
+		chainconfig:    config,
+		peers:          newPeerSet(),
+		consensusPeers: newConsensusPeerSet(),
+		whitelist:      whitelist,
+		newPeerCh:      make(chan *Peer),
+		noMorePeers:    make(chan struct{}),
+		txsyncCh:       make(chan *txsync),
+		quitSync:       make(chan struct{}),
+		engine:         engine,
 	}
 
 	if handler, ok := engine.(consensus.Handler); ok {
@@ -278,6 +288,7 @@ func (pm *ProtocolManager) Stop() {
 	// sessions which are already established but not added to pm.peers yet
 	// will exit when they try to register.
 	pm.peers.Close()
+	pm.consensusPeers.Close()
 
 	// Wait for all Peer handler goroutines and the loops to come down.
 	pm.wg.Wait()
@@ -288,7 +299,11 @@ func (pm *ProtocolManager) Stop() {
 //NewPeer reutrn a pper to abstract the connection to another node
 //It is exported for testing purposes
 func (pm *ProtocolManager) NewPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
-	return newPeer(pv, p, newMeteredMsgWriter(rw))
+	peer := newPeer(pv, p, newMeteredMsgWriter(rw))
+	// a node running the consensus protocol sends no consensus message over evr/64 to the peers not running it,
+	// which opted out of the consensus traffic with NoConsensusProtocol
+	peer.noConsensus = pm.consensusEnabled && !runsConsensusProtocol(p)
+	return peer
 }
 
 // handle is the callback invoked to manage the life cycle of an evr Peer. When
@@ -377,6 +392,11 @@ func (pm *ProtocolManager) HandleMsg(p *Peer) error {
 	}
 	defer msg.Discard()
 
+	// on a validator only network the consensus messages are only accepted over evrc, where the peer is authorized
+	if pm.consensusWhitelist != nil && isConsensusMsg(msg.Code) {
+		p.Log().Trace("Dropped consensus message received over evr", "code", msg.Code)
+		return nil
+	}
 	// if the handler and engine is implemented in consensus
 	if handler, ok := pm.engine.(consensus.Handler); ok {
 		pubKey := p.Node().Pubkey()
@@ -797,13 +817,33 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	var txset = make(map[*Peer]types.Transactions)
 
+	// The validators get the transactions of the pending proposal with its block
+	var (
+		proposed    map[common.Hash]struct{}
+		validators  map[common.Address]bool
+		isValidator = make(map[*Peer]bool)
+	)
+	if gossiper, ok := pm.engine.(consensus.ProposalGossiper); ok {
+		proposed, validators = gossiper.ProposedTxs()
+	}
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
 		peers := pm.peers.PeersWithoutTx(tx.Hash())
+		_, withheld := proposed[tx.Hash()]
 		for _, peer := range peers {
+			if withheld {
+				validator, ok := isValidator[peer]
+				if !ok {
+					validator = validators[peer.Address()]
+					isValidator[peer] = validator
+				}
+				if validator {
+					continue
+				}
+			}
 			txset[peer] = append(txset[peer], tx)
 		}
-		log.Trace("Broadcast transaction", "hash", tx.Hash(), "recipients", len(peers))
+		log.Trace("Broadcast transaction", "hash", tx.Hash(), "recipients", len(peers), "proposed", withheld)
 	}
 	// FIXME include this again: peers = peers[:int(math.Sqrt(float64(len(peers))))]
 	for peer, txs := range txset {
@@ -871,11 +911,22 @@ func (pm *ProtocolManager) NodeInfo() *NodeInfo {
 // FindPeers retrives peers by addresses
 func (pm *ProtocolManager) FindPeers(targets map[common.Address]bool) map[common.Address]consensus.Peer {
 	m := make(map[common.Address]consensus.Peer)
+	// prefer the peers connected over the consensus protocol
+	for addr := range targets {
+		if p := pm.consensusPeers.Peer(addr); p != nil {
+			m[addr] = p
+		}
+	}
+	// the others still accept consensus messages over evr/64, unless the network is validator only
+	// or they opted out of the consensus traffic
+	if pm.consensusWhitelist != nil {
+		return m
+	}
 	for _, p := range pm.peers.Peers() {
 		pubKey := p.Node().Pubkey()
 		if pubKey != nil {
 			addr := crypto.PubkeyToAddress(*pubKey)
-			if targets[addr] {
+			if _, ok := m[addr]; !ok && targets[addr] && !p.noConsensus {
 				m[addr] = p
 			}
 		}
@@ -26,6 +26,7 @@ import (
 	mapset "github.com/deckarep/golang-set"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/p2p"
@@ -36,6 +37,7 @@ var (
 	errClosed            = errors.New("Peer set is closed")
 	errAlreadyRegistered = errors.New("Peer is already registered")
 	errNotRegistered     = errors.New("Peer is not registered")
+	errPeerClosed        = errors.New("Peer is closed")
 )
 
 const (
@@ -57,6 +59,10 @@ const (
 	// above some healthy uncle limit, so use that.
 	maxQueuedAnns = 4
 
+	// maxQueuedConsensus is the maximum number of consensus messages waiting to be
+	// sent. Consensus messages are never dropped, the sender waits for a slot.
+	maxQueuedConsensus = 256
+
 	handshakeTimeout = 5 * time.Second
 )
 
@@ -74,6 +80,12 @@ type propEvent struct {
 	td    *big.Int
 }
 
+// consensusSend is a consensus message write, waiting for its turn in the broadcast queue.
+type consensusSend struct {
+	send func() error
+	errc chan error // receives the result of send
+}
+
 type Peer struct {
 	id string
 
@@ -93,6 +105,9 @@ type Peer struct {
 	queuedProps chan *propEvent           // Queue of blocks to broadcast to the Peer
 	queuedAnns  chan *types.Block         // Queue of blocks to announce to the Peer
 	term        chan struct{}             // Termination channel to stop the broadcaster
+
+	queuedConsensus chan *consensusSend // Queue of consensus messages to send to the Peer, ahead of the others
+	noConsensus     bool                // Whether the Peer opted out of the consensus messages over evr/64
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
@@ -107,32 +122,62 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
 		queuedProps: make(chan *propEvent, maxQueuedProps),
 		queuedAnns:  make(chan *types.Block, maxQueuedAnns),
 		term:        make(chan struct{}),
+
+		queuedConsensus: make(chan *consensusSend, maxQueuedConsensus),
 	}
 }
 
-// broadcast is a write loop that multiplexes block propagations, announcements
-// and transaction broadcasts into the remote Peer. The goal is to have an async
-// writer that does not lock up node internals.
+// broadcast is a write loop that multiplexes consensus messages, block propagations,
+// announcements and transaction broadcasts into the remote Peer. The goal is to have
+// an async writer that does not lock up node internals.
+// The queues are prioritized: consensus messages first, then blocks, then transactions.
 func (p *Peer) broadcast() {
 	for {
 		select {
-		case txs := <-p.queuedTxs:
-			if err := p.SendTransactions(txs); err != nil {
+		case msg := <-p.queuedConsensus:
+			if err := p.sendConsensus(msg); err != nil {
 				return
 			}
-			p.Log().Trace("Broadcast transactions", "count", len(txs))
+			continue
+		default:
+		}
 
+		select {
 		case prop := <-p.queuedProps:
-			if err := p.SendNewBlock(prop.block, prop.td); err != nil {
+			if err := p.propagateBlock(prop); err != nil {
+				return
+			}
+			continue
+		case block := <-p.queuedAnns:
+			if err := p.announceBlock(block); err != nil {
+				return
+			}
+			continue
+		default:
+		}
+
+		// Nothing has priority, wait for the first message of any queue
+		select {
+		case msg := <-p.queuedConsensus:
+			if err := p.sendConsensus(msg); err != nil {
+				return
+			}
+
+		case prop := <-p.queuedProps:
+			if err := p.propagateBlock(prop); err != nil {
 				return
 			}
-			p.Log().Trace("Propagated block", "number", prop.block.Number(), "hash", prop.block.Hash(), "td", prop.td)
 
 		case block := <-p.queuedAnns:
-			if err := p.SendNewBlockHashes([]common.Hash{block.Hash()}, []uint64{block.NumberU64()}); err != nil {
+			if err := p.announceBlock(block); err != nil {
 				return
 			}
-			p.Log().Trace("Announced block", "number", block.Number(), "hash", block.Hash())
+
+		case txs := <-p.queuedTxs:
+			if err := p.SendTransactions(txs); err != nil {
+				return
+			}
+			p.Log().Trace("Broadcast transactions", "count", len(txs))
 
 		case <-p.term:
 			return
@@ -140,6 +185,28 @@ func (p *Peer) broadcast() {
 	}
 }
 
+func (p *Peer) sendConsensus(msg *consensusSend) error {
+	err := msg.send()
+	msg.errc <- err
+	return err
+}
+
+func (p *Peer) propagateBlock(prop *propEvent) error {
+	if err := p.SendNewBlock(prop.block, prop.td); err != nil {
+		return err
+	}
+	p.Log().Trace("Propagated block", "number", prop.block.Number(), "hash", prop.block.Hash(), "td", prop.td)
+	return nil
+}
+
+func (p *Peer) announceBlock(block *types.Block) error {
+	if err := p.SendNewBlockHashes([]common.Hash{block.Hash()}, []uint64{block.NumberU64()}); err != nil {
+		return err
+	}
+	p.Log().Trace("Announced block", "number", block.Number(), "hash", block.Hash())
+	return nil
+}
+
 // close signals the broadcast goroutine to terminate.
 func (p *Peer) close() {
 	close(p.term)
@@ -197,10 +264,33 @@ func (p *Peer) MarkTransaction(hash common.Hash) {
 
 // Send writes an RLP-encoded message with the given code.
 // data should encode as an RLP list.
+// Consensus messages go through the broadcast queue, ahead of the queued blocks and transactions.
 func (p *Peer) Send(msgcode uint64, data interface{}) error {
+	if msgcode == consensus.TendermintMsg {
+		return p.sendPrioritized(func() error {
+			return p2p.Send(p.rw, msgcode, data)
+		})
+	}
 	return p2p.Send(p.rw, msgcode, data)
 }
 
+// sendPrioritized runs send in the broadcast loop of the Peer before any queued block or
+// transaction broadcast, and returns its result. It waits for a slot if the queue is full.
+func (p *Peer) sendPrioritized(send func() error) error {
+	msg := &consensusSend{send: send, errc: make(chan error, 1)}
+	select {
+	case p.queuedConsensus <- msg:
+	case <-p.term:
+		return errPeerClosed
+	}
+	select {
+	case err := <-msg.errc:
+		return err
+	case <-p.term:
+		return errPeerClosed
+	}
+}
+
 // SendTransactions sends transactions to the Peer and includes the hashes
 // in its transaction hash set for future reference.
 func (p *Peer) SendTransactions(txs types.Transactions) error {
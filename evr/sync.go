@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/evr/downloader"
 	"github.com/Evrynetlabs/evrynet-node/log"
@@ -35,6 +36,8 @@ const (
 	// This is the target size for the packs of transactions sent by txsyncLoop.
 	// A pack can get larger than this if a single transactions exceeds this size.
 	txsyncPackSize = 100 * 1024
+
+	commitFetchTimeout = 5 * time.Second // Time allowance for the sync peer to answer the request of the commit of the head
 )
 
 type txsync struct {
@@ -205,6 +208,7 @@ func (pm *ProtocolManager) synchronise(peer *Peer) {
 	// If we've successfully finished a sync cycle and passed any required checkpoint,
 	// enable accepting transactions from the network.
 	head := pm.blockchain.CurrentBlock()
+	pm.finalizeHead(peer, head)
 	if head.NumberU64() >= pm.checkpointNumber {
 		// Checkpoint passed, sanity check the timestamp to have a fallback mechanism
 		// for non-checkpointed (number = 0) private networks.
@@ -222,3 +226,25 @@ func (pm *ProtocolManager) synchronise(peer *Peer) {
 		go pm.BroadcastBlock(head, false)
 	}
 }
+
+// finalizeHead marks the head block finalized with the commit of the sync peer if it was synced without checking
+// its commit, e.g. the pivot block of a fast sync. The engine verifies the commit against the validator set of the
+// block, which the node knows from the synced state, sparing the node the headers in between.
+func (pm *ProtocolManager) finalizeHead(peer *Peer, head *types.Block) {
+	commitHandler, ok := pm.engine.(consensus.CommitHandler)
+	if !ok || head.NumberU64() == 0 || pm.blockchain.IsFinalized(head.Hash()) {
+		return
+	}
+	header, err := commitHandler.FetchCommit(peer.Address(), head.NumberU64(), commitFetchTimeout)
+	if err != nil {
+		peer.Log().Debug("Failed to fetch the commit of the head block", "number", head.Number(), "err", err)
+		return
+	}
+	if header.Hash() != head.Hash() {
+		peer.Log().Warn("Sync peer committed another head block", "number", head.Number(), "head", head.Hash(), "committed", header.Hash())
+		return
+	}
+	if err := pm.blockchain.MarkFinalized(head.Hash()); err != nil {
+		log.Warn("Failed to mark the head block finalized", "number", head.Number(), "hash", head.Hash(), "err", err)
+	}
+}
@@ -17,13 +17,27 @@
 package evr
 
 import (
+	"encoding/hex"
+	"errors"
+	"math/big"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/evr/downloader"
 	"github.com/Evrynetlabs/evrynet-node/p2p"
 	"github.com/Evrynetlabs/evrynet-node/p2p/enode"
+	"github.com/Evrynetlabs/evrynet-node/params"
 )
 
 // Tests that fast sync gets disabled as soon as a real block is successfully
@@ -53,3 +67,128 @@ func TestFastSyncDisabling(t *testing.T) {
 		t.Fatalf("fast sync not disabled after successful synchronisation")
 	}
 }
+
+// commitEngine serves the commits of its headers to the consensus peers and fetches theirs
+type commitEngine struct {
+	consensus.Engine
+	peers   consensus.Broadcaster
+	headers map[uint64]*types.Header
+	commits chan *types.Header
+}
+
+func (e *commitEngine) HandleNewChainHead(blockNumber *big.Int) error {
+	return nil
+}
+
+func (e *commitEngine) HandleMsg(address common.Address, data p2p.Msg) (bool, error) {
+	return true, nil
+}
+
+func (e *commitEngine) SetBroadcaster(broadcaster consensus.Broadcaster) {
+	e.peers = broadcaster
+}
+
+func (e *commitEngine) CommitAt(number uint64) *types.Header {
+	return e.headers[number]
+}
+
+func (e *commitEngine) HandleCommit(address common.Address, number uint64, header *types.Header) {
+	e.commits <- header
+}
+
+func (e *commitEngine) FetchCommit(address common.Address, number uint64, timeout time.Duration) (*types.Header, error) {
+	p, ok := e.peers.FindPeers(map[common.Address]bool{address: true})[address].(consensus.CommitPeer)
+	if !ok || !p.Commits() {
+		return nil, errors.New("commits not served")
+	}
+	if err := p.RequestCommit(number); err != nil {
+		return nil, err
+	}
+	select {
+	case header := <-e.commits:
+		if header == nil {
+			return nil, errors.New("commit not kept")
+		}
+		return header, nil
+	case <-time.After(timeout):
+		return nil, errors.New("commit request timed out")
+	}
+}
+
+// Tests that the head of a fast synced tendermint chain is marked finalized once the sync peer serves its commit
+// over the consensus protocol.
+func TestFinalizeSyncedHead(t *testing.T) {
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		gspec        = &core.Genesis{Config: params.TendermintTestChainConfig}
+		genesis      = gspec.MustCommit(db)
+		generated, _ = core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 5, nil)
+	)
+	// the generated states are kept in db, the headers are rebuilt as tendermint ones
+	var (
+		blocks   = make([]*types.Block, len(generated))
+		headers  = make([]*types.Header, len(generated))
+		receipts = make([]types.Receipts, len(generated))
+		parent   = genesis.Header()
+	)
+	for i, block := range generated {
+		header := block.Header()
+		header.MixDigest = types.TendermintDigest
+		header.ParentHash = parent.Hash()
+		blocks[i], headers[i], parent = types.NewBlockWithHeader(header), header, header
+	}
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	require.NoError(t, err)
+	defer chain.Stop()
+	_, err = chain.InsertHeaderChain(headers, 1)
+	require.NoError(t, err)
+	_, err = chain.InsertReceiptChain(blocks, receipts, 0)
+	require.NoError(t, err)
+	require.NoError(t, chain.FastSyncCommitHead(blocks[4].Hash()))
+	head := chain.CurrentBlock()
+	require.Equal(t, blocks[4].Hash(), head.Hash())
+	require.False(t, chain.IsFinalized(head.Hash()))
+
+	// the local node is connected to the sync peer over the consensus protocol
+	var (
+		engine    = &commitEngine{commits: make(chan *types.Header, 1)}
+		pm        = &ProtocolManager{engine: engine, blockchain: chain, peers: newPeerSet(), consensusPeers: newConsensusPeerSet()}
+		remote    = &commitEngine{headers: make(map[uint64]*types.Header)}
+		remotePm  = &ProtocolManager{engine: remote, consensusPeers: newConsensusPeerSet()}
+		localKey  = mustGeneratePrivateKey(t)
+		peerKey   = mustGeneratePrivateKey(t)
+		local     = enode.MustParseV4("enode://" + hex.EncodeToString(crypto.FromECDSAPub(&localKey.PublicKey)[1:]) + "@0.0.0.0:30303")
+		node      = enode.MustParseV4("enode://" + hex.EncodeToString(crypto.FromECDSAPub(&peerKey.PublicKey)[1:]) + "@0.0.0.0:30304")
+		io1, io2  = p2p.MsgPipe()
+		syncIO, _ = p2p.MsgPipe()
+		syncPeer  = newPeer(eth64, p2p.NewPeerFromNode(node, "peer", nil), syncIO)
+		cp        = newConsensusPeer(consensus6, p2p.NewPeerFromNode(node, "peer", nil), io1)
+		remoteCp  = newConsensusPeer(consensus6, p2p.NewPeerFromNode(local, "local", nil), io2)
+	)
+	defer io1.Close()
+	defer syncPeer.close()
+	engine.SetBroadcaster(pm)
+	require.NoError(t, pm.consensusPeers.Register(cp))
+	go func() {
+		for pm.handleConsensusMsg(engine, cp) == nil {
+		}
+	}()
+	go func() {
+		for remotePm.handleConsensusMsg(remote, remoteCp) == nil {
+		}
+	}()
+
+	// the head is not finalized while the peer does not keep its commit, nor with the commit of another block
+	pm.finalizeHead(syncPeer, head)
+	require.False(t, chain.IsFinalized(head.Hash()))
+	fork := types.CopyHeader(headers[4])
+	fork.Coinbase = common.Address{1}
+	remote.headers[5] = fork
+	pm.finalizeHead(syncPeer, head)
+	require.False(t, chain.IsFinalized(head.Hash()))
+
+	remote.headers[5] = headers[4]
+	pm.finalizeHead(syncPeer, head)
+	require.True(t, chain.IsFinalized(head.Hash()))
+	require.Equal(t, head.Hash(), chain.LatestFinalized().Hash())
+}
@@ -207,6 +207,7 @@ type ExtraDataDetails struct {
 	RawData       hexutil.Bytes     `json:"rawData"`
 	BlockProposer *common.Address   `json:"blockProposer"`
 	CommitSigners []*common.Address `json:"commitSigners"`
+	Round         hexutil.Uint64    `json:"round"` // the round the block was committed in, 0 for blocks committed before it was recorded
 }
 
 // UnmarshalJSON unmarshals from JSON.
@@ -219,6 +220,7 @@ func (h *ExtraDataDetails) UnmarshalJSON(input []byte) error {
 	h.RawData = dec.RawData
 	h.BlockProposer = dec.BlockProposer
 	h.CommitSigners = dec.CommitSigners
+	h.Round = dec.Round
 	return nil
 }
 
@@ -28,6 +28,7 @@ import (
 	ethereum "github.com/Evrynetlabs/evrynet-node"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
@@ -653,6 +654,60 @@ func (b *Block) Miner(ctx context.Context, args BlockNumberArgs) (*Account, erro
 	}, nil
 }
 
+// validatorInfo returns the validator info of the consensus engine, nil if the chain is not run by validators
+func validatorInfo(backend *evr.EvrAPIBackend) consensus.ValidatorInfo {
+	info, _ := backend.Engine().(consensus.ValidatorInfo)
+	return info
+}
+
+func (b *Block) Proposer(ctx context.Context) (*common.Address, error) {
+	if validatorInfo(b.backend) == nil {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	proposer, err := b.backend.Engine().Author(header)
+	if err != nil {
+		return nil, err
+	}
+	return &proposer, nil
+}
+
+func (b *Block) Round(ctx context.Context) (*hexutil.Uint64, error) {
+	info := validatorInfo(b.backend)
+	if info == nil {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	round, err := info.BlockRound(b.backend.ChainReader(), header)
+	if err != nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(round)
+	return &ret, nil
+}
+
+func (b *Block) Signers(ctx context.Context) (*[]common.Address, error) {
+	info := validatorInfo(b.backend)
+	if info == nil {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	signers, err := info.BlockSigners(header)
+	if err != nil {
+		return nil, err
+	}
+	return &signers, nil
+}
+
 func (b *Block) TransactionCount(ctx context.Context) (*int32, error) {
 	block, err := b.resolve(ctx)
 	if err != nil || block == nil {
@@ -1101,6 +1156,20 @@ func (r *Resolver) ProtocolVersion(ctx context.Context) (int32, error) {
 	return int32(r.backend.ProtocolVersion()), nil
 }
 
+// Validators returns the validators allowed to commit the block at the given height, the next block by default
+func (r *Resolver) Validators(ctx context.Context, args struct{ Height *hexutil.Uint64 }) (*[]common.Address, error) {
+	info := validatorInfo(r.backend)
+	if info == nil {
+		return nil, nil
+	}
+	height := r.backend.CurrentBlock().NumberU64() + 1
+	if args.Height != nil {
+		height = uint64(*args.Height)
+	}
+	validators := info.BlockValidators(r.backend.ChainReader(), height)
+	return &validators, nil
+}
+
 // SyncState represents the synchronisation status returned from the `syncing` accessor.
 type SyncState struct {
 	progress ethereum.SyncProgress
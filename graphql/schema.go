@@ -158,6 +158,16 @@ const schema string = `
         receiptsRoot: Bytes32!
         # Miner is the account that mined this block.
         miner(block: Long): Account!
+        # Proposer is the validator which proposed this block. If the chain is
+        # not run by validators, this field will be null.
+        proposer: Address
+        # Round is the consensus round in which this block was proposed. It is
+        # derived from the proposer so it is only known modulo the number of
+        # validators. If the chain is not run by validators, this field will be null.
+        round: Long
+        # Signers are the validators whose committed seals are in this block. If
+        # the chain is not run by validators, this field will be null.
+        signers: [Address!]
         # ExtraData is an arbitrary data field supplied by the miner.
         extraData: Bytes!
         # GasLimit is the maximum amount of gas that was available to transactions in this block.
@@ -311,6 +321,10 @@ const schema string = `
         protocolVersion: Int!
         # Syncing returns information on the current synchronisation state.
         syncing: SyncState
+        # Validators returns the validators allowed to commit the block at
+        # height. If height is not supplied, it defaults to the next block. If
+        # the chain is not run by validators, this field will be null.
+        validators(height: Long): [Address!]
     }
 
     type Mutation {
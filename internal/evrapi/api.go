@@ -36,6 +36,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/common/math"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/clique"
 	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
@@ -671,10 +672,14 @@ func (s *PublicBlockChainAPI) GetBlockSignerByNumber(ctx context.Context, blockN
 
 // GetBlockByNumber returns the requested block. When blockNr is -1 the chain head is returned. When fullTx is true all
 // transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
-func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+// When withConsensus is true the decoded extra-data is returned in the consensus field.
+func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, fullTx bool, withConsensus *bool) (map[string]interface{}, error) {
 	block, err := s.b.BlockByNumber(ctx, blockNr)
 	if block != nil {
 		response, err := s.rpcOutputBlock(block, true, fullTx)
+		if err == nil && withConsensus != nil && *withConsensus {
+			response["consensus"] = s.rpcOutputConsensus(block)
+		}
 		if err == nil && blockNr == rpc.PendingBlockNumber {
 			// Pending blocks need to nil out a few fields
 			for _, field := range []string{"hash", "nonce", "miner"} {
@@ -688,10 +693,15 @@ func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.
 
 // GetBlockByHash returns the requested block. When fullTx is true all transactions in the block are returned in full
 // detail, otherwise only the transaction hash is returned.
-func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash common.Hash, fullTx bool) (map[string]interface{}, error) {
+// When withConsensus is true the decoded extra-data is returned in the consensus field.
+func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash common.Hash, fullTx bool, withConsensus *bool) (map[string]interface{}, error) {
 	block, err := s.b.GetBlock(ctx, blockHash)
 	if block != nil {
-		return s.rpcOutputBlock(block, true, fullTx)
+		response, err := s.rpcOutputBlock(block, true, fullTx)
+		if err == nil && withConsensus != nil && *withConsensus {
+			response["consensus"] = s.rpcOutputConsensus(block)
+		}
+		return response, err
 	}
 	return nil, err
 }
@@ -1038,7 +1048,7 @@ func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]inter
 }
 
 // RPCMarshalExtraData converts the given extra data with (extraData, blockProposer, commitSigners to the RPC output
-func RPCMarshalExtraData(b *types.Block) (map[string]interface{}, error) {
+func RPCMarshalExtraData(config *params.ChainConfig, b *types.Block) (map[string]interface{}, error) {
 	blockHeader := b.Header() // copies the header once
 	fields := map[string]interface{}{
 		"rawData": hexutil.Bytes(blockHeader.Extra),
@@ -1059,7 +1069,7 @@ func RPCMarshalExtraData(b *types.Block) (map[string]interface{}, error) {
 
 	// get all committed's signer
 	var commitSigner []common.Address
-	proposalSeal := utils.PrepareCommittedSeal(blockHeader.Hash())
+	proposalSeal := utils.PrepareCommittedSealAt(utils.SealConfig(config), blockHeader.Number, blockHeader.Hash())
 	log.Info("RPCMarshalExtraData", "committedSeal", extra.CommittedSeal)
 	for _, seal := range extra.CommittedSeal {
 		// Get the original address by seal and parent block hash
@@ -1071,6 +1081,7 @@ func RPCMarshalExtraData(b *types.Block) (map[string]interface{}, error) {
 	}
 
 	fields["commitSigners"] = commitSigner
+	fields["round"] = hexutil.Uint64(extra.Round)
 
 	return fields, nil
 }
@@ -1098,13 +1109,42 @@ func getSignatureAddress(data []byte, sig []byte) (common.Address, error) {
 }
 
 func (s *PublicBlockChainAPI) rpcOutputExtraData(b *types.Block) (map[string]interface{}, error) {
-	fields, err := RPCMarshalExtraData(b)
+	fields, err := RPCMarshalExtraData(s.b.ChainConfig(), b)
 	if err != nil {
 		return nil, err
 	}
 	return fields, err
 }
 
+// consensusBackend is implemented by the backends which expose the consensus engine of their chain
+type consensusBackend interface {
+	Engine() consensus.Engine
+	ChainReader() consensus.ChainReader
+}
+
+// rpcOutputConsensus returns the decoded extra-data of the block: the proposer, the commit signers and the commit round.
+// The blocks which don't record their commit round, committed before it was written or in round 0, get the round the
+// engine derives from the proposer if it knows it. It returns nil if the block has no seals, e.g. the genesis or a pending block.
+func (s *PublicBlockChainAPI) rpcOutputConsensus(b *types.Block) map[string]interface{} {
+	fields, err := RPCMarshalExtraData(s.b.ChainConfig(), b)
+	if err != nil {
+		log.Debug("failed to decode the consensus extra-data", "number", b.Number(), "err", err)
+		return nil
+	}
+	delete(fields, "rawData") // already returned as extraData
+	if round, _ := fields["round"].(hexutil.Uint64); round != 0 {
+		return fields
+	}
+	if cb, ok := s.b.(consensusBackend); ok {
+		if info, ok := cb.Engine().(consensus.ValidatorInfo); ok {
+			if round, err := info.BlockRound(cb.ChainReader(), b.Header()); err == nil {
+				fields["round"] = hexutil.Uint64(round)
+			}
+		}
+	}
+	return fields
+}
+
 // rpcOutputBlock uses the generalized output filler, then adds the total difficulty field, which requires
 // a `PublicBlockchainAPI`.
 func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
@@ -1842,9 +1882,14 @@ func (api *PrivateDebugAPI) ChaindbCompact() error {
 	return nil
 }
 
-// SetHead rewinds the head of the blockchain to a previous block.
-func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64) {
-	api.b.SetHead(uint64(number))
+// SetHead rewinds the head of the blockchain to a previous block, not below the latest finalized block.
+func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64) error {
+	return api.b.SetHead(uint64(number), false)
+}
+
+// ForceSetHead rewinds the head of the blockchain to a previous block, even below the latest finalized block.
+func (api *PrivateDebugAPI) ForceSetHead(number hexutil.Uint64) error {
+	return api.b.SetHead(uint64(number), true)
 }
 
 // PublicNetAPI offers network related RPC methods
@@ -0,0 +1,143 @@
+package evrapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// blockBackend serves a single block
+type blockBackend struct {
+	Backend
+	block *types.Block
+}
+
+func (b *blockBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	return b.block, nil
+}
+
+func (b *blockBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.block, nil
+}
+
+func (b *blockBackend) GetTd(hash common.Hash) *big.Int {
+	return big.NewInt(1)
+}
+
+func (b *blockBackend) ChainConfig() *params.ChainConfig {
+	return &params.ChainConfig{ChainID: big.NewInt(1), Tendermint: &params.TendermintConfig{Epoch: 10}}
+}
+
+// engineBackend is a blockBackend which exposes its consensus engine
+type engineBackend struct {
+	*blockBackend
+	engine consensus.Engine
+}
+
+func (b *engineBackend) Engine() consensus.Engine {
+	return b.engine
+}
+
+func (b *engineBackend) ChainReader() consensus.ChainReader {
+	return nil
+}
+
+// roundEngine is a consensus engine which knows the round of every block
+type roundEngine struct {
+	consensus.Engine
+	round int64
+}
+
+func (e *roundEngine) BlockValidators(chain consensus.ChainReader, number uint64) []common.Address {
+	return nil
+}
+
+func (e *roundEngine) BlockSigners(header *types.Header) ([]common.Address, error) {
+	return nil, nil
+}
+
+func (e *roundEngine) BlockRound(chain consensus.ChainReader, header *types.Header) (int64, error) {
+	return e.round, nil
+}
+
+func TestPublicBlockChainAPI_GetBlockConsensus(t *testing.T) {
+	var (
+		proposer   = tests_utils.MakeNodeKey()
+		committers = []*ecdsa.PrivateKey{tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey()}
+		validators = []common.Address{crypto.PubkeyToAddress(proposer.PublicKey)}
+		header     = tests_utils.MakeBlockWithoutSeal(tests_utils.MakeGenesisHeader(validators)).Header()
+		yes, no    = true, false
+	)
+	require.NoError(t, utils.WriteCommitRound(header, 5))
+	tests_utils.AppendSealByPkKey(header, proposer)
+	tests_utils.AppendCommitedSealByPkKeys(header, committers)
+	block := types.NewBlockWithHeader(header)
+
+	getBlocks := func(api *PublicBlockChainAPI, withConsensus *bool) []map[string]interface{} {
+		byNumber, err := api.GetBlockByNumber(context.Background(), rpc.BlockNumber(1), false, withConsensus)
+		require.NoError(t, err)
+		byHash, err := api.GetBlockByHash(context.Background(), block.Hash(), false, withConsensus)
+		require.NoError(t, err)
+		return []map[string]interface{}{byNumber, byHash}
+	}
+
+	// the consensus field is only returned on request
+	api := NewPublicBlockChainAPI(&engineBackend{blockBackend: &blockBackend{block: block}, engine: &roundEngine{round: 3}})
+	for _, withConsensus := range []*bool{nil, &no} {
+		for _, response := range getBlocks(api, withConsensus) {
+			require.NotContains(t, response, "consensus")
+		}
+	}
+	// it holds the proposer, the committed seal signers and the commit round recorded in the block
+	for _, response := range getBlocks(api, &yes) {
+		fields := response["consensus"].(map[string]interface{})
+		require.Equal(t, validators[0], fields["blockProposer"])
+		require.Equal(t, []common.Address{crypto.PubkeyToAddress(committers[0].PublicKey), crypto.PubkeyToAddress(committers[1].PublicKey)}, fields["commitSigners"])
+		require.Equal(t, hexutil.Uint64(5), fields["round"])
+		require.NotContains(t, fields, "rawData")
+	}
+
+	// a block which records no commit round gets the round the tendermint engine derives from its proposer
+	header = types.CopyHeader(header)
+	require.NoError(t, utils.WriteCommitRound(header, 0))
+	tests_utils.AppendSealByPkKey(header, proposer)
+	tests_utils.AppendCommitedSealByPkKeys(header, committers)
+	block = types.NewBlockWithHeader(header)
+	api = NewPublicBlockChainAPI(&engineBackend{blockBackend: &blockBackend{block: block}, engine: &roundEngine{round: 3}})
+	for _, response := range getBlocks(api, &yes) {
+		require.Equal(t, hexutil.Uint64(3), response["consensus"].(map[string]interface{})["round"])
+	}
+	// and keeps the round 0 of its extra-data if the backend exposes no engine or one which isn't tendermint
+	for _, backend := range []Backend{
+		&blockBackend{block: block},
+		&engineBackend{blockBackend: &blockBackend{block: block}, engine: ethash.NewFaker()},
+	} {
+		for _, response := range getBlocks(NewPublicBlockChainAPI(backend), &yes) {
+			fields := response["consensus"].(map[string]interface{})
+			require.Equal(t, validators[0], fields["blockProposer"])
+			require.Equal(t, hexutil.Uint64(0), fields["round"])
+		}
+	}
+
+	// a block without tendermint seals has no consensus to decode, the requested field is null
+	block = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Extra: []byte("ethash")})
+	api = NewPublicBlockChainAPI(&blockBackend{block: block})
+	for _, response := range getBlocks(api, &yes) {
+		require.Contains(t, response, "consensus")
+		require.Nil(t, response["consensus"])
+	}
+}
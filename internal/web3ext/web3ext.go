@@ -18,21 +18,22 @@
 package web3ext
 
 var Modules = map[string]string{
-	"accounting": AccountingJs,
-	"admin":      AdminJs,
-	"chequebook": ChequebookJs,
-	"clique":     CliqueJs,
-	"ethash":     EthashJs,
-	"debug":      DebugJs,
-	"eth":        EvrJs,
-	"miner":      MinerJs,
-	"net":        NetJs,
-	"personal":   PersonalJs,
-	"rpc":        RpcJs,
-	"shh":        ShhJs,
-	"swarmfs":    SwarmfsJs,
-	"txpool":     TxpoolJs,
-	"tendermint": TendermintJs,
+	"accounting":      AccountingJs,
+	"admin":           AdminJs,
+	"chequebook":      ChequebookJs,
+	"clique":          CliqueJs,
+	"ethash":          EthashJs,
+	"debug":           DebugJs,
+	"eth":             EvrJs,
+	"miner":           MinerJs,
+	"net":             NetJs,
+	"personal":        PersonalJs,
+	"rpc":             RpcJs,
+	"shh":             ShhJs,
+	"swarmfs":         SwarmfsJs,
+	"txpool":          TxpoolJs,
+	"tendermint":      TendermintJs,
+	"tendermintadmin": TendermintAdminJs,
 }
 
 const ChequebookJs = `
@@ -243,6 +244,11 @@ web3._extend({
 			call: 'debug_setHead',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'forceSetHead',
+			call: 'debug_forceSetHead',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'seedHash',
 			call: 'debug_seedHash',
@@ -796,7 +802,121 @@ web3._extend({
 			params: 1,
 			inputFormatter:[null]
 		}),
+		new web3._extend.Method({
+			name: 'getValidatorSets',
+			call: 'tendermint_getValidatorSets',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getProposerSchedule',
+			call: 'tendermint_getProposerSchedule',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getConsensusLag',
+			call: 'tendermint_getConsensusLag',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getStakingInfo',
+			call: 'tendermint_getStakingInfo',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getDelegatorRewards',
+			call: 'tendermint_getDelegatorRewards',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getMissingValidators',
+			call: 'tendermint_getMissingValidators',
+			params: 1,
+			inputFormatter:[null]
+		}),
+		new web3._extend.Method({
+			name: 'getDuplicateProposals',
+			call: 'tendermint_getDuplicateProposals',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getAmnesias',
+			call: 'tendermint_getAmnesias',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getLunatics',
+			call: 'tendermint_getLunatics',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getPendingEvidences',
+			call: 'tendermint_getPendingEvidences',
+			params: 0
+		}),
 	],
-	properties: []
+	properties: [
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'tendermint_status'
+		}),
+	]
+});
+`
+
+const TendermintAdminJs = `
+web3._extend({
+	property: 'tendermintadmin',
+	methods: [
+		new web3._extend.Method({
+			name: 'setLogLevel',
+			call: 'tendermintadmin_setLogLevel',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'logLevels',
+			call: 'tendermintadmin_logLevels',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'dumpConsensusState',
+			call: 'tendermintadmin_dumpConsensusState',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'peerScores',
+			call: 'tendermintadmin_peerScores',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'addValidator',
+			call: 'tendermintadmin_addValidator',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'removeValidator',
+			call: 'tendermintadmin_removeValidator',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'pause',
+			call: 'tendermintadmin_pause',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'resume',
+			call: 'tendermintadmin_resume',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'signRecovery',
+			call: 'tendermintadmin_signRecovery',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+	]
 });
 `
@@ -53,15 +53,23 @@ func (b *LesApiBackend) CurrentBlock() *types.Block {
 	return types.NewBlockWithHeader(b.evr.BlockChain().CurrentHeader())
 }
 
-func (b *LesApiBackend) SetHead(number uint64) {
+func (b *LesApiBackend) SetHead(number uint64, force bool) error {
 	b.evr.protocolManager.downloader.Cancel()
-	b.evr.blockchain.SetHead(number)
+	return b.evr.blockchain.SetHead(number)
 }
 
 func (b *LesApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
 	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
 		return b.evr.blockchain.CurrentHeader(), nil
 	}
+	// The light chain verifies the commit of every tendermint header, its head is final. No header is final under the
+	// other engines.
+	if blockNr == rpc.FinalizedBlockNumber || blockNr == rpc.SafeBlockNumber {
+		if b.evr.chainConfig.Tendermint == nil {
+			return nil, nil
+		}
+		return b.evr.blockchain.CurrentHeader(), nil
+	}
 	return b.evr.blockchain.GetHeaderByNumberOdr(ctx, uint64(blockNr))
 }
 
@@ -88,6 +88,9 @@ func New(ctx *node.ServiceContext, config *evr.Config) (*LightEvrynet, error) {
 		return nil, genesisErr
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
+	if err := chainConfig.CheckEngine(); err != nil {
+		return nil, err
+	}
 
 	peers := newPeerSet()
 	quitSync := make(chan struct{})
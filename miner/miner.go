@@ -49,6 +49,9 @@ type Config struct {
 	GasCeil   uint64         // Target gas ceiling for mined blocks.
 	Recommit  time.Duration  // The time interval for miner to re-create mining work.
 	Noverify  bool           // Disable remote mining solution verification(only useful in ethash).
+
+	PriorityGas       uint64           `toml:",omitempty"` // Gas reserved in each block for the transactions to the system contracts, 0 disables the priority lane
+	PriorityContracts []common.Address `toml:",omitempty"` // Contracts the transactions to which are in the priority lane, in addition to the staking contract
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// priorityContracts returns the system contracts the transactions to which are committed in the priority lane:
+// the staking contract of the chain and the ones of the miner config.
+func priorityContracts(config *Config, chainConfig *params.ChainConfig) map[common.Address]struct{} {
+	contracts := make(map[common.Address]struct{}, len(config.PriorityContracts)+1)
+	if chainConfig.Tendermint != nil && chainConfig.Tendermint.StakingSCAddress != nil {
+		contracts[*chainConfig.Tendermint.StakingSCAddress] = struct{}{}
+	}
+	for _, addr := range config.PriorityContracts {
+		contracts[addr] = struct{}{}
+	}
+	return contracts
+}
+
+// splitPriorityTxs moves the transactions to the contracts out of pending and returns them.
+// Only the leading transactions of each account are moved, so that the transactions left keep following
+// the moved ones by nonce.
+func splitPriorityTxs(pending map[common.Address]types.Transactions, contracts map[common.Address]struct{}) map[common.Address]types.Transactions {
+	priority := make(map[common.Address]types.Transactions)
+	for account, txs := range pending {
+		n := 0
+		for ; n < len(txs); n++ {
+			if to := txs[n].To(); to == nil {
+				break
+			} else if _, ok := contracts[*to]; !ok {
+				break
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		priority[account] = txs[:n]
+		if n == len(txs) {
+			delete(pending, account)
+		} else {
+			pending[account] = txs[n:]
+		}
+	}
+	return priority
+}
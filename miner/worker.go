@@ -65,6 +65,10 @@ const (
 	// any newly arrived transactions.
 	maxRecommitInterval = 15 * time.Second
 
+	// speculativeInterval is the time interval to recreate the mining block with any newly
+	// arrived transactions when the engine proposes one of the next rounds.
+	speculativeInterval = 200 * time.Millisecond
+
 	// intervalAdjustRatio is the impact a single interval adjustment has on sealing work
 	// resubmitting interval.
 	intervalAdjustRatio = 0.1
@@ -87,6 +91,8 @@ type environment struct {
 	uncles    mapset.Set     // uncle set
 	tcount    int            // tx count in cycle
 	gasPool   *core.GasPool  // available gas used to pack transactions
+	gasBudget uint64         // gas the transactions may use, at most the block gas limit
+	deadline  time.Time      // no transaction is committed after it, zero without assembly time limit
 
 	header   *types.Header
 	txs      []*types.Transaction
@@ -129,6 +135,8 @@ type worker struct {
 	evr         Backend
 	chain       *core.BlockChain
 
+	priorityContracts map[common.Address]struct{} // The contracts the transactions to which are committed first
+
 	// Subscriptions
 	mux          *event.TypeMux
 	txsCh        chan core.NewTxsEvent
@@ -185,6 +193,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		evr:                evr,
 		mux:                mux,
 		chain:              evr.BlockChain(),
+		priorityContracts:  priorityContracts(config, chainConfig),
 		isLocalBlock:       isLocalBlock,
 		localUncles:        make(map[common.Hash]*types.Block),
 		remoteUncles:       make(map[common.Hash]*types.Block),
@@ -309,6 +318,14 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 	timer := time.NewTimer(0)
 	<-timer.C // discard the initial tick
 
+	// speculate ticks when the engine proposes in turns, to keep the block of an upcoming proposal full and fresh
+	var speculate <-chan time.Time
+	if _, ok := w.engine.(consensus.Proposer); ok {
+		ticker := time.NewTicker(speculativeInterval)
+		defer ticker.Stop()
+		speculate = ticker.C
+	}
+
 	// commit aborts in-flight transaction execution with given signal and resubmits a new one.
 	commit := func(noempty bool, s int32) {
 		if interrupt != nil {
@@ -383,6 +400,12 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 				commit(true, commitInterruptResubmit)
 			}
 
+		case <-speculate:
+			// Resubmit as soon as transactions arrive if this node proposes one of the next rounds.
+			if w.isRunning() && atomic.LoadInt32(&w.newTxs) > 0 && w.engine.(consensus.Proposer).ProposesSoon() {
+				commit(true, commitInterruptResubmit)
+			}
+
 		case interval := <-w.resubmitIntervalCh:
 			// Adjust resubmit interval explicitly by user.
 			if interval < minRecommitInterval {
@@ -646,6 +669,17 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		family:    mapset.NewSet(),
 		uncles:    mapset.NewSet(),
 		header:    header,
+		gasBudget: header.GasLimit,
+	}
+	// The engine may bound the blocks it proposes below the block gas limit and in assembly time
+	if budgeter, ok := w.engine.(consensus.ProposalBudgeter); ok {
+		gas, assembly := budgeter.ProposalBudget()
+		if gas > 0 && gas < env.gasBudget {
+			env.gasBudget = gas
+		}
+		if assembly > 0 {
+			env.deadline = time.Now().Add(assembly)
+		}
 	}
 
 	// when 08 is processed ancestors contain 07 (quick block)
@@ -736,7 +770,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 	}
 
 	if w.current.gasPool == nil {
-		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.gasBudget)
 	}
 
 	var coalescedLogs []*types.Log
@@ -751,7 +785,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		if interrupt != nil && atomic.LoadInt32(interrupt) != commitInterruptNone {
 			// Notify resubmit loop to increase resubmitting interval due to too frequent commits.
 			if atomic.LoadInt32(interrupt) == commitInterruptResubmit {
-				ratio := float64(w.current.header.GasLimit-w.current.gasPool.Gas()) / float64(w.current.header.GasLimit)
+				ratio := float64(w.current.gasBudget-w.current.gasPool.Gas()) / float64(w.current.gasBudget)
 				if ratio < 0.1 {
 					ratio = 0.1
 				}
@@ -762,6 +796,11 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			}
 			return atomic.LoadInt32(interrupt) == commitInterruptNewHead
 		}
+		// The assembly time of the block is spent, it is sealed with the transactions committed so far
+		if !w.current.deadline.IsZero() && time.Now().After(w.current.deadline) {
+			log.Debug("Block assembly time spent", "number", w.current.header.Number, "txs", w.current.tcount)
+			break
+		}
 		// If we don't have enough gas for any further transactions then we're done
 		if w.current.gasPool.Gas() < params.TxGas {
 			log.Trace("Not enough gas for further transactions", "have", w.current.gasPool, "want", params.TxGas)
@@ -946,6 +985,22 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		}
 		return
 	}
+	// Commit the transactions to the system contracts first, within the gas reserved for them,
+	// so that validator registrations and the like are not crowded out during congestion.
+	if w.config.PriorityGas > 0 {
+		if priorityTxs := splitPriorityTxs(pending, w.priorityContracts); len(priorityTxs) > 0 {
+			reserved := w.config.PriorityGas
+			if reserved > w.current.gasBudget {
+				reserved = w.current.gasBudget
+			}
+			w.current.gasPool = new(core.GasPool).AddGas(reserved)
+			txs := types.NewTransactionsByPriceAndNonce(w.current.signer, priorityTxs)
+			if w.commitTransactions(txs, w.coinbase, interrupt) {
+				return
+			}
+			w.current.gasPool.AddGas(w.current.gasBudget - reserved)
+		}
+	}
 	// Split the pending transactions into locals and remotes
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
 	for _, account := range w.evr.TxPool().Locals() {
@@ -1023,8 +1078,12 @@ func (w *worker) VerifyAndSubmitPendingBlock(block *types.Block) error {
 	if err != nil {
 		return err
 	}
-	// insert txs to stateDB
-	receipts, _, usedGas, err := w.chain.Processor().Process(block, stateDB, *w.chain.GetVMConfig())
+	// insert txs to stateDB, the independent ones concurrently to verify full blocks within the propose timeout
+	process := w.chain.Processor().Process
+	if processor, ok := w.chain.Processor().(core.ParallelProcessor); ok {
+		process = processor.ProcessParallel
+	}
+	receipts, _, usedGas, err := process(block, stateDB, *w.chain.GetVMConfig())
 	if err != nil {
 		return err
 	}
@@ -458,3 +458,64 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestSplitPriorityTxs(t *testing.T) {
+	var (
+		staking   = common.HexToAddress("0x01")
+		contracts = map[common.Address]struct{}{staking: {}}
+		user      = common.HexToAddress("0x02")
+		tx        = func(nonce uint64, to common.Address) *types.Transaction {
+			return types.NewTransaction(nonce, to, big.NewInt(1), params.TxGas, big.NewInt(1), nil)
+		}
+		pending = map[common.Address]types.Transactions{
+			// a registration followed by a transfer
+			common.HexToAddress("0xa"): {tx(0, staking), tx(1, user)},
+			// a transfer followed by a registration, which must wait for the transfer
+			common.HexToAddress("0xb"): {tx(0, user), tx(1, staking)},
+			common.HexToAddress("0xc"): {tx(0, staking)},
+		}
+	)
+	priority := splitPriorityTxs(pending, contracts)
+	if len(priority) != 2 || len(priority[common.HexToAddress("0xa")]) != 1 || len(priority[common.HexToAddress("0xc")]) != 1 {
+		t.Fatalf("priority transactions mismatch: have %v", priority)
+	}
+	if len(pending) != 2 || pending[common.HexToAddress("0xa")][0].Nonce() != 1 || len(pending[common.HexToAddress("0xb")]) != 2 {
+		t.Fatalf("pending transactions mismatch: have %v", pending)
+	}
+}
+
+// budgetEngine bounds the blocks the ethash faker seals as a consensus.ProposalBudgeter
+type budgetEngine struct {
+	*ethash.Ethash
+	gas      uint64
+	assembly time.Duration
+}
+
+func (e *budgetEngine) ProposalBudget() (uint64, time.Duration) { return e.gas, e.assembly }
+
+func TestProposalBudget(t *testing.T) {
+	pendingCount := func(engine *budgetEngine) int {
+		defer engine.Close()
+		b := newTestWorkerBackend(t, ethashChainConfig, engine.Ethash, 0)
+		b.txPool.AddLocals(append(append([]*types.Transaction{}, pendingTxs...), newTxs...))
+		w := newWorker(testConfig, ethashChainConfig, engine, b, new(event.TypeMux), nil)
+		w.setEtherbase(testBankAddress)
+		defer w.close()
+
+		// Ensure snapshot has been updated.
+		time.Sleep(100 * time.Millisecond)
+		block, _ := w.pending()
+		return len(block.Transactions())
+	}
+	if have := pendingCount(&budgetEngine{Ethash: ethash.NewFaker()}); have != 2 {
+		t.Errorf("transaction count mismatch without budget: have %d, want %d", have, 2)
+	}
+	// the gas budget fits a single transfer, below the block gas limit
+	if have := pendingCount(&budgetEngine{Ethash: ethash.NewFaker(), gas: params.TxGas}); have != 1 {
+		t.Errorf("transaction count mismatch with gas budget: have %d, want %d", have, 1)
+	}
+	// the assembly time is spent before the first transaction
+	if have := pendingCount(&budgetEngine{Ethash: ethash.NewFaker(), assembly: time.Nanosecond}); have != 0 {
+		t.Errorf("transaction count mismatch with assembly time: have %d, want %d", have, 0)
+	}
+}
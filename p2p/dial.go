@@ -35,6 +35,10 @@ const (
 	// private networks.
 	dialHistoryExpiration = inboundThrottleTime + 5*time.Second
 
+	// Validator nodes are redialed much sooner than other nodes, since every moment
+	// without a direct link to a fellow validator can cost consensus rounds.
+	validatorRedialInterval = 2 * time.Second
+
 	// Discovery lookups are throttled and can only run
 	// once every few seconds.
 	lookupInterval = 4 * time.Second
@@ -84,6 +88,7 @@ type dialstate struct {
 	lookupBuf     []*enode.Node // current discovery lookup results
 	randomNodes   []*enode.Node // filled from Table
 	static        map[enode.ID]*dialTask
+	validators    map[enode.ID]bool // static nodes which are redialed after validatorRedialInterval
 	hist          expHeap
 }
 
@@ -130,6 +135,7 @@ func newDialState(self enode.ID, ntab discoverTable, maxdyn int, cfg *Config) *d
 		netrestrict: cfg.NetRestrict,
 		log:         cfg.Logger,
 		static:      make(map[enode.ID]*dialTask),
+		validators:  make(map[enode.ID]bool),
 		dialing:     make(map[enode.ID]connFlag),
 		bootnodes:   make([]*enode.Node, len(cfg.BootstrapNodes)),
 		randomNodes: make([]*enode.Node, maxdyn/2),
@@ -141,6 +147,10 @@ func newDialState(self enode.ID, ntab discoverTable, maxdyn int, cfg *Config) *d
 	for _, n := range cfg.StaticNodes {
 		s.addStatic(n)
 	}
+	for _, n := range cfg.ValidatorNodes {
+		s.addStatic(n)
+		s.validators[n.ID()] = true
+	}
 	return s
 }
 
@@ -320,7 +330,11 @@ func (s *dialstate) checkDial(n *enode.Node, peers map[enode.ID]*Peer) error {
 func (s *dialstate) taskDone(t task, now time.Time) {
 	switch t := t.(type) {
 	case *dialTask:
-		s.hist.add(string(t.dest.ID().Bytes()), now.Add(dialHistoryExpiration))
+		expiration := dialHistoryExpiration
+		if s.validators[t.dest.ID()] {
+			expiration = validatorRedialInterval
+		}
+		s.hist.add(string(t.dest.ID().Bytes()), now.Add(expiration))
 		delete(s.dialing, t.dest.ID())
 	case *discoverTask:
 		s.lookupRunning = false
@@ -547,6 +547,54 @@ func TestDialStateStaticDial(t *testing.T) {
 	})
 }
 
+// This test checks that validator nodes are redialed without waiting for the dial history.
+func TestDialStateValidatorDial(t *testing.T) {
+	config := &Config{
+		PrivateKey: newkey(),
+		StaticNodes: []*enode.Node{
+			newNode(uintID(1), nil),
+		},
+		ValidatorNodes: []*enode.Node{
+			newNode(uintID(2), nil),
+		},
+		Logger: testlog.Logger(t, log.LvlTrace),
+	}
+	runDialTest(t, dialtest{
+		init: newDialState(enode.ID{}, fakeTable{}, 0, config),
+		rounds: []round{
+			// Validator nodes are dialed like static nodes.
+			{
+				peers: nil,
+				new: []task{
+					&dialTask{flags: staticDialedConn, dest: newNode(uintID(1), nil)},
+					&dialTask{flags: staticDialedConn, dest: newNode(uintID(2), nil)},
+				},
+			},
+			// Both dials failed, the validator node history entry expires first.
+			{
+				peers: nil,
+				done: []task{
+					&dialTask{flags: staticDialedConn, dest: newNode(uintID(1), nil)},
+					&dialTask{flags: staticDialedConn, dest: newNode(uintID(2), nil)},
+				},
+				new: []task{
+					&waitExpireTask{Duration: validatorRedialInterval},
+				},
+			},
+			// Only the validator node is dialed again.
+			{
+				peers: nil,
+				done: []task{
+					&waitExpireTask{Duration: validatorRedialInterval},
+				},
+				new: []task{
+					&dialTask{flags: staticDialedConn, dest: newNode(uintID(2), nil)},
+				},
+			},
+		},
+	})
+}
+
 // This test checks that past dials are not retried for some time.
 func TestDialStateCache(t *testing.T) {
 	config := &Config{
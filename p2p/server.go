@@ -43,7 +43,6 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/p2p/enr"
 	"github.com/Evrynetlabs/evrynet-node/p2p/nat"
 	"github.com/Evrynetlabs/evrynet-node/p2p/netutil"
-	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
 const (
@@ -115,6 +114,11 @@ type Config struct {
 	// allowed to connect, even above the peer limit.
 	TrustedNodes []*enode.Node
 
+	// Validator nodes are fellow validators which are always kept connected. They are
+	// trusted, so they are allowed above the peer limit, redialed within seconds on
+	// disconnects and never throttled when they reconnect.
+	ValidatorNodes []*enode.Node
+
 	// Connectivity can be restricted to certain IP networks.
 	// If this option is set to a non-nil value, only hosts which match one of the
 	// IP networks contained in the list are considered.
@@ -199,6 +203,7 @@ type Server struct {
 	// State of run loop and listenLoop.
 	lastLookup     time.Time
 	inboundHistory expHeap
+	validatorIPs   map[string]bool // IPs of the validator nodes, which are not throttled
 }
 
 type peerOpFunc func(map[enode.ID]*Peer)
@@ -463,6 +468,12 @@ func (srv *Server) Start() (err error) {
 	srv.removetrusted = make(chan *enode.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
+	srv.validatorIPs = make(map[string]bool, len(srv.ValidatorNodes))
+	for _, n := range srv.ValidatorNodes {
+		if n.IP() != nil {
+			srv.validatorIPs[n.IP().String()] = true
+		}
+	}
 
 	if srv.Config.MaxPeers < len(srv.currentValidators) {
 		srv.Config.MaxPeers = len(srv.currentValidators)
@@ -527,15 +538,13 @@ func (srv *Server) GetValSetAddresses(header *types.Header) ([]common.Address, e
 	if err != nil {
 		return nil, err
 	}
-	if len(tdmExtra.ValidatorAdds) == 0 {
-		return nil, errors.New("Zero validator set")
-	}
-
-	// RLP decode validator's address from bytes
-	err = rlp.DecodeBytes(tdmExtra.ValidatorAdds, &validators)
+	validators, err = tdmExtra.Validators()
 	if err != nil {
 		return nil, err
 	}
+	if len(validators) == 0 {
+		return nil, errors.New("Zero validator set")
+	}
 	return validators, nil
 }
 
@@ -688,7 +697,7 @@ func (srv *Server) run(dialstate dialer) {
 	var (
 		peers        = make(map[enode.ID]*Peer)
 		inboundCount = 0
-		trusted      = make(map[enode.ID]bool, len(srv.TrustedNodes))
+		trusted      = make(map[enode.ID]bool, len(srv.TrustedNodes)+len(srv.ValidatorNodes))
 		taskdone     = make(chan task, maxActiveDialTasks)
 		runningTasks []task
 		queuedTasks  []task // tasks that can't run yet
@@ -698,6 +707,10 @@ func (srv *Server) run(dialstate dialer) {
 	for _, n := range srv.TrustedNodes {
 		trusted[n.ID()] = true
 	}
+	// Validator nodes must stay connected whatever the peer limit.
+	for _, n := range srv.ValidatorNodes {
+		trusted[n.ID()] = true
+	}
 
 	// removes t from runningTasks
 	delTask := func(t task) {
@@ -956,9 +969,9 @@ func (srv *Server) checkInboundConn(fd net.Conn, remoteIP net.IP) error {
 		if srv.NetRestrict != nil && !srv.NetRestrict.Contains(remoteIP) {
 			return fmt.Errorf("not whitelisted in NetRestrict")
 		}
-		// Reject Internet peers that try too often.
+		// Reject Internet peers that try too often, except the validator nodes.
 		srv.inboundHistory.expire(time.Now())
-		if !netutil.IsLAN(remoteIP) && srv.inboundHistory.contains(remoteIP.String()) {
+		if !netutil.IsLAN(remoteIP) && !srv.validatorIPs[remoteIP.String()] && srv.inboundHistory.contains(remoteIP.String()) {
 			return fmt.Errorf("too many attempts")
 		}
 		srv.inboundHistory.add(remoteIP.String(), time.Now().Add(inboundThrottleTime))
@@ -0,0 +1,51 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// TendermintBonding sets the bonding period of the stakes in the staking contract: from the checkpoint Block on,
+// the stake of a candidate, its owner's and the one its voters delegate to it, counts toward the validator
+// selection and the proposer weights once it stayed bonded at the Epochs checkpoints before. The stake recorded
+// at each checkpoint counts from the Epochs-th one after it, a stake unbonded stops counting at the next checkpoint.
+type TendermintBonding struct {
+	Block  uint64 `json:"block"`
+	Epochs uint64 `json:"epochs"`
+}
+
+// CheckBonding checks that the bonding period starts at a checkpoint block and lasts at least an epoch
+func (c *TendermintConfig) CheckBonding() error {
+	bonding := c.Bonding
+	if bonding == nil {
+		return nil
+	}
+	if c.Epoch != 0 && bonding.Block%c.Epoch != 0 {
+		return fmt.Errorf("bonding at block %d is not at a checkpoint block", bonding.Block)
+	}
+	if bonding.Epochs == 0 {
+		return fmt.Errorf("bonding at block %d lasts no epoch", bonding.Block)
+	}
+	return nil
+}
+
+// IsBonding returns true if the validators of the checkpoint are selected by their bonded stakes,
+// the stakes of the checkpoints of the bonding period before it being recorded
+func (c *TendermintConfig) IsBonding(checkpoint uint64) bool {
+	return c.Bonding != nil && checkpoint >= c.Bonding.Block+c.Bonding.Epochs*c.Epoch
+}
+
+// bondingCompatible returns false and the block of the bonding if it cannot be changed from b1 to b2
+// because head is already past the block of either
+func bondingCompatible(b1, b2 *TendermintBonding, head *big.Int) (uint64, bool) {
+	switch {
+	case b1 == nil && b2 == nil:
+		return 0, true
+	case b1 != nil && b2 != nil && *b1 == *b2:
+		return 0, true
+	case b1 != nil && (b2 == nil || b1.Block <= b2.Block):
+		return b1.Block, !isForked(new(big.Int).SetUint64(b1.Block), head)
+	default:
+		return b2.Block, !isForked(new(big.Int).SetUint64(b2.Block), head)
+	}
+}
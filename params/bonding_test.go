@@ -0,0 +1,49 @@
+package params
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTendermintConfig_CheckBonding(t *testing.T) {
+	tests := []struct {
+		bonding *TendermintBonding
+		wantErr bool
+	}{
+		{bonding: nil},
+		{bonding: &TendermintBonding{Block: 20, Epochs: 2}},
+		{bonding: &TendermintBonding{Block: 25, Epochs: 2}, wantErr: true},
+		{bonding: &TendermintBonding{Block: 20}, wantErr: true},
+	}
+	for i, test := range tests {
+		config := &TendermintConfig{Epoch: 10, Bonding: test.bonding}
+		if err := config.CheckBonding(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestTendermintConfig_IsBonding(t *testing.T) {
+	config := &TendermintConfig{Epoch: 10, Bonding: &TendermintBonding{Block: 20, Epochs: 2}}
+	for checkpoint, want := range map[uint64]bool{20: false, 30: false, 40: true, 50: true} {
+		if have := config.IsBonding(checkpoint); have != want {
+			t.Errorf("checkpoint %d: have %v, want %v", checkpoint, have, want)
+		}
+	}
+	if (&TendermintConfig{Epoch: 10}).IsBonding(40) {
+		t.Errorf("no bonding should be enforced without bonding period")
+	}
+}
+
+func TestBondingCompatible(t *testing.T) {
+	bonding := &TendermintBonding{Block: 20, Epochs: 1}
+	if _, ok := bondingCompatible(nil, bonding, big.NewInt(19)); !ok {
+		t.Errorf("bonding at block 20 should be addable at head 19")
+	}
+	if block, ok := bondingCompatible(nil, bonding, big.NewInt(20)); ok || block != 20 {
+		t.Errorf("bonding at block 20 should not be addable at head 20, have block %d", block)
+	}
+	if _, ok := bondingCompatible(bonding, &TendermintBonding{Block: 20, Epochs: 1}, big.NewInt(30)); !ok {
+		t.Errorf("the same bonding should be compatible")
+	}
+}
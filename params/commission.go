@@ -0,0 +1,48 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// TendermintCommission lets the validators set their commission in the staking contract, the percentage of their
+// rewards they keep before their voters share the rest. The commissions apply to the rewards of the epochs after
+// the checkpoint Block, bounded by Min and Max, and move at most MaxChange percentage points from an epoch to the
+// next one, 0 for no limit. A validator which sets no commission is charged 100 - the voter percentage of the
+// reward schedule, within the same bounds.
+type TendermintCommission struct {
+	Block     uint64 `json:"block"`
+	Min       uint64 `json:"min"`
+	Max       uint64 `json:"max"`
+	MaxChange uint64 `json:"maxChange,omitempty"`
+}
+
+// CheckCommission checks that the commissions apply from a checkpoint block with valid bounds
+func (c *TendermintConfig) CheckCommission() error {
+	commission := c.Commission
+	if commission == nil {
+		return nil
+	}
+	if c.Epoch != 0 && commission.Block%c.Epoch != 0 {
+		return fmt.Errorf("commission at block %d is not at a checkpoint block", commission.Block)
+	}
+	if commission.Min > commission.Max || commission.Max > 100 {
+		return fmt.Errorf("commission bounds %d%%-%d%% are invalid", commission.Min, commission.Max)
+	}
+	return nil
+}
+
+// commissionCompatible returns false and the block of the commissions if they cannot be changed from c1 to c2
+// because head is already past the block of either
+func commissionCompatible(c1, c2 *TendermintCommission, head *big.Int) (uint64, bool) {
+	switch {
+	case c1 == nil && c2 == nil:
+		return 0, true
+	case c1 != nil && c2 != nil && *c1 == *c2:
+		return 0, true
+	case c1 != nil && (c2 == nil || c1.Block <= c2.Block):
+		return c1.Block, !isForked(new(big.Int).SetUint64(c1.Block+1), head)
+	default:
+		return c2.Block, !isForked(new(big.Int).SetUint64(c2.Block+1), head)
+	}
+}
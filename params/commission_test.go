@@ -0,0 +1,38 @@
+package params
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTendermintConfig_CheckCommission(t *testing.T) {
+	tests := []struct {
+		commission *TendermintCommission
+		wantErr    bool
+	}{
+		{commission: nil},
+		{commission: &TendermintCommission{Block: 20, Min: 5, Max: 50, MaxChange: 1}},
+		{commission: &TendermintCommission{Block: 25, Max: 50}, wantErr: true},
+		{commission: &TendermintCommission{Block: 20, Min: 60, Max: 50}, wantErr: true},
+		{commission: &TendermintCommission{Block: 20, Max: 101}, wantErr: true},
+	}
+	for i, test := range tests {
+		config := &TendermintConfig{Epoch: 10, Commission: test.commission}
+		if err := config.CheckCommission(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestCommissionCompatible(t *testing.T) {
+	commission := &TendermintCommission{Block: 20, Max: 50}
+	if _, ok := commissionCompatible(nil, commission, big.NewInt(20)); !ok {
+		t.Errorf("commission at block 20 should be addable at head 20")
+	}
+	if block, ok := commissionCompatible(nil, commission, big.NewInt(21)); ok || block != 20 {
+		t.Errorf("commission at block 20 should not be addable at head 21, have block %d", block)
+	}
+	if _, ok := commissionCompatible(commission, &TendermintCommission{Block: 20, Max: 50}, big.NewInt(30)); !ok {
+		t.Errorf("the same commission should be compatible")
+	}
+}
@@ -25,6 +25,9 @@ import (
 
 const GasPriceConfig = 1000000000
 
+// maxTimeoutBackoff is the last known tendermint timeout backoff strategy, the constant one
+const maxTimeoutBackoff = 2
+
 // Genesis hashes to enforce below configs on.
 var (
 	MainnetGenesisHash = common.HexToHash("0x38b23d699697336cd0d95a550a1d3a1ac7ee7148c4854c93b0464973dcea17b6")
@@ -242,6 +245,108 @@ type TendermintConfig struct {
 	BlockReward      *big.Int         `json:"blockReward"`      // TendermintBlockReward for accumulating reward
 	StakingSCAddress *common.Address  `json:"stakingSCAddress"` // The staking SC address for validating when deploy SC
 	FixedValidators  []common.Address `json:"fixedValidators"`
+	// BlockPeriod is the minimum difference in seconds between the timestamps of consecutive blocks,
+	// 0 keeps the block period of the node configuration
+	BlockPeriod uint64 `json:"period,omitempty"`
+	// TimeoutBackoff is the strategy to grow the timeouts with the round: 0 linear, 1 exponential, 2 constant
+	TimeoutBackoff uint64 `json:"timeoutBackoff,omitempty"`
+	// TimeoutBackoffMax is the maximum of a timeout in milliseconds, 0 means no limit
+	TimeoutBackoffMax uint64 `json:"timeoutBackoffMax,omitempty"`
+	// DomainSeparationBlock is the block from which consensus messages are signed together with the chain id,
+	// block number, round and message type. Nil keeps the legacy signing for existing networks.
+	DomainSeparationBlock *big.Int `json:"domainSeparationBlock,omitempty"`
+	// WeightedVotingBlock is the block from which the validators vote with their stake weights, a quorum being
+	// more than two thirds of the total weight instead of the validator count. Nil keeps the equal votes.
+	WeightedVotingBlock *big.Int `json:"weightedVotingBlock,omitempty"`
+	// ProtobufSigningBlock is the block from which consensus messages are signed protobuf encoded,
+	// for signers built against the protobuf schema. Nil keeps the RLP signing.
+	ProtobufSigningBlock *big.Int `json:"protobufSigningBlock,omitempty"`
+	// StrictTimestampBlock is the block from which a block's timestamp must be after its parent's.
+	// Nil keeps accepting a timestamp equal to the parent's, as the existing chains have.
+	StrictTimestampBlock *big.Int `json:"strictTimestampBlock,omitempty"`
+	// GasLimitBoundBlock is the block from which a block's gas limit must be at least MinGasLimit and move from
+	// its parent's by less than 1/GasLimitBoundDivisor of it. Nil keeps the gas limits unbounded.
+	GasLimitBoundBlock *big.Int `json:"gasLimitBoundBlock,omitempty"`
+	// KeyRotations replace the keys of validators without them leaving the validator set, in order of block
+	KeyRotations []TendermintKeyRotation `json:"keyRotations,omitempty"`
+	// RewardSchedule changes the block reward and its split at checkpoint blocks, in order of block.
+	// BlockReward is paid before the first stage.
+	RewardSchedule []TendermintRewardStage `json:"rewardSchedule,omitempty"`
+	// FeeTreasury receives FeeTreasuryPercentage of the transaction fees of each block and FeeBurnPercentage
+	// of them are burnt, the proposer earns the rest.
+	FeeTreasury           *common.Address `json:"feeTreasury,omitempty"`
+	FeeTreasuryPercentage uint64          `json:"feeTreasuryPercentage,omitempty"`
+	FeeBurnPercentage     uint64          `json:"feeBurnPercentage,omitempty"`
+	// Recoveries replace the validator set when the remaining validators no longer reach a quorum, in order of block
+	Recoveries []TendermintRecovery `json:"recoveries,omitempty"`
+	// ParamsContract holds the evidence parameters set by the validator governance, from its block on
+	ParamsContract *TendermintParamsContract `json:"paramsContract,omitempty"`
+	// Commission bounds the commissions the validators set in the staking contract, nil keeps the voter percentage
+	// of the reward schedule for all of them
+	Commission *TendermintCommission `json:"commission,omitempty"`
+	// Bonding delays the stake bonded in the staking contract from counting toward the validator selection,
+	// nil counts the stakes as soon as they are bonded
+	Bonding *TendermintBonding `json:"bonding,omitempty"`
+	// DelegatorRewards keeps the rewards of the voters until they claim them, nil pays them at each epoch block
+	DelegatorRewards *TendermintDelegatorRewards `json:"delegatorRewards,omitempty"`
+	// MinSelfBond is the stake the owners of the candidates bond to them for the candidates to be selected,
+	// nil selects them by the minimum stake of the staking contract only
+	MinSelfBond *TendermintMinSelfBond `json:"minSelfBond,omitempty"`
+}
+
+// TendermintParamsContract is a contract holding consensus parameters the validator governance updates.
+// From Block on, the parameters are read from its storage at the state of the chain head, so every node
+// applies the same values at the same head. A zero parameter keeps the value of the node configuration.
+type TendermintParamsContract struct {
+	Block   uint64         `json:"block"`
+	Address common.Address `json:"address"`
+}
+
+// TendermintKeyRotation replaces the key Old of a validator by the key New.
+// The validator sets of the checkpoint Block and of the following checkpoints list New in place of Old,
+// so that New signs from the block after Block on, while the staking contract still knows the validator as Old.
+type TendermintKeyRotation struct {
+	Block uint64         `json:"block"`
+	Old   common.Address `json:"old"`
+	New   common.Address `json:"new"`
+}
+
+// CheckKeyRotations checks that the key rotations happen at checkpoint blocks, in order
+func (c *TendermintConfig) CheckKeyRotations() error {
+	var last uint64
+	for _, r := range c.KeyRotations {
+		if r.Block == 0 || (c.Epoch != 0 && r.Block%c.Epoch != 0) {
+			return fmt.Errorf("key rotation of %s at block %d is not at a checkpoint block", r.Old.Hex(), r.Block)
+		}
+		if r.Block < last {
+			return fmt.Errorf("key rotation of %s at block %d is out of order", r.Old.Hex(), r.Block)
+		}
+		if r.Old == r.New {
+			return fmt.Errorf("key rotation of %s at block %d keeps the same key", r.Old.Hex(), r.Block)
+		}
+		last = r.Block
+	}
+	return nil
+}
+
+// CheckFeeRouting checks that the treasury and burnt shares of the transaction fees don't exceed them,
+// and that the treasury share has a treasury to go to
+func (c *TendermintConfig) CheckFeeRouting() error {
+	if c.FeeTreasuryPercentage+c.FeeBurnPercentage > 100 {
+		return fmt.Errorf("fee treasury and burn percentages sum to %d%%", c.FeeTreasuryPercentage+c.FeeBurnPercentage)
+	}
+	if c.FeeTreasuryPercentage > 0 && c.FeeTreasury == nil {
+		return fmt.Errorf("fee treasury percentage is %d%% without fee treasury", c.FeeTreasuryPercentage)
+	}
+	return nil
+}
+
+// CheckTimeoutBackoff checks that the timeout backoff is one of the known strategies
+func (c *TendermintConfig) CheckTimeoutBackoff() error {
+	if c.TimeoutBackoff > maxTimeoutBackoff {
+		return fmt.Errorf("unknown timeout backoff %d", c.TimeoutBackoff)
+	}
+	return nil
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -344,6 +449,27 @@ func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 	}
 }
 
+// CheckEngine checks that the configuration selects at most one consensus engine,
+// the chain runs ethash if it selects none. The node creates the first engine configured of clique,
+// tendermint and ethash, so a genesis selecting several would silently run one of them and ignore
+// the configuration of the others.
+func (c *ChainConfig) CheckEngine() error {
+	var engines []string
+	if c.Ethash != nil {
+		engines = append(engines, "ethash")
+	}
+	if c.Clique != nil {
+		engines = append(engines, "clique")
+	}
+	if c.Tendermint != nil {
+		engines = append(engines, "tendermint")
+	}
+	if len(engines) > 1 {
+		return fmt.Errorf("chain configuration selects several consensus engines: %v", engines)
+	}
+	return nil
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -396,9 +522,63 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if c.Tendermint != nil && newcfg.Tendermint != nil {
+		if isForkIncompatible(c.Tendermint.DomainSeparationBlock, newcfg.Tendermint.DomainSeparationBlock, head) {
+			return newCompatError("Tendermint domain separation fork block", c.Tendermint.DomainSeparationBlock, newcfg.Tendermint.DomainSeparationBlock)
+		}
+		if isForkIncompatible(c.Tendermint.WeightedVotingBlock, newcfg.Tendermint.WeightedVotingBlock, head) {
+			return newCompatError("Tendermint weighted voting fork block", c.Tendermint.WeightedVotingBlock, newcfg.Tendermint.WeightedVotingBlock)
+		}
+		if isForkIncompatible(c.Tendermint.ProtobufSigningBlock, newcfg.Tendermint.ProtobufSigningBlock, head) {
+			return newCompatError("Tendermint protobuf signing fork block", c.Tendermint.ProtobufSigningBlock, newcfg.Tendermint.ProtobufSigningBlock)
+		}
+		if isForkIncompatible(c.Tendermint.StrictTimestampBlock, newcfg.Tendermint.StrictTimestampBlock, head) {
+			return newCompatError("Tendermint strict timestamp fork block", c.Tendermint.StrictTimestampBlock, newcfg.Tendermint.StrictTimestampBlock)
+		}
+		if isForkIncompatible(c.Tendermint.GasLimitBoundBlock, newcfg.Tendermint.GasLimitBoundBlock, head) {
+			return newCompatError("Tendermint gas limit bound fork block", c.Tendermint.GasLimitBoundBlock, newcfg.Tendermint.GasLimitBoundBlock)
+		}
+		if block, ok := keyRotationsCompatible(c.Tendermint.KeyRotations, newcfg.Tendermint.KeyRotations, head); !ok {
+			return newCompatError("Tendermint key rotation", new(big.Int).SetUint64(block), new(big.Int).SetUint64(block))
+		}
+		if block, ok := rewardScheduleCompatible(c.Tendermint.RewardSchedule, newcfg.Tendermint.RewardSchedule, head); !ok {
+			return newCompatError("Tendermint reward schedule", new(big.Int).SetUint64(block), new(big.Int).SetUint64(block))
+		}
+		if block, ok := recoveriesCompatible(c.Tendermint.Recoveries, newcfg.Tendermint.Recoveries, head); !ok {
+			return newCompatError("Tendermint recovery", new(big.Int).SetUint64(block), new(big.Int).SetUint64(block))
+		}
+		if block, ok := commissionCompatible(c.Tendermint.Commission, newcfg.Tendermint.Commission, head); !ok {
+			return newCompatError("Tendermint commission", new(big.Int).SetUint64(block), new(big.Int).SetUint64(block))
+		}
+		if block, ok := bondingCompatible(c.Tendermint.Bonding, newcfg.Tendermint.Bonding, head); !ok {
+			return newCompatError("Tendermint bonding", new(big.Int).SetUint64(block), new(big.Int).SetUint64(block))
+		}
+		if block, ok := delegatorRewardsCompatible(c.Tendermint.DelegatorRewards, newcfg.Tendermint.DelegatorRewards, head); !ok {
+			return newCompatError("Tendermint delegator rewards", new(big.Int).SetUint64(block), new(big.Int).SetUint64(block))
+		}
+		if block, ok := minSelfBondCompatible(c.Tendermint.MinSelfBond, newcfg.Tendermint.MinSelfBond, head); !ok {
+			return newCompatError("Tendermint minimum self-bond", new(big.Int).SetUint64(block), new(big.Int).SetUint64(block))
+		}
+	}
 	return nil
 }
 
+// keyRotationsCompatible returns false and the block of the first rotation which differs
+// if the rotations s1 cannot be rescheduled to s2 because head is already past it.
+func keyRotationsCompatible(s1, s2 []TendermintKeyRotation, head *big.Int) (uint64, bool) {
+	for i := 0; i < len(s1) || i < len(s2); i++ {
+		switch {
+		case i < len(s1) && i < len(s2) && s1[i] == s2[i]:
+			continue
+		case i < len(s1) && (i >= len(s2) || s1[i].Block <= s2[i].Block):
+			return s1[i].Block, !isForked(new(big.Int).SetUint64(s1[i].Block), head)
+		default:
+			return s2[i].Block, !isForked(new(big.Int).SetUint64(s2[i].Block), head)
+		}
+	}
+	return 0, true
+}
+
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
 // block s2 because head is already past the fork.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
@@ -19,7 +19,10 @@ package params
 import (
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -70,6 +73,38 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored: &ChainConfig{Tendermint: &TendermintConfig{Epoch: 10}},
+			new:    &ChainConfig{Tendermint: &TendermintConfig{Epoch: 10, KeyRotations: []TendermintKeyRotation{{Block: 20, Old: common.Address{1}, New: common.Address{2}}}}},
+			head:   15,
+		},
+		{
+			stored: &ChainConfig{Tendermint: &TendermintConfig{Epoch: 10}},
+			new:    &ChainConfig{Tendermint: &TendermintConfig{Epoch: 10, KeyRotations: []TendermintKeyRotation{{Block: 20, Old: common.Address{1}, New: common.Address{2}}}}},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "Tendermint key rotation",
+				StoredConfig: big.NewInt(20),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored: &ChainConfig{Tendermint: &TendermintConfig{Epoch: 10}},
+			new:    &ChainConfig{Tendermint: &TendermintConfig{Epoch: 10, WeightedVotingBlock: big.NewInt(20)}},
+			head:   15,
+		},
+		{
+			stored: &ChainConfig{Tendermint: &TendermintConfig{Epoch: 10, WeightedVotingBlock: big.NewInt(20)}},
+			new:    &ChainConfig{Tendermint: &TendermintConfig{Epoch: 10, WeightedVotingBlock: big.NewInt(30)}},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "Tendermint weighted voting fork block",
+				StoredConfig: big.NewInt(20),
+				NewConfig:    big.NewInt(30),
+				RewindTo:     19,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +114,93 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestTendermintConfig_CheckKeyRotations(t *testing.T) {
+	var (
+		old  = common.Address{1}
+		next = common.Address{2}
+	)
+	tests := []struct {
+		rotations []TendermintKeyRotation
+		wantErr   bool
+	}{
+		{rotations: nil},
+		{rotations: []TendermintKeyRotation{{Block: 20, Old: old, New: next}, {Block: 30, Old: next, New: old}}},
+		{rotations: []TendermintKeyRotation{{Block: 0, Old: old, New: next}}, wantErr: true},
+		{rotations: []TendermintKeyRotation{{Block: 25, Old: old, New: next}}, wantErr: true},
+		{rotations: []TendermintKeyRotation{{Block: 30, Old: old, New: next}, {Block: 20, Old: next, New: old}}, wantErr: true},
+		{rotations: []TendermintKeyRotation{{Block: 20, Old: old, New: old}}, wantErr: true},
+	}
+	for i, test := range tests {
+		config := &TendermintConfig{Epoch: 10, KeyRotations: test.rotations}
+		if err := config.CheckKeyRotations(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestTendermintConfig_CheckFeeRouting(t *testing.T) {
+	treasury := common.Address{1}
+	tests := []struct {
+		config  TendermintConfig
+		wantErr bool
+	}{
+		{config: TendermintConfig{}},
+		{config: TendermintConfig{FeeBurnPercentage: 100}},
+		{config: TendermintConfig{FeeTreasury: &treasury, FeeTreasuryPercentage: 40, FeeBurnPercentage: 60}},
+		{config: TendermintConfig{FeeTreasury: &treasury, FeeTreasuryPercentage: 50, FeeBurnPercentage: 60}, wantErr: true},
+		{config: TendermintConfig{FeeTreasuryPercentage: 10}, wantErr: true},
+	}
+	for i, test := range tests {
+		if err := test.config.CheckFeeRouting(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestTendermintConfig_CheckTimeoutBackoff(t *testing.T) {
+	for backoff := uint64(0); backoff <= maxTimeoutBackoff; backoff++ {
+		config := TendermintConfig{TimeoutBackoff: backoff}
+		if err := config.CheckTimeoutBackoff(); err != nil {
+			t.Errorf("backoff %d: unexpected error %v", backoff, err)
+		}
+	}
+	config := TendermintConfig{TimeoutBackoff: maxTimeoutBackoff + 1}
+	if err := config.CheckTimeoutBackoff(); err == nil {
+		t.Errorf("backoff %d: expected an error", config.TimeoutBackoff)
+	}
+}
+
+func TestChainConfig_CheckEngine(t *testing.T) {
+	tests := []struct {
+		config  ChainConfig
+		wantErr bool
+	}{
+		{config: ChainConfig{}},
+		{config: ChainConfig{Ethash: new(EthashConfig)}},
+		{config: ChainConfig{Clique: &CliqueConfig{Period: 15, Epoch: 30000}}},
+		{config: ChainConfig{Tendermint: &TendermintConfig{Epoch: 10}}},
+		{config: ChainConfig{Ethash: new(EthashConfig), Tendermint: &TendermintConfig{Epoch: 10}}, wantErr: true},
+		{config: ChainConfig{Clique: &CliqueConfig{Period: 15, Epoch: 30000}, Tendermint: &TendermintConfig{Epoch: 10}}, wantErr: true},
+		{config: ChainConfig{Ethash: new(EthashConfig), Clique: &CliqueConfig{Period: 15, Epoch: 30000}}, wantErr: true},
+		{config: ChainConfig{Ethash: new(EthashConfig), Clique: &CliqueConfig{Period: 15, Epoch: 30000}, Tendermint: &TendermintConfig{Epoch: 10}}, wantErr: true},
+	}
+	for i, test := range tests {
+		if err := test.config.CheckEngine(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+	// the error names the conflicting engines
+	config := ChainConfig{Ethash: new(EthashConfig), Tendermint: &TendermintConfig{Epoch: 10}}
+	if err := config.CheckEngine(); err == nil || !strings.Contains(err.Error(), "[ethash tendermint]") {
+		t.Errorf("conflicting engines: have %v, want the engines named", err)
+	}
+	for name, config := range map[string]*ChainConfig{
+		"mainnet": MainnetChainConfig,
+		"testnet": TestnetChainConfig,
+	} {
+		if err := config.CheckEngine(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
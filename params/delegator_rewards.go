@@ -0,0 +1,41 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// TendermintDelegatorRewards keeps the rewards the voters of the validators earn in a system account, from the
+// epochs after the checkpoint Block, instead of paying them at each epoch block. The rewards accrue per unit of stake
+// of each validator and the voters claim them with a transaction to the account.
+type TendermintDelegatorRewards struct {
+	Block uint64 `json:"block"`
+}
+
+// CheckDelegatorRewards checks that the delegator rewards are kept from a checkpoint block
+func (c *TendermintConfig) CheckDelegatorRewards() error {
+	if c.DelegatorRewards != nil && c.Epoch != 0 && c.DelegatorRewards.Block%c.Epoch != 0 {
+		return fmt.Errorf("delegator rewards at block %d are not at a checkpoint block", c.DelegatorRewards.Block)
+	}
+	return nil
+}
+
+// IsDelegatorRewards returns true if the rewards of the voters paid at the epoch block number are kept until they claim them
+func (c *TendermintConfig) IsDelegatorRewards(number uint64) bool {
+	return c.DelegatorRewards != nil && number > c.DelegatorRewards.Block
+}
+
+// delegatorRewardsCompatible returns false and the block of the delegator rewards if they cannot be changed from
+// r1 to r2 because head is already past the block of either
+func delegatorRewardsCompatible(r1, r2 *TendermintDelegatorRewards, head *big.Int) (uint64, bool) {
+	switch {
+	case r1 == nil && r2 == nil:
+		return 0, true
+	case r1 != nil && r2 != nil && *r1 == *r2:
+		return 0, true
+	case r1 != nil && (r2 == nil || r1.Block <= r2.Block):
+		return r1.Block, !isForked(new(big.Int).SetUint64(r1.Block+1), head)
+	default:
+		return r2.Block, !isForked(new(big.Int).SetUint64(r2.Block+1), head)
+	}
+}
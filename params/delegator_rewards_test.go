@@ -0,0 +1,36 @@
+package params
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTendermintConfig_CheckDelegatorRewards(t *testing.T) {
+	tests := []struct {
+		rewards *TendermintDelegatorRewards
+		wantErr bool
+	}{
+		{rewards: nil},
+		{rewards: &TendermintDelegatorRewards{Block: 20}},
+		{rewards: &TendermintDelegatorRewards{Block: 25}, wantErr: true},
+	}
+	for i, test := range tests {
+		config := &TendermintConfig{Epoch: 10, DelegatorRewards: test.rewards}
+		if err := config.CheckDelegatorRewards(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestDelegatorRewardsCompatible(t *testing.T) {
+	rewards := &TendermintDelegatorRewards{Block: 20}
+	if _, ok := delegatorRewardsCompatible(nil, rewards, big.NewInt(20)); !ok {
+		t.Errorf("delegator rewards at block 20 should be addable at head 20")
+	}
+	if block, ok := delegatorRewardsCompatible(nil, rewards, big.NewInt(21)); ok || block != 20 {
+		t.Errorf("delegator rewards at block 20 should not be addable at head 21, have block %d", block)
+	}
+	if _, ok := delegatorRewardsCompatible(rewards, &TendermintDelegatorRewards{Block: 20}, big.NewInt(30)); !ok {
+		t.Errorf("the same delegator rewards should be compatible")
+	}
+}
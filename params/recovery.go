@@ -0,0 +1,82 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+)
+
+// TendermintRecovery replaces the validator set of the blocks from Block to the first checkpoint at or after it,
+// for a network whose validators able to sign no longer reach a quorum. The checkpoint lists Validators as the
+// validator set of the following epoch, after which the validators come from the staking contract again
+// (or are the fixed validators again on a network of fixed validators).
+// Validators must be validators of Block before the recovery, and Approvals[i] is the signature of the recovery
+// by Validators[i], see tendermint.RecoverySigningData.
+type TendermintRecovery struct {
+	Block      uint64           `json:"block"`
+	Validators []common.Address `json:"validators"`
+	Approvals  []hexutil.Bytes  `json:"approvals"`
+}
+
+// CheckRecoveries checks that the recoveries are in order and approved once by each of their validators.
+// The approvals are checked against the validator sets by the engine.
+func (c *TendermintConfig) CheckRecoveries() error {
+	for i, r := range c.Recoveries {
+		if r.Block == 0 {
+			return fmt.Errorf("recovery at the genesis block")
+		}
+		if i > 0 && r.Block <= c.Recoveries[i-1].Block {
+			return fmt.Errorf("recovery at block %d is out of order", r.Block)
+		}
+		if len(r.Validators) == 0 {
+			return fmt.Errorf("recovery at block %d has no validators", r.Block)
+		}
+		seen := make(map[common.Address]bool, len(r.Validators))
+		for _, validator := range r.Validators {
+			if seen[validator] {
+				return fmt.Errorf("recovery at block %d lists %s twice", r.Block, validator.Hex())
+			}
+			seen[validator] = true
+		}
+		if len(r.Approvals) != len(r.Validators) {
+			return fmt.Errorf("recovery at block %d has %d approvals for %d validators", r.Block, len(r.Approvals), len(r.Validators))
+		}
+	}
+	return nil
+}
+
+// equal returns whether the recoveries r and o replace the validators the same way
+func (r TendermintRecovery) equal(o TendermintRecovery) bool {
+	if r.Block != o.Block || len(r.Validators) != len(o.Validators) || len(r.Approvals) != len(o.Approvals) {
+		return false
+	}
+	for i := range r.Validators {
+		if r.Validators[i] != o.Validators[i] {
+			return false
+		}
+	}
+	for i := range r.Approvals {
+		if string(r.Approvals[i]) != string(o.Approvals[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// recoveriesCompatible returns false and the block of the first recovery which differs
+// if the recoveries s1 cannot be changed to s2 because head is already past it.
+func recoveriesCompatible(s1, s2 []TendermintRecovery, head *big.Int) (uint64, bool) {
+	for i := 0; i < len(s1) || i < len(s2); i++ {
+		switch {
+		case i < len(s1) && i < len(s2) && s1[i].equal(s2[i]):
+			continue
+		case i < len(s1) && (i >= len(s2) || s1[i].Block <= s2[i].Block):
+			return s1[i].Block, !isForked(new(big.Int).SetUint64(s1[i].Block), head)
+		default:
+			return s2[i].Block, !isForked(new(big.Int).SetUint64(s2[i].Block), head)
+		}
+	}
+	return 0, true
+}
@@ -0,0 +1,57 @@
+package params
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+)
+
+func TestTendermintConfig_CheckRecoveries(t *testing.T) {
+	var (
+		a, b      = common.Address{1}, common.Address{2}
+		approvals = []hexutil.Bytes{{1}, {2}}
+	)
+	tests := []struct {
+		recoveries []TendermintRecovery
+		wantErr    bool
+	}{
+		{recoveries: nil},
+		{recoveries: []TendermintRecovery{{Block: 15, Validators: []common.Address{a, b}, Approvals: approvals}}},
+		{recoveries: []TendermintRecovery{{Block: 0, Validators: []common.Address{a, b}, Approvals: approvals}}, wantErr: true},
+		{recoveries: []TendermintRecovery{
+			{Block: 15, Validators: []common.Address{a, b}, Approvals: approvals},
+			{Block: 15, Validators: []common.Address{a, b}, Approvals: approvals},
+		}, wantErr: true},
+		{recoveries: []TendermintRecovery{{Block: 15}}, wantErr: true},
+		{recoveries: []TendermintRecovery{{Block: 15, Validators: []common.Address{a, a}, Approvals: approvals}}, wantErr: true},
+		{recoveries: []TendermintRecovery{{Block: 15, Validators: []common.Address{a, b}, Approvals: approvals[:1]}}, wantErr: true},
+	}
+	for i, test := range tests {
+		config := &TendermintConfig{Epoch: 10, Recoveries: test.recoveries}
+		if err := config.CheckRecoveries(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestRecoveriesCompatible(t *testing.T) {
+	var (
+		recovery = TendermintRecovery{Block: 15, Validators: []common.Address{{1}}, Approvals: []hexutil.Bytes{{1}}}
+		other    = TendermintRecovery{Block: 15, Validators: []common.Address{{2}}, Approvals: []hexutil.Bytes{{2}}}
+	)
+	// a recovery can be added or changed until the chain reaches its block
+	if _, ok := recoveriesCompatible(nil, []TendermintRecovery{recovery}, big.NewInt(14)); !ok {
+		t.Error("recovery ahead of the head is incompatible")
+	}
+	if block, ok := recoveriesCompatible(nil, []TendermintRecovery{recovery}, big.NewInt(15)); ok || block != 15 {
+		t.Errorf("recovery at the head is compatible, block %d", block)
+	}
+	if _, ok := recoveriesCompatible([]TendermintRecovery{recovery}, []TendermintRecovery{other}, big.NewInt(20)); ok {
+		t.Error("changed recovery behind the head is compatible")
+	}
+	if _, ok := recoveriesCompatible([]TendermintRecovery{recovery}, []TendermintRecovery{recovery}, big.NewInt(20)); !ok {
+		t.Error("same recoveries are incompatible")
+	}
+}
@@ -0,0 +1,145 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// DefaultVoterPercentage is the percentage of a validator's rewards shared among its voters if the schedule doesn't set it
+const DefaultVoterPercentage uint64 = 50
+
+// TendermintRewardStage sets the reward of the blocks after the checkpoint Block, until the next stage.
+// The reward decays by DecayPercentage every DecayEpochs epochs, a halving is a decay of 50%.
+// The Recipients take their shares of each block reward, then CommitterPercentage of it is split equally among the
+// validators who committed the parent block. The proposer earns the remainder and the transaction fees.
+type TendermintRewardStage struct {
+	Block               uint64                      `json:"block"`
+	Reward              *big.Int                    `json:"reward"`
+	DecayEpochs         uint64                      `json:"decayEpochs,omitempty"`
+	DecayPercentage     uint64                      `json:"decayPercentage,omitempty"`
+	VoterPercentage     *uint64                     `json:"voterPercentage,omitempty"`
+	Recipients          []TendermintRewardRecipient `json:"recipients,omitempty"`
+	CommitterPercentage uint64                      `json:"committerPercentage,omitempty"`
+}
+
+// TendermintRewardRecipient is paid Percentage of each block reward, e.g. a treasury.
+type TendermintRewardRecipient struct {
+	Address    common.Address `json:"address"`
+	Percentage uint64         `json:"percentage"`
+}
+
+// CheckRewardSchedule checks that the reward stages start at checkpoint blocks, in order, with valid percentages
+func (c *TendermintConfig) CheckRewardSchedule() error {
+	for i, stage := range c.RewardSchedule {
+		if c.Epoch != 0 && stage.Block%c.Epoch != 0 {
+			return fmt.Errorf("reward stage at block %d is not at a checkpoint block", stage.Block)
+		}
+		if i > 0 && stage.Block <= c.RewardSchedule[i-1].Block {
+			return fmt.Errorf("reward stage at block %d is out of order", stage.Block)
+		}
+		if stage.Reward == nil || stage.Reward.Sign() < 0 {
+			return fmt.Errorf("reward stage at block %d has no valid reward", stage.Block)
+		}
+		if stage.DecayPercentage > 100 || (stage.VoterPercentage != nil && *stage.VoterPercentage > 100) {
+			return fmt.Errorf("reward stage at block %d has a percentage above 100", stage.Block)
+		}
+		total := stage.CommitterPercentage
+		for _, recipient := range stage.Recipients {
+			total += recipient.Percentage
+		}
+		if total > 100 {
+			return fmt.Errorf("reward stage at block %d shares %d%% of the reward with recipients and committers", stage.Block, total)
+		}
+	}
+	return nil
+}
+
+// RewardStage returns the reward stage of block number, nil before the first stage
+func (c *TendermintConfig) RewardStage(number uint64) *TendermintRewardStage {
+	for i := len(c.RewardSchedule) - 1; i >= 0; i-- {
+		if number > c.RewardSchedule[i].Block {
+			return &c.RewardSchedule[i]
+		}
+	}
+	return nil
+}
+
+// BlockRewardAt returns the reward of block number, decayed since the start of its stage
+func (c *TendermintConfig) BlockRewardAt(number uint64) *big.Int {
+	stage := c.RewardStage(number)
+	if stage == nil {
+		if c.BlockReward == nil {
+			return new(big.Int)
+		}
+		return new(big.Int).Set(c.BlockReward)
+	}
+	reward := new(big.Int).Set(stage.Reward)
+	if stage.DecayEpochs == 0 || stage.DecayPercentage == 0 || c.Epoch == 0 {
+		return reward
+	}
+	decays := (number - stage.Block - 1) / c.Epoch / stage.DecayEpochs
+	if decays == 0 {
+		return reward
+	}
+	if stage.DecayPercentage == 100 {
+		return new(big.Int)
+	}
+	// reward * (100 - decay)^decays / 100^decays, the reward is 0 long before the exponents grow big
+	if limit := uint64(reward.BitLen()) * 100; decays > limit {
+		decays = limit
+	}
+	exp := new(big.Int).SetUint64(decays)
+	reward.Mul(reward, new(big.Int).Exp(new(big.Int).SetUint64(100-stage.DecayPercentage), exp, nil))
+	return reward.Div(reward, new(big.Int).Exp(big.NewInt(100), exp, nil))
+}
+
+// VoterPercentageAt returns the percentage of a validator's rewards shared among its voters at block number
+func (c *TendermintConfig) VoterPercentageAt(number uint64) uint64 {
+	if stage := c.RewardStage(number); stage != nil && stage.VoterPercentage != nil {
+		return *stage.VoterPercentage
+	}
+	return DefaultVoterPercentage
+}
+
+// CommitterPercentageAt returns the percentage of the block reward of block number shared among the committers of its parent
+func (c *TendermintConfig) CommitterPercentageAt(number uint64) uint64 {
+	if stage := c.RewardStage(number); stage != nil {
+		return stage.CommitterPercentage
+	}
+	return 0
+}
+
+// equal returns whether the stages s and o pay the same rewards
+func (s TendermintRewardStage) equal(o TendermintRewardStage) bool {
+	if s.Block != o.Block || !configNumEqual(s.Reward, o.Reward) || s.DecayEpochs != o.DecayEpochs ||
+		s.DecayPercentage != o.DecayPercentage || s.CommitterPercentage != o.CommitterPercentage || len(s.Recipients) != len(o.Recipients) {
+		return false
+	}
+	if (s.VoterPercentage == nil) != (o.VoterPercentage == nil) || (s.VoterPercentage != nil && *s.VoterPercentage != *o.VoterPercentage) {
+		return false
+	}
+	for i := range s.Recipients {
+		if s.Recipients[i] != o.Recipients[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rewardScheduleCompatible returns false and the block of the first stage which differs
+// if the schedule s1 cannot be changed to s2 because head is already past the start of the stage.
+func rewardScheduleCompatible(s1, s2 []TendermintRewardStage, head *big.Int) (uint64, bool) {
+	for i := 0; i < len(s1) || i < len(s2); i++ {
+		switch {
+		case i < len(s1) && i < len(s2) && s1[i].equal(s2[i]):
+			continue
+		case i < len(s1) && (i >= len(s2) || s1[i].Block <= s2[i].Block):
+			return s1[i].Block, !isForked(new(big.Int).SetUint64(s1[i].Block+1), head)
+		default:
+			return s2[i].Block, !isForked(new(big.Int).SetUint64(s2[i].Block+1), head)
+		}
+	}
+	return 0, true
+}
@@ -0,0 +1,96 @@
+package params
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+func TestTendermintConfig_CheckRewardSchedule(t *testing.T) {
+	var (
+		reward = big.NewInt(100)
+		above  = uint64(101)
+	)
+	tests := []struct {
+		schedule []TendermintRewardStage
+		wantErr  bool
+	}{
+		{schedule: nil},
+		{schedule: []TendermintRewardStage{{Block: 0, Reward: reward}, {Block: 20, Reward: reward, DecayEpochs: 1, DecayPercentage: 50}}},
+		{schedule: []TendermintRewardStage{{Block: 25, Reward: reward}}, wantErr: true},
+		{schedule: []TendermintRewardStage{{Block: 20, Reward: reward}, {Block: 20, Reward: reward}}, wantErr: true},
+		{schedule: []TendermintRewardStage{{Block: 20}}, wantErr: true},
+		{schedule: []TendermintRewardStage{{Block: 20, Reward: reward, DecayPercentage: 101}}, wantErr: true},
+		{schedule: []TendermintRewardStage{{Block: 20, Reward: reward, VoterPercentage: &above}}, wantErr: true},
+		{schedule: []TendermintRewardStage{{Block: 20, Reward: reward, Recipients: []TendermintRewardRecipient{
+			{Address: common.Address{1}, Percentage: 60}, {Address: common.Address{2}, Percentage: 50},
+		}}}, wantErr: true},
+		{schedule: []TendermintRewardStage{{Block: 20, Reward: reward, CommitterPercentage: 50, Recipients: []TendermintRewardRecipient{
+			{Address: common.Address{1}, Percentage: 60},
+		}}}, wantErr: true},
+	}
+	for i, test := range tests {
+		config := &TendermintConfig{Epoch: 10, RewardSchedule: test.schedule}
+		if err := config.CheckRewardSchedule(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestTendermintConfig_BlockRewardAt(t *testing.T) {
+	voters := uint64(30)
+	config := &TendermintConfig{
+		Epoch:       10,
+		BlockReward: big.NewInt(1000),
+		RewardSchedule: []TendermintRewardStage{
+			{Block: 20, Reward: big.NewInt(800), DecayEpochs: 2, DecayPercentage: 50, VoterPercentage: &voters},
+			{Block: 100, Reward: big.NewInt(300), DecayEpochs: 1, DecayPercentage: 10},
+		},
+	}
+	tests := []struct {
+		number uint64
+		reward int64
+		voters uint64
+	}{
+		{number: 1, reward: 1000, voters: DefaultVoterPercentage},
+		{number: 20, reward: 1000, voters: DefaultVoterPercentage},
+		{number: 21, reward: 800, voters: 30},
+		{number: 40, reward: 800, voters: 30},
+		{number: 41, reward: 400, voters: 30},
+		{number: 61, reward: 200, voters: 30},
+		{number: 100, reward: 100, voters: 30},
+		{number: 101, reward: 300, voters: DefaultVoterPercentage},
+		{number: 111, reward: 270, voters: DefaultVoterPercentage},
+		{number: 121, reward: 243, voters: DefaultVoterPercentage},
+		{number: 1 << 40, reward: 0, voters: DefaultVoterPercentage},
+	}
+	for _, test := range tests {
+		if have := config.BlockRewardAt(test.number); have.Cmp(big.NewInt(test.reward)) != 0 {
+			t.Errorf("block %d: reward mismatch: have %v, want %v", test.number, have, test.reward)
+		}
+		if have := config.VoterPercentageAt(test.number); have != test.voters {
+			t.Errorf("block %d: voter percentage mismatch: have %v, want %v", test.number, have, test.voters)
+		}
+	}
+}
+
+func TestRewardScheduleCompatible(t *testing.T) {
+	var (
+		s1 = []TendermintRewardStage{{Block: 10, Reward: big.NewInt(100)}}
+		s2 = []TendermintRewardStage{{Block: 10, Reward: big.NewInt(100)}, {Block: 20, Reward: big.NewInt(50)}}
+		s3 = []TendermintRewardStage{{Block: 10, Reward: big.NewInt(200)}}
+	)
+	if _, ok := rewardScheduleCompatible(s1, s2, big.NewInt(15)); !ok {
+		t.Errorf("adding a future stage is incompatible")
+	}
+	if block, ok := rewardScheduleCompatible(s2, s1, big.NewInt(25)); ok || block != 20 {
+		t.Errorf("removing a started stage mismatch: have %d, %v, want 20, false", block, ok)
+	}
+	if _, ok := rewardScheduleCompatible(s1, s3, big.NewInt(10)); !ok {
+		t.Errorf("changing a stage before its first block is incompatible")
+	}
+	if _, ok := rewardScheduleCompatible(s1, s3, big.NewInt(11)); ok {
+		t.Errorf("changing a started stage is compatible")
+	}
+}
@@ -0,0 +1,51 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// TendermintMinSelfBond excludes from the validator selection of the checkpoints from Block on the candidates whose
+// owner bonds less than Stake to them, whatever the stake their voters delegate
+type TendermintMinSelfBond struct {
+	Block uint64   `json:"block"`
+	Stake *big.Int `json:"stake"`
+}
+
+// CheckMinSelfBond checks that the minimum self-bond applies from a checkpoint block
+func (c *TendermintConfig) CheckMinSelfBond() error {
+	bond := c.MinSelfBond
+	if bond == nil {
+		return nil
+	}
+	if c.Epoch != 0 && bond.Block%c.Epoch != 0 {
+		return fmt.Errorf("minimum self-bond at block %d is not at a checkpoint block", bond.Block)
+	}
+	if bond.Stake == nil || bond.Stake.Sign() < 0 {
+		return fmt.Errorf("minimum self-bond at block %d has an invalid stake", bond.Block)
+	}
+	return nil
+}
+
+// MinSelfBondAt returns the minimum self-bond of the candidates selected at the checkpoint, nil if there is none
+func (c *TendermintConfig) MinSelfBondAt(checkpoint uint64) *big.Int {
+	if c.MinSelfBond == nil || checkpoint < c.MinSelfBond.Block {
+		return nil
+	}
+	return c.MinSelfBond.Stake
+}
+
+// minSelfBondCompatible returns false and the block of the minimum self-bond if it cannot be changed from b1 to b2
+// because head is already past the block of either
+func minSelfBondCompatible(b1, b2 *TendermintMinSelfBond, head *big.Int) (uint64, bool) {
+	switch {
+	case b1 == nil && b2 == nil:
+		return 0, true
+	case b1 != nil && b2 != nil && b1.Block == b2.Block && b1.Stake.Cmp(b2.Stake) == 0:
+		return 0, true
+	case b1 != nil && (b2 == nil || b1.Block <= b2.Block):
+		return b1.Block, !isForked(new(big.Int).SetUint64(b1.Block), head)
+	default:
+		return b2.Block, !isForked(new(big.Int).SetUint64(b2.Block), head)
+	}
+}
@@ -0,0 +1,41 @@
+package params
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTendermintConfig_CheckMinSelfBond(t *testing.T) {
+	tests := []struct {
+		bond    *TendermintMinSelfBond
+		wantErr bool
+	}{
+		{bond: nil},
+		{bond: &TendermintMinSelfBond{Block: 20, Stake: big.NewInt(100)}},
+		{bond: &TendermintMinSelfBond{Block: 25, Stake: big.NewInt(100)}, wantErr: true},
+		{bond: &TendermintMinSelfBond{Block: 20}, wantErr: true},
+		{bond: &TendermintMinSelfBond{Block: 20, Stake: big.NewInt(-1)}, wantErr: true},
+	}
+	for i, test := range tests {
+		config := &TendermintConfig{Epoch: 10, MinSelfBond: test.bond}
+		if err := config.CheckMinSelfBond(); (err != nil) != test.wantErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.wantErr)
+		}
+	}
+}
+
+func TestMinSelfBondCompatible(t *testing.T) {
+	bond := &TendermintMinSelfBond{Block: 20, Stake: big.NewInt(100)}
+	if _, ok := minSelfBondCompatible(nil, bond, big.NewInt(19)); !ok {
+		t.Errorf("minimum self-bond at block 20 should be addable at head 19")
+	}
+	if block, ok := minSelfBondCompatible(nil, bond, big.NewInt(20)); ok || block != 20 {
+		t.Errorf("minimum self-bond at block 20 should not be addable at head 20, have block %d", block)
+	}
+	if _, ok := minSelfBondCompatible(bond, &TendermintMinSelfBond{Block: 20, Stake: big.NewInt(100)}, big.NewInt(30)); !ok {
+		t.Errorf("the same minimum self-bond should be compatible")
+	}
+	if _, ok := minSelfBondCompatible(bond, &TendermintMinSelfBond{Block: 20, Stake: big.NewInt(200)}, big.NewInt(30)); ok {
+		t.Errorf("a minimum self-bond already applied should not be changed")
+	}
+}
@@ -61,13 +61,16 @@ type jsonWriter interface {
 type BlockNumber int64
 
 const (
-	PendingBlockNumber  = BlockNumber(-2)
-	LatestBlockNumber   = BlockNumber(-1)
-	EarliestBlockNumber = BlockNumber(0)
+	SafeBlockNumber      = BlockNumber(-4)
+	FinalizedBlockNumber = BlockNumber(-3)
+	PendingBlockNumber   = BlockNumber(-2)
+	LatestBlockNumber    = BlockNumber(-1)
+	EarliestBlockNumber  = BlockNumber(0)
 )
 
 // UnmarshalJSON parses the given JSON fragment into a BlockNumber. It supports:
 // - "latest", "earliest" or "pending" as string arguments
+// - "finalized" or "safe" as string arguments, the backends resolve them to the latest finalized block
 // - the block number
 // Returned errors:
 // - an invalid block number error when the given argument isn't a known strings
@@ -85,6 +88,12 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 	case "latest":
 		*bn = LatestBlockNumber
 		return nil
+	case "finalized":
+		*bn = FinalizedBlockNumber
+		return nil
+	case "safe":
+		*bn = SafeBlockNumber
+		return nil
 	case "pending":
 		*bn = PendingBlockNumber
 		return nil
@@ -1,7 +1,6 @@
 package tests_utils
 
 import (
-	"bytes"
 	"crypto/ecdsa"
 	"errors"
 	"math/big"
@@ -12,7 +11,6 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
-	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
 func MakeNodeKey() *ecdsa.PrivateKey {
@@ -29,19 +27,12 @@ func MakeGenesisHeader(validators []common.Address) *types.Header {
 		Difficulty: big.NewInt(1),
 		MixDigest:  types.TendermintDigest,
 	}
-	extra, _ := PrepareExtra(header)
-
-	var buf bytes.Buffer
-	buf.Write(extra[:types.TendermintExtraVanity])
-	valSetData, _ := rlp.EncodeToBytes(validators)
 	tdm := &types.TendermintExtra{
 		Seal:          []byte{},
 		CommittedSeal: [][]byte{},
-		ValidatorAdds: valSetData,
 	}
-	payload, _ := rlp.EncodeToBytes(&tdm)
-
-	header.Extra = append(buf.Bytes(), payload...)
+	_ = tdm.SetValidators(validators)
+	header.Extra, _ = tdm.Encode(nil)
 	return header
 }
 
@@ -51,21 +42,7 @@ func GeneratePrivateKey() (*ecdsa.PrivateKey, error) {
 
 // PrepareExtra returns a extra-data of the given header and validators
 func PrepareExtra(header *types.Header) ([]byte, error) {
-	var buf bytes.Buffer
-
-	// compensate the lack bytes if header.Extra is not enough TendermintExtraVanity bytes.
-	if len(header.Extra) < types.TendermintExtraVanity {
-		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, types.TendermintExtraVanity-len(header.Extra))...)
-	}
-	buf.Write(header.Extra[:types.TendermintExtraVanity])
-
-	tdm := &types.TendermintExtra{}
-	payload, err := rlp.EncodeToBytes(&tdm)
-	if err != nil {
-		return nil, err
-	}
-
-	return append(buf.Bytes(), payload...), nil
+	return (&types.TendermintExtra{}).Encode(header.Extra)
 }
 
 func GenerateMockChainReader() (*MockChainReader, error) {